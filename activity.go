@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// 账号动态：写操作结果、登录失效、触发验证码等面向运维的事件。
+// 与 MCP 调用记录一样保存在内存环形缓冲中，配置了运行状态目录时同时追加到 activity.jsonl。
+const maxActivityRecords = 500
+
+// 动态类型（写操作沿用任务类型 publish/publish_video/comment/reply）
+const (
+	activityLoginExpired = "login_expired"
+	activityCaptcha      = "captcha"
+)
+
+// ActivityRecord 一条账号动态
+type ActivityRecord struct {
+	At      time.Time `json:"at"`
+	Kind    string    `json:"kind"`
+	Status  string    `json:"status"`            // ok, error
+	Subject string    `json:"subject,omitempty"` // 笔记标题、笔记 ID 或页面地址
+	Error   string    `json:"error,omitempty"`
+}
+
+type activityLog struct {
+	mu        sync.Mutex
+	path      string
+	records   []ActivityRecord
+	loggedOut bool // 已记录过登录失效，重新登录前不重复记录
+}
+
+// activities 全局账号动态
+var activities = &activityLog{}
+
+// openActivityLog 加载历史动态，超出上限的旧记录在加载时压缩掉
+func openActivityLog(stateDir string) (*activityLog, error) {
+	l := &activityLog{}
+	if stateDir == "" {
+		return l, nil
+	}
+	l.path = filepath.Join(stateDir, "activity.jsonl")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建状态目录失败: %w", err)
+	}
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取账号动态失败: %w", err)
+	}
+	total := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r ActivityRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || r.Kind == "" {
+			continue
+		}
+		total++
+		l.appendLocked(r)
+	}
+	_ = f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取账号动态失败: %w", err)
+	}
+
+	if total > maxActivityRecords {
+		if err := l.rewrite(); err != nil {
+			logrus.Warnf("压缩账号动态失败: %v", err)
+		}
+	}
+	return l, nil
+}
+
+// rewrite 用内存中的记录重写文件
+func (l *activityLog) rewrite() error {
+	var b strings.Builder
+	for _, r := range l.records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+func (l *activityLog) appendLocked(r ActivityRecord) {
+	l.records = append(l.records, r)
+	if len(l.records) > maxActivityRecords {
+		l.records = append(l.records[:0:0], l.records[len(l.records)-maxActivityRecords:]...)
+	}
+}
+
+// Add 记录一条动态，落盘失败只记录警告
+func (l *activityLog) Add(kind, subject string, err error) {
+	r := ActivityRecord{At: time.Now(), Kind: kind, Status: mcpCallOK, Subject: truncateString(subject, 200)}
+	if err != nil {
+		r.Status = mcpCallError
+		r.Error = truncateString(err.Error(), maxMCPCallError)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addLocked(r)
+}
+
+func (l *activityLog) addLocked(r ActivityRecord) {
+	l.appendLocked(r)
+	publishActivity(r)
+	if l.path == "" {
+		return
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logrus.Warnf("写入账号动态失败: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logrus.Warnf("写入账号动态失败: %v", err)
+	}
+}
+
+// NoteLoginState 根据登录检查结果记录登录失效：有 cookies 却未登录视为失效，同一次失效只记录一次
+func (l *activityLog) NoteLoginState(loggedIn bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if loggedIn {
+		l.loggedOut = false
+		return
+	}
+	if l.loggedOut {
+		return
+	}
+	if st, err := os.Stat(cookies.GetCookiesFilePath()); err != nil || st.Size() <= 2 {
+		return
+	}
+	l.loggedOut = true
+	l.addLocked(ActivityRecord{At: time.Now(), Kind: activityLoginExpired, Status: mcpCallError, Error: "cookies 已失效，需要重新登录"})
+}
+
+// Query 返回 since 之后的动态，按时间倒序
+func (l *activityLog) Query(since time.Time, limit int) []ActivityRecord {
+	if limit <= 0 || limit > maxActivityRecords {
+		limit = 100
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]ActivityRecord, 0, limit)
+	for i := len(l.records) - 1; i >= 0 && len(out) < limit; i-- {
+		if !since.IsZero() && l.records[i].At.Before(since) {
+			break
+		}
+		out = append(out, l.records[i])
+	}
+	return out
+}
+
+// isCaptchaURL 页面被重定向到验证码/安全验证页
+func isCaptchaURL(u string) bool {
+	return xiaohongshu.IsCaptchaURL(u)
+}
+
+// noteCaptcha 页面关闭前检查是否停留在验证码页
+func noteCaptcha(page *rod.Page) {
+	info, err := page.Timeout(2 * time.Second).Info()
+	if err != nil || !isCaptchaURL(info.URL) {
+		return
+	}
+	logrus.Warnf("页面触发验证码: %s", info.URL)
+	activities.Add(activityCaptcha, info.URL, fmt.Errorf("触发验证码"))
+}
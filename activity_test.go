@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityLogPersistAndLoginExpiry(t *testing.T) {
+	dir := t.TempDir()
+	cookiePath := filepath.Join(dir, "cookies.json")
+	require.NoError(t, os.WriteFile(cookiePath, []byte(`[{"name":"web_session"}]`), 0644))
+	t.Setenv("COOKIES_PATH", cookiePath)
+
+	l, err := openActivityLog(dir)
+	require.NoError(t, err)
+	l.Add(taskKindPublish, "春日穿搭", nil)
+	l.Add(taskKindComment, "feed-1", errors.New("评论框未找到"))
+
+	// 同一次失效只记录一次，重新登录后再次失效才会再记录
+	l.NoteLoginState(false)
+	l.NoteLoginState(false)
+	l.NoteLoginState(true)
+	l.NoteLoginState(false)
+
+	reopened, err := openActivityLog(dir)
+	require.NoError(t, err)
+	all := reopened.Query(time.Time{}, 0)
+	require.Len(t, all, 4)
+	require.Equal(t, activityLoginExpired, all[0].Kind, "结果按时间倒序")
+	require.Equal(t, activityLoginExpired, all[1].Kind)
+	require.Equal(t, mcpCallError, all[2].Status)
+	require.Equal(t, "春日穿搭", all[3].Subject)
+	require.Len(t, reopened.Query(time.Time{}, 1), 1)
+}
+
+func TestIsCaptchaURL(t *testing.T) {
+	require.True(t, isCaptchaURL("https://www.xiaohongshu.com/website-login/captcha?redirectPath=x"))
+	require.False(t, isCaptchaURL("https://www.xiaohongshu.com/explore"))
+}
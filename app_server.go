@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
 )
 
 // AppServer 应用服务器结构体，封装所有服务和处理器
@@ -19,14 +23,41 @@ type AppServer struct {
 	mcpServer          *mcp.Server
 	router             *gin.Engine
 	httpServer         *http.Server
+	drain              *drainTracker
+	limiter            *opLimiter
+	feeds              *feedMonitor
+	mentions           *mentionWatcher
+	followers          *mentionWatcher
+	triggers           *triggerHub
+	autoReply          *autoReplier // 未配置运行状态目录时为 nil
 }
 
 // NewAppServer 创建新的应用服务器实例
 func NewAppServer(xiaohongshuService *XiaohongshuService) *AppServer {
 	appServer := &AppServer{
 		xiaohongshuService: xiaohongshuService,
+		drain:              newDrainTracker(),
+		limiter:            newOpLimiter(configs.GetMaxConcurrent(), configs.GetMaxQueue()),
+		feeds:              newFeedMonitor(),
+		mentions:           newMentionWatcher(),
+		followers:          newMentionWatcher(),
 	}
 
+	autoReply, err := openAutoReplier(configs.GetStateDir())
+	if err != nil {
+		logrus.Warnf("加载自动回复规则失败，自动回复不可用: %v", err)
+	} else if autoReply != nil {
+		autoReply.sendFunc = appServer.sendAutoReply
+		appServer.autoReply = autoReply
+	}
+
+	triggers, err := openTriggerHub(configs.GetStateDir())
+	if err != nil {
+		logrus.Warnf("加载触发器订阅失败，已有订阅不会推送: %v", err)
+		triggers = &triggerHub{subs: map[string]*TriggerSubscription{}}
+	}
+	appServer.triggers = triggers
+
 	// 初始化 MCP Server（需要在创建 appServer 之后，因为工具注册需要访问 appServer）
 	appServer.mcpServer = InitMCPServer(appServer)
 
@@ -38,19 +69,34 @@ func (s *AppServer) Start(port string) error {
 	s.router = setupRoutes(s)
 
 	s.httpServer = &http.Server{
-		Addr:    port,
 		Handler: s.router,
 	}
 
+	ln, err := listen(port)
+	if err != nil {
+		return err
+	}
+
 	// 启动服务器的 goroutine
 	go func() {
 		logrus.Infof("启动 HTTP 服务器: %s", port)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
 			logrus.Errorf("服务器启动失败: %v", err)
 			os.Exit(1)
 		}
 	}()
 
+	// 重放上次崩溃时未完成的写操作
+	go s.xiaohongshuService.ReplayPendingTasks(context.Background())
+
+	bgCtx, stopBg := context.WithCancel(context.Background())
+	defer stopBg()
+	s.xiaohongshuService.StartIdleBrowserReaper(bgCtx, configs.GetBrowserIdleTimeout())
+	s.xiaohongshuService.StartScheduler(bgCtx)
+	s.setupEventSinks()
+	s.StartMentionWatcher(bgCtx, configs.GetMentionWatchInterval())
+	s.StartFollowerWatcher(bgCtx, configs.GetFollowerWatchInterval())
+
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -58,6 +104,17 @@ func (s *AppServer) Start(port string) error {
 
 	logrus.Infof("正在关闭服务器...")
 
+	// 先拒绝新请求，再等待进行中的发布/评论等操作完成
+	s.drain.BeginDrain()
+	bus.CloseStreams()
+	drainTimeout := configs.GetDrainTimeout()
+	if n := s.drain.InFlight(); n > 0 {
+		logrus.Infof("等待 %d 个进行中的请求完成（最长 %s）", n, drainTimeout)
+	}
+	if !s.drain.Wait(drainTimeout) {
+		logrus.Warnf("等待进行中的请求超时，剩余 %d 个", s.drain.InFlight())
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -67,5 +124,40 @@ func (s *AppServer) Start(port string) error {
 		logrus.Infof("服务器已优雅关闭")
 	}
 
+	// 等待排队中的事件推送完成
+	bus.Close(5 * time.Second)
+
+	// 落盘最新 cookies 后再关闭浏览器
+	if err := s.xiaohongshuService.PersistCookies(); err != nil {
+		logrus.Warnf("退出前保存 cookies 失败: %v", err)
+	}
+	s.xiaohongshuService.Close()
+
 	return nil
 }
+
+// listen 监听地址，支持 TCP（如 :18060）和 Unix socket（unix:/path/to.sock）
+func listen(addr string) (net.Listener, error) {
+	socket, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if socket == "" {
+		return nil, fmt.Errorf("unix socket 路径不能为空")
+	}
+
+	// 清理上次异常退出残留的 socket 文件
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("清理旧 socket 文件失败: %w", err)
+	}
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	// 仅允许当前用户访问，避免多租户主机上被其他用户调用
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("设置 socket 权限失败: %w", err)
+	}
+	return ln, nil
+}
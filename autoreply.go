@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 评论自动回复：评论和@监控发现新评论后按规则匹配，命中则用模板回复。
+// 规则与当天计数保存在运行状态目录（autoreply.json、autoreply_state.json），重启后每日上限不会被重置。
+const (
+	defaultAutoReplyDailyCap = 20
+	autoReplyRepliedLimit    = 2000 // 记住已回复的通知数，防止重复回复
+)
+
+var errAutoReplyDisabled = errors.New("未配置运行状态目录，自动回复不可用")
+
+// AutoReplyRule 一条回复规则，Keywords 任一包含或 Regex 匹配即命中，按顺序取第一条命中的规则
+type AutoReplyRule struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	Reply    string   `json:"reply"`               // 支持 {nickname}、{content} 占位符
+	DailyCap int      `json:"daily_cap,omitempty"` // 本规则每日上限，0 表示只受全局上限约束
+
+	re *regexp.Regexp
+}
+
+// AutoReplyConfig 自动回复配置
+type AutoReplyConfig struct {
+	Enabled   bool            `json:"enabled"`
+	DryRun    bool            `json:"dry_run,omitempty"`   // 只记录命中，不实际回复
+	DailyCap  int             `json:"daily_cap,omitempty"` // 每日回复总数上限，默认 20
+	Blacklist []string        `json:"blacklist,omitempty"` // 不自动回复的用户 ID 或昵称
+	Rules     []AutoReplyRule `json:"rules"`
+}
+
+// AutoReplyStats 当天回复计数
+type AutoReplyStats struct {
+	Day     string         `json:"day"`
+	Total   int            `json:"total"`
+	PerRule map[string]int `json:"per_rule"`
+	Replied []string       `json:"replied,omitempty"` // 已处理的通知 ID
+}
+
+// AutoReplyMatch 一次命中记录
+type AutoReplyMatch struct {
+	At        time.Time `json:"at"`
+	MentionID string    `json:"mention_id"`
+	Rule      string    `json:"rule"`
+	Nickname  string    `json:"nickname,omitempty"`
+	Content   string    `json:"content"`
+	Reply     string    `json:"reply"`
+	Status    string    `json:"status"` // replied, dry_run, skipped, error
+	Error     string    `json:"error,omitempty"`
+}
+
+type autoReplier struct {
+	mu       sync.Mutex
+	dir      string
+	cfg      AutoReplyConfig
+	stats    AutoReplyStats
+	replied  map[string]struct{}
+	recent   []AutoReplyMatch
+	replyMu  sync.Mutex // 回复串行执行
+	now      func() time.Time
+	sendFunc func(ctx context.Context, target autoReplyTarget, content string) error
+}
+
+// autoReplyTarget 回复所需的笔记与评论信息
+type autoReplyTarget struct {
+	MentionID string
+	FeedID    string
+	XsecToken string
+	CommentID string
+	UserID    string
+	Nickname  string
+	Content   string
+}
+
+// openAutoReplier 加载规则与当天计数；stateDir 为空时返回 nil
+func openAutoReplier(stateDir string) (*autoReplier, error) {
+	if stateDir == "" {
+		return nil, nil
+	}
+	r := &autoReplier{dir: stateDir, replied: map[string]struct{}{}, now: time.Now}
+	data, err := os.ReadFile(filepath.Join(stateDir, "autoreply.json"))
+	if err == nil {
+		var cfg AutoReplyConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("解析自动回复规则失败: %w", err)
+		}
+		if err := cfg.compile(); err != nil {
+			return nil, err
+		}
+		r.cfg = cfg
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取自动回复规则失败: %w", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(stateDir, "autoreply_state.json")); err == nil {
+		_ = json.Unmarshal(data, &r.stats)
+		for _, id := range r.stats.Replied {
+			r.replied[id] = struct{}{}
+		}
+	}
+	return r, nil
+}
+
+// compile 校验并编译规则
+func (c *AutoReplyConfig) compile() error {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if strings.TrimSpace(rule.Reply) == "" {
+			return fmt.Errorf("规则 %s 缺少回复内容", rule.Name)
+		}
+		if len(rule.Keywords) == 0 && rule.Regex == "" {
+			return fmt.Errorf("规则 %s 需要配置 keywords 或 regex", rule.Name)
+		}
+		rule.re = nil
+		if rule.Regex != "" {
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("规则 %s 正则表达式无效: %w", rule.Name, err)
+			}
+			rule.re = re
+		}
+	}
+	if c.DailyCap < 0 {
+		return fmt.Errorf("daily_cap 不能为负数")
+	}
+	return nil
+}
+
+func (c *AutoReplyConfig) dailyCap() int {
+	if c.DailyCap > 0 {
+		return c.DailyCap
+	}
+	return defaultAutoReplyDailyCap
+}
+
+// match 返回第一条命中的规则
+func (c *AutoReplyConfig) match(content string) *AutoReplyRule {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		for _, kw := range rule.Keywords {
+			if kw != "" && strings.Contains(content, kw) {
+				return rule
+			}
+		}
+		if rule.re != nil && rule.re.MatchString(content) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (c *AutoReplyConfig) blacklisted(t autoReplyTarget) bool {
+	for _, b := range c.Blacklist {
+		if b != "" && (b == t.UserID || b == t.Nickname) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderReply 填充回复模板
+func renderReply(tpl string, t autoReplyTarget) string {
+	return strings.NewReplacer("{nickname}", t.Nickname, "{content}", t.Content).Replace(tpl)
+}
+
+// mentionReplyTarget 从评论通知中取出回复目标，非评论类通知返回 false
+func mentionReplyTarget(ev MentionEvent) (autoReplyTarget, bool) {
+	m := ev.Mention
+	if !strings.Contains(m.Type, "comment") {
+		return autoReplyTarget{}, false
+	}
+	t := autoReplyTarget{
+		MentionID: ev.ID,
+		FeedID:    mapString(m.ItemInfo, "id"),
+		XsecToken: mapString(m.ItemInfo, "xsec_token"),
+		CommentID: mapString(m.CommentInfo, "id"),
+		UserID:    firstNonEmpty(mapString(m.UserInfo, "userid"), mapString(m.UserInfo, "user_id")),
+		Nickname:  mapString(m.UserInfo, "nickname"),
+		Content:   mapString(m.CommentInfo, "content"),
+	}
+	if t.FeedID == "" || t.XsecToken == "" || t.CommentID == "" || t.Content == "" {
+		return autoReplyTarget{}, false
+	}
+	return t, true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Handle 处理一批新通知，逐条匹配并回复
+func (r *autoReplier) Handle(ctx context.Context, events []MentionEvent) {
+	r.replyMu.Lock()
+	defer r.replyMu.Unlock()
+	for _, ev := range events {
+		t, ok := mentionReplyTarget(ev)
+		if !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		r.handleOne(ctx, t)
+	}
+}
+
+func (r *autoReplier) handleOne(ctx context.Context, t autoReplyTarget) {
+	r.mu.Lock()
+	cfg := r.cfg
+	if !cfg.Enabled || cfg.blacklisted(t) {
+		r.mu.Unlock()
+		return
+	}
+	if _, done := r.replied[t.MentionID]; done {
+		r.mu.Unlock()
+		return
+	}
+	rule := cfg.match(t.Content)
+	if rule == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.rollDayLocked()
+	m := AutoReplyMatch{At: r.now(), MentionID: t.MentionID, Rule: rule.Name, Nickname: t.Nickname, Content: t.Content, Reply: renderReply(rule.Reply, t)}
+	switch {
+	case r.stats.Total >= cfg.dailyCap():
+		m.Status, m.Error = "skipped", "已达每日回复上限"
+	case rule.DailyCap > 0 && r.stats.PerRule[rule.Name] >= rule.DailyCap:
+		m.Status, m.Error = "skipped", "已达本规则每日上限"
+	case cfg.DryRun:
+		m.Status = "dry_run"
+	}
+	r.mu.Unlock()
+
+	if m.Status == "" {
+		if err := r.sendFunc(ctx, t, m.Reply); err != nil {
+			m.Status, m.Error = "error", err.Error()
+			logrus.Warnf("自动回复评论 %s 失败: %v", t.CommentID, err)
+		} else {
+			m.Status = "replied"
+			logrus.Infof("已自动回复评论 %s（规则 %s）", t.CommentID, rule.Name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recent = append(r.recent, m)
+	if len(r.recent) > 100 {
+		r.recent = append(r.recent[:0:0], r.recent[len(r.recent)-100:]...)
+	}
+	if m.Status == "replied" {
+		r.rollDayLocked()
+		r.stats.Total++
+		r.stats.PerRule[rule.Name]++
+	}
+	if m.Status != "error" {
+		r.replied[t.MentionID] = struct{}{}
+		r.stats.Replied = append(r.stats.Replied, t.MentionID)
+		if len(r.stats.Replied) > autoReplyRepliedLimit {
+			drop := r.stats.Replied[:len(r.stats.Replied)-autoReplyRepliedLimit]
+			for _, id := range drop {
+				delete(r.replied, id)
+			}
+			r.stats.Replied = append([]string(nil), r.stats.Replied[len(drop):]...)
+		}
+	}
+	if err := r.saveStatsLocked(); err != nil {
+		logrus.Warnf("保存自动回复计数失败: %v", err)
+	}
+}
+
+// rollDayLocked 跨天后清零计数
+func (r *autoReplier) rollDayLocked() {
+	day := r.now().Format("2006-01-02")
+	if r.stats.Day != day {
+		r.stats.Day = day
+		r.stats.Total = 0
+		r.stats.PerRule = map[string]int{}
+	}
+	if r.stats.PerRule == nil {
+		r.stats.PerRule = map[string]int{}
+	}
+}
+
+func (r *autoReplier) saveStatsLocked() error {
+	data, err := json.MarshalIndent(r.stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(filepath.Join(r.dir, "autoreply_state.json"), data, 0644)
+}
+
+// SetConfig 校验并保存规则
+func (r *autoReplier) SetConfig(cfg AutoReplyConfig) error {
+	if err := cfg.compile(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := fileutil.WriteFileAtomic(filepath.Join(r.dir, "autoreply.json"), data, 0644); err != nil {
+		return fmt.Errorf("保存自动回复规则失败: %w", err)
+	}
+	r.cfg = cfg
+	return nil
+}
+
+// autoReplyStatus GET 接口返回内容
+type autoReplyStatus struct {
+	Config   AutoReplyConfig  `json:"config"`
+	Watching bool             `json:"watching"` // 是否启用了评论和@监控，未启用时规则不会触发
+	Today    AutoReplyStats   `json:"today"`
+	Recent   []AutoReplyMatch `json:"recent"`
+}
+
+func (r *autoReplier) status() autoReplyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollDayLocked()
+	today := r.stats
+	today.Replied = nil
+	perRule := make(map[string]int, len(today.PerRule))
+	for k, v := range today.PerRule {
+		perRule[k] = v
+	}
+	today.PerRule = perRule
+	return autoReplyStatus{
+		Config:   r.cfg,
+		Watching: configs.GetMentionWatchInterval() > 0,
+		Today:    today,
+		Recent:   append([]AutoReplyMatch{}, r.recent...),
+	}
+}
+
+// sendAutoReply 通过回复评论流程发送，占用一个并发名额
+func (s *AppServer) sendAutoReply(ctx context.Context, t autoReplyTarget, content string) error {
+	release, err := s.limiter.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	replyCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+	defer cancel()
+	_, err = s.xiaohongshuService.ReplyCommentToFeed(replyCtx, t.FeedID, t.XsecToken, t.CommentID, t.UserID, content)
+	return err
+}
+
+// getAutoReplyHandler 自动回复规则、当天计数与最近命中
+// GET /api/v1/autoreply
+func (s *AppServer) getAutoReplyHandler(c *gin.Context) {
+	if s.autoReply == nil {
+		respondError(c, http.StatusConflict, "AUTOREPLY_DISABLED", errAutoReplyDisabled.Error(), nil)
+		return
+	}
+	respondSuccess(c, s.autoReply.status(), "获取自动回复配置成功")
+}
+
+// putAutoReplyHandler 替换自动回复规则
+// PUT /api/v1/autoreply
+func (s *AppServer) putAutoReplyHandler(c *gin.Context) {
+	if s.autoReply == nil {
+		respondError(c, http.StatusConflict, "AUTOREPLY_DISABLED", errAutoReplyDisabled.Error(), nil)
+		return
+	}
+	var cfg AutoReplyConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数错误", err.Error())
+		return
+	}
+	if err := s.autoReply.SetConfig(cfg); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_AUTOREPLY_RULES", "自动回复规则无效", err.Error())
+		return
+	}
+	respondSuccess(c, s.autoReply.status(), "自动回复规则已保存")
+}
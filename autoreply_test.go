@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+func commentEvent(id, nickname, content string) MentionEvent {
+	return MentionEvent{ID: id, Type: "comment/item", Mention: xiaohongshu.NotificationMention{
+		ID:          id,
+		Type:        "comment/item",
+		UserInfo:    map[string]any{"userid": "u-" + nickname, "nickname": nickname},
+		CommentInfo: map[string]any{"id": "c-" + id, "content": content},
+		ItemInfo:    map[string]any{"id": "note1", "xsec_token": "tok"},
+	}}
+}
+
+func TestAutoReplierRulesAndCaps(t *testing.T) {
+	dir := t.TempDir()
+	r, err := openAutoReplier(dir)
+	require.NoError(t, err)
+
+	var sent []string
+	r.sendFunc = func(_ context.Context, target autoReplyTarget, content string) error {
+		sent = append(sent, target.CommentID+":"+content)
+		return nil
+	}
+	day := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	r.now = func() time.Time { return day }
+
+	require.Error(t, r.SetConfig(AutoReplyConfig{Rules: []AutoReplyRule{{Name: "bad", Regex: "(", Reply: "x"}}}))
+	require.NoError(t, r.SetConfig(AutoReplyConfig{
+		Enabled:   true,
+		DailyCap:  2,
+		Blacklist: []string{"黑名单"},
+		Rules: []AutoReplyRule{
+			{Name: "price", Keywords: []string{"多少钱"}, Reply: "@{nickname} 已私信"},
+			{Name: "link", Regex: `链接|怎么买`, Reply: "看主页"},
+		},
+	}))
+
+	ctx := context.Background()
+	r.Handle(ctx, []MentionEvent{
+		commentEvent("1", "小王", "这个多少钱"),
+		commentEvent("2", "黑名单", "多少钱"),
+		commentEvent("3", "小李", "好看"),
+		commentEvent("4", "小张", "求链接"),
+		commentEvent("5", "小赵", "怎么买"),
+	})
+	require.Equal(t, []string{"c-1:@小王 已私信", "c-4:看主页"}, sent)
+
+	st := r.status()
+	require.Equal(t, 2, st.Today.Total)
+	require.Equal(t, "skipped", st.Recent[len(st.Recent)-1].Status)
+
+	// 已处理的通知不再回复；计数在重启后保留，跨天清零
+	r.Handle(ctx, []MentionEvent{commentEvent("1", "小王", "这个多少钱")})
+	require.Len(t, sent, 2)
+
+	r2, err := openAutoReplier(dir)
+	require.NoError(t, err)
+	r2.sendFunc = r.sendFunc
+	r2.now = func() time.Time { return day }
+	require.Equal(t, 2, r2.status().Today.Total)
+	require.Len(t, r2.status().Config.Rules, 2)
+
+	r2.now = func() time.Time { return day.Add(24 * time.Hour) }
+	r2.Handle(ctx, []MentionEvent{commentEvent("6", "小周", "多少钱呀")})
+	require.Len(t, sent, 3)
+	require.Equal(t, 1, r2.status().Today.Total)
+}
@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
@@ -17,6 +18,7 @@ import (
 	"github.com/go-rod/stealth"
 	"github.com/sirupsen/logrus"
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/replay"
 )
 
 // defaultUserAgent 默认 User-Agent（向后兼容）
@@ -24,9 +26,14 @@ const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleW
 
 // Browser 浏览器实例
 type Browser struct {
-	browser   *rod.Browser
-	launcher  *launcher.Launcher
-	proxyAuth *proxyAuth
+	browser    *rod.Browser
+	launcher   *launcher.Launcher
+	proxyAuth  *proxyAuth
+	controlURL string // CDP websocket 地址，如 ws://127.0.0.1:9222/devtools/browser/<id>
+
+	timezone string            // 新页面的时区覆盖
+	recorder *replay.Recorder  // 录制模式下保存页面响应与快照
+	router   *rod.HijackRouter // 回放模式下拦截全部请求
 }
 
 type proxyAuth struct {
@@ -41,6 +48,16 @@ type Config struct {
 	Proxy       string // 代理地址，如 http://127.0.0.1:7890
 	UserAgent   string // 浏览器 User-Agent（为空使用默认值）
 	UserDataDir string // 用户数据目录，多用户隔离必须
+	RecordDir   string // 录制目录，非空时保存页面响应与 HTML 快照
+	ReplayDir   string // 回放目录，非空时所有请求由录制结果响应
+	Timezone    string // 页面时区（IANA 名称，如 America/New_York），为空跟随系统
+}
+
+// WithTimezone 设置页面时区，页面中的日期控件与时间显示按该时区
+func WithTimezone(tz string) Option {
+	return func(c *Config) {
+		c.Timezone = tz
+	}
 }
 
 // Option 配置选项
@@ -82,6 +99,27 @@ func NewBrowser(headless bool, options ...Option) (*Browser, error) {
 	}
 
 	// 确定使用的 User-Agent（为空时使用默认值）
+	if cfg.RecordDir != "" && cfg.ReplayDir != "" {
+		return nil, fmt.Errorf("录制与回放不能同时启用")
+	}
+	var (
+		recorder *replay.Recorder
+		player   *replay.Player
+		err      error
+	)
+	if cfg.RecordDir != "" {
+		if recorder, err = replay.NewRecorder(cfg.RecordDir); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ReplayDir != "" {
+		if player, err = replay.Load(cfg.ReplayDir); err != nil {
+			return nil, err
+		}
+		// 回放不访问网络，代理无意义
+		cfg.Proxy = ""
+	}
+
 	ua := strings.TrimSpace(cfg.UserAgent)
 	if ua == "" {
 		ua = defaultUserAgent
@@ -148,8 +186,11 @@ func NewBrowser(headless bool, options ...Option) (*Browser, error) {
 	if data, err := cookieLoader.LoadCookies(); err == nil {
 		var cks []*proto.NetworkCookie
 		if err := json.Unmarshal(data, &cks); err == nil {
-			b.MustSetCookies(cks...)
-			logrus.Debugf("loaded cookies from file successfully")
+			if err := b.SetCookies(proto.CookiesToParams(cks)); err != nil {
+				logrus.Warnf("failed to set cookies: %v", err)
+			} else {
+				logrus.Debugf("loaded cookies from file successfully")
+			}
 		} else {
 			logrus.Warnf("failed to unmarshal cookies: %v", err)
 		}
@@ -159,11 +200,22 @@ func NewBrowser(headless bool, options ...Option) (*Browser, error) {
 		logrus.Warnf("failed to load cookies: %v", err)
 	}
 
-	return &Browser{
-		browser:   b,
-		launcher:  l,
-		proxyAuth: proxyAuthCfg,
-	}, nil
+	out := &Browser{
+		browser:    b,
+		launcher:   l,
+		proxyAuth:  proxyAuthCfg,
+		controlURL: url,
+		recorder:   recorder,
+		timezone:   strings.TrimSpace(cfg.Timezone),
+	}
+	if player != nil {
+		out.router = startReplay(b, player)
+		logrus.Infof("浏览器回放模式，录制目录: %s", cfg.ReplayDir)
+	}
+	if recorder != nil {
+		logrus.Infof("浏览器录制模式，录制目录: %s", cfg.RecordDir)
+	}
+	return out, nil
 }
 
 func startProxyAuth(b *rod.Browser, username, password string) {
@@ -381,18 +433,90 @@ func sanitizeProxyForLog(raw string) string {
 
 // Close 关闭浏览器
 func (b *Browser) Close() {
-	b.browser.MustClose()
+	if b.router != nil {
+		_ = b.router.Stop()
+	}
+	if err := b.browser.Close(); err != nil {
+		logrus.Warnf("failed to close browser: %v", err)
+	}
 	b.launcher.Cleanup()
 }
 
+// GetCookies 获取浏览器当前全部 cookies
+func (b *Browser) GetCookies() ([]*proto.NetworkCookie, error) {
+	return b.browser.GetCookies()
+}
+
+// ReplaceCookies 用 cookies 文件内容整体替换浏览器中的 cookies
+func (b *Browser) ReplaceCookies(data []byte) error {
+	var cks []*proto.NetworkCookie
+	if err := json.Unmarshal(data, &cks); err != nil {
+		return err
+	}
+	if err := b.browser.SetCookies(nil); err != nil {
+		return err
+	}
+	return b.browser.SetCookies(proto.CookiesToParams(cks))
+}
+
+// DevToolsURL 返回 Chrome 远程调试 HTTP 地址（如 http://127.0.0.1:9222），
+// 可访问 /json/list 与内置 DevTools 前端
+func (b *Browser) DevToolsURL() (string, error) {
+	u, err := url.Parse(b.controlURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("无法解析调试地址: %q", b.controlURL)
+	}
+	return "http://" + u.Host, nil
+}
+
+// OpenPageCount 统计当前打开的业务页面数（忽略空白页）
+func (b *Browser) OpenPageCount() (int, error) {
+	pages, err := b.OpenPages()
+	return len(pages), err
+}
+
+// OpenPages 返回当前打开的业务页面（忽略空白页）
+func (b *Browser) OpenPages() ([]*rod.Page, error) {
+	pages, err := b.browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*rod.Page, 0, len(pages))
+	for _, p := range pages {
+		info, err := p.Info()
+		if err != nil || info.URL == "about:blank" || info.URL == "" {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// Ping 检查 Chrome 进程是否仍可响应 CDP 调用
+func (b *Browser) Ping(timeout time.Duration) error {
+	_, err := proto.BrowserGetVersion{}.Call(b.browser.Timeout(timeout))
+	return err
+}
+
 // NewPage 创建新页面（带 stealth 模式）
-func (b *Browser) NewPage() *rod.Page {
-	page := stealth.MustPage(b.browser)
+func (b *Browser) NewPage() (*rod.Page, error) {
+	page, err := stealth.Page(b.browser)
+	if err != nil {
+		return nil, err
+	}
 	if b != nil && b.proxyAuth != nil && strings.TrimSpace(b.proxyAuth.Username) != "" {
 		// 仅在需要代理认证时启用 Fetch 拦截，否则会带来额外开销。
 		b.browser.EnableDomain(page.SessionID, &proto.FetchEnable{HandleAuthRequests: true})
 	}
-	return page
+	if b.timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: b.timezone}).Call(page); err != nil {
+			logrus.Warnf("设置页面时区 %s 失败: %v", b.timezone, err)
+		}
+	}
+	if b.recorder != nil {
+		recordPage(page, b.recorder)
+	}
+	return page, nil
 }
 
 // cleanupChromeLocks 清理 Chrome 的所有锁文件
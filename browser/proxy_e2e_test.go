@@ -47,7 +47,11 @@ func TestProxyAuthE2E(t *testing.T) {
 	}
 	t.Cleanup(b.Close)
 
-	page := b.NewPage().Timeout(15 * time.Second)
+	page, err := b.NewPage()
+	if err != nil {
+		t.Fatalf("NewPage 失败: %v", err)
+	}
+	page = page.Timeout(15 * time.Second)
 	defer page.Close()
 
 	// 使用 .invalid 域名避免真实 DNS 依赖；只要代理生效，就会走本地 proxyServer。
@@ -0,0 +1,160 @@
+package browser
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/replay"
+)
+
+// WithRecordDir 录制模式：保存页面网络响应与 HTML 快照到目录，供回放使用
+func WithRecordDir(dir string) Option {
+	return func(c *Config) {
+		c.RecordDir = dir
+	}
+}
+
+// WithReplayDir 回放模式：所有请求由录制目录响应，不访问网络
+func WithReplayDir(dir string) Option {
+	return func(c *Config) {
+		c.ReplayDir = dir
+	}
+}
+
+// recordTypes 录制的资源类型；图片、字体、媒体体积大且不影响页面逻辑，回放时直接失败
+var recordTypes = map[proto.NetworkResourceType]bool{
+	proto.NetworkResourceTypeDocument:   true,
+	proto.NetworkResourceTypeScript:     true,
+	proto.NetworkResourceTypeStylesheet: true,
+	proto.NetworkResourceTypeXHR:        true,
+	proto.NetworkResourceTypeFetch:      true,
+}
+
+// recordHeaders 随响应保存的头，其余（如 set-cookie、签名相关）不保存
+var recordHeaders = []string{"content-type", "location"}
+
+// startReplay 拦截浏览器全部请求，用录制结果响应
+func startReplay(b *rod.Browser, player *replay.Player) *rod.HijackRouter {
+	router := b.HijackRequests()
+	_ = router.Add("*", "", func(h *rod.Hijack) {
+		req := h.Request
+		f, ok := player.Lookup(req.Method(), req.URL().String(), req.Body())
+		if !ok {
+			if req.Type() == proto.NetworkResourceTypeDocument || req.Type() == proto.NetworkResourceTypeXHR || req.Type() == proto.NetworkResourceTypeFetch {
+				logrus.Warnf("回放未命中: %s %s", req.Method(), req.URL())
+			}
+			h.Response.Fail(proto.NetworkErrorReasonConnectionRefused)
+			return
+		}
+		h.Response.Payload().ResponseCode = f.Status
+		for k, v := range f.Headers {
+			h.Response.SetHeader(k, v)
+		}
+		h.Response.SetBody(f.Body)
+	})
+	go router.Run()
+	return router
+}
+
+// recordPage 录制页面的网络响应，并在每次加载完成后保存 HTML 快照；页面关闭后停止
+func recordPage(page *rod.Page, rec *replay.Recorder) {
+	type pending struct {
+		method, url, body string
+		status            int
+		headers           map[string]string
+	}
+	reqs := map[proto.NetworkRequestID]*pending{}
+
+	wait := page.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			if e.Request == nil {
+				return
+			}
+			// 重定向沿用同一个 RequestID，先保存跳转响应，回放时按原地址跳转
+			if prev := reqs[e.RequestID]; prev != nil && e.RedirectResponse != nil && e.Type == proto.NetworkResourceTypeDocument {
+				if err := rec.Add(replay.Fixture{
+					Method:  prev.method,
+					URL:     prev.url,
+					Status:  e.RedirectResponse.Status,
+					Headers: map[string]string{"location": e.Request.URL},
+				}); err != nil {
+					logrus.Warnf("保存录制响应失败: %v", err)
+				}
+			}
+			reqs[e.RequestID] = &pending{method: e.Request.Method, url: e.Request.URL, body: e.Request.PostData}
+		},
+		func(e *proto.NetworkResponseReceived) {
+			p := reqs[e.RequestID]
+			if p == nil {
+				return
+			}
+			if !recordTypes[e.Type] || e.Response == nil {
+				delete(reqs, e.RequestID)
+				return
+			}
+			p.status = e.Response.Status
+			p.headers = map[string]string{}
+			for k, v := range e.Response.Headers {
+				lk := strings.ToLower(k)
+				for _, keep := range recordHeaders {
+					if lk == keep {
+						p.headers[lk] = v.String()
+					}
+				}
+			}
+		},
+		func(e *proto.NetworkLoadingFailed) {
+			delete(reqs, e.RequestID)
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			p := reqs[e.RequestID]
+			delete(reqs, e.RequestID)
+			if p == nil || p.status == 0 {
+				return
+			}
+			body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(page)
+			if err != nil {
+				logrus.Debugf("录制响应失败 %s: %v", p.url, err)
+				return
+			}
+			data := []byte(body.Body)
+			if body.Base64Encoded {
+				if data, err = base64.StdEncoding.DecodeString(body.Body); err != nil {
+					return
+				}
+			}
+			if err := rec.Add(replay.Fixture{
+				Method:   p.method,
+				URL:      p.url,
+				BodyHash: replay.HashBody(p.body),
+				Status:   p.status,
+				Headers:  p.headers,
+				Body:     data,
+			}); err != nil {
+				logrus.Warnf("保存录制响应失败: %v", err)
+			}
+		},
+		func(e *proto.PageLoadEventFired) {
+			go func() {
+				info, err := page.Info()
+				if err != nil {
+					return
+				}
+				html, err := page.HTML()
+				if err != nil {
+					return
+				}
+				if err := rec.Snapshot(info.URL, html); err != nil {
+					logrus.Warnf("保存页面快照失败: %v", err)
+				}
+			}()
+		},
+		func(e *proto.InspectorDetached) bool {
+			return true
+		},
+	)
+	go wait()
+}
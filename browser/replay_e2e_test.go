@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplayE2E(t *testing.T) {
+	if os.Getenv("XHSMCP_E2E_REPLAY") == "" {
+		t.Skip("跳过：未设置 XHSMCP_E2E_REPLAY")
+	}
+	chrome := findChromeBin()
+	if chrome == "" {
+		t.Skip("跳过：未找到可用的 Chrome/Chromium，可通过 ROD_BROWSER_BIN 指定")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/feeds":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"title":"录制的笔记"}`)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = fmt.Fprint(w, `<html><body><div id="out"></div><script>
+fetch("/api/feeds").then(r => r.json()).then(d => { document.getElementById("out").textContent = d.title })
+</script></body></html>`)
+		}
+	}))
+	dir := t.TempDir()
+	render := func(opts ...Option) string {
+		t.Helper()
+		b, err := NewBrowser(true, append(opts, WithBinPath(chrome), WithUserDataDir(filepath.Join(t.TempDir(), "profile")))...)
+		if err != nil {
+			t.Fatalf("NewBrowser 失败: %v", err)
+		}
+		defer b.Close()
+		page, err := b.NewPage()
+		if err != nil {
+			t.Fatalf("NewPage 失败: %v", err)
+		}
+		page = page.Timeout(15 * time.Second)
+		page.MustNavigate(srv.URL + "/explore")
+		page.MustWaitLoad()
+		el := page.MustElement("#out")
+		for i := 0; i < 50 && strings.TrimSpace(el.MustText()) == ""; i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+		time.Sleep(500 * time.Millisecond) // 等待录制写盘
+		return el.MustText()
+	}
+
+	if got := render(WithRecordDir(dir)); got != "录制的笔记" {
+		t.Fatalf("录制时内容 = %q", got)
+	}
+	srv.Close()
+	if got := render(WithReplayDir(dir)); got != "录制的笔记" {
+		t.Fatalf("回放时内容 = %q", got)
+	}
+}
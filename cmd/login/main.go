@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"os"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/xpzouying/xiaohongshu-mcp/browser"
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/chromecookies"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
 
@@ -18,11 +20,22 @@ func main() {
 	var (
 		binPath string // 浏览器二进制文件路径
 		proxy   string // 代理地址（可空）
+
+		fromBrowser    string // 从本机浏览器导入 cookies
+		browserProfile string
 	)
 	flag.StringVar(&binPath, "bin", "", "浏览器二进制文件路径")
 	flag.StringVar(&proxy, "proxy", "", "代理地址，如 http://127.0.0.1:7890（可空）")
+	flag.StringVar(&fromBrowser, "from-browser", "", "先从本机已登录的浏览器导入 cookies（chrome、edge、chromium），仍未登录时再扫码")
+	flag.StringVar(&browserProfile, "browser-profile", "", "配合 -from-browser 使用的浏览器配置文件目录名，默认 Default")
 	flag.Parse()
 
+	if fromBrowser != "" {
+		if err := importBrowserCookies(fromBrowser, browserProfile); err != nil {
+			logrus.Warnf("从 %s 导入 cookies 失败，继续扫码登录: %v", fromBrowser, err)
+		}
+	}
+
 	// 环境变量 fallback
 	if proxy == "" {
 		proxy = os.Getenv("XHS_PROXY")
@@ -44,7 +57,10 @@ func main() {
 	}
 	defer b.Close()
 
-	page := b.NewPage()
+	page, err := b.NewPage()
+	if err != nil {
+		logrus.Fatalf("failed to create page: %v", err)
+	}
 	defer page.Close()
 
 	action := xiaohongshu.NewLogin(page)
@@ -84,6 +100,29 @@ func main() {
 
 }
 
+// importBrowserCookies 把本机浏览器中的小红书 cookies 合并到 cookies 文件
+func importBrowserCookies(browserName, profile string) error {
+	list, err := chromecookies.Read(chromecookies.Options{Browser: browserName, Profile: profile})
+	if err != nil {
+		return err
+	}
+	loader := cookies.NewLoadCookie(cookies.GetCookiesFilePath())
+	data, err := loader.LoadCookies()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, c := range list {
+		if data, err = cookies.Upsert(data, c); err != nil {
+			return err
+		}
+	}
+	if err := loader.SaveCookies(data); err != nil {
+		return err
+	}
+	logrus.Infof("已从 %s 导入 %d 条 cookies", browserName, len(list))
+	return nil
+}
+
 func saveCookies(page *rod.Page) error {
 	cks, err := page.Browser().GetCookies()
 	if err != nil {
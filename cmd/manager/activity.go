@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认/最大返回条数
+const (
+	defaultActivityLimit = 100
+	maxActivityLimit     = 500
+)
+
+// ActivityEvent 账号动态，来自各实例的动态记录与 manager 记录的进程失败
+type ActivityEvent struct {
+	At      time.Time `json:"at"`
+	UserID  string    `json:"user_id"`
+	Kind    string    `json:"kind"` // publish, publish_video, comment, reply, login_expired, captcha, crash
+	Status  string    `json:"status"`
+	Subject string    `json:"subject,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ActivityResponse 跨账号动态时间线
+type ActivityResponse struct {
+	Events     []ActivityEvent   `json:"events"`
+	Count      int               `json:"count"`
+	NotRunning []string          `json:"not_running,omitempty"` // 未运行的账号只包含进程失败记录
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// GetActivity 合并所有账号的最近动态，按时间倒序；支持 ?user=id&since=RFC3339&limit=N
+// GET /api/admin/v1/activity
+func (a *App) GetActivity(c *gin.Context) {
+	filter := strings.TrimSpace(c.Query("user"))
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since 需为 RFC3339 时间"})
+			return
+		}
+		since = t
+	}
+	limit := defaultActivityLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 需为正整数"})
+			return
+		}
+		limit = min(n, maxActivityLimit)
+	}
+
+	ctx := c.Request.Context()
+	resp := ActivityResponse{Events: []ActivityEvent{}}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, u := range a.store.ListUsers() {
+		if filter != "" && u.ID != filter {
+			continue
+		}
+		for _, f := range a.proc.RecentFailures(u.ID) {
+			if f.At.Before(since) {
+				continue
+			}
+			resp.Events = append(resp.Events, ActivityEvent{At: f.At, UserID: u.ID, Kind: "crash", Status: "error", Error: f.Error})
+		}
+		if !a.proc.GetStatus(u.ID).Running {
+			resp.NotRunning = append(resp.NotRunning, u.ID)
+			continue
+		}
+		wg.Add(1)
+		go func(u UserConfig) {
+			defer wg.Done()
+			events, err := a.fetchActivity(ctx, u, since, limit)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if resp.Errors == nil {
+					resp.Errors = map[string]string{}
+				}
+				resp.Errors[u.ID] = err.Error()
+				return
+			}
+			resp.Events = append(resp.Events, events...)
+		}(u)
+	}
+	wg.Wait()
+
+	sort.SliceStable(resp.Events, func(i, j int) bool { return resp.Events[i].At.After(resp.Events[j].At) })
+	if len(resp.Events) > limit {
+		resp.Events = resp.Events[:limit]
+	}
+	resp.Count = len(resp.Events)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (a *App) fetchActivity(ctx context.Context, u UserConfig, since time.Time, limit int) ([]ActivityEvent, error) {
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	if !since.IsZero() {
+		q.Set("since", since.Format(time.RFC3339))
+	}
+	status, _, data, err := a.proxyGet(ctx, a.endpoint(u), "/api/v1/activity?"+q.Encode(), 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Data    struct {
+			Records []ActivityEvent `json:"records"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("解析响应失败: HTTP %d", status)
+	}
+	if !body.Success {
+		return nil, fmt.Errorf("HTTP %d: %s", status, body.Error)
+	}
+	for i := range body.Data.Records {
+		body.Data.Records[i].UserID = u.ID
+	}
+	return body.Data.Records, nil
+}
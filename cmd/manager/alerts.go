@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 告警规则：后台按间隔检查各账号是否满足规则条件，条件持续 for_minutes 后告警（firing），
+// 条件不再满足时解除（resolved）。告警与解除作为 alert_firing / alert_resolved 事件发布到事件总线，
+// 由 webhook 转发并推送到管理界面。规则保存在 store 中，告警状态只保存在内存，manager 重启后重新计时。
+//
+//   - process_down：设置了自动启动（已手动或定时启动过）的账号未运行
+//   - login_expired：后台登录检查确认登录已失效
+//   - log_growth：window_minutes 内日志（当前+历史）增长超过 threshold_mb
+
+// 规则类型
+const (
+	AlertProcessDown  = "process_down"
+	AlertLoginExpired = "login_expired"
+	AlertLogGrowth    = "log_growth"
+)
+
+var alertKinds = []string{AlertProcessDown, AlertLoginExpired, AlertLogGrowth}
+
+// 告警状态
+const (
+	AlertPending  = "pending" // 条件已满足，未达到 for_minutes
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+const (
+	maxAlertRules          = 50
+	maxAlertForMinutes     = 7 * 24 * 60
+	defaultAlertLogWindow  = 60
+	maxAlertLogWindow      = 24 * 60
+	maxResolvedAlerts      = 50
+	maxAlertRuleNameLength = 64
+)
+
+// alertTick 检查告警规则的间隔
+var alertTick = 30 * time.Second
+
+// AlertRule 告警规则，保存在 store 中
+type AlertRule struct {
+	Name          string   `json:"name"`
+	Kind          string   `json:"kind"`                     // process_down、login_expired、log_growth
+	ForMinutes    int      `json:"for_minutes,omitempty"`    // 条件持续满足多久后告警，0 表示立即
+	ThresholdMB   int64    `json:"threshold_mb,omitempty"`   // log_growth：窗口内的日志增长量
+	WindowMinutes int      `json:"window_minutes,omitempty"` // log_growth：统计窗口，默认 60 分钟
+	Users         []string `json:"users,omitempty"`          // 只检查这些账号，为空表示全部
+	Tags          []string `json:"tags,omitempty"`           // 只检查带有其中任一标签的账号
+	Disabled      bool     `json:"disabled,omitempty"`
+}
+
+func (r AlertRule) window() time.Duration {
+	if r.WindowMinutes <= 0 {
+		return defaultAlertLogWindow * time.Minute
+	}
+	return time.Duration(r.WindowMinutes) * time.Minute
+}
+
+func (r AlertRule) matches(u UserConfig) bool {
+	if len(r.Users) > 0 && !slices.Contains(r.Users, u.ID) {
+		return false
+	}
+	return len(r.Tags) == 0 || hasAnyTag(u, r.Tags)
+}
+
+func validateAlertRules(rules []AlertRule) error {
+	if len(rules) > maxAlertRules {
+		return fmt.Errorf("最多 %d 条告警规则", maxAlertRules)
+	}
+	names := map[string]bool{}
+	for i, r := range rules {
+		switch {
+		case strings.TrimSpace(r.Name) == "" || len(r.Name) > maxAlertRuleNameLength:
+			return fmt.Errorf("alert_rules[%d]: name 不能为空且最多 %d 个字符", i, maxAlertRuleNameLength)
+		case names[r.Name]:
+			return fmt.Errorf("alert_rules[%d]: name %q 重复", i, r.Name)
+		case !slices.Contains(alertKinds, r.Kind):
+			return fmt.Errorf("alert_rules[%d]: 未知类型 %q（可选 %s）", i, r.Kind, strings.Join(alertKinds, "、"))
+		case r.ForMinutes < 0 || r.ForMinutes > maxAlertForMinutes:
+			return fmt.Errorf("alert_rules[%d]: for_minutes 范围为 0~%d", i, maxAlertForMinutes)
+		case r.Kind == AlertLogGrowth && r.ThresholdMB <= 0:
+			return fmt.Errorf("alert_rules[%d]: log_growth 需要 threshold_mb", i)
+		case r.Kind != AlertLogGrowth && (r.ThresholdMB != 0 || r.WindowMinutes != 0):
+			return fmt.Errorf("alert_rules[%d]: threshold_mb、window_minutes 只用于 log_growth", i)
+		case r.WindowMinutes < 0 || r.WindowMinutes > maxAlertLogWindow:
+			return fmt.Errorf("alert_rules[%d]: window_minutes 范围为 0~%d", i, maxAlertLogWindow)
+		}
+		if err := validateTags(r.Tags); err != nil {
+			return fmt.Errorf("alert_rules[%d]: %w", i, err)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
+
+// SetAlertRules 替换告警规则
+func (s *Store) SetAlertRules(rules []AlertRule) error {
+	if err := validateAlertRules(rules); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.cfg.AlertRules = rules
+	return s.saveLocked()
+}
+
+// AlertRules 告警规则
+func (s *Store) AlertRules() []AlertRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]AlertRule{}, s.cfg.AlertRules...)
+}
+
+// Alert 一条告警，也是 alert_firing / alert_resolved 事件的 data 字段
+type Alert struct {
+	Rule       string     `json:"rule"`
+	Kind       string     `json:"kind"`
+	User       string     `json:"user"`
+	State      string     `json:"state"` // pending、firing、resolved
+	Message    string     `json:"message"`
+	Since      time.Time  `json:"since"` // 条件开始满足的时间
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type alertKey struct {
+	rule, user string
+}
+
+type logSample struct {
+	at   time.Time
+	size int64
+}
+
+// alertEngine 告警状态，零值可用
+type alertEngine struct {
+	mu       sync.Mutex
+	active   map[alertKey]*Alert
+	resolved []Alert                // 最近解除的告警，最新的在前
+	samples  map[string][]logSample // 各账号日志总大小的采样，用于 log_growth
+}
+
+// logGrowth 窗口内日志的增长量；轮转清理导致的减少不计入
+func logGrowth(samples []logSample, since time.Time) int64 {
+	var growth int64
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1].at.Before(since) {
+			continue
+		}
+		if d := samples[i].size - samples[i-1].size; d > 0 {
+			growth += d
+		}
+	}
+	return growth
+}
+
+// recordLogSizes 记录一次日志大小采样，丢弃超过 keep 的旧采样
+func (e *alertEngine) recordLogSizes(sizes map[string]int64, now time.Time, keep time.Duration) {
+	if e.samples == nil {
+		e.samples = map[string][]logSample{}
+	}
+	for id := range e.samples {
+		if _, ok := sizes[id]; !ok {
+			delete(e.samples, id)
+		}
+	}
+	for id, size := range sizes {
+		list := append(e.samples[id], logSample{at: now, size: size})
+		i := 0
+		for i < len(list)-1 && now.Sub(list[i].at) > keep {
+			i++
+		}
+		e.samples[id] = list[i:]
+	}
+}
+
+// alertCondition 账号是否满足规则条件，满足时返回说明
+func (a *App) alertCondition(r AlertRule, u UserConfig, now time.Time) (string, bool) {
+	switch r.Kind {
+	case AlertProcessDown:
+		if !u.AutoStart {
+			return "", false
+		}
+		st := a.proc.GetStatus(u.ID)
+		if st.Running {
+			return "", false
+		}
+		msg := "账号未运行"
+		if st.LastError != "" {
+			msg += "：" + st.LastError
+		}
+		return msg, true
+	case AlertLoginExpired:
+		if u.Login == nil || u.Login.Status != LoginExpired {
+			return "", false
+		}
+		return "登录已失效，需要重新扫码登录", true
+	case AlertLogGrowth:
+		growth := logGrowth(a.alerts.samples[u.ID], now.Add(-r.window()))
+		if growth <= r.ThresholdMB<<20 {
+			return "", false
+		}
+		return fmt.Sprintf("%d 分钟内日志增长 %s", int(r.window().Minutes()), formatBytes(growth)), true
+	}
+	return "", false
+}
+
+// evaluateAlerts 检查全部规则，返回本次开始告警与解除的告警
+func (a *App) evaluateAlerts(now time.Time) (fired, resolved []Alert) {
+	rules := a.store.AlertRules()
+	users := a.store.ListUsers()
+	var keep time.Duration
+	for _, r := range rules {
+		if r.Kind == AlertLogGrowth && !r.Disabled {
+			keep = max(keep, r.window())
+		}
+	}
+	sizes := map[string]int64{}
+	if keep > 0 {
+		for _, u := range users {
+			paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+			sizes[u.ID] = logsSize(paths.LogFile) + logsSize(paths.ErrLogFile)
+		}
+	}
+
+	e := &a.alerts
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.recordLogSizes(sizes, now, keep)
+	if e.active == nil {
+		e.active = map[alertKey]*Alert{}
+	}
+	seen := map[alertKey]bool{}
+	for _, r := range rules {
+		if r.Disabled {
+			continue
+		}
+		for _, u := range users {
+			if !r.matches(u) || !a.manages(u.ID) {
+				continue // 集群模式下由运行实例的节点（无归属时由 leader）检查
+			}
+			msg, ok := a.alertCondition(r, u, now)
+			if !ok {
+				continue
+			}
+			key := alertKey{r.Name, u.ID}
+			seen[key] = true
+			al := e.active[key]
+			if al == nil {
+				al = &Alert{Rule: r.Name, Kind: r.Kind, User: u.ID, State: AlertPending, Since: now}
+				e.active[key] = al
+			}
+			al.Message = msg
+			if al.State == AlertPending && now.Sub(al.Since) >= time.Duration(r.ForMinutes)*time.Minute {
+				at := now
+				al.State, al.FiredAt = AlertFiring, &at
+				fired = append(fired, *al)
+			}
+		}
+	}
+	for key, al := range e.active {
+		if seen[key] {
+			continue
+		}
+		delete(e.active, key)
+		if al.State != AlertFiring {
+			continue // 未达到 for_minutes 的不记录
+		}
+		at := now
+		al.State, al.ResolvedAt = AlertResolved, &at
+		resolved = append(resolved, *al)
+		e.resolved = append([]Alert{*al}, e.resolved...)
+	}
+	if len(e.resolved) > maxResolvedAlerts {
+		e.resolved = e.resolved[:maxResolvedAlerts]
+	}
+	return fired, resolved
+}
+
+// StartAlertEvaluator 按间隔检查告警规则并发布告警事件
+func (a *App) StartAlertEvaluator(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(alertTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.runAlerts(now)
+			}
+		}
+	}()
+}
+
+func (a *App) runAlerts(now time.Time) {
+	fired, resolved := a.evaluateAlerts(now)
+	for _, al := range fired {
+		fmt.Printf("告警 %s（%s）: %s\n", al.Rule, al.User, al.Message)
+		a.proc.events.Publish(eventAlertFiring, fmt.Sprintf("告警 %s：用户 %s %s", al.Rule, al.User, al.Message), al)
+	}
+	for _, al := range resolved {
+		fmt.Printf("告警 %s（%s）已解除\n", al.Rule, al.User)
+		a.proc.events.Publish(eventAlertResolved, fmt.Sprintf("告警 %s 已解除：用户 %s", al.Rule, al.User), al)
+	}
+}
+
+// ListAlerts 当前告警（firing 在前）与最近解除的告警
+// GET /api/admin/v1/alerts
+func (a *App) ListAlerts(c *gin.Context) {
+	e := &a.alerts
+	e.mu.Lock()
+	active := make([]Alert, 0, len(e.active))
+	for _, al := range e.active {
+		active = append(active, *al)
+	}
+	resolved := append([]Alert{}, e.resolved...)
+	e.mu.Unlock()
+	slices.SortFunc(active, func(x, y Alert) int {
+		if x.State != y.State {
+			if x.State == AlertFiring {
+				return -1
+			}
+			return 1
+		}
+		if r := x.Since.Compare(y.Since); r != 0 {
+			return r
+		}
+		return strings.Compare(x.Rule+"/"+x.User, y.Rule+"/"+y.User)
+	})
+	c.JSON(http.StatusOK, gin.H{"alerts": active, "resolved": resolved})
+}
+
+// GetAlertRules 告警规则
+// GET /api/admin/v1/alerts/rules
+func (a *App) GetAlertRules(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"rules": a.store.AlertRules(), "kinds": alertKinds})
+}
+
+type updateAlertRulesReq struct {
+	Rules []AlertRule `json:"rules"`
+}
+
+// UpdateAlertRules 替换告警规则，下一次检查时生效
+// PUT /api/admin/v1/alerts/rules
+func (a *App) UpdateAlertRules(c *gin.Context) {
+	var req updateAlertRulesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	for i := range req.Rules {
+		req.Rules[i].Name = strings.TrimSpace(req.Rules[i].Name)
+		req.Rules[i].Tags = normalizeTags(req.Rules[i].Tags)
+	}
+	if err := a.store.SetAlertRules(req.Rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fmt.Printf("告警规则已更新，共 %d 条\n", len(req.Rules))
+	c.JSON(http.StatusOK, gin.H{"rules": a.store.AlertRules(), "kinds": alertKinds})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAlertTestApp(t *testing.T) (*App, *Store) {
+	t.Helper()
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDataDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []UserConfig{{ID: "alice", Port: 18201}, {ID: "bob", Port: 18202, Tags: []string{"vip"}}} {
+		if err := store.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewApp(store, NewProcessManager(), ""), store
+}
+
+func TestValidateAlertRules(t *testing.T) {
+	bad := [][]AlertRule{
+		{{Name: "", Kind: AlertProcessDown}},
+		{{Name: "a", Kind: "cpu"}},
+		{{Name: "a", Kind: AlertProcessDown}, {Name: "a", Kind: AlertLoginExpired}},
+		{{Name: "a", Kind: AlertLogGrowth}},
+		{{Name: "a", Kind: AlertProcessDown, ThresholdMB: 10}},
+		{{Name: "a", Kind: AlertProcessDown, ForMinutes: -1}},
+	}
+	for i, rules := range bad {
+		if err := validateAlertRules(rules); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	ok := []AlertRule{
+		{Name: "down", Kind: AlertProcessDown, ForMinutes: 5},
+		{Name: "logs", Kind: AlertLogGrowth, ThresholdMB: 100, Tags: []string{"vip"}},
+	}
+	if err := validateAlertRules(ok); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvaluateAlertsFiresAfterForAndResolves(t *testing.T) {
+	app, store := newAlertTestApp(t)
+	if err := store.SetAlertRules([]AlertRule{{Name: "down", Kind: AlertProcessDown, ForMinutes: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetUserAutoStart("alice", true); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Now()
+	if fired, _ := app.evaluateAlerts(t0); len(fired) != 0 {
+		t.Fatalf("fired before for_minutes: %+v", fired)
+	}
+	if al := app.alerts.active[alertKey{"down", "alice"}]; al == nil || al.State != AlertPending {
+		t.Fatalf("expected pending alert, got %+v", al)
+	}
+	fired, _ := app.evaluateAlerts(t0.Add(5 * time.Minute))
+	if len(fired) != 1 || fired[0].User != "alice" || fired[0].State != AlertFiring {
+		t.Fatalf("expected alice firing, got %+v", fired)
+	}
+	if fired, _ := app.evaluateAlerts(t0.Add(6 * time.Minute)); len(fired) != 0 {
+		t.Fatalf("fired twice: %+v", fired)
+	}
+
+	if err := store.SetUserAutoStart("alice", false); err != nil {
+		t.Fatal(err)
+	}
+	_, resolved := app.evaluateAlerts(t0.Add(7 * time.Minute))
+	if len(resolved) != 1 || resolved[0].State != AlertResolved || resolved[0].ResolvedAt == nil {
+		t.Fatalf("expected resolved alert, got %+v", resolved)
+	}
+
+	r := gin.New()
+	r.GET("/alerts", app.ListAlerts)
+	w := doReq(r, http.MethodGet, "/alerts", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEvaluateAlertsLoginExpiredMatchesTags(t *testing.T) {
+	app, store := newAlertTestApp(t)
+	if err := store.SetAlertRules([]AlertRule{{Name: "login", Kind: AlertLoginExpired, Tags: []string{"vip"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"alice", "bob"} {
+		if err := store.SetUserLogin(id, &LoginHealth{Status: LoginExpired}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fired, _ := app.evaluateAlerts(time.Now())
+	if len(fired) != 1 || fired[0].User != "bob" {
+		t.Fatalf("expected only bob, got %+v", fired)
+	}
+}
+
+func TestEvaluateAlertsLogGrowth(t *testing.T) {
+	app, store := newAlertTestApp(t)
+	if err := store.SetAlertRules([]AlertRule{{Name: "logs", Kind: AlertLogGrowth, ThresholdMB: 1, WindowMinutes: 60, Users: []string{"alice"}}}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := store.GetUser("alice")
+	logFile := app.proc.DerivePaths(store.UserDataDir(u), u.ID, u.Port).LogFile
+	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Now()
+	writeSized(t, logFile, 100)
+	app.evaluateAlerts(t0)
+	writeSized(t, logFile, 1<<20) // 增长不足 1MB
+	if fired, _ := app.evaluateAlerts(t0.Add(10 * time.Minute)); len(fired) != 0 {
+		t.Fatalf("fired below threshold: %+v", fired)
+	}
+	writeSized(t, logFile, 2<<20)
+	fired, _ := app.evaluateAlerts(t0.Add(20 * time.Minute))
+	if len(fired) != 1 || fired[0].Kind != AlertLogGrowth {
+		t.Fatalf("expected log growth alert, got %+v", fired)
+	}
+
+	// 超出窗口后早期的增长不再计入
+	_, resolved := app.evaluateAlerts(t0.Add(100 * time.Minute))
+	if len(resolved) != 1 {
+		t.Fatalf("expected resolved after window, got %+v", resolved)
+	}
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 运营数据导出：账号资料中的粉丝/获赞等互动数据与当日写操作用量，
+// 定时推送到 Prometheus Pushgateway、InfluxDB，或追加写入 CSV 文件供 BI 工具导入。
+// Parquet 需要引入额外依赖，暂不支持，可由 CSV 转换。
+
+const (
+	analyticsPushJob      = "xiaohongshu_manager"
+	analyticsPushTimeout  = 15 * time.Second
+	analyticsInfluxEnvKey = "XHS_ANALYTICS_INFLUX_TOKEN"
+)
+
+// AnalyticsConfig 导出配置
+type AnalyticsConfig struct {
+	Interval    time.Duration // 定时导出间隔，0 表示不定时导出
+	Pushgateway string        // Pushgateway 地址，如 http://127.0.0.1:9091
+	InfluxURL   string        // InfluxDB 写入地址，如 http://127.0.0.1:8086/api/v2/write?org=o&bucket=b
+	InfluxToken string
+	CSVDir      string // CSV 输出目录，按天分文件
+}
+
+// Enabled 是否配置了任一导出目标
+func (c AnalyticsConfig) Enabled() bool {
+	return c.Pushgateway != "" || c.InfluxURL != "" || c.CSVDir != ""
+}
+
+// AnalyticsSample 单个账号的一次采样
+type AnalyticsSample struct {
+	UserID        string    `json:"user_id"`
+	Nickname      string    `json:"nickname,omitempty"`
+	Running       bool      `json:"running"`
+	Fans          float64   `json:"fans"`
+	Follows       float64   `json:"follows"`
+	Interactions  float64   `json:"interactions"` // 获赞与收藏
+	Notes         int       `json:"notes"`
+	ProfileAt     time.Time `json:"profile_at,omitempty"`
+	Publishes     int       `json:"publishes"` // 当日成功发布（图文+视频）
+	Comments      int       `json:"comments"`  // 当日成功评论
+	Replies       int       `json:"replies"`   // 当日成功回复
+	WriteFailures int       `json:"write_failures"`
+	Crashes       int       `json:"crashes"` // 当日进程异常退出次数
+	Error         string    `json:"error,omitempty"`
+}
+
+// AnalyticsExportResult 各导出目标的结果
+type AnalyticsExportResult struct {
+	At      time.Time         `json:"at"`
+	Samples int               `json:"samples"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+type analyticsState struct {
+	mu   sync.Mutex
+	cfg  AnalyticsConfig
+	last *AnalyticsExportResult
+}
+
+// SetAnalytics 设置导出配置
+func (a *App) SetAnalytics(cfg AnalyticsConfig) {
+	a.analytics.mu.Lock()
+	defer a.analytics.mu.Unlock()
+	a.analytics.cfg = cfg
+}
+
+// StartAnalyticsExporter 按间隔定时导出，采样前刷新过期的账号资料
+func (a *App) StartAnalyticsExporter(ctx context.Context) {
+	a.analytics.mu.Lock()
+	cfg := a.analytics.cfg
+	a.analytics.mu.Unlock()
+	if cfg.Interval <= 0 || !cfg.Enabled() {
+		return
+	}
+	fmt.Printf("运营数据导出已启用，间隔 %s\n", cfg.Interval)
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				res := a.exportAnalytics(ctx, cfg.Interval)
+				for target, msg := range res.Errors {
+					fmt.Fprintf(os.Stderr, "运营数据导出到 %s 失败: %s\n", target, msg)
+				}
+			}
+		}
+	}()
+}
+
+// exportAnalytics 采样并写入所有导出目标；refreshOlder > 0 时先刷新早于该时长的账号资料
+func (a *App) exportAnalytics(ctx context.Context, refreshOlder time.Duration) AnalyticsExportResult {
+	a.analytics.mu.Lock()
+	cfg := a.analytics.cfg
+	a.analytics.mu.Unlock()
+
+	now := time.Now()
+	samples := a.collectAnalytics(ctx, now, refreshOlder)
+	res := AnalyticsExportResult{At: now, Samples: len(samples)}
+	fail := func(target string, err error) {
+		if res.Errors == nil {
+			res.Errors = map[string]string{}
+		}
+		res.Errors[target] = err.Error()
+	}
+	if cfg.Pushgateway != "" {
+		if err := pushAnalyticsPrometheus(ctx, cfg.Pushgateway, samples); err != nil {
+			fail("pushgateway", err)
+		}
+	}
+	if cfg.InfluxURL != "" {
+		if err := pushAnalyticsInflux(ctx, cfg.InfluxURL, cfg.InfluxToken, samples, now); err != nil {
+			fail("influxdb", err)
+		}
+	}
+	if cfg.CSVDir != "" {
+		if err := appendAnalyticsCSV(cfg.CSVDir, samples, now); err != nil {
+			fail("csv", err)
+		}
+	}
+
+	a.analytics.mu.Lock()
+	a.analytics.last = &res
+	a.analytics.mu.Unlock()
+	return res
+}
+
+// collectAnalytics 汇总所有账号的采样，按用户 ID 排序
+func (a *App) collectAnalytics(ctx context.Context, now time.Time, refreshOlder time.Duration) []AnalyticsSample {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	users := a.store.ListUsers()
+	samples := make([]AnalyticsSample, len(users))
+	var wg sync.WaitGroup
+	for i, u := range users {
+		s := &samples[i]
+		s.UserID = u.ID
+		for _, f := range a.proc.RecentFailures(u.ID) {
+			if !f.At.Before(dayStart) {
+				s.Crashes++
+			}
+		}
+		s.Running = a.proc.GetStatus(u.ID).Running
+		profile := u.Profile
+		if !s.Running {
+			s.fillProfile(profile)
+			continue
+		}
+		wg.Add(1)
+		go func(u UserConfig) {
+			defer wg.Done()
+			var errs []string
+			if refreshOlder > 0 && (profile == nil || now.Sub(profile.UpdatedAt) >= refreshOlder) {
+				if _, p, err := a.refreshProfile(ctx, a.endpoint(u), u.ID); err != nil {
+					errs = append(errs, "刷新账号资料失败: "+err.Error())
+				} else {
+					profile = p
+				}
+			}
+			s.fillProfile(profile)
+			events, err := a.fetchActivity(ctx, u, dayStart, maxActivityLimit)
+			if err != nil {
+				errs = append(errs, "获取动态失败: "+err.Error())
+			}
+			s.countActivity(events)
+			s.Error = strings.Join(errs, "；")
+		}(u)
+	}
+	wg.Wait()
+	sort.Slice(samples, func(i, j int) bool { return samples[i].UserID < samples[j].UserID })
+	return samples
+}
+
+func (s *AnalyticsSample) fillProfile(p *AccountProfile) {
+	if p == nil {
+		return
+	}
+	s.Nickname = p.Nickname
+	s.Fans = parseCount(p.Fans)
+	s.Follows = parseCount(p.Follows)
+	s.Interactions = parseCount(p.Interaction)
+	s.Notes = p.NoteCount
+	s.ProfileAt = p.UpdatedAt
+}
+
+func (s *AnalyticsSample) countActivity(events []ActivityEvent) {
+	for _, ev := range events {
+		if ev.Status == "error" {
+			switch ev.Kind {
+			case "publish", "publish_video", "comment", "reply":
+				s.WriteFailures++
+			}
+			continue
+		}
+		switch ev.Kind {
+		case "publish", "publish_video":
+			s.Publishes++
+		case "comment":
+			s.Comments++
+		case "reply":
+			s.Replies++
+		}
+	}
+}
+
+// parseCount 解析页面展示的计数，如 "1234"、"1.2万"、"3.5w"、"10+"；无法解析返回 0
+func parseCount(s string) float64 {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "+")
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "万"):
+		s, mult = strings.TrimSuffix(s, "万"), 10000
+	case strings.HasSuffix(s, "亿"):
+		s, mult = strings.TrimSuffix(s, "亿"), 1e8
+	case strings.HasSuffix(s, "w"), strings.HasSuffix(s, "W"):
+		s, mult = s[:len(s)-1], 10000
+	case strings.HasSuffix(s, "k"), strings.HasSuffix(s, "K"):
+		s, mult = s[:len(s)-1], 1000
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return v * mult
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// analyticsMetric 导出指标定义，Prometheus 与 InfluxDB 共用
+type analyticsMetric struct {
+	name  string
+	field string
+	help  string
+	value func(s AnalyticsSample) float64
+}
+
+var analyticsMetrics = []analyticsMetric{
+	{"xhs_account_running", "running", "实例是否运行", func(s AnalyticsSample) float64 { return boolFloat(s.Running) }},
+	{"xhs_account_fans", "fans", "粉丝数", func(s AnalyticsSample) float64 { return s.Fans }},
+	{"xhs_account_follows", "follows", "关注数", func(s AnalyticsSample) float64 { return s.Follows }},
+	{"xhs_account_interactions", "interactions", "获赞与收藏数", func(s AnalyticsSample) float64 { return s.Interactions }},
+	{"xhs_account_notes", "notes", "主页笔记数（首屏下限）", func(s AnalyticsSample) float64 { return float64(s.Notes) }},
+	{"xhs_publishes_today", "publishes", "当日成功发布数", func(s AnalyticsSample) float64 { return float64(s.Publishes) }},
+	{"xhs_comments_today", "comments", "当日成功评论数", func(s AnalyticsSample) float64 { return float64(s.Comments) }},
+	{"xhs_replies_today", "replies", "当日成功回复数", func(s AnalyticsSample) float64 { return float64(s.Replies) }},
+	{"xhs_write_failures_today", "write_failures", "当日写操作失败数", func(s AnalyticsSample) float64 { return float64(s.WriteFailures) }},
+	{"xhs_crashes_today", "crashes", "当日进程异常退出次数", func(s AnalyticsSample) float64 { return float64(s.Crashes) }},
+}
+
+// writeAnalyticsPrometheus 输出 Prometheus 文本格式
+func writeAnalyticsPrometheus(w io.Writer, samples []AnalyticsSample) {
+	for _, m := range analyticsMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range samples {
+			fmt.Fprintf(w, "%s{user=%q} %s\n", m.name, s.UserID, strconv.FormatFloat(m.value(s), 'f', -1, 64))
+		}
+	}
+}
+
+// writeAnalyticsInflux 输出 InfluxDB 行协议，每个账号一行
+func writeAnalyticsInflux(w io.Writer, samples []AnalyticsSample, at time.Time) {
+	for _, s := range samples {
+		fields := make([]string, 0, len(analyticsMetrics))
+		for _, m := range analyticsMetrics {
+			fields = append(fields, m.field+"="+strconv.FormatFloat(m.value(s), 'f', -1, 64))
+		}
+		fmt.Fprintf(w, "xhs_account,user=%s %s %d\n", influxEscape(s.UserID), strings.Join(fields, ","), at.UnixNano())
+	}
+}
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func influxEscape(s string) string { return influxTagEscaper.Replace(s) }
+
+var analyticsCSVHeader = []string{"time", "user_id", "nickname", "running", "fans", "follows", "interactions", "notes", "publishes", "comments", "replies", "write_failures", "crashes", "error"}
+
+func analyticsCSVRow(s AnalyticsSample, at time.Time) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		at.Format(time.RFC3339), s.UserID, s.Nickname, strconv.FormatBool(s.Running),
+		f(s.Fans), f(s.Follows), f(s.Interactions), strconv.Itoa(s.Notes),
+		strconv.Itoa(s.Publishes), strconv.Itoa(s.Comments), strconv.Itoa(s.Replies),
+		strconv.Itoa(s.WriteFailures), strconv.Itoa(s.Crashes), s.Error,
+	}
+}
+
+// writeAnalyticsCSV 输出带表头的 CSV
+func writeAnalyticsCSV(w io.Writer, samples []AnalyticsSample, at time.Time, header bool) error {
+	cw := csv.NewWriter(w)
+	if header {
+		if err := cw.Write(analyticsCSVHeader); err != nil {
+			return err
+		}
+	}
+	for _, s := range samples {
+		if err := cw.Write(analyticsCSVRow(s, at)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// appendAnalyticsCSV 追加到 <dir>/analytics-YYYY-MM-DD.csv，新文件写入表头
+func appendAnalyticsCSV(dir string, samples []AnalyticsSample, at time.Time) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "analytics-"+at.Format("2006-01-02")+".csv")
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writeAnalyticsCSV(f, samples, at, errors.Is(statErr, os.ErrNotExist)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// pushAnalyticsPrometheus PUT 到 Pushgateway，整组替换上次推送的指标
+func pushAnalyticsPrometheus(ctx context.Context, gateway string, samples []AnalyticsSample) error {
+	var buf bytes.Buffer
+	writeAnalyticsPrometheus(&buf, samples)
+	u := strings.TrimRight(gateway, "/") + "/metrics/job/" + analyticsPushJob
+	return postAnalytics(ctx, http.MethodPut, u, "text/plain; version=0.0.4", "", &buf)
+}
+
+// pushAnalyticsInflux POST 行协议到 InfluxDB 写入地址
+func pushAnalyticsInflux(ctx context.Context, writeURL, token string, samples []AnalyticsSample, at time.Time) error {
+	var buf bytes.Buffer
+	writeAnalyticsInflux(&buf, samples, at)
+	auth := ""
+	if token != "" {
+		auth = "Token " + token
+	}
+	return postAnalytics(ctx, http.MethodPost, writeURL, "text/plain; charset=utf-8", auth, &buf)
+}
+
+func postAnalytics(ctx context.Context, method, u, contentType, auth string, body io.Reader) error {
+	ctx, cancel := context.WithTimeout(ctx, analyticsPushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// GetAnalytics 当前采样，不刷新账号资料；?format=csv|prometheus 输出对应格式，可直接被 Prometheus 抓取
+// GET /api/admin/v1/analytics
+func (a *App) GetAnalytics(c *gin.Context) {
+	now := time.Now()
+	samples := a.collectAnalytics(c.Request.Context(), now, 0)
+	switch c.Query("format") {
+	case "", "json":
+		a.analytics.mu.Lock()
+		last := a.analytics.last
+		a.analytics.mu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"samples": samples, "generated_at": now, "last_export": last})
+	case "csv":
+		var buf bytes.Buffer
+		if err := writeAnalyticsCSV(&buf, samples, now, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="analytics-`+now.Format("20060102-150405")+`.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "prometheus":
+		var buf bytes.Buffer
+		writeAnalyticsPrometheus(&buf, samples)
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 取值为 json、csv 或 prometheus"})
+	}
+}
+
+// ExportAnalytics 立即导出到已配置的目标
+// POST /api/admin/v1/analytics/export
+func (a *App) ExportAnalytics(c *gin.Context) {
+	a.analytics.mu.Lock()
+	cfg := a.analytics.cfg
+	a.analytics.mu.Unlock()
+	if !cfg.Enabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置导出目标，启动时通过 -analytics-pushgateway、-analytics-influx 或 -analytics-csv-dir 指定"})
+		return
+	}
+	res := a.exportAnalytics(c.Request.Context(), 0)
+	status := http.StatusOK
+	if len(res.Errors) > 0 {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, res)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCount(t *testing.T) {
+	cases := map[string]float64{"1234": 1234, "1.2万": 12000, "3.5w": 35000, "2k": 2000, "10+": 10, "1,024": 1024, "": 0, "abc": 0}
+	for in, want := range cases {
+		if got := parseCount(in); got != want {
+			t.Errorf("parseCount(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestAnalyticsFormats(t *testing.T) {
+	s := AnalyticsSample{UserID: "u 1", Running: true}
+	s.fillProfile(&AccountProfile{Nickname: "小红", Fans: "1.2万", Interaction: "300", NoteCount: 5})
+	s.countActivity([]ActivityEvent{
+		{Kind: "publish", Status: "success"},
+		{Kind: "publish_video", Status: "success"},
+		{Kind: "comment", Status: "error"},
+		{Kind: "reply", Status: "success"},
+		{Kind: "captcha", Status: "error"},
+	})
+	if s.Publishes != 2 || s.Replies != 1 || s.Comments != 0 || s.WriteFailures != 1 {
+		t.Fatalf("计数不符: %+v", s)
+	}
+
+	var prom bytes.Buffer
+	writeAnalyticsPrometheus(&prom, []AnalyticsSample{s})
+	if !strings.Contains(prom.String(), `xhs_account_fans{user="u 1"} 12000`) {
+		t.Fatalf("Prometheus 输出不符:\n%s", prom.String())
+	}
+
+	var influx bytes.Buffer
+	writeAnalyticsInflux(&influx, []AnalyticsSample{s}, time.Unix(1, 0))
+	if line := influx.String(); !strings.HasPrefix(line, `xhs_account,user=u\ 1 running=1,fans=12000,`) || !strings.HasSuffix(line, " 1000000000\n") {
+		t.Fatalf("行协议不符: %s", line)
+	}
+}
+
+func TestAppendAnalyticsCSV(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	samples := []AnalyticsSample{{UserID: "a"}, {UserID: "b"}}
+	for i := 0; i < 2; i++ {
+		if err := appendAnalyticsCSV(dir, samples, at); err != nil {
+			t.Fatalf("appendAnalyticsCSV: %v", err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "analytics-2026-01-02.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "time,user_id") || strings.HasPrefix(lines[3], "time,") {
+		t.Fatalf("表头应只写一次，共 5 行:\n%s", data)
+	}
+}
+
+func TestPushAnalyticsPrometheus(t *testing.T) {
+	var method, path, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+	}))
+	defer srv.Close()
+
+	if err := pushAnalyticsPrometheus(context.Background(), srv.URL+"/", []AnalyticsSample{{UserID: "a", Fans: 3}}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/"+analyticsPushJob || !strings.Contains(body, `xhs_account_fans{user="a"} 3`) {
+		t.Fatalf("推送请求不符: %s %s\n%s", method, path, body)
+	}
+}
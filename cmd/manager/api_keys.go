@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 实例接口访问密钥：manager 为每个账号生成并保存一个密钥，启动实例时通过 XHS_API_TOKEN 注入，
+// 实例的 MCP 与 HTTP 接口（健康检查除外）都要求携带（Authorization: Bearer <key> 或 X-API-Token）。
+// 能访问实例端口的其他主机没有密钥就无法操作已登录的账号；manager 转发的请求会自动附带。
+// 密钥在重启后保持不变，直连实例的 MCP 客户端只需配置一次；未设置密钥的账号（旧版本创建）
+// 每次启动随机生成令牌。轮换或撤销在实例下次启动时生效。
+
+// apiKeyView 密钥信息
+type apiKeyView struct {
+	ID              string `json:"id"`
+	APIKey          string `json:"api_key"`          // 为空表示每次启动随机生成
+	InUse           bool   `json:"in_use"`           // 运行中的实例是否已使用该密钥
+	RestartRequired bool   `json:"restart_required"` // 实例运行中且仍在使用旧令牌，重启后生效
+}
+
+func (a *App) apiKeyView(u UserConfig) apiKeyView {
+	v := apiKeyView{ID: u.ID, APIKey: u.APIKey}
+	if a.proc.GetStatus(u.ID).Running {
+		v.InUse = u.APIKey != "" && a.endpoint(u).Token == u.APIKey
+		v.RestartRequired = !v.InUse
+	}
+	return v
+}
+
+// GetUserAPIKey 查看账号的实例访问密钥
+// GET /api/admin/v1/users/:id/api-key
+func (a *App) GetUserAPIKey(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, a.apiKeyView(user))
+}
+
+// RotateUserAPIKey 生成新的访问密钥（没有密钥时为签发），旧密钥在实例下次启动后失效
+// POST /api/admin/v1/users/:id/api-key
+func (a *App) RotateUserAPIKey(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, ok := a.store.GetUser(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	key, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.store.SetUserAPIKey(id, key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fmt.Printf("用户 %s 的实例访问密钥已更新\n", id)
+	user, _ := a.store.GetUser(id)
+	c.JSON(http.StatusOK, a.apiKeyView(user))
+}
+
+// DeleteUserAPIKey 撤销固定密钥，改回每次启动随机生成令牌
+// DELETE /api/admin/v1/users/:id/api-key
+func (a *App) DeleteUserAPIKey(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, ok := a.store.GetUser(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if err := a.store.SetUserAPIKey(id, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fmt.Printf("用户 %s 的实例访问密钥已撤销\n", id)
+	user, _ := a.store.GetUser(id)
+	c.JSON(http.StatusOK, a.apiKeyView(user))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserAPIKey(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := store.GetUser("u1")
+	if len(u.APIKey) != 64 {
+		t.Fatalf("创建账号时应生成密钥，实际 %q", u.APIKey)
+	}
+
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/api-key", a.GetUserAPIKey)
+	r.POST("/users/:id/api-key", a.RotateUserAPIKey)
+	r.DELETE("/users/:id/api-key", a.DeleteUserAPIKey)
+	call := func(method string) apiKeyView {
+		t.Helper()
+		w := doReq(r, method, "/users/u1/api-key", "", nil)
+		var v apiKeyView
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &v) != nil {
+			t.Fatalf("%s: %d %s", method, w.Code, w.Body.String())
+		}
+		return v
+	}
+
+	if v := call(http.MethodGet); v.APIKey != u.APIKey || v.InUse || v.RestartRequired {
+		t.Fatalf("get = %+v", v)
+	}
+	rotated := call(http.MethodPost)
+	if rotated.APIKey == u.APIKey || len(rotated.APIKey) != 64 {
+		t.Fatalf("轮换后应生成新密钥: %+v", rotated)
+	}
+	if got, _ := store.GetUser("u1"); got.APIKey != rotated.APIKey {
+		t.Fatal("新密钥应保存到配置")
+	}
+	if v := call(http.MethodDelete); v.APIKey != "" {
+		t.Fatalf("撤销后应为空: %+v", v)
+	}
+	if w := doReq(r, http.MethodGet, "/users/nobody/api-key", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("不存在的账号应返回 404，实际 %d", w.Code)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计日志：记录管理 API 的写操作与敏感读取（导出 cookies 等），包括时间、操作者、参数与结果，
+// 以 JSON Lines 追加写入 store 所在目录（远程存储为 ./data/manager）的 audit.jsonl，只追加不修改。
+// 参数中的密码、令牌、cookie 值等会被替换为 "***"，cookies 导入与打包导入导出的请求体不记录。
+
+const (
+	auditFileName     = "audit.jsonl"
+	auditMaxBody      = 8 << 10 // 记录的请求体上限，超出时只记录大小
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// AuditEntry 一条审计记录
+type AuditEntry struct {
+	At     time.Time      `json:"at"`
+	Actor  string         `json:"actor"`
+	IP     string         `json:"ip"`
+	Action string         `json:"action"` // 如 user.create、process.start，未归类的为 "METHOD 路由"
+	Method string         `json:"method"`
+	Path   string         `json:"path"`
+	User   string         `json:"user,omitempty"` // 操作的账号
+	Params map[string]any `json:"params,omitempty"`
+	Status int            `json:"status"`
+}
+
+// auditActions 常见操作的名称，按 "METHOD 路由" 查找
+var auditActions = map[string]string{
+	"POST /api/admin/v1/users":                                      "user.create",
+	"PUT /api/admin/v1/users/:id":                                   "user.update",
+	"DELETE /api/admin/v1/users/:id":                                "user.delete",
+	"POST /api/admin/v1/users/:id/clone":                            "user.clone",
+	"POST /api/admin/v1/users/import":                               "user.import",
+	"PUT /api/admin/v1/users/:id/tags":                              "user.tags",
+	"POST /api/admin/v1/users/:id/start":                            "process.start",
+	"POST /api/admin/v1/users/:id/stop":                             "process.stop",
+	"POST /api/admin/v1/users/batch/start":                          "process.batch_start",
+	"POST /api/admin/v1/users/batch/stop":                           "process.batch_stop",
+	"GET /api/admin/v1/users/:id/debug/cookies/items":               "cookies.export",
+	"POST /api/admin/v1/users/:id/debug/cookies/import":             "cookies.import",
+	"POST /api/admin/v1/users/:id/debug/cookies/items":              "cookies.update",
+	"DELETE /api/admin/v1/users/:id/debug/cookies/items/:name":      "cookies.delete",
+	"DELETE /api/admin/v1/users/:id/debug/cookies":                  "cookies.clear",
+	"POST /api/admin/v1/users/:id/debug/mcp/call":                   "mcp.call",
+	"POST /api/admin/v1/bundle/export":                              "bundle.export",
+	"POST /api/admin/v1/bundle/import":                              "bundle.import",
+	"GET /api/admin/v1/backup":                                      "store.backup",
+	"GET /api/admin/v1/users/:id/data/export":                       "user.data_export",
+	"POST /api/admin/v1/users/:id/snapshots":                        "snapshot.create",
+	"POST /api/admin/v1/users/:id/snapshots/:sid/restore":           "snapshot.restore",
+	"DELETE /api/admin/v1/users/:id/snapshots/:sid":                 "snapshot.delete",
+	"GET /api/admin/v1/users/:id/api-key":                           "api_key.view",
+	"POST /api/admin/v1/users/:id/api-key":                          "api_key.rotate",
+	"DELETE /api/admin/v1/users/:id/api-key":                        "api_key.revoke",
+	"POST /api/admin/v1/mcp/broadcast":                              "mcp.broadcast",
+	"POST /api/admin/v1/media":                                      "media.upload",
+	"DELETE /api/admin/v1/media/:mid":                               "media.delete",
+	"POST /api/admin/v1/posts":                                      "post.create",
+	"PUT /api/admin/v1/posts/:pid":                                  "post.update",
+	"POST /api/admin/v1/posts/:pid/cancel":                          "post.cancel",
+	"POST /api/admin/v1/posts/:pid/retry":                           "post.retry",
+	"DELETE /api/admin/v1/posts/:pid":                               "post.delete",
+	"POST /api/admin/v1/restore":                                    "store.restore",
+	"POST /api/admin/v1/upgrade":                                    "binary.upgrade",
+	"POST /api/admin/v1/users/:id/migrate":                          "user.migrate",
+	"POST /api/admin/v1/migrate":                                    "user.migrate_all",
+	"POST /api/admin/v1/reload":                                     "store.reload",
+	"PUT /api/admin/v1/webhooks":                                    "webhooks.update",
+	"PUT /api/admin/v1/alerts/rules":                                "alert_rules.update",
+	"PUT /api/admin/v1/notifiers":                                   "notifiers.update",
+	"POST /api/admin/v1/notifiers/:name/test":                       "notifiers.test",
+	"POST /api/admin/v1/admins":                                     "admin.create",
+	"PUT /api/admin/v1/admins/:name":                                "admin.update",
+	"DELETE /api/admin/v1/admins/:name":                             "admin.delete",
+	"PUT /api/admin/v1/logs/rotation":                               "logs.rotation",
+	"POST /api/admin/v1/logs/cleanup":                               "logs.cleanup",
+	"DELETE /api/admin/v1/users/:id/debug/logs":                     "logs.clear",
+	"POST /api/admin/v1/users/:id/debug/login/browser/action":       "debug.browser_action",
+	"POST /api/admin/v1/users/:id/debug/flow/sessions/:sid/control": "debug.flow_control",
+}
+
+// auditedReads 需要审计的读取操作（会返回敏感数据）
+var auditedReads = map[string]bool{
+	"/api/admin/v1/users/:id/debug/cookies/items": true,
+	"/api/admin/v1/users/:id/debug/devtools":      true,
+	"/api/admin/v1/users/:id/debug/logs/download": true,
+	"/api/admin/v1/backup":                        true,
+	"/api/admin/v1/users/:id/data/export":         true,
+	"/api/admin/v1/users/:id/api-key":             true,
+}
+
+// auditSecretKeys 参数中需要隐去的字段（小写，包含匹配）
+var auditSecretKeys = []string{"password", "passphrase", "token", "secret", "cookie", "value", "authorization", "api_key", "apikey", "access_key", "private_key"}
+
+// AuditLog 追加写入的审计日志
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Append 追加一条记录
+func (l *AuditLog) Append(e AuditEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// AuditQuery 查询条件，空值表示不限
+type AuditQuery struct {
+	User   string
+	Actor  string
+	Action string
+	Since  time.Time
+	Limit  int
+}
+
+func (q AuditQuery) match(e AuditEntry) bool {
+	return (q.User == "" || e.User == q.User) &&
+		(q.Actor == "" || e.Actor == q.Actor) &&
+		(q.Action == "" || e.Action == q.Action) &&
+		(q.Since.IsZero() || !e.At.Before(q.Since))
+}
+
+// Query 按条件查询，最新的在前
+func (l *AuditLog) Query(q AuditQuery) ([]AuditEntry, error) {
+	if q.Limit <= 0 {
+		q.Limit = auditDefaultLimit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// 只保留最近 Limit 条匹配的记录
+	var ring []AuditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var e AuditEntry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || !q.match(e) {
+			continue
+		}
+		ring = append(ring, e)
+		if len(ring) > q.Limit {
+			ring = ring[1:]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]AuditEntry, 0, len(ring))
+	for i := len(ring) - 1; i >= 0; i-- {
+		out = append(out, ring[i])
+	}
+	return out, nil
+}
+
+// Middleware 记录写操作与 auditedReads 中的读取，需放在鉴权之后
+func (l *AuditLog) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		method := c.Request.Method
+		if route == "" || ((method == http.MethodGet || method == http.MethodHead) && !auditedReads[route]) {
+			c.Next()
+			return
+		}
+
+		params := map[string]any{}
+		for _, p := range c.Params {
+			if p.Key != "id" {
+				params[p.Key] = p.Value
+			}
+		}
+		for k, v := range c.Request.URL.Query() {
+			params[k] = strings.Join(v, ",")
+		}
+		if body := auditBody(c, route); body != nil {
+			params["body"] = body
+		}
+		redactAuditParams(params)
+		if len(params) == 0 {
+			params = nil
+		}
+
+		c.Next()
+
+		action := auditActions[method+" "+route]
+		if action == "" {
+			action = method + " " + route
+		}
+		if err := l.Append(AuditEntry{
+			At:     time.Now(),
+			Actor:  adminActor(c),
+			IP:     c.ClientIP(),
+			Action: action,
+			Method: method,
+			Path:   c.Request.URL.Path,
+			User:   c.Param("id"),
+			Params: params,
+			Status: c.Writer.Status(),
+		}); err != nil {
+			fmt.Printf("写入审计日志失败: %v\n", err)
+		}
+	}
+}
+
+// auditBody 读取 JSON 请求体用于记录并放回，cookies 与打包数据不记录
+func auditBody(c *gin.Context, route string) any {
+	if c.Request.Body == nil || strings.Contains(route, "/cookies") || strings.Contains(route, "/bundle/") || strings.HasSuffix(route, "/restore") ||
+		!strings.Contains(c.GetHeader("Content-Type"), "json") {
+		return nil
+	}
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, auditMaxBody+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(raw), c.Request.Body), c.Request.Body}
+	if err != nil || len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	if len(raw) > auditMaxBody {
+		return fmt.Sprintf("<超过 %d 字节，未记录>", auditMaxBody)
+	}
+	var v any
+	if json.Unmarshal(raw, &v) != nil {
+		return nil
+	}
+	return v
+}
+
+// redactAuditParams 递归隐去敏感字段
+func redactAuditParams(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			lk := strings.ToLower(k)
+			secret := false
+			for _, s := range auditSecretKeys {
+				if strings.Contains(lk, s) {
+					secret = true
+					break
+				}
+			}
+			if secret {
+				t[k] = "***"
+				continue
+			}
+			redactAuditParams(child)
+		}
+	case []any:
+		for _, child := range t {
+			redactAuditParams(child)
+		}
+	}
+}
+
+// GetAudit 查询审计日志
+// GET /api/admin/v1/audit?user=&actor=&action=&since=RFC3339&limit=100
+func (a *App) GetAudit(c *gin.Context) {
+	q := AuditQuery{
+		User:   strings.TrimSpace(c.Query("user")),
+		Actor:  strings.TrimSpace(c.Query("actor")),
+		Action: strings.TrimSpace(c.Query("action")),
+	}
+	if s := c.Query("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since 需为 RFC3339 时间"})
+			return
+		}
+		q.Since = t
+	}
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > auditMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit 需在 1-%d 之间", auditMaxLimit)})
+			return
+		}
+		q.Limit = n
+	}
+	entries, err := a.audit.Query(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "count": len(entries)})
+}
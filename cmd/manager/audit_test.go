@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditLog(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	auth := NewAdminAuth(AdminAuthConfig{Token: "tk"}, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api/admin/v1", auth.Require(), a.audit.Middleware())
+	api.GET("/audit", a.GetAudit)
+	api.GET("/users", a.ListUsers)
+	api.POST("/users", a.CreateUser)
+	api.DELETE("/users/:id", a.DeleteUser)
+	api.PUT("/webhooks", a.UpdateWebhooks)
+	bearer := http.Header{"Authorization": {"Bearer tk"}}
+
+	if w := doReq(r, http.MethodPost, "/api/admin/v1/users", `{"id":"u1","port":18060}`, bearer); w.Code != http.StatusCreated {
+		t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+	}
+	doReq(r, http.MethodGet, "/api/admin/v1/users", "", bearer)
+	doReq(r, http.MethodPut, "/api/admin/v1/webhooks", `{"webhooks":[{"url":"https://example.com/hook","secret":"s3"}]}`, bearer)
+	if w := doReq(r, http.MethodDelete, "/api/admin/v1/users/u1", "", bearer); w.Code != http.StatusNoContent {
+		t.Fatalf("删除失败: %d %s", w.Code, w.Body.String())
+	}
+	// 未通过鉴权的请求不记录
+	doReq(r, http.MethodDelete, "/api/admin/v1/users/u1", "", nil)
+
+	w := doReq(r, http.MethodGet, "/api/admin/v1/audit", "", bearer)
+	var resp struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 3 {
+		t.Fatalf("应记录 3 条写操作，got %+v", resp.Entries)
+	}
+	del, hook, create := resp.Entries[0], resp.Entries[1], resp.Entries[2]
+	if del.Action != "user.delete" || del.User != "u1" || del.Actor != "token" || del.Status != http.StatusNoContent {
+		t.Fatalf("删除记录不符: %+v", del)
+	}
+	body, _ := create.Params["body"].(map[string]any)
+	if create.Action != "user.create" || body["id"] != "u1" {
+		t.Fatalf("创建记录不符: %+v", create)
+	}
+	hooks, _ := hook.Params["body"].(map[string]any)["webhooks"].([]any)
+	if len(hooks) != 1 || hooks[0].(map[string]any)["secret"] != "***" {
+		t.Fatalf("密钥应被隐去: %+v", hook.Params)
+	}
+
+	w = doReq(r, http.MethodGet, "/api/admin/v1/audit?action=user.create&limit=5", "", bearer)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Action != "user.create" {
+		t.Fatalf("按 action 过滤失败: %+v", resp.Entries)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/audit?since=yesterday", "", bearer); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法 since 应返回 400，got %d", w.Code)
+	}
+}
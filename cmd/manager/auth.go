@@ -0,0 +1,335 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 管理接口鉴权：GUI 使用用户名/密码登录后的会话 cookie，脚本和 API 使用 Authorization: Bearer <token>。
+// 除 -admin-user/-admin-password 外，还可在 store 中添加带角色的管理员账号（见 roles.go）。
+// 都未配置时不鉴权（仅适合监听 127.0.0.1）。
+
+const (
+	sessionCookieName = "xhs_manager_session"
+
+	adminPasswordEnvKey = "XHS_MANAGER_ADMIN_PASSWORD"
+	adminTokenEnvKey    = "XHS_MANAGER_ADMIN_TOKEN"
+
+	// 同一来源在 loginLockout 内登录失败达到次数后锁定一段时间
+	loginMaxFailures = 5
+	loginLockout     = 10 * time.Minute
+)
+
+// AdminAuthConfig 管理接口鉴权配置
+type AdminAuthConfig struct {
+	Username   string        // GUI 登录用户名
+	Password   string        // GUI 登录密码，为空表示不允许密码登录
+	Token      string        // API Bearer 令牌，也可在登录页直接使用
+	SessionTTL time.Duration // 会话有效期
+}
+
+type loginFailure struct {
+	count int
+	last  time.Time // 最近一次失败时间
+	until time.Time // 锁定截止时间
+}
+
+// expired 锁定已结束，或未锁定且距最近一次失败已超过 loginLockout
+func (f *loginFailure) expired(now time.Time) bool {
+	if !f.until.IsZero() {
+		return now.After(f.until)
+	}
+	return now.Sub(f.last) > loginLockout
+}
+
+// AdminAuth 管理接口鉴权
+type AdminAuth struct {
+	cfg       AdminAuthConfig
+	loginHTML string
+
+	accounts adminAccountSource // store 中的管理员账号，可为 nil
+
+	mu       sync.Mutex
+	sessions map[string]adminSession // 会话 ID -> 会话
+	failures map[string]*loginFailure
+}
+
+type adminSession struct {
+	user    string // 登录的用户名，令牌登录为 "token"
+	builtin bool   // 通过 -admin-password / -admin-token 登录，拥有 admin 角色
+	expires time.Time
+}
+
+// adminPrincipal 已通过鉴权的操作者
+type adminPrincipal struct {
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+// gin.Context 中记录操作者与角色的键，供审计日志与权限检查使用
+const (
+	adminActorKey = "admin_actor"
+	adminRoleKey  = "admin_role"
+)
+
+// 未启用鉴权时的操作者
+const anonymousActor = "anonymous"
+
+// adminActor 当前请求的操作者：会话的用户名、Bearer 令牌为 "token"，未鉴权为 "anonymous"
+func adminActor(c *gin.Context) string {
+	if v := c.GetString(adminActorKey); v != "" {
+		return v
+	}
+	return anonymousActor
+}
+
+// NewAdminAuth 创建鉴权
+func NewAdminAuth(cfg AdminAuthConfig, loginHTML string) *AdminAuth {
+	if cfg.Username == "" {
+		cfg.Username = "admin"
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = 12 * time.Hour
+	}
+	return &AdminAuth{
+		cfg:       cfg,
+		loginHTML: loginHTML,
+		sessions:  map[string]adminSession{},
+		failures:  map[string]*loginFailure{},
+	}
+}
+
+// SetAccounts 启用 store 中的管理员账号
+func (a *AdminAuth) SetAccounts(src adminAccountSource) {
+	a.accounts = src
+}
+
+// Enabled 是否配置了密码、令牌或管理员账号
+func (a *AdminAuth) Enabled() bool {
+	return a.cfg.Password != "" || a.cfg.Token != "" || (a.accounts != nil && a.accounts.HasAdminAccounts())
+}
+
+// secretEqual 定长比较，避免通过响应时间猜测密钥
+func secretEqual(got, want string) bool {
+	if want == "" {
+		return false
+	}
+	g, w := sha256.Sum256([]byte(got)), sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(g[:], w[:]) == 1
+}
+
+// authenticated 请求是否携带有效的 Bearer 令牌或会话，返回操作者。
+// 账号的角色每次从 store 读取，修改或删除账号后已有会话立即生效
+func (a *AdminAuth) authenticated(c *gin.Context) (adminPrincipal, bool) {
+	if h := c.GetHeader("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return adminPrincipal{User: "token", Role: RoleAdmin}, secretEqual(strings.TrimPrefix(h, "Bearer "), a.cfg.Token)
+	}
+	sid, err := c.Cookie(sessionCookieName)
+	if err != nil || sid == "" {
+		return adminPrincipal{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[sid]
+	if !ok {
+		return adminPrincipal{}, false
+	}
+	if time.Now().After(s.expires) {
+		delete(a.sessions, sid)
+		return adminPrincipal{}, false
+	}
+	if s.builtin {
+		return adminPrincipal{User: s.user, Role: RoleAdmin}, true
+	}
+	if a.accounts != nil {
+		if acc, ok := a.accounts.AdminAccount(s.user); ok {
+			return adminPrincipal{User: acc.Username, Role: acc.Role}, true
+		}
+	}
+	delete(a.sessions, sid)
+	return adminPrincipal{}, false
+}
+
+// Require 管理 API 鉴权，未登录返回 401
+func (a *AdminAuth) Require() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Enabled() {
+			c.Set(adminRoleKey, RoleAdmin)
+			c.Next()
+			return
+		}
+		if p, ok := a.authenticated(c); ok {
+			c.Set(adminActorKey, p.User)
+			c.Set(adminRoleKey, p.Role)
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="xhs-manager"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未登录或令牌无效"})
+	}
+}
+
+// RequirePage 页面鉴权，未登录跳转到登录页
+func (a *AdminAuth) RequirePage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := a.authenticated(c); !a.Enabled() || ok {
+			c.Next()
+			return
+		}
+		c.Redirect(http.StatusFound, "/login")
+		c.Abort()
+	}
+}
+
+// HandleLoginPage 登录页；未启用鉴权时直接回到首页
+func (a *AdminAuth) HandleLoginPage(c *gin.Context) {
+	if !a.Enabled() {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, a.loginHTML)
+}
+
+type loginReq struct {
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" form:"password"`
+	Token    string `json:"token" form:"token"`
+}
+
+// Login 校验用户名密码（或令牌）并下发会话 cookie
+func (a *AdminAuth) Login(c *gin.Context) {
+	if !a.Enabled() {
+		c.JSON(http.StatusOK, gin.H{"auth": false})
+		return
+	}
+	var req loginReq
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效请求"})
+		return
+	}
+
+	// 按直连地址计数：X-Forwarded-For 可由客户端任意伪造，不能用来绕过锁定
+	ip := c.RemoteIP()
+	if a.lockedOut(ip) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登录失败次数过多，请稍后再试"})
+		return
+	}
+	session := adminSession{user: a.cfg.Username, builtin: true}
+	role := RoleAdmin
+	ok := a.cfg.Password != "" && secretEqual(req.Username, a.cfg.Username) && secretEqual(req.Password, a.cfg.Password)
+	if !ok && secretEqual(req.Token, a.cfg.Token) {
+		session.user, ok = "token", true
+	}
+	if !ok && a.accounts != nil {
+		if acc, found := a.accounts.AdminAccount(req.Username); found && verifyPassword(acc.PasswordHash, req.Password) {
+			session = adminSession{user: acc.Username}
+			role, ok = acc.Role, true
+		}
+	}
+	if !ok {
+		a.recordFailure(ip)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+		return
+	}
+	a.clearFailures(ip)
+
+	sid, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	a.mu.Lock()
+	now := time.Now()
+	for id, s := range a.sessions {
+		if now.After(s.expires) {
+			delete(a.sessions, id)
+		}
+	}
+	session.expires = now.Add(a.cfg.SessionTTL)
+	a.sessions[sid] = session
+	a.mu.Unlock()
+
+	a.setSessionCookie(c, sid, int(a.cfg.SessionTTL/time.Second))
+	c.JSON(http.StatusOK, gin.H{"auth": true, "user": session.user, "role": role, "expires_at": session.expires})
+}
+
+// Logout 注销当前会话
+func (a *AdminAuth) Logout(c *gin.Context) {
+	if sid, err := c.Cookie(sessionCookieName); err == nil && sid != "" {
+		a.mu.Lock()
+		delete(a.sessions, sid)
+		a.mu.Unlock()
+	}
+	a.setSessionCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// setSessionCookie 会话 cookie：HttpOnly + SameSite=Strict，HTTPS（含反向代理）下加 Secure
+func (a *AdminAuth) setSessionCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (a *AdminAuth) lockedOut(ip string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f := a.failures[ip]
+	return f != nil && time.Now().Before(f.until)
+}
+
+func (a *AdminAuth) recordFailure(ip string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	// 顺带清理过期记录，避免不同来源的失败记录无限增长
+	for k, f := range a.failures {
+		if f.expired(now) {
+			delete(a.failures, k)
+		}
+	}
+	f := a.failures[ip]
+	if f == nil {
+		f = &loginFailure{}
+		a.failures[ip] = f
+	}
+	f.count++
+	f.last = now
+	if f.count >= loginMaxFailures {
+		f.until = now.Add(loginLockout)
+	}
+}
+
+func (a *AdminAuth) clearFailures(ip string) {
+	a.mu.Lock()
+	delete(a.failures, ip)
+	a.mu.Unlock()
+}
+
+// isLoopbackListen 监听地址是否只在本机可访问
+func isLoopbackListen(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAuthRouter(cfg AdminAuthConfig) (*gin.Engine, *AdminAuth) {
+	gin.SetMode(gin.TestMode)
+	auth := NewAdminAuth(cfg, "<html>login</html>")
+	r := gin.New()
+	r.GET("/", auth.RequirePage(), func(c *gin.Context) { c.String(http.StatusOK, "index") })
+	r.POST("/api/admin/login", auth.Login)
+	r.POST("/api/admin/logout", auth.Logout)
+	api := r.Group("/api/admin/v1", auth.Require())
+	api.GET("/users", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"users": []string{}}) })
+	return r, auth
+}
+
+func doReq(r http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminAuthDisabled(t *testing.T) {
+	r, _ := newAuthRouter(AdminAuthConfig{})
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("未配置鉴权时应放行，got %d", w.Code)
+	}
+}
+
+func TestAdminAuthBearerToken(t *testing.T) {
+	r, _ := newAuthRouter(AdminAuthConfig{Token: "secret"})
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌应返回 401，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", http.Header{"Authorization": {"Bearer wrong"}}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("错误令牌应返回 401，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", http.Header{"Authorization": {"Bearer secret"}}); w.Code != http.StatusOK {
+		t.Fatalf("正确令牌应放行，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/", "", nil); w.Code != http.StatusFound || w.Header().Get("Location") != "/login" {
+		t.Fatalf("未登录访问首页应跳转登录页，got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestAdminAuthSessionLogin(t *testing.T) {
+	r, auth := newAuthRouter(AdminAuthConfig{Username: "root", Password: "pw", SessionTTL: time.Hour})
+
+	if w := doReq(r, http.MethodPost, "/api/admin/login", `{"username":"root","password":"bad"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("错误密码应返回 401，got %d", w.Code)
+	}
+	w := doReq(r, http.MethodPost, "/api/admin/login", `{"username":"root","password":"pw"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", w.Code, w.Body.String())
+	}
+	cookie := w.Header().Get("Set-Cookie")
+	if !strings.Contains(cookie, sessionCookieName+"=") || !strings.Contains(cookie, "HttpOnly") || !strings.Contains(cookie, "SameSite=Strict") {
+		t.Fatalf("会话 cookie 属性不正确: %q", cookie)
+	}
+	session := http.Header{"Cookie": {strings.SplitN(cookie, ";", 2)[0]}}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", session); w.Code != http.StatusOK {
+		t.Fatalf("登录后应放行，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/", "", session); w.Code != http.StatusOK {
+		t.Fatalf("登录后应能访问首页，got %d", w.Code)
+	}
+
+	// 未配置令牌时 Bearer 不能绕过
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", http.Header{"Authorization": {"Bearer "}}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("空令牌应返回 401，got %d", w.Code)
+	}
+
+	if w := doReq(r, http.MethodPost, "/api/admin/logout", "", session); w.Code != http.StatusNoContent {
+		t.Fatalf("注销失败: %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", session); w.Code != http.StatusUnauthorized {
+		t.Fatalf("注销后会话应失效，got %d", w.Code)
+	}
+	if len(auth.sessions) != 0 {
+		t.Fatalf("注销后不应残留会话: %d", len(auth.sessions))
+	}
+}
+
+func TestAdminAuthLockout(t *testing.T) {
+	r, _ := newAuthRouter(AdminAuthConfig{Password: "pw"})
+	// 每次伪造不同的 X-Forwarded-For 也不能绕过锁定
+	for i := 0; i < loginMaxFailures; i++ {
+		h := http.Header{"X-Forwarded-For": {fmt.Sprintf("10.0.0.%d", i)}}
+		doReq(r, http.MethodPost, "/api/admin/login", `{"username":"admin","password":"bad"}`, h)
+	}
+	if w := doReq(r, http.MethodPost, "/api/admin/login", `{"username":"admin","password":"pw"}`, nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("连续失败后应锁定，got %d", w.Code)
+	}
+}
+
+func TestAdminAuthPrunesExpiredFailures(t *testing.T) {
+	auth := NewAdminAuth(AdminAuthConfig{Password: "pw"}, "")
+	auth.recordFailure("10.0.0.1")
+	auth.failures["10.0.0.1"].last = time.Now().Add(-2 * loginLockout)
+	auth.failures["10.0.0.2"] = &loginFailure{count: loginMaxFailures, until: time.Now().Add(-time.Second)}
+	auth.recordFailure("10.0.0.3")
+	if len(auth.failures) != 1 || auth.failures["10.0.0.3"] == nil {
+		t.Fatalf("过期的失败记录应被清理: %v", auth.failures)
+	}
+}
+
+func TestIsLoopbackListen(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:18050": true,
+		"localhost:18050": true,
+		"[::1]:18050":     true,
+		"0.0.0.0:18050":   false,
+		":18050":          false,
+	} {
+		if got := isLoopbackListen(addr); got != want {
+			t.Errorf("isLoopbackListen(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 启动恢复：manager 启动时拉起上次处于运行态的账号（auto_start）。
+// 按 start_priority 从高到低排队（相同优先级按账号顺序），最多 -autostart-concurrency 个同时启动，
+// 高优先级账号先开始启动，但不等待其就绪；已在运行（如重新接管的实例）的账号跳过。
+// 进度可通过 GET /api/admin/v1/autostart 查询。
+
+// 启动优先级范围
+const (
+	minStartPriority = -1000
+	maxStartPriority = 1000
+)
+
+// 启动恢复中单个账号的状态
+const (
+	autoStartPending  = "pending"
+	autoStartStarting = "starting"
+	autoStartStarted  = "started"
+	autoStartFailed   = "failed"
+	autoStartSkipped  = "skipped" // 已在运行，或集群中已由其他节点运行
+)
+
+// AutoStartConfig 启动恢复的并发与超时
+type AutoStartConfig struct {
+	Concurrency int
+	Timeout     time.Duration // 单个账号的启动超时
+}
+
+// AutoStartUser 单个账号的启动进度
+type AutoStartUser struct {
+	ID         string `json:"id"`
+	Priority   int    `json:"priority"`
+	State      string `json:"state"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+}
+
+// AutoStartStatus 启动恢复的整体进度
+type AutoStartStatus struct {
+	State       string          `json:"state"` // idle / running / done
+	Concurrency int             `json:"concurrency"`
+	Total       int             `json:"total"`
+	Started     int             `json:"started"`
+	Failed      int             `json:"failed"`
+	Skipped     int             `json:"skipped"`
+	Pending     int             `json:"pending"` // 尚未完成（含启动中）
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	Users       []AutoStartUser `json:"users"`
+}
+
+// autoStartState 最近一次启动恢复的进度
+type autoStartState struct {
+	mu     sync.Mutex
+	status AutoStartStatus
+}
+
+func validateStartPriority(p int) error {
+	if p < minStartPriority || p > maxStartPriority {
+		return fmt.Errorf("start_priority 范围为 %d~%d", minStartPriority, maxStartPriority)
+	}
+	return nil
+}
+
+// autoStartOrder 需要自动启动的账号，按优先级从高到低排序（稳定排序，相同优先级保持原顺序）
+func autoStartOrder(users []UserConfig) []UserConfig {
+	var out []UserConfig
+	for _, u := range users {
+		if u.AutoStart {
+			out = append(out, u)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b UserConfig) int { return b.StartPriority - a.StartPriority })
+	return out
+}
+
+// AutoStartUsers 启动恢复：上次记录为运行态的用户，自动拉起
+func (a *App) AutoStartUsers(cfg AutoStartConfig) {
+	storeCfg := a.store.GetConfig()
+	binPath := a.store.ResolveBinPath()
+	a.runAutoStart(autoStartOrder(a.store.ListUsers()), cfg, func(ctx context.Context, u UserConfig) error {
+		return a.proc.StartUser(ctx, StartUserParams{
+			User:     u,
+			BinPath:  binPath,
+			Headless: storeCfg.Headless,
+			DataDir:  a.store.UserDataDir(u),
+		})
+	})
+}
+
+// runAutoStart 按顺序分发给 cfg.Concurrency 个 worker 启动，并记录进度
+func (a *App) runAutoStart(users []UserConfig, cfg AutoStartConfig, start func(context.Context, UserConfig) error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	now := time.Now()
+	st := AutoStartStatus{State: "running", Concurrency: cfg.Concurrency, Total: len(users), Pending: len(users), StartedAt: &now, Users: make([]AutoStartUser, len(users))}
+	for i, u := range users {
+		st.Users[i] = AutoStartUser{ID: u.ID, Priority: u.StartPriority, State: autoStartPending}
+	}
+	a.autoStart.mu.Lock()
+	a.autoStart.status = st
+	a.autoStart.mu.Unlock()
+
+	if len(users) > 0 {
+		fmt.Printf("auto-start: 发现 %d 个需要自动启动的用户，并发 %d\n", len(users), cfg.Concurrency)
+	}
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(cfg.Concurrency, max(len(users), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				a.autoStartOne(i, users[i], cfg.Timeout, start)
+			}
+		}()
+	}
+	for i := range users {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	a.autoStart.mu.Lock()
+	finished := time.Now()
+	a.autoStart.status.State = "done"
+	a.autoStart.status.FinishedAt = &finished
+	summary := a.autoStart.status
+	a.autoStart.mu.Unlock()
+	if len(users) > 0 {
+		fmt.Printf("auto-start: 完成，成功 %d，失败 %d，跳过 %d，耗时 %s\n",
+			summary.Started, summary.Failed, summary.Skipped, finished.Sub(now).Round(time.Second))
+	}
+}
+
+func (a *App) autoStartOne(i int, u UserConfig, timeout time.Duration, start func(context.Context, UserConfig) error) {
+	set := func(fn func(*AutoStartUser, *AutoStartStatus)) {
+		a.autoStart.mu.Lock()
+		fn(&a.autoStart.status.Users[i], &a.autoStart.status)
+		a.autoStart.mu.Unlock()
+	}
+	if a.proc.GetStatus(u.ID).Running {
+		set(func(r *AutoStartUser, s *AutoStartStatus) { r.State = autoStartSkipped; s.Skipped++; s.Pending-- })
+		return
+	}
+	set(func(r *AutoStartUser, _ *AutoStartStatus) { r.State = autoStartStarting })
+
+	begin := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	err := start(ctx, u)
+	cancel()
+	elapsed := time.Since(begin).Milliseconds()
+	var owned *clusterOwnedError
+	if errors.As(err, &owned) {
+		set(func(r *AutoStartUser, s *AutoStartStatus) {
+			r.State, r.Error = autoStartSkipped, err.Error()
+			s.Skipped++
+			s.Pending--
+		})
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "auto-start %s 失败: %v\n", u.ID, err)
+		a.proc.publishAutoStartFailed(u.ID, triggerAutoStart, err)
+		set(func(r *AutoStartUser, s *AutoStartStatus) {
+			r.State, r.Error, r.DurationMs = autoStartFailed, err.Error(), elapsed
+			s.Failed++
+			s.Pending--
+		})
+		return
+	}
+	fmt.Printf("auto-start %s 成功\n", u.ID)
+	set(func(r *AutoStartUser, s *AutoStartStatus) {
+		r.State, r.DurationMs = autoStartStarted, elapsed
+		s.Started++
+		s.Pending--
+	})
+}
+
+// GetAutoStart 启动恢复的进度
+// GET /api/admin/v1/autostart
+func (a *App) GetAutoStart(c *gin.Context) {
+	a.autoStart.mu.Lock()
+	st := a.autoStart.status
+	st.Users = slices.Clone(st.Users)
+	a.autoStart.mu.Unlock()
+	if st.State == "" {
+		st.State = "idle"
+	}
+	if st.Users == nil {
+		st.Users = []AutoStartUser{}
+	}
+	c.JSON(http.StatusOK, st)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAutoStartOrder(t *testing.T) {
+	users := []UserConfig{
+		{ID: "a", AutoStart: true},
+		{ID: "b", AutoStart: true, StartPriority: 10},
+		{ID: "c"},
+		{ID: "d", AutoStart: true, StartPriority: -5},
+		{ID: "e", AutoStart: true, StartPriority: 10},
+	}
+	var ids []string
+	for _, u := range autoStartOrder(users) {
+		ids = append(ids, u.ID)
+	}
+	if !slices.Equal(ids, []string{"b", "e", "a", "d"}) {
+		t.Fatalf("启动顺序不符: %v", ids)
+	}
+	if validateStartPriority(maxStartPriority+1) == nil {
+		t.Fatal("超出范围的优先级应被拒绝")
+	}
+}
+
+func TestRunAutoStart(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+
+	var users []UserConfig
+	for _, id := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		users = append(users, UserConfig{ID: id, AutoStart: true})
+	}
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	var order []string
+	app.runAutoStart(users, AutoStartConfig{Concurrency: 2, Timeout: time.Second}, func(ctx context.Context, u UserConfig) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		order = append(order, u.ID)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		if u.ID == "u3" {
+			return errors.New("端口被占用")
+		}
+		return nil
+	})
+	if peak.Load() != 2 {
+		t.Fatalf("并发上限应为 2，实际峰值 %d", peak.Load())
+	}
+	if order[0] != "u1" && order[1] != "u1" {
+		t.Fatalf("应按顺序分发: %v", order)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/autostart", app.GetAutoStart)
+	w := doReq(r, http.MethodGet, "/autostart", "", nil)
+	var st AutoStartStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.State != "done" || st.Total != 5 || st.Started != 4 || st.Failed != 1 || st.Pending != 0 || st.FinishedAt == nil {
+		t.Fatalf("进度不符: %+v", st)
+	}
+	if st.Users[2].State != autoStartFailed || st.Users[2].Error == "" {
+		t.Fatalf("失败原因未记录: %+v", st.Users[2])
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 整机备份与恢复：内容与部署状态导出包相同（manager 配置即 users.json 全部内容、各账号 cookies 与运行状态），
+// 但不加密，直接以 tar.gz 流式返回，便于脚本定期备份或迁移到新主机。
+// 备份中的 cookies 为明文，仅 admin 可调用，请妥善保管备份文件。
+
+// Backup 下载 tar.gz 备份
+// GET /api/admin/v1/backup
+func (a *App) Backup(c *gin.Context) {
+	name := fmt.Sprintf("xhs-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "application/gzip")
+	c.Status(http.StatusOK)
+	// 响应头已发出，中途失败只能截断输出；截断的备份无法通过 gzip 校验，恢复时会被拒绝
+	if err := a.writeBundleArchive(c.Writer); err != nil {
+		fmt.Printf("生成备份失败: %v\n", err)
+		c.Abort()
+	}
+}
+
+// Restore 从 Backup 生成的 tar.gz 恢复：先校验全部条目再写入，校验失败不做任何修改。
+// 已有账号时需 ?replace=true；所有账号必须处于停止状态。bin 与 data_dir 保留本机配置。
+// POST /api/admin/v1/restore
+func (a *App) Restore(c *gin.Context) {
+	raw, ok := a.readRestoreBody(c)
+	if !ok {
+		return
+	}
+	users, files, err := a.restoreBundleArchive(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"restored": true,
+		"users":    users,
+		"files":    files,
+	})
+}
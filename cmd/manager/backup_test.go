@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBackupRestore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "alice", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+	paths := app.proc.DerivePaths(store.ResolveDataDir(), "alice", 18060)
+	writeTestFile(t, paths.CookiesPath, `[{"name":"web_session"}]`)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/backup", app.Backup)
+	w := doReq(r, http.MethodGet, "/backup", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("备份失败: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	backup := w.Body.String()
+
+	// 在新主机（新目录）恢复
+	t.Chdir(t.TempDir())
+	fresh, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := NewApp(fresh, NewProcessManager(), "")
+	r = gin.New()
+	r.POST("/restore", target.Restore)
+	if w := doReq(r, http.MethodPost, "/restore", "not a tarball", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法备份应返回 400，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/restore", backup, nil); w.Code != http.StatusOK {
+		t.Fatalf("恢复失败: %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := fresh.GetUser("alice"); !ok {
+		t.Fatal("账号应已恢复")
+	}
+	newPaths := target.proc.DerivePaths(fresh.ResolveDataDir(), "alice", 18060)
+	if data, err := os.ReadFile(newPaths.CookiesPath); err != nil || string(data) != `[{"name":"web_session"}]` {
+		t.Fatalf("cookies 未恢复: %q %v", data, err)
+	}
+	// 已有账号时需显式确认覆盖
+	if w := doReq(r, http.MethodPost, "/restore", backup, nil); w.Code != http.StatusConflict {
+		t.Fatalf("已有账号时应返回 409，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/restore?replace=true", backup, nil); w.Code != http.StatusOK {
+		t.Fatalf("replace 恢复失败: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 部署状态导出包：manager 配置、各账号 cookies 与运行状态目录（定时任务、发布历史、
+// 调用记录等），不含浏览器用户数据目录与日志。
+// 内容为 tar.gz，整体用口令派生的 AES-256-GCM 密钥加密：
+//
+//	magic(8) | salt(16) | nonce(12) | ciphertext
+const (
+	bundleMagic      = "XHSBNDL1"
+	bundleSaltSize   = 16
+	bundleKDFIter    = 200_000
+	bundleMinPass    = 8
+	maxBundleSize    = 256 << 20
+	bundleConfigName = "manager.json"
+)
+
+// maxBundleUnpacked 导出包解压后的总大小上限，防止压缩炸弹耗尽内存
+var maxBundleUnpacked int64 = 512 << 20
+
+// 加密包相关错误
+var (
+	errBundleFormat     = errors.New("不是有效的导出包")
+	errBundlePassphrase = errors.New("口令错误或导出包已损坏")
+)
+
+// bundleManifest 导出包说明
+type bundleManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Users     []string  `json:"users"`
+}
+
+type exportBundleReq struct {
+	Passphrase string `json:"passphrase" binding:"required"`
+}
+
+// ExportBundle 导出加密的部署状态包
+// POST /api/admin/v1/bundle/export
+func (a *App) ExportBundle(c *gin.Context) {
+	var req exportBundleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 passphrase"})
+		return
+	}
+	if len(req.Passphrase) < bundleMinPass {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("口令至少 %d 个字符", bundleMinPass)})
+		return
+	}
+
+	archive, err := a.buildBundleArchive()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sealed, err := sealBundle(archive, req.Passphrase)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("xhs-bundle-%s.bin", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/octet-stream", sealed)
+}
+
+// ImportBundle 在新部署上导入状态包：请求体为导出包，口令通过 X-Bundle-Passphrase 传入。
+// 已有账号时需 ?replace=true；所有账号必须处于停止状态。
+// bin 与 data_dir 保留本机配置，其余配置与账号以导出包为准。
+// POST /api/admin/v1/bundle/import
+func (a *App) ImportBundle(c *gin.Context) {
+	passphrase := c.GetHeader("X-Bundle-Passphrase")
+	if passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 X-Bundle-Passphrase 请求头"})
+		return
+	}
+	raw, ok := a.readRestoreBody(c)
+	if !ok {
+		return
+	}
+	archive, err := openBundle(raw, passphrase)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, files, err := a.restoreBundleArchive(archive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"imported": true,
+		"users":    users,
+		"files":    files,
+	})
+}
+
+// readRestoreBody 检查能否覆盖当前部署（账号均已停止，已有账号时需 ?replace=true）并读取请求体。
+// 失败时已写入响应
+func (a *App) readRestoreBody(c *gin.Context) ([]byte, bool) {
+	for _, u := range a.store.ListUsers() {
+		if a.proc.GetStatus(u.ID).Running {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("请先停止所有账号（%s 运行中）", u.ID)})
+			return nil, false
+		}
+	}
+	if len(a.store.ListUsers()) > 0 && c.Query("replace") != "true" {
+		c.JSON(http.StatusConflict, gin.H{"error": "当前已有账号，确认覆盖请使用 ?replace=true"})
+		return nil, false
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBundleSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+		return nil, false
+	}
+	if len(raw) > maxBundleSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "导出包过大"})
+		return nil, false
+	}
+	return raw, true
+}
+
+// buildBundleArchive 打包 manager 配置与各账号的 cookies、运行状态
+func (a *App) buildBundleArchive() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := a.writeBundleArchive(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBundleArchive 将 tar.gz 打包内容写入 w
+func (a *App) writeBundleArchive(w io.Writer) error {
+	cfg := a.store.GetConfig()
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte, mtime time.Time) error {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: mtime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	manifest := bundleManifest{Version: 1, CreatedAt: time.Now()}
+	for _, u := range cfg.Users {
+		manifest.Users = append(manifest.Users, u.ID)
+	}
+	for name, v := range map[string]any{bundleConfigName: cfg, "manifest.json": manifest} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := add(name, data, manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range cfg.Users {
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+		prefix := "users/" + u.ID + "/"
+		// 导出包已用口令加密，cookies 以明文打包，新部署可使用不同的 cookies 密钥
+		if data, err := readCookieFile(paths.CookiesPath); err != nil {
+			return fmt.Errorf("读取 %s cookies 失败: %w", u.ID, err)
+		} else if data != nil {
+			if err := add(prefix+"cookies.json", data, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		err := filepath.WalkDir(paths.StateDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !isBundleStateFile(d.Name()) {
+				return nil
+			}
+			rel, err := filepath.Rel(paths.StateDir, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return add(prefix+"state/"+filepath.ToSlash(rel), data, info.ModTime())
+		})
+		if err != nil {
+			return fmt.Errorf("读取 %s 运行状态失败: %w", u.ID, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// isBundleStateFile 跳过锁文件与写入中的临时文件
+func isBundleStateFile(name string) bool {
+	return !strings.HasSuffix(name, ".lock") && !strings.HasSuffix(name, ".tmp")
+}
+
+// restoreBundleArchive 校验并写回导出包内容，返回导入的账号与文件数
+func (a *App) restoreBundleArchive(archive []byte) ([]string, int, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, 0, errBundleFormat
+	}
+	unpacked := &io.LimitedReader{R: gz, N: maxBundleUnpacked + 1}
+	tr := tar.NewReader(unpacked)
+	tooLarge := fmt.Errorf("导出包解压后超过 %d MB", maxBundleUnpacked>>20)
+
+	entries := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if unpacked.N <= 0 {
+			return nil, 0, tooLarge
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, errBundleFormat
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if unpacked.N <= 0 {
+			return nil, 0, tooLarge
+		}
+		if err != nil {
+			return nil, 0, errBundleFormat
+		}
+		entries[hdr.Name] = data
+	}
+
+	raw, ok := entries[bundleConfigName]
+	if !ok {
+		return nil, 0, fmt.Errorf("导出包缺少 %s", bundleConfigName)
+	}
+	cfg, err := decodeConfig(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	known := map[string]UserConfig{}
+	for _, u := range cfg.Users {
+		// 导出包中的 data_dir 是导出方的本机路径，按本机数据目录写入（ImportConfig 同样会清除）
+		u.DataDir = ""
+		known[u.ID] = u
+	}
+
+	// 先校验全部路径，避免写到一半才发现非法条目
+	type restoreFile struct {
+		dest string
+		data []byte
+	}
+	var files []restoreFile
+	for name, data := range entries {
+		if name == bundleConfigName || name == "manifest.json" {
+			continue
+		}
+		id, rest, ok := strings.Cut(strings.TrimPrefix(name, "users/"), "/")
+		u, found := known[id]
+		if !strings.HasPrefix(name, "users/") || !ok || !found {
+			return nil, 0, fmt.Errorf("导出包包含未知条目: %s", name)
+		}
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+		switch {
+		case rest == "cookies.json":
+			sealed, err := cookies.Encrypt(data)
+			if err != nil {
+				return nil, 0, err
+			}
+			files = append(files, restoreFile{paths.CookiesPath, sealed})
+		case strings.HasPrefix(rest, "state/"):
+			rel := path.Clean(strings.TrimPrefix(rest, "state/"))
+			if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+				return nil, 0, fmt.Errorf("导出包包含非法路径: %s", name)
+			}
+			files = append(files, restoreFile{filepath.Join(paths.StateDir, filepath.FromSlash(rel)), data})
+		default:
+			return nil, 0, fmt.Errorf("导出包包含未知条目: %s", name)
+		}
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.dest), 0755); err != nil {
+			return nil, 0, fmt.Errorf("创建目录失败: %w", err)
+		}
+		if err := fileutil.WriteFileAtomic(f.dest, f.data, 0644); err != nil {
+			return nil, 0, fmt.Errorf("写入 %s 失败: %w", f.dest, err)
+		}
+	}
+	if err := a.store.ImportConfig(cfg); err != nil {
+		return nil, 0, err
+	}
+	a.proc.SetLogRotation(a.store.LogRotation())
+	a.ApplyWebhooks()
+
+	ids := make([]string, 0, len(cfg.Users))
+	for _, u := range cfg.Users {
+		ids = append(ids, u.ID)
+	}
+	return ids, len(files), nil
+}
+
+// sealBundle 用口令加密导出包
+func sealBundle(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(bundleMagic)+len(salt)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, bundleMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(bundleMagic)), nil
+}
+
+// openBundle 解密导出包
+func openBundle(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < len(bundleMagic)+bundleSaltSize || string(data[:len(bundleMagic)]) != bundleMagic {
+		return nil, errBundleFormat
+	}
+	data = data[len(bundleMagic):]
+	salt, data := data[:bundleSaltSize], data[bundleSaltSize:]
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errBundleFormat
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(bundleMagic))
+	if err != nil {
+		return nil, errBundlePassphrase
+	}
+	return plain, nil
+}
+
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleKDFIter, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	t.Chdir(src)
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "alice", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+	paths := app.proc.DerivePaths(store.ResolveDataDir(), "alice", 18060)
+	writeTestFile(t, paths.CookiesPath, `[{"name":"web_session"}]`)
+	writeTestFile(t, filepath.Join(paths.StateDir, "jobs.json"), `[]`)
+	writeTestFile(t, filepath.Join(paths.StateDir, "jobs.json.lock"), "")
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "Default", "Preferences"), "{}")
+
+	archive, err := app.buildBundleArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := sealBundle(archive, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openBundle(sealed, "wrong pass"); !errors.Is(err, errBundlePassphrase) {
+		t.Fatalf("错误口令应解密失败，got %v", err)
+	}
+	opened, err := openBundle(sealed, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 在新目录导入
+	dst := t.TempDir()
+	t.Chdir(dst)
+	fresh, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := NewApp(fresh, NewProcessManager(), "")
+	users, files, err := target.restoreBundleArchive(opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0] != "alice" || files != 2 {
+		t.Fatalf("导入结果不符: users=%v files=%d", users, files)
+	}
+	if _, ok := fresh.GetUser("alice"); !ok {
+		t.Fatal("账号应已导入")
+	}
+	newPaths := target.proc.DerivePaths(fresh.ResolveDataDir(), "alice", 18060)
+	if data, err := os.ReadFile(newPaths.CookiesPath); err != nil || string(data) != `[{"name":"web_session"}]` {
+		t.Fatalf("cookies 未恢复: %q %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(newPaths.StateDir, "jobs.json")); err != nil {
+		t.Fatalf("运行状态未恢复: %v", err)
+	}
+	if _, err := os.Stat(newPaths.UserDataDir); !os.IsNotExist(err) {
+		t.Fatal("浏览器用户数据目录不应导出")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestArchive 生成 tar.gz 导出包内容
+func writeTestArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBundleRestoreIgnoresForeignDataDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+	foreign := filepath.Join(t.TempDir(), "foreign")
+	cfg, _ := json.Marshal(ManagerConfig{Users: []UserConfig{{ID: "alice", Port: 18060, DataDir: foreign}}})
+	archive := writeTestArchive(t, map[string]string{
+		bundleConfigName:              string(cfg),
+		"users/alice/cookies.json":    `[{"name":"web_session"}]`,
+		"users/alice/state/jobs.json": `[]`,
+	})
+
+	if _, _, err := app.restoreBundleArchive(archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(foreign); !os.IsNotExist(err) {
+		t.Fatalf("不应写入导出包中的 data_dir: %v", err)
+	}
+	u, _ := store.GetUser("alice")
+	paths := app.proc.DerivePaths(store.UserDataDir(u), "alice", 18060)
+	if _, err := os.Stat(paths.CookiesPath); err != nil {
+		t.Fatalf("cookies 应写入本机数据目录: %v", err)
+	}
+}
+
+func TestBundleRestoreRejectsOversizedArchive(t *testing.T) {
+	prev := maxBundleUnpacked
+	maxBundleUnpacked = 1 << 20
+	t.Cleanup(func() { maxBundleUnpacked = prev })
+
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+	archive := writeTestArchive(t, map[string]string{
+		bundleConfigName:              `{"users":[{"id":"alice","port":18060}]}`,
+		"users/alice/state/bomb.json": strings.Repeat("0", 2<<20),
+	})
+	if len(archive) > 64<<10 {
+		t.Fatalf("压缩后应远小于解压大小: %d", len(archive))
+	}
+	if _, _, err := app.restoreBundleArchive(archive); err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Fatalf("应拒绝解压后过大的导出包: %v", err)
+	}
+	if _, ok := store.GetUser("alice"); ok {
+		t.Fatal("拒绝后不应导入账号")
+	}
+}
@@ -0,0 +1,629 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 内容日历：manager 保存计划发布的笔记（账号、标题、正文、图片或视频、发布时间），到点调用对应实例的
+// publish_content / publish_with_video 发布并记录状态：
+//
+//	pending → publishing → published
+//	             ↘ 失败后按退避重新进入 pending，失败次数达到 max_attempts 后为 failed
+//
+// 图片与视频可使用媒体库引用（media:<ID>），发布时传输到实例。同一账号同一时间只发布一篇。
+// 计划保存在 manager 本地目录的 posts.json 中。manager 在发布过程中退出时无法确认笔记是否已发出，
+// 这类计划重启后标记为 failed，确认后再手动重试，避免重复发布。
+
+// 计划状态
+const (
+	PostPending    = "pending"
+	PostPublishing = "publishing"
+	PostPublished  = "published"
+	PostFailed     = "failed"
+	PostCanceled   = "canceled"
+)
+
+var postStatuses = []string{PostPending, PostPublishing, PostPublished, PostFailed, PostCanceled}
+
+const (
+	postsFileName          = "posts.json"
+	defaultPostMaxAttempts = 3
+	maxPostMaxAttempts     = 10
+	maxPostRetryDelay      = 30 * time.Minute
+)
+
+// postTick 检查到期计划的间隔
+var postTick = 15 * time.Second
+
+// PostContent 笔记内容，字段与实例的发布工具参数一致
+type PostContent struct {
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	Images     []string `json:"images,omitempty"`
+	Video      string   `json:"video,omitempty"` // 与 images 二选一
+	Tags       []string `json:"tags,omitempty"`
+	Visibility string   `json:"visibility,omitempty"`
+}
+
+// PlannedPost 计划发布的笔记
+type PlannedPost struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	PostContent
+	PublishAt     time.Time  `json:"publish_at"`
+	MaxAttempts   int        `json:"max_attempts"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // 失败后的下次重试时间
+	LastError     string     `json:"last_error,omitempty"`
+	Result        string     `json:"result,omitempty"` // 发布工具返回的文本
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// dueAt 下次应发布的时间
+func (p *PlannedPost) dueAt() time.Time {
+	if p.NextAttemptAt != nil {
+		return *p.NextAttemptAt
+	}
+	return p.PublishAt
+}
+
+// toolCall 发布使用的工具与参数
+func (p *PlannedPost) toolCall() (string, map[string]any) {
+	args := map[string]any{"title": p.Title, "content": p.Content}
+	if len(p.Tags) > 0 {
+		args["tags"] = stringsToAny(p.Tags)
+	}
+	if p.Visibility != "" {
+		args["visibility"] = p.Visibility
+	}
+	if p.Video != "" {
+		args["video"] = p.Video
+		return "publish_with_video", args
+	}
+	args["images"] = stringsToAny(p.Images)
+	return "publish_content", args
+}
+
+func stringsToAny(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+// postRetryDelay 第 attempts 次失败后的重试间隔：1 分钟起每次翻倍，最长 30 分钟
+func postRetryDelay(attempts int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempts; i++ {
+		if d *= 2; d >= maxPostRetryDelay {
+			return maxPostRetryDelay
+		}
+	}
+	return d
+}
+
+var errPostNotFound = errors.New("计划不存在")
+
+// postStateError 计划当前的状态不允许该操作
+type postStateError struct{ status string }
+
+func (e postStateError) Error() string {
+	return fmt.Sprintf("计划当前为 %s，不能执行该操作", e.status)
+}
+
+type postsFile struct {
+	Posts []*PlannedPost `json:"posts"`
+}
+
+// postCalendar 计划的存储，首次使用时从文件加载
+type postCalendar struct {
+	mu     sync.Mutex
+	path   string
+	posts  map[string]*PlannedPost
+	loaded bool
+}
+
+func newPostCalendar(path string) *postCalendar {
+	return &postCalendar{path: path}
+}
+
+func (pc *postCalendar) loadLocked() error {
+	if pc.loaded {
+		return nil
+	}
+	posts := map[string]*PlannedPost{}
+	raw, err := os.ReadFile(pc.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取内容日历失败: %w", err)
+	}
+	if err == nil {
+		var f postsFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return fmt.Errorf("解析 %s 失败: %w", pc.path, err)
+		}
+		for _, p := range f.Posts {
+			if p.Status == PostPublishing {
+				p.Status = PostFailed
+				p.LastError = "manager 在发布过程中退出，无法确认是否已发布，请确认后重试"
+			}
+			posts[p.ID] = p
+		}
+	}
+	pc.posts, pc.loaded = posts, true
+	return nil
+}
+
+func (pc *postCalendar) saveLocked() error {
+	f := postsFile{Posts: make([]*PlannedPost, 0, len(pc.posts))}
+	for _, p := range pc.posts {
+		f.Posts = append(f.Posts, p)
+	}
+	sortPosts(f.Posts)
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(pc.path, raw, 0600); err != nil {
+		return fmt.Errorf("保存内容日历失败: %w", err)
+	}
+	return nil
+}
+
+// sortPosts 按发布时间排序
+func sortPosts(posts []*PlannedPost) {
+	slices.SortFunc(posts, func(x, y *PlannedPost) int {
+		if r := x.PublishAt.Compare(y.PublishAt); r != 0 {
+			return r
+		}
+		return strings.Compare(x.ID, y.ID)
+	})
+}
+
+// list 按发布时间列出计划，userID、status 为空时不过滤
+func (pc *postCalendar) list(userID, status string) ([]PlannedPost, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err := pc.loadLocked(); err != nil {
+		return nil, err
+	}
+	ptrs := make([]*PlannedPost, 0, len(pc.posts))
+	for _, p := range pc.posts {
+		if (userID == "" || p.UserID == userID) && (status == "" || p.Status == status) {
+			ptrs = append(ptrs, p)
+		}
+	}
+	sortPosts(ptrs)
+	out := make([]PlannedPost, len(ptrs))
+	for i, p := range ptrs {
+		out[i] = *p
+	}
+	return out, nil
+}
+
+func (pc *postCalendar) get(id string) (PlannedPost, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err := pc.loadLocked(); err != nil {
+		return PlannedPost{}, err
+	}
+	p, ok := pc.posts[id]
+	if !ok {
+		return PlannedPost{}, errPostNotFound
+	}
+	return *p, nil
+}
+
+func (pc *postCalendar) add(p PlannedPost) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err := pc.loadLocked(); err != nil {
+		return err
+	}
+	pc.posts[p.ID] = &p
+	if err := pc.saveLocked(); err != nil {
+		delete(pc.posts, p.ID)
+		return err
+	}
+	return nil
+}
+
+// update 修改计划，fn 返回错误时不保存；fn 返回 nil 的 *PlannedPost 表示删除
+func (pc *postCalendar) update(id string, fn func(p PlannedPost) (*PlannedPost, error)) (PlannedPost, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err := pc.loadLocked(); err != nil {
+		return PlannedPost{}, err
+	}
+	old, ok := pc.posts[id]
+	if !ok {
+		return PlannedPost{}, errPostNotFound
+	}
+	next, err := fn(*old)
+	if err != nil {
+		return PlannedPost{}, err
+	}
+	if next == nil {
+		delete(pc.posts, id)
+	} else {
+		pc.posts[id] = next
+	}
+	if err := pc.saveLocked(); err != nil {
+		pc.posts[id] = old
+		return PlannedPost{}, err
+	}
+	if next == nil {
+		return *old, nil
+	}
+	return *next, nil
+}
+
+// due 取出到期的计划并标记为发布中，每个账号最多一篇
+func (pc *postCalendar) due(now time.Time) ([]PlannedPost, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err := pc.loadLocked(); err != nil {
+		return nil, err
+	}
+	busy := map[string]bool{}
+	var pending []*PlannedPost
+	for _, p := range pc.posts {
+		switch {
+		case p.Status == PostPublishing:
+			busy[p.UserID] = true
+		case p.Status == PostPending && !p.dueAt().After(now):
+			pending = append(pending, p)
+		}
+	}
+	slices.SortFunc(pending, func(x, y *PlannedPost) int { return x.dueAt().Compare(y.dueAt()) })
+
+	var out []PlannedPost
+	for _, p := range pending {
+		if busy[p.UserID] {
+			continue
+		}
+		busy[p.UserID] = true
+		p.Status = PostPublishing
+		p.Attempts++
+		p.UpdatedAt = now
+		out = append(out, *p)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	if err := pc.saveLocked(); err != nil {
+		for _, p := range out {
+			q := pc.posts[p.ID]
+			q.Status, q.Attempts = PostPending, q.Attempts-1
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// finish 记录一次发布的结果：成功为 published，失败时未达到次数上限则安排重试，否则为 failed
+func (pc *postCalendar) finish(id, result string, pubErr error, now time.Time) (PlannedPost, error) {
+	return pc.update(id, func(p PlannedPost) (*PlannedPost, error) {
+		if p.Status != PostPublishing {
+			return nil, postStateError{p.Status}
+		}
+		p.UpdatedAt, p.NextAttemptAt = now, nil
+		switch {
+		case pubErr == nil:
+			p.Status, p.Result, p.LastError, p.PublishedAt = PostPublished, result, "", &now
+		case p.Attempts >= p.MaxAttempts:
+			p.Status, p.LastError = PostFailed, pubErr.Error()
+		default:
+			next := now.Add(postRetryDelay(p.Attempts))
+			p.Status, p.LastError, p.NextAttemptAt = PostPending, pubErr.Error(), &next
+		}
+		return &p, nil
+	})
+}
+
+// StartPostScheduler 启动内容日历的发布调度
+func (a *App) StartPostScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(postTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.dispatchPosts(ctx, now)
+			}
+		}
+	}()
+}
+
+// dispatchPosts 发布到期的计划，每篇在单独的 goroutine 中进行
+func (a *App) dispatchPosts(ctx context.Context, now time.Time) {
+	due, err := a.posts.due(now)
+	if err != nil {
+		fmt.Printf("内容日历调度失败: %v\n", err)
+		return
+	}
+	for _, p := range due {
+		go func() {
+			fmt.Printf("用户 %s 开始发布计划 %s（第 %d 次）\n", p.UserID, p.ID, p.Attempts)
+			result, err := a.publishPlannedPost(ctx, p)
+			if ctx.Err() != nil {
+				return // manager 退出，保持发布中，重启后按结果未知处理
+			}
+			done, ferr := a.posts.finish(p.ID, result, err, time.Now())
+			if ferr != nil {
+				fmt.Printf("记录计划 %s 的发布结果失败: %v\n", p.ID, ferr)
+				return
+			}
+			switch done.Status {
+			case PostPublished:
+				fmt.Printf("用户 %s 的计划 %s 已发布\n", p.UserID, p.ID)
+				a.proc.publish(eventPostPublished, fmt.Sprintf("用户 %s 的计划 %s 已发布", p.UserID, p.ID),
+					ProcessEvent{User: p.UserID, Post: p.ID})
+			case PostFailed:
+				fmt.Printf("用户 %s 的计划 %s 发布失败: %v\n", p.UserID, p.ID, err)
+				a.proc.publish(eventPostFailed, fmt.Sprintf("用户 %s 的计划 %s 发布失败: %v", p.UserID, p.ID, err),
+					ProcessEvent{User: p.UserID, Post: p.ID, Error: err.Error()})
+			default:
+				fmt.Printf("用户 %s 的计划 %s 发布失败，%s 后重试: %v\n", p.UserID, p.ID, done.NextAttemptAt.Format(time.DateTime), err)
+			}
+		}()
+	}
+}
+
+// publishPlannedPost 调用实例的发布工具，返回工具输出的文本
+func (a *App) publishPlannedPost(ctx context.Context, p PlannedPost) (string, error) {
+	u, ok := a.store.GetUser(p.UserID)
+	if !ok {
+		return "", errors.New("用户不存在")
+	}
+	if !a.proc.GetStatus(u.ID).Running {
+		return "", errors.New("用户进程未运行")
+	}
+	ep := a.endpoint(u)
+	if !a.proc.CheckHealth(ep, 800*time.Millisecond) {
+		return "", errors.New("用户实例健康检查失败")
+	}
+	name, args := p.toolCall()
+	args, err := a.mediaArguments(u, args)
+	if err != nil {
+		return "", err
+	}
+	res, err := a.callMCPTool(ctx, ep, name, args, normalizeMCPCallTimeout(name, 0))
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(res.Content))
+	for _, c := range res.Content {
+		if c.Text != "" {
+			texts = append(texts, c.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if res.IsError {
+		return "", fmt.Errorf("发布工具返回错误: %s", text)
+	}
+	return text, nil
+}
+
+// plannedPostReq 创建或修改计划的请求
+type plannedPostReq struct {
+	UserID string `json:"user_id"`
+	PostContent
+	PublishAt   string `json:"publish_at"`             // RFC3339；不带时区偏移时按账号时区的当地时间
+	MaxAttempts int    `json:"max_attempts,omitempty"` // 最多尝试次数，默认 3
+}
+
+// parsePublishAt 解析发布时间，不带时区偏移时按 loc 解析
+func parsePublishAt(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", time.DateTime, "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("publish_at 格式应为 RFC3339，如 2024-01-20T10:30:00+08:00: %q", s)
+}
+
+// buildPlannedPost 校验请求并生成计划内容（不含 ID 与状态）
+func (a *App) buildPlannedPost(req plannedPostReq) (PlannedPost, error) {
+	var p PlannedPost
+	u, ok := a.store.GetUser(strings.TrimSpace(req.UserID))
+	if !ok {
+		return p, errors.New("user_id 对应的用户不存在")
+	}
+	c := req.PostContent
+	c.Title, c.Video = strings.TrimSpace(c.Title), strings.TrimSpace(c.Video)
+	if c.Title == "" {
+		return p, errors.New("title 不能为空")
+	}
+	switch {
+	case len(c.Images) == 0 && c.Video == "":
+		return p, errors.New("需要 images 或 video")
+	case len(c.Images) > 0 && c.Video != "":
+		return p, errors.New("images 与 video 只能二选一")
+	}
+	for _, ref := range append(slices.Clone(c.Images), c.Video) {
+		if id, ok := strings.CutPrefix(strings.TrimSpace(ref), mediaRefPrefix); ok {
+			if _, err := readMedia(a.mediaDir(), id); err != nil {
+				return p, fmt.Errorf("媒体 %s 不存在", id)
+			}
+		}
+	}
+	at, err := parsePublishAt(req.PublishAt, userLocation(u))
+	if err != nil {
+		return p, err
+	}
+	if req.MaxAttempts == 0 {
+		req.MaxAttempts = defaultPostMaxAttempts
+	}
+	if req.MaxAttempts < 1 || req.MaxAttempts > maxPostMaxAttempts {
+		return p, fmt.Errorf("max_attempts 范围为 1~%d", maxPostMaxAttempts)
+	}
+	return PlannedPost{UserID: u.ID, PostContent: c, PublishAt: at, MaxAttempts: req.MaxAttempts}, nil
+}
+
+// postError 按错误类型写入响应
+func postError(c *gin.Context, err error) {
+	var stateErr postStateError
+	switch {
+	case errors.Is(err, errPostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &stateErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListPosts 列出计划，可用 ?user=id、?status= 过滤
+// GET /api/admin/v1/posts
+func (a *App) ListPosts(c *gin.Context) {
+	status := strings.TrimSpace(c.Query("status"))
+	if status != "" && !slices.Contains(postStatuses, status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("status 可选 %s", strings.Join(postStatuses, "、"))})
+		return
+	}
+	posts, err := a.posts.list(strings.TrimSpace(c.Query("user")), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "count": len(posts)})
+}
+
+// CreatePost 添加计划
+// POST /api/admin/v1/posts
+func (a *App) CreatePost(c *gin.Context) {
+	var req plannedPostReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	p, err := a.buildPlannedPost(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := generateToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	p.ID = now.Format(snapshotTimeLayout) + "-" + token[:6]
+	p.Status, p.CreatedAt, p.UpdatedAt = PostPending, now, now
+	if err := a.posts.add(p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fmt.Printf("用户 %s 新增计划 %s，发布时间 %s\n", p.UserID, p.ID, p.PublishAt.Format(time.RFC3339))
+	c.JSON(http.StatusCreated, p)
+}
+
+// GetPost 查看计划
+// GET /api/admin/v1/posts/:pid
+func (a *App) GetPost(c *gin.Context) {
+	p, err := a.posts.get(c.Param("pid"))
+	if err != nil {
+		postError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// UpdatePost 修改未发布的计划，修改后重新进入 pending 并清零尝试次数
+// PUT /api/admin/v1/posts/:pid
+func (a *App) UpdatePost(c *gin.Context) {
+	var req plannedPostReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	next, err := a.buildPlannedPost(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := a.posts.update(c.Param("pid"), func(p PlannedPost) (*PlannedPost, error) {
+		if p.Status == PostPublishing || p.Status == PostPublished {
+			return nil, postStateError{p.Status}
+		}
+		next.ID, next.CreatedAt, next.UpdatedAt, next.Status = p.ID, p.CreatedAt, time.Now(), PostPending
+		return &next, nil
+	})
+	if err != nil {
+		postError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// CancelPost 取消尚未发布的计划
+// POST /api/admin/v1/posts/:pid/cancel
+func (a *App) CancelPost(c *gin.Context) {
+	p, err := a.posts.update(c.Param("pid"), func(p PlannedPost) (*PlannedPost, error) {
+		if p.Status != PostPending {
+			return nil, postStateError{p.Status}
+		}
+		p.Status, p.NextAttemptAt, p.UpdatedAt = PostCanceled, nil, time.Now()
+		return &p, nil
+	})
+	if err != nil {
+		postError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// RetryPost 重新发布失败或已取消的计划，发布时间已过时在下一次检查时发布
+// POST /api/admin/v1/posts/:pid/retry
+func (a *App) RetryPost(c *gin.Context) {
+	p, err := a.posts.update(c.Param("pid"), func(p PlannedPost) (*PlannedPost, error) {
+		if p.Status != PostFailed && p.Status != PostCanceled {
+			return nil, postStateError{p.Status}
+		}
+		p.Status, p.Attempts, p.NextAttemptAt, p.UpdatedAt = PostPending, 0, nil, time.Now()
+		return &p, nil
+	})
+	if err != nil {
+		postError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// DeletePost 删除计划，发布中的计划不能删除
+// DELETE /api/admin/v1/posts/:pid
+func (a *App) DeletePost(c *gin.Context) {
+	p, err := a.posts.update(c.Param("pid"), func(p PlannedPost) (*PlannedPost, error) {
+		if p.Status == PostPublishing {
+			return nil, postStateError{p.Status}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		postError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": p.ID})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPostRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 6: 30 * time.Minute, 20: 30 * time.Minute} {
+		if got := postRetryDelay(attempts); got != want {
+			t.Errorf("postRetryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestPostCalendarDispatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), postsFileName)
+	pc := newPostCalendar(path)
+	now := time.Now()
+	for _, p := range []PlannedPost{
+		{ID: "a", UserID: "u1", PublishAt: now.Add(-time.Minute), MaxAttempts: 2, Status: PostPending},
+		{ID: "b", UserID: "u1", PublishAt: now, MaxAttempts: 2, Status: PostPending},
+		{ID: "c", UserID: "u2", PublishAt: now.Add(time.Hour), MaxAttempts: 2, Status: PostPending},
+	} {
+		if err := pc.add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 同一账号同一时间只发布一篇，未到时间的不发布
+	due, err := pc.due(now)
+	if err != nil || len(due) != 1 || due[0].ID != "a" || due[0].Status != PostPublishing || due[0].Attempts != 1 {
+		t.Fatalf("due = %+v, %v", due, err)
+	}
+	if due, _ := pc.due(now); len(due) != 0 {
+		t.Fatalf("账号发布中时不应再取出: %+v", due)
+	}
+
+	// 失败后按退避重试，达到次数上限后为 failed
+	p, err := pc.finish("a", "", errors.New("boom"), now)
+	if err != nil || p.Status != PostPending || p.NextAttemptAt == nil || !p.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("finish = %+v, %v", p, err)
+	}
+	if due, _ := pc.due(now.Add(30 * time.Second)); len(due) != 1 || due[0].ID != "b" {
+		t.Fatalf("重试时间未到，应先发布 b: %+v", due)
+	}
+	if p, _ := pc.finish("b", "发布成功", nil, now); p.Status != PostPublished || p.Result != "发布成功" || p.PublishedAt == nil {
+		t.Fatalf("b = %+v", p)
+	}
+	if due, _ := pc.due(now.Add(2 * time.Minute)); len(due) != 1 || due[0].ID != "a" || due[0].Attempts != 2 {
+		t.Fatalf("due = %+v", due)
+	}
+	if p, _ := pc.finish("a", "", errors.New("boom"), now); p.Status != PostFailed || p.LastError != "boom" {
+		t.Fatalf("a = %+v", p)
+	}
+
+	// 发布中时 manager 退出：重启后标记为 failed，不自动重试
+	if due, _ := pc.due(now.Add(2 * time.Hour)); len(due) != 1 || due[0].ID != "c" {
+		t.Fatalf("due = %+v", due)
+	}
+	reloaded := newPostCalendar(path)
+	if p, err := reloaded.get("c"); err != nil || p.Status != PostFailed || p.LastError == "" {
+		t.Fatalf("c = %+v, %v", p, err)
+	}
+	if posts, _ := reloaded.list("u1", ""); len(posts) != 2 || posts[0].ID != "a" {
+		t.Fatalf("list = %+v", posts)
+	}
+}
+
+func TestPostHandlers(t *testing.T) {
+	dir := t.TempDir()
+	store, err := LoadStore(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDataDir(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Timezone: "Asia/Shanghai"}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/posts", a.ListPosts)
+	r.POST("/posts", a.CreatePost)
+	r.GET("/posts/:pid", a.GetPost)
+	r.PUT("/posts/:pid", a.UpdatePost)
+	r.POST("/posts/:pid/cancel", a.CancelPost)
+	r.POST("/posts/:pid/retry", a.RetryPost)
+	r.DELETE("/posts/:pid", a.DeletePost)
+
+	for _, body := range []string{
+		`{"user_id":"nobody","title":"t","images":["/a.jpg"],"publish_at":"2030-01-01T10:00:00+08:00"}`,
+		`{"user_id":"u1","title":"t","publish_at":"2030-01-01T10:00:00+08:00"}`,
+		`{"user_id":"u1","title":"t","images":["/a.jpg"],"video":"/a.mp4","publish_at":"2030-01-01T10:00:00+08:00"}`,
+		`{"user_id":"u1","title":"t","images":["media:0000000000000000"],"publish_at":"2030-01-01T10:00:00+08:00"}`,
+		`{"user_id":"u1","title":"t","images":["/a.jpg"],"publish_at":"明天"}`,
+		`{"user_id":"u1","title":"t","images":["/a.jpg"],"publish_at":"2030-01-01T10:00:00+08:00","max_attempts":99}`,
+	} {
+		if w := doReq(r, http.MethodPost, "/posts", body, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: 应返回 400，got %d", body, w.Code)
+		}
+	}
+
+	// 不带时区偏移时按账号时区解析
+	w := doReq(r, http.MethodPost, "/posts", `{"user_id":"u1","title":"新品","content":"正文","images":["/a.jpg"],"tags":["美食"],"publish_at":"2030-01-01 10:00"}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var p PlannedPost
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2030, 1, 1, 2, 0, 0, 0, time.UTC); !p.PublishAt.Equal(want) || p.Status != PostPending || p.MaxAttempts != defaultPostMaxAttempts {
+		t.Fatalf("post = %+v", p)
+	}
+	if name, args := p.toolCall(); name != "publish_content" || len(args["images"].([]any)) != 1 || args["tags"].([]any)[0] != "美食" {
+		t.Fatalf("toolCall = %s %v", name, args)
+	}
+
+	if w := doReq(r, http.MethodPost, "/posts/"+p.ID+"/cancel", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/posts/"+p.ID+"/cancel", "", nil); w.Code != http.StatusConflict {
+		t.Fatalf("重复取消应返回 409，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/posts/"+p.ID+"/retry", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("retry: %d", w.Code)
+	}
+	w = doReq(r, http.MethodPut, "/posts/"+p.ID, `{"user_id":"u1","title":"改标题","video":"/a.mp4","publish_at":"2030-01-02T10:00:00+08:00"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	var list struct{ Count int }
+	if w := doReq(r, http.MethodGet, "/posts?status=pending&user=u1", "", nil); json.Unmarshal(w.Body.Bytes(), &list) != nil || list.Count != 1 {
+		t.Fatalf("list: %s", w.Body.String())
+	}
+	got, _ := a.posts.get(p.ID)
+	if name, _ := got.toolCall(); got.Title != "改标题" || name != "publish_with_video" || !got.CreatedAt.Equal(p.CreatedAt) {
+		t.Fatalf("updated = %+v", got)
+	}
+	if w := doReq(r, http.MethodGet, "/posts?status=unknown", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("未知状态应返回 400，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodDelete, "/posts/"+p.ID, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/posts/"+p.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("删除后应返回 404，got %d", w.Code)
+	}
+}
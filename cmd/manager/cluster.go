@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 集群模式（-cluster）：多个 manager 共享同一 Postgres/Redis store（同一主机上也可共享 SQLite），
+// 通过 store 中的租约选出 leader，并记录每个账号的实例由哪个节点运行：
+//
+//   - node:<id>  节点心跳，值为节点的 -advertise 地址
+//   - leader     leader 负责没有归属的账号的定时启停与告警
+//   - user:<id>  账号归属：启动实例前获取，运行期间由所在节点续期，实例退出后释放
+//
+// 节点失联超过 -cluster-ttl 后租约过期，其他节点接管其中设置了自动启动的账号；
+// 节点无法续期超过 2/3 个 ttl 时主动停止本地实例，避免账号被接管后在两处同时运行。
+// 实例的数据目录需放在所有节点都能访问的共享存储上，各节点时钟需同步。
+
+// 租约名称
+const (
+	leaseLeader     = "leader"
+	leaseNodePrefix = "node:"
+	leaseUserPrefix = "user:"
+)
+
+const (
+	defaultClusterTTL    = 30 * time.Second
+	minClusterTTL        = 3 * time.Second
+	clusterFailoverTries = 3 // 接管同一账号的最多尝试次数
+	clusterFailoverBatch = 4 // 每轮最多接管的账号数，使账号分散到各节点
+)
+
+// clusterFailoverBackoff 接管失败后再次尝试同一账号的间隔
+var clusterFailoverBackoff = 2 * time.Minute
+
+// Lease store 中的一条租约
+type Lease struct {
+	Name      string    `json:"name"`
+	Holder    string    `json:"holder"`
+	Value     string    `json:"value,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// leaseBackend 支持租约的存储后端（SQLite、Postgres、Redis），集群模式使用
+type leaseBackend interface {
+	AcquireLease(name, holder, value string, ttl time.Duration) (bool, error) // 获取、续期，或接管已过期的租约
+	ReleaseLease(name, holder string) error                                   // 只释放自己持有的租约
+	ListLeases(prefix string) ([]Lease, error)                                // 未过期的租约
+}
+
+// ClusterConfig 集群模式参数
+type ClusterConfig struct {
+	Enabled   bool
+	NodeID    string        // 节点 ID，默认主机名
+	Advertise string        // 其他节点与客户端访问本节点的地址
+	TTL       time.Duration // 租约有效期，每 1/3 个 ttl 续期一次
+}
+
+// clusterOwnedError 账号的实例正由其他节点运行
+type clusterOwnedError struct {
+	User, Node, Addr string
+}
+
+func (e *clusterOwnedError) Error() string {
+	return fmt.Sprintf("账号 %s 正由节点 %s 运行", e.User, e.Node)
+}
+
+// failoverCandidate 所在节点失联、等待接管的账号
+type failoverCandidate struct {
+	node    string // 原节点
+	tries   int
+	lastTry time.Time
+}
+
+type clusterNode struct {
+	id, addr string
+	ttl      time.Duration
+	leases   leaseBackend
+	leader   atomic.Bool
+	leaseMu  sync.Mutex // 串行化账号租约的获取与释放
+
+	mu       sync.Mutex       // 保护以下字段
+	owners   map[string]Lease // 最近一次读取的账号归属
+	lastOK   time.Time        // 最近一次成功续期
+	fenced   bool             // 已因无法续期停止本地实例
+	failover map[string]*failoverCandidate
+}
+
+// EnableCluster 以集群模式运行：注册节点并在启动实例前获取账号归属
+func (a *App) EnableCluster(cfg ClusterConfig) error {
+	leases, ok := a.store.backend.(leaseBackend)
+	if !ok {
+		return fmt.Errorf("集群模式需要 sqlite、postgres 或 redis store")
+	}
+	if cfg.NodeID == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("获取主机名失败，请指定 -node-id: %w", err)
+		}
+		cfg.NodeID = host
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultClusterTTL
+	}
+	if cfg.TTL < minClusterTTL {
+		return fmt.Errorf("-cluster-ttl 最小 %s", minClusterTTL)
+	}
+	c := &clusterNode{
+		id:       cfg.NodeID,
+		addr:     cfg.Advertise,
+		ttl:      cfg.TTL,
+		leases:   leases,
+		owners:   map[string]Lease{},
+		failover: map[string]*failoverCandidate{},
+	}
+	ok, err := leases.AcquireLease(leaseNodePrefix+c.id, c.id, c.addr, c.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("节点 ID %s 已被其他 manager 使用", c.id)
+	}
+	c.lastOK = time.Now()
+	a.cluster = c
+	a.proc.SetClaim(c.claim)
+	if kube := a.proc.Kube(); kube != nil {
+		kube.cfg.NodeID = c.id
+	}
+	return nil
+}
+
+// claim 获取账号归属，由 ProcessManager 在启动实例前调用
+func (c *clusterNode) claim(userID string) error {
+	c.leaseMu.Lock()
+	defer c.leaseMu.Unlock()
+	name := leaseUserPrefix + userID
+	ok, err := c.leases.AcquireLease(name, c.id, c.addr, c.ttl)
+	if err != nil {
+		return err
+	}
+	owner := Lease{Name: name, Holder: c.id, Value: c.addr, ExpiresAt: time.Now().Add(c.ttl)}
+	if !ok {
+		list, err := c.leases.ListLeases(name)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(list, func(l Lease) bool { return l.Name == name })
+		if i < 0 {
+			return fmt.Errorf("账号 %s 的归属刚发生变化，请重试", userID)
+		}
+		owner = list[i]
+	}
+	c.mu.Lock()
+	c.owners[userID] = owner
+	c.mu.Unlock()
+	if !ok {
+		return &clusterOwnedError{User: userID, Node: owner.Holder, Addr: owner.Value}
+	}
+	return nil
+}
+
+// leasedElsewhere 账号的租约是否由其他节点持有（直接查询共享存储），单机模式始终为 false
+func (a *App) leasedElsewhere(userID string) bool {
+	c := a.cluster
+	if c == nil {
+		return false
+	}
+	name := leaseUserPrefix + userID
+	list, err := c.leases.ListLeases(name)
+	if err != nil {
+		return true // 无法确认时保守处理，不删除
+	}
+	i := slices.IndexFunc(list, func(l Lease) bool { return l.Name == name })
+	return i >= 0 && list[i].Holder != c.id
+}
+
+// remoteOwner 账号由其他节点运行时返回该节点的租约
+func (c *clusterNode) remoteOwner(userID string) (Lease, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.owners[userID]
+	if !ok || l.Holder == c.id || time.Now().After(l.ExpiresAt) {
+		return Lease{}, false
+	}
+	return l, true
+}
+
+// manages 本节点是否负责该账号的定时启停与告警：实例在本节点运行，或没有归属且本节点是 leader
+func (a *App) manages(userID string) bool {
+	c := a.cluster
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	l, ok := c.owners[userID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(l.ExpiresAt) {
+		return l.Holder == c.id
+	}
+	return c.leader.Load()
+}
+
+// activeUsers 本节点占用中（启动中、运行中或停止中）的账号
+func (pm *ProcessManager) activeUsers() map[string]bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	out := map[string]bool{}
+	for id, p := range pm.procs {
+		if p != nil && (p.state.active() || p.alive()) {
+			out[id] = true
+		}
+	}
+	return out
+}
+
+// StartCluster 定期续期节点、leader 与账号租约，并接管失联节点上的账号
+func (a *App) StartCluster(ctx context.Context) {
+	if a.cluster == nil {
+		return
+	}
+	go func() {
+		a.clusterTick(ctx, time.Now())
+		ticker := time.NewTicker(a.cluster.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.clusterTick(ctx, now)
+			}
+		}
+	}()
+}
+
+func (a *App) clusterTick(ctx context.Context, now time.Time) {
+	c := a.cluster
+	if err := a.renewLeases(now); err != nil {
+		fmt.Printf("集群租约续期失败: %v\n", err)
+		c.mu.Lock()
+		fence := !c.fenced && now.Sub(c.lastOK) > c.ttl*2/3
+		if fence {
+			c.fenced = true
+			c.leader.Store(false)
+			clear(c.owners)
+		}
+		c.mu.Unlock()
+		if fence {
+			a.fenceLocalInstances(ctx)
+		}
+		return
+	}
+	a.runFailover(ctx, now)
+}
+
+// renewLeases 续期节点与 leader 租约，续期或释放账号租约，并刷新归属缓存
+func (a *App) renewLeases(now time.Time) error {
+	c := a.cluster
+	c.leaseMu.Lock()
+	defer c.leaseMu.Unlock()
+	if ok, err := c.leases.AcquireLease(leaseNodePrefix+c.id, c.id, c.addr, c.ttl); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("节点 ID %s 已被其他 manager 使用", c.id)
+	}
+	leader, err := c.leases.AcquireLease(leaseLeader, c.id, c.addr, c.ttl)
+	if err != nil {
+		return err
+	}
+	if c.leader.Swap(leader) != leader {
+		if leader {
+			fmt.Printf("节点 %s 成为集群 leader\n", c.id)
+		} else {
+			fmt.Printf("节点 %s 不再是集群 leader\n", c.id)
+		}
+	}
+
+	// 本地占用的账号续期；其他账号若仍由本节点持有（实例已退出）则释放
+	active := a.proc.activeUsers()
+	var lost []string
+	for id := range active {
+		ok, err := c.leases.AcquireLease(leaseUserPrefix+id, c.id, c.addr, c.ttl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			lost = append(lost, id)
+		}
+	}
+	c.mu.Lock()
+	prev := maps.Clone(c.owners)
+	c.mu.Unlock()
+	for id, l := range prev {
+		if l.Holder == c.id && !active[id] {
+			if err := c.leases.ReleaseLease(leaseUserPrefix+id, c.id); err != nil {
+				return err
+			}
+		}
+	}
+
+	nodes, err := c.leases.ListLeases(leaseNodePrefix)
+	if err != nil {
+		return err
+	}
+	users, err := c.leases.ListLeases(leaseUserPrefix)
+	if err != nil {
+		return err
+	}
+	alive := map[string]bool{}
+	for _, n := range nodes {
+		alive[n.Holder] = true
+	}
+	owners := make(map[string]Lease, len(users))
+	for _, l := range users {
+		owners[strings.TrimPrefix(l.Name, leaseUserPrefix)] = l
+	}
+	// 归属消失且原节点已失联的账号等待接管；原节点仍存活说明实例是正常停止或退出的
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, l := range prev {
+		if _, ok := owners[id]; !ok && l.Holder != c.id && !alive[l.Holder] && c.failover[id] == nil {
+			c.failover[id] = &failoverCandidate{node: l.Holder}
+		}
+	}
+	for id := range c.failover {
+		if _, ok := owners[id]; ok {
+			delete(c.failover, id)
+		}
+	}
+	c.owners = owners
+	c.lastOK = now
+	if c.fenced {
+		c.fenced = false
+		fmt.Printf("集群租约已恢复\n")
+	}
+
+	for _, id := range lost {
+		fmt.Printf("账号 %s 已被其他节点接管，停止本地实例\n", id)
+		go func() { _ = a.proc.StopUser(context.Background(), id, 0) }()
+	}
+	return nil
+}
+
+// fenceLocalInstances 无法续期时停止本地实例（不清除 auto_start），由其他节点接管
+func (a *App) fenceLocalInstances(ctx context.Context) {
+	ids := a.proc.activeUsers()
+	fmt.Printf("超过 %s 无法续期集群租约，停止本地 %d 个实例\n", a.cluster.ttl*2/3, len(ids))
+	for id := range ids {
+		go func() {
+			if err := a.proc.StopUser(ctx, id, 0); err != nil {
+				fmt.Printf("停止用户 %s 失败: %v\n", id, err)
+			}
+		}()
+	}
+}
+
+// runFailover 在本节点启动所在节点已失联、设置了自动启动的账号
+func (a *App) runFailover(ctx context.Context, now time.Time) {
+	c := a.cluster
+	active := a.proc.activeUsers()
+	var picked []UserConfig
+	c.mu.Lock()
+	for _, u := range autoStartOrder(a.store.ListUsers()) {
+		fc := c.failover[u.ID]
+		if fc == nil || active[u.ID] || now.Sub(fc.lastTry) < clusterFailoverBackoff {
+			continue
+		}
+		if len(picked) == clusterFailoverBatch {
+			break
+		}
+		fc.tries++
+		fc.lastTry = now
+		if fc.tries >= clusterFailoverTries {
+			delete(c.failover, u.ID)
+		}
+		picked = append(picked, u)
+	}
+	// 已删除或取消自动启动的账号不再接管
+	for id := range c.failover {
+		if u, ok := a.store.GetUser(id); !ok || !u.AutoStart {
+			delete(c.failover, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, u := range picked {
+		go func() {
+			startCtx, cancel := context.WithTimeout(ctx, a.proc.ReadyTimeout()+30*time.Second)
+			defer cancel()
+			cfg := a.store.GetConfig()
+			err := a.proc.StartUser(startCtx, StartUserParams{
+				User:     u,
+				BinPath:  a.store.ResolveBinPath(),
+				Headless: cfg.Headless,
+				DataDir:  a.store.UserDataDir(u),
+			})
+			var owned *clusterOwnedError
+			switch {
+			case errors.As(err, &owned):
+				// 其他节点先接管
+			case err != nil:
+				fmt.Printf("接管账号 %s 失败: %v\n", u.ID, err)
+				a.proc.publishAutoStartFailed(u.ID, triggerFailover, err)
+			default:
+				fmt.Printf("已接管账号 %s\n", u.ID)
+			}
+		}()
+	}
+}
+
+// LeaveCluster manager 退出时释放租约：先释放节点租约，其他节点随后接管本节点设置了自动启动的账号
+func (a *App) LeaveCluster() {
+	c := a.cluster
+	if c == nil {
+		return
+	}
+	c.leaseMu.Lock()
+	defer c.leaseMu.Unlock()
+	c.mu.Lock()
+	owners := maps.Clone(c.owners)
+	c.mu.Unlock()
+	_ = c.leases.ReleaseLease(leaseNodePrefix+c.id, c.id)
+	_ = c.leases.ReleaseLease(leaseLeader, c.id)
+	for id, l := range owners {
+		if l.Holder == c.id {
+			_ = c.leases.ReleaseLease(leaseUserPrefix+id, c.id)
+		}
+	}
+}
+
+// clusterLocalRoute 需要由运行实例的节点处理的路由：转发到实例的请求、启停与调试
+func clusterLocalRoute(route string) bool {
+	const base = "/api/admin/v1/users/:id"
+	return strings.HasPrefix(route, "/users/:id/") ||
+		route == base+"/start" || route == base+"/stop" || strings.HasPrefix(route, base+"/debug/")
+}
+
+// RequireLocalOwner 集群模式下账号由其他节点运行时返回 409 与该节点的地址
+func (a *App) RequireLocalOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.cluster == nil || !clusterLocalRoute(c.FullPath()) {
+			c.Next()
+			return
+		}
+		id := c.Param("id")
+		if owner, ok := a.cluster.remoteOwner(id); ok {
+			err := &clusterOwnedError{User: id, Node: owner.Holder, Addr: owner.Value}
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error(), "node": owner.Holder, "addr": owner.Value})
+			return
+		}
+		c.Next()
+	}
+}
+
+type clusterNodeView struct {
+	ID        string    `json:"id"`
+	Addr      string    `json:"addr,omitempty"`
+	Leader    bool      `json:"leader"`
+	Self      bool      `json:"self"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Users     []string  `json:"users"` // 该节点运行的账号
+}
+
+// GetCluster 集群节点与账号归属
+// GET /api/admin/v1/cluster
+func (a *App) GetCluster(c *gin.Context) {
+	cl := a.cluster
+	if cl == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	nodes, err := cl.leases.ListLeases(leaseNodePrefix)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	users, err := cl.leases.ListLeases(leaseUserPrefix)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	leader, err := cl.leases.ListLeases(leaseLeader)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	views := make([]clusterNodeView, 0, len(nodes))
+	for _, n := range nodes {
+		v := clusterNodeView{ID: n.Holder, Addr: n.Value, Self: n.Holder == cl.id, ExpiresAt: n.ExpiresAt, Users: []string{}}
+		v.Leader = slices.ContainsFunc(leader, func(l Lease) bool { return l.Name == leaseLeader && l.Holder == n.Holder })
+		for _, u := range users {
+			if u.Holder == n.Holder {
+				v.Users = append(v.Users, strings.TrimPrefix(u.Name, leaseUserPrefix))
+			}
+		}
+		views = append(views, v)
+	}
+	cl.mu.Lock()
+	pending := make([]string, 0, len(cl.failover))
+	for id := range cl.failover {
+		pending = append(pending, id)
+	}
+	cl.mu.Unlock()
+	slices.Sort(pending)
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  true,
+		"node":     cl.id,
+		"leader":   cl.leader.Load(),
+		"ttl_sec":  int(cl.ttl.Seconds()),
+		"nodes":    views,
+		"failover": pending, // 等待接管的账号
+	})
+}
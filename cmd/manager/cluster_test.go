@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newClusterTestApp 打开共享的 SQLite store 并以集群节点 id 运行
+func newClusterTestApp(t *testing.T, spec, id string, ttl time.Duration) *App {
+	t.Helper()
+	store, err := LoadStore(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	app := NewApp(store, NewProcessManager(), "")
+	if err := app.EnableCluster(ClusterConfig{NodeID: id, Advertise: "http://" + id + ":18050", TTL: minClusterTTL}); err != nil {
+		t.Fatal(err)
+	}
+	app.cluster.ttl = ttl
+	if err := app.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestSQLiteLeases(t *testing.T) {
+	b, err := openSQLiteBackend(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if ok, err := b.AcquireLease("user:alice", "n1", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: %v %v", ok, err)
+	}
+	if ok, _ := b.AcquireLease("user:alice", "n2", "b", time.Minute); ok {
+		t.Fatal("其他节点不应获取未过期的租约")
+	}
+	if ok, _ := b.AcquireLease("user:alice", "n1", "a", time.Minute); !ok {
+		t.Fatal("持有者应能续期")
+	}
+	if ok, _ := b.AcquireLease("user:bob", "n2", "b", -time.Second); !ok {
+		t.Fatal("acquire bob")
+	}
+	list, err := b.ListLeases("user:")
+	if err != nil || len(list) != 1 || list[0].Holder != "n1" {
+		t.Fatalf("过期租约不应列出: %+v %v", list, err)
+	}
+	if ok, _ := b.AcquireLease("user:bob", "n1", "a", time.Minute); !ok {
+		t.Fatal("应能接管已过期的租约")
+	}
+	if err := b.ReleaseLease("user:alice", "n2"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := b.ListLeases("user:alice"); len(list) != 1 {
+		t.Fatal("非持有者不应释放租约")
+	}
+	if err := b.ReleaseLease("user:alice", "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := b.ListLeases("user:alice"); len(list) != 0 {
+		t.Fatalf("释放后仍存在: %+v", list)
+	}
+}
+
+func TestClusterOwnershipAndFailover(t *testing.T) {
+	spec := "sqlite://" + filepath.Join(t.TempDir(), "users.db")
+	ttl := 300 * time.Millisecond
+	a := newClusterTestApp(t, spec, "node-a", ttl)
+	b := newClusterTestApp(t, spec, "node-b", ttl)
+	if err := a.store.CreateUser(UserConfig{ID: "alice", Port: 18301, AutoStart: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.cluster.claim("alice"); err != nil {
+		t.Fatal(err)
+	}
+	var owned *clusterOwnedError
+	if err := b.cluster.claim("alice"); !errors.As(err, &owned) || owned.Node != "node-a" || owned.Addr != "http://node-a:18050" {
+		t.Fatalf("应返回归属错误: %v", err)
+	}
+
+	// node-b 上访问 alice 的实例返回 409 与所在节点
+	r := gin.New()
+	r.Use(b.RequireLocalOwner())
+	r.POST("/api/admin/v1/users/:id/stop", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/admin/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	if w := doReq(r, http.MethodPost, "/api/admin/v1/users/alice/stop", "", nil); w.Code != http.StatusConflict {
+		t.Fatalf("stop on node-b: %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users/alice", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("读取配置不需要转到所在节点: %d", w.Code)
+	}
+	if b.manages("alice") || !a.manages("alice") {
+		t.Fatal("alice 应由 node-a 负责")
+	}
+	if !b.leasedElsewhere("alice") || a.leasedElsewhere("alice") || b.leasedElsewhere("carol") {
+		t.Fatal("leasedElsewhere 应按共享存储中的租约判断")
+	}
+
+	// node-a 失联：租约过期后 node-b 将 alice 列为待接管
+	if err := b.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(ttl + 50*time.Millisecond)
+	if err := b.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !b.cluster.leader.Load() {
+		t.Fatal("node-a 失联后 node-b 应成为 leader")
+	}
+	if fc := b.cluster.failover["alice"]; fc == nil || fc.node != "node-a" {
+		t.Fatalf("alice 应等待接管: %+v", b.cluster.failover)
+	}
+	if err := b.cluster.claim("alice"); err != nil {
+		t.Fatalf("接管: %v", err)
+	}
+}
+
+func TestClusterReleaseIsNotFailover(t *testing.T) {
+	spec := "sqlite://" + filepath.Join(t.TempDir(), "users.db")
+	a := newClusterTestApp(t, spec, "node-a", time.Minute)
+	b := newClusterTestApp(t, spec, "node-b", time.Minute)
+
+	if err := a.cluster.claim("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// node-a 存活，本地没有运行 bob，续期时释放租约
+	if err := a.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.renewLeases(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.cluster.remoteOwner("bob"); ok {
+		t.Fatal("释放后不应仍有归属")
+	}
+	if len(b.cluster.failover) != 0 {
+		t.Fatalf("节点存活时释放的账号不应被接管: %+v", b.cluster.failover)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+)
+
+// 启动实例前用 -capabilities 探测二进制支持的功能，只传入支持的可选参数；
+// 启动后从 /health 读取实际版本，与 manager 自身构建比对并标记版本偏差。
+
+// capabilitiesTimeout 探测 -capabilities 的超时，该参数只输出 JSON，不会打开浏览器
+var capabilitiesTimeout = 10 * time.Second
+
+// cachedCaps 按二进制路径缓存的探测结果，文件修改时间或大小变化后重新探测
+type cachedCaps struct {
+	modTime time.Time
+	size    int64
+	info    compat.Info
+}
+
+// Capabilities 返回实例二进制的能力信息；旧版本不支持 -capabilities 时返回未知（Known 为 false）
+func (pm *ProcessManager) Capabilities(bin string) compat.Info {
+	fi, err := os.Stat(bin)
+	if err != nil {
+		// 可能是 PATH 中的命令名，不缓存
+		info, _ := probeCapabilities(bin)
+		return info
+	}
+	pm.mu.RLock()
+	cached, ok := pm.caps[bin]
+	pm.mu.RUnlock()
+	if ok && cached.modTime.Equal(fi.ModTime()) && cached.size == fi.Size() {
+		return cached.info
+	}
+
+	info, err := probeCapabilities(bin)
+	if err != nil {
+		fmt.Printf("实例二进制 %s 未报告兼容信息，按旧版本处理: %v\n", bin, err)
+	}
+	pm.mu.Lock()
+	pm.caps[bin] = cachedCaps{modTime: fi.ModTime(), size: fi.Size(), info: info}
+	pm.mu.Unlock()
+	return info
+}
+
+func probeCapabilities(bin string) (compat.Info, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, "-capabilities").Output()
+	if err != nil {
+		return compat.Info{}, fmt.Errorf("执行 -capabilities 失败: %w", err)
+	}
+	return compat.Parse(out)
+}
+
+// checkCapabilities 账号配置要求的功能实例不支持时拒绝启动；未知能力的旧版本只检查只读模式，
+// 只读是安全限制，不能确认实例支持时不启动
+func checkCapabilities(caps compat.Info, u UserConfig, ep InstanceEndpoint) error {
+	if u.ReadOnly && !caps.Supports(compat.FeatureReadOnly) {
+		version := caps.Version
+		if version == "" {
+			version = "unknown"
+		}
+		return fmt.Errorf("实例版本 %s 不支持只读模式，为避免写操作未被拦截，拒绝启动", version)
+	}
+	if !caps.Known() {
+		return nil
+	}
+	if u.Moderation != "" && !caps.Supports(compat.FeatureModeration) {
+		return fmt.Errorf("实例版本 %s 不支持内容审核（moderation），请升级实例或清空该配置", caps.Version)
+	}
+	if u.Timezone != "" && !caps.Supports(compat.FeatureTimezone) {
+		return fmt.Errorf("实例版本 %s 不支持账号时区（timezone），请升级实例或清空该配置", caps.Version)
+	}
+	if ep.IsUnix() && !caps.Supports(compat.FeatureUnixSocket) {
+		return fmt.Errorf("实例版本 %s 不支持 Unix socket 监听，请升级实例或关闭 socket", caps.Version)
+	}
+	return nil
+}
+
+// fetchInfo 从实例 /health 读取版本与能力；旧版本实例只有 version 字段
+func (pm *ProcessManager) fetchInfo(ep InstanceEndpoint) compat.Info {
+	client := ep.HTTPClient(2 * time.Second)
+	resp, err := client.Get(ep.URL("/health"))
+	if err != nil {
+		return compat.Info{}
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Data compat.Info `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return compat.Info{}
+	}
+	return body.Data
+}
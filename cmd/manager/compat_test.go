@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+)
+
+func TestCapabilitiesGateOptionalFeatures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-capabilities\" ]; then\n  echo '{\"version\":\"v0.9.0\",\"tool_schema\":1,\"features\":[\"devtools\"]}'\n  exit 0\nfi\nexit 3\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewProcessManager()
+	caps := pm.Capabilities(bin)
+	if !caps.Known() || caps.Version != "v0.9.0" || !caps.Supports(compat.FeatureDevTools) || caps.Supports(compat.FeatureModeration) {
+		t.Fatalf("caps = %+v", caps)
+	}
+	if _, ok := pm.caps[bin]; !ok {
+		t.Fatal("探测结果应按路径缓存")
+	}
+
+	err := pm.StartUser(context.Background(), StartUserParams{
+		User:    UserConfig{ID: "u1", Port: 1, Moderation: "block"},
+		BinPath: bin,
+		DataDir: filepath.Join(dir, "data"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "moderation") {
+		t.Fatalf("实例不支持审核时应拒绝启动: %v", err)
+	}
+	if pm.GetStatus("u1").PID != 0 {
+		t.Fatal("不应拉起子进程")
+	}
+}
+
+func TestCapabilitiesLegacyBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	bin := filepath.Join(t.TempDir(), "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho 'flag provided but not defined: -capabilities' >&2\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	caps := NewProcessManager().Capabilities(bin)
+	if caps.Known() {
+		t.Fatalf("旧版本应为未知: %+v", caps)
+	}
+	if err := checkCapabilities(caps, UserConfig{Moderation: "block"}, InstanceEndpoint{}); err != nil {
+		t.Fatalf("未知能力不应限制启动: %v", err)
+	}
+	// 只读是安全限制，无法确认实例支持时拒绝启动
+	if err := checkCapabilities(caps, UserConfig{ReadOnly: true}, InstanceEndpoint{}); err == nil {
+		t.Fatal("旧版本实例不支持只读模式，应拒绝启动")
+	}
+}
+
+func TestBinaryVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	a := &App{proc: NewProcessManager()}
+	bin := filepath.Join(t.TempDir(), "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho '{\"version\":\"v1.2.0\",\"commit\":\"abc\",\"tool_schema\":1}'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if v := a.binaryVersion(bin); v.Source != "capabilities" || v.Version != "v1.2.0" || v.Commit != "abc" || v.SizeBytes == 0 {
+		t.Fatalf("capabilities 版本不符: %+v", v)
+	}
+
+	// 不支持 -capabilities 的 Go 二进制读取内嵌构建信息（用测试二进制自身代替）
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(t.TempDir(), "legacy-mcp")
+	if err := os.Symlink(self, legacy); err != nil {
+		t.Skip(err)
+	}
+	// 预置缓存，避免真正执行测试二进制
+	fi, err := os.Stat(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.proc.caps[legacy] = cachedCaps{modTime: fi.ModTime(), size: fi.Size()}
+	if v := a.binaryVersion(legacy); v.Source != "buildinfo" || v.GoVersion == "" || v.Version == "" {
+		t.Fatalf("buildinfo 版本不符: %+v", v)
+	}
+
+	if v := a.binaryVersion(filepath.Join(t.TempDir(), "missing")); v.Error == "" {
+		t.Fatal("二进制不存在时应返回错误")
+	}
+}
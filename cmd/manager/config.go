@@ -9,6 +9,10 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
 )
 
 // 用户 ID 只允许字母、数字、下划线、连字符
@@ -17,11 +21,50 @@ var validIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 // UserConfig 用户配置
 type UserConfig struct {
 	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"` // 备注名，便于在列表中辨认账号
 	Port      int    `json:"port"`
 	Proxy     string `json:"proxy,omitempty"`
 	ProxyPool string `json:"proxy_pool_url,omitempty"`
 	UserAgent string `json:"user_agent,omitempty"` // 浏览器 User-Agent（首次创建时自动生成）
 	AutoStart bool   `json:"auto_start,omitempty"` // 上次运行态，manager 重启时自动恢复
+	Socket    bool   `json:"socket,omitempty"`     // 使用 Unix socket 代替 TCP 端口，此时 port 可为 0
+	APIKey    string `json:"api_key,omitempty"`    // 实例接口访问密钥（创建时生成），启动时注入实例；为空时每次启动随机生成
+
+	StartPriority  int `json:"start_priority,omitempty"`   // 启动恢复的优先级，越大越先启动
+	StopTimeoutSec int `json:"stop_timeout_sec,omitempty"` // 停止时等待优雅退出的秒数，超时后发送 SIGTERM、SIGKILL；0 使用 -stop-timeout
+
+	ProxyRatePerMin int `json:"proxy_rate_per_min,omitempty"` // 经 manager 转发到实例的请求每分钟上限，0 使用 -proxy-rate，-1 不限制
+
+	Moderation  string `json:"moderation,omitempty"`    // 内容审核策略 block/warn/log/off，为空使用实例默认
+	DiskQuotaMB int64  `json:"disk_quota_mb,omitempty"` // 数据目录磁盘配额（MB），超出后拒绝启动，0 表示不限制
+	DataDir     string `json:"data_dir,omitempty"`      // 单独迁移后的数据目录，为空使用全局 data_dir
+	ReadOnly    bool   `json:"read_only,omitempty"`     // 只读账号：实例拒绝发布、评论、点赞等所有写操作
+	Timezone    string `json:"timezone,omitempty"`      // 账号运营地区的时区（IANA 名称），定时发布与周期任务按当地时间，为空跟随服务器
+
+	Env map[string]string `json:"env,omitempty"` // 启动实例时额外注入的环境变量（日志级别、超时、功能开关等）
+
+	Restart     string `json:"restart,omitempty"`      // 实例意外退出后的重启策略 never/on-failure/always，为空不重启
+	MaxRestarts int    `json:"max_restarts,omitempty"` // 连续自动重启上限，0 使用默认 5，负数不限制
+
+	Schedule *RunSchedule `json:"schedule,omitempty"` // 定时启停，按 timezone 执行，为空不启用
+
+	Tags []string `json:"tags,omitempty"` // 分组标签，用于列表过滤与按标签批量启停
+
+	Profile *AccountProfile `json:"profile,omitempty"` // 最近一次获取的账号资料，由 manager 维护
+	Login   *LoginHealth    `json:"login,omitempty"`   // 最近一次登录检查结果，由 manager 维护
+}
+
+// AccountProfile 已登录账号的资料快照，计数保持页面展示的原文（如 "1.2万"）
+type AccountProfile struct {
+	Nickname    string    `json:"nickname"`
+	Avatar      string    `json:"avatar,omitempty"`
+	RedID       string    `json:"red_id,omitempty"`
+	Follows     string    `json:"follows,omitempty"`
+	Fans        string    `json:"fans,omitempty"`
+	Interaction string    `json:"interaction,omitempty"` // 获赞与收藏
+	NoteCount   int       `json:"note_count"`            // 主页首屏笔记数
+	MoreNotes   bool      `json:"more_notes,omitempty"`  // 还有更多笔记，note_count 只是下限
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // ManagerConfig 管理器配置
@@ -30,70 +73,121 @@ type ManagerConfig struct {
 	Headless bool         `json:"headless"`
 	DataDir  string       `json:"data_dir"`
 	Users    []UserConfig `json:"users"`
+
+	LogRotation *LogRotationConfig `json:"log_rotation,omitempty"` // 子进程日志轮转，为空使用默认值
+	Webhooks    []WebhookConfig    `json:"webhooks,omitempty"`     // 接收实例启停、崩溃等事件的 webhook
+	Admins      []AdminAccount     `json:"admins,omitempty"`       // 带角色的管理员账号
+	AlertRules  []AlertRule        `json:"alert_rules,omitempty"`  // 告警规则
+	Notifiers   []NotifierConfig   `json:"notifiers,omitempty"`    // 推送告警等事件的聊天、邮件通知渠道
 }
 
-// Store JSON 存储
+// Store 用户配置存储，持久化在 JSON 文件、SQLite、Postgres 或 Redis 中（见 StoreBackend）。
+// 写入时持有后端的写锁（本地为 .lock 文件锁，远程后端在多台主机之间互斥）；
+// 每次修改前检查存储是否被外部改动（手工编辑、另一个 manager 进程或主机），有则先重新加载。
 type Store struct {
-	mu   sync.RWMutex
-	path string
-	cwd  string // 当前工作目录，用于解析相对路径
-	cfg  ManagerConfig
+	mu      sync.RWMutex
+	backend StoreBackend
+	cwd     string // 当前工作目录，用于解析相对路径
+	cfg     ManagerConfig
+	stamp   storeStamp // 最近一次读/写时存储的状态
+
+	portRange PortRange // 自动分配端口的范围，不持久化，由 -port-range 指定
+
+	onReload func(StoreChange) // 自动加载外部修改后的回调
 }
 
-// LoadStore 加载存储
-func LoadStore(path string) (*Store, error) {
-	if path == "" {
+// LoadStore 加载存储。spec 为 JSON 文件路径，或 sqlite:///path.db、postgres://…、redis://… 形式的地址
+func LoadStore(spec string) (*Store, error) {
+	if spec == "" {
 		return nil, fmt.Errorf("store 路径不能为空")
 	}
 
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return nil, fmt.Errorf("解析 store 绝对路径失败: %w", err)
-	}
-
 	// 使用当前工作目录作为相对路径基准
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("获取当前工作目录失败: %w", err)
 	}
 
+	backend, err := openStoreBackend(spec)
+	if err != nil {
+		return nil, err
+	}
 	s := &Store{
-		path: absPath,
-		cwd:  cwd,
-		cfg: ManagerConfig{
-			Bin:      "./xiaohongshu-mcp",
-			Headless: true,
-			DataDir:  "./data",
-			Users:    []UserConfig{},
-		},
+		backend: backend,
+		cwd:     cwd,
+		cfg:     defaultManagerConfig(),
+
+		portRange: PortRange{Start: defaultPortRangeStart, End: defaultPortRangeEnd},
 	}
 
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
-			return nil, fmt.Errorf("创建 store 目录失败: %w", err)
-		}
-		if err := s.saveLocked(); err != nil {
-			return nil, err
-		}
-		return s, nil
+	unlock, err := backend.Lock()
+	if err != nil {
+		_ = backend.Close()
+		return nil, err
 	}
+	defer unlock()
 
-	raw, err := os.ReadFile(absPath)
+	cfg, found, err := backend.Load()
 	if err != nil {
-		return nil, fmt.Errorf("读取 store 失败: %w", err)
+		_ = backend.Close()
+		return nil, err
 	}
-	if len(raw) == 0 {
+	if !found {
 		if err := s.saveLocked(); err != nil {
+			_ = backend.Close()
 			return nil, err
 		}
 		return s, nil
 	}
+	s.cfg = cfg
+	if s.stamp, err = backend.Stamp(); err != nil {
+		_ = backend.Close()
+		return nil, fmt.Errorf("读取 store 状态失败: %w", err)
+	}
+	return s, nil
+}
+
+// Path 存储文件路径（远程存储为隐去密码的地址）
+func (s *Store) Path() string {
+	return s.backend.Path()
+}
+
+// LocalDir 存放本地附属文件的目录：本地存储为其所在目录，远程存储为 ./data/manager
+func (s *Store) LocalDir() string {
+	if dir := s.backend.LocalDir(); dir != "" {
+		return dir
+	}
+	return resolvePath(s.cwd, "./data/manager")
+}
 
+// Close 关闭存储（数据库连接）
+func (s *Store) Close() error {
+	return s.backend.Close()
+}
+
+func defaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		Bin:      "./xiaohongshu-mcp",
+		Headless: true,
+		DataDir:  "./data",
+		Users:    []UserConfig{},
+	}
+}
+
+// decodeConfig 解析并校验配置，填充默认值
+func decodeConfig(raw []byte) (ManagerConfig, error) {
 	var cfg ManagerConfig
 	if err := json.Unmarshal(raw, &cfg); err != nil {
-		return nil, fmt.Errorf("解析 JSON 失败: %w", err)
+		return ManagerConfig{}, fmt.Errorf("解析 JSON 失败: %w", err)
+	}
+	if err := normalizeConfig(&cfg); err != nil {
+		return ManagerConfig{}, err
 	}
+	return cfg, nil
+}
 
+// normalizeConfig 填充默认值、校验并按 ID 排序
+func normalizeConfig(cfg *ManagerConfig) error {
 	// 默认值兜底
 	if cfg.Bin == "" {
 		cfg.Bin = "./xiaohongshu-mcp"
@@ -101,13 +195,17 @@ func LoadStore(path string) (*Store, error) {
 	if cfg.DataDir == "" {
 		cfg.DataDir = "./data"
 	}
-	s.cfg = cfg
+	if cfg.Users == nil {
+		cfg.Users = []UserConfig{}
+	}
 
-	if err := validateConfig(&s.cfg); err != nil {
-		return nil, err
+	if err := validateConfig(cfg); err != nil {
+		return err
 	}
-	s.sortUsersLocked()
-	return s, nil
+	sort.Slice(cfg.Users, func(i, j int) bool {
+		return cfg.Users[i].ID < cfg.Users[j].ID
+	})
+	return nil
 }
 
 // GetConfig 获取配置
@@ -131,6 +229,16 @@ func (s *Store) ResolveDataDir() string {
 	return resolvePath(s.cwd, s.cfg.DataDir)
 }
 
+// UserDataDir 用户实际使用的数据目录：单独迁移过的用户使用自己的目录，否则为全局数据目录
+func (s *Store) UserDataDir(u UserConfig) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if u.DataDir != "" {
+		return resolvePath(s.cwd, u.DataDir)
+	}
+	return resolvePath(s.cwd, s.cfg.DataDir)
+}
+
 // ListUsers 获取用户列表
 func (s *Store) ListUsers() []UserConfig {
 	s.mu.RLock()
@@ -156,12 +264,28 @@ func (s *Store) GetUser(id string) (UserConfig, bool) {
 func (s *Store) CreateUser(u UserConfig) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// 如果未指定 UserAgent，自动生成随机 UA
 	u.UserAgent = strings.TrimSpace(u.UserAgent)
 	if u.UserAgent == "" {
 		u.UserAgent = generateRandomUserAgent()
 	}
+	if u.APIKey == "" {
+		if u.APIKey, err = generateToken(); err != nil {
+			return err
+		}
+	}
+	// 未指定端口时自动分配
+	if u.Port == 0 && !u.Socket {
+		if u.Port, err = s.allocatePortLocked(); err != nil {
+			return err
+		}
+	}
 
 	if err := validateUser(u); err != nil {
 		return err
@@ -170,7 +294,7 @@ func (s *Store) CreateUser(u UserConfig) error {
 		if ex.ID == u.ID {
 			return fmt.Errorf("用户已存在: %s", u.ID)
 		}
-		if ex.Port == u.Port {
+		if u.Port != 0 && ex.Port == u.Port {
 			return fmt.Errorf("端口已被占用: %d", u.Port)
 		}
 	}
@@ -183,6 +307,11 @@ func (s *Store) CreateUser(u UserConfig) error {
 func (s *Store) UpdateUser(id string, patch UserConfig) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	if id == "" {
 		return fmt.Errorf("id 不能为空")
@@ -193,6 +322,39 @@ func (s *Store) UpdateUser(id string, patch UserConfig) error {
 	if patch.Port != 0 && (patch.Port <= 0 || patch.Port > 65535) {
 		return fmt.Errorf("port 非法: %d", patch.Port)
 	}
+	if err := validateModeration(patch.Moderation); err != nil {
+		return err
+	}
+	if err := validateTimezone(patch.Timezone); err != nil {
+		return err
+	}
+	if err := validateEnv(patch.Env); err != nil {
+		return err
+	}
+	if err := validateRestartPolicy(patch.Restart, patch.MaxRestarts); err != nil {
+		return err
+	}
+	if err := validateSchedule(patch.Schedule); err != nil {
+		return err
+	}
+	if err := validateTags(patch.Tags); err != nil {
+		return err
+	}
+	if err := validateUserName(patch.Name); err != nil {
+		return err
+	}
+	if err := validateStartPriority(patch.StartPriority); err != nil {
+		return err
+	}
+	if err := validateStopTimeout(patch.StopTimeoutSec); err != nil {
+		return err
+	}
+	if err := validateProxyRate(patch.ProxyRatePerMin); err != nil {
+		return err
+	}
+	if patch.DiskQuotaMB < 0 {
+		return fmt.Errorf("disk_quota_mb 不能为负数")
+	}
 
 	found := false
 	for i := range s.cfg.Users {
@@ -211,6 +373,20 @@ func (s *Store) UpdateUser(id string, patch UserConfig) error {
 		// 允许清空 proxy
 		s.cfg.Users[i].Proxy = patch.Proxy
 		s.cfg.Users[i].ProxyPool = patch.ProxyPool
+		s.cfg.Users[i].Socket = patch.Socket
+		s.cfg.Users[i].Moderation = patch.Moderation
+		s.cfg.Users[i].DiskQuotaMB = patch.DiskQuotaMB
+		s.cfg.Users[i].ReadOnly = patch.ReadOnly
+		s.cfg.Users[i].Timezone = patch.Timezone
+		s.cfg.Users[i].Env = patch.Env
+		s.cfg.Users[i].Restart = patch.Restart
+		s.cfg.Users[i].MaxRestarts = patch.MaxRestarts
+		s.cfg.Users[i].Schedule = patch.Schedule
+		s.cfg.Users[i].Tags = patch.Tags
+		s.cfg.Users[i].Name = patch.Name
+		s.cfg.Users[i].StartPriority = patch.StartPriority
+		s.cfg.Users[i].StopTimeoutSec = patch.StopTimeoutSec
+		s.cfg.Users[i].ProxyRatePerMin = patch.ProxyRatePerMin
 		break
 	}
 	if !found {
@@ -224,6 +400,11 @@ func (s *Store) UpdateUser(id string, patch UserConfig) error {
 func (s *Store) DeleteUser(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	if id == "" {
 		return fmt.Errorf("id 不能为空")
@@ -246,6 +427,11 @@ func (s *Store) DeleteUser(id string) error {
 func (s *Store) SetUserAutoStart(id string, autoStart bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	if id == "" {
 		return fmt.Errorf("id 不能为空")
@@ -259,10 +445,295 @@ func (s *Store) SetUserAutoStart(id string, autoStart bool) error {
 	return fmt.Errorf("用户不存在: %s", id)
 }
 
+// SetUserAPIKey 更新用户的实例访问密钥，为空表示改回每次启动随机生成
+func (s *Store) SetUserAPIKey(id, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].ID == id {
+			s.cfg.Users[i].APIKey = key
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("用户不存在: %s", id)
+}
+
+// SetUserDataDir 仅更新用户单独的数据目录，为空表示改回全局数据目录
+func (s *Store) SetUserDataDir(id, dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].ID == id {
+			s.cfg.Users[i].DataDir = dir
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("用户不存在: %s", id)
+}
+
+// SetDataDir 更新全局数据目录
+func (s *Store) SetDataDir(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if dir == "" {
+		return fmt.Errorf("data_dir 不能为空")
+	}
+	s.cfg.DataDir = dir
+	return s.saveLocked()
+}
+
+// SetLogRotation 更新日志轮转配置
+func (s *Store) SetLogRotation(c LogRotationConfig) error {
+	if err := validateLogRotation(c); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.cfg.LogRotation = &c
+	return s.saveLocked()
+}
+
+// SetWebhooks 替换 webhook 配置
+func (s *Store) SetWebhooks(hooks []WebhookConfig) error {
+	if err := validateWebhooks(hooks); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.cfg.Webhooks = hooks
+	return s.saveLocked()
+}
+
+// Webhooks webhook 配置
+func (s *Store) Webhooks() []WebhookConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]WebhookConfig{}, s.cfg.Webhooks...)
+}
+
+// AdminAccounts 管理员账号列表
+func (s *Store) AdminAccounts() []AdminAccount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]AdminAccount{}, s.cfg.Admins...)
+}
+
+// AdminAccount 按用户名查找管理员账号
+func (s *Store) AdminAccount(username string) (AdminAccount, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, acc := range s.cfg.Admins {
+		if acc.Username == username {
+			return acc, true
+		}
+	}
+	return AdminAccount{}, false
+}
+
+// HasAdminAccounts 是否配置了管理员账号
+func (s *Store) HasAdminAccounts() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.cfg.Admins) > 0
+}
+
+// PutAdminAccount 添加或替换同名管理员账号
+func (s *Store) PutAdminAccount(acc AdminAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	admins := append([]AdminAccount{}, s.cfg.Admins...)
+	replaced := false
+	for i := range admins {
+		if admins[i].Username == acc.Username {
+			admins[i] = acc
+			replaced = true
+		}
+	}
+	if !replaced {
+		admins = append(admins, acc)
+	}
+	if err := validateAdminAccounts(admins); err != nil {
+		return err
+	}
+	s.cfg.Admins = admins
+	return s.saveLocked()
+}
+
+// DeleteAdminAccount 删除管理员账号
+func (s *Store) DeleteAdminAccount(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, acc := range s.cfg.Admins {
+		if acc.Username == username {
+			s.cfg.Admins = append(s.cfg.Admins[:i:i], s.cfg.Admins[i+1:]...)
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("管理员不存在")
+}
+
+// LogRotation 日志轮转配置（已补全默认值）
+func (s *Store) LogRotation() LogRotationConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cfg.LogRotation == nil {
+		return LogRotationConfig{}.Effective()
+	}
+	return s.cfg.LogRotation.Effective()
+}
+
+// ImportJSON 把 JSON 存储文件的全部配置（含 bin、data_dir）导入当前存储，用于切换存储后端；
+// 当前存储已有账号时拒绝，避免覆盖。返回导入的账号数
+func (s *Store) ImportJSON(path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取 JSON 存储失败: %w", err)
+	}
+	cfg, err := decodeConfig(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if len(s.cfg.Users) > 0 {
+		return 0, fmt.Errorf("目标存储已有 %d 个账号，拒绝导入", len(s.cfg.Users))
+	}
+	prev := s.cfg
+	s.cfg = cfg
+	if err := s.saveLocked(); err != nil {
+		s.cfg = prev
+		return 0, err
+	}
+	return len(cfg.Users), nil
+}
+
+// ImportConfig 用导出包中的配置替换账号与全局设置，bin、data_dir 保留本机配置
+func (s *Store) ImportConfig(cfg ManagerConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	next := cfg
+	next.Bin = s.cfg.Bin
+	next.DataDir = s.cfg.DataDir
+	next.Users = append([]UserConfig(nil), cfg.Users...)
+	// 单独迁移的数据目录是本机路径，导入后统一使用本机全局数据目录
+	for i := range next.Users {
+		next.Users[i].DataDir = ""
+	}
+	if err := validateConfig(&next); err != nil {
+		return err
+	}
+	prev := s.cfg
+	s.cfg = next
+	s.sortUsersLocked()
+	if err := s.saveLocked(); err != nil {
+		s.cfg = prev
+		return err
+	}
+	return nil
+}
+
+// SetUserProfile 仅更新账号资料快照
+func (s *Store) SetUserProfile(id string, p *AccountProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].ID == id {
+			s.cfg.Users[i].Profile = p
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("用户不存在: %s", id)
+}
+
+// SetUserLogin 仅更新登录检查结果
+func (s *Store) SetUserLogin(id string, h *LoginHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].ID == id {
+			s.cfg.Users[i].Login = h
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("用户不存在: %s", id)
+}
+
 // ResetUserAgent 重置用户的 User-Agent（重新生成随机 UA）
 func (s *Store) ResetUserAgent(id string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
 
 	if id == "" {
 		return "", fmt.Errorf("id 不能为空")
@@ -280,22 +751,63 @@ func (s *Store) ResetUserAgent(id string) (string, error) {
 	return "", fmt.Errorf("用户不存在: %s", id)
 }
 
+// beginWriteLocked 获取文件锁，并在文件被外部修改时重新加载，
+// 保证本次修改基于磁盘上的最新内容。调用方需持有 s.mu 写锁。
+func (s *Store) beginWriteLocked() (func(), error) {
+	unlock, err := s.backend.Lock()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reloadIfChangedLocked(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// reloadIfChangedLocked 存储状态与上次读写不一致时重新加载；
+// 外部写入的内容无法解析时拒绝修改，避免覆盖掉他人的编辑
+func (s *Store) reloadIfChangedLocked() error {
+	stamp, err := s.backend.Stamp()
+	if os.IsNotExist(err) {
+		fmt.Printf("store 文件已被删除，将以内存中的配置重新写入: %s\n", s.backend.Path())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取 store 状态失败: %w", err)
+	}
+	if stamp == s.stamp {
+		return nil
+	}
+
+	cfg, found, err := s.backend.Load()
+	if err != nil {
+		return fmt.Errorf("store 已被外部修改且无法解析，拒绝写入: %w", err)
+	}
+	if !found {
+		return nil
+	}
+	fmt.Printf("检测到 store 被外部修改，已重新加载: %s\n", s.backend.Path())
+	if ch := s.applyLoadedLocked(cfg, stamp); !ch.Empty() && s.onReload != nil {
+		// 回调会读取 store，不能在持有锁时同步执行
+		go s.onReload(ch)
+	}
+	return nil
+}
+
+// saveLocked 写入存储。调用方需持有 s.mu 写锁与文件锁。
 func (s *Store) saveLocked() error {
 	if err := validateConfig(&s.cfg); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
-		return fmt.Errorf("创建 store 目录失败: %w", err)
+	if err := s.backend.Save(s.cfg); err != nil {
+		return err
 	}
-
-	data, err := json.MarshalIndent(s.cfg, "", "  ")
+	stamp, err := s.backend.Stamp()
 	if err != nil {
-		return fmt.Errorf("序列化 JSON 失败: %w", err)
-	}
-	// 直接写入（Windows 兼容，简单化）
-	if err := os.WriteFile(s.path, data, 0644); err != nil {
-		return fmt.Errorf("写入文件失败: %w", err)
+		return fmt.Errorf("读取 store 状态失败: %w", err)
 	}
+	s.stamp = stamp
 	return nil
 }
 
@@ -322,6 +834,23 @@ func validateConfig(cfg *ManagerConfig) error {
 	if cfg.DataDir == "" {
 		return fmt.Errorf("data_dir 不能为空")
 	}
+	if cfg.LogRotation != nil {
+		if err := validateLogRotation(*cfg.LogRotation); err != nil {
+			return err
+		}
+	}
+	if err := validateWebhooks(cfg.Webhooks); err != nil {
+		return err
+	}
+	if err := validateAlertRules(cfg.AlertRules); err != nil {
+		return err
+	}
+	if err := validateNotifiers(cfg.Notifiers); err != nil {
+		return err
+	}
+	if err := validateAdminAccounts(cfg.Admins); err != nil {
+		return err
+	}
 
 	seenID := map[string]struct{}{}
 	seenPort := map[int]struct{}{}
@@ -333,6 +862,9 @@ func validateConfig(cfg *ManagerConfig) error {
 			return fmt.Errorf("id 重复: %s", u.ID)
 		}
 		seenID[u.ID] = struct{}{}
+		if u.Port == 0 {
+			continue
+		}
 		if _, ok := seenPort[u.Port]; ok {
 			return fmt.Errorf("port 重复: %d", u.Port)
 		}
@@ -341,6 +873,90 @@ func validateConfig(cfg *ManagerConfig) error {
 	return nil
 }
 
+// validateModeration 校验内容审核策略
+func validateModeration(policy string) error {
+	switch policy {
+	case "", "block", "warn", "log", "off":
+		return nil
+	default:
+		return fmt.Errorf("moderation 取值非法: %q（可选 block、warn、log、off）", policy)
+	}
+}
+
+// maxUserNameLen 备注名的最大长度（字符数）
+const maxUserNameLen = 64
+
+func validateUserName(name string) error {
+	if utf8.RuneCountInString(name) > maxUserNameLen {
+		return fmt.Errorf("name 过长（最多 %d 个字符）", maxUserNameLen)
+	}
+	return nil
+}
+
+// maxStopTimeoutSec 账号优雅退出等待时间的上限
+const maxStopTimeoutSec = 600
+
+func validateStopTimeout(sec int) error {
+	if sec < 0 || sec > maxStopTimeoutSec {
+		return fmt.Errorf("stop_timeout_sec 范围为 0~%d", maxStopTimeoutSec)
+	}
+	return nil
+}
+
+// maxProxyRatePerMin 账号转发请求每分钟上限的最大值
+const maxProxyRatePerMin = 100000
+
+func validateProxyRate(n int) error {
+	if n < -1 || n > maxProxyRatePerMin {
+		return fmt.Errorf("proxy_rate_per_min 范围为 -1~%d（0 使用全局设置，-1 不限制）", maxProxyRatePerMin)
+	}
+	return nil
+}
+
+// validateTimezone 校验时区名称，需能在本机时区数据库中找到
+func validateTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("timezone 非法: %q（使用 IANA 名称，如 Asia/Shanghai）", tz)
+	}
+	return nil
+}
+
+// envKeyRegex 环境变量名
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvKey 由 manager 设置、不允许通过 env 覆盖的变量（代理使用 proxy 字段配置）
+func reservedEnvKey(key string) bool {
+	switch strings.ToUpper(key) {
+	case envCookiesPath, envXHSAPIToken, cookies.EnvKey, "TMPDIR", "TMP", "TEMP":
+		return true
+	}
+	return isProxyRelatedEnvKey(key)
+}
+
+func validateEnv(env map[string]string) error {
+	if len(env) > 100 {
+		return fmt.Errorf("env 最多 100 项")
+	}
+	for k, v := range env {
+		if !envKeyRegex.MatchString(k) {
+			return fmt.Errorf("env 变量名非法: %q", k)
+		}
+		if reservedEnvKey(k) {
+			return fmt.Errorf("env 不允许设置 %s（由 manager 管理）", k)
+		}
+		if len(v) > 4096 {
+			return fmt.Errorf("env %s 的值过长（最大 4096 字符）", k)
+		}
+		if strings.ContainsRune(v, 0) {
+			return fmt.Errorf("env %s 的值不允许包含 NUL 字符", k)
+		}
+	}
+	return nil
+}
+
 func validateUser(u UserConfig) error {
 	if u.ID == "" {
 		return fmt.Errorf("id 不能为空")
@@ -348,9 +964,42 @@ func validateUser(u UserConfig) error {
 	if !validIDRegex.MatchString(u.ID) {
 		return fmt.Errorf("id 只能包含字母、数字、下划线、连字符")
 	}
-	if u.Port <= 0 || u.Port > 65535 {
+	if u.Port < 0 || u.Port > 65535 || (u.Port == 0 && !u.Socket) {
 		return fmt.Errorf("port 非法: %d", u.Port)
 	}
+	if err := validateModeration(u.Moderation); err != nil {
+		return err
+	}
+	if err := validateTimezone(u.Timezone); err != nil {
+		return err
+	}
+	if u.DiskQuotaMB < 0 {
+		return fmt.Errorf("disk_quota_mb 不能为负数")
+	}
+	if err := validateEnv(u.Env); err != nil {
+		return err
+	}
+	if err := validateRestartPolicy(u.Restart, u.MaxRestarts); err != nil {
+		return err
+	}
+	if err := validateSchedule(u.Schedule); err != nil {
+		return err
+	}
+	if err := validateStartPriority(u.StartPriority); err != nil {
+		return err
+	}
+	if err := validateStopTimeout(u.StopTimeoutSec); err != nil {
+		return err
+	}
+	if err := validateProxyRate(u.ProxyRatePerMin); err != nil {
+		return err
+	}
+	if err := validateTags(u.Tags); err != nil {
+		return err
+	}
+	if err := validateUserName(u.Name); err != nil {
+		return err
+	}
 	// 验证 UserAgent
 	if ua := strings.TrimSpace(u.UserAgent); ua != "" {
 		if len(ua) > 1024 {
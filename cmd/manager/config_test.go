@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreReloadsExternalEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟另一个进程/手工编辑追加用户
+	other, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.CreateUser(UserConfig{ID: "bob", Port: 18061}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CreateUser(UserConfig{ID: "carol", Port: 18062}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reloaded.ListUsers()); got != 3 {
+		t.Fatalf("外部写入的用户不应被覆盖，got %d users", got)
+	}
+
+	// 外部写入了无法解析的内容时拒绝修改，原文件保持不变
+	if err := os.WriteFile(path, []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(path, future, future)
+	if err := s.DeleteUser("alice"); err == nil {
+		t.Fatal("外部内容无法解析时应拒绝写入")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "{broken" {
+		t.Fatalf("拒绝写入时不应改动文件: %q", data)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".users.json.*.tmp"))
+	if len(matches) != 0 {
+		t.Fatalf("不应残留临时文件: %v", matches)
+	}
+}
+
+func TestStoreModerationPolicy(t *testing.T) {
+	s, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Moderation: "strict"}); err == nil {
+		t.Fatal("非法审核策略应被拒绝")
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Moderation: "warn"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUser("alice", UserConfig{Moderation: "block"}); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := s.GetUser("alice"); u.Moderation != "block" {
+		t.Fatalf("审核策略未更新: %q", u.Moderation)
+	}
+	if err := s.UpdateUser("alice", UserConfig{Moderation: "nope"}); err == nil {
+		t.Fatal("非法审核策略应被拒绝")
+	}
+}
+
+func TestStoreTimezone(t *testing.T) {
+	s, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Timezone: "Mars/Olympus"}); err == nil {
+		t.Fatal("非法时区应被拒绝")
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUser("alice", UserConfig{Timezone: "Etc/GMT+5"}); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := s.GetUser("alice"); u.Timezone != "Etc/GMT+5" {
+		t.Fatalf("时区未更新: %q", u.Timezone)
+	}
+}
+
+func TestStoreUserEnv(t *testing.T) {
+	s, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []map[string]string{
+		{"1BAD": "x"},
+		{"XHS_API_TOKEN": "x"},
+		{"https_proxy": "http://127.0.0.1:7890"},
+	} {
+		if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Env: env}); err == nil {
+			t.Fatalf("env %v 应被拒绝", env)
+		}
+	}
+	if err := s.CreateUser(UserConfig{ID: "alice", Port: 18060, Env: map[string]string{"LOG_LEVEL": "debug"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateUser("alice", UserConfig{Env: map[string]string{"XHS_FEATURE_X": "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := s.GetUser("alice"); len(u.Env) != 1 || u.Env["XHS_FEATURE_X"] != "1" {
+		t.Fatalf("env 未更新: %v", u.Env)
+	}
+}
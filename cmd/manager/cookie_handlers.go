@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 单条 cookie 的查看与编辑：实例运行中时转发给实例（同时作用于浏览器），
+// 未运行时直接读写 cookies 文件，下次启动生效
+
+// cookieTarget 解析账号并判断走实例还是文件
+func (a *App) cookieTarget(c *gin.Context) (ep InstanceEndpoint, cookiePath string, running, ok bool) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
+		return
+	}
+	user, found := a.store.GetUser(id)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+	running = a.proc.GetStatus(id).Running
+	if running {
+		ep = a.endpoint(user)
+	}
+	return ep, paths.CookiesPath, running, true
+}
+
+// ListDebugCookieItems 结构化列出 cookies，默认隐藏 value，?reveal=true 显示完整值
+// GET /api/admin/v1/users/:id/debug/cookies/items
+func (a *App) ListDebugCookieItems(c *gin.Context) {
+	ep, cookiePath, running, ok := a.cookieTarget(c)
+	if !ok {
+		return
+	}
+	if running {
+		path := "/api/v1/login/cookies"
+		if c.Query("reveal") == "true" {
+			path += "?reveal=true"
+		}
+		status, _, data, err := a.proxyGet(c.Request.Context(), ep, path, 15*time.Second)
+		a.respondInstanceData(c, status, data, err)
+		return
+	}
+
+	data, err := readCookieFile(cookiePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := cookies.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	reveal := c.Query("reveal") == "true"
+	now := time.Now()
+	expired := 0
+	for i := range list {
+		if list[i].Expired(now) {
+			expired++
+		}
+		if !reveal {
+			list[i] = list[i].Masked()
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mode":    "file",
+		"cookies": list,
+		"count":   len(list),
+		"expired": expired,
+	})
+}
+
+// UpsertDebugCookieItem 新增或修改单条 cookie，请求体为 cookie 对象，可带 validate=true 检查登录
+// POST /api/admin/v1/users/:id/debug/cookies/items
+func (a *App) UpsertDebugCookieItem(c *gin.Context) {
+	ep, cookiePath, running, ok := a.cookieTarget(c)
+	if !ok {
+		return
+	}
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+		return
+	}
+	if running {
+		status, _, data, err := a.proxyPostJSON(c.Request.Context(), ep, "/api/v1/login/cookies", bytes.NewReader(raw), 60*time.Second)
+		a.respondInstanceData(c, status, data, err)
+		return
+	}
+
+	var req struct {
+		cookies.Cookie
+		Validate bool `json:"validate"`
+	}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	err = editCookieFile(cookiePath, func(data []byte) ([]byte, error) {
+		return cookies.Upsert(data, req.Cookie)
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	resp := gin.H{"mode": "file", "name": req.Name}
+	if req.Validate {
+		resp["login"] = gin.H{"error": "用户实例未运行，无法检查登录状态"}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteDebugCookieItem 删除单条 cookie，可用 ?domain=&path= 精确匹配
+// DELETE /api/admin/v1/users/:id/debug/cookies/items/:name
+func (a *App) DeleteDebugCookieItem(c *gin.Context) {
+	ep, cookiePath, running, ok := a.cookieTarget(c)
+	if !ok {
+		return
+	}
+	name := c.Param("name")
+	if running {
+		path := "/api/v1/login/cookies/" + url.PathEscape(name)
+		if q := c.Request.URL.RawQuery; q != "" {
+			path += "?" + q
+		}
+		status, _, data, err := a.proxyRequest(c.Request.Context(), ep, http.MethodDelete, path, 60*time.Second)
+		a.respondInstanceData(c, status, data, err)
+		return
+	}
+
+	var removed int
+	err := editCookieFile(cookiePath, func(data []byte) ([]byte, error) {
+		out, n, err := cookies.Remove(data, name, c.Query("domain"), c.Query("path"))
+		removed = n
+		return out, err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cookie 不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": "file", "name": name, "removed": removed})
+}
+
+// ValidateDebugCookies 用当前 cookies 检查登录状态，需要实例运行中
+// POST /api/admin/v1/users/:id/debug/cookies/validate
+func (a *App) ValidateDebugCookies(c *gin.Context) {
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+	status, _, data, err := a.proxyPostJSON(c.Request.Context(), ep, "/api/v1/login/cookies/validate", nil, 60*time.Second)
+	a.respondInstanceData(c, status, data, err)
+}
+
+// respondInstanceData 解开实例的 {success, data} 响应，加上 mode=upstream 后返回
+func (a *App) respondInstanceData(c *gin.Context, status int, data []byte, err error) {
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	var body struct {
+		Success bool           `json:"success"`
+		Data    map[string]any `json:"data"`
+		Error   string         `json:"error"`
+		Details any            `json:"details"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("解析响应失败: HTTP %d", status)})
+		return
+	}
+	if !body.Success {
+		c.JSON(status, gin.H{"error": body.Error, "details": body.Details})
+		return
+	}
+	if body.Data == nil {
+		body.Data = map[string]any{}
+	}
+	body.Data["mode"] = "upstream"
+	c.JSON(status, body.Data)
+}
+
+// readCookieFile 读取 cookies 文件，加密的文件自动解密
+func readCookieFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 cookies 失败: %w", err)
+	}
+	return cookies.Decrypt(data)
+}
+
+// editCookieFile 读改写 cookies 文件，文件锁防止与其它写入者交错
+func editCookieFile(path string, edit func([]byte) ([]byte, error)) error {
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+	}
+	unlock, err := fileutil.Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := readCookieFile(path)
+	if err != nil {
+		return err
+	}
+	out, err := edit(data)
+	if err != nil {
+		return err
+	}
+	if out, err = cookies.Encrypt(out); err != nil {
+		return err
+	}
+	return fileutil.ReplaceFile(path, out, 0644)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 数据目录包含浏览器 profile，遍历较慢，结果缓存一段时间
+const (
+	diskUsageCacheTTL    = time.Minute
+	dashboardMaxFailures = 20
+)
+
+// DashboardUserCounts 账号状态计数
+type DashboardUserCounts struct {
+	Total        int `json:"total"`
+	Running      int `json:"running"`
+	Starting     int `json:"starting"`
+	Stopped      int `json:"stopped"` // 未运行（含从未启动、已退出、启动失败）
+	Failed       int `json:"failed"`  // 最近一次启动失败
+	CrashLooping int `json:"crash_looping"`
+	LoggedOut    int `json:"logged_out"` // cookies 缺失或全部过期
+}
+
+// DashboardFailure 最近失败记录
+type DashboardFailure struct {
+	UserID   string `json:"user_id"`
+	At       string `json:"at"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DashboardResponse 总览
+type DashboardResponse struct {
+	Users          DashboardUserCounts `json:"users"`
+	DiskUsageBytes int64               `json:"disk_usage_bytes"`
+	ManagerVersion string              `json:"manager_version"`
+	Versions       map[string]int      `json:"versions"` // 运行中实例的版本分布
+	VersionSkew    []string            `json:"version_skew_users,omitempty"`
+	CrashLooping   []string            `json:"crash_looping_users,omitempty"`
+	LoggedOut      []string            `json:"logged_out_users,omitempty"`
+	RecentFailures []DashboardFailure  `json:"recent_failures"`
+	GeneratedAt    string              `json:"generated_at"`
+}
+
+// diskUsageCache 数据目录占用缓存
+type diskUsageCache struct {
+	mu    sync.Mutex
+	dir   string
+	bytes int64
+	at    time.Time
+}
+
+func (d *diskUsageCache) get(dir string) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dir == dir && time.Since(d.at) < diskUsageCacheTTL {
+		return d.bytes
+	}
+	d.dir, d.bytes, d.at = dir, dirSize(dir), time.Now()
+	return d.bytes
+}
+
+func dirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // 跳过无权限或已删除的文件
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// GetDashboard 账号总览
+// GET /api/admin/v1/dashboard
+func (a *App) GetDashboard(c *gin.Context) {
+	dataDir := a.store.ResolveDataDir()
+	users := a.store.ListUsers()
+	now := time.Now()
+
+	resp := DashboardResponse{
+		ManagerVersion: a.proc.self.Version,
+		Versions:       map[string]int{},
+		RecentFailures: []DashboardFailure{},
+		GeneratedAt:    now.Format(time.RFC3339),
+	}
+	resp.Users.Total = len(users)
+
+	for _, u := range users {
+		st := a.proc.GetStatus(u.ID)
+		switch {
+		case st.State == StateStarting:
+			resp.Users.Starting++
+		case st.Running:
+			resp.Users.Running++
+			version := st.Version
+			if version == "" {
+				version = "unknown"
+			}
+			resp.Versions[version]++
+			if st.VersionSkew != "" {
+				resp.VersionSkew = append(resp.VersionSkew, u.ID)
+			}
+		default:
+			resp.Users.Stopped++
+		}
+		if st.State == StateFailed {
+			resp.Users.Failed++
+		}
+		if a.proc.CrashLooping(u.ID) {
+			resp.Users.CrashLooping++
+			resp.CrashLooping = append(resp.CrashLooping, u.ID)
+		}
+
+		cookies := a.getCookieStatus(a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port).CookiesPath)
+		if cookiesLoggedOut(cookies, now) {
+			resp.Users.LoggedOut++
+			resp.LoggedOut = append(resp.LoggedOut, u.ID)
+		}
+
+		for _, f := range a.proc.RecentFailures(u.ID) {
+			resp.RecentFailures = append(resp.RecentFailures, DashboardFailure{
+				UserID:   u.ID,
+				At:       f.At.Format(time.RFC3339),
+				ExitCode: f.ExitCode,
+				Error:    f.Error,
+			})
+		}
+	}
+
+	// RFC3339 字符串可直接按字典序比较时间
+	sort.Slice(resp.RecentFailures, func(i, j int) bool {
+		return resp.RecentFailures[i].At > resp.RecentFailures[j].At
+	})
+	if len(resp.RecentFailures) > dashboardMaxFailures {
+		resp.RecentFailures = resp.RecentFailures[:dashboardMaxFailures]
+	}
+
+	resp.DiskUsageBytes = a.diskUsage.get(dataDir)
+	c.JSON(http.StatusOK, resp)
+}
+
+// cookiesLoggedOut cookies 文件缺失、为空或最晚过期时间已过
+func cookiesLoggedOut(info DebugCookieInfo, now time.Time) bool {
+	if !info.Exists || info.Count == 0 {
+		return true
+	}
+	if info.MaxExpiresAt == "" {
+		return false // 仅有会话 cookie，无法判断
+	}
+	t, err := time.Parse(time.RFC3339, info.MaxExpiresAt)
+	return err == nil && t.Before(now)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 管理界面的实时事件：通过 WebSocket 推送进程状态变化（state_changed）、登录状态变化（login_changed）、
+// 日志过大提醒（log_size_alert）以及 webhook 可订阅的其他事件，界面无需轮询账号列表。
+// 每条消息为一个 eventbus.Event JSON；?types= 过滤事件类型（逗号分隔），?replay=N 连接后先补发最近 N 条，
+// 断线重连时用 ?last_id= 补发该事件之后的事件。
+
+// StreamEvents 以 WebSocket 推送事件
+// GET /api/admin/v1/events
+func (a *App) StreamEvents(c *gin.Context) {
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !slices.Contains(dashboardEventTypes, t) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("未知事件 %q（可选 %s）", t, strings.Join(dashboardEventTypes, "、"))})
+			return
+		}
+		types = append(types, t)
+	}
+	replay := 0
+	if v := c.Query("replay"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "replay 需为非负整数"})
+			return
+		}
+		replay = n
+	}
+	if code, err := checkWebSocketRequest(c.Request); err != nil {
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	ch, backlog, cancel, ok := a.proc.events.Stream(types, c.Query("last_id"), replay)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "manager 正在退出"})
+		return
+	}
+	defer cancel()
+	ws, err := upgradeWebSocket(c.Writer, c.Request)
+	if err != nil {
+		fmt.Printf("建立事件推送连接失败: %v\n", err)
+		return
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- ws.readLoop() }()
+	for _, ev := range backlog {
+		if raw, err := json.Marshal(ev); err == nil && ws.WriteText(raw) != nil {
+			ws.Close(0)
+			return
+		}
+	}
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			ws.Close(1000)
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				ws.Close(1001) // manager 退出
+				return
+			}
+			raw, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if err := ws.WriteText(raw); err != nil {
+				ws.Close(0)
+				return
+			}
+		case <-ping.C:
+			if err := ws.Ping(); err != nil {
+				ws.Close(0)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+// wsTestFrame 客户端发送的帧需加掩码
+func wsTestFrame(opcode byte, payload []byte) []byte {
+	mask := [4]byte{1, 2, 3, 4}
+	out := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	out = append(out, mask[:]...)
+	for i, b := range payload {
+		out = append(out, b^mask[i%4])
+	}
+	return out
+}
+
+func readTestFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(h[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return h[0] & 0x0F, payload
+}
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc := NewProcessManager()
+	defer proc.CloseEvents(time.Second)
+	a := NewApp(store, proc, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/events", a.StreamEvents)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	if w := doReq(r, http.MethodGet, "/events", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非 WebSocket 请求应返回 400，got %d", w.Code)
+	}
+	upgrade := http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}, "Sec-Websocket-Version": {"13"},
+		"Sec-Websocket-Key": {"dGhlIHNhbXBsZSBub25jZQ=="}, "Origin": {"https://evil.example"}}
+	if w := doReq(r, http.MethodGet, "/events", "", upgrade); w.Code != http.StatusForbidden {
+		t.Fatalf("跨站连接应返回 403，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/events?types=nope", "", upgrade); w.Code != http.StatusBadRequest {
+		t.Fatalf("未知事件应返回 400，got %d", w.Code)
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, _ = io.WriteString(conn, "GET /events?types=state_changed HTTP/1.1\r\nHost: "+host+"\r\nOrigin: http://"+host+
+		"\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RFC 6455 中的示例
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("握手失败: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	if _, err := proc.reserve("alice"); err != nil {
+		t.Fatal(err)
+	}
+	op, payload := readTestFrame(t, br)
+	var ev struct {
+		eventbus.Event
+		Data ProcessEvent `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil || op != wsOpText {
+		t.Fatalf("frame %d %s: %v", op, payload, err)
+	}
+	if ev.Type != eventStateChanged || ev.Data.User != "alice" || ev.Data.State != string(StateStarting) || ev.Data.Prev != string(StateStopped) {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	_, _ = conn.Write(wsTestFrame(wsOpPing, []byte("hi")))
+	if op, payload := readTestFrame(t, br); op != wsOpPong || string(payload) != "hi" {
+		t.Fatalf("应回复 pong: %d %q", op, payload)
+	}
+	_, _ = conn.Write(wsTestFrame(wsOpClose, nil))
+	if op, _ := readTestFrame(t, br); op != wsOpClose {
+		t.Fatalf("应回复 close，got %d", op)
+	}
+}
+
+func TestCheckLogSizes(t *testing.T) {
+	dir := t.TempDir()
+	pm := NewProcessManager()
+	defer pm.CloseEvents(time.Second)
+	pm.SetLogRotation(LogRotationConfig{AlertMB: 1})
+	pm.procs["alice"] = &runningProc{state: StateRunning, adoptedPID: os.Getpid(), params: StartUserParams{User: UserConfig{ID: "alice"}, DataDir: dir}}
+	logFile := pm.DerivePaths(dir, "alice", 0).LogFile
+	writeSized(t, logFile+".1", 1<<20)
+	writeSized(t, logFile, 10)
+
+	ch, _, cancel, _ := pm.events.Stream([]string{eventLogSizeAlert}, "", 0)
+	defer cancel()
+	pm.checkLogSizes()
+	pm.checkLogSizes() // 仍超过阈值时不重复提醒
+	select {
+	case ev := <-ch:
+		if d := ev.Data.(ProcessEvent); d.User != "alice" || d.Size != 1<<20+10 {
+			t.Fatalf("event = %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("应发布 log_size_alert")
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("不应重复提醒: %+v", ev)
+	default:
+	}
+
+	// 回落到阈值以下后再次超过时重新提醒
+	_ = os.Remove(logFile + ".1")
+	pm.checkLogSizes()
+	writeSized(t, logFile+".1", 2<<20)
+	pm.checkLogSizes()
+	if len(ch) != 1 {
+		t.Fatalf("再次超过阈值应重新提醒，got %d", len(ch))
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
 )
 
 // DebugSummary 调试汇总信息
@@ -26,11 +27,12 @@ type DebugSummary struct {
 
 // DebugUserInfo 用户信息
 type DebugUserInfo struct {
-	ID       string `json:"id"`
-	Port     int    `json:"port"`
-	Running  bool   `json:"running"`
-	HealthOK bool   `json:"health_ok"`
-	URL      string `json:"url"`
+	ID       string    `json:"id"`
+	Port     int       `json:"port"`
+	State    ProcState `json:"state"`
+	Running  bool      `json:"running"`
+	HealthOK bool      `json:"health_ok"`
+	URL      string    `json:"url"`
 }
 
 // DebugLoginInfo 登录信息
@@ -48,6 +50,8 @@ type DebugCookieInfo struct {
 	Count        int    `json:"count"`
 	MinExpiresAt string `json:"min_expires_at,omitempty"`
 	MaxExpiresAt string `json:"max_expires_at,omitempty"`
+	Encrypted    bool   `json:"encrypted"`
+	Error        string `json:"error,omitempty"`
 }
 
 // DebugMCPInfo MCP信息
@@ -101,27 +105,27 @@ func (a *App) GetDebugSummary(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
 	st := a.proc.GetStatus(id)
 	healthOK := false
 	if st.Running {
-		healthOK = a.proc.CheckHealth(user.Port, 800*time.Millisecond)
+		healthOK = a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond)
 	}
 
 	summary := DebugSummary{
 		User: DebugUserInfo{
 			ID:       id,
 			Port:     user.Port,
+			State:    st.State,
 			Running:  st.Running,
 			HealthOK: healthOK,
-			URL:      fmt.Sprintf("http://127.0.0.1:%d", user.Port),
+			URL:      a.endpoint(user).String(),
 		},
 	}
 
 	// 获取登录状态
 	if st.Running && healthOK {
-		loginInfo := a.fetchLoginStatus(c.Request.Context(), user.Port)
+		loginInfo := a.fetchLoginStatus(c.Request.Context(), a.endpoint(user))
 		summary.Login = loginInfo
 	}
 
@@ -130,7 +134,7 @@ func (a *App) GetDebugSummary(c *gin.Context) {
 
 	// 检查MCP可达性
 	if st.Running && healthOK {
-		summary.MCP.Reachable = a.checkMCPReachable(c.Request.Context(), user.Port)
+		summary.MCP.Reachable = a.checkMCPReachable(c.Request.Context(), a.endpoint(user))
 	}
 
 	c.JSON(http.StatusOK, summary)
@@ -157,14 +161,14 @@ func (a *App) GetDebugLoginQRCode(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
 
 	// 转发到用户实例
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/qrcode", user.Port)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 60*time.Second)
+	path := "/api/v1/login/qrcode"
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 60*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -194,14 +198,14 @@ func (a *App) GetDebugLoginStatus(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
 
 	// 转发到用户实例
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/status", user.Port)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 10*time.Second)
+	path := "/api/v1/login/status"
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -231,14 +235,14 @@ func (a *App) GetDebugBrowserScreenshot(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
 
 	// 转发到用户实例
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/browser/screenshot", user.Port)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 10*time.Second)
+	path := "/api/v1/login/browser/screenshot"
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -247,6 +251,37 @@ func (a *App) GetDebugBrowserScreenshot(c *gin.Context) {
 	c.Data(status, contentType, data)
 }
 
+// GetDebugScreenshot 获取实例浏览器当前页面截图（PNG），页面地址通过 X-Page-URL 返回
+func (a *App) GetDebugScreenshot(c *gin.Context) {
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, ep.URL("/api/v1/debug/screenshot"), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := ep.HTTPClient(15 * time.Second).Do(req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("读取截图失败: %v", err)})
+		return
+	}
+
+	if url := resp.Header.Get("X-Page-URL"); url != "" {
+		c.Header("X-Page-URL", url)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), data)
+}
+
 // PostDebugBrowserAction 发送浏览器交互动作
 func (a *App) PostDebugBrowserAction(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -268,7 +303,7 @@ func (a *App) PostDebugBrowserAction(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -281,8 +316,8 @@ func (a *App) PostDebugBrowserAction(c *gin.Context) {
 	}
 
 	// 转发到用户实例
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/browser/action", user.Port)
-	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), url, bytes.NewReader(body), 10*time.Second)
+	path := "/api/v1/login/browser/action"
+	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), a.endpoint(user), path, bytes.NewReader(body), 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -311,13 +346,13 @@ func (a *App) GetDebugFlowSessions(c *gin.Context) {
 		return
 	}
 
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
 
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/debug/sessions", user.Port)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 10*time.Second)
+	path := "/api/v1/debug/sessions"
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -350,7 +385,7 @@ func (a *App) GetDebugFlowStream(c *gin.Context) {
 		return
 	}
 
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -359,8 +394,8 @@ func (a *App) GetDebugFlowStream(c *gin.Context) {
 	if q != "" {
 		q = "?" + q
 	}
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/debug/sessions/%s/stream%s", user.Port, sid, q)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 10*time.Second)
+	path := fmt.Sprintf("/api/v1/debug/sessions/%s/stream%s", sid, q)
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -393,7 +428,7 @@ func (a *App) GetDebugFlowScreenshot(c *gin.Context) {
 		return
 	}
 
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -402,8 +437,8 @@ func (a *App) GetDebugFlowScreenshot(c *gin.Context) {
 	if q != "" {
 		q = "?" + q
 	}
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/debug/sessions/%s/browser/screenshot%s", user.Port, sid, q)
-	status, contentType, data, err := a.proxyGet(c.Request.Context(), url, 10*time.Second)
+	path := fmt.Sprintf("/api/v1/debug/sessions/%s/browser/screenshot%s", sid, q)
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -436,7 +471,7 @@ func (a *App) PostDebugFlowControl(c *gin.Context) {
 		return
 	}
 
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -447,8 +482,8 @@ func (a *App) PostDebugFlowControl(c *gin.Context) {
 		return
 	}
 
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/debug/sessions/%s/control", user.Port, sid)
-	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), url, bytes.NewReader(body), 10*time.Second)
+	path := fmt.Sprintf("/api/v1/debug/sessions/%s/control", sid)
+	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), a.endpoint(user), path, bytes.NewReader(body), 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -481,7 +516,7 @@ func (a *App) PostDebugFlowBrowserAction(c *gin.Context) {
 		return
 	}
 
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -492,8 +527,8 @@ func (a *App) PostDebugFlowBrowserAction(c *gin.Context) {
 		return
 	}
 
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/debug/sessions/%s/browser/action", user.Port, sid)
-	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), url, bytes.NewReader(body), 10*time.Second)
+	path := fmt.Sprintf("/api/v1/debug/sessions/%s/browser/action", sid)
+	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), a.endpoint(user), path, bytes.NewReader(body), 10*time.Second)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
 		return
@@ -515,8 +550,7 @@ func (a *App) GetDebugCookies(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
 	info := a.getCookieStatus(paths.CookiesPath)
 
 	c.JSON(http.StatusOK, info)
@@ -557,51 +591,17 @@ func (a *App) ImportDebugCookies(c *gin.Context) {
 		return
 	}
 
-	// 验证JSON格式：必须是数组，元素必须是对象
-	var arr []map[string]any
-	if err := json.Unmarshal(raw, &arr); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON：需要 cookies 数组"})
+	arr, err := parseCookiesJSON(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	for i, ck := range arr {
-		if ck == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("第 %d 条 cookie 不是对象", i+1)})
-			return
-		}
-		// 严格类型校验：name字段必须是非空字符串
-		name, ok := ck["name"].(string)
-		if !ok || strings.TrimSpace(name) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("第 %d 条 cookie 缺少有效的 name 字段", i+1)})
-			return
-		}
-	}
 
 	// 获取cookie文件路径
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
-
-	// 确保目录存在
-	if dir := filepath.Dir(paths.CookiesPath); dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建目录失败: %v", err)})
-			return
-		}
-	}
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
 
-	// 序列化并保存（原子写入：先写临时文件再重命名）
-	normalized, err := json.Marshal(arr)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "JSON 序列化失败"})
-		return
-	}
-	tmpPath := paths.CookiesPath + ".tmp"
-	if err := os.WriteFile(tmpPath, normalized, 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存 cookies 失败: %v", err)})
-		return
-	}
-	if err := os.Rename(tmpPath, paths.CookiesPath); err != nil {
-		_ = os.Remove(tmpPath) // 清理临时文件
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存 cookies 失败: %v", err)})
+	if err := saveCookiesFile(paths.CookiesPath, arr); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -622,6 +622,51 @@ func (a *App) ImportDebugCookies(c *gin.Context) {
 	})
 }
 
+// parseCookiesJSON 校验导入的 cookies：必须是数组，元素必须是带非空 name 的对象
+func parseCookiesJSON(raw []byte) ([]map[string]any, error) {
+	var arr []map[string]any
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return nil, fmt.Errorf("无效 JSON：需要 cookies 数组")
+	}
+	for i, ck := range arr {
+		if ck == nil {
+			return nil, fmt.Errorf("第 %d 条 cookie 不是对象", i+1)
+		}
+		// 严格类型校验：name字段必须是非空字符串
+		name, ok := ck["name"].(string)
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("第 %d 条 cookie 缺少有效的 name 字段", i+1)
+		}
+	}
+	return arr, nil
+}
+
+// saveCookiesFile 保存 cookies（原子写入：先写临时文件再重命名）
+func saveCookiesFile(path string, arr []map[string]any) error {
+	// 确保目录存在
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+	normalized, err := json.Marshal(arr)
+	if err != nil {
+		return fmt.Errorf("JSON 序列化失败")
+	}
+	if normalized, err = cookies.Encrypt(normalized); err != nil {
+		return fmt.Errorf("加密 cookies 失败: %v", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, normalized, 0644); err != nil {
+		return fmt.Errorf("保存 cookies 失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) // 清理临时文件
+		return fmt.Errorf("保存 cookies 失败: %v", err)
+	}
+	return nil
+}
+
 // DeleteDebugCookies 删除Cookie
 func (a *App) DeleteDebugCookies(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -636,17 +681,16 @@ func (a *App) DeleteDebugCookies(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
 	st := a.proc.GetStatus(id)
 
 	var mode string
 	var message string
 
 	// 如果用户实例运行中，优先调用用户实例API
-	if st.Running && a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
-		url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/cookies", user.Port)
-		err := a.proxyDelete(c.Request.Context(), url, 10*time.Second)
+	if st.Running && a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
+		path := "/api/v1/login/cookies"
+		err := a.proxyDelete(c.Request.Context(), a.endpoint(user), path, 10*time.Second)
 		if err != nil {
 			// 回退到直接删除文件
 			if err := os.Remove(paths.CookiesPath); err != nil && !os.IsNotExist(err) {
@@ -698,12 +742,12 @@ func (a *App) GetDebugMCPTools(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
 
-	tools, err := a.fetchMCPTools(c.Request.Context(), user.Port)
+	tools, err := a.fetchMCPTools(c.Request.Context(), a.endpoint(user))
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("获取MCP工具列表失败: %v", err)})
 		return
@@ -712,6 +756,38 @@ func (a *App) GetDebugMCPTools(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"tools": tools})
 }
 
+// GetDebugMCPHistory 查询实例的 MCP 工具调用记录，支持 tool/status/since/limit 过滤
+func (a *App) GetDebugMCPHistory(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
+		return
+	}
+
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	st := a.proc.GetStatus(id)
+	if !st.Running {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户进程未运行"})
+		return
+	}
+
+	q := c.Request.URL.RawQuery
+	if q != "" {
+		q = "?" + q
+	}
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), a.endpoint(user), "/api/v1/mcp/history"+q, 10*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	c.Data(status, contentType, data)
+}
+
 // PostDebugMCPCall 调用MCP工具
 func (a *App) PostDebugMCPCall(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -733,7 +809,7 @@ func (a *App) PostDebugMCPCall(c *gin.Context) {
 	}
 
 	// 健康检查
-	if !a.proc.CheckHealth(user.Port, 800*time.Millisecond) {
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
 		return
 	}
@@ -749,9 +825,14 @@ func (a *App) PostDebugMCPCall(c *gin.Context) {
 		return
 	}
 
+	args, err := a.mediaArguments(user, req.Arguments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	timeout := normalizeMCPCallTimeout(req.Name, req.TimeoutMs)
 
-	result, err := a.callMCPTool(c.Request.Context(), user.Port, req.Name, req.Arguments, timeout)
+	result, err := a.callMCPTool(c.Request.Context(), a.endpoint(user), req.Name, args, timeout)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("调用MCP工具失败: %v", err)})
 		return
@@ -786,20 +867,27 @@ func isLongRunningMCPTool(toolName string) bool {
 
 // 辅助方法
 
-func (a *App) fetchLoginStatus(ctx context.Context, port int) DebugLoginInfo {
-	url := fmt.Sprintf("http://127.0.0.1:%d/api/v1/login/status", port)
+func (a *App) fetchLoginStatus(ctx context.Context, ep InstanceEndpoint) DebugLoginInfo {
+	info, err := a.queryLoginStatus(ctx, ep)
+	if err != nil {
+		fmt.Printf("[DEBUG] fetchLoginStatus 失败: addr=%s err=%v\n", ep, err)
+		return DebugLoginInfo{}
+	}
+	return info
+}
+
+// queryLoginStatus 查询实例登录状态，区分“未登录”与请求失败
+func (a *App) queryLoginStatus(ctx context.Context, ep InstanceEndpoint) (DebugLoginInfo, error) {
 	// 登录状态检查需要启动浏览器并导航页面，增加超时到30秒
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	status, _, data, err := a.proxyGet(ctx, url, 30*time.Second)
+	status, _, data, err := a.proxyGet(ctx, ep, "/api/v1/login/status", 30*time.Second)
 	if err != nil {
-		fmt.Printf("[DEBUG] fetchLoginStatus 请求失败: port=%d err=%v\n", port, err)
-		return DebugLoginInfo{}
+		return DebugLoginInfo{}, fmt.Errorf("请求失败: %w", err)
 	}
 	if status >= 400 {
-		fmt.Printf("[DEBUG] fetchLoginStatus 状态码异常: port=%d status=%d body=%s\n", port, status, string(data))
-		return DebugLoginInfo{}
+		return DebugLoginInfo{}, fmt.Errorf("状态码异常: status=%d body=%s", status, string(data))
 	}
 
 	// 解析响应
@@ -811,19 +899,15 @@ func (a *App) fetchLoginStatus(ctx context.Context, port int) DebugLoginInfo {
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(data, &resp); err != nil {
-		fmt.Printf("[DEBUG] fetchLoginStatus JSON解析失败: port=%d err=%v body=%s\n", port, err, string(data))
-		return DebugLoginInfo{}
+		return DebugLoginInfo{}, fmt.Errorf("JSON解析失败: %w body=%s", err, string(data))
 	}
 	if !resp.Success {
-		fmt.Printf("[DEBUG] fetchLoginStatus success=false: port=%d body=%s\n", port, string(data))
-		return DebugLoginInfo{}
+		return DebugLoginInfo{}, fmt.Errorf("success=false body=%s", string(data))
 	}
-
-	fmt.Printf("[DEBUG] fetchLoginStatus 成功: port=%d is_logged_in=%v username=%s\n", port, resp.Data.IsLoggedIn, resp.Data.Username)
 	return DebugLoginInfo{
 		IsLoggedIn: resp.Data.IsLoggedIn,
 		Username:   resp.Data.Username,
-	}
+	}, nil
 }
 
 func (a *App) getCookieStatus(cookiePath string) DebugCookieInfo {
@@ -848,17 +932,22 @@ func (a *App) getCookieStatus(cookiePath string) DebugCookieInfo {
 	if err != nil {
 		return info
 	}
+	info.Encrypted = cookies.IsEncrypted(data)
+	if data, err = cookies.Decrypt(data); err != nil {
+		info.Error = err.Error()
+		return info
+	}
 
-	var cookies []map[string]any
-	if err := json.Unmarshal(data, &cookies); err != nil {
+	var list []map[string]any
+	if err := json.Unmarshal(data, &list); err != nil {
 		return info
 	}
 
-	info.Count = len(cookies)
+	info.Count = len(list)
 
 	// 计算过期时间范围
 	var minExpires, maxExpires float64
-	for _, cookie := range cookies {
+	for _, cookie := range list {
 		if expires, ok := cookie["expires"].(float64); ok && expires > 0 {
 			if minExpires == 0 || expires < minExpires {
 				minExpires = expires
@@ -879,13 +968,12 @@ func (a *App) getCookieStatus(cookiePath string) DebugCookieInfo {
 	return info
 }
 
-func (a *App) checkMCPReachable(ctx context.Context, port int) bool {
-	url := fmt.Sprintf("http://127.0.0.1:%d/mcp", port)
+func (a *App) checkMCPReachable(ctx context.Context, ep InstanceEndpoint) bool {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	client := &http.Client{Timeout: 2 * time.Second}
-	req, _ := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+	client := ep.HTTPClient(2 * time.Second)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodOptions, ep.URL("/mcp"), nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		return false
@@ -895,13 +983,13 @@ func (a *App) checkMCPReachable(ctx context.Context, port int) bool {
 }
 
 // proxyGet 转发GET请求，返回状态码、Content-Type和响应体
-func (a *App) proxyGet(ctx context.Context, url string, timeout time.Duration) (int, string, []byte, error) {
-	return a.proxyRequest(ctx, http.MethodGet, url, timeout)
+func (a *App) proxyGet(ctx context.Context, ep InstanceEndpoint, path string, timeout time.Duration) (int, string, []byte, error) {
+	return a.proxyRequest(ctx, ep, http.MethodGet, path, timeout)
 }
 
 // proxyDelete 转发DELETE请求
-func (a *App) proxyDelete(ctx context.Context, url string, timeout time.Duration) error {
-	status, _, body, err := a.proxyRequest(ctx, http.MethodDelete, url, timeout)
+func (a *App) proxyDelete(ctx context.Context, ep InstanceEndpoint, path string, timeout time.Duration) error {
+	status, _, body, err := a.proxyRequest(ctx, ep, http.MethodDelete, path, timeout)
 	if err != nil {
 		return err
 	}
@@ -912,9 +1000,9 @@ func (a *App) proxyDelete(ctx context.Context, url string, timeout time.Duration
 }
 
 // proxyRequest 通用HTTP请求转发
-func (a *App) proxyRequest(ctx context.Context, method, url string, timeout time.Duration) (int, string, []byte, error) {
-	client := &http.Client{Timeout: timeout}
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+func (a *App) proxyRequest(ctx context.Context, ep InstanceEndpoint, method, path string, timeout time.Duration) (int, string, []byte, error) {
+	client := ep.HTTPClient(timeout)
+	req, err := http.NewRequestWithContext(ctx, method, ep.URL(path), nil)
 	if err != nil {
 		return 0, "", nil, err
 	}
@@ -939,9 +1027,9 @@ func (a *App) proxyRequest(ctx context.Context, method, url string, timeout time
 }
 
 // proxyPostJSON 转发POST请求（带JSON body）
-func (a *App) proxyPostJSON(ctx context.Context, url string, body io.Reader, timeout time.Duration) (int, string, []byte, error) {
-	client := &http.Client{Timeout: timeout}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+func (a *App) proxyPostJSON(ctx context.Context, ep InstanceEndpoint, path string, body io.Reader, timeout time.Duration) (int, string, []byte, error) {
+	client := ep.HTTPClient(timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL(path), body)
 	if err != nil {
 		return 0, "", nil, err
 	}
@@ -975,9 +1063,22 @@ type LogsResponse struct {
 	Content    string `json:"content"`
 	Truncated  bool   `json:"truncated"`
 	TotalLines int    `json:"total_lines"`
+
+	Offset     int64 `json:"offset"`          // 本次内容在文件中的起始字节
+	NextOffset int64 `json:"next_offset"`     // 下次以 ?offset= 增量读取的位置
+	Reset      bool  `json:"reset,omitempty"` // offset 超出文件大小（日志已轮转或清空），已从头读取
 }
 
+const (
+	logTailMaxLines  = 5000
+	logTailMaxBytes  = 4 << 20 // ?tail= 最多向前读取的字节数
+	logChunkMaxBytes = 1 << 20 // ?offset= 单次最多返回的字节数
+)
+
 // GetDebugLogs 获取用户实例日志
+// 默认返回最后 ?lines= 行；?tail=N 从文件末尾向前读取最后 N 行，
+// ?offset=B 返回从字节 B 开始的新内容，配合 next_offset 增量轮询，避免每次拉取整个文件；
+// ?stream=stderr 读取标准错误日志
 func (a *App) GetDebugLogs(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
 	if id == "" {
@@ -991,14 +1092,17 @@ func (a *App) GetDebugLogs(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+	logFile, ok := selectLogFile(c, paths)
+	if !ok {
+		return
+	}
 
 	resp := LogsResponse{
-		LogFile: paths.LogFile,
+		LogFile: logFile,
 	}
 
-	stat, err := os.Stat(paths.LogFile)
+	stat, err := os.Stat(logFile)
 	if os.IsNotExist(err) {
 		c.JSON(http.StatusOK, resp)
 		return
@@ -1011,6 +1115,47 @@ func (a *App) GetDebugLogs(c *gin.Context) {
 	resp.Exists = true
 	resp.SizeBytes = stat.Size()
 
+	tailParam, offsetParam := c.Query("tail"), c.Query("offset")
+	if tailParam != "" && offsetParam != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tail 与 offset 不能同时指定"})
+		return
+	}
+	if tailParam != "" {
+		n, err := strconv.Atoi(tailParam)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tail 需为正整数"})
+			return
+		}
+		if n > logTailMaxLines {
+			n = logTailMaxLines
+		}
+		chunk, err := readLogTail(logFile, n)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取日志失败: %v", err)})
+			return
+		}
+		chunk.fill(&resp)
+		resp.TotalLines = -1
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if offsetParam != "" {
+		offset, err := strconv.ParseInt(offsetParam, 10, 64)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset 需为非负整数"})
+			return
+		}
+		chunk, err := readLogFrom(logFile, offset, logChunkMaxBytes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取日志失败: %v", err)})
+			return
+		}
+		chunk.fill(&resp)
+		resp.TotalLines = -1
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
 	// 读取最后N行，默认200行，最大1000行
 	lines := 200
 	if linesParam := c.Query("lines"); linesParam != "" {
@@ -1022,7 +1167,7 @@ func (a *App) GetDebugLogs(c *gin.Context) {
 		}
 	}
 
-	content, totalLines, err := readLastLines(paths.LogFile, lines)
+	content, totalLines, err := readLastLines(logFile, lines)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取日志失败: %v", err)})
 		return
@@ -1044,10 +1189,140 @@ func (a *App) GetDebugLogs(c *gin.Context) {
 		resp.Lines = min(lines, totalLines)
 		resp.Truncated = totalLines > lines
 	}
+	resp.NextOffset = resp.SizeBytes
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// logChunk 按字节范围读取的日志片段
+type logChunk struct {
+	Content   string
+	Offset    int64
+	Next      int64
+	Size      int64
+	Truncated bool // tail：前面还有更早的内容；offset：后面还有未返回的内容
+	Reset     bool
+}
+
+func (ch logChunk) fill(resp *LogsResponse) {
+	resp.Content = ch.Content
+	resp.Lines = countLines(ch.Content)
+	resp.Truncated = ch.Truncated
+	resp.Offset = ch.Offset
+	resp.NextOffset = ch.Next
+	resp.Reset = ch.Reset
+	resp.SizeBytes = ch.Size
+}
+
+func countLines(s string) int {
+	n := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// readLogTail 从文件末尾按块向前读取，直到凑够 n 行或达到 logTailMaxBytes
+func readLogTail(filePath string, n int) (logChunk, error) {
+	const blockSize = 64 << 10
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return logChunk{}, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return logChunk{}, err
+	}
+	size := stat.Size()
+
+	pos := size
+	var data []byte
+	newlines := 0
+	for pos > 0 && len(data) < logTailMaxBytes {
+		step := int64(blockSize)
+		if step > pos {
+			step = pos
+		}
+		pos -= step
+		block := make([]byte, step)
+		if _, err := file.ReadAt(block, pos); err != nil && err != io.EOF {
+			return logChunk{}, err
+		}
+		newlines += bytes.Count(block, []byte{'\n'})
+		data = append(block, data...)
+		// 末尾换行之外还需要 n 个换行才能确定第 n 行的起点
+		if newlines > n {
+			break
+		}
+	}
+
+	start := pos
+	body := bytes.TrimRight(data, "\n")
+	lines := bytes.Split(body, []byte{'\n'})
+	if len(lines) > n || pos > 0 {
+		// 起始行可能不完整（读取上限截断），或多于所需
+		drop := len(lines) - n
+		if drop < 1 && pos > 0 {
+			drop = 1
+		}
+		if drop > 0 {
+			skip := 0
+			for _, l := range lines[:drop] {
+				skip += len(l) + 1
+			}
+			start += int64(skip)
+			lines = lines[drop:]
+		}
+	}
+	return logChunk{
+		Content:   string(bytes.Join(lines, []byte{'\n'})),
+		Offset:    start,
+		Next:      size,
+		Size:      size,
+		Truncated: start > 0,
+	}, nil
+}
+
+// readLogFrom 读取从 offset 开始的内容，最多 limit 字节；被截断时只返回完整的行
+func readLogFrom(filePath string, offset int64, limit int) (logChunk, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return logChunk{}, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return logChunk{}, err
+	}
+	size := stat.Size()
+
+	ch := logChunk{Size: size}
+	if offset > size {
+		offset, ch.Reset = 0, true
+	}
+	n := size - offset
+	if n > int64(limit) {
+		n, ch.Truncated = int64(limit), true
+	}
+	data := make([]byte, n)
+	read, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return logChunk{}, err
+	}
+	data = data[:read]
+	if ch.Truncated {
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			data = data[:i+1]
+		}
+	}
+	ch.Content = string(data)
+	ch.Offset = offset
+	ch.Next = offset + int64(len(data))
+	return ch, nil
+}
+
 // readLastLines 读取文件最后N行
 // 对小文件直接读取，对大文件只读取末尾部分以避免OOM
 func readLastLines(filePath string, n int) (string, int, error) {
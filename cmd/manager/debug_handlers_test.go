@@ -1,6 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -37,3 +41,70 @@ func TestIsLongRunningMCPTool(t *testing.T) {
 		t.Fatalf("check_login_status 不应识别为长任务")
 	}
 }
+
+func TestRewriteDevToolsWS(t *testing.T) {
+	got := rewriteDevToolsWS("ws://127.0.0.1:9222/devtools/page/ABC", "localhost:18050", devToolsBase("u1"))
+	want := "localhost:18050/api/admin/v1/users/u1/debug/devtools/devtools/page/ABC"
+	if got != want {
+		t.Fatalf("rewriteDevToolsWS = %q, want %q", got, want)
+	}
+	if got := rewriteDevToolsWS("", "localhost:18050", devToolsBase("u1")); got != "" {
+		t.Fatalf("空地址应返回空，got %q", got)
+	}
+}
+
+func TestReadLogWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "u1.log")
+	var sb strings.Builder
+	for i := 1; i <= 20000; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	size := int64(sb.Len())
+
+	tail, err := readLogTail(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail.Content != "line 19998\nline 19999\nline 20000" || !tail.Truncated || tail.Next != size {
+		t.Fatalf("tail 结果不符: %+v", tail)
+	}
+	if got := sb.String()[tail.Offset:]; !strings.HasPrefix(got, "line 19998\n") {
+		t.Fatalf("tail 起始偏移不符: %q", got[:20])
+	}
+
+	// 从 tail 的 next_offset 增量读取
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("line 20001\nline 20002\n")
+	f.Close()
+	next, err := readLogFrom(path, tail.Next, logChunkMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Content != "line 20001\nline 20002\n" || next.Truncated || next.Reset {
+		t.Fatalf("增量读取不符: %+v", next)
+	}
+
+	// 超过单次上限时只返回完整的行
+	part, err := readLogFrom(path, 0, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Content != "line 1\nline 2\nline 3\n" || !part.Truncated || part.Next != int64(len(part.Content)) {
+		t.Fatalf("截断读取不符: %+v", part)
+	}
+
+	// 日志被截断后 offset 超出文件大小，从头读取
+	if err := os.WriteFile(path, []byte("fresh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reset, err := readLogFrom(path, next.Next, logChunkMaxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reset.Reset || reset.Content != "fresh\n" {
+		t.Fatalf("轮转后应从头读取: %+v", reset)
+	}
+}
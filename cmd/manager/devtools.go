@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevToolsTarget 浏览器中的一个可调试目标（页面），地址已改写为经 manager 代理的路径
+type DevToolsTarget struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	InspectorURL string `json:"inspector_url,omitempty"` // 在浏览器中打开即可使用 DevTools
+	WebSocketURL string `json:"websocket_url,omitempty"` // CDP 调试连接
+}
+
+// devToolsBase 账号 DevTools 代理在 manager 上的路径前缀
+func devToolsBase(id string) string {
+	return "/api/admin/v1/users/" + url.PathEscape(id) + "/debug/devtools"
+}
+
+func (a *App) devToolsEndpoint(c *gin.Context) (InstanceEndpoint, bool) {
+	if !a.proc.DevToolsEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未开启 DevTools 代理，请使用 -enable-devtools 启动 manager 并重启该账号"})
+		return InstanceEndpoint{}, false
+	}
+	return a.runningEndpoint(c)
+}
+
+// GetDebugDevTools 列出实例浏览器中的页面及对应的 DevTools 地址
+// GET /api/admin/v1/users/:id/debug/devtools
+func (a *App) GetDebugDevTools(c *gin.Context) {
+	ep, ok := a.devToolsEndpoint(c)
+	if !ok {
+		return
+	}
+	status, contentType, data, err := a.proxyGet(c.Request.Context(), ep, "/api/v1/debug/devtools/json/list", 10*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	if status != http.StatusOK {
+		c.Data(status, contentType, data)
+		return
+	}
+
+	var raw []struct {
+		ID                   string `json:"id"`
+		Type                 string `json:"type"`
+		Title                string `json:"title"`
+		URL                  string `json:"url"`
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("解析调试目标失败: %v", err)})
+		return
+	}
+
+	id := strings.TrimSpace(c.Param("id"))
+	targets := make([]DevToolsTarget, 0, len(raw))
+	for _, r := range raw {
+		t := DevToolsTarget{ID: r.ID, Type: r.Type, Title: r.Title, URL: r.URL}
+		if ws := rewriteDevToolsWS(r.WebSocketDebuggerURL, c.Request.Host, devToolsBase(id)); ws != "" {
+			t.WebSocketURL = "ws://" + ws
+			t.InspectorURL = devToolsBase(id) + "/devtools/inspector.html?ws=" + ws
+		}
+		targets = append(targets, t)
+	}
+	c.JSON(http.StatusOK, gin.H{"targets": targets, "count": len(targets)})
+}
+
+// rewriteDevToolsWS 把 Chrome 返回的 ws://127.0.0.1:port/devtools/page/<id> 改写为
+// manager 上的代理地址（不含协议），无法识别时返回空
+func rewriteDevToolsWS(raw, host, base string) string {
+	u, err := url.Parse(raw)
+	if err != nil || !strings.HasPrefix(u.Path, "/devtools/") {
+		return ""
+	}
+	return host + base + u.Path
+}
+
+// ProxyDebugDevTools 反向代理到实例的 DevTools 代理，支持 DevTools 前端静态资源与 websocket
+// ANY /api/admin/v1/users/:id/debug/devtools/*path
+func (a *App) ProxyDebugDevTools(c *gin.Context) {
+	ep, ok := a.devToolsEndpoint(c)
+	if !ok {
+		return
+	}
+	target, err := url.Parse(ep.URL("/api/v1/debug/devtools"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = target.Path + c.Param("path")
+			req.URL.RawPath = ""
+			req.Host = target.Host
+		},
+		Transport: ep.Transport(),
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Printf("DevTools 代理失败 %s: %v\n", c.Param("id"), err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiskUsage 用户数据目录的磁盘占用（字节）
+type DiskUsage struct {
+	UserID     string `json:"user_id"`
+	Profile    int64  `json:"profile"`     // 浏览器用户目录，含缓存
+	Cache      int64  `json:"cache"`       // 其中可安全清理的浏览器缓存
+	Logs       int64  `json:"logs"`        // 实例日志
+	State      int64  `json:"state"`       // 任务日志、发布历史等运行状态
+	Media      int64  `json:"media"`       // 临时目录中下载的图片/视频等
+	Cookies    int64  `json:"cookies"`     // cookies 文件
+	Snapshots  int64  `json:"snapshots"`   // 浏览器数据快照
+	Total      int64  `json:"total"`       // 以上合计（缓存已包含在 profile 中）
+	QuotaBytes int64  `json:"quota_bytes"` // 配额，0 表示不限制
+	OverQuota  bool   `json:"over_quota"`
+}
+
+// chromeCacheDirs 浏览器用户目录中可删除的缓存，删除后浏览器会按需重建，不影响登录状态
+var chromeCacheDirs = struct {
+	root    []string // 相对用户数据目录
+	profile []string // 相对每个配置文件目录（Default、Profile 1 等）
+}{
+	root: []string{"GrShaderCache", "ShaderCache", "GraphiteDawnCache", "component_crx_cache", filepath.Join("Crashpad", "completed")},
+	profile: []string{
+		"Cache", "Code Cache", "GPUCache", "DawnCache", "DawnGraphiteCache", "DawnWebGPUCache",
+		filepath.Join("Service Worker", "CacheStorage"), filepath.Join("Service Worker", "ScriptCache"),
+	},
+}
+
+// cacheDirs 用户数据目录下实际存在的缓存目录
+func cacheDirs(userDataDir string) []string {
+	var out []string
+	for _, rel := range chromeCacheDirs.root {
+		out = append(out, filepath.Join(userDataDir, rel))
+	}
+	entries, _ := os.ReadDir(userDataDir)
+	for _, e := range entries {
+		if !e.IsDir() || (e.Name() != "Default" && !strings.HasPrefix(e.Name(), "Profile ")) {
+			continue
+		}
+		for _, rel := range chromeCacheDirs.profile {
+			out = append(out, filepath.Join(userDataDir, e.Name(), rel))
+		}
+	}
+	existing := out[:0]
+	for _, dir := range out {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			existing = append(existing, dir)
+		}
+	}
+	return existing
+}
+
+// userDiskUsage 统计用户各数据目录的占用
+func userDiskUsage(userID string, paths DerivedPaths, quotaMB int64) DiskUsage {
+	u := DiskUsage{
+		UserID:     userID,
+		Profile:    dirSize(paths.UserDataDir),
+		Logs:       logsSize(paths.LogFile) + logsSize(paths.ErrLogFile),
+		State:      dirSize(paths.StateDir),
+		Media:      dirSize(paths.TempDir),
+		Cookies:    dirSize(paths.CookiesPath),
+		Snapshots:  dirSize(paths.SnapshotDir),
+		QuotaBytes: quotaMB << 20,
+	}
+	for _, dir := range cacheDirs(paths.UserDataDir) {
+		u.Cache += dirSize(dir)
+	}
+	u.Total = u.Profile + u.Logs + u.State + u.Media + u.Cookies + u.Snapshots
+	u.OverQuota = u.QuotaBytes > 0 && u.Total > u.QuotaBytes
+	return u
+}
+
+// checkDiskQuota 启动前检查配额，超出时拒绝启动
+func checkDiskQuota(paths DerivedPaths, quotaMB int64) error {
+	if quotaMB <= 0 {
+		return nil
+	}
+	u := userDiskUsage("", paths, quotaMB)
+	if !u.OverQuota {
+		return nil
+	}
+	return fmt.Errorf("磁盘占用 %s 超出配额 %s，请先清理（POST /api/admin/v1/users/:id/disk/cleanup）或调大 disk_quota_mb",
+		formatBytes(u.Total), formatBytes(u.QuotaBytes))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// DiskCleanupOptions 清理范围
+type DiskCleanupOptions struct {
+	Cache bool `json:"cache"` // 浏览器缓存
+	Media bool `json:"media"` // 临时目录中下载的媒体等文件
+	Logs  bool `json:"logs"`  // 清空实例日志
+}
+
+// DiskCleanupResult 清理结果
+type DiskCleanupResult struct {
+	Freed   int64     `json:"freed"` // 释放的字节数
+	Removed []string  `json:"removed"`
+	Errors  []string  `json:"errors,omitempty"`
+	Usage   DiskUsage `json:"usage"` // 清理后的占用
+}
+
+// cleanupUserDisk 清理已停止用户的缓存与临时文件；cookies、登录数据、运行状态不会被删除
+func cleanupUserDisk(dataDir string, paths DerivedPaths, opts DiskCleanupOptions) DiskCleanupResult {
+	var res DiskCleanupResult
+	remove := func(path string) {
+		if !withinDir(dataDir, path) {
+			res.Errors = append(res.Errors, "跳过数据目录以外的路径: "+path)
+			return
+		}
+		size := dirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+			size -= dirSize(path)
+		}
+		res.Freed += size
+		res.Removed = append(res.Removed, path)
+	}
+	if opts.Cache {
+		for _, dir := range cacheDirs(paths.UserDataDir) {
+			remove(dir)
+		}
+	}
+	if opts.Media {
+		entries, _ := os.ReadDir(paths.TempDir)
+		for _, e := range entries {
+			remove(filepath.Join(paths.TempDir, e.Name()))
+		}
+	}
+	if opts.Logs {
+		for _, logFile := range []string{paths.LogFile, paths.ErrLogFile} {
+			if fi, err := os.Stat(logFile); err == nil {
+				if err := os.Truncate(logFile, 0); err != nil {
+					res.Errors = append(res.Errors, err.Error())
+				} else {
+					res.Freed += fi.Size()
+					res.Removed = append(res.Removed, logFile)
+				}
+			}
+			for _, r := range listRotatedLogs(logFile) {
+				remove(r.Path)
+			}
+		}
+	}
+	return res
+}
+
+// PurgeItem 删除账号时清除的文件或目录
+type PurgeItem struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// userPurgeTargets 删除账号时可一并清除的数据：cookies、浏览器资料、日志（含轮转的历史日志）、
+// 运行状态、临时目录、socket 与运行记录。只返回实际存在且位于数据目录内的路径
+func userPurgeTargets(dataDir string, paths DerivedPaths) []PurgeItem {
+	candidates := append(userDataPaths(paths), paths.SocketPath, paths.RunFile)
+	for _, logFile := range []string{paths.LogFile, paths.ErrLogFile} {
+		for _, r := range listRotatedLogs(logFile) {
+			candidates = append(candidates, r.Path)
+		}
+	}
+	var out []PurgeItem
+	for _, p := range candidates {
+		if _, err := os.Lstat(p); err != nil || !withinDir(dataDir, p) {
+			continue
+		}
+		out = append(out, PurgeItem{Path: p, SizeBytes: dirSize(p)})
+	}
+	return out
+}
+
+// purgeUserFiles 删除 userPurgeTargets 列出的数据，返回释放的字节数与失败原因
+func purgeUserFiles(items []PurgeItem) (int64, []string) {
+	var freed int64
+	var errs []string
+	for _, it := range items {
+		if err := os.RemoveAll(it.Path); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		freed += it.SizeBytes
+	}
+	return freed, errs
+}
+
+// logsSize 当前日志与轮转后的历史日志总大小
+func logsSize(path string) int64 {
+	total := dirSize(path)
+	for _, r := range listRotatedLogs(path) {
+		total += r.SizeBytes
+	}
+	return total
+}
+
+// withinDir path 是否位于 dir 之下（不含 dir 本身）
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return !filepath.IsAbs(rel)
+}
+
+// ListDiskUsage 所有用户的磁盘占用
+// GET /api/admin/v1/disk
+func (a *App) ListDiskUsage(c *gin.Context) {
+	dataDir := a.store.ResolveDataDir()
+	users := a.store.ListUsers()
+	out := make([]DiskUsage, 0, len(users))
+	var total int64
+	for _, u := range users {
+		usage := userDiskUsage(u.ID, a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port), u.DiskQuotaMB)
+		total += usage.Total
+		out = append(out, usage)
+	}
+	c.JSON(http.StatusOK, gin.H{"data_dir": dataDir, "total": total, "users": out})
+}
+
+// GetUserDiskUsage 单个用户的磁盘占用
+// GET /api/admin/v1/users/:id/disk
+func (a *App) GetUserDiskUsage(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, userDiskUsage(user.ID, a.proc.DerivePaths(a.store.UserDataDir(user), user.ID, user.Port), user.DiskQuotaMB))
+}
+
+// CleanupUserDisk 清理已停止用户的浏览器缓存、临时媒体文件，可选清空日志；请求体为空时清理缓存与媒体
+// POST /api/admin/v1/users/:id/disk/cleanup
+func (a *App) CleanupUserDisk(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	// 浏览器运行时缓存文件被占用，且删除可能损坏正在使用的数据
+	if st := a.proc.GetStatus(user.ID); st.Running || st.State.active() {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户进程运行中，请先停止再清理"})
+		return
+	}
+	opts := DiskCleanupOptions{Cache: true, Media: true}
+	if body, err := c.GetRawData(); err != nil || (len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &opts) != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	if !opts.Cache && !opts.Media && !opts.Logs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "至少选择一项清理内容（cache、media、logs）"})
+		return
+	}
+
+	dataDir := a.store.UserDataDir(user)
+	paths := a.proc.DerivePaths(dataDir, user.ID, user.Port)
+	res := cleanupUserDisk(dataDir, paths, opts)
+	res.Usage = userDiskUsage(user.ID, paths, user.DiskQuotaMB)
+	fmt.Printf("用户 %s 磁盘清理完成，释放 %s\n", user.ID, formatBytes(res.Freed))
+	c.JSON(http.StatusOK, res)
+}
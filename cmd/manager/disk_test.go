@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func writeSized(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserDiskUsageAndCleanup(t *testing.T) {
+	dataDir := t.TempDir()
+	paths := NewProcessManager().DerivePaths(dataDir, "u1", 18060)
+
+	writeSized(t, filepath.Join(paths.UserDataDir, "Default", "Cookies"), 100)
+	writeSized(t, filepath.Join(paths.UserDataDir, "Default", "Cache", "Cache_Data", "data_0"), 1000)
+	writeSized(t, filepath.Join(paths.UserDataDir, "GrShaderCache", "data_1"), 500)
+	writeSized(t, filepath.Join(paths.TempDir, "xiaohongshu_images", "a.jpg"), 2000)
+	writeSized(t, paths.LogFile, 300)
+	writeSized(t, paths.CookiesPath, 50)
+
+	u := userDiskUsage("u1", paths, 0)
+	if u.Profile != 1600 || u.Cache != 1500 || u.Media != 2000 || u.Logs != 300 || u.Cookies != 50 {
+		t.Fatalf("usage = %+v", u)
+	}
+	if u.Total != 3950 || u.OverQuota {
+		t.Fatalf("total = %d over=%v", u.Total, u.OverQuota)
+	}
+
+	// 配额以 MB 计，这里直接构造超额
+	if u := userDiskUsage("u1", paths, 1); u.OverQuota {
+		t.Fatalf("4KB 不应超出 1MB 配额: %+v", u)
+	}
+	writeSized(t, filepath.Join(paths.TempDir, "big.mp4"), 2<<20)
+	if err := checkDiskQuota(paths, 1); err == nil || !strings.Contains(err.Error(), "超出配额") {
+		t.Fatalf("checkDiskQuota err = %v", err)
+	}
+
+	res := cleanupUserDisk(dataDir, paths, DiskCleanupOptions{Cache: true, Media: true})
+	if len(res.Errors) > 0 {
+		t.Fatalf("cleanup errors: %v", res.Errors)
+	}
+	if res.Freed != 1500+2000+2<<20 {
+		t.Fatalf("freed = %d", res.Freed)
+	}
+	after := userDiskUsage("u1", paths, 1)
+	if after.Profile != 100 || after.Media != 0 || after.Logs != 300 || after.OverQuota {
+		t.Fatalf("after cleanup = %+v", after)
+	}
+	if _, err := os.Stat(filepath.Join(paths.UserDataDir, "Default", "Cookies")); err != nil {
+		t.Fatal("登录数据不应被清理")
+	}
+	if err := checkDiskQuota(paths, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithinDir(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "data")
+	if !withinDir(base, filepath.Join(base, "tmp", "u1", "x")) {
+		t.Fatal("子路径应在数据目录内")
+	}
+	for _, p := range []string{base, filepath.Dir(base), filepath.Join(base, "..", "other")} {
+		if withinDir(base, p) {
+			t.Fatalf("%s 不应视为数据目录内", p)
+		}
+	}
+}
+
+func TestDeleteUserPurge(t *testing.T) {
+	dir := t.TempDir()
+	store, err := LoadStore(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDir := filepath.Join(dir, "data")
+	if err := store.SetDataDir(dataDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"u1", "u2"} {
+		if err := store.CreateUser(UserConfig{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/users/:id", a.DeleteUser)
+
+	u1, _ := store.GetUser("u1")
+	paths := a.proc.DerivePaths(dataDir, "u1", u1.Port)
+	writeSized(t, paths.CookiesPath, 50)
+	writeSized(t, filepath.Join(paths.UserDataDir, "Default", "Cookies"), 100)
+	writeSized(t, paths.LogFile, 30)
+	writeSized(t, paths.LogFile+".1", 20)
+	other := a.proc.DerivePaths(dataDir, "u2", 0).CookiesPath
+	writeSized(t, other, 10)
+
+	w := doReq(r, http.MethodDelete, "/users/u1?purge=true&dry_run=true", "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"size_bytes":200`) {
+		t.Fatalf("dry_run: %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := store.GetUser("u1"); !ok {
+		t.Fatal("dry_run 不应删除账号")
+	}
+	if _, err := os.Stat(paths.CookiesPath); err != nil {
+		t.Fatal("dry_run 不应删除文件")
+	}
+
+	if w := doReq(r, http.MethodDelete, "/users/u1?purge=true", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("purge: %d %s", w.Code, w.Body.String())
+	}
+	for _, p := range []string{paths.CookiesPath, paths.UserDataDir, paths.LogFile, paths.LogFile + ".1"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s 应被删除", p)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatal("不应删除其他账号的数据")
+	}
+	if w := doReq(r, http.MethodDelete, "/users/u2?dry_run=true", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("单独的 dry_run 应返回 400，实际 %d", w.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+)
+
+// Docker 后端：指定 -docker-image 后每个实例以容器运行（docker run，前台附着），而不是直接拉起子进程。
+// 容器内使用与宿主机相同的路径挂载该账号的目录，-user-data-dir 等参数与日志位置不变，管理 API 无需区分；
+// TCP 端口只映射到宿主机 127.0.0.1，Unix socket 通过挂载 sockets 目录共享（仅 Linux 宿主机可用）。
+// 账号配置的环境变量与访问令牌只传入容器，不继承宿主机环境；docker 命令行里只出现变量名（-e KEY），
+// 值通过 docker CLI 进程的环境传递，不会出现在 ps 中。
+// 限制：
+//   - 浏览器在容器内总是无头运行；
+//   - cookies 目录为所有账号共用，整体挂载（cookies 以临时文件 + rename 写入，无法只挂载单个文件）；
+//   - 容器内的 127.0.0.1 是容器自身，宿主机上的代理需改用宿主机地址或 -docker-network=host；
+//   - 资源占用与进程树管理针对的是 docker CLI 进程，容器的内存/CPU 限制请用 -docker-memory/-docker-cpus；
+//   - 升级接口只替换本机二进制，Docker 模式下请更新镜像后重启实例。
+
+// defaultDockerBin 官方镜像中实例二进制的位置（WORKDIR 为 /app）
+const defaultDockerBin = "./app"
+
+// DockerConfig 以容器运行实例的配置，Image 为空表示不使用 Docker
+type DockerConfig struct {
+	Image     string
+	Command   string   // docker CLI，默认 docker
+	Bin       string   // 容器内实例二进制（作为 --entrypoint），为空时使用镜像自身的 ENTRYPOINT
+	CPUs      string   // --cpus
+	Memory    string   // --memory
+	Network   string   // --network
+	ExtraArgs []string // 追加到 docker run 的其他参数
+}
+
+// Enabled 是否以容器运行实例
+func (d DockerConfig) Enabled() bool {
+	return d.Image != ""
+}
+
+func (d DockerConfig) command() string {
+	if d.Command != "" {
+		return d.Command
+	}
+	return "docker"
+}
+
+// SetDocker 设置 Docker 后端，之后启动的实例生效
+func (pm *ProcessManager) SetDocker(cfg DockerConfig) {
+	pm.mu.Lock()
+	pm.docker = cfg
+	pm.mu.Unlock()
+}
+
+// Docker 当前的 Docker 后端配置
+func (pm *ProcessManager) Docker() DockerConfig {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.docker
+}
+
+// containerName 实例容器名，manager 据此清理残留容器
+func containerName(userID string) string {
+	return "xhs-mcp-" + userID
+}
+
+// containerMounts 实例需要读写的目录（绝对路径）
+func containerMounts(paths DerivedPaths, ep InstanceEndpoint) ([]string, error) {
+	dirs := []string{filepath.Dir(paths.CookiesPath), paths.UserDataDir, paths.StateDir, paths.TempDir}
+	if ep.IsUnix() {
+		dirs = append(dirs, filepath.Dir(ep.Socket))
+	}
+	out := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("解析挂载目录失败: %w", err)
+		}
+		out = append(out, abs)
+	}
+	return out, nil
+}
+
+// dockerRunArgs 生成 docker run 的参数。env 为传入容器的 KEY=VALUE，这里只写入变量名
+func dockerRunArgs(cfg DockerConfig, userID string, ep InstanceEndpoint, mounts, env, args []string) []string {
+	out := []string{"run", "--rm", "--init", "--name", containerName(userID)}
+	for _, dir := range mounts {
+		out = append(out, "-v", dir+":"+dir)
+	}
+	if !ep.IsUnix() {
+		port := strconv.Itoa(ep.Port)
+		out = append(out, "-p", "127.0.0.1:"+port+":"+port)
+	}
+	// 以 manager 的用户运行，容器写入的文件 manager 可以清理
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 && gid >= 0 {
+		out = append(out, "--user", strconv.Itoa(uid)+":"+strconv.Itoa(gid))
+	}
+	if cfg.CPUs != "" {
+		out = append(out, "--cpus", cfg.CPUs)
+	}
+	if cfg.Memory != "" {
+		out = append(out, "--memory", cfg.Memory)
+	}
+	if cfg.Network != "" {
+		out = append(out, "--network", cfg.Network)
+	}
+	seen := map[string]bool{}
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, "-e", key)
+	}
+	out = append(out, cfg.ExtraArgs...)
+	if cfg.Bin != "" {
+		out = append(out, "--entrypoint", cfg.Bin)
+	}
+	out = append(out, cfg.Image)
+	return append(out, args...)
+}
+
+// dockerCommand 以容器运行实例的命令；容器环境之外只保留宿主机环境供 docker CLI 使用（DOCKER_HOST 等）
+func dockerCommand(cfg DockerConfig, userID string, ep InstanceEndpoint, mounts, env, args []string) *exec.Cmd {
+	cmd := exec.Command(cfg.command(), dockerRunArgs(cfg, userID, ep, mounts, env, args)...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
+// removeContainer 强制删除实例容器：启动前清理上次残留，docker CLI 被强制终止后容器也不会遗留
+func removeContainer(cfg DockerConfig, userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel()
+	_ = exec.CommandContext(ctx, cfg.command(), "rm", "-f", containerName(userID)).Run()
+}
+
+// instanceCapabilities 实例的能力信息：Docker 模式探测镜像，否则探测本机二进制
+func (pm *ProcessManager) instanceCapabilities(bin string) compat.Info {
+	if d := pm.Docker(); d.Enabled() {
+		return pm.imageCapabilities(d)
+	}
+	return pm.Capabilities(bin)
+}
+
+// imageCapabilities 在镜像中执行 -capabilities，按镜像 ID 缓存，镜像更新后重新探测
+func (pm *ProcessManager) imageCapabilities(cfg DockerConfig) compat.Info {
+	ctx, cancel := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, cfg.command(), "image", "inspect", "--format", "{{.Id}}", cfg.Image).Output()
+	if err != nil {
+		fmt.Printf("读取镜像 %s 失败，按旧版本处理: %v\n", cfg.Image, err)
+		return compat.Info{}
+	}
+	key := "docker:" + strings.TrimSpace(string(out))
+	pm.mu.RLock()
+	cached, ok := pm.caps[key]
+	pm.mu.RUnlock()
+	if ok {
+		return cached.info
+	}
+
+	args := []string{"run", "--rm"}
+	if cfg.Bin != "" {
+		args = append(args, "--entrypoint", cfg.Bin)
+	}
+	args = append(args, cfg.Image, "-capabilities")
+	ctx2, cancel2 := context.WithTimeout(context.Background(), capabilitiesTimeout)
+	defer cancel2()
+	out, err = exec.CommandContext(ctx2, cfg.command(), args...).Output()
+	var info compat.Info
+	if err == nil {
+		info, err = compat.Parse(out)
+	}
+	if err != nil {
+		fmt.Printf("镜像 %s 未报告兼容信息，按旧版本处理: %v\n", cfg.Image, err)
+	}
+	pm.mu.Lock()
+	pm.caps[key] = cachedCaps{info: info}
+	pm.mu.Unlock()
+	return info
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	cfg := DockerConfig{Image: "xhs:latest", Bin: "./app", Memory: "2g", ExtraArgs: []string{"--shm-size=1g"}}
+	env := []string{"XHS_API_TOKEN=secret", "TMPDIR=/data/tmp/u1", "XHS_API_TOKEN=again"}
+	args := dockerRunArgs(cfg, "u1", InstanceEndpoint{Port: 18061}, []string{"/data/profiles/u1"}, env, []string{"-port=:18061"})
+	got := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"--name xhs-mcp-u1",
+		"-v /data/profiles/u1:/data/profiles/u1",
+		"-p 127.0.0.1:18061:18061",
+		"--memory 2g",
+		"--shm-size=1g --entrypoint ./app xhs:latest -port=:18061",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("缺少 %q: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Fatalf("环境变量的值不应出现在命令行中: %s", got)
+	}
+	if n := strings.Count(got, "-e XHS_API_TOKEN"); n != 1 {
+		t.Fatalf("同名变量应只传一次，got %d", n)
+	}
+
+	// Unix socket 不映射端口
+	args = dockerRunArgs(DockerConfig{Image: "xhs"}, "u2", InstanceEndpoint{Socket: "/data/sockets/u2.sock"}, nil, nil, nil)
+	if slices.Contains(args, "-p") || slices.Contains(args, "--entrypoint") || args[len(args)-1] != "xhs" {
+		t.Fatalf("参数不符: %v", args)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// unixSocketHost Unix socket 模式下 URL 中使用的占位主机名
+const unixSocketHost = "unix"
+
+// InstanceEndpoint 用户实例的访问地址：TCP 端口或 Unix socket 二选一
+type InstanceEndpoint struct {
+	Port   int
+	Socket string
+	Host   string // 实例不在本机时的地址（Kubernetes Pod IP），为空表示 127.0.0.1
+	Token  string // 实例接口访问令牌，请求时自动附带
+}
+
+// Endpoint 根据用户配置计算实例访问地址
+func (pm *ProcessManager) Endpoint(dataDir string, u UserConfig) InstanceEndpoint {
+	ep := InstanceEndpoint{Port: u.Port}
+	if u.Socket {
+		ep = InstanceEndpoint{Socket: pm.DerivePaths(dataDir, u.ID, u.Port).SocketPath}
+	}
+
+	pm.mu.RLock()
+	if p, ok := pm.procs[u.ID]; ok && p != nil && p.state.active() {
+		ep.Token = p.token
+		ep.Host = p.host
+	}
+	pm.mu.RUnlock()
+	return ep
+}
+
+// IsUnix 是否为 Unix socket 地址
+func (e InstanceEndpoint) IsUnix() bool {
+	return e.Socket != ""
+}
+
+// ListenArg 传给子进程 -port 参数的值
+func (e InstanceEndpoint) ListenArg() string {
+	if e.IsUnix() {
+		return "unix:" + e.Socket
+	}
+	return fmt.Sprintf(":%d", e.Port)
+}
+
+// BaseURL 访问实例的 HTTP 基础地址
+func (e InstanceEndpoint) BaseURL() string {
+	if e.IsUnix() {
+		return "http://" + unixSocketHost
+	}
+	host := e.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(e.Port))
+}
+
+// URL 拼接实例上的完整地址，path 需以 / 开头
+func (e InstanceEndpoint) URL(path string) string {
+	return e.BaseURL() + path
+}
+
+// String 用于展示
+func (e InstanceEndpoint) String() string {
+	if e.IsUnix() {
+		return "unix:" + e.Socket
+	}
+	return e.BaseURL()
+}
+
+// HTTPClient 返回可访问该实例的 HTTP 客户端（Unix socket 模式下改写拨号，并自动附带令牌）
+func (e InstanceEndpoint) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: e.Transport()}
+}
+
+// Transport 返回访问该实例的 RoundTripper，供没有整体超时的长连接（反向代理、websocket）使用
+func (e InstanceEndpoint) Transport() http.RoundTripper {
+	var transport http.RoundTripper = http.DefaultTransport
+	if e.IsUnix() {
+		transport = unixTransport(e.Socket)
+	}
+	if e.Token != "" {
+		transport = &tokenTransport{token: e.Token, base: transport}
+	}
+	return transport
+}
+
+// unixTransports 每个 socket 路径复用一个 Transport，避免每次请求新建连接池导致空闲连接泄漏
+var unixTransports sync.Map // socket 路径 -> *http.Transport
+
+func unixTransport(socket string) *http.Transport {
+	if t, ok := unixTransports.Load(socket); ok {
+		return t.(*http.Transport)
+	}
+	t, _ := unixTransports.LoadOrStore(socket, &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+		MaxIdleConnsPerHost: 8,
+		IdleConnTimeout:     90 * time.Second,
+	})
+	return t.(*http.Transport)
+}
+
+// tokenTransport 为每个请求附加 Authorization 头
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// validateSocketPath 校验 socket 路径长度（sun_path 上限约 108 字节）
+func validateSocketPath(p string) error {
+	if len(p) >= 104 {
+		return fmt.Errorf("unix socket 路径过长（%d 字节），请缩短 data_dir: %s", len(p), p)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUnixTransportReusesConnections(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket")
+	}
+	socket := filepath.Join(t.TempDir(), "u.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conns atomic.Int32
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) }),
+		ConnState: func(_ net.Conn, s http.ConnState) {
+			if s == http.StateNew {
+				conns.Add(1)
+			}
+		},
+	}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+
+	ep := InstanceEndpoint{Socket: socket, Token: "t"}
+	for i := 0; i < 5; i++ {
+		resp, err := ep.HTTPClient(time.Second).Get(ep.URL("/health"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+	if unixTransport(socket) != unixTransport(socket) {
+		t.Fatal("同一 socket 应复用 Transport")
+	}
+	if n := conns.Load(); n != 1 {
+		t.Fatalf("应复用空闲连接，实际建立 %d 个连接", n)
+	}
+}
@@ -5,12 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/proxyutil"
 )
 
 // App 应用
@@ -18,6 +24,24 @@ type App struct {
 	store     *Store
 	proc      *ProcessManager
 	indexHTML string
+	diskUsage diskUsageCache
+	analytics analyticsState
+	migrateMu sync.Mutex // 同一时间只进行一次数据目录迁移
+	snapMu    sync.Mutex // 快照的创建、恢复与删除串行进行
+	mediaMu   sync.Mutex // 媒体库文件的写入与删除串行进行
+	upgrade   upgradeState
+	autoStart autoStartState
+	requests  requestMetrics
+	throttle  proxyLimiter // 转发到实例的请求限流
+	posts     *postCalendar
+	cluster   *clusterNode // 集群模式下的本节点，未启用时为 nil
+	alerts    alertEngine
+	audit     *AuditLog
+	started   time.Time
+	draining  atomic.Bool // 已收到退出信号
+
+	webhookMu   sync.Mutex
+	webhookSubs []func() // 当前 webhook 订阅的取消函数
 }
 
 // NewApp 创建应用
@@ -26,7 +50,34 @@ func NewApp(store *Store, proc *ProcessManager, indexHTML string) *App {
 		store:     store,
 		proc:      proc,
 		indexHTML: indexHTML,
+		audit:     newAuditLog(filepath.Join(store.LocalDir(), auditFileName)),
+		posts:     newPostCalendar(filepath.Join(store.LocalDir(), postsFileName)),
+		started:   time.Now(),
+	}
+}
+
+// endpoint 获取用户实例的访问地址
+func (a *App) endpoint(u UserConfig) InstanceEndpoint {
+	return a.proc.Endpoint(a.store.UserDataDir(u), u)
+}
+
+// runningEndpoint 校验账号存在且进程运行中，失败时已写入响应
+func (a *App) runningEndpoint(c *gin.Context) (InstanceEndpoint, bool) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
+		return InstanceEndpoint{}, false
+	}
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return InstanceEndpoint{}, false
 	}
+	if !a.proc.GetStatus(id).Running {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户进程未运行"})
+		return InstanceEndpoint{}, false
+	}
+	return a.endpoint(user), true
 }
 
 // HandleIndex 首页
@@ -37,47 +88,120 @@ func (a *App) HandleIndex(c *gin.Context) {
 
 type userView struct {
 	ID             string `json:"id"`
+	Name           string `json:"name,omitempty"`
 	Port           int    `json:"port"`
 	Proxy          string `json:"proxy"`
 	ProxyPool      string `json:"proxy_pool_url,omitempty"`
 	EffectiveProxy string `json:"effective_proxy,omitempty"`
 	UserAgent      string `json:"user_agent"`
 	AutoStart      bool   `json:"auto_start"`
+	Socket         bool   `json:"socket"`
+	Moderation     string `json:"moderation,omitempty"`
+	DiskQuotaMB    int64  `json:"disk_quota_mb,omitempty"`
+	DataDir        string `json:"data_dir,omitempty"` // 单独迁移后的数据目录
+	ReadOnly       bool   `json:"read_only"`
+	Timezone       string `json:"timezone,omitempty"`
+	StartPriority  int    `json:"start_priority,omitempty"`
+	StopTimeoutSec int    `json:"stop_timeout_sec,omitempty"`
+
+	ProxyRatePerMin int `json:"proxy_rate_per_min,omitempty"`
 
-	URL string `json:"url"`
+	Env map[string]string `json:"env,omitempty"`
+
+	URL      string `json:"url"`
+	MCPPath  string `json:"mcp_path"`            // 经 manager 转发的 MCP 地址（相对 manager）
+	APIToken string `json:"api_token,omitempty"` // 本次运行的实例访问令牌，直连实例时需携带
 
 	CookiesPath string `json:"cookies_path"`
 	UserDataDir string `json:"user_data_dir"`
 	LogFile     string `json:"log_file"`
 
-	Running   bool   `json:"running"`
-	PID       int    `json:"pid"`
-	HealthOK  bool   `json:"health_ok"`
-	StartedAt string `json:"started_at,omitempty"`
-	LastError string `json:"last_error,omitempty"`
+	State      ProcState `json:"state"`
+	Running    bool      `json:"running"`
+	PID        int       `json:"pid"`
+	HealthOK   bool      `json:"health_ok"`
+	StartedAt  string    `json:"started_at,omitempty"`
+	Ready      bool      `json:"ready"`                // 已通过健康检查，可接受 MCP 请求；starting 阶段为 false
+	ReadyAt    string    `json:"ready_at,omitempty"`   // 本次启动就绪的时间
+	StartupMs  int64     `json:"startup_ms,omitempty"` // 从拉起到就绪的耗时
+	UptimeSec  int64     `json:"uptime_sec,omitempty"` // 运行时长，仅运行中
+	ExitedAt   string    `json:"exited_at,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	ExitSignal string    `json:"exit_signal,omitempty"` // 被信号终止时的信号名
+	LastError  string    `json:"last_error,omitempty"`
+	LastExit   *ProcExit `json:"last_exit,omitempty"` // 最近一次退出，重新启动后仍保留
+
+	Restart       string `json:"restart,omitempty"`
+	MaxRestarts   int    `json:"max_restarts,omitempty"`
+	Restarts      int    `json:"restarts"`                  // 自动重启次数
+	TotalRestarts int    `json:"total_restarts"`            // manager 启动以来再次启动的次数（含手动）
+	NextRestartAt string `json:"next_restart_at,omitempty"` // 等待中的自动重启时间
+	RestartGaveUp bool   `json:"restart_gave_up,omitempty"` // 连续重启达到上限后已放弃
+
+	Schedule     *RunSchedule   `json:"schedule,omitempty"`
+	Tags         []string       `json:"tags"`
+	NextSchedule *scheduleEvent `json:"next_schedule,omitempty"` // 下一次定时启动/停止
+
+	Resources *ResourceUsage `json:"resources,omitempty"` // 实例及浏览器进程的资源占用，仅运行中
+
+	Version     string `json:"version,omitempty"`
+	ToolSchema  int    `json:"tool_schema,omitempty"`
+	VersionSkew string `json:"version_skew,omitempty"` // 与 manager 版本不一致时的说明
+
+	Profile *AccountProfile `json:"profile,omitempty"`
+	Login   *LoginHealth    `json:"login,omitempty"`
 }
 
 type usersResponse struct {
-	Bin      string     `json:"bin"`
-	Headless bool       `json:"headless"`
-	DataDir  string     `json:"data_dir"`
-	Users    []userView `json:"users"`
+	Bin       string     `json:"bin"`
+	Headless  bool       `json:"headless"`
+	DataDir   string     `json:"data_dir"`
+	PortRange PortRange  `json:"port_range"` // 创建账号未指定端口时的自动分配范围
+	Users     []userView `json:"users"`
+	Total     int        `json:"total"`           // 符合过滤条件的账号数（分页前）
+	Page      int        `json:"page,omitempty"`  // 指定 limit 时的当前页
+	Limit     int        `json:"limit,omitempty"` // 每页数量
+
+	LoginSummary map[string]int `json:"login_summary"` // 各登录状态的账号数，未检查过的计入 unchecked
 }
 
 type managerUserView struct {
-	ID             string `json:"id"`
-	Port           int    `json:"port"`
-	Proxy          string `json:"proxy"`
-	ProxyPool      string `json:"proxy_pool_url,omitempty"`
-	EffectiveProxy string `json:"effective_proxy,omitempty"`
-	UserAgent      string `json:"user_agent"`
-	AutoStart      bool   `json:"auto_start"`
-	URL            string `json:"url"`
-	Running        bool   `json:"running"`
-	PID            int    `json:"pid"`
-	HealthOK       bool   `json:"health_ok"`
-	StartedAt      string `json:"started_at,omitempty"`
-	LastError      string `json:"last_error,omitempty"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name,omitempty"`
+	Port           int       `json:"port"`
+	Proxy          string    `json:"proxy"`
+	ProxyPool      string    `json:"proxy_pool_url,omitempty"`
+	EffectiveProxy string    `json:"effective_proxy,omitempty"`
+	UserAgent      string    `json:"user_agent"`
+	AutoStart      bool      `json:"auto_start"`
+	Socket         bool      `json:"socket"`
+	Moderation     string    `json:"moderation,omitempty"`
+	ReadOnly       bool      `json:"read_only"`
+	Timezone       string    `json:"timezone,omitempty"`
+	URL            string    `json:"url"`
+	MCPPath        string    `json:"mcp_path"`
+	State          ProcState `json:"state"`
+	Running        bool      `json:"running"`
+	PID            int       `json:"pid"`
+	HealthOK       bool      `json:"health_ok"`
+	StartedAt      string    `json:"started_at,omitempty"`
+	Ready          bool      `json:"ready"`
+	ReadyAt        string    `json:"ready_at,omitempty"`
+	UptimeSec      int64     `json:"uptime_sec,omitempty"`
+	ExitedAt       string    `json:"exited_at,omitempty"`
+	ExitCode       *int      `json:"exit_code,omitempty"`
+	ExitSignal     string    `json:"exit_signal,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastExit       *ProcExit `json:"last_exit,omitempty"`
+	Restarts       int       `json:"restarts"`
+	TotalRestarts  int       `json:"total_restarts"`
+	NextRestartAt  string    `json:"next_restart_at,omitempty"`
+
+	NextSchedule *scheduleEvent `json:"next_schedule,omitempty"`
+
+	Resources *ResourceUsage `json:"resources,omitempty"`
+
+	Login *LoginHealth `json:"login,omitempty"`
 }
 
 type managerUsersResponse struct {
@@ -89,79 +213,160 @@ type managerUserResponse struct {
 	User managerUserView `json:"user"`
 }
 
-func (a *App) buildUserView(dataDir string, u UserConfig) userView {
+func (a *App) buildUserView(u UserConfig) userView {
+	dataDir := a.store.UserDataDir(u)
 	derived := a.proc.DerivePaths(dataDir, u.ID, u.Port)
+	ep := a.proc.Endpoint(dataDir, u)
 	st := a.proc.GetStatus(u.ID)
 	healthOK := false
+	var usage *ResourceUsage
 	if st.Running {
-		healthOK = a.proc.CheckHealth(u.Port, 800*time.Millisecond)
+		healthOK = a.proc.CheckHealth(ep, 800*time.Millisecond)
+		usage, _ = a.proc.ResourceUsage(u.ID)
 	}
 	return userView{
-		ID:             u.ID,
-		Port:           u.Port,
-		Proxy:          u.Proxy,
-		ProxyPool:      u.ProxyPool,
-		EffectiveProxy: st.EffectiveProxy,
-		UserAgent:      u.UserAgent,
-		AutoStart:      u.AutoStart,
-		URL:            fmt.Sprintf("http://127.0.0.1:%d", u.Port),
-		CookiesPath:    derived.CookiesPath,
-		UserDataDir:    derived.UserDataDir,
-		LogFile:        derived.LogFile,
-		Running:        st.Running,
-		PID:            st.PID,
-		HealthOK:       healthOK,
-		StartedAt:      st.StartedAt,
-		LastError:      st.LastError,
+		ID:              u.ID,
+		Name:            u.Name,
+		Port:            u.Port,
+		Proxy:           u.Proxy,
+		ProxyPool:       u.ProxyPool,
+		EffectiveProxy:  st.EffectiveProxy,
+		UserAgent:       u.UserAgent,
+		AutoStart:       u.AutoStart,
+		Socket:          u.Socket,
+		Moderation:      u.Moderation,
+		DiskQuotaMB:     u.DiskQuotaMB,
+		DataDir:         u.DataDir,
+		ReadOnly:        u.ReadOnly,
+		Timezone:        u.Timezone,
+		StartPriority:   u.StartPriority,
+		StopTimeoutSec:  u.StopTimeoutSec,
+		ProxyRatePerMin: u.ProxyRatePerMin,
+		Env:             u.Env,
+		URL:             ep.String(),
+		MCPPath:         instanceRouteBase(u.ID) + "/mcp",
+		APIToken:        ep.Token,
+		CookiesPath:     derived.CookiesPath,
+		UserDataDir:     derived.UserDataDir,
+		LogFile:         derived.LogFile,
+		State:           st.State,
+		Running:         st.Running,
+		PID:             st.PID,
+		HealthOK:        healthOK,
+		StartedAt:       st.StartedAt,
+		Ready:           st.Ready,
+		ReadyAt:         st.ReadyAt,
+		StartupMs:       st.StartupMs,
+		UptimeSec:       st.UptimeSec,
+		ExitedAt:        st.ExitedAt,
+		ExitCode:        st.ExitCode,
+		ExitSignal:      st.ExitSignal,
+		LastError:       st.LastError,
+		LastExit:        st.LastExit,
+		Restart:         u.Restart,
+		MaxRestarts:     u.MaxRestarts,
+		Restarts:        st.Restarts,
+		TotalRestarts:   st.TotalRestarts,
+		NextRestartAt:   st.NextRestartAt,
+		RestartGaveUp:   st.RestartGaveUp,
+		Schedule:        u.Schedule,
+		Tags:            tagsOrEmpty(u.Tags),
+		NextSchedule:    nextScheduleEvent(u, time.Now()),
+		Resources:       usage,
+		Version:         st.Version,
+		ToolSchema:      st.ToolSchema,
+		VersionSkew:     st.VersionSkew,
+		Profile:         u.Profile,
+		Login:           u.Login,
 	}
 }
 
 func toManagerUserView(v userView) managerUserView {
 	return managerUserView{
 		ID:             v.ID,
+		Name:           v.Name,
 		Port:           v.Port,
 		Proxy:          v.Proxy,
 		ProxyPool:      v.ProxyPool,
 		EffectiveProxy: v.EffectiveProxy,
 		UserAgent:      v.UserAgent,
 		AutoStart:      v.AutoStart,
+		Socket:         v.Socket,
+		Moderation:     v.Moderation,
+		ReadOnly:       v.ReadOnly,
+		Timezone:       v.Timezone,
 		URL:            v.URL,
+		MCPPath:        v.MCPPath,
+		State:          v.State,
 		Running:        v.Running,
 		PID:            v.PID,
 		HealthOK:       v.HealthOK,
 		StartedAt:      v.StartedAt,
+		Ready:          v.Ready,
+		ReadyAt:        v.ReadyAt,
+		UptimeSec:      v.UptimeSec,
+		ExitedAt:       v.ExitedAt,
+		ExitCode:       v.ExitCode,
+		ExitSignal:     v.ExitSignal,
 		LastError:      v.LastError,
+		LastExit:       v.LastExit,
+		Restarts:       v.Restarts,
+		TotalRestarts:  v.TotalRestarts,
+		NextRestartAt:  v.NextRestartAt,
+		NextSchedule:   v.NextSchedule,
+		Resources:      v.Resources,
+		Login:          v.Login,
 	}
 }
 
-// ListUsers 获取用户列表
+// ListUsers 获取用户列表，支持过滤、排序与分页（参数见 user_list.go）
 func (a *App) ListUsers(c *gin.Context) {
+	q, err := parseUserListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	cfg := a.store.GetConfig()
 	binPath := a.store.ResolveBinPath()
 	dataDir := a.store.ResolveDataDir()
-	users := a.store.ListUsers()
+	users := a.filterUsers(a.store.ListUsers(), q)
 
-	out := make([]userView, 0, len(users))
-	for _, u := range users {
-		out = append(out, a.buildUserView(dataDir, u))
+	page := paginate(users, q.Page, q.Limit)
+	// 实例令牌可绕过角色直连实例，env 可能包含密钥，仅 admin 可见
+	admin := c.GetString(adminRoleKey) == RoleAdmin
+	out := make([]userView, 0, len(page))
+	for _, u := range page {
+		v := a.buildUserView(u)
+		if !admin {
+			v.APIToken, v.Env = "", nil
+		}
+		out = append(out, v)
 	}
 
-	c.JSON(http.StatusOK, usersResponse{
-		Bin:      binPath,
-		Headless: cfg.Headless,
-		DataDir:  dataDir,
-		Users:    out,
-	})
+	resp := usersResponse{
+		Bin:       binPath,
+		Headless:  cfg.Headless,
+		DataDir:   dataDir,
+		PortRange: a.store.PortRange(),
+		Users:     out,
+		Total:     len(users),
+		Limit:     q.Limit,
+
+		LoginSummary: loginSummary(users),
+	}
+	if q.Limit > 0 {
+		resp.Page = q.Page
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// ListPublicUsers 公开查询全部用户信息
+// ListPublicUsers 查询全部用户信息（精简视图）
 // GET /api/manager/v1/users
 func (a *App) ListPublicUsers(c *gin.Context) {
-	dataDir := a.store.ResolveDataDir()
 	users := a.store.ListUsers()
 	out := make([]managerUserView, 0, len(users))
 	for _, u := range users {
-		out = append(out, toManagerUserView(a.buildUserView(dataDir, u)))
+		out = append(out, toManagerUserView(a.buildUserView(u)))
 	}
 	c.JSON(http.StatusOK, managerUsersResponse{
 		Count: len(out),
@@ -169,7 +374,7 @@ func (a *App) ListPublicUsers(c *gin.Context) {
 	})
 }
 
-// GetPublicUser 公开查询单个用户信息
+// GetPublicUser 查询单个用户信息（精简视图）
 // GET /api/manager/v1/users/:id
 func (a *App) GetPublicUser(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -182,17 +387,37 @@ func (a *App) GetPublicUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
-	dataDir := a.store.ResolveDataDir()
 	c.JSON(http.StatusOK, managerUserResponse{
-		User: toManagerUserView(a.buildUserView(dataDir, user)),
+		User: toManagerUserView(a.buildUserView(user)),
 	})
 }
 
 type createUserReq struct {
 	ID        string `json:"id"`
-	Port      int    `json:"port"`
+	Name      string `json:"name"` // 备注名
+	Port      int    `json:"port"` // 为 0 时从 -port-range 中自动分配
 	Proxy     string `json:"proxy"`
 	ProxyPool string `json:"proxy_pool_url"`
+	Socket    bool   `json:"socket"`
+
+	Moderation  string `json:"moderation"`
+	DiskQuotaMB int64  `json:"disk_quota_mb"`
+	ReadOnly    bool   `json:"read_only"`
+	Timezone    string `json:"timezone"`
+
+	Env map[string]string `json:"env"`
+
+	Restart     string `json:"restart"`      // never/on-failure/always
+	MaxRestarts int    `json:"max_restarts"` // 0 使用默认 5，负数不限制
+
+	Schedule *RunSchedule `json:"schedule"` // 定时启停
+
+	Tags []string `json:"tags"`
+
+	StartPriority  int `json:"start_priority"`   // 启动恢复的优先级，越大越先启动
+	StopTimeoutSec int `json:"stop_timeout_sec"` // 停止时等待优雅退出的秒数，0 使用 -stop-timeout
+
+	ProxyRatePerMin int `json:"proxy_rate_per_min"` // 转发请求每分钟上限，0 使用 -proxy-rate，-1 不限制
 }
 
 // CreateUser 创建用户
@@ -205,23 +430,117 @@ func (a *App) CreateUser(c *gin.Context) {
 	req.ID = strings.TrimSpace(req.ID)
 	req.Proxy = strings.TrimSpace(req.Proxy)
 	req.ProxyPool = strings.TrimSpace(req.ProxyPool)
+	if err := validateProxyInput(req.Proxy, req.ProxyPool); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := a.store.CreateUser(UserConfig{
-		ID:        req.ID,
-		Port:      req.Port,
-		Proxy:     req.Proxy,
-		ProxyPool: req.ProxyPool,
+		ID:          req.ID,
+		Name:        strings.TrimSpace(req.Name),
+		Port:        req.Port,
+		Proxy:       req.Proxy,
+		ProxyPool:   req.ProxyPool,
+		Socket:      req.Socket,
+		Moderation:  strings.TrimSpace(req.Moderation),
+		DiskQuotaMB: req.DiskQuotaMB,
+		ReadOnly:    req.ReadOnly,
+		Timezone:    strings.TrimSpace(req.Timezone),
+		Env:         req.Env,
+		Restart:     strings.TrimSpace(req.Restart),
+		MaxRestarts: req.MaxRestarts,
+		Schedule:    normalizeSchedule(req.Schedule),
+		Tags:        normalizeTags(req.Tags),
+
+		StartPriority:  req.StartPriority,
+		StopTimeoutSec: req.StopTimeoutSec,
+
+		ProxyRatePerMin: req.ProxyRatePerMin,
 	}); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.Status(http.StatusCreated)
+	user, _ := a.store.GetUser(req.ID)
+	c.JSON(http.StatusCreated, gin.H{"id": user.ID, "port": user.Port, "api_key": user.APIKey})
+}
+
+type cloneUserReq struct {
+	ID   string `json:"id" binding:"required"`
+	Port int    `json:"port"` // 为 0 时从 -port-range 中自动分配
+}
+
+// CloneUser 以已有用户的配置（代理、审核策略、配额、环境变量、重启策略、定时启停、标签等）创建新用户。
+// 新用户使用新端口、全局数据目录下的空目录和新生成的 UA，不复制 cookies、账号资料与自动启动状态。
+// POST /api/admin/v1/users/:id/clone
+func (a *App) CloneUser(c *gin.Context) {
+	var req cloneUserReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 id"})
+		return
+	}
+	src, ok := a.store.GetUser(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	u := UserConfig{
+		ID:          strings.TrimSpace(req.ID),
+		Port:        req.Port,
+		Proxy:       src.Proxy,
+		ProxyPool:   src.ProxyPool,
+		Socket:      src.Socket,
+		Moderation:  src.Moderation,
+		DiskQuotaMB: src.DiskQuotaMB,
+		ReadOnly:    src.ReadOnly,
+		Timezone:    src.Timezone,
+		Env:         maps.Clone(src.Env),
+		Restart:     src.Restart,
+		MaxRestarts: src.MaxRestarts,
+		Tags:        slices.Clone(src.Tags),
+
+		StartPriority:  src.StartPriority,
+		StopTimeoutSec: src.StopTimeoutSec,
+
+		ProxyRatePerMin: src.ProxyRatePerMin,
+	}
+	if src.Schedule != nil {
+		sched := *src.Schedule
+		u.Schedule = &sched
+	}
+	if err := a.store.CreateUser(u); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, _ := a.store.GetUser(u.ID)
+	c.JSON(http.StatusCreated, gin.H{"id": user.ID, "port": user.Port, "source": src.ID, "api_key": user.APIKey})
 }
 
 type updateUserReq struct {
-	Port      int    `json:"port"`
-	Proxy     string `json:"proxy"`
-	ProxyPool string `json:"proxy_pool_url"`
+	Name      *string `json:"name"` // 为空表示不修改
+	Port      int     `json:"port"`
+	Proxy     *string `json:"proxy"`          // 为空表示不修改，空字符串表示不使用代理
+	ProxyPool *string `json:"proxy_pool_url"` // 为空表示不修改
+	Socket    *bool   `json:"socket"`         // 为空表示不修改
+
+	Moderation  *string `json:"moderation"`    // 为空表示不修改
+	DiskQuotaMB *int64  `json:"disk_quota_mb"` // 为空表示不修改
+	ReadOnly    *bool   `json:"read_only"`     // 为空表示不修改
+	Timezone    *string `json:"timezone"`      // 为空表示不修改，空字符串表示跟随服务器
+
+	Env *map[string]string `json:"env"` // 为空表示不修改，{} 表示清空
+
+	Restart     *string `json:"restart"`      // 为空表示不修改
+	MaxRestarts *int    `json:"max_restarts"` // 为空表示不修改
+
+	Schedule *RunSchedule `json:"schedule"` // 为空表示不修改，{} 表示取消定时
+
+	Tags *[]string `json:"tags"` // 为空表示不修改，[] 表示清除
+
+	StartPriority  *int `json:"start_priority"`   // 为空表示不修改
+	StopTimeoutSec *int `json:"stop_timeout_sec"` // 为空表示不修改
+
+	ProxyRatePerMin *int `json:"proxy_rate_per_min"` // 为空表示不修改
 }
 
 // UpdateUser 更新用户
@@ -241,14 +560,102 @@ func (a *App) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
 		return
 	}
-	req.Proxy = strings.TrimSpace(req.Proxy)
-	req.ProxyPool = strings.TrimSpace(req.ProxyPool)
+
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	proxy := user.Proxy
+	if req.Proxy != nil {
+		proxy = strings.TrimSpace(*req.Proxy)
+	}
+	proxyPool := user.ProxyPool
+	if req.ProxyPool != nil {
+		proxyPool = strings.TrimSpace(*req.ProxyPool)
+	}
+	if err := validateProxyInput(proxy, proxyPool); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	socket := user.Socket
+	if req.Socket != nil {
+		socket = *req.Socket
+	}
+	moderation := user.Moderation
+	if req.Moderation != nil {
+		moderation = strings.TrimSpace(*req.Moderation)
+	}
+	quota := user.DiskQuotaMB
+	if req.DiskQuotaMB != nil {
+		quota = *req.DiskQuotaMB
+	}
+	readOnly := user.ReadOnly
+	if req.ReadOnly != nil {
+		readOnly = *req.ReadOnly
+	}
+	timezone := user.Timezone
+	if req.Timezone != nil {
+		timezone = strings.TrimSpace(*req.Timezone)
+	}
+	env := user.Env
+	if req.Env != nil {
+		env = *req.Env
+	}
+	restart := user.Restart
+	if req.Restart != nil {
+		restart = strings.TrimSpace(*req.Restart)
+	}
+	maxRestarts := user.MaxRestarts
+	if req.MaxRestarts != nil {
+		maxRestarts = *req.MaxRestarts
+	}
+	schedule := user.Schedule
+	if req.Schedule != nil {
+		schedule = normalizeSchedule(req.Schedule)
+	}
+	tags := user.Tags
+	if req.Tags != nil {
+		tags = normalizeTags(*req.Tags)
+	}
+	priority := user.StartPriority
+	if req.StartPriority != nil {
+		priority = *req.StartPriority
+	}
+	stopTimeout := user.StopTimeoutSec
+	if req.StopTimeoutSec != nil {
+		stopTimeout = *req.StopTimeoutSec
+	}
+	proxyRate := user.ProxyRatePerMin
+	if req.ProxyRatePerMin != nil {
+		proxyRate = *req.ProxyRatePerMin
+	}
+	name := user.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
 
 	if err := a.store.UpdateUser(id, UserConfig{
-		ID:        id,
-		Port:      req.Port,
-		Proxy:     req.Proxy,
-		ProxyPool: req.ProxyPool,
+		ID:          id,
+		Name:        name,
+		Port:        req.Port,
+		Proxy:       proxy,
+		ProxyPool:   proxyPool,
+		Socket:      socket,
+		Moderation:  moderation,
+		DiskQuotaMB: quota,
+		ReadOnly:    readOnly,
+		Timezone:    timezone,
+		Env:         env,
+		Restart:     restart,
+		MaxRestarts: maxRestarts,
+		Schedule:    schedule,
+		Tags:        tags,
+
+		StartPriority:  priority,
+		StopTimeoutSec: stopTimeout,
+
+		ProxyRatePerMin: proxyRate,
 	}); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -256,22 +663,75 @@ func (a *App) UpdateUser(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// DeleteUser 删除用户
+// validateProxyInput 校验 API 传入的代理配置，格式错误时在保存前拒绝，而不是等到实例启动失败。
+// 已保存的配置不做此校验，避免旧数据导致 store 无法加载。
+func validateProxyInput(proxy, proxyPool string) error {
+	if _, err := proxyutil.NormalizeHTTPProxy(proxy); err != nil {
+		return fmt.Errorf("proxy 不合法: %w", err)
+	}
+	if proxyPool != "" {
+		u, err := url.Parse(proxyPool)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("proxy_pool_url 需为 http(s) 地址")
+		}
+	}
+	return nil
+}
+
+// DeleteUser 删除用户，默认保留磁盘上的数据
+// DELETE /api/admin/v1/users/:id?purge=true&dry_run=true
 func (a *App) DeleteUser(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
 		return
 	}
-	if st := a.proc.GetStatus(id); st.Running {
+	if st := a.proc.GetStatus(id); st.Running || st.State.active() {
 		c.JSON(http.StatusConflict, gin.H{"error": "用户进程运行中，请先停止再删除"})
 		return
 	}
+	purge, dryRun := c.Query("purge") == "true", c.Query("dry_run") == "true"
+	if !purge {
+		if dryRun {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run 需与 purge=true 同时使用"})
+			return
+		}
+		if err := a.store.DeleteUser(id); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		a.proc.Forget(id)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// purge=true 同时删除 cookies、浏览器资料、日志等数据；加 dry_run=true 只列出将被删除的内容
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	dataDir := a.store.UserDataDir(user)
+	items := userPurgeTargets(dataDir, a.proc.DerivePaths(dataDir, id, user.Port))
+	var total int64
+	for _, it := range items {
+		total += it.SizeBytes
+	}
+	if items == nil {
+		items = []PurgeItem{}
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{"id": id, "dry_run": true, "purge": items, "size_bytes": total})
+		return
+	}
 	if err := a.store.DeleteUser(id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.Status(http.StatusNoContent)
+	a.proc.Forget(id)
+	freed, errs := purgeUserFiles(items)
+	fmt.Printf("用户 %s 已删除，清除数据 %s\n", id, formatBytes(freed))
+	c.JSON(http.StatusOK, gin.H{"id": id, "purged": items, "freed": freed, "errors": errs})
 }
 
 // StartUser 启动用户
@@ -293,25 +753,31 @@ func (a *App) StartUser(c *gin.Context) {
 
 	cfg := a.store.GetConfig()
 	binPath := a.store.ResolveBinPath()
-	dataDir := a.store.ResolveDataDir()
 
 	if err := a.proc.StartUser(c.Request.Context(), StartUserParams{
 		User:     user,
 		BinPath:  binPath,
 		Headless: cfg.Headless,
-		DataDir:  dataDir,
+		DataDir:  a.store.UserDataDir(user),
 	}); err != nil {
+		var owned *clusterOwnedError
+		if errors.As(err, &owned) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "node": owned.Node, "addr": owned.Addr})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	// 启动成功，记录 AutoStart 状态
 	if err := a.store.SetUserAutoStart(id, true); err != nil {
 		// 落库失败，回滚进程
-		_ = a.proc.StopUser(c.Request.Context(), id, 10*time.Second)
+		_ = a.proc.StopUser(c.Request.Context(), id, 0)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.Status(http.StatusNoContent)
+	// StartUser 在实例通过健康检查后才返回
+	st := a.proc.GetStatus(id)
+	c.JSON(http.StatusOK, gin.H{"id": id, "state": st.State, "ready": st.Ready, "ready_at": st.ReadyAt, "startup_ms": st.StartupMs})
 }
 
 // StopUser 停止用户
@@ -325,8 +791,9 @@ func (a *App) StopUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
-	if err := a.proc.StopUser(c.Request.Context(), id, 10*time.Second); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	res, err := a.proc.StopUserResult(c.Request.Context(), id, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "stage": res.Stage})
 		return
 	}
 	// 用户主动停止，清除 AutoStart 状态
@@ -334,12 +801,13 @@ func (a *App) StopUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, gin.H{"id": id, "stage": res.Stage, "timeout_ms": res.TimeoutMs, "duration_ms": res.DurationMs})
 }
 
 // batchReq 批量操作请求
 type batchReq struct {
-	IDs []string `json:"ids"` // 为空表示全部用户
+	IDs  []string `json:"ids"`  // 为空表示全部用户
+	Tags []string `json:"tags"` // 按标签选择带有任一标签的账号，不能与 ids 同时指定
 }
 
 // batchResultItem 批量操作单项结果
@@ -347,6 +815,7 @@ type batchResultItem struct {
 	ID     string `json:"id"`
 	Status string `json:"status"` // started, already_running, stopped, already_stopped, not_found, error
 	Error  string `json:"error,omitempty"`
+	Stage  string `json:"stage,omitempty"` // 停止时：graceful / terminated / killed
 }
 
 // BatchStartUsers 批量启动用户
@@ -360,15 +829,13 @@ func (a *App) BatchStartUsers(c *gin.Context) {
 
 	users := a.store.ListUsers()
 	usersByID := make(map[string]UserConfig, len(users))
-	allIDs := make([]string, 0, len(users))
 	for _, u := range users {
 		usersByID[u.ID] = u
-		allIDs = append(allIDs, u.ID)
 	}
-
-	ids := req.IDs
-	if len(ids) == 0 {
-		ids = allIDs
+	ids, err := resolveBatchIDs(req, users)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if len(ids) == 0 {
@@ -381,7 +848,6 @@ func (a *App) BatchStartUsers(c *gin.Context) {
 
 	cfg := a.store.GetConfig()
 	binPath := a.store.ResolveBinPath()
-	dataDir := a.store.ResolveDataDir()
 
 	type job struct {
 		Idx int
@@ -424,7 +890,7 @@ func (a *App) BatchStartUsers(c *gin.Context) {
 					User:     u,
 					BinPath:  binPath,
 					Headless: cfg.Headless,
-					DataDir:  dataDir,
+					DataDir:  a.store.UserDataDir(u),
 				})
 				cancel()
 				if err != nil {
@@ -432,7 +898,7 @@ func (a *App) BatchStartUsers(c *gin.Context) {
 					continue
 				}
 				if err := a.store.SetUserAutoStart(id, true); err != nil {
-					_ = a.proc.StopUser(context.Background(), id, 10*time.Second)
+					_ = a.proc.StopUser(context.Background(), id, 0)
 					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "error", Error: err.Error()}}
 					continue
 				}
@@ -475,15 +941,13 @@ func (a *App) BatchStopUsers(c *gin.Context) {
 
 	users := a.store.ListUsers()
 	usersByID := make(map[string]UserConfig, len(users))
-	allIDs := make([]string, 0, len(users))
 	for _, u := range users {
 		usersByID[u.ID] = u
-		allIDs = append(allIDs, u.ID)
 	}
-
-	ids := req.IDs
-	if len(ids) == 0 {
-		ids = allIDs
+	ids, err := resolveBatchIDs(req, users)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if len(ids) == 0 {
@@ -528,8 +992,9 @@ func (a *App) BatchStopUsers(c *gin.Context) {
 					continue
 				}
 				running := a.proc.GetStatus(id).Running
-				if err := a.proc.StopUser(c.Request.Context(), id, 10*time.Second); err != nil {
-					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "error", Error: err.Error()}}
+				res, err := a.proc.StopUserResult(c.Request.Context(), id, 0)
+				if err != nil {
+					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "error", Error: err.Error(), Stage: res.Stage}}
 					continue
 				}
 				if err := a.store.SetUserAutoStart(id, false); err != nil {
@@ -537,7 +1002,7 @@ func (a *App) BatchStopUsers(c *gin.Context) {
 					continue
 				}
 				if running {
-					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "stopped"}}
+					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "stopped", Stage: res.Stage}}
 				} else {
 					results <- jobRes{Idx: j.Idx, Item: batchResultItem{ID: id, Status: "already_stopped"}}
 				}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserProxyAPI(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", a.CreateUser)
+	r.PUT("/users/:id", a.UpdateUser)
+
+	if w := doReq(r, http.MethodPost, "/users", `{"id":"u1","port":18060,"proxy":"http://user:pass@"}`, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法代理应被拒绝，got %d %s", w.Code, w.Body.String())
+	}
+	if w := doReq(r, http.MethodPost, "/users", `{"id":"u1","port":18060,"proxy":"127.0.0.1:7890"}`, nil); w.Code != http.StatusCreated {
+		t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 未传 proxy 时保留原值，传空字符串时清除
+	if w := doReq(r, http.MethodPut, "/users/u1", `{"port":18061}`, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("更新失败: %d %s", w.Code, w.Body.String())
+	}
+	if u, _ := store.GetUser("u1"); u.Proxy != "127.0.0.1:7890" || u.Port != 18061 {
+		t.Fatalf("未传 proxy 不应修改: %+v", u)
+	}
+	if w := doReq(r, http.MethodPut, "/users/u1", `{"port":18061,"proxy_pool_url":"ftp://pool"}`, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法代理池地址应被拒绝，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPut, "/users/u1", `{"port":18061,"proxy":""}`, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("更新失败: %d %s", w.Code, w.Body.String())
+	}
+	if u, _ := store.GetUser("u1"); u.Proxy != "" {
+		t.Fatalf("proxy 应被清除: %+v", u)
+	}
+}
+
+func TestCloneUser(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", a.CreateUser)
+	r.POST("/users/:id/clone", a.CloneUser)
+
+	body := `{"id":"u1","port":18060,"proxy":"127.0.0.1:7890","read_only":true,"env":{"LOG_LEVEL":"debug"},"restart":"on-failure"}`
+	if w := doReq(r, http.MethodPost, "/users", body, nil); w.Code != http.StatusCreated {
+		t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+	}
+	if err := store.SetUserAutoStart("u1", true); err != nil {
+		t.Fatal(err)
+	}
+	if w := doReq(r, http.MethodPost, "/users/u1/clone", `{"id":"u2","port":18061}`, nil); w.Code != http.StatusCreated {
+		t.Fatalf("克隆失败: %d %s", w.Code, w.Body.String())
+	}
+	src, _ := store.GetUser("u1")
+	got, ok := store.GetUser("u2")
+	if !ok || got.Proxy != src.Proxy || !got.ReadOnly || got.Env["LOG_LEVEL"] != "debug" || got.Restart != "on-failure" {
+		t.Fatalf("配置未复制: %+v", got)
+	}
+	if got.Port != 18061 || got.AutoStart || got.UserAgent == "" {
+		t.Fatalf("端口与运行状态不应复制: %+v", got)
+	}
+
+	if w := doReq(r, http.MethodPost, "/users/u1/clone", `{"id":"u3","port":18060}`, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("端口冲突应返回 400，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/users/nobody/clone", `{"id":"u3"}`, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("源用户不存在应返回 404，got %d", w.Code)
+	}
+}
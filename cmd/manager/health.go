@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// manager 自身的健康检查，供负载均衡与监控使用，无需登录：
+//   /healthz 存活检查：只要能响应即返回 200，正文附带状态供监控查看
+//   /readyz  就绪检查：store 无法访问或正在退出时返回 503
+// 账号实例的异常（连续重启已放弃、应运行却未运行）只标记为 degraded，不影响就绪。
+
+// storeCheckTimeout 检查 store 可访问性的超时
+var storeCheckTimeout = 3 * time.Second
+
+// HealthProblem 单个账号的异常
+type HealthProblem struct {
+	User  string `json:"user"`
+	Error string `json:"error"`
+}
+
+// HealthReport 健康检查结果
+type HealthReport struct {
+	Status        string          `json:"status"` // ok、degraded、unavailable
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Draining      bool            `json:"draining,omitempty"` // 已收到退出信号
+	StoreOK       bool            `json:"store_ok"`
+	StoreError    string          `json:"store_error,omitempty"`
+	Users         int             `json:"users"`
+	Running       int             `json:"running"`
+	Restarting    int             `json:"restarting"` // 等待自动重启的账号数
+	Problems      []HealthProblem `json:"problems"`
+}
+
+// CheckStore 检查存储后端是否可访问（JSON 文件尚未写入过视为正常）
+func (s *Store) CheckStore() error {
+	_, err := s.backend.Stamp()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// checkStoreWithTimeout 远程后端无响应时不阻塞健康检查
+func (a *App) checkStoreWithTimeout() error {
+	done := make(chan error, 1)
+	go func() { done <- a.store.CheckStore() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(storeCheckTimeout):
+		return fmt.Errorf("访问 store 超时（%s）", storeCheckTimeout)
+	}
+}
+
+// healthReport 汇总 store 与各账号实例状态
+func (a *App) healthReport() HealthReport {
+	rep := HealthReport{
+		UptimeSeconds: int64(time.Since(a.started).Seconds()),
+		Draining:      a.draining.Load(),
+		Problems:      []HealthProblem{},
+	}
+	if err := a.checkStoreWithTimeout(); err != nil {
+		rep.StoreError = err.Error()
+	} else {
+		rep.StoreOK = true
+	}
+
+	for _, u := range a.store.ListUsers() {
+		rep.Users++
+		st := a.proc.GetStatus(u.ID)
+		switch {
+		case st.Running:
+			rep.Running++
+		case st.RestartGaveUp:
+			rep.Problems = append(rep.Problems, healthProblem(u.ID, "连续重启次数达到上限，已放弃", st.LastError))
+		case st.NextRestartAt != "":
+			rep.Restarting++
+		case u.AutoStart && st.State == StateFailed:
+			rep.Problems = append(rep.Problems, healthProblem(u.ID, "启动失败", st.LastError))
+		}
+	}
+
+	switch {
+	case !rep.StoreOK || rep.Draining:
+		rep.Status = "unavailable"
+	case len(rep.Problems) > 0:
+		rep.Status = "degraded"
+	default:
+		rep.Status = "ok"
+	}
+	return rep
+}
+
+func healthProblem(user, msg, lastErr string) HealthProblem {
+	if lastErr != "" {
+		msg += ": " + lastErr
+	}
+	return HealthProblem{User: user, Error: msg}
+}
+
+// SetDraining 标记 manager 正在退出，/readyz 随即返回 503
+func (a *App) SetDraining() {
+	a.draining.Store(true)
+}
+
+// Healthz 存活检查
+// GET /healthz
+func (a *App) Healthz(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, a.healthReport())
+}
+
+// Readyz 就绪检查
+// GET /readyz
+func (a *App) Readyz(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	rep := a.healthReport()
+	code := http.StatusOK
+	if rep.Status == "unavailable" {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, rep)
+}
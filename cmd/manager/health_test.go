@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", a.Healthz)
+	r.GET("/readyz", a.Readyz)
+
+	var rep HealthReport
+	w := doReq(r, http.MethodGet, "/readyz", "", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || rep.Status != "ok" || !rep.StoreOK || rep.Users != 1 || rep.Running != 0 {
+		t.Fatalf("就绪检查不符: %d %+v", w.Code, rep)
+	}
+
+	// 退出过程中不再就绪，但仍存活
+	a.SetDraining()
+	if w := doReq(r, http.MethodGet, "/readyz", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("退出中应返回 503，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/healthz", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("存活检查应返回 200，got %d", w.Code)
+	}
+}
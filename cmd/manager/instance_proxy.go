@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 经 manager 访问实例：/users/:id/mcp 转发到实例的 /mcp，/users/:id/api/* 转发到实例的 /api/*，
+// 客户端只需访问 manager 一个地址，无需暴露每个实例的端口或 socket。
+// 转发时替换为实例自身的访问令牌，调用方使用 manager 的管理令牌鉴权。MCP 与 API 请求按 proxy_limit.go 限流。
+
+// instanceRouteBase 账号实例在 manager 上的转发路径前缀
+func instanceRouteBase(id string) string {
+	return "/users/" + url.PathEscape(id)
+}
+
+// ProxyUserMCP 转发 MCP 请求（含 SSE 流式响应）
+// ANY /users/:id/mcp, /users/:id/mcp/*path
+func (a *App) ProxyUserMCP(c *gin.Context) {
+	// GET 为 SSE 长连接，只计入速率
+	a.proxyInstance(c, "/mcp"+c.Param("path"), true, c.Request.Method == http.MethodGet)
+}
+
+// ProxyUserAPI 转发实例 HTTP API
+// ANY /users/:id/api/*path
+func (a *App) ProxyUserAPI(c *gin.Context) {
+	// 实例的 cookies、DevTools 等接口与管理 API 同样按 requiredRole 授权，避免经转发绕过
+	p := path.Clean("/api" + c.Param("path"))
+	if required := requiredRole(c.Request.Method, p); !roleAllows(c.GetString(adminRoleKey), required) {
+		forbidden(c, required)
+		return
+	}
+	a.proxyInstance(c, p, true, false)
+}
+
+// ProxyUserHealth 转发实例健康检查
+// GET /users/:id/health
+func (a *App) ProxyUserHealth(c *gin.Context) {
+	a.proxyInstance(c, "/health", false, false)
+}
+
+// proxyInstance 转发到实例的 path，limited 时按账号限流，stream 表示 SSE 长连接
+func (a *App) proxyInstance(c *gin.Context, path string, limited, stream bool) {
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+	if limited {
+		user, _ := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+		release, ok := a.limitProxy(c, user, stream)
+		if !ok {
+			return
+		}
+		defer release()
+	}
+	target, err := url.Parse(ep.BaseURL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	base := instanceRouteBase(id)
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = path
+			req.URL.RawPath = ""
+			req.Host = target.Host
+			// 管理令牌与会话 cookie 只用于访问 manager，不转发给实例
+			req.Header.Del("Authorization")
+			req.Header.Del("Cookie")
+			req.Header.Set("X-Forwarded-Prefix", base)
+		},
+		Transport:     ep.Transport(),
+		FlushInterval: -1, // MCP 响应为 SSE 流，立即转发
+		ModifyResponse: func(resp *http.Response) error {
+			// 实例返回的重定向改写为 manager 上的地址
+			if loc := resp.Header.Get("Location"); strings.HasPrefix(loc, "/") {
+				resp.Header.Set("Location", base+loc)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Printf("转发请求到实例 %s 失败: %v\n", id, err)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(w, `{"error":%q}`, "转发请求失败: "+err.Error())
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProxyInstance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer child-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Path", r.URL.Path)
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: port}); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager()
+	self, _ := os.FindProcess(os.Getpid())
+	pm.procs["u1"] = &runningProc{state: StateRunning, cmd: &exec.Cmd{Process: self}, token: "child-token"}
+	a := NewApp(store, pm, "")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	auth := NewAdminAuth(AdminAuthConfig{Token: "admin-token"}, "")
+	g := r.Group("/users/:id", auth.Require())
+	g.Any("/mcp", a.ProxyUserMCP)
+	g.Any("/mcp/*path", a.ProxyUserMCP)
+	g.Any("/api/*path", a.ProxyUserAPI)
+
+	// ReverseProxy 需要 CloseNotifier，使用真实的 HTTP 服务而不是 ResponseRecorder
+	manager := httptest.NewServer(r)
+	defer manager.Close()
+	type result struct {
+		code   int
+		header http.Header
+		body   string
+	}
+	do := func(method, path, token string) result {
+		t.Helper()
+		req, _ := http.NewRequest(method, manager.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return result{code: resp.StatusCode, header: resp.Header, body: string(body)}
+	}
+
+	if w := do(http.MethodPost, "/users/u1/mcp", ""); w.code != http.StatusUnauthorized {
+		t.Fatalf("未携带管理令牌应拒绝，got %d", w.code)
+	}
+	// 管理令牌被替换为实例令牌
+	w := do(http.MethodPost, "/users/u1/mcp", "admin-token")
+	if w.code != http.StatusOK || w.header.Get("X-Path") != "/mcp" {
+		t.Fatalf("mcp: %d %q %s", w.code, w.header.Get("X-Path"), w.body)
+	}
+	w = do(http.MethodGet, "/users/u1/api/v1/feeds/list?limit=5", "admin-token")
+	if w.header.Get("X-Path") != "/api/v1/feeds/list" || w.body != "limit=5" {
+		t.Fatalf("api: %q %s", w.header.Get("X-Path"), w.body)
+	}
+	if w := do(http.MethodGet, "/users/nobody/mcp", "admin-token"); w.code != http.StatusNotFound {
+		t.Fatalf("不存在的账号应返回 404，got %d", w.code)
+	}
+
+	// 超出转发限流时返回 429，健康检查不限流
+	a.SetProxyLimits(ProxyLimitConfig{RatePerMin: 60, Burst: 1})
+	if w := do(http.MethodPost, "/users/u1/mcp", "admin-token"); w.code != http.StatusOK {
+		t.Fatalf("限流内的请求应转发，got %d", w.code)
+	}
+	if w := do(http.MethodPost, "/users/u1/mcp", "admin-token"); w.code != http.StatusTooManyRequests || w.header.Get("Retry-After") != "1" {
+		t.Fatalf("超出限流应返回 429，got %d %q", w.code, w.header.Get("Retry-After"))
+	}
+	a.SetProxyLimits(ProxyLimitConfig{})
+
+	pm.procs["u1"].exitedAt = pm.procs["u1"].startedAt.AddDate(1, 0, 0)
+	if w := do(http.MethodGet, "/users/u1/api/v1/feeds/list", "admin-token"); w.code != http.StatusConflict || !strings.Contains(w.body, "未运行") {
+		t.Fatalf("实例未运行应返回 409，got %d %s", w.code, w.body)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 定时任务保存在各实例的运行状态目录中（jobs.json），manager 只做汇总与转发
+
+// FleetJob 实例返回的任务对象，附加 user_id 字段
+type FleetJob map[string]any
+
+// FleetJobsResponse 所有账号的定时任务
+type FleetJobsResponse struct {
+	Jobs       []FleetJob        `json:"jobs"`
+	Count      int               `json:"count"`
+	NotRunning []string          `json:"not_running,omitempty"` // 未运行的账号，其任务暂不可见
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// ListFleetJobs 汇总所有运行中账号的定时任务，可用 ?user=id 过滤
+// GET /api/admin/v1/jobs
+func (a *App) ListFleetJobs(c *gin.Context) {
+	filter := strings.TrimSpace(c.Query("user"))
+	users := a.store.ListUsers()
+
+	ctx := c.Request.Context()
+	resp := FleetJobsResponse{Jobs: []FleetJob{}}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, u := range users {
+		if filter != "" && u.ID != filter {
+			continue
+		}
+		if !a.proc.GetStatus(u.ID).Running {
+			resp.NotRunning = append(resp.NotRunning, u.ID)
+			continue
+		}
+		wg.Add(1)
+		go func(u UserConfig) {
+			defer wg.Done()
+			jobs, err := a.fetchJobs(ctx, u)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if resp.Errors == nil {
+					resp.Errors = map[string]string{}
+				}
+				resp.Errors[u.ID] = err.Error()
+				return
+			}
+			resp.Jobs = append(resp.Jobs, jobs...)
+		}(u)
+	}
+	wg.Wait()
+
+	// 实例返回的任务已按执行时间排序，这里按账号稳定排序
+	sort.SliceStable(resp.Jobs, func(i, j int) bool {
+		return fmt.Sprint(resp.Jobs[i]["user_id"]) < fmt.Sprint(resp.Jobs[j]["user_id"])
+	})
+	resp.Count = len(resp.Jobs)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (a *App) fetchJobs(ctx context.Context, u UserConfig) ([]FleetJob, error) {
+	status, _, data, err := a.proxyGet(ctx, a.endpoint(u), "/api/v1/jobs", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Data    struct {
+			Jobs []FleetJob `json:"jobs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("解析响应失败: HTTP %d", status)
+	}
+	if !body.Success {
+		return nil, fmt.Errorf("HTTP %d: %s", status, body.Error)
+	}
+	for _, j := range body.Data.Jobs {
+		j["user_id"] = u.ID
+	}
+	return body.Data.Jobs, nil
+}
+
+// CreateUserJob 为账号创建定时任务，请求体原样转发给实例
+// POST /api/admin/v1/users/:id/jobs
+func (a *App) CreateUserJob(c *gin.Context) {
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+		return
+	}
+	status, contentType, data, err := a.proxyPostJSON(c.Request.Context(), ep, "/api/v1/jobs", bytes.NewReader(body), 10*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	c.Data(status, contentType, data)
+}
+
+// PauseUserJob 暂停定时任务
+// POST /api/admin/v1/users/:id/jobs/:jid/pause
+func (a *App) PauseUserJob(c *gin.Context) {
+	a.forwardJobAction(c, http.MethodPost, "/pause")
+}
+
+// ResumeUserJob 恢复定时任务
+// POST /api/admin/v1/users/:id/jobs/:jid/resume
+func (a *App) ResumeUserJob(c *gin.Context) {
+	a.forwardJobAction(c, http.MethodPost, "/resume")
+}
+
+// DeleteUserJob 删除定时任务
+// DELETE /api/admin/v1/users/:id/jobs/:jid
+func (a *App) DeleteUserJob(c *gin.Context) {
+	a.forwardJobAction(c, http.MethodDelete, "")
+}
+
+func (a *App) forwardJobAction(c *gin.Context, method, suffix string) {
+	jid := strings.TrimSpace(c.Param("jid"))
+	if jid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "jid 不能为空"})
+		return
+	}
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+	status, contentType, data, err := a.proxyRequest(c.Request.Context(), ep, method, "/api/v1/jobs/"+jid+suffix, 10*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("转发请求失败: %v", err)})
+		return
+	}
+	c.Data(status, contentType, data)
+}
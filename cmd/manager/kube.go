@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kubernetes 后端：指定 -k8s-image 后每个实例是一个 Pod（restartPolicy: Never），通过 Kubernetes API 创建、
+// 查询与删除，manager 仍是控制面：重启策略、定时任务、日志轮转等照常由 manager 负责。
+// 不使用 Deployment，否则控制器会在 manager 之外自行重建 Pod，与 manager 的启停和重启策略冲突。
+//   - 数据目录：-k8s-pvc 指定的 PVC 挂载到 Pod 内与 manager 相同的 data_dir 绝对路径，manager 需挂载同一个
+//     PVC（ReadWriteMany，或与实例调度到同一节点），cookies、登录状态等接口才能读到实例写入的文件；
+//     未指定时使用 emptyDir，Pod 删除后浏览器数据随之丢失；
+//   - 网络：manager 通过 Pod IP 访问实例，需运行在集群内（或能直接访问 Pod 网络），不支持 Unix socket；
+//   - 环境变量与访问令牌写入同名 Secret，通过 envFrom 注入，实例退出后与 Pod 一起删除；
+//   - 日志：跟随 Pod 日志写入 manager 的日志文件，日志查询、搜索、下载接口不变；
+//   - 停止：删除 Pod，优雅退出（SIGTERM）超时后以 gracePeriodSeconds=0 强制删除；
+//   - 能力探测在实例启动后读取 /health，配置要求的功能不支持时随即停止。
+
+const (
+	kubeServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	kubeContainerName     = "instance"
+	kubeStartTimeout      = 5 * time.Minute // 调度与拉取镜像
+	kubePollInterval      = 2 * time.Second
+	kubeGracePeriod       = 15
+	kubeTokenEnvKey       = "XHS_K8S_TOKEN"
+	kubeManagedBy         = "xiaohongshu-mcp-manager"
+	kubeNodeLabel         = "xiaohongshu-mcp/node"
+)
+
+var errKubeNotFound = errors.New("资源不存在")
+
+// KubeConfig 以 Pod 运行实例的配置，Image 为空表示不使用 Kubernetes
+type KubeConfig struct {
+	API       string // API server 地址，为空时使用集群内配置（ServiceAccount）
+	Token     string // 指定 API 时使用的 Bearer 令牌，可为空（如 kubectl proxy）
+	Namespace string // 为空时使用 ServiceAccount 所在命名空间
+	Image     string
+	Bin       string // 容器内实例二进制（作为 command），为空时使用镜像自身的 ENTRYPOINT
+	CPU       string // 容器 CPU 限制，如 1 或 500m
+	Memory    string // 容器内存限制，如 2Gi
+	PVC       string // 挂载为数据目录的 PersistentVolumeClaim
+
+	NodeID string // 集群模式下本节点的 ID，写入 Pod 标签，启动清理时只处理本节点创建的 Pod
+}
+
+// Enabled 是否以 Pod 运行实例
+func (k KubeConfig) Enabled() bool {
+	return k.Image != ""
+}
+
+// kubeClient 最小化的 Kubernetes REST 客户端，只覆盖 Pod 与 Secret
+type kubeClient struct {
+	cfg       KubeConfig
+	base      string
+	tokenFile string // 集群内令牌会轮换，每次请求重新读取
+	http      *http.Client
+}
+
+// newKubeClient 按配置创建客户端；未指定 API 时读取集群内的 ServiceAccount
+func newKubeClient(cfg KubeConfig) (*kubeClient, error) {
+	c := &kubeClient{cfg: cfg, base: strings.TrimRight(cfg.API, "/"), http: &http.Client{}}
+	if c.base == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("不在集群内运行，请通过 -k8s-api 指定 API server 地址")
+		}
+		c.base = "https://" + net.JoinHostPort(host, port)
+		c.tokenFile = kubeServiceAccountDir + "/token"
+		ca, err := os.ReadFile(kubeServiceAccountDir + "/ca.crt")
+		if err != nil {
+			return nil, fmt.Errorf("读取集群 CA 失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("集群 CA 无法解析")
+		}
+		c.http.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	if c.cfg.Namespace == "" {
+		ns, err := os.ReadFile(kubeServiceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("未指定 -k8s-namespace 且无法读取 ServiceAccount 命名空间: %w", err)
+		}
+		c.cfg.Namespace = strings.TrimSpace(string(ns))
+	}
+	return c, nil
+}
+
+func (c *kubeClient) token() string {
+	if c.tokenFile == "" {
+		return c.cfg.Token
+	}
+	data, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (c *kubeClient) path(kind, name string) string {
+	p := "/api/v1/namespaces/" + url.PathEscape(c.cfg.Namespace) + "/" + kind
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
+
+func (c *kubeClient) request(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errKubeNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&status)
+		return nil, fmt.Errorf("%s %s: HTTP %d %s", method, path, resp.StatusCode, status.Message)
+	}
+	return resp, nil
+}
+
+// do 发送 JSON 请求，out 非空时解析响应
+func (c *kubeClient) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// delete 删除资源，不存在时不报错；grace 小于 0 时使用资源自身的宽限期
+func (c *kubeClient) delete(ctx context.Context, kind, name string, grace int) error {
+	var body any
+	if grace >= 0 {
+		body = map[string]any{"gracePeriodSeconds": grace}
+	}
+	err := c.do(ctx, http.MethodDelete, c.path(kind, name), body, nil)
+	if errors.Is(err, errKubeNotFound) {
+		return nil
+	}
+	return err
+}
+
+// kubeObjectName 账号 ID 对应的资源名：转为 DNS-1123 格式，发生改写时追加哈希避免不同 ID 冲突
+func kubeObjectName(userID string) string {
+	name := strings.Trim(strings.ReplaceAll(strings.ToLower(userID), "_", "-"), "-")
+	if len(name) > 40 {
+		name = strings.TrimRight(name[:40], "-")
+	}
+	if name != userID {
+		sum := sha256.Sum256([]byte(userID))
+		name = strings.Trim(name+"-"+hex.EncodeToString(sum[:3]), "-")
+	}
+	return "xhs-mcp-" + name
+}
+
+// kubeLabelValue 把节点 ID 转为合法的标签值（最长 63 个字符，字母数字开头结尾）
+func kubeLabelValue(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 0x80 && (r == '-' || r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '-'
+	}, v)
+	if len(v) > 63 {
+		v = v[:63]
+	}
+	return strings.Trim(v, "-_.")
+}
+
+// kubePodStatus Pod 状态中用到的部分
+type kubePodStatus struct {
+	Status struct {
+		Phase             string `json:"phase"`
+		PodIP             string `json:"podIP"`
+		Reason            string `json:"reason"`
+		Message           string `json:"message"`
+		ContainerStatuses []struct {
+			State struct {
+				Waiting *struct {
+					Reason  string `json:"reason"`
+					Message string `json:"message"`
+				} `json:"waiting"`
+				Terminated *struct {
+					ExitCode int    `json:"exitCode"`
+					Reason   string `json:"reason"`
+				} `json:"terminated"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// terminated 容器已退出时返回退出码
+func (s kubePodStatus) terminated() (int, string, bool) {
+	for _, cs := range s.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil {
+			return t.ExitCode, t.Reason, true
+		}
+	}
+	return 0, "", false
+}
+
+// waitingError 容器无法启动的原因（镜像拉取失败等），正常等待中返回空
+func (s kubePodStatus) waitingError() string {
+	for _, cs := range s.Status.ContainerStatuses {
+		if w := cs.State.Waiting; w != nil {
+			switch w.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+				return w.Reason + ": " + w.Message
+			}
+		}
+	}
+	return ""
+}
+
+// kubePodSpec 生成实例 Pod 与保存环境变量的 Secret
+func kubePodSpec(cfg KubeConfig, name, userID, dataDir string, port int, env, args []string) (pod, secret map[string]any) {
+	labels := map[string]string{
+		"app.kubernetes.io/name":       "xiaohongshu-mcp",
+		"app.kubernetes.io/managed-by": kubeManagedBy,
+		"xiaohongshu-mcp/user":         strings.TrimPrefix(name, "xhs-mcp-"),
+	}
+	if cfg.NodeID != "" {
+		labels[kubeNodeLabel] = kubeLabelValue(cfg.NodeID)
+	}
+	meta := map[string]any{"name": name, "labels": labels, "annotations": map[string]string{"xiaohongshu-mcp/user-id": userID}}
+
+	data := map[string]string{}
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			data[k] = v // 同名时后者覆盖，与进程环境一致
+		}
+	}
+	secret = map[string]any{"apiVersion": "v1", "kind": "Secret", "metadata": meta, "type": "Opaque", "stringData": data}
+
+	container := map[string]any{
+		"name":         kubeContainerName,
+		"image":        cfg.Image,
+		"args":         args,
+		"ports":        []map[string]any{{"containerPort": port, "protocol": "TCP"}},
+		"envFrom":      []map[string]any{{"secretRef": map[string]string{"name": name}}},
+		"volumeMounts": []map[string]any{{"name": "data", "mountPath": dataDir}},
+	}
+	if cfg.Bin != "" {
+		container["command"] = []string{cfg.Bin}
+	}
+	limits := map[string]string{}
+	if cfg.CPU != "" {
+		limits["cpu"] = cfg.CPU
+	}
+	if cfg.Memory != "" {
+		limits["memory"] = cfg.Memory
+	}
+	if len(limits) > 0 {
+		container["resources"] = map[string]any{"limits": limits, "requests": limits}
+	}
+	volume := map[string]any{"name": "data", "emptyDir": map[string]any{}}
+	if cfg.PVC != "" {
+		volume = map[string]any{"name": "data", "persistentVolumeClaim": map[string]string{"claimName": cfg.PVC}}
+	}
+	pod = map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   meta,
+		"spec": map[string]any{
+			"restartPolicy":                 "Never",
+			"terminationGracePeriodSeconds": kubeGracePeriod,
+			"containers":                    []any{container},
+			"volumes":                       []any{volume},
+		},
+	}
+	return pod, secret
+}
+
+// kubePod 运行中的实例 Pod
+type kubePod struct {
+	client *kubeClient
+	name   string
+
+	mu       sync.Mutex
+	exitCode *int
+}
+
+// startPod 删除残留的同名 Pod 后创建 Secret 与 Pod
+func (c *kubeClient) startPod(ctx context.Context, userID, dataDir string, port int, env, args []string) (*kubePod, error) {
+	name := kubeObjectName(userID)
+	if err := c.removePod(ctx, name); err != nil {
+		return nil, err
+	}
+	pod, secret := kubePodSpec(c.cfg, name, userID, dataDir, port, env, args)
+	if err := c.do(ctx, http.MethodPost, c.path("secrets", ""), secret, nil); err != nil {
+		return nil, fmt.Errorf("创建 Secret 失败: %w", err)
+	}
+	if err := c.do(ctx, http.MethodPost, c.path("pods", ""), pod, nil); err != nil {
+		_ = c.delete(context.Background(), "secrets", name, -1)
+		return nil, fmt.Errorf("创建 Pod 失败: %w", err)
+	}
+	return &kubePod{client: c, name: name}, nil
+}
+
+// removePod 强制删除 Pod 与 Secret 并等待 Pod 消失，之后才能创建同名 Pod
+func (c *kubeClient) removePod(ctx context.Context, name string) error {
+	if err := c.delete(ctx, "pods", name, 0); err != nil {
+		return fmt.Errorf("删除残留 Pod 失败: %w", err)
+	}
+	if err := c.delete(ctx, "secrets", name, -1); err != nil {
+		return fmt.Errorf("删除残留 Secret 失败: %w", err)
+	}
+	for deadline := time.Now().Add(time.Minute); ; {
+		err := c.do(ctx, http.MethodGet, c.path("pods", name), nil, &kubePodStatus{})
+		if errors.Is(err, errKubeNotFound) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待残留 Pod %s 删除超时", name)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (p *kubePod) status(ctx context.Context) (kubePodStatus, error) {
+	var st kubePodStatus
+	err := p.client.do(ctx, http.MethodGet, p.client.path("pods", p.name), nil, &st)
+	return st, err
+}
+
+// waitRunning 等待 Pod 调度并运行，返回 Pod IP；Pod 提前退出（exited 关闭）或无法启动时返回错误
+func (p *kubePod) waitRunning(ctx context.Context, exited <-chan struct{}, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := p.status(ctx)
+		switch {
+		case err != nil && !errors.Is(err, errKubeNotFound):
+			fmt.Printf("查询 Pod %s 失败: %v\n", p.name, err)
+		case err == nil && st.Status.Phase == "Running" && st.Status.PodIP != "":
+			return st.Status.PodIP, nil
+		case err == nil && st.waitingError() != "":
+			return "", fmt.Errorf("Pod %s 无法启动: %s", p.name, st.waitingError())
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("等待 Pod %s 运行超时（%s）", p.name, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-exited:
+			return "", fmt.Errorf("Pod %s 已退出", p.name)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// wait 跟随日志写入 w，直到 Pod 结束或被删除，随后清理 Pod 与 Secret。返回值与 exec.Cmd.Wait 类似：
+// 非 0 退出码或 Pod 被外部删除时返回错误
+func (p *kubePod) wait(w io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	logsDone := make(chan struct{})
+	go func() {
+		defer close(logsDone)
+		p.followLogs(ctx, w)
+	}()
+
+	var waitErr error
+	for {
+		st, err := p.status(context.Background())
+		if errors.Is(err, errKubeNotFound) {
+			waitErr = fmt.Errorf("Pod %s 已被删除", p.name)
+			break
+		}
+		if err == nil {
+			if code, reason, ok := st.terminated(); ok && (st.Status.Phase == "Succeeded" || st.Status.Phase == "Failed") {
+				p.mu.Lock()
+				p.exitCode = &code
+				p.mu.Unlock()
+				if code != 0 {
+					waitErr = fmt.Errorf("exit status %d（%s）", code, reason)
+				}
+				break
+			}
+			if st.Status.Phase == "Failed" {
+				waitErr = fmt.Errorf("Pod %s 失败: %s %s", p.name, st.Status.Reason, st.Status.Message)
+				break
+			}
+		}
+		time.Sleep(kubePollInterval)
+	}
+
+	// 等日志读完再关闭日志文件
+	select {
+	case <-logsDone:
+	case <-time.After(5 * time.Second):
+	}
+	cancel()
+	<-logsDone
+	cleanup, cancelCleanup := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelCleanup()
+	_ = p.client.delete(cleanup, "pods", p.name, 0)
+	_ = p.client.delete(cleanup, "secrets", p.name, -1)
+	return waitErr
+}
+
+// followLogs 跟随容器日志；容器尚未启动时重试，连接中断后从断开时刻续读
+func (p *kubePod) followLogs(ctx context.Context, w io.Writer) {
+	var since time.Time
+	for ctx.Err() == nil {
+		q := url.Values{"container": {kubeContainerName}, "follow": {"true"}}
+		if !since.IsZero() {
+			q.Set("sinceTime", since.UTC().Format(time.RFC3339))
+		}
+		resp, err := p.client.request(ctx, http.MethodGet, p.client.path("pods", p.name)+"/log?"+q.Encode(), nil)
+		if errors.Is(err, errKubeNotFound) {
+			return
+		}
+		if err == nil {
+			_, _ = io.Copy(w, resp.Body)
+			resp.Body.Close()
+			since = time.Now()
+			if st, err := p.status(ctx); err != nil || st.Status.Phase == "Succeeded" || st.Status.Phase == "Failed" {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(kubePollInterval):
+		}
+	}
+}
+
+func (p *kubePod) code() *int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exitCode
+}
+
+// interrupt 删除 Pod，容器收到 SIGTERM 后优雅退出
+func (p *kubePod) interrupt() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.client.delete(ctx, "pods", p.name, kubeGracePeriod)
+}
+
+// terminate 以较短的宽限期重新删除 Pod
+func (p *kubePod) terminate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.client.delete(ctx, "pods", p.name, 2)
+}
+
+// kill 立即删除 Pod
+func (p *kubePod) kill() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.client.delete(ctx, "pods", p.name, 0)
+}
+
+// SetKube 设置 Kubernetes 后端，之后启动的实例生效
+func (pm *ProcessManager) SetKube(c *kubeClient) {
+	pm.mu.Lock()
+	pm.kube = c
+	pm.mu.Unlock()
+}
+
+// Kube 当前的 Kubernetes 后端，未启用时为 nil
+func (pm *ProcessManager) Kube() *kubeClient {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.kube
+}
+
+// startPod 以 Pod 启动实例并等待其运行。返回的 created 表示 Pod 已创建（之后的退出由后台 goroutine 记录），
+// 此时的错误需要调用方停止实例
+func (pm *ProcessManager) startPod(ctx context.Context, kube *kubeClient, rp *runningProc, dataDir string, port int, logFile *os.File, env, args []string) (created bool, err error) {
+	userID := rp.params.User.ID
+	pod, err := kube.startPod(ctx, userID, dataDir, port, env, args)
+	if err != nil {
+		return false, err
+	}
+	pm.mu.Lock()
+	rp.pod = pod
+	rp.logFile = logFile
+	rp.logSince = time.Now()
+	pm.mu.Unlock()
+	pm.metrics.inc(userID, func(c *procCounters) { c.starts++ })
+
+	go func(p *runningProc) {
+		waitErr := pod.wait(logFile)
+		_ = logFile.Close()
+		pm.markExited(p, waitErr)
+	}(rp)
+
+	host, err := pod.waitRunning(ctx, rp.done, kubeStartTimeout)
+	if err != nil {
+		return true, err
+	}
+	pm.mu.Lock()
+	rp.host = host
+	pm.mu.Unlock()
+	fmt.Printf("用户 %s 的实例 Pod %s 已运行: %s\n", userID, pod.name, host)
+	return true, nil
+}
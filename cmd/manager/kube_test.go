@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKubeObjectName(t *testing.T) {
+	if got := kubeObjectName("alice-1"); got != "xhs-mcp-alice-1" {
+		t.Fatalf("合法 ID 不应改写: %s", got)
+	}
+	a, b := kubeObjectName("Alice_1"), kubeObjectName("alice-1")
+	if a == b || strings.ToLower(a) != a || strings.Contains(a, "_") {
+		t.Fatalf("改写后的名称不符: %s %s", a, b)
+	}
+}
+
+// fakeKubeAPI 模拟 Pod 生命周期：创建后运行，删除后以退出码 0 结束
+type fakeKubeAPI struct {
+	mu      sync.Mutex
+	pods    map[string]map[string]any
+	secrets map[string]map[string]any
+	phase   string
+}
+
+func (f *fakeKubeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/ns/"), "/")
+	store := f.pods
+	if parts[0] == "secrets" {
+		store = f.secrets
+	}
+	switch {
+	case r.Method == http.MethodPost:
+		var obj map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&obj)
+		store[obj["metadata"].(map[string]any)["name"].(string)] = obj
+		f.phase = "Running"
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	case len(parts) == 3 && parts[2] == "log":
+		_, _ = w.Write([]byte("instance started\n"))
+	case r.Method == http.MethodDelete:
+		if _, ok := store[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if parts[0] == "pods" && f.phase == "Running" {
+			f.phase = "Succeeded" // 先报告退出码，再次删除时移除
+			return
+		}
+		delete(store, parts[1])
+	case r.Method == http.MethodGet:
+		if _, ok := store[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		st := map[string]any{"phase": f.phase, "podIP": "10.0.0.7"}
+		if f.phase == "Succeeded" {
+			st["containerStatuses"] = []any{map[string]any{"state": map[string]any{"terminated": map[string]any{"exitCode": 0}}}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": st})
+	}
+}
+
+func TestKubePodLifecycle(t *testing.T) {
+	api := &fakeKubeAPI{pods: map[string]map[string]any{}, secrets: map[string]map[string]any{}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	client, err := newKubeClient(KubeConfig{API: srv.URL, Namespace: "ns", Image: "xhs:latest", Memory: "2Gi", PVC: "xhs-data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod, err := client.startPod(context.Background(), "u1", "/data", 18061, []string{"XHS_API_TOKEN=tok-123"}, []string{"-port=:18061"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.mu.Lock()
+	spec, _ := json.Marshal(api.pods["xhs-mcp-u1"])
+	secret, _ := json.Marshal(api.secrets["xhs-mcp-u1"])
+	api.mu.Unlock()
+	for _, want := range []string{`"restartPolicy":"Never"`, `"claimName":"xhs-data"`, `"mountPath":"/data"`, `"memory":"2Gi"`, `"args":["-port=:18061"]`} {
+		if !strings.Contains(string(spec), want) {
+			t.Errorf("Pod 缺少 %s: %s", want, spec)
+		}
+	}
+	if strings.Contains(string(spec), "tok-123") || !strings.Contains(string(secret), `"XHS_API_TOKEN":"tok-123"`) {
+		t.Fatalf("令牌应只写入 Secret: %s / %s", spec, secret)
+	}
+
+	host, err := pod.waitRunning(context.Background(), nil, time.Second)
+	if err != nil || host != "10.0.0.7" {
+		t.Fatalf("waitRunning = %q, %v", host, err)
+	}
+
+	var logs bytes.Buffer
+	done := make(chan error, 1)
+	go func() { done <- pod.wait(&logs) }()
+	if err := pod.interrupt(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("正常退出不应返回错误: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Pod 结束后 wait 未返回")
+	}
+	if code := pod.code(); code == nil || *code != 0 {
+		t.Fatalf("退出码不符: %v", code)
+	}
+	if !strings.Contains(logs.String(), "instance started") {
+		t.Fatalf("未写入 Pod 日志: %q", logs.String())
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.pods) != 0 || len(api.secrets) != 0 {
+		t.Fatalf("退出后应删除 Pod 与 Secret: %v %v", api.pods, api.secrets)
+	}
+}
+
+func TestKubeRemoveManagedPodsByNode(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		selector string
+		deleted  []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			selector = r.URL.Query().Get("labelSelector")
+			_, _ = w.Write([]byte(`{"items":[
+				{"metadata":{"name":"xhs-mcp-alice","annotations":{"xiaohongshu-mcp/user-id":"alice"}}},
+				{"metadata":{"name":"xhs-mcp-bob","annotations":{"xiaohongshu-mcp/user-id":"bob"}}}]}`))
+			return
+		}
+		deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/ns/"))
+	}))
+	defer srv.Close()
+	client, err := newKubeClient(KubeConfig{API: srv.URL, Namespace: "ns", Image: "xhs:latest", NodeID: "node_a.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pod, _ := kubePodSpec(client.cfg, "xhs-mcp-alice", "alice", "/data", 18061, nil, nil)
+	if labels := pod["metadata"].(map[string]any)["labels"].(map[string]string); labels[kubeNodeLabel] != "node_a.local" {
+		t.Fatalf("Pod 应带节点标签: %v", labels)
+	}
+
+	n, err := client.removeManagedPods(context.Background(), func(userID string) bool { return userID == "bob" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(selector, kubeNodeLabel+"=node_a.local") {
+		t.Fatalf("selector 应只选择本节点的 Pod: %s", selector)
+	}
+	if n != 1 || strings.Join(deleted, ",") != "pods/xhs-mcp-alice,secrets/xhs-mcp-alice" {
+		t.Fatalf("只应删除租约未被其他节点持有的 Pod: %d %v", n, deleted)
+	}
+}
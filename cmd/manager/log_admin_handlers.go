@@ -1,6 +1,8 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,13 +22,41 @@ type LogOverviewItem struct {
 	Exists    bool   `json:"exists"`
 	SizeBytes int64  `json:"size_bytes"`
 	Mtime     string `json:"mtime,omitempty"`
+
+	Rotated      []RotatedLog `json:"rotated,omitempty"` // 轮转后的历史日志，序号越小越新
+	RotatedBytes int64        `json:"rotated_bytes,omitempty"`
+
+	// 标准错误输出（?stream=stderr），panic 与浏览器启动错误在这里
+	ErrLogFile   string `json:"err_log_file"`
+	ErrExists    bool   `json:"err_exists"`
+	ErrSizeBytes int64  `json:"err_size_bytes"`
+	ErrMtime     string `json:"err_mtime,omitempty"`
+}
+
+// 日志输出流，由 ?stream= 选择，默认 stdout
+const (
+	logStdout = "stdout"
+	logStderr = "stderr"
+)
+
+// selectLogFile 按 ?stream= 选择标准输出或标准错误日志，参数无效时写入 400 并返回 false
+func selectLogFile(c *gin.Context, paths DerivedPaths) (string, bool) {
+	switch c.Query("stream") {
+	case "", logStdout:
+		return paths.LogFile, true
+	case logStderr:
+		return paths.ErrLogFile, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "stream 只能是 stdout 或 stderr"})
+	return "", false
 }
 
 // LogsOverviewResponse 日志概览响应
 type LogsOverviewResponse struct {
-	DataDir string            `json:"data_dir"`
-	Total   int               `json:"total"`
-	Items   []LogOverviewItem `json:"items"`
+	DataDir  string            `json:"data_dir"`
+	Rotation LogRotationConfig `json:"rotation"`
+	Total    int               `json:"total"`
+	Items    []LogOverviewItem `json:"items"`
 }
 
 // ListLogs 获取所有用户日志概览
@@ -37,10 +67,20 @@ func (a *App) ListLogs(c *gin.Context) {
 
 	items := make([]LogOverviewItem, 0, len(users))
 	for _, u := range users {
-		paths := a.proc.DerivePaths(dataDir, u.ID, u.Port)
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
 		item := LogOverviewItem{
-			UserID:  u.ID,
-			LogFile: paths.LogFile,
+			UserID:     u.ID,
+			LogFile:    paths.LogFile,
+			Rotated:    listRotatedLogs(paths.LogFile),
+			ErrLogFile: paths.ErrLogFile,
+		}
+		for _, r := range item.Rotated {
+			item.RotatedBytes += r.SizeBytes
+		}
+		if stat, err := os.Stat(paths.ErrLogFile); err == nil {
+			item.ErrExists = true
+			item.ErrSizeBytes = stat.Size()
+			item.ErrMtime = stat.ModTime().Format(time.RFC3339)
 		}
 
 		stat, err := os.Stat(paths.LogFile)
@@ -62,13 +102,30 @@ func (a *App) ListLogs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, LogsOverviewResponse{
-		DataDir: dataDir,
-		Total:   len(items),
-		Items:   items,
+		DataDir:  dataDir,
+		Rotation: a.proc.LogRotation(),
+		Total:    len(items),
+		Items:    items,
 	})
 }
 
-// DeleteDebugLogs 清空用户实例日志
+// UpdateLogRotation 更新日志轮转配置，对运行中的实例立即生效
+// PUT /api/admin/v1/logs/rotation
+func (a *App) UpdateLogRotation(c *gin.Context) {
+	var req LogRotationConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	if err := a.store.SetLogRotation(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a.proc.SetLogRotation(req)
+	c.JSON(http.StatusOK, a.proc.LogRotation())
+}
+
+// DeleteDebugLogs 清空用户实例日志（?stream=stderr 清空标准错误日志）
 // DELETE /api/admin/v1/users/:id/debug/logs
 func (a *App) DeleteDebugLogs(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -83,14 +140,17 @@ func (a *App) DeleteDebugLogs(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+	logFile, ok := selectLogFile(c, paths)
+	if !ok {
+		return
+	}
 
 	// 文件不存在视为已清空
-	if _, err := os.Stat(paths.LogFile); os.IsNotExist(err) {
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
 		c.JSON(http.StatusOK, gin.H{
 			"cleared":  true,
-			"log_file": paths.LogFile,
+			"log_file": logFile,
 			"message":  "日志文件不存在，无需清空",
 		})
 		return
@@ -103,14 +163,14 @@ func (a *App) DeleteDebugLogs(c *gin.Context) {
 	}
 
 	// 优先使用 Truncate 清空内容（保留文件句柄）
-	if err := os.Truncate(paths.LogFile, 0); err != nil {
+	if err := os.Truncate(logFile, 0); err != nil {
 		// 某些平台/场景 Truncate 可能失败，尝试用 O_TRUNC 兜底
-		f, openErr := os.OpenFile(paths.LogFile, os.O_WRONLY|os.O_TRUNC, 0644)
+		f, openErr := os.OpenFile(logFile, os.O_WRONLY|os.O_TRUNC, 0644)
 		if openErr == nil {
 			_ = f.Close()
 			c.JSON(http.StatusOK, gin.H{
 				"cleared":  true,
-				"log_file": paths.LogFile,
+				"log_file": logFile,
 				"message":  "日志已清空",
 			})
 			return
@@ -131,12 +191,15 @@ func (a *App) DeleteDebugLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"cleared":  true,
-		"log_file": paths.LogFile,
+		"log_file": logFile,
 		"message":  "日志已清空",
 	})
 }
 
 // DownloadDebugLogs 下载用户实例日志（流式传输）
+// ?compress=gzip 下载 .log.gz 压缩文件；未指定时若客户端声明 Accept-Encoding: gzip，
+// 以 Content-Encoding: gzip 传输（浏览器与 curl --compressed 自动解压），?compress=none 强制原文；
+// ?stream=stderr 下载标准错误日志
 // GET /api/admin/v1/users/:id/debug/logs/download
 func (a *App) DownloadDebugLogs(c *gin.Context) {
 	id := strings.TrimSpace(c.Param("id"))
@@ -151,10 +214,13 @@ func (a *App) DownloadDebugLogs(c *gin.Context) {
 		return
 	}
 
-	dataDir := a.store.ResolveDataDir()
-	paths := a.proc.DerivePaths(dataDir, id, user.Port)
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+	logFile, ok := selectLogFile(c, paths)
+	if !ok {
+		return
+	}
 
-	f, err := os.Open(paths.LogFile)
+	f, err := os.Open(logFile)
 	if os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "日志文件不存在"})
 		return
@@ -172,7 +238,7 @@ func (a *App) DownloadDebugLogs(c *gin.Context) {
 	}
 
 	// 构造下载文件名
-	filename := filepath.Base(paths.LogFile)
+	filename := filepath.Base(logFile)
 	if filename == "" || filename == "." {
 		filename = id + ".log"
 	}
@@ -180,7 +246,39 @@ func (a *App) DownloadDebugLogs(c *gin.Context) {
 	// 快照文件大小，避免文件增长导致 Content-Length 不一致
 	fileSize := stat.Size()
 
+	compress := c.Query("compress")
+	if compress != "" && compress != "gzip" && compress != "none" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "compress 只能是 gzip 或 none"})
+		return
+	}
+	if compress == "gzip" || (compress == "" && acceptsGzip(c.GetHeader("Accept-Encoding"))) {
+		if compress == "gzip" {
+			c.Header("Content-Type", "application/gzip")
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.gz"`, filename))
+		} else {
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			c.Header("Content-Encoding", "gzip")
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		}
+		c.Header("Vary", "Accept-Encoding")
+		c.Header("X-Uncompressed-Length", strconv.FormatInt(fileSize, 10))
+		c.Header("Last-Modified", stat.ModTime().UTC().Format(http.TimeFormat))
+		c.Status(http.StatusOK)
+
+		// 日志压缩率高，优先速度
+		gz, _ := gzip.NewWriterLevel(c.Writer, gzip.BestSpeed)
+		if _, err := io.CopyN(gz, f, fileSize); err != nil && err != io.EOF {
+			_ = c.Error(err)
+			return
+		}
+		if err := gz.Close(); err != nil {
+			_ = c.Error(err)
+		}
+		return
+	}
+
 	// 设置响应头
+	c.Header("Vary", "Accept-Encoding")
 	c.Header("Content-Type", "text/plain; charset=utf-8")
 	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
 	c.Header("Content-Length", strconv.FormatInt(fileSize, 10))
@@ -199,3 +297,164 @@ func (a *App) DownloadDebugLogs(c *gin.Context) {
 		flusher.Flush()
 	}
 }
+
+// 实时日志 SSE：先推送末尾若干行，之后轮询日志文件追加的内容逐批推送。
+// 日志被清空或截断时推送 reset 事件并从头读取。
+var (
+	logStreamPoll      = 500 * time.Millisecond
+	logStreamHeartbeat = 15 * time.Second
+	logStreamMaxBatch  = 500            // 单个事件最多携带的行数
+	logStreamMaxRead   = int64(1 << 20) // 单次轮询最多读取的字节数
+)
+
+// LogLinesEvent log 事件
+type LogLinesEvent struct {
+	Lines []string `json:"lines"`
+}
+
+// StreamUserLogs 以 SSE 实时推送用户实例日志
+// GET /api/admin/v1/users/:id/logs/stream?lines=100&stream=stderr
+func (a *App) StreamUserLogs(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
+		return
+	}
+
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+	logFile, ok := selectLogFile(c, paths)
+	if !ok {
+		return
+	}
+
+	lines := 100
+	if n, err := strconv.Atoi(c.Query("lines")); err == nil && n >= 0 {
+		lines = min(n, 1000)
+	}
+
+	// 先记录当前大小再读取末尾，之后从该位置开始跟踪
+	var offset int64
+	var backlog []string
+	if stat, err := os.Stat(logFile); err == nil {
+		offset = stat.Size()
+		if lines > 0 {
+			content, _, err := readLastLines(logFile, lines)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取日志失败: %v", err)})
+				return
+			}
+			if content = strings.TrimRight(content, "\n"); content != "" {
+				backlog = strings.Split(content, "\n")
+			}
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	emit := func(event string, data any) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+	ping := func() {
+		_, _ = fmt.Fprint(c.Writer, ": ping\n\n")
+		c.Writer.Flush()
+	}
+	emitLines(emit, backlog)
+	c.Writer.Flush()
+	tailLog(c.Request.Context(), logFile, offset, emit, ping)
+}
+
+// emitLines 按批推送日志行
+func emitLines(emit func(string, any), lines []string) {
+	for len(lines) > 0 {
+		n := min(len(lines), logStreamMaxBatch)
+		emit("log", LogLinesEvent{Lines: lines[:n]})
+		lines = lines[n:]
+	}
+}
+
+// tailLog 从 offset 开始跟踪日志文件追加的完整行，直到 ctx 结束；文件变短时推送 reset 并从头读取
+func tailLog(ctx context.Context, path string, offset int64, emit func(string, any), ping func()) {
+	poll := time.NewTicker(logStreamPoll)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(logStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	var partial []byte // 尚未读到换行的末尾内容
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			ping()
+		case <-poll.C:
+			stat, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			size := stat.Size()
+			if size < offset {
+				offset, partial = 0, nil
+				emit("reset", gin.H{"size": size})
+			}
+			if size == offset {
+				continue
+			}
+			// 两次轮询间追加过多时只推送最后一段，避免一次读入整个文件
+			if size-offset > logStreamMaxRead {
+				offset, partial = size-logStreamMaxRead, nil
+			}
+			data, err := readRange(path, offset, size)
+			if err != nil {
+				continue
+			}
+			offset += int64(len(data))
+			data = append(partial, data...)
+			end := strings.LastIndexByte(string(data), '\n')
+			if end < 0 {
+				partial = data
+				continue
+			}
+			partial = append([]byte(nil), data[end+1:]...)
+			emitLines(emit, strings.Split(string(data[:end]), "\n"))
+		}
+	}
+}
+
+// readRange 读取文件 [from, to) 区间
+func readRange(path string, from, to int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, to-from)
+	n, err := f.ReadAt(buf, from)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// acceptsGzip Accept-Encoding 是否接受 gzip（q=0 表示拒绝）
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 日志搜索：在服务端逐行匹配实例日志，返回行号与上下文，无需下载整个文件。
+// 结果按时间顺序排列，超过 limit 时保留最近的匹配。
+
+const (
+	logSearchDefaultLimit = 100
+	logSearchMaxLimit     = 1000
+	logSearchMaxContext   = 10
+	logSearchMaxQuery     = 1000
+	logSearchMaxLineLen   = 2000 // 返回的单行最大长度，超出截断
+)
+
+// LogMatch 一处匹配
+type LogMatch struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"` // 从 1 开始
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// LogSearchResponse 搜索结果
+type LogSearchResponse struct {
+	Matches   []*LogMatch `json:"matches"`
+	Total     int         `json:"total"`     // 全部匹配数
+	Truncated bool        `json:"truncated"` // 匹配数超过 limit，只返回最近的 limit 条
+	Files     []string    `json:"files"`     // 已搜索的文件（由旧到新）
+}
+
+// logSearcher 逐行匹配并维护上下文
+type logSearcher struct {
+	match        func(string) bool
+	contextLines int
+	limit        int
+
+	resp    LogSearchResponse
+	before  []string    // 当前文件最近的 context 行
+	pending []*LogMatch // 仍在收集后文的匹配
+}
+
+func newLogSearcher(q string, isRegex, ignoreCase bool, contextLines, limit int) (*logSearcher, error) {
+	s := &logSearcher{contextLines: contextLines, limit: limit}
+	s.resp.Matches = []*LogMatch{}
+	s.resp.Files = []string{}
+	switch {
+	case isRegex:
+		if ignoreCase {
+			q = "(?i)" + q
+		}
+		re, err := regexp.Compile(q)
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式无效: %w", err)
+		}
+		s.match = re.MatchString
+	case ignoreCase:
+		lq := strings.ToLower(q)
+		s.match = func(line string) bool { return strings.Contains(strings.ToLower(line), lq) }
+	default:
+		s.match = func(line string) bool { return strings.Contains(line, q) }
+	}
+	return s, nil
+}
+
+// searchFile 搜索单个文件，不存在的文件跳过
+func (s *logSearcher) searchFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s.resp.Files = append(s.resp.Files, path)
+	s.before, s.pending = nil, nil
+
+	r := bufio.NewReaderSize(f, 64<<10)
+	for n := 1; ; n++ {
+		raw, err := r.ReadString('\n')
+		if raw == "" && err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if n%10000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.feed(path, n, clipLogLine(strings.TrimRight(raw, "\r\n")))
+	}
+}
+
+func (s *logSearcher) feed(path string, n int, line string) {
+	if s.contextLines > 0 {
+		kept := s.pending[:0]
+		for _, m := range s.pending {
+			m.After = append(m.After, line)
+			if len(m.After) < s.contextLines {
+				kept = append(kept, m)
+			}
+		}
+		s.pending = kept
+	}
+
+	if s.match(line) {
+		m := &LogMatch{File: path, Line: n, Text: line}
+		if len(s.before) > 0 {
+			m.Before = append([]string(nil), s.before...)
+		}
+		s.resp.Total++
+		s.resp.Matches = append(s.resp.Matches, m)
+		if len(s.resp.Matches) > s.limit {
+			s.resp.Matches = s.resp.Matches[1:]
+			s.resp.Truncated = true
+		}
+		if s.contextLines > 0 {
+			s.pending = append(s.pending, m)
+		}
+	}
+
+	if s.contextLines > 0 {
+		s.before = append(s.before, line)
+		if len(s.before) > s.contextLines {
+			s.before = s.before[1:]
+		}
+	}
+}
+
+func clipLogLine(line string) string {
+	if len(line) <= logSearchMaxLineLen {
+		return line
+	}
+	return strings.ToValidUTF8(line[:logSearchMaxLineLen], "") + "…"
+}
+
+// SearchUserLogs 搜索用户实例日志
+// GET /api/admin/v1/users/:id/logs/search?q=发布失败&regex=false&ignore_case=true&context=2&limit=100&rotated=true
+// rotated=true 时同时搜索轮转后的历史日志；stream=stderr 搜索标准错误日志
+func (a *App) SearchUserLogs(c *gin.Context) {
+	user, ok := a.store.GetUser(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	q := c.Query("q")
+	if q == "" || len(q) > logSearchMaxQuery {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("需要 q（最长 %d 字符）", logSearchMaxQuery)})
+		return
+	}
+	contextLines := 2
+	if v := c.Query("context"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "context 需为非负整数"})
+			return
+		}
+		contextLines = min(n, logSearchMaxContext)
+	}
+	limit := logSearchDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 需为正整数"})
+			return
+		}
+		limit = min(n, logSearchMaxLimit)
+	}
+
+	s, err := newLogSearcher(q, c.Query("regex") == "true", c.Query("ignore_case") == "true", contextLines, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logFile, ok := selectLogFile(c, a.proc.DerivePaths(a.store.UserDataDir(user), user.ID, user.Port))
+	if !ok {
+		return
+	}
+	var files []string
+	if c.Query("rotated") == "true" {
+		rotated := listRotatedLogs(logFile)
+		for i := len(rotated) - 1; i >= 0; i-- {
+			files = append(files, rotated[i].Path)
+		}
+	}
+	files = append(files, logFile)
+	for _, path := range files {
+		if err := s.searchFile(c.Request.Context(), path); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("搜索日志失败: %v", err)})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, s.resp)
+}
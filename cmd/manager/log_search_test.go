@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSearchUserLogs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	logFile := a.proc.DerivePaths(store.ResolveDataDir(), "u1", 18060).LogFile
+	writeTestFile(t, rotatedLogPath(logFile, 1), "old publish failed: timeout\nok\n")
+	writeTestFile(t, logFile, "start\nprepare\npublish failed: captcha\nretry\nPUBLISH FAILED: login expired\ndone\n")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/logs/search", a.SearchUserLogs)
+	search := func(query string) LogSearchResponse {
+		t.Helper()
+		w := doReq(r, http.MethodGet, "/users/u1/logs/search?"+query, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", query, w.Code, w.Body.String())
+		}
+		var resp LogSearchResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := search("q=publish+failed&context=1")
+	if resp.Total != 1 || resp.Matches[0].Line != 3 || resp.Matches[0].Before[0] != "prepare" || resp.Matches[0].After[0] != "retry" {
+		t.Fatalf("普通搜索不符: %+v", resp.Matches)
+	}
+	resp = search("q=publish+failed&ignore_case=true&rotated=true&limit=2&context=0")
+	if resp.Total != 3 || !resp.Truncated || len(resp.Matches) != 2 || resp.Matches[1].Line != 5 || len(resp.Files) != 2 {
+		t.Fatalf("应保留最近的匹配: %+v", resp)
+	}
+	resp = search("q=failed:+(captcha|timeout)&regex=true")
+	if resp.Total != 1 || resp.Matches[0].Text != "publish failed: captcha" {
+		t.Fatalf("正则搜索不符: %+v", resp.Matches)
+	}
+	if w := doReq(r, http.MethodGet, "/users/u1/logs/search?q=(&regex=true", "", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法正则应返回 400，got %d", w.Code)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTailLogFollowsAppendsAndTruncation(t *testing.T) {
+	oldPoll := logStreamPoll
+	logStreamPoll = 10 * time.Millisecond
+	defer func() { logStreamPoll = oldPoll }()
+
+	path := filepath.Join(t.TempDir(), "mcp.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	emit := func(event string, data any) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ev, ok := data.(LogLinesEvent); ok {
+			got = append(got, ev.Lines...)
+			return
+		}
+		got = append(got, "<"+event+">")
+	}
+	snapshot := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(got, "|")
+	}
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for snapshot() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("want %q, got %q", want, snapshot())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tailLog(ctx, path, 4, emit, func() {})
+
+	appendLog := func(s string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(s)
+		_ = f.Close()
+	}
+
+	// 未换行的内容等到整行写完再推送
+	appendLog("a\nb")
+	waitFor("a")
+	appendLog("c\n")
+	waitFor("a|bc")
+
+	// 清空日志后从头读取
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	appendLog("new\n")
+	waitFor("a|bc|<reset>|new")
+}
+
+func TestDownloadLogsGzip(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	content := strings.Repeat("publish ok\n", 1000)
+	writeTestFile(t, a.proc.DerivePaths(store.ResolveDataDir(), "u1", 18060).LogFile, content)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/debug/logs/download", a.DownloadDebugLogs)
+	gunzip := func(body []byte) string {
+		t.Helper()
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	w := doReq(r, http.MethodGet, "/users/u1/debug/logs/download?compress=gzip", "", nil)
+	if w.Header().Get("Content-Type") != "application/gzip" || !strings.Contains(w.Header().Get("Content-Disposition"), "u1.log.gz") {
+		t.Fatalf("gzip 下载响应头不符: %v", w.Header())
+	}
+	if gunzip(w.Body.Bytes()) != content || w.Body.Len() >= len(content) {
+		t.Fatal("gzip 内容不符或未压缩")
+	}
+
+	w = doReq(r, http.MethodGet, "/users/u1/debug/logs/download", "", http.Header{"Accept-Encoding": {"br, gzip;q=0.8"}})
+	if w.Header().Get("Content-Encoding") != "gzip" || gunzip(w.Body.Bytes()) != content {
+		t.Fatalf("按 Accept-Encoding 应压缩传输: %v", w.Header())
+	}
+	w = doReq(r, http.MethodGet, "/users/u1/debug/logs/download", "", http.Header{"Accept-Encoding": {"gzip;q=0"}})
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != content {
+		t.Fatal("拒绝 gzip 时应返回原文")
+	}
+}
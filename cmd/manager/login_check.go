@@ -0,0 +1,132 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 后台登录有效性检查：定期逐个查询运行中实例的登录状态并写入 store，
+// 账号列表可直接看到各账号会话是否有效。查询需要实例打开页面，因此串行执行并在账号之间留出间隔。
+
+// 登录状态
+const (
+	LoginValid   = "valid"   // 已登录
+	LoginExpired = "expired" // 实例确认未登录，需要重新扫码
+	LoginUnknown = "unknown" // 查询失败（实例未就绪、超时等），保留上次确认的昵称
+)
+
+// loginCheckGap 相邻两个账号检查之间的间隔，避免同时占用多个浏览器
+var loginCheckGap = 5 * time.Second
+
+// LoginHealth 最近一次登录检查结果，由 manager 维护
+type LoginHealth struct {
+	Status    string    `json:"status"`
+	Nickname  string    `json:"nickname,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// StartLoginChecker 按间隔检查所有运行中实例的登录状态，interval <= 0 不启用
+func (a *App) StartLoginChecker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	fmt.Printf("登录状态检查已启用，间隔 %s\n", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.checkAllLogins(ctx)
+			}
+		}
+	}()
+}
+
+func (a *App) checkAllLogins(ctx context.Context) {
+	first := true
+	for _, u := range a.store.ListUsers() {
+		if !a.proc.GetStatus(u.ID).Running {
+			continue
+		}
+		if !first {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(loginCheckGap):
+			}
+		}
+		first = false
+		if _, err := a.checkLogin(ctx, u); err != nil {
+			fmt.Printf("保存用户 %s 登录状态失败: %v\n", u.ID, err)
+		}
+	}
+}
+
+// checkLogin 查询一个实例的登录状态并写入 store
+func (a *App) checkLogin(ctx context.Context, u UserConfig) (*LoginHealth, error) {
+	h := &LoginHealth{CheckedAt: time.Now()}
+	info, err := a.queryLoginStatus(ctx, a.endpoint(u))
+	switch {
+	case err != nil:
+		h.Status = LoginUnknown
+		h.Error = err.Error()
+		if u.Login != nil {
+			h.Nickname = u.Login.Nickname
+		}
+	case info.IsLoggedIn:
+		h.Status = LoginValid
+		h.Nickname = info.Username
+	default:
+		h.Status = LoginExpired
+	}
+	prev := ""
+	if u.Login != nil {
+		prev = u.Login.Status
+	}
+	if prev == LoginValid && h.Status == LoginExpired {
+		fmt.Printf("用户 %s 登录已失效，需要重新扫码登录\n", u.ID)
+	}
+	if prev != h.Status {
+		a.proc.publish(eventLoginChanged, fmt.Sprintf("用户 %s 登录状态 %s → %s", u.ID, cmp.Or(prev, "-"), h.Status),
+			ProcessEvent{User: u.ID, State: h.Status, Prev: prev, Error: h.Error})
+	}
+	return h, a.store.SetUserLogin(u.ID, h)
+}
+
+// CheckUserLogin 立即检查登录状态
+// POST /api/admin/v1/users/:id/login/check
+func (a *App) CheckUserLogin(c *gin.Context) {
+	if _, ok := a.runningEndpoint(c); !ok {
+		return
+	}
+	user, _ := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	h, err := a.checkLogin(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
+
+// loginSummary 账号列表中的登录状态汇总
+func loginSummary(users []UserConfig) map[string]int {
+	out := map[string]int{}
+	for _, u := range users {
+		status := "unchecked"
+		if u.Login != nil {
+			status = strings.TrimSpace(u.Login.Status)
+		}
+		out[status]++
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckLoginPersistsResult(t *testing.T) {
+	var loggedIn atomic.Bool
+	var broken atomic.Bool
+	loggedIn.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken.Load() {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true,"data":{"is_logged_in":` + strconv.FormatBool(loggedIn.Load()) + `,"username":"小红"}}`))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: port}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	check := func() *LoginHealth {
+		t.Helper()
+		user, _ := store.GetUser("u1")
+		if _, err := a.checkLogin(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+		user, _ = store.GetUser("u1")
+		if user.Login == nil || user.Login.CheckedAt.IsZero() {
+			t.Fatalf("检查结果应写入 store: %+v", user.Login)
+		}
+		return user.Login
+	}
+
+	if h := check(); h.Status != LoginValid || h.Nickname != "小红" {
+		t.Fatalf("valid: %+v", h)
+	}
+	// 查询失败时为 unknown，保留上次的昵称
+	broken.Store(true)
+	if h := check(); h.Status != LoginUnknown || h.Nickname != "小红" || h.Error == "" {
+		t.Fatalf("unknown: %+v", h)
+	}
+	broken.Store(false)
+	loggedIn.Store(false)
+	if h := check(); h.Status != LoginExpired {
+		t.Fatalf("expired: %+v", h)
+	}
+
+	summary := loginSummary(append(store.ListUsers(), UserConfig{ID: "u2"}))
+	if summary[LoginExpired] != 1 || summary["unchecked"] != 1 {
+		t.Fatalf("summary = %v", summary)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 登录流程 SSE：推送二维码与登录状态变化，二维码过期时自动刷新。
+// 实例登录成功后会写入 cookies 文件，这里通过文件修改时间判断是否需要确认登录状态，
+// 避免频繁调用需要打开浏览器页面的 /api/v1/login/status。
+var (
+	loginStreamPoll       = time.Second
+	loginStreamHeartbeat  = 15 * time.Second
+	loginStreamMaxQRCodes = 3 // 最多刷新的二维码数量，超过后结束推送
+)
+
+const (
+	loginStateWaiting  = "waiting"
+	loginStateLoggedIn = "logged_in"
+	loginStateExpired  = "expired"
+	loginStateError    = "error"
+)
+
+// LoginQRCodeEvent qrcode 事件
+type LoginQRCodeEvent struct {
+	Img       string `json:"img"`
+	Timeout   string `json:"timeout"`
+	ExpiresAt string `json:"expires_at"`
+	Refresh   int    `json:"refresh"` // 第几次刷新，首张为 0
+}
+
+// LoginStateEvent status 事件
+type LoginStateEvent struct {
+	State    string `json:"state"` // waiting, logged_in, expired, error
+	Username string `json:"username,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GetDebugLoginStream 以 SSE 推送登录二维码与登录状态
+// GET /api/admin/v1/users/:id/debug/login/stream
+func (a *App) GetDebugLoginStream(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 不能为空"})
+		return
+	}
+
+	user, ok := a.store.GetUser(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	st := a.proc.GetStatus(id)
+	if !st.Running {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户进程未运行"})
+		return
+	}
+
+	if !a.proc.CheckHealth(a.endpoint(user), 800*time.Millisecond) {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户实例健康检查失败，请稍后重试"})
+		return
+	}
+
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), id, user.Port)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	emit := func(event string, data any) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+	ping := func() {
+		_, _ = fmt.Fprint(c.Writer, ": ping\n\n")
+		c.Writer.Flush()
+	}
+	a.streamLogin(c.Request.Context(), a.endpoint(user), paths.CookiesPath, emit, ping)
+}
+
+// streamLogin 驱动登录推送流程，直到登录成功、二维码全部过期、出错或客户端断开
+func (a *App) streamLogin(ctx context.Context, ep InstanceEndpoint, cookiesPath string, emit func(string, any), ping func()) {
+	poll := time.NewTicker(loginStreamPoll)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(loginStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for refresh := 0; refresh < loginStreamMaxQRCodes; refresh++ {
+		qr, err := a.fetchLoginQRCode(ctx, ep)
+		if err != nil {
+			emit("status", LoginStateEvent{State: loginStateError, Error: err.Error()})
+			return
+		}
+		if qr.IsLoggedIn {
+			a.emitLoggedIn(ctx, ep, emit)
+			return
+		}
+
+		timeout, err := time.ParseDuration(qr.Timeout)
+		if err != nil || timeout <= 0 {
+			timeout = 4 * time.Minute
+		}
+		expiresAt := time.Now().Add(timeout)
+		baseline := fileModTime(cookiesPath)
+		emit("qrcode", LoginQRCodeEvent{
+			Img:       qr.Img,
+			Timeout:   qr.Timeout,
+			ExpiresAt: expiresAt.Format(time.RFC3339),
+			Refresh:   refresh,
+		})
+		emit("status", LoginStateEvent{State: loginStateWaiting})
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				ping()
+			case <-poll.C:
+				if mt := fileModTime(cookiesPath); mt.After(baseline) {
+					baseline = mt
+					info, err := a.queryLoginStatus(ctx, ep)
+					if err == nil && info.IsLoggedIn {
+						emit("status", LoginStateEvent{State: loginStateLoggedIn, Username: info.Username})
+						return
+					}
+				}
+				if time.Now().After(expiresAt) {
+					break wait
+				}
+			}
+		}
+	}
+	emit("status", LoginStateEvent{State: loginStateExpired})
+}
+
+// loginQRCode 实例 /api/v1/login/qrcode 的 data 结构
+type loginQRCode struct {
+	Timeout    string `json:"timeout"`
+	IsLoggedIn bool   `json:"is_logged_in"`
+	Img        string `json:"img"`
+}
+
+func (a *App) fetchLoginQRCode(ctx context.Context, ep InstanceEndpoint) (loginQRCode, error) {
+	status, _, data, err := a.proxyGet(ctx, ep, "/api/v1/login/qrcode", 60*time.Second)
+	if err != nil {
+		return loginQRCode{}, fmt.Errorf("获取二维码失败: %w", err)
+	}
+	if status >= 400 {
+		return loginQRCode{}, fmt.Errorf("获取二维码失败: HTTP %d: %s", status, string(data))
+	}
+	var resp struct {
+		Success bool        `json:"success"`
+		Data    loginQRCode `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return loginQRCode{}, fmt.Errorf("解析二维码响应失败: %w", err)
+	}
+	if !resp.Success {
+		return loginQRCode{}, fmt.Errorf("获取二维码失败: %s", string(data))
+	}
+	return resp.Data, nil
+}
+
+func (a *App) emitLoggedIn(ctx context.Context, ep InstanceEndpoint, emit func(string, any)) {
+	info, _ := a.queryLoginStatus(ctx, ep)
+	emit("status", LoginStateEvent{State: loginStateLoggedIn, Username: info.Username})
+}
+
+func fileModTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamLoginRefreshesAndDetectsLogin(t *testing.T) {
+	oldPoll, oldMax := loginStreamPoll, loginStreamMaxQRCodes
+	loginStreamPoll, loginStreamMaxQRCodes = 20*time.Millisecond, 3
+	defer func() { loginStreamPoll, loginStreamMaxQRCodes = oldPoll, oldMax }()
+
+	cookiesPath := filepath.Join(t.TempDir(), "cookies.json")
+	var loggedIn atomic.Bool
+	var qrcodes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login/qrcode":
+			qrcodes.Add(1)
+			_, _ = w.Write([]byte(`{"success":true,"data":{"timeout":"100ms","img":"data:image/png;base64,xx"}}`))
+		case "/api/v1/login/status":
+			_, _ = w.Write([]byte(`{"success":true,"data":{"is_logged_in":` + strconv.FormatBool(loggedIn.Load()) + `,"username":"小红"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	var events []string
+	var last LoginStateEvent
+	emit := func(event string, data any) {
+		events = append(events, event)
+		if st, ok := data.(LoginStateEvent); ok {
+			last = st
+		}
+		// 第二张二维码下发后模拟扫码成功：实例写入 cookies
+		if q, ok := data.(LoginQRCodeEvent); ok && q.Refresh == 1 {
+			loggedIn.Store(true)
+			time.Sleep(10 * time.Millisecond)
+			_ = os.WriteFile(cookiesPath, []byte("[]"), 0644)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	(&App{}).streamLogin(ctx, InstanceEndpoint{Port: port}, cookiesPath, emit, func() {})
+
+	if qrcodes.Load() != 2 {
+		t.Fatalf("二维码过期后应自动刷新一次，got %d", qrcodes.Load())
+	}
+	if last.State != loginStateLoggedIn || last.Username != "小红" {
+		t.Fatalf("最终应推送 logged_in，got %+v (events=%v)", last, events)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 日志保留清理：轮转只限制单个文件与历史文件数，长期运行后已停止账号、已删除账号的日志仍会堆积。
+// 后台定期按 retain_days 删除过期日志，按 max_total_mb 从最旧的历史日志删起；
+// 运行中实例正在写入的当前日志不删除（其大小由轮转限制）。
+
+// logJanitorInterval 后台清理间隔
+var logJanitorInterval = 10 * time.Minute
+
+// LogCleanupResult 一次清理的结果
+type LogCleanupResult struct {
+	Removed    []string `json:"removed"`
+	FreedBytes int64    `json:"freed_bytes"`
+}
+
+func (r *LogCleanupResult) remove(path string, size int64) {
+	if err := os.Remove(path); err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("删除日志 %s 失败: %v\n", path, err)
+		}
+		return
+	}
+	r.Removed = append(r.Removed, path)
+	r.FreedBytes += size
+}
+
+// enforceLogRetention 按保留策略清理单个账号的日志；running 为 true 时保留当前日志
+func enforceLogRetention(path string, cfg LogRotationConfig, running bool, now time.Time, res *LogCleanupResult) {
+	var cutoff time.Time
+	if cfg.RetainDays > 0 {
+		cutoff = now.AddDate(0, 0, -cfg.RetainDays)
+	}
+
+	var total int64
+	if fi, err := os.Stat(path); err == nil {
+		if !running && !cutoff.IsZero() && fi.ModTime().Before(cutoff) {
+			res.remove(path, fi.Size())
+		} else {
+			total = fi.Size()
+		}
+	}
+
+	var kept []RotatedLog
+	for _, r := range listRotatedLogs(path) {
+		if !cutoff.IsZero() && !r.modTime.IsZero() && r.modTime.Before(cutoff) {
+			res.remove(r.Path, r.SizeBytes)
+			continue
+		}
+		kept = append(kept, r)
+		total += r.SizeBytes
+	}
+
+	if cfg.MaxTotalMB <= 0 {
+		return
+	}
+	// 序号越大越旧
+	for i := len(kept) - 1; i >= 0 && total > cfg.MaxTotalMB<<20; i-- {
+		res.remove(kept[i].Path, kept[i].SizeBytes)
+		total -= kept[i].SizeBytes
+	}
+}
+
+// CleanupLogs 对全部账号执行日志保留策略，并清理全局日志目录中已删除账号的过期日志
+func (a *App) CleanupLogs(now time.Time) LogCleanupResult {
+	cfg := a.proc.LogRotation()
+	res := LogCleanupResult{Removed: []string{}}
+	known := map[string]bool{}
+	for _, u := range a.store.ListUsers() {
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+		running := a.proc.GetStatus(u.ID).Running
+		for _, logFile := range []string{paths.LogFile, paths.ErrLogFile} {
+			known[filepath.Base(logFile)] = true
+			enforceLogRetention(logFile, cfg, running, now, &res)
+		}
+	}
+
+	if cfg.RetainDays > 0 {
+		logDir := filepath.Dir(a.proc.DerivePaths(a.store.ResolveDataDir(), "_", 0).LogFile)
+		bases := map[string]bool{}
+		entries, _ := os.ReadDir(logDir)
+		for _, e := range entries {
+			name := e.Name()
+			if i := strings.Index(name, ".log"); i > 0 && !e.IsDir() {
+				bases[name[:i+len(".log")]] = true
+			}
+		}
+		for base := range bases {
+			if !known[base] {
+				enforceLogRetention(filepath.Join(logDir, base), cfg, false, now, &res)
+			}
+		}
+	}
+
+	if len(res.Removed) > 0 {
+		fmt.Printf("日志清理：删除 %d 个文件，释放 %.1f MB\n", len(res.Removed), float64(res.FreedBytes)/(1<<20))
+	}
+	return res
+}
+
+// StartLogJanitor 定期执行日志保留策略
+func (a *App) StartLogJanitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(logJanitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.CleanupLogs(time.Now())
+			}
+		}
+	}()
+}
+
+// PostLogCleanup 立即执行日志保留策略
+// POST /api/admin/v1/logs/cleanup
+func (a *App) PostLogCleanup(c *gin.Context) {
+	c.JSON(http.StatusOK, a.CleanupLogs(time.Now()))
+}
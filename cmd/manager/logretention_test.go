@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRetention(t *testing.T) {
+	t.Chdir(t.TempDir())
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1", Port: 18060}); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(store, NewProcessManager(), "")
+	logFile := app.proc.DerivePaths(store.ResolveDataDir(), "u1", 18060).LogFile
+	orphan := filepath.Join(filepath.Dir(logFile), "gone.log")
+
+	now := time.Now()
+	old := now.Add(-10 * 24 * time.Hour)
+	mb := strings.Repeat("x", 1<<20)
+	for path, mtime := range map[string]time.Time{
+		logFile:                    now,
+		rotatedLogPath(logFile, 1): now,
+		rotatedLogPath(logFile, 2): now,
+		rotatedLogPath(logFile, 3): old,
+		orphan:                     old,
+	} {
+		writeTestFile(t, path, mb)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app.proc.SetLogRotation(LogRotationConfig{RetainDays: 7, MaxTotalMB: 2})
+	res := app.CleanupLogs(now)
+	// .3 过期，.2 超出总大小，已删除账号的过期日志也被清理
+	if len(res.Removed) != 3 || res.FreedBytes != 3<<20 {
+		t.Fatalf("清理结果不符: %+v", res)
+	}
+	for _, path := range []string{logFile, rotatedLogPath(logFile, 1)} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("%s 不应被删除: %v", path, err)
+		}
+	}
+	for _, path := range []string{rotatedLogPath(logFile, 2), rotatedLogPath(logFile, 3), orphan} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s 应被删除", path)
+		}
+	}
+
+	// 已停止账号的过期当前日志同样删除
+	if err := os.Chtimes(logFile, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if res := app.CleanupLogs(now); len(res.Removed) != 1 || res.Removed[0] != logFile {
+		t.Fatalf("应删除过期的当前日志: %+v", res)
+	}
+}
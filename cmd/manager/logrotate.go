@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 子进程日志轮转：<id>.log 超过大小或写入时长后依次移为 <id>.log.1 … <id>.log.N，超出保留数的删除。
+// 运行中的实例以 O_APPEND 持有日志文件，采用“复制后截断”，实例无需重新打开文件；
+// 启动实例前轮转直接重命名。
+
+const (
+	defaultLogMaxSizeMB = 50
+	defaultLogMaxFiles  = 5
+)
+
+// logRotateInterval 检查运行中实例日志的间隔
+var logRotateInterval = time.Minute
+
+// LogRotationConfig 日志轮转配置，保存在 store 中
+type LogRotationConfig struct {
+	MaxSizeMB   int64 `json:"max_size_mb,omitempty"`   // 单个日志超过该大小后轮转，0 使用默认 50MB，负数不按大小轮转
+	MaxAgeHours int   `json:"max_age_hours,omitempty"` // 当前日志写入超过该时长后轮转，0 表示不按时间轮转
+	MaxFiles    int   `json:"max_files,omitempty"`     // 保留的历史日志数，0 使用默认 5
+
+	RetainDays int   `json:"retain_days,omitempty"`  // 历史日志及已停止账号的日志超过该天数未写入即删除，0 表示不按时间清理
+	MaxTotalMB int64 `json:"max_total_mb,omitempty"` // 每个账号日志（当前+历史）总大小上限，超出时从最旧的历史日志删起，0 表示不限制
+	AlertMB    int64 `json:"alert_mb,omitempty"`     // 运行中账号的日志总大小超过该值时发布 log_size_alert 事件，0 表示不提醒
+}
+
+// Effective 补全默认值
+func (c LogRotationConfig) Effective() LogRotationConfig {
+	if c.MaxSizeMB == 0 {
+		c.MaxSizeMB = defaultLogMaxSizeMB
+	}
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = defaultLogMaxFiles
+	}
+	return c
+}
+
+func validateLogRotation(c LogRotationConfig) error {
+	if c.MaxAgeHours < 0 {
+		return fmt.Errorf("max_age_hours 不能为负数")
+	}
+	if c.MaxFiles < 0 || c.MaxFiles > 100 {
+		return fmt.Errorf("max_files 取值范围 0-100")
+	}
+	if c.RetainDays < 0 {
+		return fmt.Errorf("retain_days 不能为负数")
+	}
+	if c.MaxTotalMB < 0 {
+		return fmt.Errorf("max_total_mb 不能为负数")
+	}
+	if c.AlertMB < 0 {
+		return fmt.Errorf("alert_mb 不能为负数")
+	}
+	return nil
+}
+
+// due 日志是否需要轮转；since 为当前日志开始写入的时间
+func (c LogRotationConfig) due(size int64, since, now time.Time) bool {
+	if size == 0 {
+		return false
+	}
+	if c.MaxSizeMB > 0 && size >= c.MaxSizeMB<<20 {
+		return true
+	}
+	return c.MaxAgeHours > 0 && !since.IsZero() && now.Sub(since) >= time.Duration(c.MaxAgeHours)*time.Hour
+}
+
+// rotatedLogPath 第 n 个历史日志路径
+func rotatedLogPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
+
+// rotateLogFile 轮转日志：copyTruncate 为 true 时复制后截断（文件被运行中的实例持有），否则直接重命名
+func rotateLogFile(path string, keep int, copyTruncate bool) error {
+	if keep <= 0 {
+		keep = defaultLogMaxFiles
+	}
+	// 删除超出保留数的历史文件（包括之前保留数更大时留下的）
+	for _, old := range listRotatedLogs(path) {
+		if old.Index >= keep {
+			_ = os.Remove(old.Path)
+		}
+	}
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedLogPath(path, i), rotatedLogPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("轮转日志失败: %w", err)
+		}
+	}
+	if !copyTruncate {
+		if err := os.Rename(path, rotatedLogPath(path, 1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("轮转日志失败: %w", err)
+		}
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(rotatedLogPath(path, 1), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("复制日志失败: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Truncate(path, 0)
+}
+
+// RotatedLog 历史日志文件
+type RotatedLog struct {
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	Mtime     string `json:"mtime,omitempty"`
+
+	modTime time.Time
+}
+
+// listRotatedLogs 按序号列出历史日志
+func listRotatedLogs(path string) []RotatedLog {
+	matches, _ := filepath.Glob(path + ".*")
+	var out []RotatedLog
+	for _, m := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(m, path+"."))
+		if err != nil || n <= 0 {
+			continue
+		}
+		item := RotatedLog{Index: n, Path: m}
+		if fi, err := os.Stat(m); err == nil {
+			item.SizeBytes = fi.Size()
+			item.Mtime = fi.ModTime().Format(time.RFC3339)
+			item.modTime = fi.ModTime()
+		}
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
+
+// SetLogRotation 设置日志轮转配置，对运行中的实例立即生效
+func (pm *ProcessManager) SetLogRotation(c LogRotationConfig) {
+	pm.mu.Lock()
+	pm.rotation = c.Effective()
+	pm.mu.Unlock()
+}
+
+// LogRotation 当前生效的日志轮转配置
+func (pm *ProcessManager) LogRotation() LogRotationConfig {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.rotation
+}
+
+// rotateBeforeStart 启动实例前按大小轮转（此时没有进程持有日志文件）
+func (pm *ProcessManager) rotateBeforeStart(path string) {
+	cfg := pm.LogRotation()
+	fi, err := os.Stat(path)
+	if err != nil || !cfg.due(fi.Size(), time.Time{}, time.Now()) {
+		return
+	}
+	if err := rotateLogFile(path, cfg.MaxFiles, false); err != nil {
+		fmt.Printf("轮转日志 %s 失败: %v\n", path, err)
+	}
+}
+
+// StartLogRotation 定期检查运行中实例的日志并轮转
+func (pm *ProcessManager) StartLogRotation(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(logRotateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pm.rotateRunningLogs(time.Now())
+				pm.checkLogSizes()
+			}
+		}
+	}()
+}
+
+func (pm *ProcessManager) rotateRunningLogs(now time.Time) {
+	type target struct {
+		path  string
+		since *time.Time // 受 pm.mu 保护
+	}
+	pm.mu.RLock()
+	cfg := pm.rotation
+	var targets []target
+	for _, p := range pm.procs {
+		if !p.alive() {
+			continue
+		}
+		if p.logFile != nil {
+			targets = append(targets, target{path: p.logFile.Name(), since: &p.logSince})
+		}
+		if p.errLogFile != nil {
+			targets = append(targets, target{path: p.errLogFile.Name(), since: &p.errLogSince})
+		}
+	}
+	pm.mu.RUnlock()
+
+	for _, t := range targets {
+		pm.mu.RLock()
+		since := *t.since
+		pm.mu.RUnlock()
+		fi, err := os.Stat(t.path)
+		if err != nil || !cfg.due(fi.Size(), since, now) {
+			continue
+		}
+		if err := rotateLogFile(t.path, cfg.MaxFiles, true); err != nil {
+			fmt.Printf("轮转日志 %s 失败: %v\n", t.path, err)
+			continue
+		}
+		pm.mu.Lock()
+		*t.since = now
+		pm.mu.Unlock()
+	}
+}
+
+// checkLogSizes 运行中账号的日志总大小（当前+历史）超过 alert_mb 时发布 log_size_alert，
+// 回落到阈值以下（轮转清理或手动删除）之前不重复提醒
+func (pm *ProcessManager) checkLogSizes() {
+	pm.mu.RLock()
+	limit := pm.rotation.AlertMB << 20
+	logs := map[string][]string{}
+	for id, p := range pm.procs {
+		if limit > 0 && p.alive() {
+			paths := pm.DerivePaths(p.params.DataDir, id, p.params.User.Port)
+			logs[id] = []string{paths.LogFile, paths.ErrLogFile}
+		}
+	}
+	pm.mu.RUnlock()
+
+	for id, paths := range logs {
+		var total int64
+		for _, path := range paths {
+			total += logsSize(path)
+		}
+		pm.mu.Lock()
+		if pm.logAlerts == nil {
+			pm.logAlerts = map[string]bool{}
+		}
+		alerted := pm.logAlerts[id]
+		pm.logAlerts[id] = total > limit
+		pm.mu.Unlock()
+		if total > limit && !alerted {
+			fmt.Printf("用户 %s 日志已达 %s，超过提醒阈值\n", id, formatBytes(total))
+			pm.publish(eventLogSizeAlert, fmt.Sprintf("用户 %s 日志已达 %s，超过 %d MB", id, formatBytes(total), limit>>20),
+				ProcessEvent{User: id, Size: total})
+		}
+	}
+}
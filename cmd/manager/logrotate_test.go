@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotateLogFileKeepsLimitedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "u1.log")
+	for i, content := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// 交替使用重命名与复制截断
+		if err := rotateLogFile(path, 2, i%2 == 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rotated := listRotatedLogs(path)
+	if len(rotated) != 2 || rotated[0].Index != 1 || rotated[1].Index != 2 {
+		t.Fatalf("应只保留 2 个历史日志，got %+v", rotated)
+	}
+	if data, _ := os.ReadFile(rotated[0].Path); string(data) != "four\n" {
+		t.Fatalf("最新的历史日志应为 four，got %q", data)
+	}
+	if data, _ := os.ReadFile(rotated[1].Path); string(data) != "three\n" {
+		t.Fatalf("次新的历史日志应为 three，got %q", data)
+	}
+	// 复制截断后当前日志保留为空文件，实例可继续写入
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+		t.Fatalf("复制截断后当前日志应为空: %v", err)
+	}
+}
+
+func TestLogRotationDue(t *testing.T) {
+	now := time.Now()
+	cfg := LogRotationConfig{MaxSizeMB: 1, MaxAgeHours: 24}.Effective()
+	if cfg.due(0, now.Add(-48*time.Hour), now) {
+		t.Fatal("空日志不需要轮转")
+	}
+	if !cfg.due(1<<20, now, now) {
+		t.Fatal("超过大小应轮转")
+	}
+	if !cfg.due(10, now.Add(-25*time.Hour), now) {
+		t.Fatal("超过时长应轮转")
+	}
+	if cfg.due(10, now.Add(-time.Hour), now) {
+		t.Fatal("未超过大小和时长不应轮转")
+	}
+	if (LogRotationConfig{MaxSizeMB: -1}).Effective().due(1<<40, now, now) {
+		t.Fatal("关闭按大小轮转后不应轮转")
+	}
+}
+
+func TestStoreLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	s, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.LogRotation(); got.MaxSizeMB != defaultLogMaxSizeMB || got.MaxFiles != defaultLogMaxFiles {
+		t.Fatalf("未配置时应使用默认值，got %+v", got)
+	}
+	if err := s.SetLogRotation(LogRotationConfig{MaxFiles: -1}); err == nil {
+		t.Fatal("非法保留数应被拒绝")
+	}
+	if err := s.SetLogRotation(LogRotationConfig{MaxSizeMB: 10, MaxAgeHours: 24, MaxFiles: 3}); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.LogRotation(); got.MaxSizeMB != 10 || got.MaxAgeHours != 24 || got.MaxFiles != 3 {
+		t.Fatalf("轮转配置应持久化，got %+v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 多账号同时调用同一个 MCP 工具：POST /api/admin/v1/mcp/broadcast
+//
+//	{"tags":["campaign-a"],"name":"publish_content","arguments":{...},
+//	 "per_user_arguments":{"shop-01":{"title":"..."}},"concurrency":4}
+//
+// 目标账号需通过 ids 或 tags 明确指定（不会默认选中全部账号）。per_user_arguments 中的参数按账号覆盖 arguments
+// 的同名参数，便于同一活动为每个账号调整标题等。每个账号的调用计入转发限流，汇总返回各账号的结果。
+
+const (
+	defaultBroadcastConcurrency = 4
+	maxBroadcastConcurrency     = 16
+)
+
+type mcpBroadcastReq struct {
+	batchReq
+	MCPCallRequest
+	PerUserArguments map[string]map[string]any `json:"per_user_arguments,omitempty"`
+	Concurrency      int                       `json:"concurrency,omitempty"` // 同时调用的账号数，默认 4，最大 16
+}
+
+// mcpBroadcastItem 单个账号的调用结果
+type mcpBroadcastItem struct {
+	ID         string           `json:"id"`
+	Status     string           `json:"status"` // ok, tool_error, not_found, not_running, unhealthy, rate_limited, error
+	Error      string           `json:"error,omitempty"`
+	DurationMs int64            `json:"duration_ms"`
+	Result     *MCPCallResponse `json:"result,omitempty"`
+}
+
+// broadcastArguments 账号实际使用的参数
+func broadcastArguments(req mcpBroadcastReq, id string) map[string]any {
+	override, ok := req.PerUserArguments[id]
+	if !ok {
+		return req.Arguments
+	}
+	args := maps.Clone(req.Arguments)
+	if args == nil {
+		args = map[string]any{}
+	}
+	maps.Copy(args, override)
+	return args
+}
+
+// broadcastOne 在一个账号上调用工具
+func (a *App) broadcastOne(ctx context.Context, u UserConfig, req mcpBroadcastReq, timeout time.Duration) mcpBroadcastItem {
+	item := mcpBroadcastItem{ID: u.ID, Status: "error"}
+	if !a.proc.GetStatus(u.ID).Running {
+		item.Status, item.Error = "not_running", "用户进程未运行"
+		return item
+	}
+	ep := a.endpoint(u)
+	if !a.proc.CheckHealth(ep, 800*time.Millisecond) {
+		item.Status, item.Error = "unhealthy", "用户实例健康检查失败"
+		return item
+	}
+	args, err := a.mediaArguments(u, broadcastArguments(req, u.ID))
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	release, reason, _ := a.throttle.acquire(u, false, time.Now())
+	if release == nil {
+		item.Status, item.Error = "rate_limited", "超出转发限流（"+reason+"）"
+		return item
+	}
+	defer release()
+
+	start := time.Now()
+	res, err := a.callMCPTool(ctx, ep, req.Name, args, timeout)
+	item.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.Result = res
+	item.Status = "ok"
+	if res.IsError {
+		item.Status = "tool_error"
+	}
+	return item
+}
+
+// BroadcastMCPCall 在多个账号上并发调用同一个 MCP 工具
+// POST /api/admin/v1/mcp/broadcast
+func (a *App) BroadcastMCPCall(c *gin.Context) {
+	var req mcpBroadcastReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "工具名称不能为空"})
+		return
+	}
+	if len(req.IDs) == 0 && len(normalizeTags(req.Tags)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要通过 ids 或 tags 指定账号"})
+		return
+	}
+	if req.Concurrency < 0 || req.Concurrency > maxBroadcastConcurrency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("concurrency 范围为 1~%d", maxBroadcastConcurrency)})
+		return
+	}
+	users := a.store.ListUsers()
+	resolved, err := resolveBatchIDs(req.batchReq, users)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 去重，避免同一账号重复发布
+	ids := make([]string, 0, len(resolved))
+	for _, id := range resolved {
+		if id = strings.TrimSpace(id); !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	for id := range req.PerUserArguments {
+		if !slices.Contains(ids, id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("per_user_arguments 中的 %s 不在目标账号中", id)})
+			return
+		}
+	}
+	usersByID := make(map[string]UserConfig, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+
+	timeout := normalizeMCPCallTimeout(req.Name, req.TimeoutMs)
+	workers := req.Concurrency
+	if workers == 0 {
+		workers = defaultBroadcastConcurrency
+	}
+	workers = min(workers, len(ids))
+
+	out := make([]mcpBroadcastItem, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				id := ids[idx]
+				u, ok := usersByID[id]
+				if !ok || id == "" {
+					out[idx] = mcpBroadcastItem{ID: id, Status: "not_found", Error: "用户不存在"}
+					continue
+				}
+				out[idx] = a.broadcastOne(c.Request.Context(), u, req, timeout)
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	succeeded := 0
+	for _, it := range out {
+		if it.Status == "ok" {
+			succeeded++
+		}
+	}
+	fmt.Printf("MCP 广播 %s：%d 个账号，成功 %d\n", req.Name, len(ids), succeeded)
+	c.JSON(http.StatusOK, gin.H{
+		"tool":    req.Name,
+		"summary": gin.H{"requested": len(ids), "succeeded": succeeded, "failed": len(ids) - succeeded},
+		"results": out,
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBroadcastArguments(t *testing.T) {
+	req := mcpBroadcastReq{
+		MCPCallRequest:   MCPCallRequest{Arguments: map[string]any{"title": "通用", "content": "正文"}},
+		PerUserArguments: map[string]map[string]any{"u1": {"title": "u1 专属"}},
+	}
+	if args := broadcastArguments(req, "u1"); args["title"] != "u1 专属" || args["content"] != "正文" {
+		t.Fatalf("args = %v", args)
+	}
+	if args := broadcastArguments(req, "u2"); args["title"] != "通用" {
+		t.Fatalf("args = %v", args)
+	}
+	if req.Arguments["title"] != "通用" {
+		t.Fatal("不应修改公共参数")
+	}
+}
+
+func TestBroadcastMCPCall(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []UserConfig{{ID: "u1", Port: 18101, Tags: []string{"a"}}, {ID: "u2", Port: 18102, Tags: []string{"a"}}} {
+		if err := store.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	r := gin.New()
+	r.POST("/mcp/broadcast", a.BroadcastMCPCall)
+
+	for _, body := range []string{
+		`{"name":"publish_content"}`,
+		`{"ids":["u1"]}`,
+		`{"name":"publish_content","ids":["u1"],"concurrency":99}`,
+		`{"name":"publish_content","ids":["u1"],"per_user_arguments":{"u2":{}}}`,
+	} {
+		if w := doReq(r, http.MethodPost, "/mcp/broadcast", body, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: 应返回 400，got %d", body, w.Code)
+		}
+	}
+
+	w := doReq(r, http.MethodPost, "/mcp/broadcast", `{"name":"check_login_status","tags":["a"]}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Summary struct{ Requested, Succeeded, Failed int }
+		Results []mcpBroadcastItem
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary.Requested != 2 || resp.Summary.Failed != 2 || len(resp.Results) != 2 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	for _, it := range resp.Results {
+		if it.Status != "not_running" {
+			t.Fatalf("%s: 未运行的账号应为 not_running，got %s", it.ID, it.Status)
+		}
+	}
+
+	// 重复的 id 只调用一次
+	w = doReq(r, http.MethodPost, "/mcp/broadcast", `{"name":"check_login_status","ids":["u1"," u1","ghost"]}`, nil)
+	resp.Results = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || resp.Results[1].Status != "not_found" {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+}
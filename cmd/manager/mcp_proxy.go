@@ -4,14 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // withMCPSession 创建MCP会话并执行操作
-func (a *App) withMCPSession(ctx context.Context, port int, timeout time.Duration, fn func(context.Context, *mcp.ClientSession) error) error {
+func (a *App) withMCPSession(ctx context.Context, ep InstanceEndpoint, timeout time.Duration, fn func(context.Context, *mcp.ClientSession) error) error {
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
@@ -24,8 +23,8 @@ func (a *App) withMCPSession(ctx context.Context, port int, timeout time.Duratio
 	}, nil)
 
 	transport := &mcp.StreamableClientTransport{
-		Endpoint:   fmt.Sprintf("http://127.0.0.1:%d/mcp", port),
-		HTTPClient: &http.Client{Timeout: timeout},
+		Endpoint:   ep.URL("/mcp"),
+		HTTPClient: ep.HTTPClient(timeout),
 		MaxRetries: 0, // 不重试
 	}
 
@@ -39,9 +38,9 @@ func (a *App) withMCPSession(ctx context.Context, port int, timeout time.Duratio
 }
 
 // fetchMCPTools 获取MCP工具列表
-func (a *App) fetchMCPTools(ctx context.Context, port int) ([]MCPToolInfo, error) {
+func (a *App) fetchMCPTools(ctx context.Context, ep InstanceEndpoint) ([]MCPToolInfo, error) {
 	var out []MCPToolInfo
-	if err := a.withMCPSession(ctx, port, 15*time.Second, func(ctx context.Context, session *mcp.ClientSession) error {
+	if err := a.withMCPSession(ctx, ep, 15*time.Second, func(ctx context.Context, session *mcp.ClientSession) error {
 		toolsResult, err := session.ListTools(ctx, nil)
 		if err != nil {
 			return fmt.Errorf("获取工具列表失败: %w", err)
@@ -73,9 +72,9 @@ func (a *App) fetchMCPTools(ctx context.Context, port int) ([]MCPToolInfo, error
 }
 
 // callMCPTool 调用MCP工具
-func (a *App) callMCPTool(ctx context.Context, port int, name string, args map[string]any, timeout time.Duration) (*MCPCallResponse, error) {
+func (a *App) callMCPTool(ctx context.Context, ep InstanceEndpoint, name string, args map[string]any, timeout time.Duration) (*MCPCallResponse, error) {
 	var out *MCPCallResponse
-	if err := a.withMCPSession(ctx, port, timeout, func(ctx context.Context, session *mcp.ClientSession) error {
+	if err := a.withMCPSession(ctx, ep, timeout, func(ctx context.Context, session *mcp.ClientSession) error {
 		res, err := session.CallTool(ctx, &mcp.CallToolParams{
 			Name:      name,
 			Arguments: args,
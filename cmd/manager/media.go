@@ -0,0 +1,384 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 媒体库：图片/视频上传到 manager 一次，之后在调试调用与多账号广播的 MCP 参数中以 "media:<ID>"
+// 引用（如 "images":["media:3f2a…"]、"video":"media:…"）。调用时 manager 把文件放到目标账号的
+// 临时目录（<data_dir>/tmp/<id>/media/，Docker 模式下已挂载进容器）并替换为绝对路径，
+// 无需把素材复制到每个实例所在的文件系统。
+// 文件保存在 <data_dir>/media/ 下：<ID><扩展名> 为文件，<ID>.json 为说明。ID 取内容 sha256 的前 16 位，
+// 重复上传同一文件得到同一个 ID。
+
+const (
+	maxMediaSize        = 1 << 30 // 单个文件上限 1GB
+	maxMediaNameLen     = 200
+	mediaRefPrefix      = "media:"
+	mediaStageSubdir    = "media"
+	mediaIDLen          = 16
+	mediaSniffLen       = 512
+	mediaUploadTempGlob = ".upload-*"
+)
+
+var mediaIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// mediaExts 常见类型的扩展名，浏览器上传时据此识别文件类型
+var mediaExts = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"video/webm":      ".webm",
+}
+
+// Media 媒体文件说明
+type Media struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"` // image 或 video
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"`
+	Ext         string    `json:"ext"`
+	CreatedAt   time.Time `json:"created_at"`
+	Ref         string    `json:"ref"` // 在 MCP 参数中引用的写法
+}
+
+func (a *App) mediaDir() string {
+	return filepath.Join(a.store.ResolveDataDir(), "media")
+}
+
+// mediaContentType 识别文件类型，无法从内容识别时按文件名的扩展名判断
+func mediaContentType(head []byte, name string) string {
+	ct := http.DetectContentType(head)
+	if ct == "application/octet-stream" || strings.HasPrefix(ct, "text/plain") {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExt != "" {
+			ct = byExt
+		}
+	}
+	ct, _, _ = strings.Cut(ct, ";")
+	return strings.TrimSpace(ct)
+}
+
+// readMedia 读取媒体说明，文件不存在时返回 os.ErrNotExist
+func readMedia(dir, id string) (Media, error) {
+	var m Media
+	if !mediaIDPattern.MatchString(id) {
+		return m, os.ErrNotExist
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return m, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, id+m.Ext)); err != nil {
+		return m, err
+	}
+	m.ID, m.Ref = id, mediaRefPrefix+id
+	return m, nil
+}
+
+// listMedia 按上传时间倒序列出媒体
+func listMedia(dir string) ([]Media, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Media{}, nil
+		}
+		return nil, err
+	}
+	out := []Media{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !mediaIDPattern.MatchString(id) {
+			continue
+		}
+		if m, err := readMedia(dir, id); err == nil {
+			out = append(out, m)
+		}
+	}
+	slices.SortFunc(out, func(x, y Media) int {
+		if r := y.CreatedAt.Compare(x.CreatedAt); r != 0 {
+			return r
+		}
+		return strings.Compare(x.ID, y.ID)
+	})
+	return out, nil
+}
+
+// saveMedia 保存上传的文件；内容已存在时返回已有的记录与 false
+func (a *App) saveMedia(dir, name string, r io.Reader) (Media, bool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Media{}, false, fmt.Errorf("创建媒体目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, mediaUploadTempGlob)
+	if err != nil {
+		return Media{}, false, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // 保存成功后已不存在
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxMediaSize+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Media{}, false, fmt.Errorf("接收文件失败: %w", err)
+	}
+	if n == 0 {
+		return Media{}, false, errors.New("文件内容为空")
+	}
+	if n > maxMediaSize {
+		return Media{}, false, fmt.Errorf("文件超过 %s", formatBytes(maxMediaSize))
+	}
+
+	head := make([]byte, mediaSniffLen)
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return Media{}, false, err
+	}
+	k, _ := io.ReadFull(f, head)
+	f.Close()
+	ct := mediaContentType(head[:k], name)
+	kind, _, _ := strings.Cut(ct, "/")
+	if kind != "image" && kind != "video" {
+		return Media{}, false, fmt.Errorf("仅支持图片与视频，识别到的类型为 %s", ct)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	id := sum[:mediaIDLen]
+	// 扩展名需与识别到的类型一致，实例按扩展名判断文件类型
+	ext, ok := mediaExts[ct]
+	if byName := strings.ToLower(filepath.Ext(name)); !ok && mime.TypeByExtension(byName) == ct {
+		ext = byName
+	}
+	if name == "" {
+		name = id + ext
+	}
+
+	a.mediaMu.Lock()
+	defer a.mediaMu.Unlock()
+	if m, err := readMedia(dir, id); err == nil {
+		return m, false, nil
+	}
+	m := Media{ID: id, Name: name, Kind: kind, ContentType: ct, SizeBytes: n, SHA256: sum, Ext: ext, CreatedAt: time.Now(), Ref: mediaRefPrefix + id}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, id+ext)); err != nil {
+		return Media{}, false, fmt.Errorf("保存文件失败: %w", err)
+	}
+	raw, _ := json.MarshalIndent(m, "", "  ")
+	if err := fileutil.WriteFileAtomic(filepath.Join(dir, id+".json"), raw, 0644); err != nil {
+		_ = os.Remove(filepath.Join(dir, id+ext))
+		return Media{}, false, err
+	}
+	return m, true, nil
+}
+
+// stageMedia 把媒体文件放到账号的临时目录，返回实例可访问的绝对路径。
+// 同一文件系统时使用硬链接，否则复制
+func stageMedia(dir string, m Media, tempDir string) (string, error) {
+	src := filepath.Join(dir, m.ID+m.Ext)
+	dstDir, err := filepath.Abs(filepath.Join(tempDir, mediaStageSubdir))
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dstDir, m.ID+m.Ext)
+	if fi, err := os.Stat(dst); err == nil && fi.Size() == m.SizeBytes {
+		return dst, nil
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %w", err)
+	}
+	_ = os.Remove(dst)
+	if os.Link(src, dst) == nil {
+		return dst, nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(dstDir, "."+m.ID+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("复制媒体文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// resolveMediaRefs 返回把 "media:<ID>" 替换为 stage 结果后的参数副本，不修改 v
+func resolveMediaRefs(v any, stage func(id string) (string, error)) (any, error) {
+	switch x := v.(type) {
+	case string:
+		if id, ok := strings.CutPrefix(x, mediaRefPrefix); ok && mediaIDPattern.MatchString(id) {
+			return stage(id)
+		}
+		return x, nil
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			r, err := resolveMediaRefs(e, stage)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			r, err := resolveMediaRefs(e, stage)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// mediaArguments 为账号准备参数中引用的媒体文件；没有引用时原样返回
+func (a *App) mediaArguments(u UserConfig, args map[string]any) (map[string]any, error) {
+	if args == nil {
+		return nil, nil
+	}
+	staged := map[string]string{}
+	resolved, err := resolveMediaRefs(args, func(id string) (string, error) {
+		if p, ok := staged[id]; ok {
+			return p, nil
+		}
+		m, err := readMedia(a.mediaDir(), id)
+		if err != nil {
+			return "", fmt.Errorf("媒体 %s 不存在", id)
+		}
+		if a.endpoint(u).Host != "" {
+			return "", errors.New("实例不在本机运行，无法使用媒体库文件")
+		}
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+		p, err := stageMedia(a.mediaDir(), m, paths.TempDir)
+		if err != nil {
+			return "", fmt.Errorf("传输媒体 %s 失败: %w", id, err)
+		}
+		staged[id] = p
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(staged) == 0 {
+		return args, nil
+	}
+	return resolved.(map[string]any), nil
+}
+
+// ListMedia 列出媒体库
+// GET /api/admin/v1/media
+func (a *App) ListMedia(c *gin.Context) {
+	list, err := listMedia(a.mediaDir())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var total int64
+	for _, m := range list {
+		total += m.SizeBytes
+	}
+	c.JSON(http.StatusOK, gin.H{"media": list, "total_bytes": total})
+}
+
+// UploadMedia 上传图片或视频，请求体为文件内容，?name= 为文件名（可选，用于识别类型与展示）。
+// 内容已存在时返回 200 与已有记录
+// POST /api/admin/v1/media
+func (a *App) UploadMedia(c *gin.Context) {
+	name := filepath.Base(strings.TrimSpace(c.Query("name")))
+	if name == "." || name == string(filepath.Separator) {
+		name = ""
+	}
+	if utf8.RuneCountInString(name) > maxMediaNameLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name 最多 %d 个字符", maxMediaNameLen)})
+		return
+	}
+	if c.Request.ContentLength > maxMediaSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过 %s", formatBytes(maxMediaSize))})
+		return
+	}
+	m, created, err := a.saveMedia(a.mediaDir(), name, c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusOK, m)
+		return
+	}
+	fmt.Printf("媒体库新增 %s（%s，%s）\n", m.ID, m.Name, formatBytes(m.SizeBytes))
+	c.JSON(http.StatusCreated, m)
+}
+
+// GetMedia 查看媒体说明，?download=true 时返回文件内容
+// GET /api/admin/v1/media/:mid
+func (a *App) GetMedia(c *gin.Context) {
+	m, err := readMedia(a.mediaDir(), c.Param("mid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "媒体不存在"})
+		return
+	}
+	if c.Query("download") == "true" {
+		c.FileAttachment(filepath.Join(a.mediaDir(), m.ID+m.Ext), m.Name)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// DeleteMedia 从媒体库删除文件，已传输到各账号临时目录的副本随临时目录清理
+// DELETE /api/admin/v1/media/:mid
+func (a *App) DeleteMedia(c *gin.Context) {
+	dir := a.mediaDir()
+	a.mediaMu.Lock()
+	defer a.mediaMu.Unlock()
+	m, err := readMedia(dir, c.Param("mid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "媒体不存在"})
+		return
+	}
+	if err := os.Remove(filepath.Join(dir, m.ID+".json")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	_ = os.Remove(filepath.Join(dir, m.ID+m.Ext))
+	fmt.Printf("媒体库已删除 %s（%s）\n", m.ID, m.Name)
+	c.JSON(http.StatusOK, gin.H{"deleted": m.ID})
+}
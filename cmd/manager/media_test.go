@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMediaLibrary(t *testing.T) {
+	dir := t.TempDir()
+	store, err := LoadStore(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDataDir(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/media", a.ListMedia)
+	r.POST("/media", a.UploadMedia)
+	r.GET("/media/:mid", a.GetMedia)
+	r.DELETE("/media/:mid", a.DeleteMedia)
+
+	octet := http.Header{"Content-Type": {"application/octet-stream"}}
+	png := "\x89PNG\r\n\x1a\n" + "fake image data"
+	w := doReq(r, http.MethodPost, "/media?name=cover.png", png, octet)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", w.Code, w.Body.String())
+	}
+	var m Media
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Kind != "image" || m.Ext != ".png" || m.Name != "cover.png" || m.Ref != "media:"+m.ID {
+		t.Fatalf("media = %+v", m)
+	}
+	// 相同内容得到同一个 ID
+	if w := doReq(r, http.MethodPost, "/media", png, octet); w.Code != http.StatusOK {
+		t.Fatalf("重复上传应返回 200，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/media?name=a.txt", "hello", octet); w.Code != http.StatusBadRequest {
+		t.Fatalf("非图片/视频应拒绝，got %d", w.Code)
+	}
+	w = doReq(r, http.MethodGet, "/media", "", nil)
+	var list struct {
+		Media []Media `json:"media"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Media) != 1 {
+		t.Fatalf("list: %s", w.Body.String())
+	}
+	if w := doReq(r, http.MethodGet, "/media/"+m.ID+"?download=true", "", nil); w.Body.String() != png {
+		t.Fatalf("download: %q", w.Body.String())
+	}
+
+	// 参数中的引用替换为账号临时目录下的路径，原参数不变
+	u, _ := store.GetUser("u1")
+	args := map[string]any{"title": "标题", "images": []any{"media:" + m.ID, "https://example.com/a.jpg"}}
+	got, err := a.mediaArguments(u, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staged := got["images"].([]any)[0].(string)
+	paths := a.proc.DerivePaths(store.UserDataDir(u), u.ID, u.Port)
+	if want, _ := filepath.Abs(filepath.Join(paths.TempDir, "media", m.ID+".png")); staged != want {
+		t.Fatalf("staged = %s, want %s", staged, want)
+	}
+	if raw, err := os.ReadFile(staged); err != nil || string(raw) != png {
+		t.Fatalf("staged content: %q %v", raw, err)
+	}
+	if args["images"].([]any)[0] != "media:"+m.ID || got["images"].([]any)[1] != "https://example.com/a.jpg" {
+		t.Fatalf("args = %v, got = %v", args, got)
+	}
+	if _, err := a.mediaArguments(u, map[string]any{"video": "media:0000000000000000"}); err == nil {
+		t.Fatal("引用不存在的媒体应报错")
+	}
+
+	if w := doReq(r, http.MethodDelete, "/media/"+m.ID, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := doReq(r, http.MethodGet, "/media/"+m.ID, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("删除后应返回 404，got %d", w.Code)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// manager 自身的 Prometheus 指标：/metrics 输出账号进程状态、启停计数、日志大小与管理 API 请求数。
+// 账号运营数据（粉丝、发布数等）见 /api/admin/v1/analytics?format=prometheus。
+
+// procCounters 单个账号的进程计数，manager 重启后清零
+type procCounters struct {
+	starts        int // 成功拉起子进程
+	restarts      int // 同一账号再次启动（含崩溃后重启）
+	startFailures int
+	stopFailures  int // 停止被取消或超时后强制终止
+	crashes       int // 运行中意外退出
+	autoRestarts  int // 按重启策略自动拉起
+}
+
+// procMetrics 进程计数
+type procMetrics struct {
+	mu    sync.Mutex
+	users map[string]*procCounters
+}
+
+func (m *procMetrics) inc(userID string, fn func(c *procCounters)) {
+	if userID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.users == nil {
+		m.users = map[string]*procCounters{}
+	}
+	c := m.users[userID]
+	if c == nil {
+		c = &procCounters{}
+		m.users[userID] = c
+	}
+	fn(c)
+}
+
+func (m *procMetrics) get(userID string) procCounters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c := m.users[userID]; c != nil {
+		return *c
+	}
+	return procCounters{}
+}
+
+func (m *procMetrics) snapshot() map[string]procCounters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]procCounters, len(m.users))
+	for id, c := range m.users {
+		out[id] = *c
+	}
+	return out
+}
+
+// requestKey 管理 API 请求计数的标签
+type requestKey struct {
+	method, route string
+	code          int
+}
+
+// requestMetrics 管理 API 请求计数
+type requestMetrics struct {
+	mu     sync.Mutex
+	counts map[requestKey]int
+}
+
+// Middleware 按方法、路由模板和状态码计数
+func (m *requestMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.mu.Lock()
+		if m.counts == nil {
+			m.counts = map[requestKey]int{}
+		}
+		m.counts[requestKey{method: c.Request.Method, route: route, code: c.Writer.Status()}]++
+		m.mu.Unlock()
+	}
+}
+
+func (m *requestMetrics) snapshot() map[requestKey]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[requestKey]int, len(m.counts))
+	for k, v := range m.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// metricWriter 按 Prometheus 文本格式输出：同名指标的样本需连续出现且只写一次 HELP/TYPE，
+// 因此先按指标名缓存，flush 时按首次出现的顺序输出
+type metricWriter struct {
+	w       io.Writer
+	order   []string
+	header  map[string]string
+	samples map[string][]string
+}
+
+func newMetricWriter(w io.Writer) *metricWriter {
+	return &metricWriter{w: w, header: map[string]string{}, samples: map[string][]string{}}
+}
+
+func (mw *metricWriter) write(name, typ, help, labels string, v float64) {
+	if _, ok := mw.header[name]; !ok {
+		mw.order = append(mw.order, name)
+		mw.header[name] = fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	mw.samples[name] = append(mw.samples[name], fmt.Sprintf("%s%s %s\n", name, labels, strconv.FormatFloat(v, 'f', -1, 64)))
+}
+
+func (mw *metricWriter) flush() {
+	for _, name := range mw.order {
+		io.WriteString(mw.w, mw.header[name])
+		for _, line := range mw.samples[name] {
+			io.WriteString(mw.w, line)
+		}
+	}
+}
+
+// writeManagerMetrics 输出 manager 指标
+func (a *App) writeManagerMetrics(w io.Writer) {
+	mw := newMetricWriter(w)
+	defer mw.flush()
+	users := a.store.ListUsers()
+	counters := a.proc.metrics.snapshot()
+
+	running := 0
+	for _, u := range users {
+		if a.proc.GetStatus(u.ID).Running {
+			running++
+		}
+	}
+	mw.write("xhs_manager_users", "gauge", "已配置的账号数", "", float64(len(users)))
+	mw.write("xhs_manager_users_running", "gauge", "运行中的账号实例数", "", float64(running))
+
+	for _, u := range users {
+		label := fmt.Sprintf("user=%q", u.ID)
+		st := a.proc.GetStatus(u.ID)
+		mw.write("xhs_manager_process_up", "gauge", "账号实例是否运行", label, boolFloat(st.Running))
+		if st.Running {
+			if t, err := time.Parse(time.RFC3339, st.StartedAt); err == nil {
+				mw.write("xhs_manager_process_start_time_seconds", "gauge", "实例启动时间（Unix 秒）", label, float64(t.Unix()))
+			}
+			if usage, err := a.proc.ResourceUsage(u.ID); err == nil && usage != nil {
+				mw.write("xhs_manager_process_resident_memory_bytes", "gauge", "实例及浏览器进程的常驻内存", label, float64(usage.RSSBytes))
+				mw.write("xhs_manager_process_cpu_percent", "gauge", "实例及浏览器进程的 CPU 占用（单核百分比）", label, usage.CPUPercent)
+				if usage.OpenFDs >= 0 {
+					mw.write("xhs_manager_process_open_fds", "gauge", "实例及浏览器进程打开的文件描述符数", label, float64(usage.OpenFDs))
+				}
+			}
+		}
+		paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+		mw.write("xhs_manager_log_bytes", "gauge", "实例日志大小（含轮转后的历史日志）", label, float64(logsSize(paths.LogFile)+logsSize(paths.ErrLogFile)))
+	}
+
+	ids := make([]string, 0, len(counters))
+	for id := range counters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	counterDefs := []struct {
+		name, help string
+		value      func(c procCounters) int
+	}{
+		{"xhs_manager_process_starts_total", "成功拉起实例进程次数", func(c procCounters) int { return c.starts }},
+		{"xhs_manager_process_restarts_total", "同一账号再次启动次数", func(c procCounters) int { return c.restarts }},
+		{"xhs_manager_start_failures_total", "实例启动失败次数", func(c procCounters) int { return c.startFailures }},
+		{"xhs_manager_stop_failures_total", "实例停止被取消或超时强制终止次数", func(c procCounters) int { return c.stopFailures }},
+		{"xhs_manager_process_crashes_total", "实例运行中意外退出次数", func(c procCounters) int { return c.crashes }},
+		{"xhs_manager_auto_restarts_total", "按重启策略自动重启次数", func(c procCounters) int { return c.autoRestarts }},
+	}
+	for _, def := range counterDefs {
+		for _, id := range ids {
+			mw.write(def.name, "counter", def.help, fmt.Sprintf("user=%q", id), float64(def.value(counters[id])))
+		}
+	}
+
+	inflight, rejected := a.throttle.snapshot()
+	for _, u := range users {
+		mw.write("xhs_manager_proxy_inflight_requests", "gauge", "经 manager 转发中的实例请求数", fmt.Sprintf("user=%q", u.ID), float64(inflight[u.ID]))
+	}
+	rejectKeys := make([]proxyRejectKey, 0, len(rejected))
+	for k := range rejected {
+		rejectKeys = append(rejectKeys, k)
+	}
+	sort.Slice(rejectKeys, func(i, j int) bool {
+		if rejectKeys[i].user != rejectKeys[j].user {
+			return rejectKeys[i].user < rejectKeys[j].user
+		}
+		return rejectKeys[i].reason < rejectKeys[j].reason
+	})
+	for _, k := range rejectKeys {
+		mw.write("xhs_manager_proxy_rejected_total", "counter", "转发请求因限流被拒绝的次数", fmt.Sprintf("user=%q,reason=%q", k.user, k.reason), float64(rejected[k]))
+	}
+
+	requests := a.requests.snapshot()
+	keys := make([]requestKey, 0, len(requests))
+	for k := range requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, k := range keys {
+		labels := fmt.Sprintf("method=%q,route=%q,code=%q", k.method, k.route, strconv.Itoa(k.code))
+		mw.write("xhs_manager_admin_requests_total", "counter", "管理 API 请求数", labels, float64(requests[k]))
+	}
+}
+
+// HandleMetrics Prometheus 抓取入口
+// GET /metrics
+func (a *App) HandleMetrics(c *gin.Context) {
+	var buf bytes.Buffer
+	a.writeManagerMetrics(&buf)
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+}
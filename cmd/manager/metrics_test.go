@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestManagerMetrics(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []string{"alice", "bob"} {
+		if err := store.CreateUser(UserConfig{ID: id, Port: 18060 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proc := NewProcessManager()
+	app := NewApp(store, proc, "")
+	proc.metrics.inc("alice", func(c *procCounters) { c.starts += 2; c.crashes++ })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api/admin/v1", app.requests.Middleware())
+	api.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	for i := 0; i < 3; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/admin/v1/users/x", nil))
+	}
+
+	var buf bytes.Buffer
+	app.writeManagerMetrics(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"xhs_manager_users 2\n",
+		"xhs_manager_users_running 0\n",
+		`xhs_manager_process_up{user="alice"} 0` + "\n",
+		`xhs_manager_process_starts_total{user="alice"} 2` + "\n",
+		`xhs_manager_process_crashes_total{user="alice"} 1` + "\n",
+		`xhs_manager_log_bytes{user="alice"} 0` + "\n",
+		`xhs_manager_admin_requests_total{method="GET",route="/api/admin/v1/users/:id",code="404"} 3` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("缺少指标 %q\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "# TYPE xhs_manager_process_up "); n != 1 {
+		t.Fatalf("同名指标的 TYPE 应只输出一次，got %d", n)
+	}
+	// 同名指标的样本必须连续出现
+	var last string
+	done := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(line, "{")
+		name, _, _ = strings.Cut(name, " ")
+		if name != last {
+			if done[name] {
+				t.Fatalf("指标 %s 的样本不连续\n%s", name, out)
+			}
+			done[last] = true
+			last = name
+		}
+	}
+}
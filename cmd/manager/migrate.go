@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 数据目录迁移：把用户的 cookies、浏览器用户目录、日志、运行状态与临时目录整体移到新位置，
+// 同一磁盘直接重命名，跨磁盘先复制、核对大小后再删除源文件。
+// 迁移期间实例会被停止，迁移后启动一次确认可用；启动失败时数据与配置都会回滚。
+
+// MigrateResult 单个用户的迁移结果
+type MigrateResult struct {
+	UserID   string   `json:"user_id"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Moved    []string `json:"moved"`
+	Verified bool     `json:"verified"` // 迁移后实例已成功启动
+	Error    string   `json:"error,omitempty"`
+}
+
+type migrateReq struct {
+	DataDir string `json:"data_dir"`
+	Verify  *bool  `json:"verify"` // 迁移后启动实例确认可用，默认 true；原本在运行的实例总会重新启动
+}
+
+// userDataPaths 随用户迁移的路径；socket 由实例运行时创建，不需要迁移
+func userDataPaths(p DerivedPaths) []string {
+	return []string{p.CookiesPath, p.UserDataDir, p.LogFile, p.ErrLogFile, p.StateDir, p.TempDir, p.SnapshotDir}
+}
+
+// moveUserData 把用户数据从 from 数据目录移到 to，返回移动后的路径。
+// 目标位置已有数据时拒绝迁移；中途失败会把已移动的部分移回原处。
+func moveUserData(pm *ProcessManager, u UserConfig, from, to string) ([]string, error) {
+	src := userDataPaths(pm.DerivePaths(from, u.ID, u.Port))
+	dst := userDataPaths(pm.DerivePaths(to, u.ID, u.Port))
+	for _, p := range dst {
+		if _, err := os.Lstat(p); err == nil {
+			return nil, fmt.Errorf("目标位置已存在: %s", p)
+		}
+	}
+	var moved []int
+	for i := range src {
+		if _, err := os.Lstat(src[i]); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := movePath(src[i], dst[i]); err != nil {
+			for j := len(moved) - 1; j >= 0; j-- {
+				_ = movePath(dst[moved[j]], src[moved[j]])
+			}
+			return nil, fmt.Errorf("迁移 %s 失败（已回滚）: %w", src[i], err)
+		}
+		moved = append(moved, i)
+	}
+	out := make([]string, 0, len(moved))
+	for _, i := range moved {
+		out = append(out, dst[i])
+	}
+	return out, nil
+}
+
+// movePath 移动文件或目录，无法重命名（如跨磁盘）时复制后核对大小再删除源
+func movePath(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	if want, got := dirSize(src), dirSize(dst); want != got {
+		_ = os.RemoveAll(dst)
+		return fmt.Errorf("复制后大小不一致: %d != %d", got, want)
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree 复制文件或目录，保留权限与修改时间；符号链接原样重建，socket 等特殊文件跳过
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target, info)
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// startForMigration 按当前配置启动用户实例（StartUser 内含健康检查）
+func (a *App) startForMigration(ctx context.Context, u UserConfig) error {
+	startCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+	return a.proc.StartUser(startCtx, StartUserParams{
+		User:     u,
+		BinPath:  a.store.ResolveBinPath(),
+		Headless: a.store.GetConfig().Headless,
+		DataDir:  a.store.UserDataDir(u),
+	})
+}
+
+// verifyMigrated 迁移后启动实例确认可用，原本未运行的实例确认后再停止
+func (a *App) verifyMigrated(ctx context.Context, u UserConfig, wasRunning bool) error {
+	if err := a.startForMigration(ctx, u); err != nil {
+		_ = a.proc.StopUser(context.Background(), u.ID, 0)
+		return err
+	}
+	if !wasRunning {
+		return a.proc.StopUser(ctx, u.ID, 0)
+	}
+	return nil
+}
+
+// MigrateUserData 把单个用户迁移到独立的数据目录；目标为全局数据目录时改回使用全局目录
+func (a *App) MigrateUserData(ctx context.Context, u UserConfig, to string, verify bool) (MigrateResult, error) {
+	from := a.store.UserDataDir(u)
+	res := MigrateResult{UserID: u.ID, From: from, To: to}
+	if filepath.Clean(to) == filepath.Clean(from) {
+		return res, fmt.Errorf("目标与当前数据目录相同")
+	}
+
+	wasRunning := a.proc.GetStatus(u.ID).Running
+	if wasRunning {
+		if err := a.proc.StopUser(ctx, u.ID, 0); err != nil {
+			return res, fmt.Errorf("停止实例失败: %w", err)
+		}
+	}
+	restart := func() {
+		if wasRunning {
+			_ = a.startForMigration(context.Background(), u)
+		}
+	}
+
+	moved, err := moveUserData(a.proc, u, from, to)
+	if err != nil {
+		restart()
+		return res, err
+	}
+	res.Moved = moved
+
+	prevDir := u.DataDir
+	u.DataDir = to
+	if to == a.store.ResolveDataDir() {
+		u.DataDir = ""
+	}
+	rollback := func() {
+		_, _ = moveUserData(a.proc, u, to, from)
+		_ = a.store.SetUserDataDir(u.ID, prevDir)
+		u.DataDir = prevDir
+		restart()
+	}
+	if err := a.store.SetUserDataDir(u.ID, u.DataDir); err != nil {
+		rollback()
+		return res, err
+	}
+
+	if verify || wasRunning {
+		if err := a.verifyMigrated(ctx, u, wasRunning); err != nil {
+			rollback()
+			return res, fmt.Errorf("迁移后实例启动失败，已回滚: %w", err)
+		}
+		res.Verified = true
+	}
+	fmt.Printf("用户 %s 数据目录已迁移: %s -> %s\n", u.ID, from, to)
+	return res, nil
+}
+
+// MigrateAllData 迁移全局数据目录：移动所有使用全局目录的用户数据并更新 data_dir。
+// 移动阶段任一用户失败会全部回滚；更新配置后的启动确认失败只在结果中报告，不再回滚。
+func (a *App) MigrateAllData(ctx context.Context, to string, verify bool) ([]MigrateResult, error) {
+	from := a.store.ResolveDataDir()
+	if filepath.Clean(to) == filepath.Clean(from) {
+		return nil, fmt.Errorf("目标与当前数据目录相同")
+	}
+
+	var (
+		users   []UserConfig
+		running = map[string]bool{}
+	)
+	for _, u := range a.store.ListUsers() {
+		if u.DataDir != "" {
+			continue // 单独迁移过的用户保持原位置
+		}
+		users = append(users, u)
+		if a.proc.GetStatus(u.ID).Running {
+			running[u.ID] = true
+		}
+	}
+	restartAll := func() {
+		for _, u := range users {
+			if running[u.ID] {
+				_ = a.startForMigration(context.Background(), u)
+			}
+		}
+	}
+	for _, u := range users {
+		if running[u.ID] {
+			if err := a.proc.StopUser(ctx, u.ID, 0); err != nil {
+				restartAll()
+				return nil, fmt.Errorf("停止用户 %s 失败: %w", u.ID, err)
+			}
+		}
+	}
+
+	results := make([]MigrateResult, 0, len(users))
+	rollback := func() {
+		for i := len(results) - 1; i >= 0; i-- {
+			_, _ = moveUserData(a.proc, users[i], to, from)
+		}
+		restartAll()
+	}
+	for _, u := range users {
+		moved, err := moveUserData(a.proc, u, from, to)
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("用户 %s: %w", u.ID, err)
+		}
+		results = append(results, MigrateResult{UserID: u.ID, From: from, To: to, Moved: moved})
+	}
+	if err := a.store.SetDataDir(to); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	for i, u := range users {
+		if !verify && !running[u.ID] {
+			continue
+		}
+		if err := a.verifyMigrated(ctx, u, running[u.ID]); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Verified = true
+	}
+	fmt.Printf("数据目录已迁移: %s -> %s（%d 个用户）\n", from, to, len(users))
+	return results, nil
+}
+
+// runMigrateCommand 命令行迁移，返回进程退出码
+func runMigrateCommand(a *App, to, userID string, verify bool) int {
+	abs, err := filepath.Abs(to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "迁移失败: %v\n", err)
+		return 2
+	}
+	ctx := context.Background()
+	var results []MigrateResult
+	if userID != "" {
+		u, ok := a.store.GetUser(userID)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "用户不存在: %s\n", userID)
+			return 2
+		}
+		var res MigrateResult
+		res, err = a.MigrateUserData(ctx, u, abs, verify)
+		results = append(results, res)
+	} else {
+		results, err = a.MigrateAllData(ctx, abs, verify)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "迁移失败: %v\n", err)
+		return 1
+	}
+	code := 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			fmt.Printf("%s: 已迁移，但启动确认失败: %s\n", r.UserID, r.Error)
+			code = 1
+		case r.Verified:
+			fmt.Printf("%s: 已迁移并确认可启动\n", r.UserID)
+		default:
+			fmt.Printf("%s: 已迁移\n", r.UserID)
+		}
+	}
+	return code
+}
+
+// parseMigrateReq 解析迁移请求，目标路径转为绝对路径
+func parseMigrateReq(c *gin.Context) (string, bool, bool) {
+	var req migrateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return "", false, false
+	}
+	dir := strings.TrimSpace(req.DataDir)
+	if dir == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "data_dir 不能为空"})
+		return "", false, false
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false, false
+	}
+	return abs, req.Verify == nil || *req.Verify, true
+}
+
+// MigrateUser 迁移单个用户的数据目录
+// POST /api/admin/v1/users/:id/migrate
+func (a *App) MigrateUser(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	to, verify, ok := parseMigrateReq(c)
+	if !ok {
+		return
+	}
+	a.migrateMu.Lock()
+	defer a.migrateMu.Unlock()
+	res, err := a.MigrateUserData(c.Request.Context(), user, to, verify)
+	if err != nil {
+		res.Error = err.Error()
+		c.JSON(http.StatusConflict, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// MigrateAll 迁移全局数据目录
+// POST /api/admin/v1/migrate
+func (a *App) MigrateAll(c *gin.Context) {
+	to, verify, ok := parseMigrateReq(c)
+	if !ok {
+		return
+	}
+	a.migrateMu.Lock()
+	defer a.migrateMu.Unlock()
+	results, err := a.MigrateAllData(c.Request.Context(), to, verify)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data_dir": to, "users": results})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func newMigrateTestApp(t *testing.T, bin string) (*App, string) {
+	t.Helper()
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "users.json")
+	cfg := `{"bin":"` + filepath.ToSlash(bin) + `","headless":true,"data_dir":"` + filepath.ToSlash(filepath.Join(dir, "data")) + `","users":[{"id":"u1","port":18999}]}`
+	if err := os.WriteFile(storePath, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := LoadStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewApp(store, NewProcessManager(), ""), dir
+}
+
+func seedUserData(t *testing.T, a *App, u UserConfig) DerivedPaths {
+	t.Helper()
+	paths := a.proc.DerivePaths(a.store.UserDataDir(u), u.ID, u.Port)
+	writeSized(t, paths.CookiesPath, 10)
+	writeSized(t, filepath.Join(paths.UserDataDir, "Default", "Cookies"), 20)
+	writeSized(t, paths.LogFile, 30)
+	writeSized(t, filepath.Join(paths.StateDir, "tasks.jsonl"), 40)
+	return paths
+}
+
+func TestMigrateUserDataMovesFiles(t *testing.T) {
+	a, dir := newMigrateTestApp(t, "unused")
+	u, _ := a.store.GetUser("u1")
+	old := seedUserData(t, a, u)
+
+	to := filepath.Join(dir, "volume2")
+	res, err := a.MigrateUserData(context.Background(), u, to, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Moved) != 4 || res.Verified {
+		t.Fatalf("result = %+v", res)
+	}
+	u, _ = a.store.GetUser("u1")
+	if u.DataDir != to || a.store.UserDataDir(u) != to {
+		t.Fatalf("store data_dir = %q", u.DataDir)
+	}
+	moved := a.proc.DerivePaths(to, u.ID, u.Port)
+	if fi, err := os.Stat(filepath.Join(moved.UserDataDir, "Default", "Cookies")); err != nil || fi.Size() != 20 {
+		t.Fatalf("profile not moved: %v", err)
+	}
+	if _, err := os.Stat(old.CookiesPath); !os.IsNotExist(err) {
+		t.Fatal("源文件应已移走")
+	}
+
+	// 目标与当前目录相同、目标位置已有数据时均拒绝
+	seedUserData(t, a, UserConfig{ID: "u1", Port: 18999})
+	if _, err := a.MigrateUserData(context.Background(), u, to, false); err == nil {
+		t.Fatal("目标与当前目录相同时应拒绝")
+	}
+	if _, err := moveUserData(a.proc, u, a.store.ResolveDataDir(), to); err == nil {
+		t.Fatal("目标已存在数据时应拒绝")
+	}
+}
+
+func TestMigrateUserDataRollsBackWhenStartFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	bin := filepath.Join(t.TempDir(), "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	a, dir := newMigrateTestApp(t, bin)
+	u, _ := a.store.GetUser("u1")
+	old := seedUserData(t, a, u)
+
+	if _, err := a.MigrateUserData(context.Background(), u, filepath.Join(dir, "volume2"), true); err == nil {
+		t.Fatal("实例启动失败时迁移应报错")
+	}
+	u, _ = a.store.GetUser("u1")
+	if u.DataDir != "" {
+		t.Fatalf("配置应回滚，data_dir = %q", u.DataDir)
+	}
+	for _, p := range []string{old.CookiesPath, old.LogFile, filepath.Join(old.StateDir, "tasks.jsonl")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("数据应移回原处: %v", err)
+		}
+	}
+}
+
+func TestCopyTreePreservesContent(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeSized(t, filepath.Join(src, "a", "b.bin"), 1234)
+	writeSized(t, filepath.Join(src, "c.txt"), 5)
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := copyTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if dirSize(dst) != dirSize(src) {
+		t.Fatalf("size %d != %d", dirSize(dst), dirSize(src))
+	}
+}
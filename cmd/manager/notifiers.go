@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+// 通知渠道：把告警、崩溃、登录失效、发布结果等事件以文本消息推送到聊天工具或邮箱，
+// 与 webhook 订阅同一事件总线，配置保存在 store 中。
+
+// 通知渠道类型
+const (
+	notifyTelegram = "telegram"
+	notifySlack    = eventbus.ChatSlack
+	notifyDingTalk = eventbus.ChatDingTalk
+	notifyWeCom    = eventbus.ChatWeCom
+	notifyFeishu   = eventbus.ChatFeishu
+	notifyEmail    = "email"
+)
+
+var notifierTypes = []string{notifyTelegram, notifySlack, notifyDingTalk, notifyWeCom, notifyFeishu, notifyEmail}
+
+// notifyDefaultEvents 未指定 events 时推送的事件，不含正常的启动与停止
+var notifyDefaultEvents = []string{eventProcessCrashed, eventAutoStartFailed, eventPostPublished, eventPostFailed,
+	eventLoginChanged, eventLogSizeAlert, eventAlertFiring, eventAlertResolved}
+
+const maxNotifierNameLength = 64
+
+// NotifierConfig 通知渠道，保存在 store 中
+type NotifierConfig struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`                // telegram、slack、dingtalk、wecom、feishu、email
+	URL      string      `json:"url,omitempty"`       // 群机器人地址（slack、dingtalk、wecom、feishu）
+	Secret   string      `json:"secret,omitempty"`    // 钉钉机器人的加签密钥
+	BotToken string      `json:"bot_token,omitempty"` // telegram
+	ChatID   string      `json:"chat_id,omitempty"`   // telegram
+	SMTP     *SMTPConfig `json:"smtp,omitempty"`      // email
+	Events   []string    `json:"events,omitempty"`    // 只推送这些事件，为空表示告警类事件
+	Disabled bool        `json:"disabled,omitempty"`
+}
+
+// SMTPConfig 邮件通知的发信配置
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // 465 使用隐式 TLS，其他端口在服务器支持时使用 STARTTLS
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+func (n NotifierConfig) events() []string {
+	if len(n.Events) == 0 {
+		return notifyDefaultEvents
+	}
+	return n.Events
+}
+
+func validateNotifier(n NotifierConfig) error {
+	switch n.Type {
+	case notifySlack, notifyDingTalk, notifyWeCom, notifyFeishu:
+		u, err := url.Parse(n.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url 需为 http(s) 地址")
+		}
+	case notifyTelegram:
+		if n.BotToken == "" || n.ChatID == "" {
+			return fmt.Errorf("telegram 需要 bot_token 与 chat_id")
+		}
+	case notifyEmail:
+		s := n.SMTP
+		if s == nil || s.Host == "" || s.Port <= 0 || s.Port > 65535 {
+			return fmt.Errorf("email 需要 smtp.host 与 smtp.port")
+		}
+		if _, err := mail.ParseAddress(s.From); err != nil {
+			return fmt.Errorf("smtp.from 无效: %v", err)
+		}
+		if len(s.To) == 0 {
+			return fmt.Errorf("smtp.to 不能为空")
+		}
+		for _, to := range s.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("smtp.to %q 无效: %v", to, err)
+			}
+		}
+	default:
+		return fmt.Errorf("未知类型 %q（可选 %s）", n.Type, strings.Join(notifierTypes, "、"))
+	}
+	for _, ev := range n.Events {
+		if !slices.Contains(processEventTypes, ev) {
+			return fmt.Errorf("未知事件 %q（可选 %s）", ev, strings.Join(processEventTypes, "、"))
+		}
+	}
+	return nil
+}
+
+func validateNotifiers(list []NotifierConfig) error {
+	names := map[string]bool{}
+	for i, n := range list {
+		if strings.TrimSpace(n.Name) == "" || len(n.Name) > maxNotifierNameLength {
+			return fmt.Errorf("notifiers[%d]: name 不能为空且最多 %d 个字符", i, maxNotifierNameLength)
+		}
+		if names[n.Name] {
+			return fmt.Errorf("notifiers[%d]: name %q 重复", i, n.Name)
+		}
+		names[n.Name] = true
+		if err := validateNotifier(n); err != nil {
+			return fmt.Errorf("notifiers[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// SetNotifiers 替换通知渠道配置
+func (s *Store) SetNotifiers(list []NotifierConfig) error {
+	if err := validateNotifiers(list); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.cfg.Notifiers = list
+	return s.saveLocked()
+}
+
+// Notifiers 通知渠道配置
+func (s *Store) Notifiers() []NotifierConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]NotifierConfig{}, s.cfg.Notifiers...)
+}
+
+// newNotifierSink 按渠道类型创建事件订阅方
+func newNotifierSink(n NotifierConfig) eventbus.Sink {
+	switch n.Type {
+	case notifyTelegram:
+		return eventbus.NewTelegramSink(n.BotToken, n.ChatID)
+	case notifyEmail:
+		return &eventbus.EmailSink{
+			Addr:     net.JoinHostPort(n.SMTP.Host, strconv.Itoa(n.SMTP.Port)),
+			Username: n.SMTP.Username,
+			Password: n.SMTP.Password,
+			From:     n.SMTP.From,
+			To:       n.SMTP.To,
+		}
+	default:
+		sink := eventbus.NewChatSink(n.URL)
+		sink.Kind, sink.Secret = n.Type, n.Secret
+		return sink
+	}
+}
+
+func notifierSinkName(name string) string {
+	return "notify-" + name
+}
+
+type notifiersResponse struct {
+	Notifiers []NotifierConfig     `json:"notifiers"`
+	Types     []string             `json:"types"`  // 可选的渠道类型
+	Events    []string             `json:"events"` // 可订阅的事件类型
+	Defaults  []string             `json:"default_events"`
+	Sinks     []eventbus.SinkStats `json:"sinks"` // 各渠道的投递统计
+}
+
+// GetNotifiers 查询通知渠道配置与投递统计
+// GET /api/admin/v1/notifiers
+func (a *App) GetNotifiers(c *gin.Context) {
+	var sinks []eventbus.SinkStats
+	for _, st := range a.proc.events.Stats() {
+		if strings.HasPrefix(st.Name, notifierSinkName("")) {
+			sinks = append(sinks, st)
+		}
+	}
+	c.JSON(http.StatusOK, notifiersResponse{
+		Notifiers: a.store.Notifiers(),
+		Types:     notifierTypes,
+		Events:    processEventTypes,
+		Defaults:  notifyDefaultEvents,
+		Sinks:     sinks,
+	})
+}
+
+type updateNotifiersReq struct {
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// UpdateNotifiers 替换通知渠道配置，立即生效
+// PUT /api/admin/v1/notifiers
+func (a *App) UpdateNotifiers(c *gin.Context) {
+	var req updateNotifiersReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	for i := range req.Notifiers {
+		n := &req.Notifiers[i]
+		n.Name, n.URL = strings.TrimSpace(n.Name), strings.TrimSpace(n.URL)
+	}
+	if err := a.store.SetNotifiers(req.Notifiers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a.ApplyWebhooks()
+	a.GetNotifiers(c)
+}
+
+// TestNotifier 向通知渠道同步发送一条测试消息，停用的渠道也可测试
+// POST /api/admin/v1/notifiers/:name/test
+func (a *App) TestNotifier(c *gin.Context) {
+	name := c.Param("name")
+	list := a.store.Notifiers()
+	i := slices.IndexFunc(list, func(n NotifierConfig) bool { return n.Name == name })
+	if i < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知渠道不存在"})
+		return
+	}
+	n := list[i]
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+	ev := eventbus.Event{ID: "test", Type: "test", At: time.Now(), Summary: fmt.Sprintf("xiaohongshu-mcp 通知渠道 %s 测试消息", n.Name)}
+	if err := newNotifierSink(n).Deliver(ctx, ev); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("发送失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"name": n.Name, "sent": true})
+}
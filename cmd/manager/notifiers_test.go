@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateNotifiers(t *testing.T) {
+	for _, list := range [][]NotifierConfig{
+		{{Name: "", Type: notifySlack, URL: "https://hooks.slack.com/x"}},
+		{{Name: "a", Type: "sms"}},
+		{{Name: "a", Type: notifySlack, URL: "hooks.slack.com/x"}},
+		{{Name: "a", Type: notifyTelegram, BotToken: "t"}},
+		{{Name: "a", Type: notifyEmail, SMTP: &SMTPConfig{Host: "smtp.example.com", Port: 587, From: "a@example.com"}}},
+		{{Name: "a", Type: notifySlack, URL: "https://hooks.slack.com/x", Events: []string{"note_published"}}},
+		{{Name: "a", Type: notifySlack, URL: "https://hooks.slack.com/x"}, {Name: "a", Type: notifyTelegram, BotToken: "t", ChatID: "1"}},
+	} {
+		if err := validateNotifiers(list); err == nil {
+			t.Errorf("%+v 应校验失败", list)
+		}
+	}
+	ok := []NotifierConfig{
+		{Name: "ops", Type: notifyDingTalk, URL: "https://oapi.dingtalk.com/robot/send?access_token=x", Secret: "SEC"},
+		{Name: "tg", Type: notifyTelegram, BotToken: "t", ChatID: "-100"},
+		{Name: "mail", Type: notifyEmail, SMTP: &SMTPConfig{Host: "smtp.example.com", Port: 465, From: "MCP <mcp@example.com>", To: []string{"ops@example.com"}},
+			Events: []string{eventAlertFiring}},
+	}
+	if err := validateNotifiers(ok); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotifierReceivesEvents(t *testing.T) {
+	received := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- body.Text
+	}))
+	defer srv.Close()
+
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetNotifiers([]NotifierConfig{
+		{Name: "slack", Type: notifySlack, URL: srv.URL},
+		{Name: "off", Type: notifySlack, URL: srv.URL, Disabled: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	proc := NewProcessManager()
+	defer proc.CloseEvents(time.Second)
+	app := NewApp(store, proc, "")
+	app.ApplyWebhooks()
+
+	// 默认不推送正常启动，只推送告警类事件
+	proc.publish(eventProcessStarted, "用户 alice 实例已启动", ProcessEvent{User: "alice"})
+	proc.events.Publish(eventAlertFiring, "告警 down：用户 alice 账号未运行", Alert{Rule: "down", User: "alice"})
+	select {
+	case text := <-received:
+		if text != "[alert_firing] 告警 down：用户 alice 账号未运行" {
+			t.Fatalf("推送内容不符: %s", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到告警通知")
+	}
+
+	r := gin.New()
+	r.POST("/notifiers/:name/test", app.TestNotifier)
+	if w := doReq(r, http.MethodPost, "/notifiers/off/test", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("test: %d %s", w.Code, w.Body.String())
+	}
+	if text := <-received; !strings.Contains(text, "测试消息") {
+		t.Fatalf("测试消息不符: %s", text)
+	}
+	if w := doReq(r, http.MethodPost, "/notifiers/missing/test", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("missing: %d", w.Code)
+	}
+	select {
+	case text := <-received:
+		t.Fatalf("不应推送: %s", text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 孤儿实例的接管：manager 被强制结束（kill -9、崩溃）时，实例在独立进程组中继续运行，
+// 新的 manager 看不到它们，再次启动会因端口占用失败或与旧实例争用同一个浏览器目录。
+// 每个实例启动后在 <data_dir>/run/<id>.json 记录 PID、监听地址与访问令牌，正常退出时删除；
+// manager 启动时逐个检查：
+//   - 进程存活且用记录的令牌能通过健康检查，监听地址与当前配置一致：接管，之后照常停止、重启、查询状态；
+//   - 进程存活但无法接管（不健康、配置已变化、账号已删除）：确认命令行属于该账号后终止，避免重复启动；
+//   - 进程已不存在：删除记录。
+// 接管的实例退出码未知；其标准输出仍写入原日志文件，但接管期间不做日志轮转（文件句柄在实例进程中）。
+// Windows 下实例属于上一个 manager 的作业对象，manager 退出时已被系统终止，一般只会清理记录。
+// Kubernetes 模式不写记录，启动时删除带本 manager 标签的残留 Pod。
+
+// runRecord 运行中实例的记录
+type runRecord struct {
+	PID       int       `json:"pid"`
+	Port      int       `json:"port,omitempty"`
+	Socket    string    `json:"socket,omitempty"`
+	Token     string    `json:"token"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// adoptWaitInterval 接管的实例不是 manager 的子进程，只能轮询其是否退出
+var adoptWaitInterval = 2 * time.Second
+
+func writeRunRecord(path string, rec runRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// 包含访问令牌，仅 manager 用户可读
+	return fileutil.WriteFileAtomic(path, data, 0600)
+}
+
+func readRunRecord(path string) (runRecord, bool, error) {
+	var rec runRecord
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rec, false, nil
+	}
+	if err != nil {
+		return rec, false, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil || rec.PID <= 0 {
+		return rec, false, fmt.Errorf("运行记录无法解析: %s", path)
+	}
+	return rec, true, nil
+}
+
+// OrphanResult 启动时对一个残留实例的处理结果
+type OrphanResult struct {
+	User   string `json:"user"`
+	PID    int    `json:"pid"`
+	Action string `json:"action"` // adopted / killed / ignored
+	Reason string `json:"reason,omitempty"`
+}
+
+// 孤儿实例的处理方式
+const (
+	orphanAdopted = "adopted"
+	orphanKilled  = "killed"
+	orphanIgnored = "ignored" // 进程已不存在，或无法确认属于该账号
+)
+
+// AdoptOrphan 检查账号的运行记录并接管或终止残留实例。keep 为 false 时（账号已删除）不接管。
+// 没有记录时返回 nil
+func (pm *ProcessManager) AdoptOrphan(params StartUserParams, keep bool) *OrphanResult {
+	paths := pm.DerivePaths(params.DataDir, params.User.ID, params.User.Port)
+	rec, ok, err := readRunRecord(paths.RunFile)
+	if err != nil {
+		fmt.Printf("用户 %s: %v\n", params.User.ID, err)
+		_ = os.Remove(paths.RunFile)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	res := &OrphanResult{User: params.User.ID, PID: rec.PID}
+	if !processAlive(rec.PID) {
+		_ = os.Remove(paths.RunFile)
+		res.Action, res.Reason = orphanIgnored, "进程已退出"
+		return res
+	}
+
+	ep := InstanceEndpoint{Port: rec.Port, Socket: rec.Socket, Token: rec.Token}
+	want := pm.Endpoint(params.DataDir, params.User)
+	switch {
+	case !keep:
+		res.Reason = "账号已删除"
+	case ep.Port != want.Port || !samePath(ep.Socket, want.Socket):
+		res.Reason = "监听地址与当前配置不一致"
+	case !pm.CheckHealth(ep, 3*time.Second):
+		res.Reason = "健康检查失败"
+	default:
+		if err := pm.adopt(params, rec, ep, paths.RunFile); err != nil {
+			res.Reason = err.Error()
+			break
+		}
+		res.Action = orphanAdopted
+		return res
+	}
+
+	// 无法接管：确认 PID 仍是该账号的实例（未被其他进程复用）后终止
+	cmdline, err := processCommandLine(rec.PID)
+	if err != nil || !ownsCommandLine(cmdline, paths.UserDataDir) {
+		_ = os.Remove(paths.RunFile)
+		res.Action = orphanIgnored
+		res.Reason += "，且无法确认进程属于该账号，未终止"
+		return res
+	}
+	tree := adoptProcTree(rec.PID)
+	if err := tree.interrupt(); err != nil {
+		_ = tree.kill()
+	}
+	for deadline := time.Now().Add(10 * time.Second); processAlive(rec.PID); {
+		if time.Now().After(deadline) {
+			_ = tree.kill()
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	_ = os.Remove(paths.RunFile)
+	res.Action = orphanKilled
+	return res
+}
+
+// ownsCommandLine 命令行是否为使用该浏览器目录的实例（容器模式下传入的是绝对路径）
+func ownsCommandLine(cmdline, userDataDir string) bool {
+	if strings.Contains(cmdline, "-user-data-dir="+userDataDir+" ") || strings.HasSuffix(cmdline, "-user-data-dir="+userDataDir) {
+		return true
+	}
+	abs, err := filepath.Abs(userDataDir)
+	return err == nil && abs != userDataDir && ownsCommandLine(cmdline, abs)
+}
+
+// samePath 两个路径是否指向同一位置（相对路径与绝对路径），都为空时视为相同
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	x, err1 := filepath.Abs(a)
+	y, err2 := filepath.Abs(b)
+	return err1 == nil && err2 == nil && x == y
+}
+
+// adopt 把存活的实例登记为运行中，并在后台等待其退出
+func (pm *ProcessManager) adopt(params StartUserParams, rec runRecord, ep InstanceEndpoint, runFile string) error {
+	info := pm.fetchInfo(ep)
+	pm.mu.Lock()
+	if p, ok := pm.procs[params.User.ID]; ok && (p.state.active() || p.alive()) {
+		pm.mu.Unlock()
+		return fmt.Errorf("用户进程已在运行（%s）", p.state)
+	}
+	rp := &runningProc{
+		state:      StateRunning,
+		adoptedPID: rec.PID,
+		tree:       adoptProcTree(rec.PID),
+		startedAt:  rec.StartedAt,
+		readyAt:    time.Now(),
+		token:      rec.Token,
+		params:     params,
+		info:       info,
+		skew:       compat.Skew(pm.self, info),
+		done:       make(chan struct{}),
+	}
+	pm.procs[params.User.ID] = rp
+	pm.publishStateLocked(params.User.ID, rp, StateStopped)
+	pm.mu.Unlock()
+
+	go func(p *runningProc) {
+		for processAlive(p.adoptedPID) {
+			time.Sleep(adoptWaitInterval)
+		}
+		p.tree.release()
+		if d := pm.Docker(); d.Enabled() {
+			removeContainer(d, params.User.ID)
+		}
+		_ = os.Remove(runFile)
+		pm.markExited(p, errors.New("接管的实例已退出，退出码未知"))
+	}(rp)
+	pm.publish(eventProcessStarted, fmt.Sprintf("用户 %s 实例已由 manager 重新接管", params.User.ID),
+		ProcessEvent{User: params.User.ID, PID: rec.PID})
+	return nil
+}
+
+// adoptOrphans 启动时处理所有账号的残留实例，需在自动启动之前调用。
+// leasedElsewhere 非空时（集群模式）跳过归属其他节点的账号的 Pod
+func adoptOrphans(store *Store, proc *ProcessManager, leasedElsewhere func(userID string) bool) []OrphanResult {
+	cfg := store.GetConfig()
+	params := func(u UserConfig) StartUserParams {
+		return StartUserParams{User: u, BinPath: store.ResolveBinPath(), Headless: cfg.Headless, DataDir: store.UserDataDir(u)}
+	}
+	var results []OrphanResult
+	for _, u := range store.ListUsers() {
+		if res := proc.AdoptOrphan(params(u), true); res != nil {
+			results = append(results, *res)
+		}
+	}
+	// 已从 store 删除的账号：按默认数据目录中的记录终止
+	runDir := filepath.Join(store.ResolveDataDir(), "run")
+	entries, _ := os.ReadDir(runDir)
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !validIDRegex.MatchString(id) {
+			continue
+		}
+		if _, exists := store.GetUser(id); exists {
+			continue
+		}
+		if res := proc.AdoptOrphan(StartUserParams{User: UserConfig{ID: id}, DataDir: store.ResolveDataDir()}, false); res != nil {
+			results = append(results, *res)
+		}
+	}
+	if kube := proc.Kube(); kube != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if n, err := kube.removeManagedPods(ctx, leasedElsewhere); err != nil {
+			fmt.Printf("清理残留的实例 Pod 失败: %v\n", err)
+		} else if n > 0 {
+			fmt.Printf("已删除 %d 个残留的实例 Pod\n", n)
+		}
+		cancel()
+	}
+	for _, r := range results {
+		fmt.Printf("残留实例 %s (pid %d): %s %s\n", r.User, r.PID, r.Action, r.Reason)
+	}
+	return results
+}
+
+// removeManagedPods 删除本 manager 创建的实例 Pod 与 Secret。集群模式下只选择带本节点标签的 Pod，
+// skip 返回 true 的账号（租约由其他节点持有）保留
+func (c *kubeClient) removeManagedPods(ctx context.Context, skip func(userID string) bool) (int, error) {
+	selector := "app.kubernetes.io/managed-by=" + kubeManagedBy
+	if c.cfg.NodeID != "" {
+		selector += "," + kubeNodeLabel + "=" + kubeLabelValue(c.cfg.NodeID)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.path("pods", "")+"?labelSelector="+url.QueryEscape(selector), nil, &list); err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, item := range list.Items {
+		if userID := item.Metadata.Annotations["xiaohongshu-mcp/user-id"]; skip != nil && userID != "" && skip(userID) {
+			continue
+		}
+		if err := c.delete(ctx, "pods", item.Metadata.Name, 0); err != nil {
+			return removed, err
+		}
+		_ = c.delete(ctx, "secrets", item.Metadata.Name, -1)
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAdoptOrphan(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	dataDir := t.TempDir()
+	pm := NewProcessManager()
+	params := StartUserParams{User: UserConfig{ID: "u1", Port: port}, DataDir: dataDir}
+	runFile := pm.DerivePaths(dataDir, "u1", port).RunFile
+
+	// 进程已退出：只删除记录
+	dead := exec.Command(os.Args[0], "-test.run=^$")
+	if err := dead.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRunRecord(runFile, runRecord{PID: dead.Process.Pid, Port: port, Token: "tok"}); err != nil {
+		t.Fatal(err)
+	}
+	if res := pm.AdoptOrphan(params, true); res == nil || res.Action != orphanIgnored {
+		t.Fatalf("已退出的进程应忽略: %+v", res)
+	}
+	if _, err := os.Stat(runFile); !os.IsNotExist(err) {
+		t.Fatal("应删除失效的运行记录")
+	}
+
+	// 账号已删除且命令行不属于该账号（这里用测试进程自身）：不接管也不终止
+	if err := writeRunRecord(runFile, runRecord{PID: os.Getpid(), Port: port, Token: "tok"}); err != nil {
+		t.Fatal(err)
+	}
+	if res := pm.AdoptOrphan(params, false); res == nil || res.Action != orphanIgnored {
+		t.Fatalf("无法确认身份的进程不应被终止: %+v", res)
+	}
+
+	// 存活且健康检查通过：接管
+	started := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := writeRunRecord(runFile, runRecord{PID: os.Getpid(), Port: port, Token: "tok", StartedAt: started}); err != nil {
+		t.Fatal(err)
+	}
+	if res := pm.AdoptOrphan(params, true); res == nil || res.Action != orphanAdopted {
+		t.Fatalf("应接管健康的实例: %+v", res)
+	}
+	st := pm.GetStatus("u1")
+	if !st.Running || st.State != StateRunning || st.PID != os.Getpid() || st.StartedAt != started.Format(time.RFC3339) {
+		t.Fatalf("接管后的状态不符: %+v", st)
+	}
+	if ep := pm.Endpoint(dataDir, params.User); ep.Token != "tok" {
+		t.Fatalf("应沿用记录中的访问令牌: %+v", ep)
+	}
+}
+
+func TestOwnsCommandLine(t *testing.T) {
+	dir := filepath.Join("data", "profiles", "u1")
+	abs, _ := filepath.Abs(dir)
+	for _, tc := range []struct {
+		cmdline string
+		want    bool
+	}{
+		{"./xiaohongshu-mcp -headless=true -user-data-dir=" + dir + " -state-dir=x", true},
+		{"docker run xhs -user-data-dir=" + abs, true},
+		{"./xiaohongshu-mcp -user-data-dir=" + dir + "2 -state-dir=x", false},
+		{"/usr/bin/vim", false},
+	} {
+		if got := ownsCommandLine(tc.cmdline, dir); got != tc.want {
+			t.Errorf("ownsCommandLine(%q) = %v", tc.cmdline, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 创建账号时未指定端口则从端口范围中自动分配：跳过其他账号已使用的端口和本机已被占用的端口。
+
+const (
+	defaultPortRangeStart = 18060
+	defaultPortRangeEnd   = 18999
+)
+
+// PortRange 自动分配端口的范围（含两端）
+type PortRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// ParsePortRange 解析 "18060-18999" 形式的端口范围
+func ParsePortRange(s string) (PortRange, error) {
+	lo, hi, ok := strings.Cut(strings.TrimSpace(s), "-")
+	start, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	end, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if !ok || err1 != nil || err2 != nil {
+		return PortRange{}, fmt.Errorf("端口范围格式应为 起始-结束，如 18060-18999: %q", s)
+	}
+	if start < 1 || end > 65535 || start > end {
+		return PortRange{}, fmt.Errorf("端口范围非法: %d-%d", start, end)
+	}
+	return PortRange{Start: start, End: end}, nil
+}
+
+// portFree 本机端口是否可被监听（实例监听所有网卡，按 :port 检查）
+func portFree(port int) bool {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	_ = ln.Close()
+	return true
+}
+
+// checkPortAvailable 启动实例前检查端口，已被其他进程占用时返回明确的错误；
+// 其他监听失败（如权限不足）交给子进程自行报告
+func checkPortAvailable(port int) error {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err == nil {
+		_ = ln.Close()
+		return nil
+	}
+	if isAddrInUse(err) {
+		return fmt.Errorf("端口 %d 已被其他进程占用，请停止占用该端口的程序或修改账号端口", port)
+	}
+	return nil
+}
+
+func isAddrInUse(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	// Windows 返回 WSAEADDRINUSE (10048)
+	return errno == syscall.EADDRINUSE || (runtime.GOOS == "windows" && errno == 10048)
+}
+
+// allocatePortLocked 从端口范围中选择一个未被账号使用且本机空闲的端口。调用方需持有 s.mu 写锁。
+func (s *Store) allocatePortLocked() (int, error) {
+	used := make(map[int]bool, len(s.cfg.Users))
+	for _, u := range s.cfg.Users {
+		used[u.Port] = true
+	}
+	r := s.portRange
+	for port := r.Start; port <= r.End; port++ {
+		if !used[port] && portFree(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("端口范围 %s 内没有可用端口", r)
+}
+
+// SetPortRange 设置自动分配端口的范围
+func (s *Store) SetPortRange(r PortRange) {
+	s.mu.Lock()
+	s.portRange = r
+	s.mu.Unlock()
+}
+
+// PortRange 自动分配端口的范围
+func (s *Store) PortRange() PortRange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.portRange
+}
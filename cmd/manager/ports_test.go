@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePortRange(t *testing.T) {
+	if r, err := ParsePortRange(" 20000 - 20010 "); err != nil || r.Start != 20000 || r.End != 20010 {
+		t.Fatalf("got %+v %v", r, err)
+	}
+	for _, s := range []string{"", "20000", "20010-20000", "0-10", "1-70000", "a-b"} {
+		if _, err := ParsePortRange(s); err == nil {
+			t.Fatalf("%q 应报错", s)
+		}
+	}
+}
+
+func TestStoreAllocatesPort(t *testing.T) {
+	// 占用一个端口，并以它作为范围起点
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+	if busy+3 > 65535 {
+		t.Skip("端口过大")
+	}
+
+	s, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPortRange(PortRange{Start: busy, End: busy + 3})
+	if err := s.CreateUser(UserConfig{ID: "manual", Port: busy + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateUser(UserConfig{ID: "auto"}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := s.GetUser("auto")
+	if u.Port == busy || u.Port == busy+1 || u.Port < busy || u.Port > busy+3 {
+		t.Fatalf("应跳过已占用和已分配的端口，got %d（busy=%d）", u.Port, busy)
+	}
+	if err := s.CreateUser(UserConfig{ID: "sock", Socket: true}); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := s.GetUser("sock"); u.Port != 0 {
+		t.Fatalf("socket 账号不应分配端口，got %d", u.Port)
+	}
+
+	if err := checkPortAvailable(busy); err == nil || !strings.Contains(err.Error(), "占用") {
+		t.Fatalf("已占用端口应报错，got %v", err)
+	}
+	s.SetPortRange(PortRange{Start: busy, End: busy})
+	if err := s.CreateUser(UserConfig{ID: "full"}); err == nil {
+		t.Fatal("范围内无可用端口时应报错")
+	}
+}
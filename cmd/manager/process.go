@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
 )
 
 const (
 	envCookiesPath     = "COOKIES_PATH"
 	envXHSProxy        = "XHS_PROXY"
 	envXHSProxyPoolURL = "XHS_PROXY_POOL_URL"
+	envXHSAPIToken     = "XHS_API_TOKEN"
 )
 
 // DerivedPaths 派生路径
@@ -25,18 +33,84 @@ type DerivedPaths struct {
 	CookiesPath string
 	UserDataDir string
 	LogFile     string
+	ErrLogFile  string // 实例的标准错误输出（panic、浏览器启动错误等）；Kubernetes 模式下与 LogFile 合并
+	SocketPath  string // 启用 Unix socket 时的监听地址
+	StateDir    string // 任务日志、发布历史等运行状态
+	TempDir     string // 实例的临时目录（下载的图片/视频、浏览器临时文件）
+	RunFile     string // 运行中实例的 PID 等信息，manager 重启后据此接管实例
+	SnapshotDir string // 浏览器数据快照
 	HealthURL   string
 }
 
+// ProcState 进程生命周期状态
+//
+//	stopped → starting → running → stopping → exited
+//	              ↘ failed（启动失败/健康检查超时）   ↗
+type ProcState string
+
+const (
+	StateStopped  ProcState = "stopped"  // 从未启动
+	StateStarting ProcState = "starting" // 已占位，正在拉起子进程并等待健康检查（进程存活但尚未就绪）
+	StateRunning  ProcState = "running"  // 健康检查通过，MCP 服务已可接受请求
+	StateStopping ProcState = "stopping" // 已发送停止信号，等待退出
+	StateExited   ProcState = "exited"   // 子进程已退出，见 ExitCode
+	StateFailed   ProcState = "failed"   // 启动失败，见 LastError
+)
+
+// active 是否占用该用户（不允许重复启动）
+func (s ProcState) active() bool {
+	return s == StateStarting || s == StateRunning || s == StateStopping
+}
+
 // ProcessStatus 进程状态
 type ProcessStatus struct {
-	Running        bool
+	State          ProcState
+	Running        bool // 子进程是否存活
 	PID            int
 	StartedAt      string
+	Ready          bool   // 健康检查已通过（running），starting 阶段为 false
+	ReadyAt        string // 首次通过健康检查的时间
+	StartupMs      int64  // 从拉起到就绪的耗时
+	UptimeSec      int64  // 运行中实例自拉起以来的秒数
+	ExitedAt       string
+	ExitCode       *int
+	ExitSignal     string // 被信号终止时的信号名
 	LastError      string
 	EffectiveProxy string
+	Version        string // 实例 /health 报告的构建版本
+	Commit         string
+	ToolSchema     int       // 实例的工具结构版本，旧版本为 0
+	VersionSkew    string    // 与 manager 版本不一致的说明，一致时为空
+	Restarts       int       // 按重启策略自动重启的次数
+	NextRestartAt  string    // 等待中的自动重启时间
+	RestartGaveUp  bool      // 连续重启达到上限后已放弃
+	TotalRestarts  int       // manager 启动以来该账号再次启动的次数（含手动与自动重启）
+	LastExit       *ProcExit // 最近一次退出，重新启动后仍保留
+}
+
+// ProcExit 一次实例退出
+type ProcExit struct {
+	At       string `json:"at"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Expected bool   `json:"expected"` // 主动停止导致的退出
+}
+
+// ProcFailure 一次异常退出或启动失败
+type ProcFailure struct {
+	At       time.Time
+	ExitCode *int
+	Error    string
 }
 
+// 每个用户保留的失败记录数；窗口内失败次数达到阈值视为崩溃循环
+const (
+	maxFailureHistory  = 10
+	crashLoopWindow    = 10 * time.Minute
+	crashLoopThreshold = 3
+)
+
 // StartUserParams 启动参数
 type StartUserParams struct {
 	User     UserConfig
@@ -45,26 +119,152 @@ type StartUserParams struct {
 	DataDir  string
 }
 
+// runningProc 单个用户进程的运行记录，所有字段受 ProcessManager.mu 保护；
+// 退出后保留在 map 中，以便查询退出码与错误，直到下次启动时被替换
 type runningProc struct {
+	state          ProcState
 	cmd            *exec.Cmd
+	tree           *procTree // 实例及其浏览器子进程，停止时整体终止
+	pod            *kubePod  // Kubernetes 模式下的实例 Pod，此时 cmd 与 tree 为空
+	host           string    // 实例所在地址（Pod IP），本机实例为空
+	adoptedPID     int       // 接管的上一个 manager 启动的实例进程，此时 cmd 为空
 	logFile        *os.File
+	logSince       time.Time // 当前日志开始写入的时间，用于按时长轮转
+	errLogFile     *os.File
+	errLogSince    time.Time
 	startedAt      time.Time
+	readyAt        time.Time // 健康检查通过的时间，starting 阶段为零值
+	exitedAt       time.Time
+	exitCode       *int
+	exitSignal     string
 	lastError      string
 	effectiveProxy string
-	done           chan error
+	info           compat.Info // 实例 /health 报告的版本与能力
+	skew           string
+	token          string          // 本次启动生成的接口访问令牌
+	params         StartUserParams // 启动参数，自动重启时复用
+	cpuSample      cpuSample       // 上次资源采样，用于计算 CPU 占用
+	done           chan struct{}   // 子进程退出（Wait 返回）后关闭
+}
+
+// alive 子进程已拉起且尚未退出（failed 状态下可能仍在被终止）
+func (p *runningProc) alive() bool {
+	return (p.pod != nil || p.adoptedPID > 0 || p.cmd != nil && p.cmd.Process != nil) && p.exitedAt.IsZero()
+}
+
+// pid 实例进程的 PID，Kubernetes 模式下为 0
+func (p *runningProc) pid() int {
+	if p.cmd != nil && p.cmd.Process != nil {
+		return p.cmd.Process.Pid
+	}
+	return p.adoptedPID
+}
+
+// procHandle 停止实例的方式：本机进程树或 Kubernetes Pod
+type procHandle interface {
+	interrupt() error
+	terminate() error
+	kill() error
+}
+
+func (p *runningProc) handle() procHandle {
+	if p.pod != nil {
+		return p.pod
+	}
+	return p.tree
+}
+
+func (p *runningProc) status() ProcessStatus {
+	st := ProcessStatus{
+		State:          p.state,
+		Running:        p.alive(),
+		StartedAt:      p.startedAt.Format(time.RFC3339),
+		ExitCode:       p.exitCode,
+		LastError:      p.lastError,
+		EffectiveProxy: p.effectiveProxy,
+		Version:        p.info.Version,
+		Commit:         p.info.Commit,
+		ToolSchema:     p.info.ToolSchema,
+		VersionSkew:    p.skew,
+	}
+	st.PID = p.pid()
+	if !p.readyAt.IsZero() {
+		st.Ready = p.state == StateRunning && p.alive()
+		st.ReadyAt = p.readyAt.Format(time.RFC3339)
+		st.StartupMs = p.readyAt.Sub(p.startedAt).Milliseconds()
+	}
+	if p.alive() && !p.startedAt.IsZero() {
+		st.UptimeSec = int64(time.Since(p.startedAt).Seconds())
+	}
+	if !p.exitedAt.IsZero() {
+		st.ExitedAt = p.exitedAt.Format(time.RFC3339)
+		st.ExitSignal = p.exitSignal
+	}
+	return st
 }
 
 // ProcessManager 进程管理器
 type ProcessManager struct {
-	mu    sync.RWMutex
-	procs map[string]*runningProc
+	mu           sync.RWMutex
+	procs        map[string]*runningProc
+	failures     map[string][]ProcFailure // 最近的失败记录，跨多次启动保留
+	devTools     bool                     // 子进程是否开放浏览器 DevTools 代理
+	caps         map[string]cachedCaps    // 实例二进制的能力探测结果
+	lastExits    map[string]ProcExit      // 每个账号最近一次退出，跨多次启动保留
+	self         compat.Info              // manager 自身的版本，用于比对实例版本偏差
+	rotation     LogRotationConfig        // 子进程日志轮转配置
+	metrics      procMetrics              // 启停计数，供 /metrics 输出
+	docker       DockerConfig             // 以容器运行实例，Image 为空时直接拉起子进程
+	kube         *kubeClient              // 以 Pod 运行实例，为 nil 时不使用 Kubernetes
+	stopTimeout  time.Duration            // 未单独配置的账号停止时的优雅退出等待时间
+	readyTimeout time.Duration            // 启动后等待健康检查通过的时间
+
+	supervise map[string]*superviseState // 自动重启状态
+	closing   bool                       // manager 正在退出，不再自动重启
+	logAlerts map[string]bool            // 已发布 log_size_alert、日志仍超过阈值的账号
+
+	claim func(userID string) error // 集群模式下启动前获取账号归属，为 nil 时不检查
+
+	cookiesKey string // 传给实例的 cookies 加密口令，为空时不加密
+
+	events *eventbus.Bus // 生命周期事件，由 webhook 订阅
 }
 
 // NewProcessManager 创建进程管理器
 func NewProcessManager() *ProcessManager {
 	return &ProcessManager{
-		procs: map[string]*runningProc{},
+		procs:     map[string]*runningProc{},
+		failures:  map[string][]ProcFailure{},
+		caps:      map[string]cachedCaps{},
+		lastExits: map[string]ProcExit{},
+		self:      compat.Current(),
+
+		supervise: map[string]*superviseState{},
+		rotation:  LogRotationConfig{}.Effective(),
+		events:    newProcessEventBus(),
+	}
+}
+
+func newProcessEventBus() *eventbus.Bus {
+	b := eventbus.New()
+	b.OnError = func(sink string, ev eventbus.Event, err error) {
+		fmt.Printf("事件 %s（%s）推送到 %s 失败: %v\n", ev.ID, ev.Type, sink, err)
 	}
+	return b
+}
+
+// EnableDevTools 之后启动的实例都带上 -enable-devtools，已运行的实例需重启生效
+func (pm *ProcessManager) EnableDevTools() {
+	pm.mu.Lock()
+	pm.devTools = true
+	pm.mu.Unlock()
+}
+
+// DevToolsEnabled 是否开放浏览器 DevTools 代理
+func (pm *ProcessManager) DevToolsEnabled() bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.devTools
 }
 
 // DerivePaths 派生路径
@@ -73,6 +273,12 @@ func (pm *ProcessManager) DerivePaths(dataDir, userID string, port int) DerivedP
 		CookiesPath: filepath.Join(dataDir, "cookies", userID+".json"),
 		UserDataDir: filepath.Join(dataDir, "profiles", userID),
 		LogFile:     filepath.Join(dataDir, "logs", userID+".log"),
+		ErrLogFile:  filepath.Join(dataDir, "logs", userID+".err.log"),
+		SocketPath:  filepath.Join(dataDir, "sockets", userID+".sock"),
+		StateDir:    filepath.Join(dataDir, "state", userID),
+		TempDir:     filepath.Join(dataDir, "tmp", userID),
+		RunFile:     filepath.Join(dataDir, "run", userID+".json"),
+		SnapshotDir: filepath.Join(dataDir, "snapshots", userID),
 		HealthURL:   fmt.Sprintf("http://127.0.0.1:%d/health", port),
 	}
 }
@@ -81,26 +287,187 @@ func (pm *ProcessManager) DerivePaths(dataDir, userID string, port int) DerivedP
 func (pm *ProcessManager) GetStatus(userID string) ProcessStatus {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
-	p, ok := pm.procs[userID]
-	if !ok || p == nil || p.cmd == nil || p.cmd.Process == nil {
-		return ProcessStatus{Running: false}
+	var st ProcessStatus
+	if p, ok := pm.procs[userID]; ok && p != nil {
+		st = p.status()
+	} else {
+		st.State = StateStopped
 	}
-	return ProcessStatus{
-		Running:        true,
-		PID:            p.cmd.Process.Pid,
-		StartedAt:      p.startedAt.Format(time.RFC3339),
-		LastError:      p.lastError,
-		EffectiveProxy: p.effectiveProxy,
+	st.TotalRestarts = pm.metrics.get(userID).restarts
+	if e, ok := pm.lastExits[userID]; ok {
+		st.LastExit = &e
+	}
+	if sv := pm.supervise[userID]; sv != nil {
+		st.Restarts = sv.restarts
+		st.RestartGaveUp = sv.gaveUp
+		if !sv.nextAt.IsZero() {
+			st.NextRestartAt = sv.nextAt.Format(time.RFC3339)
+		}
 	}
+	return st
 }
 
-// StartUser 启动用户进程
+// Forget 清理已结束进程的记录（用户被删除时调用），活动进程不受影响
+func (pm *ProcessManager) Forget(userID string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if p, ok := pm.procs[userID]; ok && (p.state.active() || p.alive()) {
+		return
+	}
+	delete(pm.procs, userID)
+	delete(pm.failures, userID)
+	delete(pm.lastExits, userID)
+	pm.cancelRestartLocked(userID, true)
+	delete(pm.supervise, userID)
+}
+
+// reserve 为用户占位（starting），已有活动进程时返回错误；
+// 上一次运行的 exited/failed 记录会被替换
+func (pm *ProcessManager) reserve(userID string) (*runningProc, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	prev, restarted := pm.procs[userID]
+	if restarted && (prev.state.active() || prev.alive()) {
+		return nil, fmt.Errorf("用户进程已在运行（%s）", prev.state)
+	}
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	rp := &runningProc{
+		state:     StateStarting,
+		startedAt: time.Now(),
+		token:     token,
+		done:      make(chan struct{}),
+	}
+	pm.procs[userID] = rp
+	from := StateStopped
+	if restarted {
+		from = prev.state
+		pm.metrics.inc(userID, func(c *procCounters) { c.restarts++ })
+	}
+	pm.publishStateLocked(userID, rp, from)
+	return rp, nil
+}
+
+// fail 启动失败：标记 failed 并记录原因，子进程已退出时保留其退出错误
+func (pm *ProcessManager) fail(p *runningProc, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if p.lastError != "" {
+		p.lastError = err.Error() + ": " + p.lastError
+	} else {
+		p.lastError = err.Error()
+	}
+	pm.setStateLocked(pm.userIDLocked(p), p, StateFailed)
+	pm.recordFailureLocked(p, p.exitCode)
+	pm.metrics.inc(pm.userIDLocked(p), func(c *procCounters) { c.startFailures++ })
+}
+
+// recordFailureLocked 记录失败，调用方需持有 pm.mu
+func (pm *ProcessManager) recordFailureLocked(p *runningProc, exitCode *int) {
+	userID := pm.userIDLocked(p)
+	if userID == "" {
+		return
+	}
+	list := append(pm.failures[userID], ProcFailure{At: time.Now(), ExitCode: exitCode, Error: p.lastError})
+	if len(list) > maxFailureHistory {
+		list = list[len(list)-maxFailureHistory:]
+	}
+	pm.failures[userID] = list
+}
+
+// userIDLocked 进程记录对应的账号 ID，调用方需持有 pm.mu
+func (pm *ProcessManager) userIDLocked(p *runningProc) string {
+	for id, rp := range pm.procs {
+		if rp == p {
+			return id
+		}
+	}
+	return ""
+}
+
+// RecentFailures 返回用户最近的失败记录（按时间升序）
+func (pm *ProcessManager) RecentFailures(userID string) []ProcFailure {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return append([]ProcFailure(nil), pm.failures[userID]...)
+}
+
+// CrashLooping 最近 crashLoopWindow 内失败次数达到阈值
+func (pm *ProcessManager) CrashLooping(userID string) bool {
+	since := time.Now().Add(-crashLoopWindow)
+	n := 0
+	for _, f := range pm.RecentFailures(userID) {
+		if f.At.After(since) {
+			n++
+		}
+	}
+	return n >= crashLoopThreshold
+}
+
+// markExited 子进程 Wait 返回后调用，在同一把锁内完成状态切换，
+// 保证 GetStatus 不会在进程退出后仍返回 running
+func (pm *ProcessManager) markExited(p *runningProc, waitErr error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	p.exitedAt = time.Now()
+	expected := p.state == StateStopping
+	if p.cmd != nil && p.cmd.ProcessState != nil {
+		code := p.cmd.ProcessState.ExitCode()
+		p.exitCode = &code
+		p.exitSignal = exitSignal(p.cmd.ProcessState)
+	} else if p.pod != nil {
+		p.exitCode = p.pod.code()
+	}
+	switch p.state {
+	case StateFailed:
+		// 保留启动失败原因
+	case StateStopping:
+		// 主动停止时被信号终止属于预期，不记为错误
+		pm.setStateLocked(pm.userIDLocked(p), p, StateExited)
+		pm.publishExitLocked(pm.userIDLocked(p), p, false)
+	default:
+		if waitErr != nil {
+			p.lastError = waitErr.Error()
+		}
+		// 运行中意外退出计入失败记录；启动期间退出由 fail 记录
+		if p.state == StateRunning {
+			if p.lastError == "" {
+				p.lastError = "进程意外退出"
+			}
+			pm.recordFailureLocked(p, p.exitCode)
+			pm.metrics.inc(pm.userIDLocked(p), func(c *procCounters) { c.crashes++ })
+			pm.publishExitLocked(pm.userIDLocked(p), p, true)
+			pm.onUnexpectedExitLocked(pm.userIDLocked(p), p, waitErr != nil || (p.exitCode != nil && *p.exitCode != 0))
+		}
+		pm.setStateLocked(pm.userIDLocked(p), p, StateExited)
+	}
+	if id := pm.userIDLocked(p); id != "" {
+		pm.lastExits[id] = ProcExit{
+			At:       p.exitedAt.Format(time.RFC3339),
+			ExitCode: p.exitCode,
+			Signal:   p.exitSignal,
+			Error:    p.lastError,
+			Expected: expected,
+		}
+	}
+	close(p.done)
+}
+
+// StartUser 手动启动用户进程，取消等待中的自动重启并清零连续重启次数
+func (pm *ProcessManager) StartUser(ctx context.Context, params StartUserParams) error {
+	pm.cancelRestart(params.User.ID, true)
+	return pm.startUser(ctx, params)
+}
+
+// startUser 启动用户进程
 // 使用占位机制防止并发竞态：先占位再启动，失败时清理
-func (pm *ProcessManager) StartUser(ctx context.Context, params StartUserParams) (err error) {
+func (pm *ProcessManager) startUser(ctx context.Context, params StartUserParams) (err error) {
 	if params.User.ID == "" {
 		return fmt.Errorf("id 不能为空")
 	}
-	if params.User.Port <= 0 || params.User.Port > 65535 {
+	if !params.User.Socket && (params.User.Port <= 0 || params.User.Port > 65535) {
 		return fmt.Errorf("port 非法: %d", params.User.Port)
 	}
 	if params.BinPath == "" {
@@ -111,45 +478,89 @@ func (pm *ProcessManager) StartUser(ctx context.Context, params StartUserParams)
 	}
 
 	// 先占位，防止并发启动同一用户
-	pm.mu.Lock()
-	if _, ok := pm.procs[params.User.ID]; ok {
-		pm.mu.Unlock()
-		return fmt.Errorf("用户进程已在运行")
+	rp, err := pm.reserve(params.User.ID)
+	if err != nil {
+		return err
 	}
-	rp := &runningProc{
-		startedAt: time.Now(),
-		done:      make(chan error, 1),
+	pm.mu.Lock()
+	rp.params = params
+	claim := pm.claim
+	if params.User.APIKey != "" {
+		rp.token = params.User.APIKey
 	}
-	pm.procs[params.User.ID] = rp
 	pm.mu.Unlock()
 
 	// 标记是否成功启动进程
 	started := false
 	defer func() {
 		if err != nil && !started {
-			// 启动失败，清理占位
-			pm.mu.Lock()
-			if pm.procs[params.User.ID] == rp {
-				delete(pm.procs, params.User.ID)
-			}
-			pm.mu.Unlock()
+			// 未拉起子进程，直接记为失败
+			pm.fail(rp, err)
+			close(rp.done)
 		}
 	}()
 
-	paths := pm.DerivePaths(params.DataDir, params.User.ID, params.User.Port)
+	// 占位之后再获取归属，集群的续期循环据此判断是否仍需持有租约
+	if claim != nil {
+		if err = claim(params.User.ID); err != nil {
+			return err
+		}
+	}
+
+	docker, kube := pm.Docker(), pm.Kube()
+	dataDir := params.DataDir
+	if docker.Enabled() || kube != nil {
+		// 容器内按相同的绝对路径挂载数据目录，传给实例的路径不能是相对 manager 工作目录的
+		if dataDir, err = filepath.Abs(dataDir); err != nil {
+			return fmt.Errorf("解析 data_dir 失败: %w", err)
+		}
+	}
+	paths := pm.DerivePaths(dataDir, params.User.ID, params.User.Port)
 	if err = ensureDirs(paths); err != nil {
 		return err
 	}
+	if err = checkDiskQuota(paths, params.User.DiskQuotaMB); err != nil {
+		return err
+	}
+	ep := pm.Endpoint(dataDir, params.User)
+	if ep.IsUnix() && kube != nil {
+		return fmt.Errorf("Kubernetes 模式不支持 Unix socket，请关闭该账号的 socket")
+	}
+	if ep.IsUnix() {
+		if err = validateSocketPath(ep.Socket); err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Dir(ep.Socket), 0700); err != nil {
+			return fmt.Errorf("创建 sockets 目录失败: %w", err)
+		}
+	}
 
+	// Kubernetes 模式无法在启动前探测镜像，实例就绪后按 /health 报告的能力检查
+	var caps compat.Info
+	if kube == nil {
+		caps = pm.instanceCapabilities(params.BinPath)
+		if err = checkCapabilities(caps, params.User, ep); err != nil {
+			return err
+		}
+	}
+	if !ep.IsUnix() && kube == nil {
+		if err = checkPortAvailable(ep.Port); err != nil {
+			return err
+		}
+	}
+
+	pm.rotateBeforeStart(paths.LogFile)
 	logFile, err := os.OpenFile(paths.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return fmt.Errorf("打开日志文件失败: %w", err)
 	}
 
 	args := []string{
-		"-headless=" + strconv.FormatBool(params.Headless),
-		"-port=:" + strconv.Itoa(params.User.Port),
+		// 容器内没有显示环境，总是无头运行
+		"-headless=" + strconv.FormatBool(params.Headless || docker.Enabled() || kube != nil),
+		"-port=" + ep.ListenArg(),
 		"-user-data-dir=" + paths.UserDataDir,
+		"-state-dir=" + paths.StateDir,
 	}
 	if proxy := strings.TrimSpace(params.User.Proxy); proxy != "" {
 		args = append(args, "-proxy="+proxy)
@@ -157,90 +568,357 @@ func (pm *ProcessManager) StartUser(ctx context.Context, params StartUserParams)
 	if ua := strings.TrimSpace(params.User.UserAgent); ua != "" {
 		args = append(args, "-user-agent="+ua)
 	}
+	if pm.DevToolsEnabled() {
+		if caps.Known() && !caps.Supports(compat.FeatureDevTools) {
+			fmt.Printf("用户 %s 的实例版本 %s 不支持 DevTools 代理，已跳过\n", params.User.ID, caps.Version)
+		} else {
+			args = append(args, "-enable-devtools")
+		}
+	}
+	if m := params.User.Moderation; m != "" {
+		args = append(args, "-moderation="+m)
+	}
+	if params.User.ReadOnly {
+		args = append(args, "-read-only")
+	}
+	if tz := params.User.Timezone; tz != "" {
+		args = append(args, "-timezone="+tz)
+	}
+
+	env := append(userEnv(params.User.Env),
+		envCookiesPath+"="+paths.CookiesPath,
+		envXHSAPIToken+"="+rp.token,
+	)
+	pm.mu.RLock()
+	cookiesKey := pm.cookiesKey
+	pm.mu.RUnlock()
+	if cookiesKey != "" {
+		env = append(env, cookies.EnvKey+"="+cookiesKey)
+	}
+	// 每个用户使用独立临时目录，下载的媒体文件计入该用户的磁盘占用并可清理
+	env = append(env, tempDirEnv(paths.TempDir)...)
 
-	cmd := exec.Command(params.BinPath, args...)
-	cmd.Env = append(buildChildEnvForUser(params.User.Proxy, params.User.ProxyPool), envCookiesPath+"="+paths.CookiesPath)
+	var cmd *exec.Cmd
+	switch {
+	case kube != nil:
+		env = append(proxyEnv(params.User.Proxy, params.User.ProxyPool), env...)
+		if started, err = pm.startPod(ctx, kube, rp, dataDir, ep.Port, logFile, env, args); !started {
+			_ = logFile.Close()
+			return err
+		}
+		if err != nil {
+			pm.fail(rp, err)
+			_, _ = pm.stopUser(context.Background(), params.User.ID, 0)
+			return err
+		}
+		ep.Host = rp.host
+	case docker.Enabled():
+		var mounts []string
+		if mounts, err = containerMounts(paths, ep); err != nil {
+			_ = logFile.Close()
+			return err
+		}
+		// 容器只拿到 manager 设置的变量；以非 root 用户运行时 HOME 不可写，指向临时目录
+		env = append(append(proxyEnv(params.User.Proxy, params.User.ProxyPool), "HOME="+paths.TempDir), env...)
+		removeContainer(docker, params.User.ID)
+		cmd = dockerCommand(docker, params.User.ID, ep, mounts, env, args)
+	default:
+		cmd = exec.Command(params.BinPath, args...)
+		cmd.Env = append(buildChildEnvForUser(params.User.Proxy, params.User.ProxyPool), env...)
+	}
+	if cmd != nil {
+		pm.rotateBeforeStart(paths.ErrLogFile)
+		var errLog *os.File
+		if errLog, err = os.OpenFile(paths.ErrLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			_ = logFile.Close()
+			return fmt.Errorf("打开日志文件失败: %w", err)
+		}
+		if err = pm.startCmd(rp, cmd, logFile, errLog, docker, ep, paths.RunFile); err != nil {
+			return err
+		}
+		started = true
+	}
+
+	// 启动后轮询健康检查直到实例可接受请求；期间子进程退出则立即失败，不必等到超时
+	if err = pm.waitHealthy(ctx, ep, rp.done, pm.ReadyTimeout(), 500*time.Millisecond); err != nil {
+		pm.fail(rp, err)
+		_, _ = pm.stopUser(context.Background(), params.User.ID, 0)
+		return err
+	}
+
+	info := pm.fetchInfo(ep)
+	if !info.Known() && caps.Known() {
+		// /health 读取失败时使用启动前探测的结果，二者来自同一个二进制
+		info = caps
+	}
+	if kube != nil {
+		if err = checkCapabilities(info, params.User, ep); err != nil {
+			pm.fail(rp, err)
+			_, _ = pm.stopUser(context.Background(), params.User.ID, 0)
+			return err
+		}
+	}
+	skew := compat.Skew(pm.self, info)
+	if skew != "" {
+		fmt.Printf("用户 %s 版本偏差: %s\n", params.User.ID, skew)
+	}
+	pm.mu.Lock()
+	if rp.state == StateStarting {
+		pm.setStateLocked(params.User.ID, rp, StateRunning)
+	}
+	rp.readyAt = time.Now()
+	rp.info = info
+	rp.skew = skew
+	pm.mu.Unlock()
+	pm.publish(eventProcessStarted, fmt.Sprintf("用户 %s 实例已启动", params.User.ID),
+		ProcessEvent{User: params.User.ID, PID: rp.pid()})
+	return nil
+}
+
+// startCmd 拉起本机子进程（或 docker CLI），退出后由后台 goroutine 记录。
+// 标准输出与标准错误直接交给子进程写入（不经过管道），manager 退出后实例仍能继续写日志
+func (pm *ProcessManager) startCmd(rp *runningProc, cmd *exec.Cmd, logFile, errLog *os.File, docker DockerConfig, ep InstanceEndpoint, runFile string) error {
+	userID := rp.params.User.ID
 	cmd.Stdout = logFile
-	cmd.Stderr = logFile
+	cmd.Stderr = errLog
+	prepareProcTree(cmd)
 
-	if err = cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		_ = logFile.Close()
+		_ = errLog.Close()
 		return fmt.Errorf("启动子进程失败: %w", err)
 	}
+	tree, treeErr := attachProcTree(cmd)
+	if treeErr != nil {
+		// 仍可停止实例进程本身，只是浏览器子进程可能残留
+		fmt.Printf("用户 %s 进程树管理不可用: %v\n", userID, treeErr)
+	}
 
 	// 进程已启动，更新占位信息（需要加锁，避免与 GetStatus 读操作竞争）
 	pm.mu.Lock()
 	rp.cmd = cmd
+	rp.tree = tree
 	rp.logFile = logFile
+	rp.errLogFile = errLog
+	rp.logSince = time.Now()
+	rp.errLogSince = rp.logSince
 	pm.mu.Unlock()
-	started = true
+	pm.metrics.inc(userID, func(c *procCounters) { c.starts++ })
+	if err := writeRunRecord(runFile, runRecord{PID: cmd.Process.Pid, Port: ep.Port, Socket: ep.Socket, Token: rp.token, StartedAt: rp.startedAt}); err != nil {
+		fmt.Printf("用户 %s 记录运行信息失败，manager 重启后无法接管该实例: %v\n", userID, err)
+	}
 
-	go func(userID string, p *runningProc) {
+	go func(p *runningProc) {
 		waitErr := cmd.Wait()
 		_ = logFile.Close()
-		pm.mu.Lock()
-		if waitErr != nil {
-			p.lastError = waitErr.Error()
-		}
-		// 仅在仍指向同一对象时删除，避免极端情况下误删
-		if pm.procs[userID] == p {
-			delete(pm.procs, userID)
+		_ = errLog.Close()
+		tree.release()
+		if docker.Enabled() {
+			removeContainer(docker, userID)
 		}
-		pm.mu.Unlock()
-		p.done <- waitErr
-	}(params.User.ID, rp)
+		_ = os.Remove(runFile)
+		pm.markExited(p, waitErr)
+	}(rp)
+	return nil
+}
 
-	// 启动后健康检查
-	if err = pm.waitHealthy(ctx, params.User.Port, 30*time.Second, 500*time.Millisecond); err != nil {
-		_ = pm.StopUser(context.Background(), params.User.ID, 10*time.Second)
-		return err
+// generateToken 生成随机访问令牌
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成访问令牌失败: %w", err)
 	}
-	return nil
+	return hex.EncodeToString(buf), nil
 }
 
-// StopUser 停止用户进程
-func (pm *ProcessManager) StopUser(ctx context.Context, userID string, timeout time.Duration) error {
+// 停止实例的升级顺序：先通知实例优雅退出（SIGINT，由实例关闭浏览器并保存资料），等待账号的 stop_timeout_sec
+// （未设置时为 -stop-timeout）；仍未退出则发送 SIGTERM 并等待 terminateGrace；最后强制终止整棵进程树（SIGKILL）。
+// Windows 没有 SIGTERM，超时后直接终止作业；Kubernetes 模式下三步分别为删除 Pod（默认宽限期）、短宽限期删除与立即删除。
+
+// 停止经过的阶段
+const (
+	stopStageNone       = "none"       // 实例未运行
+	stopStageGraceful   = "graceful"   // 收到中断信号后自行退出
+	stopStageTerminated = "terminated" // 超时后收到 SIGTERM 退出
+	stopStageKilled     = "killed"     // 强制终止
+)
+
+var (
+	terminateGrace = 5 * time.Second // SIGTERM 后等待退出的时间
+	killWait       = 5 * time.Second // 强制终止后等待进程回收的时间
+)
+
+// StopResult 一次停止的结果
+type StopResult struct {
+	Stage      string `json:"stage"`
+	TimeoutMs  int64  `json:"timeout_ms,omitempty"` // 优雅退出的等待时间
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Escalated 是否未能优雅退出
+func (r StopResult) Escalated() bool {
+	return r.Stage == stopStageTerminated || r.Stage == stopStageKilled
+}
+
+// SetStopTimeout 设置未单独配置 stop_timeout_sec 的账号的优雅退出等待时间
+func (pm *ProcessManager) SetStopTimeout(d time.Duration) {
+	pm.mu.Lock()
+	pm.stopTimeout = d
+	pm.mu.Unlock()
+}
+
+// SetCookiesKey 设置传给实例的 cookies 加密口令
+func (pm *ProcessManager) SetCookiesKey(key string) {
+	pm.mu.Lock()
+	pm.cookiesKey = key
+	pm.mu.Unlock()
+}
+
+// StopTimeout 账号的优雅退出等待时间
+func (pm *ProcessManager) StopTimeout(u UserConfig) time.Duration {
+	if u.StopTimeoutSec > 0 {
+		return time.Duration(u.StopTimeoutSec) * time.Second
+	}
 	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if pm.stopTimeout > 0 {
+		return pm.stopTimeout
+	}
+	return defaultStopTimeout
+}
+
+// defaultReadyTimeout 未指定 -ready-timeout 时等待实例就绪的时间
+const defaultReadyTimeout = 30 * time.Second
+
+// SetReadyTimeout 设置启动后等待实例通过健康检查的时间
+func (pm *ProcessManager) SetReadyTimeout(d time.Duration) {
+	pm.mu.Lock()
+	pm.readyTimeout = d
+	pm.mu.Unlock()
+}
+
+// SetClaim 设置启动前获取账号归属的回调，集群模式下使用
+func (pm *ProcessManager) SetClaim(claim func(userID string) error) {
+	pm.mu.Lock()
+	pm.claim = claim
+	pm.mu.Unlock()
+}
+
+// ReadyTimeout 启动后等待实例就绪的时间
+func (pm *ProcessManager) ReadyTimeout() time.Duration {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if pm.readyTimeout > 0 {
+		return pm.readyTimeout
+	}
+	return defaultReadyTimeout
+}
+
+// defaultStopTimeout 未指定 -stop-timeout 时的优雅退出等待时间
+const defaultStopTimeout = 10 * time.Second
+
+// StopUser 手动停止用户进程，同时取消等待中的自动重启。timeout 为 0 时使用账号的 stop_timeout_sec
+func (pm *ProcessManager) StopUser(ctx context.Context, userID string, timeout time.Duration) error {
+	_, err := pm.StopUserResult(ctx, userID, timeout)
+	return err
+}
+
+// StopUserResult 同 StopUser，并返回停止经过的阶段
+func (pm *ProcessManager) StopUserResult(ctx context.Context, userID string, timeout time.Duration) (StopResult, error) {
+	pm.cancelRestart(userID, false)
+	return pm.stopUser(ctx, userID, timeout)
+}
+
+func (pm *ProcessManager) stopUser(ctx context.Context, userID string, timeout time.Duration) (StopResult, error) {
+	pm.mu.Lock()
 	p, ok := pm.procs[userID]
-	pm.mu.RUnlock()
-	if !ok || p == nil || p.cmd == nil || p.cmd.Process == nil {
-		return nil
+	if !ok || p == nil || !p.alive() {
+		pm.mu.Unlock()
+		return StopResult{Stage: stopStageNone}, nil
+	}
+	if p.state != StateFailed {
+		pm.setStateLocked(userID, p, StateStopping)
+	}
+	h := p.handle()
+	params := p.params
+	pm.mu.Unlock()
+	if timeout <= 0 {
+		timeout = pm.StopTimeout(params.User)
 	}
 
-	_ = p.cmd.Process.Signal(os.Interrupt)
+	begin := time.Now()
+	res := StopResult{TimeoutMs: timeout.Milliseconds()}
+	finish := func(stage string) StopResult {
+		res.Stage = stage
+		res.DurationMs = time.Since(begin).Milliseconds()
+		if res.Escalated() {
+			pm.metrics.inc(userID, func(c *procCounters) { c.stopFailures++ })
+			fmt.Printf("用户 %s 未在 %s 内退出，已升级为 %s\n", userID, timeout, stage)
+		}
+		return res
+	}
+	// wait 等待实例退出，ctx 结束时强制终止
+	wait := func(d time.Duration) (bool, error) {
+		select {
+		case <-p.done:
+			return true, nil
+		case <-time.After(d):
+			return false, nil
+		case <-ctx.Done():
+			_ = h.kill()
+			return false, ctx.Err()
+		}
+	}
 
-	select {
-	case <-ctx.Done():
-		_ = p.cmd.Process.Kill()
-		return ctx.Err()
-	case <-time.After(timeout):
-		_ = p.cmd.Process.Kill()
-		return nil
-	case <-p.done:
-		return nil
+	// 先通知实例优雅退出（由实例关闭浏览器），无法通知时直接进入下一步
+	if err := h.interrupt(); err == nil {
+		if exited, err := wait(timeout); err != nil {
+			return finish(stopStageKilled), err
+		} else if exited {
+			return finish(stopStageGraceful), nil
+		}
+	}
+	if err := h.terminate(); err == nil {
+		if exited, err := wait(terminateGrace); err != nil {
+			return finish(stopStageKilled), err
+		} else if exited {
+			return finish(stopStageTerminated), nil
+		}
 	}
+	_ = h.kill()
+	_, err := wait(killWait)
+	return finish(stopStageKilled), err
 }
 
-// StopAll 停止所有进程
-func (pm *ProcessManager) StopAll(ctx context.Context, stopTimeout time.Duration) error {
-	pm.mu.RLock()
+// StopAll 停止所有进程（manager 退出时调用，之后不再自动重启）
+func (pm *ProcessManager) StopAll(ctx context.Context) error {
+	pm.mu.Lock()
+	pm.closing = true
 	ids := make([]string, 0, len(pm.procs))
 	for id := range pm.procs {
 		ids = append(ids, id)
 	}
-	pm.mu.RUnlock()
+	for id := range pm.supervise {
+		pm.cancelRestartLocked(id, false)
+	}
+	pm.mu.Unlock()
 
+	var wg sync.WaitGroup
 	for _, id := range ids {
-		_ = pm.StopUser(ctx, id, stopTimeout)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = pm.StopUser(ctx, id, 0)
+		}()
 	}
+	wg.Wait()
 	return nil
 }
 
 // CheckHealth 检查健康状态
-func (pm *ProcessManager) CheckHealth(port int, timeout time.Duration) bool {
-	client := &http.Client{Timeout: timeout}
-	u := fmt.Sprintf("http://127.0.0.1:%d/health", port)
-	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
+func (pm *ProcessManager) CheckHealth(ep InstanceEndpoint, timeout time.Duration) bool {
+	client := ep.HTTPClient(timeout)
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ep.URL("/health"), nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		return false
@@ -250,17 +928,22 @@ func (pm *ProcessManager) CheckHealth(port int, timeout time.Duration) bool {
 	return resp.StatusCode >= 200 && resp.StatusCode < 300
 }
 
-func (pm *ProcessManager) waitHealthy(ctx context.Context, port int, timeout, interval time.Duration) error {
+func (pm *ProcessManager) waitHealthy(ctx context.Context, ep InstanceEndpoint, exited <-chan struct{}, timeout, interval time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	var lastErr error
 	for time.Now().Before(deadline) {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
-		if pm.CheckHealth(port, 2*time.Second) {
+		select {
+		case <-exited:
+			return fmt.Errorf("子进程启动后立即退出，请查看日志")
+		default:
+		}
+		if pm.CheckHealth(ep, 2*time.Second) {
 			return nil
 		}
-		lastErr = fmt.Errorf("health 失败: http://127.0.0.1:%d/health", port)
+		lastErr = fmt.Errorf("health 失败: %s", ep.URL("/health"))
 		time.Sleep(interval)
 	}
 	return fmt.Errorf("启动超时(%s): %v", timeout, lastErr)
@@ -276,6 +959,12 @@ func ensureDirs(p DerivedPaths) error {
 	if err := os.MkdirAll(filepath.Dir(p.LogFile), 0755); err != nil {
 		return fmt.Errorf("创建 logs 目录失败: %w", err)
 	}
+	if err := os.MkdirAll(p.StateDir, 0755); err != nil {
+		return fmt.Errorf("创建 state 目录失败: %w", err)
+	}
+	if err := os.MkdirAll(p.TempDir, 0755); err != nil {
+		return fmt.Errorf("创建 tmp 目录失败: %w", err)
+	}
 	return nil
 }
 
@@ -287,12 +976,26 @@ func buildChildEnvForUser(proxy, proxyPoolURL string) []string {
 		if i := strings.IndexByte(item, '='); i >= 0 {
 			key = item[:i]
 		}
-		if isProxyRelatedEnvKey(key) {
+		if isProxyRelatedEnvKey(key) || managerOnlyEnvKey(key) {
 			continue
 		}
 		env = append(env, item)
 	}
+	return append(env, proxyEnv(proxy, proxyPoolURL)...)
+}
 
+// managerOnlyEnvKey manager 自身的凭据，不传给实例进程
+func managerOnlyEnvKey(key string) bool {
+	switch key {
+	case adminPasswordEnvKey, adminTokenEnvKey, analyticsInfluxEnvKey, kubeTokenEnvKey:
+		return true
+	}
+	return false
+}
+
+// proxyEnv 账号代理对应的环境变量
+func proxyEnv(proxy, proxyPoolURL string) []string {
+	var env []string
 	proxy = strings.TrimSpace(proxy)
 	if proxy != "" {
 		env = append(env, envXHSProxy+"="+proxy)
@@ -304,6 +1007,25 @@ func buildChildEnvForUser(proxy, proxyPoolURL string) []string {
 	return env
 }
 
+// userEnv 账号配置的环境变量，按变量名排序；追加在继承的环境之后，同名时覆盖 manager 的设置
+func userEnv(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+// tempDirEnv 临时目录环境变量（Unix 读取 TMPDIR，Windows 读取 TMP/TEMP）
+func tempDirEnv(dir string) []string {
+	return []string{"TMPDIR=" + dir, "TMP=" + dir, "TEMP=" + dir}
+}
+
 func isProxyRelatedEnvKey(key string) bool {
 	switch strings.ToUpper(strings.TrimSpace(key)) {
 	case "HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY", envXHSProxy, envXHSProxyPoolURL:
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProcessStateTransitions(t *testing.T) {
+	pm := NewProcessManager()
+	if st := pm.GetStatus("u1"); st.State != StateStopped || st.Running {
+		t.Fatalf("未启动用户应为 stopped: %+v", st)
+	}
+
+	p, err := pm.reserve("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := pm.GetStatus("u1"); st.State != StateStarting || st.Running {
+		t.Fatalf("占位后应为 starting 且未拉起: %+v", st)
+	}
+	if _, err := pm.reserve("u1"); err == nil {
+		t.Fatal("starting 期间不应允许重复启动")
+	}
+
+	pm.fail(p, os.ErrNotExist)
+	close(p.done)
+	st := pm.GetStatus("u1")
+	if st.State != StateFailed || st.LastError == "" {
+		t.Fatalf("启动失败应为 failed 并带错误: %+v", st)
+	}
+	if _, err := pm.reserve("u1"); err != nil {
+		t.Fatalf("failed 之后应允许重新启动: %v", err)
+	}
+
+	pm.Forget("u1")
+	if pm.GetStatus("u1").State != StateStarting {
+		t.Fatal("Forget 不应清理活动记录")
+	}
+}
+
+func TestStartUserChildExitsEarly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewProcessManager()
+	start := time.Now()
+	err := pm.StartUser(context.Background(), StartUserParams{
+		User:    UserConfig{ID: "u1", Port: 1},
+		BinPath: bin,
+		DataDir: filepath.Join(dir, "data"),
+	})
+	if err == nil {
+		t.Fatal("子进程退出时启动应失败")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("子进程退出后应立即失败，而不是等待健康检查超时")
+	}
+
+	st := pm.GetStatus("u1")
+	if st.State != StateFailed || st.Running {
+		t.Fatalf("应为 failed 且不再运行: %+v", st)
+	}
+	if st.ExitCode == nil || *st.ExitCode != 3 {
+		t.Fatalf("应记录退出码 3: %+v", st.ExitCode)
+	}
+}
+
+func TestStartUserInjectsEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	out := filepath.Join(dir, "env.txt")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nenv > "+out+"\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LOG_LEVEL", "info")
+
+	pm := NewProcessManager()
+	pm.SetCookiesKey("cookie-pass")
+	_ = pm.StartUser(context.Background(), StartUserParams{
+		User:    UserConfig{ID: "u1", Port: 1, Env: map[string]string{"LOG_LEVEL": "debug", "XHS_FEATURE_X": "on"}},
+		BinPath: bin,
+		DataDir: filepath.Join(dir, "data"),
+	})
+	raw, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := "\n" + string(raw)
+	if !strings.Contains(env, "\nLOG_LEVEL=debug\n") || strings.Contains(env, "\nLOG_LEVEL=info\n") {
+		t.Fatalf("账号 env 应覆盖 manager 的同名变量:\n%s", raw)
+	}
+	if !strings.Contains(env, "\nXHS_FEATURE_X=on\n") || !strings.Contains(env, "\nCOOKIES_PATH=") || !strings.Contains(env, "\nXHS_COOKIES_KEY=cookie-pass\n") {
+		t.Fatalf("缺少注入的变量:\n%s", raw)
+	}
+}
+
+func TestChildEnvOmitsManagerSecrets(t *testing.T) {
+	t.Setenv(adminPasswordEnvKey, "pw")
+	t.Setenv(adminTokenEnvKey, "tok")
+	t.Setenv(analyticsInfluxEnvKey, "influx")
+	t.Setenv(kubeTokenEnvKey, "k8s")
+	t.Setenv("LOG_LEVEL", "info")
+
+	env := "\n" + strings.Join(buildChildEnvForUser("", ""), "\n") + "\n"
+	for _, key := range []string{adminPasswordEnvKey, adminTokenEnvKey, analyticsInfluxEnvKey, kubeTokenEnvKey} {
+		if strings.Contains(env, "\n"+key+"=") {
+			t.Errorf("实例不应继承 %s", key)
+		}
+	}
+	if !strings.Contains(env, "\nLOG_LEVEL=info\n") {
+		t.Fatal("其他变量应照常继承")
+	}
+}
+
+func TestCrashLoopDetection(t *testing.T) {
+	pm := NewProcessManager()
+	for i := 0; i < crashLoopThreshold; i++ {
+		if pm.CrashLooping("u1") {
+			t.Fatalf("失败 %d 次不应判定为崩溃循环", i)
+		}
+		p, err := pm.reserve("u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pm.fail(p, os.ErrNotExist)
+		close(p.done)
+	}
+	if !pm.CrashLooping("u1") {
+		t.Fatal("窗口内连续失败应判定为崩溃循环")
+	}
+	if got := len(pm.RecentFailures("u1")); got != crashLoopThreshold {
+		t.Fatalf("失败记录数不符: %d", got)
+	}
+
+	pm.Forget("u1")
+	if pm.CrashLooping("u1") {
+		t.Fatal("删除用户后应清理失败记录")
+	}
+}
+
+func TestSupervisorRestartsWithBackoff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	if d := restartBackoff(3); d != 8*restartBackoffBase {
+		t.Fatalf("第 4 次重启应等待 8 倍基础间隔，got %s", d)
+	}
+	if d := restartBackoff(100); d != restartBackoffMax {
+		t.Fatalf("退避应有上限，got %s", d)
+	}
+
+	old := restartBackoffBase
+	restartBackoffBase = time.Millisecond
+	defer func() { restartBackoffBase = old }()
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	params := StartUserParams{
+		User:    UserConfig{ID: "u1", Port: 1, Restart: RestartOnFailure, MaxRestarts: 2},
+		BinPath: bin,
+		DataDir: filepath.Join(dir, "data"),
+	}
+	// 模拟运行中的实例异常退出
+	crash := func(pm *ProcessManager, params StartUserParams) {
+		p, err := pm.reserve(params.User.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pm.mu.Lock()
+		p.params = params
+		p.state = StateRunning
+		pm.mu.Unlock()
+		pm.markExited(p, os.ErrProcessDone)
+	}
+
+	pm := NewProcessManager()
+	crash(pm, params)
+	deadline := time.Now().Add(10 * time.Second)
+	for !pm.GetStatus("u1").RestartGaveUp {
+		if time.Now().After(deadline) {
+			t.Fatalf("应在重启失败 2 次后放弃: %+v", pm.GetStatus("u1"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st := pm.GetStatus("u1"); st.Restarts != 2 || st.NextRestartAt != "" {
+		t.Fatalf("重启次数不符: %+v", st)
+	}
+
+	// 策略为 never 时不重启
+	pm = NewProcessManager()
+	params.User.Restart = RestartNever
+	crash(pm, params)
+	if st := pm.GetStatus("u1"); st.NextRestartAt != "" || st.Restarts != 0 {
+		t.Fatalf("never 不应安排重启: %+v", st)
+	}
+
+	// 手动停止取消等待中的重启
+	restartBackoffBase = time.Hour
+	pm = NewProcessManager()
+	params.User.Restart = RestartAlways
+	crash(pm, params)
+	if pm.GetStatus("u1").NextRestartAt == "" {
+		t.Fatal("应安排重启")
+	}
+	_ = pm.StopUser(context.Background(), "u1", time.Second)
+	if st := pm.GetStatus("u1"); st.NextRestartAt != "" {
+		t.Fatalf("停止后应取消重启: %+v", st)
+	}
+}
+
+func TestStartUserSeparatesStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho started\necho 'panic: boom' >&2\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager()
+	dataDir := filepath.Join(dir, "data")
+	_ = pm.StartUser(context.Background(), StartUserParams{User: UserConfig{ID: "u1", Port: 1}, BinPath: bin, DataDir: dataDir})
+
+	paths := pm.DerivePaths(dataDir, "u1", 1)
+	stdout, _ := os.ReadFile(paths.LogFile)
+	stderr, _ := os.ReadFile(paths.ErrLogFile)
+	if string(stdout) != "started\n" || string(stderr) != "panic: boom\n" {
+		t.Fatalf("标准输出与标准错误应分别写入: %q / %q", stdout, stderr)
+	}
+}
+
+func TestStartUserInjectsAPIKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"$XHS_API_TOKEN\"\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager()
+	dataDir := filepath.Join(dir, "data")
+	_ = pm.StartUser(context.Background(), StartUserParams{User: UserConfig{ID: "u1", Port: 1, APIKey: "fixed-key"}, BinPath: bin, DataDir: dataDir})
+
+	out, _ := os.ReadFile(pm.DerivePaths(dataDir, "u1", 1).LogFile)
+	if string(out) != "fixed-key\n" {
+		t.Fatalf("实例应使用账号的固定密钥，实际 %q", out)
+	}
+}
+
+func TestWaitHealthyUntilReady(t *testing.T) {
+	// 前几次健康检查失败（MCP 服务尚未监听），之后就绪
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	ep := InstanceEndpoint{Port: port}
+
+	pm := NewProcessManager()
+	if err := pm.waitHealthy(context.Background(), ep, nil, time.Second, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("应轮询到健康检查通过，实际 %d 次", calls.Load())
+	}
+	calls.Store(-1000)
+	if err := pm.waitHealthy(context.Background(), ep, nil, 50*time.Millisecond, 10*time.Millisecond); err == nil {
+		t.Fatal("超时未就绪应返回错误")
+	}
+
+	pm.SetReadyTimeout(5 * time.Second)
+	if pm.ReadyTimeout() != 5*time.Second {
+		t.Fatal("-ready-timeout 未生效")
+	}
+	started := time.Now().Add(-2 * time.Second)
+	rp := &runningProc{state: StateStarting, adoptedPID: os.Getpid(), startedAt: started}
+	if st := rp.status(); st.Ready || st.ReadyAt != "" {
+		t.Fatalf("starting 阶段不应报告就绪: %+v", st)
+	}
+	rp.state, rp.readyAt = StateRunning, started.Add(1500*time.Millisecond)
+	if st := rp.status(); !st.Ready || st.StartupMs != 1500 {
+		t.Fatalf("就绪状态不符: %+v", st)
+	}
+}
+
+func TestStatusRecordsExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本与信号")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "fake-mcp")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\nkill -KILL $$\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewProcessManager()
+	params := StartUserParams{User: UserConfig{ID: "u1", Port: 1}, BinPath: bin, DataDir: filepath.Join(dir, "data")}
+	for range 2 {
+		_ = pm.StartUser(context.Background(), params)
+	}
+	st := pm.GetStatus("u1")
+	if st.ExitSignal != "SIGKILL" || st.UptimeSec != 0 || st.TotalRestarts != 1 {
+		t.Fatalf("状态不符: %+v", st)
+	}
+	if st.LastExit == nil || st.LastExit.Signal != "SIGKILL" || st.LastExit.Expected {
+		t.Fatalf("最近一次退出不符: %+v", st.LastExit)
+	}
+}
@@ -0,0 +1,140 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// procTree Unix 下实例运行在独立进程组中。
+// rod 启动的浏览器会再建自己的进程组，不会随实例进程组一起收到信号，
+// 因此强制终止时先按父子关系收集整棵子进程树再逐个终止。
+type procTree struct {
+	pid int
+}
+
+// prepareProcTree 启动前设置：实例作为新进程组的组长
+func prepareProcTree(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// attachProcTree 启动后关联进程树
+func attachProcTree(cmd *exec.Cmd) (*procTree, error) {
+	return &procTree{pid: cmd.Process.Pid}, nil
+}
+
+// interrupt 只通知实例进程，由实例自行关闭浏览器后退出
+func (t *procTree) interrupt() error {
+	return syscall.Kill(t.pid, syscall.SIGINT)
+}
+
+// terminate 向实例进程组发送 SIGTERM
+func (t *procTree) terminate() error {
+	err := syscall.Kill(-t.pid, syscall.SIGTERM)
+	if errors.Is(err, syscall.ESRCH) {
+		err = nil
+	}
+	return err
+}
+
+// kill 强制终止实例及其全部子孙进程
+func (t *procTree) kill() error {
+	descendants := descendantPIDs(t.pid)
+	err := syscall.Kill(-t.pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		err = nil
+	}
+	for _, pid := range descendants {
+		// 子进程自成一组时连同其进程组一起终止（如浏览器的渲染进程）
+		_ = syscall.Kill(-pid, syscall.SIGKILL)
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+	}
+	return err
+}
+
+// release 实例退出后清理仍留在实例进程组中的进程
+func (t *procTree) release() {
+	_ = syscall.Kill(-t.pid, syscall.SIGKILL)
+}
+
+// descendantPIDs 通过 ps 获取 pid 的所有子孙进程（Linux 与 macOS 通用），失败时返回空
+func descendantPIDs(root int) []int {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=").Output()
+	if err != nil {
+		return nil
+	}
+	return parseDescendants(string(out), root)
+}
+
+// parseDescendants 从 "pid ppid" 列表中找出 root 的所有子孙进程
+func parseDescendants(psOutput string, root int) []int {
+	children := map[int][]int{}
+	for _, line := range strings.Split(psOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], pid)
+	}
+	var out []int
+	queue := []int{root}
+	seen := map[int]bool{root: true}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, c := range children[cur] {
+			if !seen[c] {
+				seen[c] = true
+				out = append(out, c)
+				queue = append(queue, c)
+			}
+		}
+	}
+	return out
+}
+
+// adoptProcTree 接管上一个 manager 启动的实例进程（实例是其进程组的组长）
+func adoptProcTree(pid int) *procTree {
+	return &procTree{pid: pid}
+}
+
+// processAlive 进程是否仍存在
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processCommandLine 进程的命令行，用于确认 PID 未被其他进程复用
+func processCommandLine(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// exitSignal 进程被信号终止时返回信号名（如 SIGKILL、SIGSEGV），正常退出时为空
+func exitSignal(ps *os.ProcessState) string {
+	if ws, ok := ps.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		if name := unix.SignalName(ws.Signal()); name != "" {
+			return name
+		}
+		return ws.Signal().String()
+	}
+	return ""
+}
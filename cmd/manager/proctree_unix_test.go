@@ -0,0 +1,116 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseDescendants(t *testing.T) {
+	ps := `
+  1     0
+ 10     1
+ 11    10
+ 12    11
+ 13    10
+ 20     1
+`
+	got := parseDescendants(ps, 10)
+	slices.Sort(got)
+	if want := []int{11, 12, 13}; !slices.Equal(got, want) {
+		t.Fatalf("descendants = %v, want %v", got, want)
+	}
+	if got := parseDescendants(ps, 20); len(got) != 0 {
+		t.Fatalf("leaf descendants = %v", got)
+	}
+}
+
+func TestProcTreeKillsDetachedChildren(t *testing.T) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		t.Skip("ps not available")
+	}
+	// 子进程用 setsid 自成一组，模拟浏览器脱离实例进程组
+	cmd := exec.Command("sh", "-c", "setsid sleep 60 & sleep 60")
+	prepareProcTree(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := attachProcTree(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var children []int
+	for i := 0; i < 50 && len(children) < 2; i++ {
+		time.Sleep(20 * time.Millisecond)
+		children = descendantPIDs(cmd.Process.Pid)
+	}
+	if len(children) < 2 {
+		t.Skipf("setsid not available, children=%v", children)
+	}
+
+	if err := tree.kill(); err != nil {
+		t.Fatal(err)
+	}
+	_ = cmd.Wait()
+	tree.release()
+
+	for _, pid := range children {
+		alive := true
+		for i := 0; i < 50 && alive; i++ {
+			// 被终止但尚未被回收的僵尸进程同样视为已退出
+			alive = syscall.Kill(pid, 0) == nil && !isZombie(pid)
+			if alive {
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+		if alive {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("child %d survived", pid)
+		}
+	}
+}
+
+func isZombie(pid int) bool {
+	out, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	return err != nil || len(out) == 0 || out[0] == 'Z'
+}
+
+func TestStopEscalatesToSigterm(t *testing.T) {
+	// 忽略 SIGINT 的实例：超时后应由 SIGTERM 结束
+	cmd := exec.Command("sh", "-c", `trap "" INT; sleep 30`)
+	prepareProcTree(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := attachProcTree(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := &runningProc{state: StateRunning, cmd: cmd, tree: tree, done: make(chan struct{})}
+	go func() {
+		_ = cmd.Wait()
+		tree.release()
+		close(rp.done)
+	}()
+	pm := NewProcessManager()
+	pm.procs["u1"] = rp
+	time.Sleep(100 * time.Millisecond) // 等待 trap 生效
+
+	res, err := pm.StopUserResult(context.Background(), "u1", 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stage != stopStageTerminated || !res.Escalated() || res.TimeoutMs != 200 {
+		t.Fatalf("停止结果不符: %+v", res)
+	}
+	if pm.StopTimeout(UserConfig{StopTimeoutSec: 30}) != 30*time.Second || pm.StopTimeout(UserConfig{}) != defaultStopTimeout {
+		t.Fatal("账号的停止超时未生效")
+	}
+}
@@ -0,0 +1,128 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// procTree Windows 下用作业对象（Job Object）管理实例及其启动的 chrome.exe：
+// 终止作业即终止整棵进程树；作业句柄关闭（实例退出或 manager 自身退出）时，
+// 作业内残留的进程也会被系统终止，不再遗留占用用户目录锁的浏览器进程。
+type procTree struct {
+	mu  sync.Mutex
+	pid int
+	job windows.Handle
+}
+
+// prepareProcTree 启动前设置：实例放入新进程组，以便单独发送 Ctrl+Break
+func prepareProcTree(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+}
+
+// attachProcTree 启动后创建作业对象并把实例加入；实例随后启动的浏览器自动属于同一作业
+func attachProcTree(cmd *exec.Cmd) (*procTree, error) {
+	t := &procTree{pid: cmd.Process.Pid}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return t, fmt.Errorf("创建作业对象失败: %w", err)
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		_ = windows.CloseHandle(job)
+		return t, fmt.Errorf("设置作业对象失败: %w", err)
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(t.pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return t, fmt.Errorf("打开子进程失败: %w", err)
+	}
+	defer windows.CloseHandle(h)
+	if err := windows.AssignProcessToJobObject(job, h); err != nil {
+		_ = windows.CloseHandle(job)
+		return t, fmt.Errorf("子进程加入作业对象失败: %w", err)
+	}
+	t.job = job
+	return t, nil
+}
+
+// interrupt 发送 Ctrl+Break（实例按 SIGINT 处理）；manager 没有控制台时会失败，由调用方直接强制终止
+func (t *procTree) interrupt() error {
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(t.pid))
+}
+
+// terminate Windows 没有 SIGTERM，由调用方直接强制终止
+func (t *procTree) terminate() error {
+	return errors.ErrUnsupported
+}
+
+// kill 终止作业内的所有进程；未能建立作业时只终止实例进程
+func (t *procTree) kill() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.job != 0 {
+		return windows.TerminateJobObject(t.job, 1)
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(t.pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.TerminateProcess(h, 1)
+}
+
+// release 实例退出后关闭作业句柄，作业内残留的浏览器进程随之被终止
+func (t *procTree) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.job != 0 {
+		_ = windows.CloseHandle(t.job)
+		t.job = 0
+	}
+}
+
+// adoptProcTree 接管上一个 manager 启动的实例进程。实例属于上一个 manager 的作业对象，
+// manager 退出时通常已随作业一并终止，这里只能按 PID 管理实例进程本身
+func adoptProcTree(pid int) *procTree {
+	return &procTree{pid: pid}
+}
+
+// processAlive 进程是否仍存在
+func processAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// stillActive GetExitCodeProcess 对运行中进程返回的 STILL_ACTIVE
+const stillActive = 259
+
+// processCommandLine Windows 下不读取其他进程的命令行，无法确认身份的进程不会被终止
+func processCommandLine(pid int) (string, error) {
+	return "", fmt.Errorf("不支持读取进程 %d 的命令行", pid)
+}
+
+// exitSignal Windows 没有信号，总是为空
+func exitSignal(*os.ProcessState) string {
+	return ""
+}
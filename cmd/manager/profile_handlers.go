@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserProfile 返回缓存的账号资料
+// GET /api/admin/v1/users/:id/profile
+func (a *App) GetUserProfile(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if user.Profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "尚未获取账号资料，请先刷新"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": user.Profile})
+}
+
+// RefreshUserProfile 向实例查询已登录账号的主页资料并缓存到存储中
+// POST /api/admin/v1/users/:id/profile/refresh
+func (a *App) RefreshUserProfile(c *gin.Context) {
+	ep, ok := a.runningEndpoint(c)
+	if !ok {
+		return
+	}
+	id := strings.TrimSpace(c.Param("id"))
+
+	status, profile, err := a.refreshProfile(c.Request.Context(), ep, id)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// refreshProfile 查询实例账号资料并写入存储，失败时返回建议的 HTTP 状态码
+func (a *App) refreshProfile(ctx context.Context, ep InstanceEndpoint, id string) (int, *AccountProfile, error) {
+	// 打开个人主页需要浏览器导航，给足时间
+	status, _, data, err := a.proxyGet(ctx, ep, "/api/v1/user/me", 90*time.Second)
+	if err != nil {
+		return http.StatusBadGateway, nil, fmt.Errorf("转发请求失败: %v", err)
+	}
+	profile, err := parseAccountProfile(data)
+	if err != nil {
+		if status == http.StatusOK {
+			status = http.StatusBadGateway
+		}
+		return status, nil, err
+	}
+	profile.UpdatedAt = time.Now()
+	if err := a.store.SetUserProfile(id, profile); err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+	return http.StatusOK, profile, nil
+}
+
+// parseAccountProfile 解析实例 /api/v1/user/me 的响应
+func parseAccountProfile(data []byte) (*AccountProfile, error) {
+	var body struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Data    struct {
+			UserBasicInfo struct {
+				Nickname string `json:"nickname"`
+				Images   string `json:"images"`
+				Imageb   string `json:"imageb"`
+				RedID    string `json:"redId"`
+			} `json:"userBasicInfo"`
+			Interactions []struct {
+				Type  string `json:"type"`
+				Count string `json:"count"`
+			} `json:"interactions"`
+			Feeds   []json.RawMessage `json:"feeds"`
+			HasMore bool              `json:"has_more"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("解析账号资料失败: %w", err)
+	}
+	if !body.Success {
+		return nil, fmt.Errorf("获取账号资料失败: %s", body.Error)
+	}
+
+	info := body.Data.UserBasicInfo
+	p := &AccountProfile{
+		Nickname:  info.Nickname,
+		Avatar:    info.Images,
+		RedID:     info.RedID,
+		NoteCount: len(body.Data.Feeds),
+		MoreNotes: body.Data.HasMore,
+	}
+	if p.Avatar == "" {
+		p.Avatar = info.Imageb
+	}
+	for _, it := range body.Data.Interactions {
+		switch it.Type {
+		case "follows":
+			p.Follows = it.Count
+		case "fans":
+			p.Fans = it.Count
+		case "interaction":
+			p.Interaction = it.Count
+		}
+	}
+	if p.Nickname == "" && p.RedID == "" {
+		return nil, fmt.Errorf("未获取到账号资料，可能未登录")
+	}
+	return p, nil
+}
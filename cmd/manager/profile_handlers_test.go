@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseAccountProfile(t *testing.T) {
+	data := []byte(`{"success":true,"data":{
+		"userBasicInfo":{"nickname":"小红","images":"https://img/a.jpg","redId":"12345"},
+		"interactions":[{"type":"follows","count":"10"},{"type":"fans","count":"1.2万"},{"type":"interaction","count":"3万"}],
+		"feeds":[{},{},{}],"has_more":true}}`)
+	p, err := parseAccountProfile(data)
+	if err != nil {
+		t.Fatalf("parseAccountProfile: %v", err)
+	}
+	if p.Nickname != "小红" || p.RedID != "12345" || p.Avatar != "https://img/a.jpg" {
+		t.Fatalf("基本信息不符: %+v", p)
+	}
+	if p.Follows != "10" || p.Fans != "1.2万" || p.Interaction != "3万" {
+		t.Fatalf("互动数据不符: %+v", p)
+	}
+	if p.NoteCount != 3 || !p.MoreNotes {
+		t.Fatalf("笔记数不符: %+v", p)
+	}
+
+	if _, err := parseAccountProfile([]byte(`{"success":false,"error":"未登录"}`)); err == nil {
+		t.Fatal("实例返回失败时应报错")
+	}
+	if _, err := parseAccountProfile([]byte(`{"success":true,"data":{}}`)); err == nil {
+		t.Fatal("资料为空时应报错")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 经 manager 转发到实例的请求限流（/users/:id/mcp、/users/:id/api/*），避免失控的 MCP 客户端
+// 高频调用把账号刷到风控：
+//
+//   - 每个账号按令牌桶限制请求速率：-proxy-rate 为每分钟请求数，账号可通过 proxy_rate_per_min 单独设置
+//   - -proxy-user-concurrent 限制单个账号、-proxy-max-concurrent 限制所有账号同时转发中的请求数
+//
+// 超出时直接返回 429 并带 Retry-After，不排队。MCP 的 SSE 长连接（GET /mcp）只计入速率，不占并发名额。
+
+// ProxyLimitConfig 转发限流配置，各项为 0 表示不限制
+type ProxyLimitConfig struct {
+	RatePerMin     int // 每个账号每分钟的请求数
+	Burst          int // 允许的突发请求数，0 时为每分钟请求数的 1/6（至少 1）
+	MaxConcurrent  int // 所有账号同时转发中的请求数
+	UserConcurrent int // 单个账号同时转发中的请求数
+}
+
+// 被拒绝的原因，用于指标
+const (
+	proxyRejectRate           = "rate"
+	proxyRejectUserConcurrent = "user_concurrency"
+	proxyRejectConcurrent     = "concurrency"
+)
+
+type proxyRejectKey struct {
+	user, reason string
+}
+
+// tokenBucket 令牌桶，rate 为每秒补充的令牌数
+type tokenBucket struct {
+	rate    float64
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+// take 取一个令牌，不足时返回需要等待的时间
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// proxyLimiter 转发限流状态，零值可用（不限制）
+type proxyLimiter struct {
+	mu       sync.Mutex
+	cfg      ProxyLimitConfig
+	buckets  map[string]*tokenBucket
+	inflight map[string]int
+	total    int
+	rejected map[proxyRejectKey]int
+}
+
+// SetProxyLimits 设置转发限流
+func (a *App) SetProxyLimits(cfg ProxyLimitConfig) {
+	l := &a.throttle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.buckets = nil // 按新配置重建
+}
+
+// userRate 账号实际使用的每分钟请求数，0 表示不限制
+func (l *proxyLimiter) userRate(u UserConfig) int {
+	switch {
+	case u.ProxyRatePerMin < 0:
+		return 0
+	case u.ProxyRatePerMin > 0:
+		return u.ProxyRatePerMin
+	}
+	return l.cfg.RatePerMin
+}
+
+// acquire 为一次转发占用名额；失败时返回原因与建议的重试间隔。
+// stream 为 SSE 长连接，不占并发名额
+func (l *proxyLimiter) acquire(u UserConfig, stream bool, now time.Time) (release func(), reason string, retry time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reject := func(r string, d time.Duration) (func(), string, time.Duration) {
+		if l.rejected == nil {
+			l.rejected = map[proxyRejectKey]int{}
+		}
+		l.rejected[proxyRejectKey{u.ID, r}]++
+		return nil, r, d
+	}
+	if !stream {
+		if n := l.cfg.UserConcurrent; n > 0 && l.inflight[u.ID] >= n {
+			return reject(proxyRejectUserConcurrent, time.Second)
+		}
+		if n := l.cfg.MaxConcurrent; n > 0 && l.total >= n {
+			return reject(proxyRejectConcurrent, time.Second)
+		}
+	}
+	if perMin := l.userRate(u); perMin > 0 {
+		rate := float64(perMin) / 60
+		burst := float64(l.cfg.Burst)
+		if burst <= 0 {
+			burst = max(1, float64(perMin/6))
+		}
+		b := l.buckets[u.ID]
+		if b == nil || b.rate != rate || b.burst != burst {
+			if l.buckets == nil {
+				l.buckets = map[string]*tokenBucket{}
+			}
+			b = &tokenBucket{rate: rate, burst: burst, tokens: burst, updated: now}
+			l.buckets[u.ID] = b
+		}
+		if ok, wait := b.take(now); !ok {
+			return reject(proxyRejectRate, wait)
+		}
+	}
+	if stream {
+		return func() {}, "", 0
+	}
+
+	if l.inflight == nil {
+		l.inflight = map[string]int{}
+	}
+	l.inflight[u.ID]++
+	l.total++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.inflight[u.ID]--; l.inflight[u.ID] <= 0 {
+				delete(l.inflight, u.ID)
+			}
+			l.total--
+		})
+	}, "", 0
+}
+
+// snapshot 各账号转发中的请求数与被拒绝次数，用于指标
+func (l *proxyLimiter) snapshot() (map[string]int, map[proxyRejectKey]int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inflight := make(map[string]int, len(l.inflight))
+	for k, v := range l.inflight {
+		inflight[k] = v
+	}
+	rejected := make(map[proxyRejectKey]int, len(l.rejected))
+	for k, v := range l.rejected {
+		rejected[k] = v
+	}
+	return inflight, rejected
+}
+
+// limitProxy 检查转发限流，超出时已写入 429 响应
+func (a *App) limitProxy(c *gin.Context, u UserConfig, stream bool) (func(), bool) {
+	release, reason, retry := a.throttle.acquire(u, stream, time.Now())
+	if release != nil {
+		return release, true
+	}
+	c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retry.Seconds())))))
+	msg := fmt.Sprintf("账号 %s 请求过于频繁，请稍后重试", u.ID)
+	if reason != proxyRejectRate {
+		msg = fmt.Sprintf("账号 %s 转发中的请求过多，请稍后重试", u.ID)
+		if reason == proxyRejectConcurrent {
+			msg = "manager 转发中的请求过多，请稍后重试"
+		}
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": msg, "reason": reason})
+	return nil, false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProxyLimiterRate(t *testing.T) {
+	var l proxyLimiter
+	l.cfg = ProxyLimitConfig{RatePerMin: 60, Burst: 2}
+	u := UserConfig{ID: "u1"}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if release, _, _ := l.acquire(u, false, now); release == nil {
+			t.Fatalf("突发额度内第 %d 个请求应通过", i+1)
+		} else {
+			release()
+		}
+	}
+	release, reason, retry := l.acquire(u, false, now)
+	if release != nil || reason != proxyRejectRate || retry != time.Second {
+		t.Fatalf("超出速率应拒绝: %v %s %s", release != nil, reason, retry)
+	}
+	if release, _, _ := l.acquire(u, false, now.Add(time.Second)); release == nil {
+		t.Fatal("补充令牌后应通过")
+	}
+
+	// 账号单独设置优先于全局：-1 不限制
+	unlimited := UserConfig{ID: "u2", ProxyRatePerMin: -1}
+	for i := 0; i < 10; i++ {
+		if release, _, _ := l.acquire(unlimited, false, now); release == nil {
+			t.Fatal("proxy_rate_per_min=-1 不应限流")
+		}
+	}
+	if _, rejected := l.snapshot(); rejected[proxyRejectKey{"u1", proxyRejectRate}] != 1 {
+		t.Fatalf("rejected = %v", rejected)
+	}
+}
+
+func TestProxyLimiterConcurrency(t *testing.T) {
+	var l proxyLimiter
+	l.cfg = ProxyLimitConfig{UserConcurrent: 1, MaxConcurrent: 2}
+	now := time.Now()
+	a1, _, _ := l.acquire(UserConfig{ID: "a"}, false, now)
+	if a1 == nil {
+		t.Fatal("第一个请求应通过")
+	}
+	if release, reason, _ := l.acquire(UserConfig{ID: "a"}, false, now); release != nil || reason != proxyRejectUserConcurrent {
+		t.Fatalf("超出单账号并发应拒绝: %s", reason)
+	}
+	if release, _, _ := l.acquire(UserConfig{ID: "a"}, true, now); release == nil {
+		t.Fatal("SSE 长连接不占并发名额")
+	}
+	b1, _, _ := l.acquire(UserConfig{ID: "b"}, false, now)
+	if b1 == nil {
+		t.Fatal("其他账号的请求应通过")
+	}
+	if release, reason, _ := l.acquire(UserConfig{ID: "c"}, false, now); release != nil || reason != proxyRejectConcurrent {
+		t.Fatalf("超出全局并发应拒绝: %s", reason)
+	}
+	a1()
+	a1() // 重复释放无影响
+	if inflight, _ := l.snapshot(); inflight["a"] != 0 || inflight["b"] != 1 {
+		t.Fatalf("inflight = %v", inflight)
+	}
+	if release, _, _ := l.acquire(UserConfig{ID: "c"}, false, now); release == nil {
+		t.Fatal("释放后应通过")
+	}
+}
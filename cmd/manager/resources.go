@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// 实例资源占用：实例进程及其启动的浏览器进程（整棵进程树）的内存、CPU 与打开文件数合计。
+// 采集方式按平台区分：Linux 读取 /proc，macOS 等使用 ps，Windows 查询作业对象。
+
+// ResourceUsage 实例及其浏览器子进程的资源占用合计
+type ResourceUsage struct {
+	RSSBytes   int64   `json:"rss_bytes"`
+	CPUPercent float64 `json:"cpu_percent"` // 相对单个核心，多核占用时可超过 100
+	OpenFDs    int     `json:"open_fds"`    // 打开的文件描述符数（Windows 为句柄数），-1 表示无法获取
+	Processes  int     `json:"processes"`
+}
+
+// procSample 进程树某一时刻的累计值
+type procSample struct {
+	cpu       time.Duration // 累计 CPU 时间
+	rss       int64
+	fds       int
+	processes int
+}
+
+// cpuSample 上次采样，用于计算两次采样之间的 CPU 占用
+type cpuSample struct {
+	at      time.Time
+	cpu     time.Duration
+	percent float64
+}
+
+// minCPUSampleInterval 两次采样间隔过短时沿用上次的 CPU 占用，避免数值抖动
+const minCPUSampleInterval = time.Second
+
+// ResourceUsage 采集运行中实例的资源占用，未运行时返回 nil
+func (pm *ProcessManager) ResourceUsage(userID string) (*ResourceUsage, error) {
+	pm.mu.RLock()
+	p := pm.procs[userID]
+	if p == nil || !p.alive() || p.tree == nil {
+		pm.mu.RUnlock()
+		return nil, nil
+	}
+	tree := p.tree
+	pm.mu.RUnlock()
+
+	s, err := tree.sample()
+	if err != nil {
+		return nil, err
+	}
+	usage := &ResourceUsage{RSSBytes: s.rss, OpenFDs: s.fds, Processes: s.processes}
+
+	now := time.Now()
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	prev := p.cpuSample
+	if prev.at.IsZero() {
+		// 首次采样取启动以来的平均值
+		prev = cpuSample{at: p.startedAt}
+	}
+	wall := now.Sub(prev.at)
+	if !p.cpuSample.at.IsZero() && wall < minCPUSampleInterval {
+		usage.CPUPercent = prev.percent
+		return usage, nil
+	}
+	if wall > 0 && s.cpu >= prev.cpu {
+		usage.CPUPercent = math.Round(float64(s.cpu-prev.cpu)/float64(wall)*1000) / 10
+	}
+	p.cpuSample = cpuSample{at: now, cpu: s.cpu, percent: usage.CPUPercent}
+	return usage, nil
+}
@@ -0,0 +1,82 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Linux 的 USER_HZ 固定为 100
+const clockTicksPerSecond = 100
+
+// procStat /proc/<pid>/stat 中用到的字段
+type procStat struct {
+	ppid     int
+	cpuTicks int64 // utime + stime
+	rssPages int64
+}
+
+func readProcStat(pid int) (procStat, error) {
+	raw, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	// 进程名可能包含空格和括号，从最后一个 ')' 之后开始解析
+	s := string(raw)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return procStat{}, fmt.Errorf("无法解析 /proc/%d/stat", pid)
+	}
+	f := strings.Fields(s[i+1:])
+	if len(f) < 22 {
+		return procStat{}, fmt.Errorf("无法解析 /proc/%d/stat", pid)
+	}
+	ppid, _ := strconv.Atoi(f[1])
+	utime, _ := strconv.ParseInt(f[11], 10, 64)
+	stime, _ := strconv.ParseInt(f[12], 10, 64)
+	rss, _ := strconv.ParseInt(f[21], 10, 64)
+	return procStat{ppid: ppid, cpuTicks: utime + stime, rssPages: rss}, nil
+}
+
+// sample 汇总实例进程树的资源占用
+func (t *procTree) sample() (procSample, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return procSample{}, err
+	}
+	stats := map[int]procStat{}
+	var pairs strings.Builder
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		st, err := readProcStat(pid)
+		if err != nil {
+			continue
+		}
+		stats[pid] = st
+		fmt.Fprintf(&pairs, "%d %d\n", pid, st.ppid)
+	}
+	if _, ok := stats[t.pid]; !ok {
+		return procSample{}, fmt.Errorf("进程 %d 不存在", t.pid)
+	}
+
+	pageSize := int64(os.Getpagesize())
+	var out procSample
+	for _, pid := range append([]int{t.pid}, parseDescendants(pairs.String(), t.pid)...) {
+		st := stats[pid]
+		out.processes++
+		out.cpu += time.Duration(st.cpuTicks) * time.Second / clockTicksPerSecond
+		out.rss += st.rssPages * pageSize
+		if fds, err := os.ReadDir(filepath.Join("/proc", strconv.Itoa(pid), "fd")); err == nil {
+			out.fds += len(fds)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+//go:build !windows && !linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sample 通过 ps 汇总实例进程树的资源占用（macOS、BSD），无法获取打开文件数
+func (t *procTree) sample() (procSample, error) {
+	raw, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "rss=", "-o", "time=").Output()
+	if err != nil {
+		return procSample{}, fmt.Errorf("执行 ps 失败: %w", err)
+	}
+	type row struct {
+		rss int64
+		cpu time.Duration
+	}
+	rows := map[int]row{}
+	var pairs strings.Builder
+	for _, line := range strings.Split(string(raw), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(f[0])
+		rss, err2 := strconv.ParseInt(f[2], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		rows[pid] = row{rss: rss * 1024, cpu: parsePSTime(f[3])}
+		fmt.Fprintf(&pairs, "%s %s\n", f[0], f[1])
+	}
+	if _, ok := rows[t.pid]; !ok {
+		return procSample{}, fmt.Errorf("进程 %d 不存在", t.pid)
+	}
+
+	out := procSample{fds: -1}
+	for _, pid := range append([]int{t.pid}, parseDescendants(pairs.String(), t.pid)...) {
+		out.processes++
+		out.rss += rows[pid].rss
+		out.cpu += rows[pid].cpu
+	}
+	return out, nil
+}
+
+// parsePSTime 解析 ps 的累计 CPU 时间 [[dd-]hh:]mm:ss[.ss]
+func parsePSTime(s string) time.Duration {
+	var days float64
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		days, _ = strconv.ParseFloat(d, 64)
+		s = rest
+	}
+	var secs float64
+	for _, part := range strings.Split(s, ":") {
+		v, _ := strconv.ParseFloat(part, 64)
+		secs = secs*60 + v
+	}
+	return time.Duration((days*86400 + secs) * float64(time.Second))
+}
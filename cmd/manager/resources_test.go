@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestProcTreeSample(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 60 & sleep 60")
+	prepareProcTree(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := attachProcTree(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = tree.kill()
+		_ = cmd.Wait()
+		tree.release()
+	}()
+
+	var s procSample
+	for i := 0; i < 50; i++ {
+		if s, err = tree.sample(); err == nil && s.processes >= 3 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.processes < 3 {
+		t.Fatalf("processes = %d, want >= 3", s.processes)
+	}
+	if s.rss <= 0 {
+		t.Fatalf("rss = %d", s.rss)
+	}
+}
@@ -0,0 +1,106 @@
+//go:build windows
+
+package main
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modpsapi                  = windows.NewLazySystemDLL("psapi.dll")
+	procGetProcessMemoryInfo  = modpsapi.NewProc("GetProcessMemoryInfo")
+	procGetProcessHandleCount = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetProcessHandleCount")
+)
+
+// jobAccounting JOBOBJECT_BASIC_ACCOUNTING_INFORMATION，时间单位为 100ns
+type jobAccounting struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// jobProcessList JOBOBJECT_BASIC_PROCESS_ID_LIST，最多取 256 个进程
+type jobProcessList struct {
+	NumberOfAssignedProcesses uint32
+	NumberOfProcessIdsInList  uint32
+	ProcessIdList             [256]uintptr
+}
+
+// processMemoryCounters PROCESS_MEMORY_COUNTERS
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// sample 汇总作业对象内所有进程的资源占用；CPU 时间包含作业内已退出的进程。
+// 未能建立作业时只统计实例进程本身。
+func (t *procTree) sample() (procSample, error) {
+	t.mu.Lock()
+	job := t.job
+	t.mu.Unlock()
+
+	pids := []uint32{uint32(t.pid)}
+	var out procSample
+	if job != 0 {
+		var acct jobAccounting
+		if err := windows.QueryInformationJobObject(job, windows.JobObjectBasicAccountingInformation,
+			uintptr(unsafe.Pointer(&acct)), uint32(unsafe.Sizeof(acct)), nil); err != nil {
+			return procSample{}, err
+		}
+		out.cpu = time.Duration(acct.TotalUserTime+acct.TotalKernelTime) * 100
+		var list jobProcessList
+		if err := windows.QueryInformationJobObject(job, windows.JobObjectBasicProcessIdList,
+			uintptr(unsafe.Pointer(&list)), uint32(unsafe.Sizeof(list)), nil); err == nil {
+			pids = pids[:0]
+			for i := uint32(0); i < list.NumberOfProcessIdsInList; i++ {
+				pids = append(pids, uint32(list.ProcessIdList[i]))
+			}
+		}
+	}
+
+	for _, pid := range pids {
+		h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+		if err != nil {
+			continue
+		}
+		out.processes++
+		var mem processMemoryCounters
+		mem.CB = uint32(unsafe.Sizeof(mem))
+		if r, _, _ := procGetProcessMemoryInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&mem)), uintptr(mem.CB)); r != 0 {
+			out.rss += int64(mem.WorkingSetSize)
+		}
+		var handles uint32
+		if r, _, _ := procGetProcessHandleCount.Call(uintptr(h), uintptr(unsafe.Pointer(&handles))); r != 0 {
+			out.fds += int(handles)
+		}
+		if job == 0 {
+			var creation, exit, kernel, user windows.Filetime
+			if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err == nil {
+				out.cpu += time.Duration(filetimeTicks(kernel)+filetimeTicks(user)) * 100
+			}
+		}
+		_ = windows.CloseHandle(h)
+	}
+	return out, nil
+}
+
+// filetimeTicks FILETIME 表示的时长（100ns 单位）
+func filetimeTicks(ft windows.Filetime) int64 {
+	return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime)
+}
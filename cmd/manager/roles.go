@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 多管理员与角色：store 中保存管理员账号（用户名、PBKDF2 密码哈希、角色），多人共用一个 manager 时按角色授权。
+//   viewer   只读：查看账号状态、日志、截图等
+//   operator 在 viewer 基础上可启停实例、执行任务与调试操作、经 manager 访问实例
+//   admin    全部权限：创建/修改/删除账号、管理 cookies、导入导出、webhook、审计日志与管理员账号
+// -admin-password 与 -admin-token 登录的始终是 admin。
+
+// 管理员角色
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+const (
+	adminPasswordIter   = 200_000
+	adminPasswordMinLen = 8
+)
+
+// AdminAccount store 中的管理员账号
+type AdminAccount struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"` // pbkdf2-sha256$迭代次数$salt$hash（base64）
+	Role         string `json:"role"`
+}
+
+// adminAccountSource 管理员账号来源（Store）
+type adminAccountSource interface {
+	AdminAccount(username string) (AdminAccount, bool)
+	HasAdminAccounts() bool
+}
+
+func validateRole(role string) error {
+	if _, ok := roleRank[role]; !ok {
+		return fmt.Errorf("role 只能是 viewer/operator/admin")
+	}
+	return nil
+}
+
+func validateAdminAccounts(accounts []AdminAccount) error {
+	seen := map[string]bool{}
+	for i, acc := range accounts {
+		if !validIDRegex.MatchString(acc.Username) {
+			return fmt.Errorf("admins[%d]: 用户名只能包含字母、数字、下划线、连字符", i)
+		}
+		if seen[acc.Username] {
+			return fmt.Errorf("管理员用户名重复: %s", acc.Username)
+		}
+		seen[acc.Username] = true
+		if err := validateRole(acc.Role); err != nil {
+			return fmt.Errorf("admins[%d]: %w", i, err)
+		}
+		if !strings.HasPrefix(acc.PasswordHash, "pbkdf2-sha256$") {
+			return fmt.Errorf("admins[%d]: password_hash 格式不正确", i)
+		}
+	}
+	return nil
+}
+
+// hashPassword 生成 PBKDF2-SHA256 密码哈希
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, adminPasswordIter, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", adminPasswordIter, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// verifyPassword 校验密码与 hashPassword 生成的哈希
+func verifyPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err1 := enc.DecodeString(parts[2])
+	want, err2 := enc.DecodeString(parts[3])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// requiredRole 管理 API 各路由所需的最低角色
+func requiredRole(method, route string) string {
+	const base = "/api/admin/v1"
+	switch {
+	case strings.Contains(route, "/cookies"),
+		strings.HasPrefix(route, base+"/bundle/"),
+		strings.HasPrefix(route, base+"/admins"),
+		strings.HasPrefix(route, base+"/audit"),
+		strings.HasPrefix(route, base+"/webhooks"),
+		strings.HasPrefix(route, base+"/notifiers"), // 包含机器人 token 与邮箱密码
+		strings.HasSuffix(route, "/migrate"),
+		strings.HasSuffix(route, "/data/export"),   // 包含 cookies 与浏览器登录数据
+		strings.HasSuffix(route, "/api-key"),       // 持有密钥即可直连实例操作账号
+		strings.Contains(route, "/debug/devtools"), // DevTools 可读取浏览器内的 cookies
+		// 备份包含 cookies 与全部凭据，恢复会替换管理员账号
+		route == base+"/backup", route == base+"/restore",
+		route == base+"/logs/rotation",
+		route == base+"/reload":
+		return RoleAdmin
+	case method == http.MethodPost && (route == base+"/users" || route == base+"/users/import" || route == base+"/users/:id/clone"),
+		method == http.MethodPost && route == base+"/upgrade", // 上传的二进制会在本机执行
+		(method == http.MethodPut || method == http.MethodDelete) && route == base+"/users/:id",
+		method == http.MethodPut && route == base+"/users/:id/tags",
+		method == http.MethodPut && route == base+"/alerts/rules",
+		strings.HasPrefix(route, base+"/users/:id/snapshots/:sid"): // 恢复会替换登录数据
+		return RoleAdmin
+	case method == http.MethodGet || method == http.MethodHead:
+		return RoleViewer
+	default:
+		return RoleOperator
+	}
+}
+
+// roleAllows role 是否满足 required
+func roleAllows(role, required string) bool {
+	return roleRank[role] >= roleRank[required]
+}
+
+func forbidden(c *gin.Context, required string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("需要 %s 角色", required)})
+}
+
+// Authorize 按 requiredRole 检查角色，需放在 Require 之后
+func (a *AdminAuth) Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		required := requiredRole(c.Request.Method, c.FullPath())
+		if !roleAllows(c.GetString(adminRoleKey), required) {
+			forbidden(c, required)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 要求固定的最低角色，需放在 Require 之后
+func (a *AdminAuth) RequireRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !roleAllows(c.GetString(adminRoleKey), required) {
+			forbidden(c, required)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Me 当前登录的操作者与角色，GUI 据此隐藏无权限的操作
+// GET /api/admin/v1/me
+func (a *AdminAuth) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, adminPrincipal{User: adminActor(c), Role: c.GetString(adminRoleKey)})
+}
+
+type adminAccountView struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// ListAdmins 管理员账号列表
+// GET /api/admin/v1/admins
+func (a *App) ListAdmins(c *gin.Context) {
+	out := []adminAccountView{}
+	for _, acc := range a.store.AdminAccounts() {
+		out = append(out, adminAccountView{Username: acc.Username, Role: acc.Role})
+	}
+	c.JSON(http.StatusOK, gin.H{"admins": out})
+}
+
+type adminAccountReq struct {
+	Username string  `json:"username"`
+	Password *string `json:"password"` // 修改时为空表示不修改
+	Role     *string `json:"role"`     // 修改时为空表示不修改
+}
+
+// CreateAdmin 添加管理员账号
+// POST /api/admin/v1/admins
+func (a *App) CreateAdmin(c *gin.Context) {
+	var req adminAccountReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == nil || req.Role == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 username、password、role"})
+		return
+	}
+	if _, ok := a.store.AdminAccount(req.Username); ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "管理员已存在"})
+		return
+	}
+	acc, err := buildAdminAccount(AdminAccount{Username: strings.TrimSpace(req.Username)}, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.store.PutAdminAccount(acc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, adminAccountView{Username: acc.Username, Role: acc.Role})
+}
+
+// UpdateAdmin 修改管理员密码或角色
+// PUT /api/admin/v1/admins/:name
+func (a *App) UpdateAdmin(c *gin.Context) {
+	var req adminAccountReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	acc, ok := a.store.AdminAccount(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "管理员不存在"})
+		return
+	}
+	acc, err := buildAdminAccount(acc, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := a.store.PutAdminAccount(acc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, adminAccountView{Username: acc.Username, Role: acc.Role})
+}
+
+// DeleteAdmin 删除管理员账号，其会话随即失效
+// DELETE /api/admin/v1/admins/:name
+func (a *App) DeleteAdmin(c *gin.Context) {
+	if err := a.store.DeleteAdminAccount(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// buildAdminAccount 按请求更新账号的密码与角色
+func buildAdminAccount(acc AdminAccount, req adminAccountReq) (AdminAccount, error) {
+	if req.Role != nil {
+		if err := validateRole(*req.Role); err != nil {
+			return acc, err
+		}
+		acc.Role = *req.Role
+	}
+	if req.Password != nil {
+		if len(*req.Password) < adminPasswordMinLen {
+			return acc, fmt.Errorf("密码至少 %d 位", adminPasswordMinLen)
+		}
+		hash, err := hashPassword(*req.Password)
+		if err != nil {
+			return acc, err
+		}
+		acc.PasswordHash = hash
+	}
+	return acc, nil
+}
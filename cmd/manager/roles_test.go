@@ -0,0 +1,180 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := hashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyPassword(hash, "correct horse") {
+		t.Fatal("正确密码应通过校验")
+	}
+	if verifyPassword(hash, "wrong horse") || verifyPassword("plain", "plain") {
+		t.Fatal("错误密码或格式不应通过校验")
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	cases := []struct{ method, route, want string }{
+		{http.MethodGet, "/api/admin/v1/users", RoleViewer},
+		{http.MethodGet, "/api/admin/v1/users/:id/logs/stream", RoleViewer},
+		{http.MethodPost, "/api/admin/v1/users/:id/start", RoleOperator},
+		{http.MethodPost, "/api/admin/v1/users/batch/stop", RoleOperator},
+		{http.MethodPost, "/api/admin/v1/users", RoleAdmin},
+		{http.MethodDelete, "/api/admin/v1/users/:id", RoleAdmin},
+		{http.MethodPost, "/api/admin/v1/users/:id/clone", RoleAdmin},
+		{http.MethodPost, "/api/admin/v1/users/import", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/users/:id/data/export", RoleAdmin},
+		{http.MethodPost, "/api/admin/v1/users/:id/snapshots", RoleOperator},
+		{http.MethodPost, "/api/admin/v1/users/:id/snapshots/:sid/restore", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/users/:id/api-key", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/users/:id/debug/cookies/items", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/audit", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/backup", RoleAdmin},
+		{http.MethodPost, "/api/admin/v1/restore", RoleAdmin},
+		{http.MethodPut, "/api/admin/v1/alerts/rules", RoleAdmin},
+		{http.MethodPost, "/api/admin/v1/upgrade", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/upgrade", RoleViewer},
+		{http.MethodGet, "/api/admin/v1/alerts", RoleViewer},
+		{http.MethodGet, "/api/admin/v1/notifiers", RoleAdmin},
+		{http.MethodGet, "/api/admin/v1/users/:id/debug/devtools", RoleAdmin},
+	}
+	for _, tc := range cases {
+		if got := requiredRole(tc.method, tc.route); got != tc.want {
+			t.Errorf("%s %s = %s, want %s", tc.method, tc.route, got, tc.want)
+		}
+	}
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, acc := range []struct{ name, role string }{{"vic", RoleViewer}, {"olga", RoleOperator}} {
+		hash, err := hashPassword("password-" + acc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.PutAdminAccount(AdminAccount{Username: acc.name, PasswordHash: hash, Role: acc.role}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.PutAdminAccount(AdminAccount{Username: "bad", PasswordHash: "x", Role: "root"}); err == nil {
+		t.Fatal("非法角色应被拒绝")
+	}
+
+	gin.SetMode(gin.TestMode)
+	auth := NewAdminAuth(AdminAuthConfig{}, "")
+	auth.SetAccounts(store)
+	if !auth.Enabled() {
+		t.Fatal("配置管理员账号后应启用鉴权")
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r := gin.New()
+	r.POST("/api/admin/login", auth.Login)
+	api := r.Group("/api/admin/v1", auth.Require(), auth.Authorize())
+	api.GET("/me", auth.Me)
+	api.GET("/users", ok)
+	api.POST("/users/:id/start", ok)
+	api.DELETE("/users/:id", ok)
+
+	login := func(user string) http.Header {
+		w := doReq(r, http.MethodPost, "/api/admin/login", `{"username":"`+user+`","password":"password-`+user+`"}`, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s 登录失败: %d %s", user, w.Code, w.Body.String())
+		}
+		return http.Header{"Cookie": {strings.SplitN(w.Header().Get("Set-Cookie"), ";", 2)[0]}}
+	}
+	viewer, operator := login("vic"), login("olga")
+
+	for _, tc := range []struct {
+		who          http.Header
+		method, path string
+		want         int
+	}{
+		{viewer, http.MethodGet, "/api/admin/v1/users", http.StatusOK},
+		{viewer, http.MethodPost, "/api/admin/v1/users/u1/start", http.StatusForbidden},
+		{operator, http.MethodPost, "/api/admin/v1/users/u1/start", http.StatusOK},
+		{operator, http.MethodDelete, "/api/admin/v1/users/u1", http.StatusForbidden},
+	} {
+		if w := doReq(r, tc.method, tc.path, "", tc.who); w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/me", "", operator); !strings.Contains(w.Body.String(), `"role":"operator"`) {
+		t.Fatalf("me 返回不符: %s", w.Body.String())
+	}
+
+	// 角色调整对已有会话立即生效，删除账号后会话失效
+	acc, _ := store.AdminAccount("vic")
+	acc.Role = RoleOperator
+	if err := store.PutAdminAccount(acc); err != nil {
+		t.Fatal(err)
+	}
+	if w := doReq(r, http.MethodPost, "/api/admin/v1/users/u1/start", "", viewer); w.Code != http.StatusOK {
+		t.Fatalf("升级角色后应放行，got %d", w.Code)
+	}
+	if err := store.DeleteAdminAccount("vic"); err != nil {
+		t.Fatal(err)
+	}
+	if w := doReq(r, http.MethodGet, "/api/admin/v1/users", "", viewer); w.Code != http.StatusUnauthorized {
+		t.Fatalf("删除账号后会话应失效，got %d", w.Code)
+	}
+}
+
+func TestListUsersHidesSecretsFromNonAdmin(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "alice", Port: 18061, Env: map[string]string{"OPENAI_API_KEY": "sk-secret"}}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	for _, role := range []string{RoleViewer, RoleOperator, RoleAdmin} {
+		r := gin.New()
+		r.GET("/users", func(c *gin.Context) { c.Set(adminRoleKey, role) }, a.ListUsers)
+		w := doReq(r, http.MethodGet, "/users", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d", role, w.Code)
+		}
+		if got := strings.Contains(w.Body.String(), "sk-secret"); got != (role == RoleAdmin) {
+			t.Errorf("%s 可见 env = %v", role, got)
+		}
+	}
+}
+
+func TestProxyUserAPIRequiresRoleForInstancePath(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	for _, tc := range []struct {
+		role, method, path string
+		want               int
+	}{
+		{RoleOperator, http.MethodGet, "/users/alice/api/v1/login/cookies?reveal=true", http.StatusForbidden},
+		{RoleOperator, http.MethodPost, "/users/alice/api/v1/login/cookies/import-browser", http.StatusForbidden},
+		{RoleOperator, http.MethodGet, "/users/alice/api/v1/debug/devtools/version", http.StatusForbidden},
+		{RoleOperator, http.MethodGet, "/users/alice/api/v1/feeds/../login/cookies", http.StatusForbidden},
+		// 放行后因账号不存在返回 404
+		{RoleOperator, http.MethodGet, "/users/alice/api/v1/feeds/list", http.StatusNotFound},
+		{RoleAdmin, http.MethodGet, "/users/alice/api/v1/login/cookies", http.StatusNotFound},
+	} {
+		r := gin.New()
+		r.Any("/users/:id/api/*path", func(c *gin.Context) { c.Set(adminRoleKey, tc.role) }, a.ProxyUserAPI)
+		if w := doReq(r, tc.method, tc.path, "", nil); w.Code != tc.want {
+			t.Errorf("%s %s %s = %d, want %d", tc.role, tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 定时启停：账号可配置每天（或指定星期）的启动与停止时间，按账号时区执行，
+// 只在非工作时段关闭浏览器以节省资源。调度只在到点时动作一次，两个时间点之间的手动启动/停止保持有效；
+// 停止时间早于启动时间表示跨夜，停止发生在次日。
+
+// 定时动作
+const (
+	ScheduleStart = "start"
+	ScheduleStop  = "stop"
+)
+
+// scheduleTick 调度检查间隔
+var scheduleTick = 30 * time.Second
+
+// RunSchedule 账号的定时启停配置
+type RunSchedule struct {
+	Start string `json:"start,omitempty"` // 启动时间 HH:MM，为空不自动启动
+	Stop  string `json:"stop,omitempty"`  // 停止时间 HH:MM，为空不自动停止
+	Days  string `json:"days,omitempty"`  // 生效的星期，如 "mon-fri"、"sat,sun"，为空表示每天
+}
+
+// scheduleEvent 一次定时动作
+type scheduleEvent struct {
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock 解析 HH:MM，返回当天零点起的分钟数
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hour, err1 := strconv.Atoi(h)
+	minute, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 || len(h) != 2 || len(m) != 2 {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// parseDays 解析星期列表，支持逗号分隔与区间（如 "fri-mon" 跨周）
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	if strings.TrimSpace(s) == "" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		a, ok1 := weekdayNames[strings.TrimSpace(from)]
+		b, ok2 := a, ok1
+		if isRange {
+			b, ok2 = weekdayNames[strings.TrimSpace(to)]
+		}
+		if !ok1 || !ok2 {
+			return days, fmt.Errorf("days 取值非法: %q（使用 mon、tue…sun，可写成 mon-fri 或 sat,sun）", part)
+		}
+		for d := a; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == b {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// normalizeSchedule 去除空白，全部为空时视为未配置
+func normalizeSchedule(s *RunSchedule) *RunSchedule {
+	if s == nil {
+		return nil
+	}
+	out := RunSchedule{
+		Start: strings.TrimSpace(s.Start),
+		Stop:  strings.TrimSpace(s.Stop),
+		Days:  strings.TrimSpace(s.Days),
+	}
+	if out == (RunSchedule{}) {
+		return nil
+	}
+	return &out
+}
+
+func validateSchedule(s *RunSchedule) error {
+	if s == nil {
+		return nil
+	}
+	if s.Start == "" && s.Stop == "" {
+		return fmt.Errorf("schedule 至少需要 start 或 stop")
+	}
+	if _, err := s.compile(); err != nil {
+		return fmt.Errorf("schedule 非法: %w", err)
+	}
+	return nil
+}
+
+// compiledSchedule 解析后的定时配置，分钟数为 -1 表示未设置
+type compiledSchedule struct {
+	start, stop int
+	days        [7]bool
+}
+
+func (s RunSchedule) compile() (compiledSchedule, error) {
+	c := compiledSchedule{start: -1, stop: -1}
+	var err error
+	if s.Start != "" {
+		if c.start, err = parseClock(s.Start); err != nil {
+			return c, err
+		}
+	}
+	if s.Stop != "" {
+		if c.stop, err = parseClock(s.Stop); err != nil {
+			return c, err
+		}
+	}
+	if c.start >= 0 && c.start == c.stop {
+		return c, fmt.Errorf("start 与 stop 不能相同")
+	}
+	c.days, err = parseDays(s.Days)
+	return c, err
+}
+
+// eventsOn 以 day 当天为起点的动作：生效日的启动，以及该日窗口的停止（跨夜时在次日）
+func (c compiledSchedule) eventsOn(day time.Time) []scheduleEvent {
+	if !c.days[day.Weekday()] {
+		return nil
+	}
+	at := func(minutes int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+	}
+	var out []scheduleEvent
+	if c.start >= 0 {
+		out = append(out, scheduleEvent{Action: ScheduleStart, At: at(c.start)})
+	}
+	if c.stop >= 0 {
+		stop := at(c.stop)
+		if c.start > c.stop {
+			stop = time.Date(day.Year(), day.Month(), day.Day()+1, c.stop/60, c.stop%60, 0, 0, day.Location())
+		}
+		out = append(out, scheduleEvent{Action: ScheduleStop, At: stop})
+	}
+	return out
+}
+
+// due 返回 (from, to] 内最后一个到点的动作，没有时返回 nil
+func (c compiledSchedule) due(from, to time.Time) *scheduleEvent {
+	var last *scheduleEvent
+	for day := startOfDay(from).AddDate(0, 0, -1); !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, ev := range c.eventsOn(day) {
+			if ev.At.After(from) && !ev.At.After(to) && (last == nil || ev.At.After(last.At)) {
+				ev := ev
+				last = &ev
+			}
+		}
+	}
+	return last
+}
+
+// next 返回 now 之后的第一个动作
+func (c compiledSchedule) next(now time.Time) *scheduleEvent {
+	var first *scheduleEvent
+	for day := startOfDay(now).AddDate(0, 0, -1); day.Before(now.AddDate(0, 0, 8)); day = day.AddDate(0, 0, 1) {
+		for _, ev := range c.eventsOn(day) {
+			if ev.At.After(now) && (first == nil || ev.At.Before(first.At)) {
+				ev := ev
+				first = &ev
+			}
+		}
+	}
+	return first
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// userLocation 账号时区，未设置或无法加载时使用服务器时区
+func userLocation(u UserConfig) *time.Location {
+	if u.Timezone != "" {
+		if loc, err := time.LoadLocation(u.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// nextScheduleEvent 账号的下一次定时动作，未配置时返回 nil
+func nextScheduleEvent(u UserConfig, now time.Time) *scheduleEvent {
+	if u.Schedule == nil {
+		return nil
+	}
+	c, err := u.Schedule.compile()
+	if err != nil {
+		return nil
+	}
+	return c.next(now.In(userLocation(u)))
+}
+
+// StartScheduler 启动定时启停调度
+func (a *App) StartScheduler(ctx context.Context) {
+	go func() {
+		last := time.Now()
+		ticker := time.NewTicker(scheduleTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				now := time.Now()
+				a.runSchedules(ctx, last, now)
+				last = now
+			}
+		}
+	}()
+}
+
+// runSchedules 执行 (from, to] 内到点的定时动作
+func (a *App) runSchedules(ctx context.Context, from, to time.Time) {
+	for _, u := range a.store.ListUsers() {
+		if u.Schedule == nil || !a.manages(u.ID) {
+			continue
+		}
+		c, err := u.Schedule.compile()
+		if err != nil {
+			continue
+		}
+		loc := userLocation(u)
+		ev := c.due(from.In(loc), to.In(loc))
+		if ev == nil {
+			continue
+		}
+		if err := a.runScheduleAction(ctx, u, ev.Action); err != nil {
+			fmt.Printf("用户 %s 定时%s失败: %v\n", u.ID, scheduleActionName(ev.Action), err)
+		}
+	}
+}
+
+func scheduleActionName(action string) string {
+	if action == ScheduleStart {
+		return "启动"
+	}
+	return "停止"
+}
+
+// runScheduleAction 与手动启动/停止相同，同时更新 AutoStart，使 manager 重启后保持定时设定的状态
+func (a *App) runScheduleAction(ctx context.Context, u UserConfig, action string) error {
+	running := a.proc.GetStatus(u.ID).Running
+	switch {
+	case action == ScheduleStart && !running:
+		startCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+		defer cancel()
+		cfg := a.store.GetConfig()
+		if err := a.proc.StartUser(startCtx, StartUserParams{
+			User:     u,
+			BinPath:  a.store.ResolveBinPath(),
+			Headless: cfg.Headless,
+			DataDir:  a.store.UserDataDir(u),
+		}); err != nil {
+			a.proc.publishAutoStartFailed(u.ID, triggerSchedule, err)
+			return err
+		}
+	case action == ScheduleStop && running:
+		if err := a.proc.StopUser(ctx, u.ID, 0); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	fmt.Printf("用户 %s 已按计划%s\n", u.ID, scheduleActionName(action))
+	return a.store.SetUserAutoStart(u.ID, action == ScheduleStart)
+}
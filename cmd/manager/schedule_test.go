@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDays(t *testing.T) {
+	days, err := parseDays("mon-fri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !days[time.Monday] || !days[time.Friday] || days[time.Saturday] || days[time.Sunday] {
+		t.Fatalf("mon-fri = %v", days)
+	}
+	days, err = parseDays("fri-mon, wed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !days[time.Saturday] || !days[time.Sunday] || !days[time.Wednesday] || days[time.Tuesday] {
+		t.Fatalf("fri-mon,wed = %v", days)
+	}
+	for _, bad := range []string{"monday", "mon-", "x,y"} {
+		if _, err := parseDays(bad); err == nil {
+			t.Errorf("parseDays(%q) 应报错", bad)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for _, s := range []RunSchedule{
+		{},
+		{Start: "8:00"},
+		{Start: "24:00"},
+		{Start: "08:00", Stop: "08:00"},
+		{Stop: "23:00", Days: "someday"},
+	} {
+		if err := validateSchedule(&s); err == nil {
+			t.Errorf("%+v 应校验失败", s)
+		}
+	}
+	if err := validateSchedule(&RunSchedule{Start: "08:00", Stop: "23:00", Days: "mon-fri"}); err != nil {
+		t.Fatal(err)
+	}
+	if normalizeSchedule(&RunSchedule{Days: " "}) != nil {
+		t.Fatal("空配置应视为未配置")
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	c, err := RunSchedule{Start: "08:00", Stop: "23:00", Days: "mon-fri"}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-16 是星期五
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, loc) }
+	cases := []struct {
+		from, to time.Time
+		want     string
+	}{
+		{at(16, 7, 59), at(16, 8, 0), ScheduleStart},
+		{at(16, 8, 0), at(16, 8, 1), ""},
+		{at(16, 22, 59), at(16, 23, 0), ScheduleStop},
+		{at(17, 7, 59), at(17, 8, 0), ""},            // 周六不启动
+		{at(16, 7, 0), at(16, 23, 30), ScheduleStop}, // 多个动作取最后一个
+	}
+	for _, tc := range cases {
+		ev := c.due(tc.from, tc.to)
+		got := ""
+		if ev != nil {
+			got = ev.Action
+		}
+		if got != tc.want {
+			t.Errorf("due(%s, %s) = %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+
+	next := c.next(at(16, 23, 30))
+	if next == nil || next.Action != ScheduleStart || !next.At.Equal(at(19, 8, 0)) {
+		t.Fatalf("next = %+v, want 周一 08:00 启动", next)
+	}
+}
+
+func TestScheduleOvernight(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	c, err := RunSchedule{Start: "22:00", Stop: "06:00", Days: "fri"}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 周五 22:00 启动的窗口在周六 06:00 停止
+	ev := c.due(time.Date(2026, 10, 17, 5, 59, 0, 0, loc), time.Date(2026, 10, 17, 6, 0, 0, 0, loc))
+	if ev == nil || ev.Action != ScheduleStop {
+		t.Fatalf("周六 06:00 应停止, got %+v", ev)
+	}
+	// 周四 22:00 不在生效日，周五 06:00 也不停止
+	if ev := c.due(time.Date(2026, 10, 16, 5, 59, 0, 0, loc), time.Date(2026, 10, 16, 6, 0, 0, 0, loc)); ev != nil {
+		t.Fatalf("周五 06:00 不应有动作, got %+v", ev)
+	}
+}
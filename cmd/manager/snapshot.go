@@ -0,0 +1,400 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// 浏览器数据快照：账号停止时保存浏览器用户数据目录（不含缓存）与 cookies 的副本，
+// 之后可回滚到该时间点，用于自动化运行损坏了浏览器数据或登录状态异常时恢复。
+// 快照保存在 <data_dir>/snapshots/<id>/ 下：<快照ID>.tar.gz 为数据（格式同账号数据导出），
+// <快照ID>.json 为说明。每个账号最多保留 maxUserSnapshots 个，超出时删除最旧的。
+
+const (
+	maxUserSnapshots    = 10
+	maxSnapshotNoteLen  = 200
+	snapshotTimeLayout  = "20060102-150405"
+	snapshotArchiveExt  = ".tar.gz"
+	snapshotRestoreTemp = ".restore"
+)
+
+// snapshotIDPattern 快照 ID：创建时间，同一秒内多次创建时追加序号
+var snapshotIDPattern = regexp.MustCompile(`^\d{8}-\d{6}(-\d+)?$`)
+
+// Snapshot 快照说明
+type Snapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Note      string    `json:"note,omitempty"`
+	SizeBytes int64     `json:"size_bytes"`
+	Cookies   bool      `json:"cookies"` // 是否包含 cookies 文件
+}
+
+// listSnapshots 按创建时间倒序列出快照
+func listSnapshots(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, err
+	}
+	out := []Snapshot{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || !snapshotIDPattern.MatchString(id) {
+			continue
+		}
+		s, err := readSnapshot(dir, id)
+		if err != nil {
+			continue // 数据已丢失或说明损坏的快照不列出
+		}
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(x, y Snapshot) int {
+		if r := y.CreatedAt.Compare(x.CreatedAt); r != 0 {
+			return r
+		}
+		return strings.Compare(y.ID, x.ID)
+	})
+	return out, nil
+}
+
+// readSnapshot 读取快照说明，数据文件不存在时返回 os.ErrNotExist
+func readSnapshot(dir, id string) (Snapshot, error) {
+	var s Snapshot
+	if _, err := os.Stat(filepath.Join(dir, id+snapshotArchiveExt)); err != nil {
+		return s, err
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return s, err
+	}
+	s.ID = id
+	return s, nil
+}
+
+// createSnapshot 保存账号当前的浏览器数据与 cookies，调用方需确认账号已停止。
+// keep 为不参与清理的快照（恢复前自动保存时为将要恢复的快照）
+func createSnapshot(u UserConfig, paths DerivedPaths, note, keep string) (Snapshot, error) {
+	if err := os.MkdirAll(paths.SnapshotDir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	now := time.Now()
+	id := now.Format(snapshotTimeLayout)
+	// 同一秒内已有快照时取更大的序号，已删除快照的 ID 不会被复用
+	seq := 0
+	matches, _ := filepath.Glob(filepath.Join(paths.SnapshotDir, id+"*"+snapshotArchiveExt))
+	for _, m := range matches {
+		name := strings.TrimSuffix(filepath.Base(m), snapshotArchiveExt)
+		n := 1
+		if suffix, ok := strings.CutPrefix(name, id+"-"); ok {
+			n, _ = strconv.Atoi(suffix)
+		}
+		seq = max(seq, n)
+	}
+	if seq > 0 {
+		id = fmt.Sprintf("%s-%d", id, seq+1)
+	}
+	archive := filepath.Join(paths.SnapshotDir, id+snapshotArchiveExt)
+
+	tmp := archive + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("创建快照失败: %w", err)
+	}
+	err = writeUserExport(f, u, paths, userExportOptions{})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, archive)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("创建快照失败: %w", err)
+	}
+
+	_, cookiesErr := os.Stat(paths.CookiesPath)
+	s := Snapshot{ID: id, CreatedAt: now, Note: note, SizeBytes: dirSize(archive), Cookies: cookiesErr == nil}
+	raw, _ := json.MarshalIndent(s, "", "  ")
+	if err := fileutil.WriteFileAtomic(filepath.Join(paths.SnapshotDir, id+".json"), raw, 0644); err != nil {
+		_ = os.Remove(archive)
+		return Snapshot{}, fmt.Errorf("写入快照说明失败: %w", err)
+	}
+	pruneSnapshots(paths.SnapshotDir, id, keep)
+	return s, nil
+}
+
+// pruneSnapshots 只保留最新的 maxUserSnapshots 个快照
+func pruneSnapshots(dir string, keep ...string) {
+	list, err := listSnapshots(dir)
+	if err != nil {
+		return
+	}
+	for _, s := range list[min(maxUserSnapshots, len(list)):] {
+		if !slices.Contains(keep, s.ID) {
+			_ = removeSnapshot(dir, s.ID)
+		}
+	}
+}
+
+// removeSnapshot 删除快照的数据与说明
+func removeSnapshot(dir, id string) error {
+	if err := os.Remove(filepath.Join(dir, id+snapshotArchiveExt)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// extractSnapshot 把快照中的浏览器数据与 cookies 解压到 staging，返回是否包含 cookies
+func extractSnapshot(archive, staging string) (bool, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return false, fmt.Errorf("快照已损坏: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	hasCookies := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("快照已损坏: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		var dest string
+		switch {
+		case hdr.Name == "user.json":
+			continue
+		case hdr.Name == "cookies.json":
+			dest, hasCookies = filepath.Join(staging, "cookies.json"), true
+		case strings.HasPrefix(hdr.Name, "profile/"):
+			rel := path.Clean(strings.TrimPrefix(hdr.Name, "profile/"))
+			if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+				return false, fmt.Errorf("快照包含非法路径: %s", hdr.Name)
+			}
+			dest = filepath.Join(staging, "profile", filepath.FromSlash(rel))
+		default:
+			return false, fmt.Errorf("快照包含未知条目: %s", hdr.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return false, err
+		}
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm()|0600)
+		if err != nil {
+			return false, err
+		}
+		_, err = io.Copy(out, tr)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return false, fmt.Errorf("解压快照失败: %w", err)
+		}
+		_ = os.Chtimes(dest, hdr.ModTime, hdr.ModTime)
+	}
+	return hasCookies, nil
+}
+
+// restoreSnapshot 用快照替换账号当前的浏览器数据与 cookies，调用方需确认账号已停止。
+// 先完整解压到临时目录再替换，替换失败时恢复原数据
+func restoreSnapshot(paths DerivedPaths, id string) error {
+	staging := filepath.Join(paths.SnapshotDir, snapshotRestoreTemp)
+	_ = os.RemoveAll(staging)
+	defer os.RemoveAll(staging)
+	hasCookies, err := extractSnapshot(filepath.Join(paths.SnapshotDir, id+snapshotArchiveExt), staging)
+	if err != nil {
+		return err
+	}
+
+	// 快照中不存在的数据（如创建时尚未登录）恢复后也不存在
+	type swap struct{ src, dst, old string }
+	swaps := []swap{{filepath.Join(staging, "profile"), paths.UserDataDir, filepath.Join(staging, "old-profile")}}
+	if hasCookies {
+		swaps = append(swaps, swap{filepath.Join(staging, "cookies.json"), paths.CookiesPath, filepath.Join(staging, "old-cookies.json")})
+	} else {
+		swaps = append(swaps, swap{"", paths.CookiesPath, filepath.Join(staging, "old-cookies.json")})
+	}
+	var done []swap
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			s := done[i]
+			_ = os.RemoveAll(s.dst)
+			if _, err := os.Lstat(s.old); err == nil {
+				_ = movePath(s.old, s.dst)
+			}
+		}
+	}
+	for _, s := range swaps {
+		if _, err := os.Lstat(s.dst); err == nil {
+			if err := movePath(s.dst, s.old); err != nil {
+				rollback()
+				return fmt.Errorf("替换 %s 失败: %w", s.dst, err)
+			}
+		}
+		done = append(done, s)
+		if s.src == "" {
+			continue
+		}
+		if _, err := os.Lstat(s.src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := movePath(s.src, s.dst); err != nil {
+			rollback()
+			return fmt.Errorf("替换 %s 失败: %w", s.dst, err)
+		}
+	}
+	return nil
+}
+
+// snapshotUser 查找账号与快照，失败时已写入响应
+func (a *App) snapshotUser(c *gin.Context, needStopped bool) (UserConfig, DerivedPaths, bool) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return user, DerivedPaths{}, false
+	}
+	if needStopped {
+		if st := a.proc.GetStatus(user.ID); st.Running || st.State.active() {
+			c.JSON(http.StatusConflict, gin.H{"error": "用户进程运行中，请先停止，避免浏览器数据不一致"})
+			return user, DerivedPaths{}, false
+		}
+	}
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), user.ID, user.Port)
+	if sid := c.Param("sid"); sid != "" {
+		if !snapshotIDPattern.MatchString(sid) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "快照不存在"})
+			return user, DerivedPaths{}, false
+		}
+		if _, err := readSnapshot(paths.SnapshotDir, sid); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "快照不存在"})
+			return user, DerivedPaths{}, false
+		}
+	}
+	return user, paths, true
+}
+
+// ListUserSnapshots 列出账号的快照（最新的在前）
+// GET /api/admin/v1/users/:id/snapshots
+func (a *App) ListUserSnapshots(c *gin.Context) {
+	_, paths, ok := a.snapshotUser(c, false)
+	if !ok {
+		return
+	}
+	list, err := listSnapshots(paths.SnapshotDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshots": list, "max": maxUserSnapshots})
+}
+
+type createSnapshotReq struct {
+	Note string `json:"note"`
+}
+
+// CreateUserSnapshot 保存账号当前的浏览器数据与 cookies
+// POST /api/admin/v1/users/:id/snapshots
+func (a *App) CreateUserSnapshot(c *gin.Context) {
+	var req createSnapshotReq
+	if body, err := c.GetRawData(); err != nil || (len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &req) != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if utf8.RuneCountInString(req.Note) > maxSnapshotNoteLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("note 最多 %d 个字符", maxSnapshotNoteLen)})
+		return
+	}
+	user, paths, ok := a.snapshotUser(c, true)
+	if !ok {
+		return
+	}
+	a.snapMu.Lock()
+	defer a.snapMu.Unlock()
+	s, err := createSnapshot(user, paths, req.Note, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fmt.Printf("用户 %s 已创建快照 %s（%s）\n", user.ID, s.ID, formatBytes(s.SizeBytes))
+	c.JSON(http.StatusCreated, s)
+}
+
+// RestoreUserSnapshot 将账号的浏览器数据与 cookies 回滚到快照，
+// 默认先为当前数据自动创建一个快照，?backup=false 时不保存
+// POST /api/admin/v1/users/:id/snapshots/:sid/restore
+func (a *App) RestoreUserSnapshot(c *gin.Context) {
+	user, paths, ok := a.snapshotUser(c, true)
+	if !ok {
+		return
+	}
+	sid := c.Param("sid")
+	a.snapMu.Lock()
+	defer a.snapMu.Unlock()
+
+	var backup *Snapshot
+	if c.Query("backup") != "false" {
+		s, err := createSnapshot(user, paths, "恢复快照 "+sid+" 前自动保存", sid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		backup = &s
+	}
+	if err := restoreSnapshot(paths, sid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "backup": backup})
+		return
+	}
+	fmt.Printf("用户 %s 已恢复到快照 %s\n", user.ID, sid)
+	c.JSON(http.StatusOK, gin.H{"id": user.ID, "restored": sid, "backup": backup})
+}
+
+// DeleteUserSnapshot 删除快照
+// DELETE /api/admin/v1/users/:id/snapshots/:sid
+func (a *App) DeleteUserSnapshot(c *gin.Context) {
+	_, paths, ok := a.snapshotUser(c, false)
+	if !ok {
+		return
+	}
+	a.snapMu.Lock()
+	defer a.snapMu.Unlock()
+	if err := removeSnapshot(paths.SnapshotDir, c.Param("sid")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
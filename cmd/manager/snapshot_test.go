@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := LoadStore(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDataDir(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateUser(UserConfig{ID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id/snapshots", a.ListUserSnapshots)
+	r.POST("/users/:id/snapshots", a.CreateUserSnapshot)
+	r.POST("/users/:id/snapshots/:sid/restore", a.RestoreUserSnapshot)
+	r.DELETE("/users/:id/snapshots/:sid", a.DeleteUserSnapshot)
+
+	u, _ := store.GetUser("u1")
+	paths := a.proc.DerivePaths(store.UserDataDir(u), u.ID, u.Port)
+	loginDB := filepath.Join(paths.UserDataDir, "Default", "Cookies")
+	writeTestFile(t, paths.CookiesPath, `[{"name":"web_session","value":"good"}]`)
+	writeTestFile(t, loginDB, "good")
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "Default", "Cache", "data_0"), "cache")
+
+	w := doReq(r, http.MethodPost, "/users/u1/snapshots", `{"note":"登录后"}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snap); err != nil || snap.Note != "登录后" || !snap.Cookies {
+		t.Fatalf("snapshot = %+v, %v", snap, err)
+	}
+
+	// 模拟一次自动化运行把数据弄坏
+	writeTestFile(t, paths.CookiesPath, `[{"name":"web_session","value":"bad"}]`)
+	writeTestFile(t, loginDB, "bad")
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "Default", "Corrupted"), "x")
+
+	if w := doReq(r, http.MethodPost, "/users/u1/snapshots/"+snap.ID+"/restore", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	for p, want := range map[string]string{paths.CookiesPath: `[{"name":"web_session","value":"good"}]`, loginDB: "good"} {
+		if data, _ := os.ReadFile(p); string(data) != want {
+			t.Errorf("%s = %q, want %q", p, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(paths.UserDataDir, "Default", "Corrupted")); !os.IsNotExist(err) {
+		t.Error("恢复后不应保留快照之后新增的文件")
+	}
+
+	var list struct{ Snapshots []Snapshot }
+	w = doReq(r, http.MethodGet, "/users/u1/snapshots", "", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Snapshots) != 2 {
+		t.Fatalf("恢复前应自动保存当前数据: %s", w.Body.String())
+	}
+
+	if w := doReq(r, http.MethodPost, "/users/u1/snapshots/../restore", "", nil); w.Code == http.StatusOK {
+		t.Fatal("非法快照 ID 不应成功")
+	}
+	if w := doReq(r, http.MethodDelete, "/users/u1/snapshots/"+snap.ID, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	if w := doReq(r, http.MethodPost, "/users/u1/snapshots/"+snap.ID+"/restore", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("已删除的快照应返回 404，实际 %d", w.Code)
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	paths := NewProcessManager().DerivePaths(t.TempDir(), "u1", 18060)
+	var first string
+	for i := 0; i < maxUserSnapshots+2; i++ {
+		s, err := createSnapshot(UserConfig{ID: "u1"}, paths, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = s.ID
+		}
+	}
+	list, err := listSnapshots(paths.SnapshotDir)
+	if err != nil || len(list) != maxUserSnapshots {
+		t.Fatalf("len = %d, %v", len(list), err)
+	}
+	for _, s := range list {
+		if s.ID == first {
+			t.Fatal("最旧的快照应被删除")
+		}
+	}
+}
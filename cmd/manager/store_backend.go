@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// StoreBackend 配置的持久化方式。Store 负责校验和内存缓存，后端只负责整份配置的读写与加锁。
+// 内置 JSON 文件、SQLite（sqlite://）、Postgres（postgres://）和 Redis（redis://），
+// 其他实现通过 RegisterStoreBackend 按 scheme 注册。
+type StoreBackend interface {
+	Path() string                       // 用于日志展示的位置（远程后端已隐去密码）
+	LocalDir() string                   // 存放本地附属文件（如自签名证书）的目录，远程后端返回空
+	Lock() (func(), error)              // 写锁；远程后端的锁在多台主机之间互斥
+	Stamp() (storeStamp, error)         // 当前状态，用于识别外部修改；文件不存在时返回 os.ErrNotExist
+	Load() (ManagerConfig, bool, error) // 读取配置，false 表示尚未写入过
+	Save(cfg ManagerConfig) error
+	Close() error
+}
+
+// storeStamp JSON 文件用修改时间 + 大小、数据库类后端用写入版本号识别外部修改
+type storeStamp struct {
+	modTime time.Time
+	size    int64
+	version int64
+}
+
+func statStamp(path string) (storeStamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return storeStamp{}, err
+	}
+	return storeStamp{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]func(spec string) (StoreBackend, error){}
+)
+
+// RegisterStoreBackend 注册 scheme 对应的存储后端，-store 以 "<scheme>:" 开头时使用
+func RegisterStoreBackend(scheme string, open func(spec string) (StoreBackend, error)) {
+	backendsMu.Lock()
+	backends[strings.ToLower(scheme)] = open
+	backendsMu.Unlock()
+}
+
+// storeSchemes 已注册的 scheme
+func storeSchemes() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	out := make([]string, 0, len(backends))
+	for s := range backends {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// openStoreBackend 按 -store 参数打开存储：已注册 scheme 的地址，或 JSON 文件路径
+func openStoreBackend(spec string) (StoreBackend, error) {
+	if scheme, _, ok := strings.Cut(spec, ":"); ok && len(scheme) > 1 {
+		backendsMu.RLock()
+		open := backends[strings.ToLower(scheme)]
+		backendsMu.RUnlock()
+		if open != nil {
+			return open(spec)
+		}
+		if strings.Contains(spec, "://") {
+			return nil, fmt.Errorf("不支持的 store 类型 %q（可选 %s，或 JSON 文件路径）", scheme, strings.Join(storeSchemes(), "、"))
+		}
+	}
+	absPath, err := localStorePath(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonBackend{path: absPath}, nil
+}
+
+// localStorePath 解析本地存储文件的绝对路径并创建所在目录
+func localStorePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("解析 store 绝对路径失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return "", fmt.Errorf("创建 store 目录失败: %w", err)
+	}
+	return absPath, nil
+}
+
+// redactStoreURL 隐去地址中的密码，用于日志
+func redactStoreURL(spec string) string {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return spec
+	}
+	return u.Redacted()
+}
+
+// storeWatchInterval 检查其他进程/主机修改的间隔
+var storeWatchInterval = 5 * time.Second
+
+// StartWatch 定期检查存储是否被外部修改并重新加载；多台主机共享远程存储时，读操作也能看到其他主机的修改
+func (s *Store) StartWatch(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(storeWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if stamp, err := s.backend.Stamp(); err == nil && stamp != s.stamp {
+					if err := s.reloadIfChangedLocked(); err != nil {
+						fmt.Printf("重新加载 store 失败: %v\n", err)
+					}
+				}
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// jsonBackend JSON 文件存储，写入采用“临时文件 + rename”
+type jsonBackend struct {
+	path string
+}
+
+func (b *jsonBackend) Path() string { return b.path }
+
+func (b *jsonBackend) LocalDir() string { return filepath.Dir(b.path) }
+
+func (b *jsonBackend) Lock() (func(), error) { return fileutil.Lock(b.path + ".lock") }
+
+func (b *jsonBackend) Stamp() (storeStamp, error) { return statStamp(b.path) }
+
+func (b *jsonBackend) Load() (ManagerConfig, bool, error) {
+	raw, err := os.ReadFile(b.path)
+	if err != nil && !os.IsNotExist(err) {
+		return ManagerConfig{}, false, fmt.Errorf("读取 store 失败: %w", err)
+	}
+	if len(raw) == 0 {
+		return ManagerConfig{}, false, nil
+	}
+	cfg, err := decodeConfig(raw)
+	if err != nil {
+		return ManagerConfig{}, false, err
+	}
+	return cfg, true, nil
+}
+
+func (b *jsonBackend) Save(cfg ManagerConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 JSON 失败: %w", err)
+	}
+	if err := fileutil.ReplaceFile(b.path, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+func (b *jsonBackend) Close() error { return nil }
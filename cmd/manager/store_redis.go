@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	RegisterStoreBackend("redis", openRedisBackend)
+	RegisterStoreBackend("rediss", openRedisBackend)
+}
+
+const defaultRedisStorePrefix = "xhs-manager:"
+
+// redisOpTimeout 单次 Redis 操作超时
+var redisOpTimeout = 10 * time.Second
+
+// redisUnlockScript 仅删除自己持有的锁，避免锁过期后误删其他主机的锁
+var redisUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// redisBackend Redis 存储：<prefix>config 保存全局设置，<prefix>users 哈希按账号 ID 保存配置，
+// <prefix>version 为写入版本号，<prefix>lock 为多主机共享的写锁。
+// spec 形如 redis://[:password@]host:6379/0?prefix=xhs-manager:
+type redisBackend struct {
+	display string
+	client  *redis.Client
+	prefix  string
+}
+
+func openRedisBackend(spec string) (StoreBackend, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Redis 地址失败: %w", err)
+	}
+	// prefix 不是 go-redis 的参数，解析前移除
+	query := u.Query()
+	prefix := query.Get("prefix")
+	if prefix == "" {
+		prefix = defaultRedisStorePrefix
+	}
+	query.Del("prefix")
+	u.RawQuery = query.Encode()
+
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("解析 Redis 地址失败: %w", err)
+	}
+	b := &redisBackend{
+		display: redactStoreURL(spec),
+		client:  redis.NewClient(opts),
+		prefix:  prefix,
+	}
+	ctx, cancel := b.ctx()
+	defer cancel()
+	if err := b.client.Ping(ctx).Err(); err != nil {
+		_ = b.client.Close()
+		return nil, fmt.Errorf("连接 Redis 失败: %w", err)
+	}
+	return b, nil
+}
+
+func (b *redisBackend) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisOpTimeout)
+}
+
+func (b *redisBackend) key(name string) string { return b.prefix + name }
+
+func (b *redisBackend) Path() string { return b.display }
+
+func (b *redisBackend) LocalDir() string { return "" }
+
+// Lock SET NX 获取写锁并设置过期时间，持有者异常退出后锁自动释放
+func (b *redisBackend) Lock() (func(), error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(storeLockTimeout)
+	for {
+		ctx, cancel := b.ctx()
+		ok, err := b.client.SetNX(ctx, b.key("lock"), token, storeLockTimeout).Result()
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("获取 store 锁失败: %w", err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("获取 store 锁超时")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return func() {
+		ctx, cancel := b.ctx()
+		defer cancel()
+		_ = redisUnlockScript.Run(ctx, b.client, []string{b.key("lock")}, token).Err()
+	}, nil
+}
+
+func (b *redisBackend) Stamp() (storeStamp, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	version, err := b.client.Get(ctx, b.key("version")).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return storeStamp{}, err
+	}
+	return storeStamp{version: version}, nil
+}
+
+func (b *redisBackend) Load() (ManagerConfig, bool, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	var global *redis.StringCmd
+	var users *redis.MapStringStringCmd
+	_, err := b.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		global = p.Get(ctx, b.key("config"))
+		users = p.HGetAll(ctx, b.key("users"))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) && errors.Is(global.Err(), redis.Nil) {
+		return ManagerConfig{}, false, nil
+	}
+	if err != nil {
+		return ManagerConfig{}, false, fmt.Errorf("读取 store 失败: %w", err)
+	}
+	cfg, err := assembleConfig(global.Val(), users.Val())
+	if err != nil {
+		return ManagerConfig{}, false, err
+	}
+	return cfg, true, nil
+}
+
+func (b *redisBackend) Save(cfg ManagerConfig) error {
+	global, users, err := splitConfig(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := b.ctx()
+	defer cancel()
+	_, err = b.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, b.key("config"), global, 0)
+		p.Del(ctx, b.key("users"))
+		if len(users) > 0 {
+			p.HSet(ctx, b.key("users"), users)
+		}
+		p.Incr(ctx, b.key("version"))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入 store 失败: %w", err)
+	}
+	return nil
+}
+
+func (b *redisBackend) Close() error { return b.client.Close() }
+
+// redisAcquireLeaseScript 租约不存在或由自己持有时写入并设置过期时间；值为 {"holder","value","expires_at"} JSON
+var redisAcquireLeaseScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if cur and cjson.decode(cur).holder ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1`)
+
+// redisReleaseLeaseScript 仅删除自己持有的租约
+var redisReleaseLeaseScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+if cur and cjson.decode(cur).holder == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+type redisLease struct {
+	Holder    string `json:"holder"`
+	Value     string `json:"value"`
+	ExpiresAt int64  `json:"expires_at"` // 毫秒时间戳
+}
+
+// AcquireLease 获取、续期租约，过期由 Redis 自动删除
+func (b *redisBackend) AcquireLease(name, holder, value string, ttl time.Duration) (bool, error) {
+	raw, err := json.Marshal(redisLease{Holder: holder, Value: value, ExpiresAt: time.Now().Add(ttl).UnixMilli()})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := b.ctx()
+	defer cancel()
+	n, err := redisAcquireLeaseScript.Run(ctx, b.client, []string{b.key("lease:" + name)}, holder, raw, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("获取租约 %s 失败: %w", name, err)
+	}
+	return n == 1, nil
+}
+
+// ReleaseLease 释放自己持有的租约
+func (b *redisBackend) ReleaseLease(name, holder string) error {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	if err := redisReleaseLeaseScript.Run(ctx, b.client, []string{b.key("lease:" + name)}, holder).Err(); err != nil {
+		return fmt.Errorf("释放租约 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// ListLeases 名称以 prefix 开头的租约
+func (b *redisBackend) ListLeases(prefix string) ([]Lease, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	base := b.key("lease:")
+	var keys []string
+	iter := b.client.Scan(ctx, 0, base+prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("读取租约失败: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+	vals, err := b.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取租约失败: %w", err)
+	}
+	var out []Lease
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue // 读取前已过期
+		}
+		var l redisLease
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			continue
+		}
+		out = append(out, Lease{Name: strings.TrimPrefix(keys[i], base), Holder: l.Holder, Value: l.Value, ExpiresAt: time.UnixMilli(l.ExpiresAt)})
+	}
+	return out, nil
+}
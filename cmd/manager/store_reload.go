@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 外部修改的合并：手工编辑或自动化工具改写 store 后，StartWatch 或 POST /api/admin/v1/reload 会重新加载。
+// 由 manager 维护的字段（账号资料、登录检查结果、自动生成的 UA）在新内容中缺失时沿用内存中的值，
+// 避免按模板生成的配置冲掉这些数据；重新加载后同步日志轮转与 webhook 配置，
+// 已被移除但仍在运行的账号会被停止（否则无法再通过接口管理），配置有变化的运行中账号需重启后生效。
+
+// StoreChange 一次重新加载带来的变化
+type StoreChange struct {
+	Added           []string `json:"added"`
+	Removed         []string `json:"removed"`
+	Changed         []string `json:"changed"`
+	SettingsChanged bool     `json:"settings_changed"` // bin、data_dir、日志轮转、webhook 等全局配置
+}
+
+// Empty 是否没有变化
+func (c StoreChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0 && !c.SettingsChanged
+}
+
+// mergeManagedFields 新配置中缺失的 manager 维护字段沿用旧值
+func mergeManagedFields(old, next ManagerConfig) ManagerConfig {
+	prev := make(map[string]UserConfig, len(old.Users))
+	for _, u := range old.Users {
+		prev[u.ID] = u
+	}
+	users := make([]UserConfig, len(next.Users))
+	for i, u := range next.Users {
+		if p, ok := prev[u.ID]; ok {
+			if u.Profile == nil {
+				u.Profile = p.Profile
+			}
+			if u.Login == nil {
+				u.Login = p.Login
+			}
+			if u.UserAgent == "" {
+				u.UserAgent = p.UserAgent
+			}
+			if u.APIKey == "" {
+				u.APIKey = p.APIKey
+			}
+		}
+		users[i] = u
+	}
+	next.Users = users
+	return next
+}
+
+// diffConfig 比较两份配置
+func diffConfig(old, next ManagerConfig) StoreChange {
+	ch := StoreChange{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	prev := make(map[string]UserConfig, len(old.Users))
+	for _, u := range old.Users {
+		prev[u.ID] = u
+	}
+	for _, u := range next.Users {
+		p, ok := prev[u.ID]
+		switch {
+		case !ok:
+			ch.Added = append(ch.Added, u.ID)
+		case !jsonEqual(p, u):
+			ch.Changed = append(ch.Changed, u.ID)
+		}
+		delete(prev, u.ID)
+	}
+	for id := range prev {
+		ch.Removed = append(ch.Removed, id)
+	}
+	slices.Sort(ch.Removed)
+	old.Users, next.Users = nil, nil
+	ch.SettingsChanged = !jsonEqual(old, next)
+	return ch
+}
+
+func jsonEqual(a, b any) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(x) == string(y)
+}
+
+// OnReload 设置外部修改被自动加载后的回调，回调在单独的 goroutine 中执行
+func (s *Store) OnReload(fn func(StoreChange)) {
+	s.mu.Lock()
+	s.onReload = fn
+	s.mu.Unlock()
+}
+
+// Reload 立即从存储重新加载（不论是否检测到修改），返回变化
+func (s *Store) Reload() (StoreChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.backend.Lock()
+	if err != nil {
+		return StoreChange{}, err
+	}
+	defer unlock()
+
+	stamp, err := s.backend.Stamp()
+	if err != nil {
+		return StoreChange{}, fmt.Errorf("读取 store 状态失败: %w", err)
+	}
+	cfg, found, err := s.backend.Load()
+	if err != nil {
+		return StoreChange{}, fmt.Errorf("store 无法解析: %w", err)
+	}
+	if !found {
+		return StoreChange{}, fmt.Errorf("store 不存在: %s", s.backend.Path())
+	}
+	return s.applyLoadedLocked(cfg, stamp), nil
+}
+
+// applyLoadedLocked 合并并替换内存中的配置。调用方需持有 s.mu 写锁。
+func (s *Store) applyLoadedLocked(cfg ManagerConfig, stamp storeStamp) StoreChange {
+	cfg = mergeManagedFields(s.cfg, cfg)
+	ch := diffConfig(s.cfg, cfg)
+	s.cfg = cfg
+	s.stamp = stamp
+	return ch
+}
+
+// ApplyStoreChange 使重新加载的配置生效：同步全局配置，停止已被移除的运行中账号。
+// 返回被停止的账号与需要重启才能应用新配置的账号
+func (a *App) ApplyStoreChange(ch StoreChange) (stopped, restartRequired []string) {
+	stopped, restartRequired = []string{}, []string{}
+	if ch.SettingsChanged {
+		a.proc.SetLogRotation(a.store.LogRotation())
+		a.ApplyWebhooks()
+	}
+	for _, id := range ch.Removed {
+		if !a.proc.GetStatus(id).Running {
+			a.proc.Forget(id)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := a.proc.StopUser(ctx, id, 0)
+		cancel()
+		if err != nil {
+			fmt.Printf("停止已移除的用户 %s 失败: %v\n", id, err)
+			continue
+		}
+		a.proc.Forget(id)
+		fmt.Printf("用户 %s 已从 store 中移除，已停止其实例\n", id)
+		stopped = append(stopped, id)
+	}
+	for _, id := range ch.Changed {
+		if a.proc.GetStatus(id).Running {
+			restartRequired = append(restartRequired, id)
+		}
+	}
+	if len(restartRequired) > 0 {
+		fmt.Printf("以下运行中用户的配置已变化，重启后生效: %v\n", restartRequired)
+	}
+	return stopped, restartRequired
+}
+
+type reloadResponse struct {
+	StoreChange
+	Stopped         []string `json:"stopped"`          // 已被移除而停止的账号
+	RestartRequired []string `json:"restart_required"` // 运行中且配置有变化的账号
+}
+
+// ReloadStore 重新加载 store 并应用变化
+// POST /api/admin/v1/reload
+func (a *App) ReloadStore(c *gin.Context) {
+	ch, err := a.store.Reload()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stopped, restart := a.ApplyStoreChange(ch)
+	c.JSON(http.StatusOK, reloadResponse{StoreChange: ch, Stopped: stopped, RestartRequired: restart})
+}
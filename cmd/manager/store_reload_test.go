@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStoreReloadMergesExternalEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	store, err := LoadStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"u1", "u2"} {
+		if err := store.CreateUser(UserConfig{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SetUserProfile("u1", &AccountProfile{Nickname: "小红"}); err != nil {
+		t.Fatal(err)
+	}
+	u1, _ := store.GetUser("u1")
+
+	// 按模板改写：u1 改代理且没有 profile/user_agent，删除 u2，新增 u3
+	external := `{"bin":"./xiaohongshu-mcp","headless":true,"data_dir":"./data","users":[
+		{"id":"u1","port":` + strconv.Itoa(u1.Port) + `,"proxy":"127.0.0.1:7890"},
+		{"id":"u3","port":18999}]}`
+	if err := os.WriteFile(path, []byte(external), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ch, err := store.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ch.Added, []string{"u3"}) || !slices.Equal(ch.Removed, []string{"u2"}) ||
+		!slices.Equal(ch.Changed, []string{"u1"}) || ch.SettingsChanged {
+		t.Fatalf("变化不符: %+v", ch)
+	}
+	got, _ := store.GetUser("u1")
+	if got.Proxy != "127.0.0.1:7890" || got.Profile == nil || got.Profile.Nickname != "小红" || got.UserAgent != u1.UserAgent {
+		t.Fatalf("外部修改应生效且保留 manager 维护的字段: %+v", got)
+	}
+
+	// 写操作前检测到外部修改时触发回调
+	changes := make(chan StoreChange, 1)
+	store.OnReload(func(ch StoreChange) { changes <- ch })
+	time.Sleep(10 * time.Millisecond) // 保证修改时间变化
+	if err := os.WriteFile(path, []byte(`{"bin":"./other","data_dir":"./data","users":[{"id":"u1","port":`+strconv.Itoa(u1.Port)+`}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetUserAutoStart("u1", true); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ch := <-changes:
+		if !ch.SettingsChanged || !slices.Equal(ch.Removed, []string{"u3"}) {
+			t.Fatalf("回调变化不符: %+v", ch)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未触发重新加载回调")
+	}
+}
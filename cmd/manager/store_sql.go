@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq" // Postgres 驱动，纯 Go 实现
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+	_ "modernc.org/sqlite" // 纯 Go 实现，无需 cgo
+)
+
+func init() {
+	RegisterStoreBackend("sqlite", func(spec string) (StoreBackend, error) {
+		path := strings.TrimPrefix(strings.TrimPrefix(spec, "sqlite:"), "//")
+		if path == "" {
+			return nil, fmt.Errorf("sqlite store 缺少数据库路径")
+		}
+		absPath, err := localStorePath(path)
+		if err != nil {
+			return nil, err
+		}
+		return openSQLiteBackend(absPath)
+	})
+	RegisterStoreBackend("postgres", openPostgresBackend)
+	RegisterStoreBackend("postgresql", openPostgresBackend)
+}
+
+// storeLockTimeout 等待远程存储写锁的最长时间
+var storeLockTimeout = 30 * time.Second
+
+// sqlBackend 数据库存储：全局设置与每个账号各占一行，整份配置在一个事务内写入，
+// 避免 JSON 文件频繁整体重写。写入版本号保存在设置表中，用于识别其他进程/主机的修改。
+// SQLite 与 Postgres 共用该实现，SQL 中的 ? 占位符按方言改写。
+type sqlBackend struct {
+	display  string
+	localDir string
+	db       *sql.DB
+	settings string // 设置表名
+	users    string // 账号表名
+	leases   string // 集群租约表名
+	dollar   bool   // 占位符使用 $1, $2（Postgres）
+	lock     func() (func(), error)
+}
+
+// q 替换 SQL 中的 {settings}/{users} 表名，并按方言改写占位符
+func (b *sqlBackend) q(query string) string {
+	query = strings.NewReplacer("{settings}", b.settings, "{users}", b.users, "{leases}", b.leases).Replace(query)
+	if !b.dollar {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+const sqlStoreSchema = `
+CREATE TABLE IF NOT EXISTS {settings} (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS {users} (
+	id     TEXT PRIMARY KEY,
+	port   INTEGER NOT NULL,
+	config TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS {leases} (
+	name       TEXT PRIMARY KEY,
+	holder     TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	expires_at BIGINT NOT NULL
+);`
+
+func (b *sqlBackend) init() error {
+	for _, stmt := range strings.Split(b.q(sqlStoreSchema), ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := b.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func openSQLiteBackend(path string) (*sqlBackend, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开 SQLite 失败: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	b := &sqlBackend{
+		display:  path,
+		localDir: filepath.Dir(path),
+		db:       db,
+		settings: "settings",
+		users:    "users",
+		leases:   "leases",
+		lock:     func() (func(), error) { return fileutil.Lock(path + ".lock") },
+	}
+	if err := b.init(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化 SQLite 表失败: %w", err)
+	}
+	return b, nil
+}
+
+// openPostgresBackend 连接 Postgres，spec 为 lib/pq 支持的连接 URL。
+// 多台 manager 共享同一数据库时，写锁使用 advisory lock 在主机之间互斥。
+func openPostgresBackend(spec string) (StoreBackend, error) {
+	db, err := sql.Open("postgres", spec)
+	if err != nil {
+		return nil, fmt.Errorf("打开 Postgres 失败: %w", err)
+	}
+	db.SetMaxOpenConns(4)
+	b := &sqlBackend{
+		display:  redactStoreURL(spec),
+		db:       db,
+		settings: "xhs_manager_settings",
+		users:    "xhs_manager_users",
+		leases:   "xhs_manager_leases",
+		dollar:   true,
+	}
+	b.lock = b.advisoryLock
+	ctx, cancel := context.WithTimeout(context.Background(), storeLockTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("连接 Postgres 失败: %w", err)
+	}
+	if err := b.init(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化 Postgres 表失败: %w", err)
+	}
+	return b, nil
+}
+
+// advisoryLock 在独立连接上持有 pg_advisory_lock，释放时解锁并归还连接
+func (b *sqlBackend) advisoryLock() (func(), error) {
+	h := fnv.New64a()
+	h.Write([]byte(b.settings))
+	key := int64(h.Sum64())
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeLockTimeout)
+	defer cancel()
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取 store 锁失败: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("获取 store 锁失败: %w", err)
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+		_ = conn.Close()
+	}, nil
+}
+
+func (b *sqlBackend) Path() string { return b.display }
+
+func (b *sqlBackend) LocalDir() string { return b.localDir }
+
+func (b *sqlBackend) Lock() (func(), error) { return b.lock() }
+
+func (b *sqlBackend) Stamp() (storeStamp, error) {
+	var version int64
+	err := b.db.QueryRow(b.q(`SELECT CAST(value AS BIGINT) FROM {settings} WHERE key = 'version'`)).Scan(&version)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return storeStamp{}, err
+	}
+	return storeStamp{version: version}, nil
+}
+
+func (b *sqlBackend) Load() (ManagerConfig, bool, error) {
+	var global string
+	err := b.db.QueryRow(b.q(`SELECT value FROM {settings} WHERE key = 'config'`)).Scan(&global)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ManagerConfig{}, false, nil
+	}
+	if err != nil {
+		return ManagerConfig{}, false, fmt.Errorf("读取 store 失败: %w", err)
+	}
+
+	rows, err := b.db.Query(b.q(`SELECT id, config FROM {users} ORDER BY id`))
+	if err != nil {
+		return ManagerConfig{}, false, fmt.Errorf("读取用户失败: %w", err)
+	}
+	defer rows.Close()
+	users := map[string]string{}
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return ManagerConfig{}, false, err
+		}
+		users[id] = raw
+	}
+	if err := rows.Err(); err != nil {
+		return ManagerConfig{}, false, err
+	}
+	cfg, err := assembleConfig(global, users)
+	if err != nil {
+		return ManagerConfig{}, false, err
+	}
+	return cfg, true, nil
+}
+
+func (b *sqlBackend) Save(cfg ManagerConfig) error {
+	global, users, err := splitConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("写入 store 失败: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(b.q(`INSERT INTO {settings} (key, value) VALUES ('config', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`), global); err != nil {
+		return fmt.Errorf("写入全局配置失败: %w", err)
+	}
+	if _, err := tx.Exec(b.q(`DELETE FROM {users}`)); err != nil {
+		return fmt.Errorf("写入用户失败: %w", err)
+	}
+	for _, u := range cfg.Users {
+		if _, err := tx.Exec(b.q(`INSERT INTO {users} (id, port, config) VALUES (?, ?, ?)`), u.ID, u.Port, users[u.ID]); err != nil {
+			return fmt.Errorf("写入用户 %s 失败: %w", u.ID, err)
+		}
+	}
+	if _, err := tx.Exec(b.q(`INSERT INTO {settings} (key, value) VALUES ('version', '1')
+		ON CONFLICT(key) DO UPDATE SET value = CAST(CAST({settings}.value AS BIGINT) + 1 AS TEXT)`)); err != nil {
+		return fmt.Errorf("更新版本号失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("写入 store 失败: %w", err)
+	}
+	return nil
+}
+
+func (b *sqlBackend) Close() error { return b.db.Close() }
+
+// AcquireLease 插入租约，或在自己持有/已过期时续期、接管
+func (b *sqlBackend) AcquireLease(name, holder, value string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := b.db.Exec(b.q(`INSERT INTO {leases} (name, holder, value, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, value = excluded.value, expires_at = excluded.expires_at
+		WHERE {leases}.holder = excluded.holder OR {leases}.expires_at < ?`),
+		name, holder, value, now.Add(ttl).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, fmt.Errorf("获取租约 %s 失败: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseLease 释放自己持有的租约
+func (b *sqlBackend) ReleaseLease(name, holder string) error {
+	if _, err := b.db.Exec(b.q(`DELETE FROM {leases} WHERE name = ? AND holder = ?`), name, holder); err != nil {
+		return fmt.Errorf("释放租约 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// ListLeases 名称以 prefix 开头、未过期的租约
+func (b *sqlBackend) ListLeases(prefix string) ([]Lease, error) {
+	rows, err := b.db.Query(b.q(`SELECT name, holder, value, expires_at FROM {leases}
+		WHERE substr(name, 1, ?) = ? AND expires_at >= ? ORDER BY name`), len(prefix), prefix, time.Now().UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("读取租约失败: %w", err)
+	}
+	defer rows.Close()
+	var out []Lease
+	for rows.Next() {
+		var l Lease
+		var expires int64
+		if err := rows.Scan(&l.Name, &l.Holder, &l.Value, &expires); err != nil {
+			return nil, err
+		}
+		l.ExpiresAt = time.UnixMilli(expires)
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// splitConfig 拆分为全局设置与每个账号的 JSON，供按行/按字段存储的后端使用
+func splitConfig(cfg ManagerConfig) (string, map[string]string, error) {
+	global := cfg
+	global.Users = nil
+	globalJSON, err := json.Marshal(global)
+	if err != nil {
+		return "", nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	users := make(map[string]string, len(cfg.Users))
+	for _, u := range cfg.Users {
+		raw, err := json.Marshal(u)
+		if err != nil {
+			return "", nil, fmt.Errorf("序列化用户 %s 失败: %w", u.ID, err)
+		}
+		users[u.ID] = string(raw)
+	}
+	return string(globalJSON), users, nil
+}
+
+// assembleConfig splitConfig 的逆操作，并校验、填充默认值
+func assembleConfig(global string, users map[string]string) (ManagerConfig, error) {
+	var cfg ManagerConfig
+	if err := json.Unmarshal([]byte(global), &cfg); err != nil {
+		return ManagerConfig{}, fmt.Errorf("解析全局配置失败: %w", err)
+	}
+	cfg.Users = make([]UserConfig, 0, len(users))
+	for id, raw := range users {
+		var u UserConfig
+		if err := json.Unmarshal([]byte(raw), &u); err != nil {
+			return ManagerConfig{}, fmt.Errorf("解析用户 %s 失败: %w", id, err)
+		}
+		cfg.Users = append(cfg.Users, u)
+	}
+	if err := normalizeConfig(&cfg); err != nil {
+		return ManagerConfig{}, err
+	}
+	return cfg, nil
+}
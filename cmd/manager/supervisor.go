@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 进程守护：实例运行中退出后按账号的重启策略自动拉起，重启间隔指数退避，
+// 连续重启超过上限后放弃。手动启动/停止会取消等待中的重启；实例稳定运行一段时间后连续次数清零。
+
+// 重启策略
+const (
+	RestartNever     = "never"      // 不自动重启（默认）
+	RestartOnFailure = "on-failure" // 非 0 退出码或被信号终止时重启
+	RestartAlways    = "always"     // 任何意外退出都重启
+)
+
+const defaultMaxRestarts = 5
+
+var (
+	restartBackoffBase = time.Second
+	restartBackoffMax  = 5 * time.Minute
+	restartStableAfter = 10 * time.Minute // 运行超过该时长后退出，连续重启次数重新计算
+)
+
+func validateRestartPolicy(policy string, maxRestarts int) error {
+	switch policy {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("restart 只能是 never/on-failure/always")
+	}
+	if maxRestarts > 1000 {
+		return fmt.Errorf("max_restarts 最大 1000")
+	}
+	return nil
+}
+
+// restartBackoff 第 n 次（从 0 开始）连续重启前的等待时间
+func restartBackoff(n int) time.Duration {
+	d := restartBackoffBase
+	for i := 0; i < n && d < restartBackoffMax; i++ {
+		d *= 2
+	}
+	if d > restartBackoffMax {
+		d = restartBackoffMax
+	}
+	return d
+}
+
+// superviseState 单个账号的守护状态，受 ProcessManager.mu 保护
+type superviseState struct {
+	restarts int       // 自动重启总次数
+	attempts int       // 连续重启次数
+	nextAt   time.Time // 下次重启时间，为零表示没有等待中的重启
+	gaveUp   bool      // 已达到最大重启次数
+	gen      int       // 手动启动/停止时递增，使等待中的重启失效
+	cancel   chan struct{}
+}
+
+func (pm *ProcessManager) superviseLocked(userID string) *superviseState {
+	st := pm.supervise[userID]
+	if st == nil {
+		st = &superviseState{}
+		pm.supervise[userID] = st
+	}
+	return st
+}
+
+// cancelRestartLocked 取消等待中的重启；reset 为 true 时（手动启动）清零连续次数
+func (pm *ProcessManager) cancelRestartLocked(userID string, reset bool) {
+	st := pm.supervise[userID]
+	if st == nil {
+		return
+	}
+	st.gen++
+	if st.cancel != nil {
+		close(st.cancel)
+		st.cancel = nil
+	}
+	st.nextAt = time.Time{}
+	if reset {
+		st.attempts = 0
+		st.gaveUp = false
+	}
+}
+
+func (pm *ProcessManager) cancelRestart(userID string, reset bool) {
+	pm.mu.Lock()
+	pm.cancelRestartLocked(userID, reset)
+	pm.mu.Unlock()
+}
+
+// onUnexpectedExitLocked 运行中的实例意外退出，按策略安排重启。调用方需持有 pm.mu。
+func (pm *ProcessManager) onUnexpectedExitLocked(userID string, p *runningProc, failed bool) {
+	policy := p.params.User.Restart
+	if userID == "" || pm.closing || !(policy == RestartAlways || (policy == RestartOnFailure && failed)) {
+		return
+	}
+	st := pm.superviseLocked(userID)
+	if time.Since(p.startedAt) >= restartStableAfter {
+		st.attempts = 0
+	}
+	pm.scheduleRestartLocked(userID, st, p.params)
+}
+
+// scheduleRestartLocked 退避后重启，超过最大次数时放弃。调用方需持有 pm.mu。
+func (pm *ProcessManager) scheduleRestartLocked(userID string, st *superviseState, params StartUserParams) {
+	maxRestarts := params.User.MaxRestarts
+	if maxRestarts == 0 {
+		maxRestarts = defaultMaxRestarts
+	}
+	if maxRestarts > 0 && st.attempts >= maxRestarts {
+		st.gaveUp = true
+		st.nextAt = time.Time{}
+		fmt.Printf("用户 %s 已连续自动重启 %d 次，不再重启\n", userID, st.attempts)
+		return
+	}
+	delay := restartBackoff(st.attempts)
+	st.attempts++
+	st.nextAt = time.Now().Add(delay)
+	st.cancel = make(chan struct{})
+	go pm.restartAfter(userID, params, delay, st.gen, st.cancel)
+}
+
+func (pm *ProcessManager) restartAfter(userID string, params StartUserParams, delay time.Duration, gen int, cancel <-chan struct{}) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-cancel:
+		return
+	case <-timer.C:
+	}
+
+	pm.mu.Lock()
+	st := pm.supervise[userID]
+	if pm.closing || st == nil || st.gen != gen {
+		pm.mu.Unlock()
+		return
+	}
+	st.cancel = nil
+	st.nextAt = time.Time{}
+	st.restarts++
+	attempt := st.attempts
+	pm.mu.Unlock()
+
+	pm.metrics.inc(userID, func(c *procCounters) { c.autoRestarts++ })
+	fmt.Printf("用户 %s 自动重启（连续第 %d 次）\n", userID, attempt)
+	err := pm.startUser(context.Background(), params)
+	if err == nil {
+		return
+	}
+	fmt.Printf("用户 %s 自动重启失败: %v\n", userID, err)
+	pm.publishAutoStartFailed(userID, triggerRestart, err)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if p := pm.procs[userID]; pm.closing || st.gen != gen || (p != nil && (p.state.active() || p.alive())) {
+		return
+	}
+	pm.scheduleRestartLocked(userID, st, params)
+}
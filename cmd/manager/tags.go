@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 账号分组标签：如 "brand-a"、"test"。标签只用于管理时选择账号（列表过滤、批量启停），
+// 不影响实例运行，因此运行中的账号也可以修改标签。
+
+const (
+	maxUserTags  = 20
+	maxTagLength = 64
+)
+
+// normalizeTags 去除空白与重复标签，保持原有顺序
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func validateTags(tags []string) error {
+	if len(tags) > maxUserTags {
+		return fmt.Errorf("tags 最多 %d 个", maxUserTags)
+	}
+	for _, t := range tags {
+		if len(t) > maxTagLength || !validIDRegex.MatchString(t) {
+			return fmt.Errorf("标签 %q 非法：只能包含字母、数字、下划线、连字符，最长 %d 字符", t, maxTagLength)
+		}
+	}
+	return nil
+}
+
+// hasAnyTag 账号是否带有 tags 中任一标签
+func hasAnyTag(u UserConfig, tags []string) bool {
+	for _, t := range tags {
+		if slices.Contains(u.Tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveBatchIDs 批量操作的目标账号：指定 ids、按标签选择或全部账号
+func resolveBatchIDs(req batchReq, users []UserConfig) ([]string, error) {
+	tags := normalizeTags(req.Tags)
+	if len(req.IDs) > 0 && len(tags) > 0 {
+		return nil, fmt.Errorf("ids 与 tags 不能同时指定")
+	}
+	if len(req.IDs) > 0 {
+		return req.IDs, nil
+	}
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		if len(tags) == 0 || hasAnyTag(u, tags) {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
+// SetUserTags 仅更新账号标签
+func (s *Store) SetUserTags(id string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.beginWriteLocked()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tags = normalizeTags(tags)
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+	for i := range s.cfg.Users {
+		if s.cfg.Users[i].ID == id {
+			s.cfg.Users[i].Tags = tags
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("用户不存在: %s", id)
+}
+
+type userTagsReq struct {
+	Tags []string `json:"tags"` // 空数组表示清除全部标签
+}
+
+// UpdateUserTags 修改账号标签，运行中也可修改
+// PUT /api/admin/v1/users/:id/tags
+func (a *App) UpdateUserTags(c *gin.Context) {
+	var req userTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	id := c.Param("id")
+	if _, ok := a.store.GetUser(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if err := a.store.SetUserTags(id, req.Tags); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	u, _ := a.store.GetUser(id)
+	c.JSON(http.StatusOK, gin.H{"id": id, "tags": tagsOrEmpty(u.Tags)})
+}
+
+// ListTags 全部标签及各自的账号
+// GET /api/admin/v1/tags
+func (a *App) ListTags(c *gin.Context) {
+	tags := map[string][]string{}
+	for _, u := range a.store.ListUsers() {
+		for _, t := range u.Tags {
+			tags[t] = append(tags[t], u.ID)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
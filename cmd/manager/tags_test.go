@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserTagsAndBatchSelector(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users", a.ListUsers)
+	r.POST("/users", a.CreateUser)
+	r.PUT("/users/:id/tags", a.UpdateUserTags)
+	r.POST("/users/batch/stop", a.BatchStopUsers)
+
+	for _, body := range []string{
+		`{"id":"a1","port":18060,"tags":["brand-a"," brand-a ",""]}`,
+		`{"id":"a2","port":18061}`,
+		`{"id":"t1","port":18062,"tags":["test"]}`,
+	} {
+		if w := doReq(r, http.MethodPost, "/users", body, nil); w.Code != http.StatusCreated {
+			t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+		}
+	}
+	if u, _ := store.GetUser("a1"); !slices.Equal(u.Tags, []string{"brand-a"}) {
+		t.Fatalf("标签应去重去空白: %v", u.Tags)
+	}
+	if w := doReq(r, http.MethodPut, "/users/a2/tags", `{"tags":["brand-a","vip"]}`, nil); w.Code != http.StatusOK {
+		t.Fatalf("修改标签失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doReq(r, http.MethodPut, "/users/a2/tags", `{"tags":["bad tag"]}`, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法标签应返回 400，got %d", w.Code)
+	}
+
+	var list usersResponse
+	w := doReq(r, http.MethodGet, "/users?tag=brand-a", "", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Users) != 2 || list.Users[0].ID != "a1" || list.Users[1].ID != "a2" {
+		t.Fatalf("按标签过滤不符: %+v", list.Users)
+	}
+
+	var resp struct {
+		Results []batchResultItem `json:"results"`
+	}
+	w = doReq(r, http.MethodPost, "/users/batch/stop", `{"tags":["test","vip"]}`, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].ID != "a2" || resp.Results[1].ID != "t1" {
+		t.Fatalf("按标签批量操作的账号不符: %+v", resp.Results)
+	}
+	if w := doReq(r, http.MethodPost, "/users/batch/stop", `{"ids":["a1"],"tags":["test"]}`, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("ids 与 tags 同时指定应返回 400，got %d", w.Code)
+	}
+}
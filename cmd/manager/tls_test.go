@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateSelfSigned(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	certFile, keyFile, err := loadOrCreateSelfSigned(dir, selfSignedHosts("192.168.1.10:18050"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.VerifyHostname("localhost"); err != nil {
+		t.Fatalf("证书应包含 localhost: %v", err)
+	}
+	if err := cert.VerifyHostname("192.168.1.10"); err != nil {
+		t.Fatalf("证书应包含监听地址: %v", err)
+	}
+	if fi, err := os.Stat(keyFile); err == nil && fi.Mode().Perm()&0077 != 0 && os.PathSeparator == '/' {
+		t.Fatalf("私钥权限过宽: %v", fi.Mode())
+	}
+
+	// 有效证书直接复用
+	before, _ := os.ReadFile(certFile)
+	if _, _, err := loadOrCreateSelfSigned(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(certFile)
+	if string(before) != string(after) {
+		t.Fatal("未过期的证书不应重新生成")
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 实例二进制升级：上传新二进制（或指定本机路径），校验 sha256 与 -capabilities 后替换配置的 bin，
+// 旧二进制保留为 <bin>.bak，然后按并发上限逐个重启运行中的实例并通过 GET /upgrade 报告进度。
+// 任一实例用新二进制启动失败时停止滚动，恢复旧二进制并用它重新启动已处理过的实例。
+
+const (
+	maxUpgradeSize         = 512 << 20
+	maxUpgradeConcurrency  = 4
+	upgradeBackupSuffix    = ".bak"
+	upgradeStartTimeout    = 45 * time.Second
+	upgradeStateVerifying  = "verifying"
+	upgradeStateRestarting = "restarting"
+	upgradeStateDone       = "done"
+	upgradeStateFailed     = "failed"
+	upgradeStateRolledBack = "rolled_back"
+)
+
+// UpgradeUserResult 单个实例的重启进度
+type UpgradeUserResult struct {
+	User   string `json:"user"`
+	Status string `json:"status"` // pending, restarting, restarted, failed, skipped, rolled_back
+	Error  string `json:"error,omitempty"`
+}
+
+// UpgradeStatus 一次升级的状态
+type UpgradeStatus struct {
+	State           string              `json:"state"`
+	Bin             string              `json:"bin"`
+	Backup          string              `json:"backup,omitempty"` // 旧二进制备份
+	SHA256          string              `json:"sha256"`
+	Version         string              `json:"version"`
+	PreviousVersion string              `json:"previous_version,omitempty"`
+	Concurrency     int                 `json:"concurrency"`
+	Users           []UpgradeUserResult `json:"users"`
+	Error           string              `json:"error,omitempty"`
+	StartedAt       time.Time           `json:"started_at"`
+	FinishedAt      *time.Time          `json:"finished_at,omitempty"`
+}
+
+// upgradeState 当前/最近一次升级，GET 时返回副本
+type upgradeState struct {
+	mu     sync.Mutex
+	status *UpgradeStatus
+}
+
+func (s *upgradeState) snapshot() *UpgradeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status == nil {
+		return nil
+	}
+	cp := *s.status
+	cp.Users = append([]UpgradeUserResult(nil), s.status.Users...)
+	return &cp
+}
+
+func (s *upgradeState) update(fn func(st *UpgradeStatus)) {
+	s.mu.Lock()
+	fn(s.status)
+	s.mu.Unlock()
+}
+
+func (s *upgradeState) setUser(i int, status, errMsg string) {
+	s.update(func(st *UpgradeStatus) {
+		st.Users[i].Status = status
+		st.Users[i].Error = errMsg
+	})
+}
+
+func (s *upgradeState) finish(state, errMsg string) {
+	now := time.Now()
+	s.update(func(st *UpgradeStatus) {
+		st.State = state
+		st.Error = errMsg
+		st.FinishedAt = &now
+	})
+}
+
+type upgradeReq struct {
+	Path        string `json:"path"`   // 本机上新二进制的路径，与上传二选一
+	SHA256      string `json:"sha256"` // 可选，校验新二进制
+	Concurrency int    `json:"concurrency"`
+	Force       bool   `json:"force"` // 工具结构版本与 manager 不一致时仍然升级
+}
+
+// StartUpgrade 开始升级：JSON 请求体指定本机路径，或以 application/octet-stream 上传二进制
+// （参数通过 ?sha256=&concurrency=&force= 传入）。校验通过后返回 202，滚动重启在后台进行。
+// POST /api/admin/v1/upgrade
+func (a *App) StartUpgrade(c *gin.Context) {
+	var req upgradeReq
+	upload := !strings.Contains(c.GetHeader("Content-Type"), "json")
+	if upload {
+		req.SHA256 = c.Query("sha256")
+		req.Concurrency, _ = strconv.Atoi(c.Query("concurrency"))
+		req.Force = c.Query("force") == "true"
+	} else if err := c.ShouldBindJSON(&req); err != nil || req.Path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 path，或以 application/octet-stream 上传二进制"})
+		return
+	}
+	if req.Concurrency <= 0 {
+		req.Concurrency = 1
+	}
+	if req.Concurrency > maxUpgradeConcurrency {
+		req.Concurrency = maxUpgradeConcurrency
+	}
+
+	if a.proc.Docker().Enabled() || a.proc.Kube() != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "实例以容器运行，请更新镜像后重启实例"})
+		return
+	}
+	bin := a.store.ResolveBinPath()
+	if fi, err := os.Stat(bin); err != nil || !fi.Mode().IsRegular() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("配置的 bin 不是本机文件，无法升级: %s", bin)})
+		return
+	}
+
+	a.upgrade.mu.Lock()
+	if st := a.upgrade.status; st != nil && st.FinishedAt == nil {
+		a.upgrade.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": "已有升级正在进行"})
+		return
+	}
+	a.upgrade.status = &UpgradeStatus{State: upgradeStateVerifying, Bin: bin, Concurrency: req.Concurrency, Users: []UpgradeUserResult{}, StartedAt: time.Now()}
+	a.upgrade.mu.Unlock()
+	fail := func(code int, err error) {
+		a.upgrade.finish(upgradeStateFailed, err.Error())
+		c.JSON(code, gin.H{"error": err.Error()})
+	}
+
+	var src io.Reader
+	if upload {
+		src = io.LimitReader(c.Request.Body, maxUpgradeSize+1)
+	} else {
+		f, err := os.Open(req.Path)
+		if err != nil {
+			fail(http.StatusBadRequest, fmt.Errorf("打开新二进制失败: %w", err))
+			return
+		}
+		defer f.Close()
+		src = f
+	}
+	staged, sum, err := stageUpgradeBinary(bin, src)
+	if err != nil {
+		fail(http.StatusBadRequest, err)
+		return
+	}
+	defer os.Remove(staged) // 替换成功后已不存在
+	if req.SHA256 != "" && !strings.EqualFold(req.SHA256, sum) {
+		fail(http.StatusBadRequest, fmt.Errorf("sha256 不匹配：实际为 %s", sum))
+		return
+	}
+	caps, err := probeCapabilities(staged)
+	if err != nil {
+		fail(http.StatusBadRequest, fmt.Errorf("新二进制校验失败: %w", err))
+		return
+	}
+	if caps.ToolSchema != a.proc.self.ToolSchema && !req.Force {
+		fail(http.StatusBadRequest, fmt.Errorf("新二进制工具结构版本 %d 与 manager（%d）不一致，确认升级请使用 force", caps.ToolSchema, a.proc.self.ToolSchema))
+		return
+	}
+
+	previous := a.proc.Capabilities(bin)
+	backup := bin + upgradeBackupSuffix
+	if err := swapBinary(bin, staged, backup); err != nil {
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+
+	var users []UserConfig
+	results := []UpgradeUserResult{}
+	for _, u := range a.store.ListUsers() {
+		if a.proc.GetStatus(u.ID).Running {
+			users = append(users, u)
+			results = append(results, UpgradeUserResult{User: u.ID, Status: "pending"})
+		}
+	}
+	a.upgrade.update(func(st *UpgradeStatus) {
+		st.State = upgradeStateRestarting
+		st.Backup = backup
+		st.SHA256 = sum
+		st.Version = caps.Version
+		st.PreviousVersion = previous.Version
+		st.Users = results
+	})
+	fmt.Printf("实例二进制已升级到 %s（sha256 %s），开始滚动重启 %d 个实例\n", caps.Version, sum, len(users))
+	go a.rollingRestart(users, req.Concurrency, bin, backup)
+
+	c.JSON(http.StatusAccepted, a.upgrade.snapshot())
+}
+
+// GetUpgrade 当前或最近一次升级的进度
+// GET /api/admin/v1/upgrade
+func (a *App) GetUpgrade(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"upgrade": a.upgrade.snapshot()})
+}
+
+// stageUpgradeBinary 把新二进制写到 bin 同目录的临时文件（保证可以原子替换），返回路径与 sha256
+func stageUpgradeBinary(bin string, src io.Reader) (string, string, error) {
+	f, err := os.CreateTemp(filepath.Dir(bin), "."+filepath.Base(bin)+".upgrade-*")
+	if err != nil {
+		return "", "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	switch {
+	case err != nil:
+		err = fmt.Errorf("写入新二进制失败: %w", err)
+	case n == 0:
+		err = fmt.Errorf("新二进制为空")
+	case n > maxUpgradeSize:
+		err = fmt.Errorf("新二进制过大（最大 %d MB）", maxUpgradeSize>>20)
+	default:
+		err = os.Chmod(f.Name(), 0755)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", err
+	}
+	return f.Name(), hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// swapBinary 旧二进制移为 backup，新二进制移到 bin；失败时恢复
+func swapBinary(bin, staged, backup string) error {
+	_ = os.Remove(backup) // Windows 下重命名不覆盖已有文件
+	if err := os.Rename(bin, backup); err != nil {
+		return fmt.Errorf("备份旧二进制失败: %w", err)
+	}
+	if err := os.Rename(staged, bin); err != nil {
+		_ = os.Rename(backup, bin)
+		return fmt.Errorf("替换二进制失败: %w", err)
+	}
+	return nil
+}
+
+// rollingRestart 按并发上限逐个重启实例；出现失败后不再开始新的重启，并回滚
+func (a *App) rollingRestart(users []UserConfig, concurrency int, bin, backup string) {
+	var (
+		mu     sync.Mutex
+		failed error
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, concurrency)
+	)
+	for i, u := range users {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed != nil
+		mu.Unlock()
+		if stop {
+			<-sem
+			a.upgrade.setUser(i, "skipped", "")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			a.upgrade.setUser(i, "restarting", "")
+			if err := a.restartUser(u); err != nil {
+				a.upgrade.setUser(i, "failed", err.Error())
+				mu.Lock()
+				if failed == nil {
+					failed = fmt.Errorf("%s 重启失败: %w", u.ID, err)
+				}
+				mu.Unlock()
+				return
+			}
+			a.upgrade.setUser(i, "restarted", "")
+		}()
+	}
+	wg.Wait()
+
+	if failed == nil {
+		a.upgrade.finish(upgradeStateDone, "")
+		fmt.Printf("实例二进制升级完成，已重启 %d 个实例\n", len(users))
+		return
+	}
+
+	fmt.Printf("实例二进制升级失败，回滚到旧二进制: %v\n", failed)
+	if err := os.Remove(bin); err != nil && !os.IsNotExist(err) {
+		a.upgrade.finish(upgradeStateFailed, fmt.Sprintf("%v；回滚失败: %v", failed, err))
+		return
+	}
+	if err := os.Rename(backup, bin); err != nil {
+		a.upgrade.finish(upgradeStateFailed, fmt.Sprintf("%v；回滚失败: %v", failed, err))
+		return
+	}
+	a.upgrade.update(func(st *UpgradeStatus) { st.Backup = "" })
+	for i, u := range users {
+		switch a.upgrade.snapshot().Users[i].Status {
+		case "restarted", "failed":
+			if err := a.restartUser(u); err != nil {
+				a.upgrade.setUser(i, "failed", "回滚后启动失败: "+err.Error())
+				continue
+			}
+			a.upgrade.setUser(i, "rolled_back", "")
+		}
+	}
+	a.upgrade.finish(upgradeStateRolledBack, failed.Error())
+}
+
+// restartUser 停止后用当前配置的二进制重新启动
+func (a *App) restartUser(u UserConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.proc.StopTimeout(u)+terminateGrace+killWait+2*time.Second)
+	err := a.proc.StopUser(ctx, u.ID, 0)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("停止失败: %w", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), max(upgradeStartTimeout, a.proc.ReadyTimeout()+15*time.Second))
+	defer cancel()
+	return a.proc.StartUser(ctx, StartUserParams{
+		User:     u,
+		BinPath:  a.store.ResolveBinPath(),
+		Headless: a.store.GetConfig().Headless,
+		DataDir:  a.store.UserDataDir(u),
+	})
+}
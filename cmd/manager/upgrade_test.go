@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpgradeBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("依赖 sh 脚本")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	store, err := LoadStore("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeBin := func(version string) string {
+		return "#!/bin/sh\necho '{\"version\":\"" + version + "\",\"tool_schema\":1}'\n"
+	}
+	bin := store.ResolveBinPath()
+	writeTestFile(t, bin, fakeBin("v1.0.0"))
+	if err := os.Chmod(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/upgrade", a.StartUpgrade)
+	r.GET("/upgrade", a.GetUpgrade)
+	upload := http.Header{"Content-Type": {"application/octet-stream"}}
+
+	// 校验失败时不替换
+	if w := doReq(r, http.MethodPost, "/upgrade", "#!/bin/sh\nexit 1\n", upload); w.Code != http.StatusBadRequest {
+		t.Fatalf("无法报告能力的二进制应被拒绝，got %d", w.Code)
+	}
+	if w := doReq(r, http.MethodPost, "/upgrade?sha256=deadbeef", fakeBin("v2.0.0"), upload); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "sha256") {
+		t.Fatalf("sha256 不匹配应被拒绝: %d %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(bin); string(data) != fakeBin("v1.0.0") {
+		t.Fatal("校验失败不应替换二进制")
+	}
+
+	// 指定本机路径升级
+	next := filepath.Join(dir, "new-mcp")
+	writeTestFile(t, next, fakeBin("v2.0.0"))
+	sum := sha256.Sum256([]byte(fakeBin("v2.0.0")))
+	body := `{"path":"` + next + `","sha256":"` + hex.EncodeToString(sum[:]) + `"}`
+	if w := doReq(r, http.MethodPost, "/upgrade", body, nil); w.Code != http.StatusAccepted {
+		t.Fatalf("升级失败: %d %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Upgrade *UpgradeStatus `json:"upgrade"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := doReq(r, http.MethodGet, "/upgrade", "", nil)
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Upgrade.FinishedAt != nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	st := resp.Upgrade
+	if st.State != upgradeStateDone || st.Version != "v2.0.0" || st.PreviousVersion != "v1.0.0" {
+		t.Fatalf("升级状态不符: %+v", st)
+	}
+	if data, _ := os.ReadFile(bin); string(data) != fakeBin("v2.0.0") {
+		t.Fatal("二进制应已替换")
+	}
+	if data, _ := os.ReadFile(bin + upgradeBackupSuffix); string(data) != fakeBin("v1.0.0") {
+		t.Fatal("旧二进制应保留为 .bak")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(bin), ".*.upgrade-*")); len(matches) != 0 {
+		t.Fatalf("临时文件未清理: %v", matches)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+// 单个账号的数据导出：GET /api/admin/v1/users/:id/data/export，返回 tar.gz，用于手动备份
+// 或把已登录的会话迁移到另一台机器。包内结构：
+//
+//	user.json      账号配置
+//	cookies.json   登录 cookies（见下）
+//	profile/...    浏览器用户数据目录（默认不含缓存，?cache=true 时包含）
+//	state/...      任务日志、发布历史等运行状态
+//	logs/...       实例日志与轮转的历史日志（?logs=false 时不包含）
+//
+// 临时目录不导出。浏览器运行时会持续写入用户数据目录，需先停止账号。
+// cookies 默认按磁盘上的加密形式导出，未加密的 cookies 不导出；?reveal=true 时导出解密后的明文。
+
+// userExportOptions 导出内容选项
+type userExportOptions struct {
+	Cache   bool
+	State   bool
+	Logs    bool
+	Cookies cookieExportMode
+}
+
+// cookieExportMode cookies 的导出方式
+type cookieExportMode int
+
+const (
+	cookiesAsStored      cookieExportMode = iota // 按磁盘原样，用于本机快照
+	cookiesEncryptedOnly                         // 只导出已加密的 cookies
+	cookiesReveal                                // 解密为明文
+)
+
+// tarWriter 向 tar 包追加文件
+type tarWriter struct {
+	tw *tar.Writer
+}
+
+// addBytes 追加内存中的数据
+func (t tarWriter) addBytes(name string, data []byte, mtime time.Time) error {
+	if err := t.tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: mtime}); err != nil {
+		return err
+	}
+	_, err := t.tw.Write(data)
+	return err
+}
+
+// addFile 追加磁盘上的文件，只写入打开时的大小，避免文件增长导致与头部不一致。
+// 文件不存在时跳过
+func (t tarWriter) addFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+	if err := t.tw.WriteHeader(&tar.Header{Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.CopyN(t.tw, f, info.Size())
+	return err
+}
+
+// addCookies 按导出方式追加 cookies 文件
+func (t tarWriter) addCookies(path string, mode cookieExportMode) error {
+	if mode == cookiesAsStored {
+		return t.addFile("cookies.json", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if mode == cookiesReveal {
+		if data, err = cookies.Decrypt(data); err != nil {
+			return err
+		}
+	} else if !cookies.IsEncrypted(data) {
+		return nil
+	}
+	return t.addBytes("cookies.json", data, time.Now())
+}
+
+// addDir 递归追加目录下的普通文件，skip 返回 true 的路径（含目录）被跳过。目录不存在时跳过
+func (t tarWriter) addDir(prefix, root string, skip func(path string, d fs.DirEntry) bool) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if p != root && skip != nil && skip(p, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return t.addFile(prefix+filepath.ToSlash(rel), p)
+	})
+}
+
+// writeUserExport 将账号数据打包为 tar.gz 写入 w
+func writeUserExport(w io.Writer, u UserConfig, paths DerivedPaths, opts userExportOptions) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	t := tarWriter{tw: tw}
+
+	cfg, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := t.addBytes("user.json", cfg, time.Now()); err != nil {
+		return err
+	}
+	if err := t.addCookies(paths.CookiesPath, opts.Cookies); err != nil {
+		return err
+	}
+
+	skip := map[string]bool{}
+	if !opts.Cache {
+		for _, dir := range cacheDirs(paths.UserDataDir) {
+			skip[dir] = true
+		}
+	}
+	err = t.addDir("profile/", paths.UserDataDir, func(p string, d fs.DirEntry) bool {
+		// Singleton* 为浏览器运行时的锁，导入后会阻止浏览器启动
+		return skip[p] || strings.HasPrefix(d.Name(), "Singleton")
+	})
+	if err != nil {
+		return fmt.Errorf("打包浏览器数据失败: %w", err)
+	}
+	if opts.State {
+		err = t.addDir("state/", paths.StateDir, func(_ string, d fs.DirEntry) bool {
+			return !d.IsDir() && !isBundleStateFile(d.Name())
+		})
+		if err != nil {
+			return fmt.Errorf("打包运行状态失败: %w", err)
+		}
+	}
+
+	if opts.Logs {
+		for _, logFile := range []string{paths.LogFile, paths.ErrLogFile} {
+			files := []string{logFile}
+			for _, r := range listRotatedLogs(logFile) {
+				files = append(files, r.Path)
+			}
+			for _, f := range files {
+				if err := t.addFile("logs/"+filepath.Base(f), f); err != nil {
+					return fmt.Errorf("打包日志失败: %w", err)
+				}
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ExportUserData 下载账号数据目录的 tar.gz 包
+// GET /api/admin/v1/users/:id/data/export?cache=true&logs=false&reveal=true
+func (a *App) ExportUserData(c *gin.Context) {
+	user, ok := a.store.GetUser(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if st := a.proc.GetStatus(user.ID); st.Running || st.State.active() {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户进程运行中，请先停止再导出，避免浏览器数据不一致"})
+		return
+	}
+	opts := userExportOptions{Cache: c.Query("cache") == "true", State: true, Logs: c.Query("logs") != "false", Cookies: cookiesEncryptedOnly}
+	if c.Query("reveal") == "true" {
+		opts.Cookies = cookiesReveal
+	}
+	paths := a.proc.DerivePaths(a.store.UserDataDir(user), user.ID, user.Port)
+
+	name := fmt.Sprintf("xhs-user-%s-%s.tar.gz", user.ID, time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// 边打包边传输，头部已发送，出错时只记录；未写完的 gzip 流在客户端解压时会报错
+	if err := writeUserExport(c.Writer, user, paths, opts); err != nil {
+		fmt.Printf("导出用户 %s 数据失败: %v\n", user.ID, err)
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+func TestWriteUserExport(t *testing.T) {
+	pm := NewProcessManager()
+	paths := pm.DerivePaths(t.TempDir(), "u1", 18060)
+	writeTestFile(t, paths.CookiesPath, `[{"name":"a1","value":"x"}]`)
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "Default", "Cookies"), "db")
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "Default", "Cache", "data_0"), "cache")
+	writeTestFile(t, filepath.Join(paths.UserDataDir, "SingletonCookie"), "lock")
+	writeTestFile(t, filepath.Join(paths.StateDir, "history.json"), "[]")
+	writeTestFile(t, filepath.Join(paths.StateDir, "history.json.tmp"), "")
+	writeTestFile(t, paths.LogFile, "log")
+	writeTestFile(t, paths.LogFile+".1", "old")
+
+	names := func(opts userExportOptions) []string {
+		var buf bytes.Buffer
+		if err := writeUserExport(&buf, UserConfig{ID: "u1", Port: 18060}, paths, opts); err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		var out []string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, hdr.Name)
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	want := []string{"cookies.json", "logs/u1.log", "logs/u1.log.1", "profile/Default/Cookies", "state/history.json", "user.json"}
+	if got := names(userExportOptions{State: true, Logs: true}); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	got := names(userExportOptions{Cache: true})
+	if !slices.Contains(got, "profile/Default/Cache/data_0") || slices.Contains(got, "logs/u1.log") {
+		t.Fatalf("cache=true logs=false: %v", got)
+	}
+}
+
+func TestWriteUserExportCookies(t *testing.T) {
+	cookies.SetKey("test-key")
+	t.Cleanup(func() { cookies.SetKey("") })
+	pm := NewProcessManager()
+	paths := pm.DerivePaths(t.TempDir(), "u1", 18060)
+	plain := `[{"name":"web_session","value":"secret"}]`
+
+	export := func(mode cookieExportMode) (string, bool) {
+		var buf bytes.Buffer
+		if err := writeUserExport(&buf, UserConfig{ID: "u1"}, paths, userExportOptions{Cookies: mode}); err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return "", false
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hdr.Name == "cookies.json" {
+				data, _ := io.ReadAll(tr)
+				return string(data), true
+			}
+		}
+	}
+
+	// 明文文件默认不导出
+	writeTestFile(t, paths.CookiesPath, plain)
+	if _, ok := export(cookiesEncryptedOnly); ok {
+		t.Fatal("未加密的 cookies 不应在未 reveal 时导出")
+	}
+
+	if err := editCookieFile(paths.CookiesPath, func(data []byte) ([]byte, error) { return data, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := export(cookiesEncryptedOnly); !ok || !strings.HasPrefix(got, "xhs-cookies-enc:") || strings.Contains(got, "secret") {
+		t.Fatalf("应导出加密的 cookies: %q", got)
+	}
+	if got, _ := export(cookiesReveal); got != plain {
+		t.Fatalf("reveal 应导出明文: %q", got)
+	}
+	if info := (&App{}).getCookieStatus(paths.CookiesPath); !info.Encrypted || info.Count != 1 {
+		t.Fatalf("cookie 状态: %+v", info)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 批量导入账号：POST /api/admin/v1/users/import，请求体为 JSON 数组或带表头的 CSV
+// （Content-Type: text/csv，或内容不以 [ 开头）。每行一个账号：
+//
+//	id,name,port,proxy,proxy_pool_url,tags,cookies_path
+//	shop-01,店铺一号,auto,http://1.2.3.4:8080,,brand-a;vip,/tmp/shop-01.json
+//
+// port 为空或 auto 时从 -port-range 自动分配；CSV 中 tags 以 ; 或 | 分隔；
+// cookies_path 为 manager 所在主机上的 cookies 文件（与调试页导入的格式相同），导入后复制到账号目录。
+// 逐行创建，单行失败不影响其他行，响应中给出每行的结果。?dry_run=true 只校验不创建。
+
+const (
+	maxUserImportBytes = 5 << 20
+	maxUserImportRows  = 1000
+)
+
+// userImportRow 导入的一行
+type userImportRow struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Port        any      `json:"port"` // 端口号，或 "auto"/空表示自动分配
+	Proxy       string   `json:"proxy"`
+	ProxyPool   string   `json:"proxy_pool_url"`
+	Tags        []string `json:"tags"`
+	CookiesPath string   `json:"cookies_path"`
+}
+
+// userImportResult 一行的导入结果
+type userImportResult struct {
+	Row     int    `json:"row"` // 从 1 开始，CSV 不含表头
+	ID      string `json:"id"`
+	Status  string `json:"status"` // created / valid（dry_run）/ error
+	Port    int    `json:"port,omitempty"`
+	Cookies int    `json:"cookies,omitempty"` // 导入的 cookie 条数
+	Error   string `json:"error,omitempty"`
+}
+
+var userImportColumns = []string{"id", "name", "port", "proxy", "proxy_pool_url", "tags", "cookies_path"}
+
+// parseUserImport 按内容类型解析导入数据
+func parseUserImport(raw []byte, contentType string) ([]userImportRow, error) {
+	raw = bytes.TrimSpace(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")))
+	if len(raw) == 0 {
+		return nil, errors.New("请求体不能为空（需要 JSON 数组或 CSV）")
+	}
+	var rows []userImportRow
+	if !strings.Contains(contentType, "csv") && raw[0] == '[' {
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, fmt.Errorf("无效 JSON：需要账号数组: %v", err)
+		}
+	} else {
+		var err error
+		if rows, err = parseUserImportCSV(raw); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("没有需要导入的账号")
+	}
+	if len(rows) > maxUserImportRows {
+		return nil, fmt.Errorf("单次最多导入 %d 个账号", maxUserImportRows)
+	}
+	return rows, nil
+}
+
+func parseUserImportCSV(raw []byte) ([]userImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("无效 CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	col := map[string]int{}
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if !slices.Contains(userImportColumns, h) {
+			return nil, fmt.Errorf("未知的列 %q，可用的列：%s", h, strings.Join(userImportColumns, ","))
+		}
+		col[h] = i
+	}
+	if _, ok := col["id"]; !ok {
+		return nil, errors.New("CSV 缺少 id 列")
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	rows := make([]userImportRow, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		rows = append(rows, userImportRow{
+			ID:          field(rec, "id"),
+			Name:        field(rec, "name"),
+			Port:        field(rec, "port"),
+			Proxy:       field(rec, "proxy"),
+			ProxyPool:   field(rec, "proxy_pool_url"),
+			Tags:        strings.FieldsFunc(field(rec, "tags"), func(r rune) bool { return r == ';' || r == '|' }),
+			CookiesPath: field(rec, "cookies_path"),
+		})
+	}
+	return rows, nil
+}
+
+// importPort 解析端口列，0 表示自动分配
+func importPort(v any) (int, error) {
+	switch p := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if p != float64(int(p)) {
+			return 0, fmt.Errorf("port 非法: %v", p)
+		}
+		return int(p), nil
+	case string:
+		p = strings.TrimSpace(p)
+		if p == "" || strings.EqualFold(p, "auto") {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("port 需为端口号或 auto: %s", p)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("port 需为端口号或 auto")
+}
+
+// importUser 校验并创建一行账号
+func (a *App) importUser(row userImportRow, dryRun bool) userImportResult {
+	res := userImportResult{ID: strings.TrimSpace(row.ID), Status: "error"}
+	port, err := importPort(row.Port)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	u := UserConfig{
+		ID:        res.ID,
+		Name:      strings.TrimSpace(row.Name),
+		Port:      port,
+		Proxy:     strings.TrimSpace(row.Proxy),
+		ProxyPool: strings.TrimSpace(row.ProxyPool),
+		Tags:      normalizeTags(row.Tags),
+	}
+	if err := validateProxyInput(u.Proxy, u.ProxyPool); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	// 先读取并校验 cookies，避免账号已创建而 cookies 导入失败
+	var list []map[string]any
+	if path := strings.TrimSpace(row.CookiesPath); path != "" {
+		raw, err := readCookieFile(path)
+		if err == nil && raw == nil {
+			err = os.ErrNotExist
+		}
+		if err != nil {
+			res.Error = fmt.Sprintf("读取 cookies 文件失败: %v", err)
+			return res
+		}
+		if list, err = parseCookiesJSON(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))); err != nil {
+			res.Error = fmt.Sprintf("cookies 文件 %s: %v", path, err)
+			return res
+		}
+		res.Cookies = len(list)
+	}
+
+	if dryRun {
+		if _, exists := a.store.GetUser(u.ID); exists {
+			res.Error = fmt.Sprintf("用户已存在: %s", u.ID)
+			return res
+		}
+		if u.Port == 0 {
+			u.Port = 1 // 只用于校验，实际端口在创建时分配
+		}
+		if err := validateUser(u); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		res.Status, res.Port = "valid", port
+		return res
+	}
+
+	if err := a.store.CreateUser(u); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	created, _ := a.store.GetUser(u.ID)
+	res.Port = created.Port
+	if list != nil {
+		paths := a.proc.DerivePaths(a.store.UserDataDir(created), created.ID, created.Port)
+		if err := saveCookiesFile(paths.CookiesPath, list); err != nil {
+			// 回滚，保证每行要么完整导入要么不导入
+			_ = a.store.DeleteUser(created.ID)
+			res.Port = 0
+			res.Error = err.Error()
+			return res
+		}
+	}
+	res.Status = "created"
+	return res
+}
+
+// ImportUsers 从 CSV 或 JSON 批量创建账号
+// POST /api/admin/v1/users/import?dry_run=true
+func (a *App) ImportUsers(c *gin.Context) {
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxUserImportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+		return
+	}
+	if len(raw) > maxUserImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件过大（最大 5MB）"})
+		return
+	}
+	rows, err := parseUserImport(raw, c.ContentType())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	results := make([]userImportResult, 0, len(rows))
+	seen := map[string]bool{}
+	ok := 0
+	for i, row := range rows {
+		var res userImportResult
+		if id := strings.TrimSpace(row.ID); id != "" && seen[id] {
+			res = userImportResult{ID: id, Status: "error", Error: "与前面的行重复"}
+		} else {
+			seen[id] = true
+			res = a.importUser(row, dryRun)
+		}
+		res.Row = i + 1
+		if res.Status != "error" {
+			ok++
+		}
+		results = append(results, res)
+	}
+	if !dryRun && ok > 0 {
+		fmt.Printf("批量导入账号：成功 %d，失败 %d\n", ok, len(rows)-ok)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":   dryRun,
+		"total":     len(rows),
+		"succeeded": ok,
+		"failed":    len(rows) - ok,
+		"results":   results,
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImportUsers(t *testing.T) {
+	dir := t.TempDir()
+	store, err := LoadStore(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDataDir(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/import", a.ImportUsers)
+
+	cookies, badCookies := filepath.Join(dir, "c.json"), filepath.Join(dir, "bad.json")
+	writeTestFile(t, cookies, `[{"name":"web_session","value":"x"}]`)
+	writeTestFile(t, badCookies, `[{"value":"x"}]`)
+	csvBody := "id,name,port,proxy,tags,cookies_path\n" +
+		"shop-01,店铺一号,auto,,brand-a;vip," + cookies + "\n" +
+		"shop-02,,18999,socks5://127.0.0.1:1080,,\n" +
+		"shop-01,重复,,,,\n" +
+		"bad id,,,,,\n" +
+		"shop-03,,,,," + badCookies + "\n"
+
+	post := func(path, contentType, body string) (int, map[string]any, []userImportResult) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			Results []userImportResult `json:"results"`
+		}
+		var m map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		_ = json.Unmarshal(w.Body.Bytes(), &m)
+		return w.Code, m, resp.Results
+	}
+
+	// dry_run 不创建账号
+	code, m, _ := post("/users/import?dry_run=true", "text/csv", csvBody)
+	if code != http.StatusOK || m["succeeded"] != float64(2) || len(store.ListUsers()) != 0 {
+		t.Fatalf("dry_run 结果不符: %d %v", code, m)
+	}
+
+	code, m, results := post("/users/import", "text/csv", csvBody)
+	if code != http.StatusOK || m["succeeded"] != float64(2) || m["failed"] != float64(3) {
+		t.Fatalf("导入结果不符: %d %v", code, m)
+	}
+	want := []string{"created", "created", "error", "error", "error"}
+	for i, res := range results {
+		if res.Row != i+1 || res.Status != want[i] {
+			t.Errorf("第 %d 行: %+v", i+1, res)
+		}
+	}
+	u, ok := store.GetUser("shop-01")
+	if !ok || u.Name != "店铺一号" || u.Port == 0 || strings.Join(u.Tags, ",") != "brand-a,vip" {
+		t.Fatalf("账号配置不符: %+v", u)
+	}
+	raw, err := os.ReadFile(a.proc.DerivePaths(store.UserDataDir(u), u.ID, u.Port).CookiesPath)
+	if err != nil || !strings.Contains(string(raw), "web_session") {
+		t.Fatalf("cookies 未导入: %s %v", raw, err)
+	}
+	if _, exists := store.GetUser("shop-03"); exists {
+		t.Fatal("cookies 无效的行不应创建账号")
+	}
+
+	// JSON 数组，port 为数字
+	if code, m, _ := post("/users/import", "application/json", `[{"id":"shop-04","port":18998}]`); code != http.StatusOK || m["succeeded"] != float64(1) {
+		t.Fatalf("JSON 导入失败: %d %v", code, m)
+	}
+	if code, _, _ := post("/users/import", "text/csv", "id,phone\nx,1\n"); code != http.StatusBadRequest {
+		t.Fatalf("未知的列应返回 400，实际 %d", code)
+	}
+}
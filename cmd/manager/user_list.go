@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 账号列表的过滤、排序与分页（GET /api/admin/v1/users）：
+//
+//	?status=running|stopped|starting|stopping|failed|exited  按运行状态过滤，可重复指定（任一匹配）
+//	?tag=brand-a                                             按标签过滤，可重复指定（任一匹配）
+//	?q=关键字                                                  按 ID、备注名、昵称、小红书号、标签模糊匹配（不区分大小写）
+//	?sort=id|name|port|started_at，前缀 - 表示倒序             默认按账号列表的顺序（ID）；name 为备注名，未设置时依次使用账号昵称、ID
+//	?page=1&limit=50                                         limit 为空时返回全部
+//
+// 过滤与排序只用配置与进程状态，健康检查与资源采样只对当前页的账号进行。
+
+const maxUserListLimit = 500
+
+// userListQuery 账号列表查询参数
+type userListQuery struct {
+	Status []string
+	Tags   []string
+	Q      string
+	Sort   string // 为空保持 store 返回的顺序
+	Desc   bool
+	Page   int
+	Limit  int // 0 表示不分页
+}
+
+var userListStatuses = []string{"running", "stopped", string(StateStarting), string(StateStopping), string(StateFailed), string(StateExited)}
+
+var userListSorts = []string{"id", "name", "port", "started_at"}
+
+func parseUserListQuery(c *gin.Context) (userListQuery, error) {
+	q := userListQuery{
+		Tags: normalizeTags(c.QueryArray("tag")),
+		Q:    strings.ToLower(strings.TrimSpace(c.Query("q"))),
+		Page: 1,
+	}
+	for _, s := range c.QueryArray("status") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if !slices.Contains(userListStatuses, s) {
+			return q, fmt.Errorf("status 只能是 %s", strings.Join(userListStatuses, "/"))
+		}
+		q.Status = append(q.Status, s)
+	}
+	if s := strings.TrimSpace(c.Query("sort")); s != "" {
+		s, q.Desc = strings.CutPrefix(s, "-")
+		if !slices.Contains(userListSorts, s) {
+			return q, fmt.Errorf("sort 只能是 %s（前缀 - 表示倒序）", strings.Join(userListSorts, "/"))
+		}
+		q.Sort = s
+	}
+	if s := c.Query("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return q, fmt.Errorf("page 需为正整数")
+		}
+		q.Page = n
+	}
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxUserListLimit {
+			return q, fmt.Errorf("limit 需在 1-%d 之间", maxUserListLimit)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// matchStatus 账号当前状态是否符合 ?status=
+func matchStatus(st ProcessStatus, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, s := range want {
+		switch s {
+		case "running":
+			if st.Running {
+				return true
+			}
+		case "stopped":
+			if !st.Running {
+				return true
+			}
+		default:
+			if string(st.State) == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchKeyword 关键字是否出现在账号 ID、备注名、昵称、小红书号或标签中（kw 已转为小写）
+func matchKeyword(u UserConfig, kw string) bool {
+	if kw == "" {
+		return true
+	}
+	fields := append([]string{u.ID, u.Name}, u.Tags...)
+	if u.Profile != nil {
+		fields = append(fields, u.Profile.Nickname, u.Profile.RedID)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// displayName 按名称排序时使用的名称：备注名，其次为账号昵称，都没有时为 ID
+func displayName(u UserConfig) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if u.Profile != nil && u.Profile.Nickname != "" {
+		return u.Profile.Nickname
+	}
+	return u.ID
+}
+
+// filterUsers 过滤并排序账号，返回符合条件的全部账号（分页前）
+func (a *App) filterUsers(users []UserConfig, q userListQuery) []UserConfig {
+	type item struct {
+		u       UserConfig
+		started time.Time
+	}
+	items := make([]item, 0, len(users))
+	for _, u := range users {
+		if len(q.Tags) > 0 && !hasAnyTag(u, q.Tags) || !matchKeyword(u, q.Q) {
+			continue
+		}
+		st := a.proc.GetStatus(u.ID)
+		if !matchStatus(st, q.Status) {
+			continue
+		}
+		started, _ := time.Parse(time.RFC3339, st.StartedAt)
+		items = append(items, item{u: u, started: started})
+	}
+
+	slices.SortStableFunc(items, func(x, y item) int {
+		if q.Sort == "" {
+			return 0
+		}
+		var r int
+		switch q.Sort {
+		case "name":
+			r = cmp.Compare(displayName(x.u), displayName(y.u))
+		case "port":
+			r = cmp.Compare(x.u.Port, y.u.Port)
+		case "started_at":
+			// 从未启动的账号总是排在最后
+			if x.started.IsZero() != y.started.IsZero() {
+				if x.started.IsZero() {
+					return 1
+				}
+				return -1
+			}
+			r = x.started.Compare(y.started)
+		}
+		if r == 0 {
+			r = cmp.Compare(x.u.ID, y.u.ID)
+		}
+		if q.Desc {
+			r = -r
+		}
+		return r
+	})
+
+	out := make([]UserConfig, len(items))
+	for i, it := range items {
+		out[i] = it.u
+	}
+	return out
+}
+
+// paginate 取第 page 页，limit 为 0 时返回全部
+func paginate[T any](items []T, page, limit int) []T {
+	if limit <= 0 {
+		return items
+	}
+	start := min((page-1)*limit, len(items))
+	return items[start:min(start+limit, len(items))]
+}
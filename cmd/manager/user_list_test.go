@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListUsersQuery(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp(store, NewProcessManager(), "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users", a.ListUsers)
+	r.POST("/users", a.CreateUser)
+	for _, body := range []string{
+		`{"id":"c-shop","port":18062,"tags":["brand-a"]}`,
+		`{"id":"a-shop","port":18061}`,
+		`{"id":"b-test","port":18060,"tags":["test"]}`,
+	} {
+		if w := doReq(r, http.MethodPost, "/users", body, nil); w.Code != http.StatusCreated {
+			t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	list := func(query string) ([]string, usersResponse) {
+		t.Helper()
+		w := doReq(r, http.MethodGet, "/users"+query, "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", query, w.Code, w.Body.String())
+		}
+		var resp usersResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, u := range resp.Users {
+			ids = append(ids, u.ID)
+		}
+		return ids, resp
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"", "[a-shop b-test c-shop]"},
+		{"?sort=port", "[b-test a-shop c-shop]"},
+		{"?sort=-id", "[c-shop b-test a-shop]"},
+		{"?q=SHOP&sort=id", "[a-shop c-shop]"},
+		{"?q=brand", "[c-shop]"},
+		{"?status=running", "[]"},
+		{"?status=stopped&tag=test", "[b-test]"},
+	} {
+		if ids, _ := list(tc.query); fmt.Sprint(ids) != tc.want {
+			t.Errorf("%s = %v, want %s", tc.query, ids, tc.want)
+		}
+	}
+
+	ids, resp := list("?sort=id&page=2&limit=2")
+	if fmt.Sprint(ids) != "[c-shop]" || resp.Total != 3 || resp.Page != 2 || resp.Limit != 2 {
+		t.Fatalf("分页不符: %v %+v", ids, resp)
+	}
+	for _, q := range []string{"?status=zombie", "?sort=cpu", "?limit=0", "?page=-1"} {
+		if w := doReq(r, http.MethodGet, "/users"+q, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回 400，实际 %d", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"debug/buildinfo"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+)
+
+// 版本信息：manager 自身构建、配置的实例二进制版本以及各运行中实例报告的版本，用于核对每台主机实际部署的内容。
+// 实例二进制优先通过 -capabilities 获取（带缓存），旧版本不支持时读取二进制内嵌的 Go 构建信息。
+
+// BinaryVersion 实例二进制的版本
+type BinaryVersion struct {
+	compat.Info
+	Path      string `json:"path"`
+	Resolved  string `json:"resolved,omitempty"` // PATH 中查找到的实际路径
+	Source    string `json:"source,omitempty"`   // capabilities 或 buildinfo
+	GoVersion string `json:"go_version,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	ModTime   string `json:"mod_time,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// InstanceVersion 运行中实例报告的版本
+type InstanceVersion struct {
+	User        string `json:"user"`
+	Version     string `json:"version,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	ToolSchema  int    `json:"tool_schema,omitempty"`
+	VersionSkew string `json:"version_skew,omitempty"`
+}
+
+// VersionResponse 版本信息
+type VersionResponse struct {
+	Manager struct {
+		compat.Info
+		GoVersion string `json:"go_version"`
+		OS        string `json:"os"`
+		Arch      string `json:"arch"`
+		StartedAt string `json:"started_at"`
+	} `json:"manager"`
+	Binary    BinaryVersion     `json:"binary"`
+	Instances []InstanceVersion `json:"instances"`
+}
+
+// binaryVersion 读取实例二进制的版本
+func (a *App) binaryVersion(bin string) BinaryVersion {
+	v := BinaryVersion{Path: bin}
+	path := bin
+	if _, err := os.Stat(path); err != nil {
+		resolved, lookErr := exec.LookPath(bin)
+		if lookErr != nil {
+			v.Error = "实例二进制不存在: " + err.Error()
+			return v
+		}
+		path, v.Resolved = resolved, resolved
+	}
+	if fi, err := os.Stat(path); err == nil {
+		v.SizeBytes = fi.Size()
+		v.ModTime = fi.ModTime().Format(time.RFC3339)
+	}
+	if bi, err := buildinfo.ReadFile(path); err == nil {
+		v.GoVersion = bi.GoVersion
+		v.Version, v.Commit = compat.BuildVersion(bi)
+		v.Source = "buildinfo"
+	}
+	if caps := a.proc.Capabilities(path); caps.Known() {
+		v.Info = caps
+		v.Source = "capabilities"
+	}
+	if v.Source == "" {
+		v.Error = "无法读取实例二进制的版本信息"
+	}
+	return v
+}
+
+// GetVersion manager 与实例二进制的版本
+// GET /api/admin/v1/version
+func (a *App) GetVersion(c *gin.Context) {
+	var resp VersionResponse
+	resp.Manager.Info = a.proc.self
+	resp.Manager.GoVersion = runtime.Version()
+	resp.Manager.OS = runtime.GOOS
+	resp.Manager.Arch = runtime.GOARCH
+	resp.Manager.StartedAt = a.started.Format(time.RFC3339)
+	resp.Binary = a.binaryVersion(a.store.ResolveBinPath())
+
+	resp.Instances = []InstanceVersion{}
+	for _, u := range a.store.ListUsers() {
+		st := a.proc.GetStatus(u.ID)
+		if !st.Running {
+			continue
+		}
+		resp.Instances = append(resp.Instances, InstanceVersion{
+			User:        u.ID,
+			Version:     st.Version,
+			Commit:      st.Commit,
+			ToolSchema:  st.ToolSchema,
+			VersionSkew: st.VersionSkew,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
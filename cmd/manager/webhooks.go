@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+// 进程生命周期、内容日历与告警事件：发布到 ProcessManager 的事件总线，由 store 中配置的 webhook 订阅，
+// 推送格式与实例的 -event-webhook 相同（eventbus.Event JSON，可选 HMAC 签名）。
+// 管理界面通过 WebSocket（/api/admin/v1/events）实时接收同一总线上的事件。
+
+// 事件类型
+const (
+	eventProcessStarted  = "process_started"   // 实例启动并通过健康检查
+	eventProcessStopped  = "process_stopped"   // 实例按请求停止（手动、定时或 manager 退出）
+	eventProcessCrashed  = "process_crashed"   // 运行中的实例意外退出
+	eventAutoStartFailed = "auto_start_failed" // 启动恢复、自动重启或定时启动失败
+	eventPostPublished   = "post_published"    // 内容日历中的计划已发布
+	eventPostFailed      = "post_failed"       // 计划多次发布失败，不再重试
+	eventLoginChanged    = "login_changed"     // 登录检查结果变化，如登录失效
+	eventLogSizeAlert    = "log_size_alert"    // 账号日志总大小超过 alert_mb
+	eventAlertFiring     = "alert_firing"      // 告警规则触发，data 为 Alert
+	eventAlertResolved   = "alert_resolved"    // 告警解除，data 为 Alert
+	eventStateChanged    = "state_changed"     // 进程状态变化，只推送给管理界面，不推送 webhook
+)
+
+// processEventTypes 可由 webhook 订阅的事件
+var processEventTypes = []string{eventProcessStarted, eventProcessStopped, eventProcessCrashed, eventAutoStartFailed, eventPostPublished, eventPostFailed,
+	eventLoginChanged, eventLogSizeAlert, eventAlertFiring, eventAlertResolved}
+
+// dashboardEventTypes 管理界面可接收的事件
+var dashboardEventTypes = append(slices.Clone(processEventTypes), eventStateChanged)
+
+// 自动启动的来源
+const (
+	triggerAutoStart = "auto_start" // manager 启动时恢复上次运行的实例
+	triggerRestart   = "restart"    // 按重启策略自动重启
+	triggerSchedule  = "schedule"   // 定时启动
+	triggerFailover  = "failover"   // 集群中接管失联节点上的账号
+)
+
+// ProcessEvent 生命周期事件的 data 字段
+type ProcessEvent struct {
+	User     string `json:"user"`
+	PID      int    `json:"pid,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Trigger  string `json:"trigger,omitempty"` // auto_start_failed 的来源 auto_start/restart/schedule/failover
+	Post     string `json:"post,omitempty"`    // post_* 事件的计划 ID
+	State    string `json:"state,omitempty"`   // state_changed 为新的进程状态，login_changed 为新的登录状态
+	Prev     string `json:"prev,omitempty"`    // 变化前的状态
+	Size     int64  `json:"size,omitempty"`    // log_size_alert 的日志总字节数
+}
+
+// WebhookConfig 接收生命周期事件的 webhook，保存在 store 中
+type WebhookConfig struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"` // 签名密钥，配置后附带 X-XHS-Signature
+	Events []string `json:"events,omitempty"` // 只推送这些事件，为空表示全部
+}
+
+func validateWebhooks(hooks []WebhookConfig) error {
+	for i, h := range hooks {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhooks[%d]: url 需为 http(s) 地址", i)
+		}
+		for _, ev := range h.Events {
+			if !slices.Contains(processEventTypes, ev) {
+				return fmt.Errorf("webhooks[%d]: 未知事件 %q（可选 %s）", i, ev, strings.Join(processEventTypes, "、"))
+			}
+		}
+	}
+	return nil
+}
+
+// publish 发布生命周期事件，总线不阻塞，可在持有 pm.mu 时调用
+func (pm *ProcessManager) publish(typ, summary string, data ProcessEvent) {
+	pm.events.Publish(typ, summary, data)
+}
+
+// publishExitLocked 实例退出后发布停止或崩溃事件。调用方需持有 pm.mu。
+func (pm *ProcessManager) publishExitLocked(userID string, p *runningProc, crashed bool) {
+	if userID == "" {
+		return
+	}
+	ev := ProcessEvent{User: userID, ExitCode: p.exitCode, PID: p.pid()}
+	if !crashed {
+		pm.publish(eventProcessStopped, fmt.Sprintf("用户 %s 实例已停止", userID), ev)
+		return
+	}
+	ev.Error = p.lastError
+	pm.publish(eventProcessCrashed, fmt.Sprintf("用户 %s 实例意外退出: %s", userID, p.lastError), ev)
+}
+
+// setStateLocked 切换进程状态并发布 state_changed。调用方需持有 pm.mu。
+func (pm *ProcessManager) setStateLocked(userID string, p *runningProc, to ProcState) {
+	from := p.state
+	p.state = to
+	pm.publishStateLocked(userID, p, from)
+}
+
+// publishStateLocked 进程状态由 from 变为当前状态时发布 state_changed。调用方需持有 pm.mu。
+func (pm *ProcessManager) publishStateLocked(userID string, p *runningProc, from ProcState) {
+	if userID == "" || from == p.state {
+		return
+	}
+	pm.publish(eventStateChanged, fmt.Sprintf("用户 %s 状态 %s → %s", userID, from, p.state),
+		ProcessEvent{User: userID, PID: p.pid(), State: string(p.state), Prev: string(from)})
+}
+
+// publishAutoStartFailed 自动启动失败
+func (pm *ProcessManager) publishAutoStartFailed(userID, trigger string, err error) {
+	pm.publish(eventAutoStartFailed, fmt.Sprintf("用户 %s 自动启动失败（%s）: %v", userID, trigger, err),
+		ProcessEvent{User: userID, Error: err.Error(), Trigger: trigger})
+}
+
+// CloseEvents 等待已排队的事件推送完成，manager 退出时调用
+func (pm *ProcessManager) CloseEvents(timeout time.Duration) {
+	pm.events.Close(timeout)
+}
+
+// ApplyWebhooks 按 store 中的配置重新注册 webhook 与通知渠道订阅方
+func (a *App) ApplyWebhooks() {
+	hooks := a.store.Webhooks()
+	notifiers := a.store.Notifiers()
+	a.webhookMu.Lock()
+	defer a.webhookMu.Unlock()
+	for _, unsubscribe := range a.webhookSubs {
+		unsubscribe()
+	}
+	a.webhookSubs = a.webhookSubs[:0]
+	for i, h := range hooks {
+		types := h.Events
+		if len(types) == 0 {
+			types = processEventTypes // 不推送仅供管理界面的事件
+		}
+		a.webhookSubs = append(a.webhookSubs,
+			a.proc.events.Subscribe(fmt.Sprintf("webhook-%d", i+1), eventbus.NewWebhookSink(h.URL, h.Secret), types...))
+	}
+	for _, n := range notifiers {
+		if !n.Disabled {
+			a.webhookSubs = append(a.webhookSubs, a.proc.events.Subscribe(notifierSinkName(n.Name), newNotifierSink(n), n.events()...))
+		}
+	}
+}
+
+type webhooksResponse struct {
+	Webhooks []WebhookConfig      `json:"webhooks"`
+	Events   []string             `json:"events"` // 可订阅的事件类型
+	Sinks    []eventbus.SinkStats `json:"sinks"`  // 各 webhook 的投递统计
+	Recent   []eventbus.Event     `json:"recent"` // 最近的生命周期事件
+}
+
+// GetWebhooks 查询 webhook 配置、投递统计与最近事件
+// GET /api/admin/v1/webhooks
+func (a *App) GetWebhooks(c *gin.Context) {
+	c.JSON(http.StatusOK, webhooksResponse{
+		Webhooks: a.store.Webhooks(),
+		Events:   processEventTypes,
+		Sinks:    a.proc.events.Stats(),
+		Recent:   a.proc.events.Recent(nil, 50),
+	})
+}
+
+type updateWebhooksReq struct {
+	Webhooks []WebhookConfig `json:"webhooks"`
+}
+
+// UpdateWebhooks 替换 webhook 配置，立即生效
+// PUT /api/admin/v1/webhooks
+func (a *App) UpdateWebhooks(c *gin.Context) {
+	var req updateWebhooksReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效 JSON"})
+		return
+	}
+	for i := range req.Webhooks {
+		req.Webhooks[i].URL = strings.TrimSpace(req.Webhooks[i].URL)
+	}
+	if err := a.store.SetWebhooks(req.Webhooks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	a.ApplyWebhooks()
+	a.GetWebhooks(c)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+func TestValidateWebhooks(t *testing.T) {
+	for _, hooks := range [][]WebhookConfig{
+		{{URL: "ftp://example.com"}},
+		{{URL: "example.com/hook"}},
+		{{URL: "https://example.com/hook", Events: []string{"note_published"}}},
+	} {
+		if err := validateWebhooks(hooks); err == nil {
+			t.Errorf("%+v 应校验失败", hooks)
+		}
+	}
+	if err := validateWebhooks([]WebhookConfig{{URL: "https://example.com/hook", Events: []string{eventProcessCrashed}}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessEventWebhook(t *testing.T) {
+	received := make(chan eventbus.Event, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev eventbus.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("解析推送失败: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	store, err := LoadStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetWebhooks([]WebhookConfig{{URL: srv.URL, Events: []string{eventProcessCrashed, eventAutoStartFailed}}}); err != nil {
+		t.Fatal(err)
+	}
+	proc := NewProcessManager()
+	defer proc.CloseEvents(time.Second)
+	app := NewApp(store, proc, "")
+	app.ApplyWebhooks()
+
+	// 主动停止不在订阅范围内，只应收到崩溃事件
+	for _, state := range []ProcState{StateStopping, StateRunning} {
+		p, err := proc.reserve("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		proc.mu.Lock()
+		p.state = state
+		proc.mu.Unlock()
+		proc.markExited(p, os.ErrProcessDone)
+	}
+
+	select {
+	case ev := <-received:
+		data, _ := ev.Data.(map[string]any)
+		if ev.Type != eventProcessCrashed || data["user"] != "alice" || data["error"] == "" {
+			t.Fatalf("推送内容不符: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到崩溃事件")
+	}
+
+	proc.publishAutoStartFailed("alice", triggerSchedule, os.ErrNotExist)
+	select {
+	case ev := <-received:
+		data, _ := ev.Data.(map[string]any)
+		if ev.Type != eventAutoStartFailed || data["trigger"] != triggerSchedule {
+			t.Fatalf("推送内容不符: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到自动启动失败事件")
+	}
+
+	// 清空配置后不再推送
+	if err := store.SetWebhooks(nil); err != nil {
+		t.Fatal(err)
+	}
+	app.ApplyWebhooks()
+	if n := len(proc.events.Stats()); n != 0 {
+		t.Fatalf("清空后仍有 %d 个订阅方", n)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 最小的 WebSocket（RFC 6455）服务端，只用于向管理界面推送文本消息：
+// 服务端发送的消息不分片、不压缩；客户端发来的数据帧被忽略，只处理 ping 与 close。
+
+const (
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxClientFrame = 64 << 10
+	wsWriteTimeout   = 10 * time.Second
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// wsPingInterval 服务端发送 ping 的间隔，超过 3 倍间隔未收到客户端的任何帧视为断开
+var wsPingInterval = 30 * time.Second
+
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex // 串行写入
+}
+
+// headerHasToken 逗号分隔的请求头中是否包含 token（不区分大小写）
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkWebSocketRequest 校验升级请求；Origin 存在时须与 Host 一致，防止其他网站借用浏览器的会话连接
+func checkWebSocketRequest(r *http.Request) (int, error) {
+	if r.Method != http.MethodGet || !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		return http.StatusBadRequest, errors.New("需要 WebSocket 连接")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" || r.Header.Get("Sec-WebSocket-Key") == "" {
+		return http.StatusBadRequest, errors.New("不支持的 WebSocket 版本")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || !strings.EqualFold(u.Host, r.Host) {
+			return http.StatusForbidden, errors.New("不允许跨站连接")
+		}
+	}
+	return 0, nil
+}
+
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// upgradeWebSocket 完成握手并接管连接，调用前需通过 checkWebSocketRequest
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("连接不支持 WebSocket")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n"
+	if _, err := rw.WriteString(resp); err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	hdr := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		hdr = append(hdr, byte(n))
+	case n <= 0xFFFF:
+		hdr = binary.BigEndian.AppendUint16(append(hdr, 126), uint16(n))
+	default:
+		hdr = binary.BigEndian.AppendUint64(append(hdr, 127), uint64(n))
+	}
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := ws.rw.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(payload); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// WriteText 发送一条文本消息
+func (ws *wsConn) WriteText(msg []byte) error {
+	return ws.writeFrame(wsOpText, msg)
+}
+
+// Ping 发送 ping
+func (ws *wsConn) Ping() error {
+	return ws.writeFrame(wsOpPing, nil)
+}
+
+// Close 发送 close 帧（code 为 0 时不带状态码）并关闭连接
+func (ws *wsConn) Close(code uint16) error {
+	var payload []byte
+	if code != 0 {
+		payload = binary.BigEndian.AppendUint16(nil, code)
+	}
+	_ = ws.writeFrame(wsOpClose, payload)
+	return ws.conn.Close()
+}
+
+// readLoop 读取客户端的帧并回复 ping，客户端关闭连接、超时或出错时返回
+func (ws *wsConn) readLoop() error {
+	for {
+		_ = ws.conn.SetReadDeadline(time.Now().Add(3 * wsPingInterval))
+		var h [2]byte
+		if _, err := io.ReadFull(ws.rw, h[:]); err != nil {
+			return err
+		}
+		opcode, n := h[0]&0x0F, uint64(h[1]&0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+				return err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(ws.rw, ext[:]); err != nil {
+				return err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if h[1]&0x80 == 0 {
+			return errors.New("客户端帧未加掩码")
+		}
+		if n > wsMaxClientFrame {
+			return fmt.Errorf("客户端帧过大: %d", n)
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(ws.rw, mask[:]); err != nil {
+			return err
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(ws.rw, payload); err != nil {
+			return err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		switch opcode {
+		case wsOpClose:
+			return io.EOF
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -7,6 +7,8 @@ var (
 	proxyPool   = "" // 登录/发布代理池地址
 	userAgent   = "" // 浏览器 User-Agent
 	userDataDir = "" // 用户数据目录
+	recordDir   = "" // 录制目录，保存页面响应与 HTML 快照
+	replayDir   = "" // 回放目录，请求由录制结果响应
 )
 
 func InitHeadless(h bool) {
@@ -65,3 +67,23 @@ func SetUserDataDir(dir string) {
 func GetUserDataDir() string {
 	return userDataDir
 }
+
+// SetRecordDir 设置录制目录
+func SetRecordDir(dir string) {
+	recordDir = dir
+}
+
+// GetRecordDir 获取录制目录
+func GetRecordDir() string {
+	return recordDir
+}
+
+// SetReplayDir 设置回放目录
+func SetReplayDir(dir string) {
+	replayDir = dir
+}
+
+// GetReplayDir 获取回放目录
+func GetReplayDir() string {
+	return replayDir
+}
@@ -0,0 +1,219 @@
+package configs
+
+import "time"
+
+var (
+	drainTimeout = 2 * time.Minute // 退出时等待进行中操作完成的最长时间
+	apiToken     string            // HTTP/MCP 接口访问令牌，为空不校验
+
+	stateDir = "state" // 任务日志、发布历史等运行状态目录
+
+	browserIdleTimeout time.Duration // 浏览器空闲多久后自动关闭，0 不启用
+
+	maxReadTabs = 3 // 只读操作可并行使用的标签页数
+
+	maxConcurrent = 4  // 同时执行的浏览器操作数，<=0 不限制
+	maxQueue      = 10 // 排队等待的操作数上限
+
+	devToolsEnabled bool // 是否通过 HTTP 接口暴露浏览器 DevTools
+
+	readOnly bool // 只读模式，拒绝所有写操作
+
+	mentionWatchInterval  time.Duration // 评论和@轮询间隔，0 不启用
+	mentionWebhook        string        // 新评论和@推送地址，为空不推送
+	mentionWebhookSecret  string        // 推送签名密钥，为空不签名
+	followerWatchInterval time.Duration // 新增关注轮询间隔，0 不启用
+
+	eventSinks EventSinks // 事件推送配置
+
+	contentHooks ContentHooks // 发布/评论前的内容处理钩子
+
+	lang = "zh" // 接口提示与工具说明的默认语言，请求可通过 Accept-Language 覆盖
+
+	location = time.Local // 账号所在时区，定时发布与周期任务按该时区的当地时间计算
+)
+
+// SetLocation 设置账号所在时区
+func SetLocation(loc *time.Location) {
+	if loc != nil {
+		location = loc
+	}
+}
+
+// GetLocation 账号所在时区，未设置时为服务器本地时区
+func GetLocation() *time.Location {
+	return location
+}
+
+// SetLang 设置默认语言（zh 或 en）
+func SetLang(l string) {
+	if l != "" {
+		lang = l
+	}
+}
+
+// GetLang 默认语言
+func GetLang() string {
+	return lang
+}
+
+// ContentHooks 发布、评论前调用的外部内容处理服务
+type ContentHooks struct {
+	PrePublish string        // 发布图文/视频前调用的地址，为空不调用
+	PreComment string        // 发表评论/回复前调用的地址，为空不调用
+	Secret     string        // 请求签名密钥
+	Timeout    time.Duration // 单次调用超时
+	FailOpen   bool          // 钩子不可用时按原内容继续，默认中止操作
+}
+
+// SetContentHooks 设置内容处理钩子
+func SetContentHooks(h ContentHooks) {
+	if h.Timeout <= 0 {
+		h.Timeout = 30 * time.Second
+	}
+	contentHooks = h
+}
+
+// GetContentHooks 内容处理钩子配置
+func GetContentHooks() ContentHooks {
+	return contentHooks
+}
+
+// EventSinks 事件推送配置
+type EventSinks struct {
+	Webhooks []string // 接收全部事件 JSON 的地址
+	Secret   string   // webhook 签名密钥
+	Notify   []string // 群机器人地址（钉钉、飞书、企业微信、Slack）
+	Types    []string // 推送的事件类型，为空表示全部
+}
+
+// SetEventSinks 设置事件推送配置
+func SetEventSinks(s EventSinks) {
+	eventSinks = s
+}
+
+// GetEventSinks 事件推送配置
+func GetEventSinks() EventSinks {
+	return eventSinks
+}
+
+// SetMentionWatch 设置评论和@轮询间隔与推送地址
+func SetMentionWatch(interval time.Duration, webhook, secret string) {
+	mentionWatchInterval = interval
+	mentionWebhook = webhook
+	mentionWebhookSecret = secret
+}
+
+// GetMentionWatchInterval 评论和@轮询间隔，0 表示不启用
+func GetMentionWatchInterval() time.Duration {
+	return mentionWatchInterval
+}
+
+// GetMentionWebhook 新评论和@推送地址与签名密钥
+func GetMentionWebhook() (url, secret string) {
+	return mentionWebhook, mentionWebhookSecret
+}
+
+// SetFollowerWatchInterval 设置新增关注轮询间隔
+func SetFollowerWatchInterval(interval time.Duration) {
+	followerWatchInterval = interval
+}
+
+// GetFollowerWatchInterval 新增关注轮询间隔，0 表示不启用
+func GetFollowerWatchInterval() time.Duration {
+	return followerWatchInterval
+}
+
+// SetDevToolsEnabled 设置是否暴露浏览器 DevTools
+func SetDevToolsEnabled(enabled bool) {
+	devToolsEnabled = enabled
+}
+
+// IsDevToolsEnabled 是否暴露浏览器 DevTools
+func IsDevToolsEnabled() bool {
+	return devToolsEnabled
+}
+
+// SetReadOnly 设置只读模式
+func SetReadOnly(enabled bool) {
+	readOnly = enabled
+}
+
+// IsReadOnly 是否为只读模式
+func IsReadOnly() bool {
+	return readOnly
+}
+
+// SetDrainTimeout 设置退出时的排空等待时间
+func SetDrainTimeout(d time.Duration) {
+	if d > 0 {
+		drainTimeout = d
+	}
+}
+
+// GetDrainTimeout 获取退出时的排空等待时间
+func GetDrainTimeout() time.Duration {
+	return drainTimeout
+}
+
+// SetAPIToken 设置接口访问令牌
+func SetAPIToken(token string) {
+	apiToken = token
+}
+
+// GetAPIToken 获取接口访问令牌
+func GetAPIToken() string {
+	return apiToken
+}
+
+// SetConcurrency 设置并发操作数与排队上限
+func SetConcurrency(concurrent, queue int) {
+	maxConcurrent = concurrent
+	if queue >= 0 {
+		maxQueue = queue
+	}
+}
+
+// GetMaxConcurrent 获取并发操作数
+func GetMaxConcurrent() int {
+	return maxConcurrent
+}
+
+// GetMaxQueue 获取排队上限
+func GetMaxQueue() int {
+	return maxQueue
+}
+
+// SetStateDir 设置运行状态目录
+func SetStateDir(dir string) {
+	if dir != "" {
+		stateDir = dir
+	}
+}
+
+// GetStateDir 获取运行状态目录
+func GetStateDir() string {
+	return stateDir
+}
+
+// SetBrowserIdleTimeout 设置浏览器空闲关闭时间
+func SetBrowserIdleTimeout(d time.Duration) {
+	browserIdleTimeout = d
+}
+
+// GetBrowserIdleTimeout 获取浏览器空闲关闭时间
+func GetBrowserIdleTimeout() time.Duration {
+	return browserIdleTimeout
+}
+
+// SetMaxReadTabs 设置只读操作并行标签页数
+func SetMaxReadTabs(n int) {
+	if n > 0 {
+		maxReadTabs = n
+	}
+}
+
+// GetMaxReadTabs 获取只读操作并行标签页数
+func GetMaxReadTabs() int {
+	return maxReadTabs
+}
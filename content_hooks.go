@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+// 内容处理钩子：发布、评论前把草稿 POST 到外部服务（翻译、润色、合规审核等），使用返回的内容继续。
+//
+// 请求体：{"hook":"pre_publish","kind":"publish","draft":{"title":"...","content":"...","tags":["..."]}}
+// 评论时 hook 为 pre_comment，kind 为 comment 或 reply，并附带 feed_id，draft 只有 content。
+// 响应体中出现的字段替换原内容，未出现的保持不变；返回 204 或空响应表示不修改；
+// 返回 {"reject":true,"reason":"..."} 中止本次操作。配置了 XHS_HOOK_SECRET 时附带 X-XHS-Signature 签名。
+const (
+	hookPrePublish = "pre_publish"
+	hookPreComment = "pre_comment"
+)
+
+// errContentRejected 内容被钩子拒绝
+var errContentRejected = errors.New("内容被处理钩子拒绝")
+
+type hookDraft struct {
+	Title   string   `json:"title,omitempty"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type hookRequest struct {
+	Hook   string    `json:"hook"`
+	Kind   string    `json:"kind"`
+	FeedID string    `json:"feed_id,omitempty"`
+	Draft  hookDraft `json:"draft"`
+}
+
+type hookResponse struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Tags    *[]string `json:"tags"`
+	Reject  bool      `json:"reject"`
+	Reason  string    `json:"reason"`
+}
+
+type skipHooksKey struct{}
+
+// withoutContentHooks 重放崩溃前的任务时内容已处理过，不再调用钩子
+func withoutContentHooks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipHooksKey{}, true)
+}
+
+// applyPublishHook 发布前处理标题、正文和标签
+func applyPublishHook(ctx context.Context, kind string, title, content *string, tags *[]string) error {
+	hooks := configs.GetContentHooks()
+	if hooks.PrePublish == "" || ctx.Value(skipHooksKey{}) != nil {
+		return nil
+	}
+	draft := hookDraft{Title: *title, Content: *content, Tags: *tags}
+	out, err := callContentHook(ctx, hooks, hooks.PrePublish, hookRequest{Hook: hookPrePublish, Kind: kind, Draft: draft})
+	if err != nil {
+		return err
+	}
+	*title, *content, *tags = out.Title, out.Content, out.Tags
+	return nil
+}
+
+// applyCommentHook 发表评论/回复前处理内容
+func applyCommentHook(ctx context.Context, kind, feedID, content string) (string, error) {
+	hooks := configs.GetContentHooks()
+	if hooks.PreComment == "" || ctx.Value(skipHooksKey{}) != nil {
+		return content, nil
+	}
+	out, err := callContentHook(ctx, hooks, hooks.PreComment, hookRequest{Hook: hookPreComment, Kind: kind, FeedID: feedID, Draft: hookDraft{Content: content}})
+	if err != nil {
+		return "", err
+	}
+	return out.Content, nil
+}
+
+// callContentHook 调用钩子并合并返回内容；FailOpen 时调用失败按原内容继续，但拒绝始终生效
+func callContentHook(ctx context.Context, hooks configs.ContentHooks, url string, req hookRequest) (hookDraft, error) {
+	resp, err := postContentHook(ctx, hooks, url, req)
+	if err != nil {
+		if hooks.FailOpen {
+			logrus.Warnf("内容处理钩子 %s 调用失败，按原内容继续: %v", req.Hook, err)
+			return req.Draft, nil
+		}
+		return hookDraft{}, fmt.Errorf("内容处理钩子 %s 调用失败: %w", req.Hook, err)
+	}
+	if resp == nil {
+		return req.Draft, nil
+	}
+	if resp.Reject {
+		reason := resp.Reason
+		if reason == "" {
+			reason = "未说明原因"
+		}
+		return hookDraft{}, fmt.Errorf("%w: %s", errContentRejected, reason)
+	}
+
+	out := req.Draft
+	if resp.Title != nil {
+		out.Title = *resp.Title
+	}
+	if resp.Content != nil {
+		out.Content = *resp.Content
+	}
+	if resp.Tags != nil {
+		out.Tags = *resp.Tags
+	}
+	if strings.TrimSpace(out.Content) == "" {
+		return hookDraft{}, fmt.Errorf("内容处理钩子 %s 返回的内容为空", req.Hook)
+	}
+	if out.Title != req.Draft.Title || out.Content != req.Draft.Content {
+		logrus.Infof("内容处理钩子 %s 修改了内容", req.Hook)
+	}
+	return out, nil
+}
+
+func postContentHook(ctx context.Context, hooks configs.ContentHooks, url string, req hookRequest) (*hookResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, hooks.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-XHS-Hook", req.Hook)
+	if hooks.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hooks.Secret))
+		mac.Write(body)
+		httpReq.Header.Set("X-XHS-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var out hookResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return &out, nil
+}
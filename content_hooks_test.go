@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+func TestContentHooks(t *testing.T) {
+	var last hookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&last))
+		switch last.Draft.Content {
+		case "原文":
+			_, _ = w.Write([]byte(`{"content":"译文","tags":["travel"]}`))
+		case "违规":
+			_, _ = w.Write([]byte(`{"reject":true,"reason":"包含敏感词"}`))
+		case "不变":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { configs.SetContentHooks(configs.ContentHooks{}) })
+	configs.SetContentHooks(configs.ContentHooks{PrePublish: srv.URL, PreComment: srv.URL})
+	ctx := context.Background()
+
+	title, content, tags := "标题", "原文", []string{"旅行"}
+	require.NoError(t, applyPublishHook(ctx, taskKindPublish, &title, &content, &tags))
+	require.Equal(t, hookPrePublish, last.Hook)
+	require.Equal(t, "标题", title)
+	require.Equal(t, "译文", content)
+	require.Equal(t, []string{"travel"}, tags)
+
+	got, err := applyCommentHook(ctx, taskKindReply, "note1", "不变")
+	require.NoError(t, err)
+	require.Equal(t, "不变", got)
+	require.Equal(t, "note1", last.FeedID)
+
+	_, err = applyCommentHook(ctx, taskKindComment, "note1", "违规")
+	require.True(t, errors.Is(err, errContentRejected))
+
+	_, err = applyCommentHook(ctx, taskKindComment, "note1", "出错")
+	require.Error(t, err)
+
+	// 失败放行时按原内容继续；重放任务不再调用钩子
+	configs.SetContentHooks(configs.ContentHooks{PreComment: srv.URL, FailOpen: true})
+	got, err = applyCommentHook(ctx, taskKindComment, "note1", "出错")
+	require.NoError(t, err)
+	require.Equal(t, "出错", got)
+
+	got, err = applyCommentHook(withoutContentHooks(ctx), taskKindComment, "note1", "违规")
+	require.NoError(t, err)
+	require.Equal(t, "违规", got)
+}
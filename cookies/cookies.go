@@ -2,9 +2,9 @@ package cookies
 
 import (
 	"os"
-	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
 )
 
 type Cookier interface {
@@ -27,7 +27,7 @@ func NewLoadCookie(path string) Cookier {
 	}
 }
 
-// LoadCookies 从文件中加载 cookies。
+// LoadCookies 从文件中加载 cookies，加密的文件自动解密。
 func (c *localCookie) LoadCookies() ([]byte, error) {
 
 	data, err := os.ReadFile(c.path)
@@ -35,18 +35,21 @@ func (c *localCookie) LoadCookies() ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to read cookies from tmp file")
 	}
 
-	return data, nil
+	return Decrypt(data)
 }
 
 // SaveCookies 保存 cookies 到文件中。
+// 采用临时文件 + rename 原子替换，写入中途崩溃不会留下损坏的 JSON。
+// 配置了 XHS_COOKIES_KEY 时加密保存。
 func (c *localCookie) SaveCookies(data []byte) error {
-	// 确保目录存在
-	if dir := filepath.Dir(c.path); dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return errors.Wrap(err, "failed to create cookies directory")
-		}
+	data, err := Encrypt(data)
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to save cookies")
 	}
-	return os.WriteFile(c.path, data, 0644)
+	return nil
 }
 
 // DeleteCookies 删除 cookies 文件。
@@ -0,0 +1,160 @@
+package cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// cookies 文件静态加密：配置密钥后以 AES-256-GCM 加密保存，读取时自动解密。
+// 未加密的旧文件照常读取，下次保存时加密；空数组不含登录信息，保持明文。
+// 文件格式：前缀 + base64(salt(16) | nonce(12) | 密文)，密钥由口令与 salt 经 PBKDF2 派生。
+
+// EnvKey 加密密钥的环境变量
+const EnvKey = "XHS_COOKIES_KEY"
+
+const (
+	keySaltSize = 16
+	keyKDFIter  = 200_000
+)
+
+// encryptedMagic 加密文件的前缀
+var encryptedMagic = []byte("xhs-cookies-enc:v1:")
+
+var (
+	ErrNoKey  = errors.New("cookies 文件已加密，但未配置 " + EnvKey)
+	ErrBadKey = errors.New("cookies 解密失败：密钥不匹配或文件已损坏")
+)
+
+// keyState 口令与派生密钥缓存。本进程写入时使用同一个随机 salt，只需派生一次
+type keyState struct {
+	secret string
+	salt   []byte
+
+	mu      sync.Mutex
+	derived map[string]cipher.AEAD // salt -> AEAD
+}
+
+var (
+	keyOnce sync.Once
+	keyMu   sync.RWMutex
+	current *keyState
+)
+
+// SetKey 设置加密口令，空字符串关闭加密。未调用时从 XHS_COOKIES_KEY 读取
+func SetKey(secret string) {
+	keyOnce.Do(func() {})
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	current = newKeyState(secret)
+}
+
+func currentKey() *keyState {
+	keyOnce.Do(func() { current = newKeyState(os.Getenv(EnvKey)) })
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return current
+}
+
+func newKeyState(secret string) *keyState {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return nil
+	}
+	salt := make([]byte, keySaltSize)
+	_, _ = rand.Read(salt) // crypto/rand.Read 不会返回错误
+	return &keyState{secret: secret, salt: salt, derived: map[string]cipher.AEAD{}}
+}
+
+// aead 按 salt 派生密钥，结果缓存
+func (k *keyState) aead(salt []byte) (cipher.AEAD, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if gcm, ok := k.derived[string(salt)]; ok {
+		return gcm, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, k.secret, salt, keyKDFIter, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	k.derived[string(salt)] = gcm
+	return gcm, nil
+}
+
+// Enabled 是否配置了加密密钥
+func Enabled() bool {
+	return currentKey() != nil
+}
+
+// IsEncrypted 判断数据是否为加密格式
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic)
+}
+
+// Encrypt 加密 cookies 数据。未配置密钥、已加密或为空数组时原样返回
+func Encrypt(data []byte) ([]byte, error) {
+	k := currentKey()
+	if k == nil || IsEncrypted(data) || len(bytes.TrimSpace(data)) <= 2 {
+		return data, nil
+	}
+	gcm, err := k.aead(k.salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive cookies key")
+	}
+	payload := make([]byte, keySaltSize+gcm.NonceSize(), keySaltSize+gcm.NonceSize()+len(data)+gcm.Overhead())
+	copy(payload, k.salt)
+	if _, err := rand.Read(payload[keySaltSize:]); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	payload = gcm.Seal(payload, payload[keySaltSize:], data, encryptedMagic)
+	out := make([]byte, len(encryptedMagic)+base64.StdEncoding.EncodedLen(len(payload)))
+	copy(out, encryptedMagic)
+	base64.StdEncoding.Encode(out[len(encryptedMagic):], payload)
+	return out, nil
+}
+
+// Decrypt 解密 cookies 数据，未加密的数据原样返回
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	k := currentKey()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	payload, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data[len(encryptedMagic):])))
+	if err != nil || len(payload) < keySaltSize {
+		return nil, ErrBadKey
+	}
+	gcm, err := k.aead(payload[:keySaltSize])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive cookies key")
+	}
+	payload = payload[keySaltSize:]
+	if len(payload) < gcm.NonceSize() {
+		return nil, ErrBadKey
+	}
+	n := gcm.NonceSize()
+	plain, err := gcm.Open(nil, payload[:n], payload[n:], encryptedMagic)
+	if err != nil {
+		return nil, ErrBadKey
+	}
+	return plain, nil
+}
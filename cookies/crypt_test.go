@@ -0,0 +1,61 @@
+package cookies
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedCookieFile(t *testing.T) {
+	t.Cleanup(func() { SetKey("") })
+	path := filepath.Join(t.TempDir(), "cookies.json")
+
+	// 未配置密钥时写入的明文文件，配置后照常读取
+	SetKey("")
+	require.NoError(t, NewLoadCookie(path).SaveCookies([]byte(sampleCookies)))
+	SetKey("correct horse battery staple")
+	require.True(t, Enabled())
+	data, err := NewLoadCookie(path).LoadCookies()
+	require.NoError(t, err)
+	require.Equal(t, sampleCookies, string(data))
+
+	require.NoError(t, NewLoadCookie(path).SaveCookies([]byte(sampleCookies)))
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(raw))
+	require.NotContains(t, string(raw), "abcdef0123456789")
+
+	data, err = NewLoadCookie(path).LoadCookies()
+	require.NoError(t, err)
+	require.Equal(t, sampleCookies, string(data))
+
+	// 重新设置同一口令（如进程重启）生成新的 salt，仍可解密已有文件
+	SetKey("correct horse battery staple")
+	data, err = NewLoadCookie(path).LoadCookies()
+	require.NoError(t, err)
+	require.Equal(t, sampleCookies, string(data))
+	other, err := Encrypt([]byte(sampleCookies))
+	require.NoError(t, err)
+	require.NotEqual(t, raw[:len(encryptedMagic)+24], other[:len(encryptedMagic)+24], "不同进程应使用不同的 salt")
+
+	again, err := Encrypt(raw)
+	require.NoError(t, err)
+	require.Equal(t, raw, again, "已加密的数据不应重复加密")
+
+	SetKey("wrong")
+	_, err = NewLoadCookie(path).LoadCookies()
+	require.ErrorIs(t, err, ErrBadKey)
+	SetKey("")
+	_, err = NewLoadCookie(path).LoadCookies()
+	require.ErrorIs(t, err, ErrNoKey)
+}
+
+func TestEncryptKeepsEmptyPlain(t *testing.T) {
+	t.Cleanup(func() { SetKey("") })
+	SetKey("k")
+	out, err := Encrypt([]byte("[]"))
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(out))
+}
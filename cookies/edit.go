@@ -0,0 +1,180 @@
+package cookies
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDomain 编辑 cookie 时未指定 domain 使用的默认值
+const DefaultDomain = ".xiaohongshu.com"
+
+// Cookie cookies 文件中单条 cookie 的结构化视图。
+// 文件格式为浏览器导出的 NetworkCookie 数组，编辑时保留未列出的字段。
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Expires  *time.Time `json:"expires,omitempty"` // 会话 cookie 为空
+	HTTPOnly bool       `json:"http_only"`
+	Secure   bool       `json:"secure"`
+	SameSite string     `json:"same_site,omitempty"`
+}
+
+// Expired 是否已过期（会话 cookie 不会过期）
+func (c Cookie) Expired(now time.Time) bool {
+	return c.Expires != nil && !c.Expires.After(now)
+}
+
+// Masked 隐藏 value 的大部分内容，用于列表展示
+func (c Cookie) Masked() Cookie {
+	if n := len(c.Value); n > 8 {
+		c.Value = c.Value[:4] + strings.Repeat("*", 4) + c.Value[n-2:]
+	} else if n > 0 {
+		c.Value = strings.Repeat("*", n)
+	}
+	return c
+}
+
+// Parse 解析 cookies 文件内容；空内容视为没有 cookie
+func Parse(data []byte) ([]Cookie, error) {
+	raw, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Cookie, 0, len(raw))
+	for _, m := range raw {
+		out = append(out, fromMap(m))
+	}
+	return out, nil
+}
+
+// Upsert 按 name+domain+path 新增或替换一条 cookie，返回新的文件内容
+func Upsert(data []byte, c Cookie) ([]byte, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return nil, errors.New("cookie name 不能为空")
+	}
+	if c.Domain = strings.TrimSpace(c.Domain); c.Domain == "" {
+		c.Domain = DefaultDomain
+	}
+	if c.Path = strings.TrimSpace(c.Path); c.Path == "" {
+		c.Path = "/"
+	}
+
+	raw, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var target map[string]any
+	for _, m := range raw {
+		if matches(m, c.Name, c.Domain, c.Path) {
+			target = m
+			break
+		}
+	}
+	if target == nil {
+		target = map[string]any{}
+		raw = append(raw, target)
+	}
+
+	target["name"] = c.Name
+	target["value"] = c.Value
+	target["domain"] = c.Domain
+	target["path"] = c.Path
+	target["httpOnly"] = c.HTTPOnly
+	target["secure"] = c.Secure
+	target["size"] = len(c.Name) + len(c.Value)
+	if c.SameSite != "" {
+		target["sameSite"] = c.SameSite
+	} else {
+		delete(target, "sameSite")
+	}
+	if c.Expires != nil {
+		target["expires"] = float64(c.Expires.Unix())
+		target["session"] = false
+	} else {
+		target["expires"] = float64(-1)
+		target["session"] = true
+	}
+	return json.Marshal(raw)
+}
+
+// Remove 删除匹配的 cookie，domain/path 为空时匹配任意值；返回新的文件内容与删除条数
+func Remove(data []byte, name, domain, path string) ([]byte, int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, 0, errors.New("cookie name 不能为空")
+	}
+	raw, err := decode(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	kept := raw[:0]
+	for _, m := range raw {
+		if matches(m, name, strings.TrimSpace(domain), strings.TrimSpace(path)) {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	removed := len(raw) - len(kept)
+	out, err := json.Marshal(kept)
+	return out, removed, err
+}
+
+func decode(data []byte) ([]map[string]any, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return []map[string]any{}, nil
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "cookies 文件不是有效的 JSON 数组")
+	}
+	out := raw[:0]
+	for _, m := range raw {
+		if m != nil {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func matches(m map[string]any, name, domain, path string) bool {
+	if str(m, "name") != name {
+		return false
+	}
+	if domain != "" && str(m, "domain") != domain {
+		return false
+	}
+	if path != "" && str(m, "path") != path {
+		return false
+	}
+	return true
+}
+
+func fromMap(m map[string]any) Cookie {
+	c := Cookie{
+		Name:     str(m, "name"),
+		Value:    str(m, "value"),
+		Domain:   str(m, "domain"),
+		Path:     str(m, "path"),
+		SameSite: str(m, "sameSite"),
+	}
+	c.HTTPOnly, _ = m["httpOnly"].(bool)
+	c.Secure, _ = m["secure"].(bool)
+	if session, _ := m["session"].(bool); !session {
+		if exp, ok := m["expires"].(float64); ok && exp > 0 {
+			t := time.Unix(int64(exp), 0)
+			c.Expires = &t
+		}
+	}
+	return c
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
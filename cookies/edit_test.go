@@ -0,0 +1,69 @@
+package cookies
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleCookies = `[
+	{"name":"web_session","value":"abcdef0123456789","domain":".xiaohongshu.com","path":"/","expires":1900000000,"httpOnly":true,"secure":true,"session":false,"priority":"Medium"},
+	{"name":"a1","value":"x","domain":".xiaohongshu.com","path":"/","expires":-1,"session":true}
+]`
+
+func TestParse(t *testing.T) {
+	list, err := Parse([]byte(sampleCookies))
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "web_session", list[0].Name)
+	require.NotNil(t, list[0].Expires)
+	require.Equal(t, int64(1900000000), list[0].Expires.Unix())
+	require.True(t, list[0].HTTPOnly)
+	require.Nil(t, list[1].Expires)
+
+	require.Equal(t, "abcd****89", list[0].Masked().Value)
+
+	empty, err := Parse(nil)
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+func TestUpsertKeepsUnknownFields(t *testing.T) {
+	exp := time.Unix(2000000000, 0)
+	out, err := Upsert([]byte(sampleCookies), Cookie{Name: "web_session", Value: "new", Expires: &exp, HTTPOnly: true})
+	require.NoError(t, err)
+
+	var raw []map[string]any
+	require.NoError(t, json.Unmarshal(out, &raw))
+	require.Len(t, raw, 2)
+	require.Equal(t, "new", raw[0]["value"])
+	require.Equal(t, float64(2000000000), raw[0]["expires"])
+	require.Equal(t, "Medium", raw[0]["priority"])
+
+	out, err = Upsert(out, Cookie{Name: "extra", Value: "1"})
+	require.NoError(t, err)
+	list, err := Parse(out)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, DefaultDomain, list[2].Domain)
+	require.Equal(t, "/", list[2].Path)
+	require.Nil(t, list[2].Expires)
+}
+
+func TestRemove(t *testing.T) {
+	out, n, err := Remove([]byte(sampleCookies), "a1", "", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	list, err := Parse(out)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	_, n, err = Remove([]byte(sampleCookies), "a1", ".other.com", "")
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	_, _, err = Remove([]byte(sampleCookies), " ", "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+var errBrowserNotRunning = errors.New("浏览器未启动")
+
+// DevToolsURL 返回共享浏览器的远程调试地址；浏览器未启动时不会主动拉起
+func (s *XiaohongshuService) DevToolsURL() (string, error) {
+	s.browserMu.Lock()
+	b := s.sharedBrowser
+	if b != nil {
+		s.browserLastUsed = time.Now()
+	}
+	s.browserMu.Unlock()
+	if b == nil {
+		return "", errBrowserNotRunning
+	}
+	return b.DevToolsURL()
+}
+
+// devToolsProxyHandler 把 /api/v1/debug/devtools/* 反向代理到 Chrome 远程调试端口，
+// 包括 /json/list、内置 DevTools 前端以及页面的 websocket 调试连接。
+// 仅在 -enable-devtools 时注册，且与其它接口一样受访问令牌保护。
+func (s *AppServer) devToolsProxyHandler(c *gin.Context) {
+	base, err := s.xiaohongshuService.DevToolsURL()
+	if err != nil {
+		respondError(c, http.StatusConflict, "BROWSER_NOT_RUNNING", "浏览器未启动，请先执行任意操作或获取登录二维码", err.Error())
+		return
+	}
+	target, err := url.Parse(base)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "DEVTOOLS_UNAVAILABLE", "调试地址无效", err.Error())
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = c.Param("path")
+			req.URL.RawPath = ""
+			// Chrome 只接受 IP/localhost 的 Host，且会拒绝带 Origin 的 websocket 连接
+			req.Host = target.Host
+			req.Header.Del("Origin")
+			req.Header.Del("Authorization")
+			req.Header.Del("X-API-Token")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logrus.Warnf("DevTools 代理失败: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
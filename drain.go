@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainTracker 跟踪进行中的请求，退出时先拒绝新请求再等待已有请求完成。
+type drainTracker struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	draining bool
+	inflight int
+}
+
+func newDrainTracker() *drainTracker {
+	t := &drainTracker{}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// Acquire 登记一个新请求；排空中返回 false。
+func (t *drainTracker) Acquire() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return false
+	}
+	t.inflight++
+	return true
+}
+
+// Release 请求结束
+func (t *drainTracker) Release() {
+	t.mu.Lock()
+	t.inflight--
+	if t.inflight <= 0 {
+		t.inflight = 0
+		t.cond.Broadcast()
+	}
+	t.mu.Unlock()
+}
+
+// BeginDrain 进入排空状态，之后的新请求一律拒绝
+func (t *drainTracker) BeginDrain() {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+}
+
+// Draining 是否处于排空状态
+func (t *drainTracker) Draining() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.draining
+}
+
+// InFlight 当前进行中的请求数
+func (t *drainTracker) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inflight
+}
+
+// Wait 等待进行中的请求全部完成，超时返回 false
+func (t *drainTracker) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.mu.Lock()
+		for t.inflight > 0 {
+			t.cond.Wait()
+		}
+		t.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// drainMiddleware 排空期间拒绝新请求，并统计进行中的请求。
+// 健康/存活检查、MCP 的 GET 长连接和事件 SSE 不计入，避免阻塞退出。
+func drainMiddleware(t *drainTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path == "/health" || path == "/livez" || (c.Request.Method == http.MethodGet && (path == "/mcp" || strings.HasPrefix(path, "/mcp/") || path == "/api/v1/events/stream" || path == "/api/v1/mentions/stream")) {
+			c.Next()
+			return
+		}
+
+		if !t.Acquire() {
+			respondError(c, http.StatusServiceUnavailable, "SERVER_DRAINING",
+				"服务正在关闭，暂不接受新请求", nil)
+			c.Abort()
+			return
+		}
+		defer t.Release()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainTrackerRejectsNewRequestsAndWaits(t *testing.T) {
+	tr := newDrainTracker()
+	if !tr.Acquire() {
+		t.Fatalf("未排空时应允许新请求")
+	}
+
+	tr.BeginDrain()
+	if tr.Acquire() {
+		t.Fatalf("排空中不应接受新请求")
+	}
+	if tr.Wait(20 * time.Millisecond) {
+		t.Fatalf("仍有进行中的请求时 Wait 应超时")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tr.Release()
+	}()
+	if !tr.Wait(time.Second) {
+		t.Fatalf("请求结束后 Wait 应返回 true")
+	}
+	if n := tr.InFlight(); n != 0 {
+		t.Fatalf("进行中请求数应为 0，got=%d", n)
+	}
+}
@@ -5,3 +5,4 @@ import "errors"
 var ErrNoFeeds = errors.New("没有捕获到 feeds 数据")
 var ErrNoFeedDetail = errors.New("没有捕获到 feed 详情数据")
 var ErrNoNotificationMentions = errors.New("没有捕获到评论和@通知数据")
+var ErrNoNotificationConnections = errors.New("没有捕获到新增关注通知数据")
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+// 事件类型
+const (
+	eventNotePublished   = "note_published"   // 图文/视频发布成功
+	eventCommentReceived = "comment_received" // 评论和@监控发现新通知
+	eventLoginExpired    = "login_expired"    // cookies 失效
+	eventCaptcha         = "captcha"          // 页面触发验证码
+	eventWriteFailed     = "write_failed"     // 发布、评论、回复失败
+	eventJobFailed       = "job_failed"       // 定时任务执行失败
+	eventJobFinished     = "job_finished"     // 定时任务执行结束（成功或失败）
+	eventNewFollower     = "new_follower"     // 新增关注监控发现新粉丝
+)
+
+const eventStreamHeartbeat = 15 * time.Second
+
+// bus 全局事件总线，业务代码只发布事件，推送方式在 setupEventSinks 中统一注册
+var bus = eventbus.New()
+
+// setupEventSinks 按配置注册日志、webhook、群机器人与自动回复订阅方
+func (s *AppServer) setupEventSinks() {
+	bus.OnError = func(sink string, ev eventbus.Event, err error) {
+		logrus.Warnf("事件 %s（%s）推送到 %s 失败: %v", ev.ID, ev.Type, sink, err)
+	}
+	bus.Subscribe("log", eventbus.SinkFunc(func(_ context.Context, ev eventbus.Event) error {
+		logrus.Infof("事件 %s: %s", ev.Type, ev.Summary)
+		return nil
+	}))
+
+	sinks := configs.GetEventSinks()
+	for i, u := range sinks.Webhooks {
+		bus.Subscribe(fmt.Sprintf("webhook-%d", i+1), eventbus.NewWebhookSink(u, sinks.Secret), sinks.Types...)
+	}
+	for i, u := range sinks.Notify {
+		bus.Subscribe(fmt.Sprintf("notify-%d", i+1), eventbus.NewChatSink(u), sinks.Types...)
+	}
+	// -mention-webhook 只接收评论和@
+	if u, secret := configs.GetMentionWebhook(); u != "" {
+		bus.Subscribe("mention-webhook", eventbus.NewWebhookSink(u, secret), eventCommentReceived)
+	}
+
+	if s.autoReply != nil {
+		bus.Subscribe("autoreply", eventbus.SinkFunc(func(ctx context.Context, ev eventbus.Event) error {
+			if m, ok := ev.Data.(MentionEvent); ok {
+				s.autoReply.Handle(ctx, []MentionEvent{m})
+			}
+			return nil
+		}), eventCommentReceived)
+	}
+	s.triggers.start()
+}
+
+// publishActivity 账号动态同时作为事件发布
+func publishActivity(r ActivityRecord) {
+	switch {
+	case r.Kind == activityLoginExpired:
+		bus.Publish(eventLoginExpired, "登录已失效，需要重新登录", r)
+	case r.Kind == activityCaptcha:
+		bus.Publish(eventCaptcha, "页面触发验证码: "+r.Subject, r)
+	case r.Status == mcpCallError:
+		bus.Publish(eventWriteFailed, fmt.Sprintf("%s 失败（%s）: %s", r.Kind, r.Subject, r.Error), r)
+	case r.Kind == taskKindPublish || r.Kind == taskKindPublishVideo:
+		bus.Publish(eventNotePublished, "发布成功: "+r.Subject, r)
+	}
+}
+
+// parseEventTypes 解析 ?types=a,b
+func parseEventTypes(c *gin.Context) []string {
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// listEventsHandler 最近事件与各订阅方投递统计
+// GET /api/v1/events?types=a,b&limit=N
+func (s *AppServer) listEventsHandler(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit 需为正整数", nil)
+			return
+		}
+		limit = n
+	}
+	respondSuccess(c, gin.H{
+		"events":  bus.Recent(parseEventTypes(c), limit),
+		"sinks":   bus.Stats(),
+		"streams": bus.StreamCount(),
+	}, "获取事件成功")
+}
+
+// eventStreamHandler 以 SSE 推送事件；支持 ?types= 过滤、?replay=N 回放，断线重连时按 Last-Event-ID 补发
+// GET /api/v1/events/stream
+func (s *AppServer) eventStreamHandler(c *gin.Context) {
+	streamEvents(c, parseEventTypes(c))
+}
+
+func streamEvents(c *gin.Context, types []string) {
+	replay := 0
+	if v := c.Query("replay"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(c, http.StatusBadRequest, "INVALID_REPLAY", "replay 需为非负整数", nil)
+			return
+		}
+		replay = n
+	}
+	ch, backlog, cancel, ok := bus.Stream(types, c.GetHeader("Last-Event-ID"), replay)
+	if !ok {
+		respondError(c, http.StatusServiceUnavailable, "SERVER_DRAINING", "服务正在关闭，暂不接受新请求", nil)
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for _, ev := range backlog {
+		writeSSEEvent(c, ev)
+	}
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeSSEEvent(c, ev)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeSSEEvent(c *gin.Context, ev eventbus.Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	c.Writer.Flush()
+}
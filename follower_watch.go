@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 新增关注监控：按间隔轮询通知页「新增关注」，新粉丝作为 new_follower 事件发布。
+// 与评论和@监控共用去重逻辑，首次轮询同样只记录不推送。
+
+// StartFollowerWatcher 启动新增关注轮询，interval<=0 表示不启用
+func (s *AppServer) StartFollowerWatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.followers == nil {
+		return
+	}
+	logrus.Infof("新增关注监控已启用，轮询间隔 %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.pollFollowers(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// pollFollowers 拉取一次新增关注并发布新增项；并发已满时跳过本轮
+func (s *AppServer) pollFollowers(ctx context.Context) {
+	release, err := s.limiter.Acquire(ctx)
+	if err != nil {
+		logrus.Debugf("跳过本轮新增关注轮询: %v", err)
+		return
+	}
+	defer release()
+
+	pollCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	resp, err := s.xiaohongshuService.GetNotificationConnections(pollCtx)
+	if err != nil {
+		logrus.Warnf("轮询新增关注失败: %v", err)
+		s.followers.setResult(err)
+		return
+	}
+	s.followers.setResult(nil)
+
+	for _, ev := range s.followers.observe(resp.Notifications, time.Now()) {
+		nickname := firstNonEmpty(mapString(ev.Mention.UserInfo, "nickname"), "有人")
+		bus.Publish(eventNewFollower, nickname+" 关注了你", ev)
+	}
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/chromecookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/taskqueue"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +31,20 @@ func respondError(c *gin.Context, statusCode int, code, message string, details
 	c.JSON(statusCode, response)
 }
 
+// respondBlocked 浏览器操作被拦截时返回明确的错误码：反爬拦截页为 CAPTCHA_REQUIRED，
+// 只读模式下的写操作为 READ_ONLY，便于调用方区分处理
+func respondBlocked(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, xiaohongshu.ErrCaptchaRequired):
+		respondError(c, http.StatusForbidden, "CAPTCHA_REQUIRED", "页面触发安全验证，需要人工处理", err.Error())
+	case errors.Is(err, errReadOnly):
+		respondError(c, http.StatusForbidden, "READ_ONLY", "当前账号为只读模式，已拒绝写操作", nil)
+	default:
+		return false
+	}
+	return true
+}
+
 // respondSuccess 返回成功响应
 func respondSuccess(c *gin.Context, data any, message string) {
 	response := SuccessResponse{
@@ -42,6 +63,9 @@ func respondSuccess(c *gin.Context, data any, message string) {
 func (s *AppServer) checkLoginStatusHandler(c *gin.Context) {
 	status, err := s.xiaohongshuService.CheckLoginStatus(c.Request.Context())
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
 			"检查登录状态失败", err.Error())
 		return
@@ -56,6 +80,9 @@ func (s *AppServer) checkLoginStatusHandler(c *gin.Context) {
 func (s *AppServer) getLoginQrcodeHandler(c *gin.Context) {
 	result, err := s.xiaohongshuService.GetLoginQrcode(c.Request.Context())
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
 			"获取登录二维码失败", err.Error())
 		return
@@ -74,6 +101,24 @@ func (s *AppServer) getLoginBrowserScreenshotHandler(c *gin.Context) {
 	c.Data(http.StatusOK, "image/png", img)
 }
 
+// pageScreenshotHandler 截取浏览器当前页面，响应头 X-Page-URL 为页面地址
+func (s *AppServer) pageScreenshotHandler(c *gin.Context) {
+	img, url, err := s.xiaohongshuService.CapturePageScreenshot(c.Request.Context())
+	if errors.Is(err, errNoOpenPage) {
+		respondError(c, http.StatusNotFound, "NO_OPEN_PAGE", "浏览器没有打开的页面", err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "SCREENSHOT_FAILED", "截图失败", err.Error())
+		return
+	}
+	if url != "" {
+		c.Header("X-Page-URL", url)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", img)
+}
+
 // BrowserActionRequest 浏览器交互请求
 type BrowserActionRequest struct {
 	Type string  `json:"type"`
@@ -114,6 +159,127 @@ func (s *AppServer) deleteCookiesHandler(c *gin.Context) {
 	}, "删除 cookies 成功")
 }
 
+// CookieEditRequest 新增/修改单条 cookie
+type CookieEditRequest struct {
+	cookies.Cookie
+	Validate bool `json:"validate,omitempty"` // 修改后检查登录状态
+}
+
+// listCookiesHandler 结构化列出 cookies，默认隐藏 value，?reveal=true 显示完整值
+func (s *AppServer) listCookiesHandler(c *gin.Context) {
+	list, err := s.xiaohongshuService.ListCookies()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "LIST_COOKIES_FAILED", "读取 cookies 失败", err.Error())
+		return
+	}
+	reveal := c.Query("reveal") == "true"
+	now := time.Now()
+	expired := 0
+	for i := range list {
+		if list[i].Expired(now) {
+			expired++
+		}
+		if !reveal {
+			list[i] = list[i].Masked()
+		}
+	}
+	respondSuccess(c, map[string]any{
+		"cookies": list,
+		"count":   len(list),
+		"expired": expired,
+	}, "获取 cookies 成功")
+}
+
+// upsertCookieHandler 新增或修改单条 cookie，运行中的浏览器立即生效
+func (s *AppServer) upsertCookieHandler(c *gin.Context) {
+	var req CookieEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "参数错误", err.Error())
+		return
+	}
+	if err := s.xiaohongshuService.UpsertCookie(req.Cookie); err != nil {
+		respondError(c, http.StatusBadRequest, "EDIT_COOKIE_FAILED", "修改 cookie 失败", err.Error())
+		return
+	}
+	data := map[string]any{"name": req.Name}
+	if req.Validate {
+		data["login"] = s.validateLogin(c)
+	}
+	respondSuccess(c, data, "修改 cookie 成功")
+}
+
+// removeCookieHandler 删除单条 cookie，可用 ?domain=&path= 精确匹配
+func (s *AppServer) removeCookieHandler(c *gin.Context) {
+	removed, err := s.xiaohongshuService.RemoveCookie(c.Param("name"), c.Query("domain"), c.Query("path"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "DELETE_COOKIE_FAILED", "删除 cookie 失败", err.Error())
+		return
+	}
+	if removed == 0 {
+		respondError(c, http.StatusNotFound, "COOKIE_NOT_FOUND", "cookie 不存在", nil)
+		return
+	}
+	data := map[string]any{"name": c.Param("name"), "removed": removed}
+	if c.Query("validate") == "true" {
+		data["login"] = s.validateLogin(c)
+	}
+	respondSuccess(c, data, "删除 cookie 成功")
+}
+
+// BrowserCookieImportRequest 从本机浏览器导入 cookies
+type BrowserCookieImportRequest struct {
+	Browser     string `json:"browser"`       // chrome、edge、chromium，默认 chrome
+	UserDataDir string `json:"user_data_dir"` // 为空使用系统默认位置
+	Profile     string `json:"profile"`       // 默认 Default
+	Validate    bool   `json:"validate"`      // 导入后检查登录状态
+}
+
+// importBrowserCookiesHandler 读取本机 Chrome/Edge 中已登录的小红书 cookies 并导入，免去扫码登录
+// 只能读取运行本服务的机器上、同一系统用户的浏览器数据
+func (s *AppServer) importBrowserCookiesHandler(c *gin.Context) {
+	var req BrowserCookieImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "参数错误", err.Error())
+		return
+	}
+	list, err := chromecookies.Read(chromecookies.Options{Browser: req.Browser, UserDataDir: req.UserDataDir, Profile: req.Profile})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, chromecookies.ErrNoCookies) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "READ_BROWSER_COOKIES_FAILED", "读取浏览器 cookies 失败", err.Error())
+		return
+	}
+	if err := s.xiaohongshuService.ImportCookies(list); err != nil {
+		respondError(c, http.StatusInternalServerError, "IMPORT_COOKIES_FAILED", "导入 cookies 失败", err.Error())
+		return
+	}
+	names := make([]string, 0, len(list))
+	for _, ck := range list {
+		names = append(names, ck.Name)
+	}
+	data := map[string]any{"imported": len(list), "names": names}
+	if req.Validate {
+		data["login"] = s.validateLogin(c)
+	}
+	respondSuccess(c, data, "导入浏览器 cookies 成功")
+}
+
+// validateCookiesHandler 用当前 cookies 检查登录状态
+func (s *AppServer) validateCookiesHandler(c *gin.Context) {
+	respondSuccess(c, s.validateLogin(c), "检查登录状态完成")
+}
+
+// validateLogin 检查登录状态，失败时在结果中返回错误而不是让整个请求失败
+func (s *AppServer) validateLogin(c *gin.Context) map[string]any {
+	status, err := s.xiaohongshuService.CheckLoginStatus(c.Request.Context())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"is_logged_in": status.IsLoggedIn, "username": status.Username}
+}
+
 // publishHandler 发布内容
 func (s *AppServer) publishHandler(c *gin.Context) {
 	var req PublishRequest
@@ -126,6 +292,9 @@ func (s *AppServer) publishHandler(c *gin.Context) {
 	// 执行发布
 	result, err := s.xiaohongshuService.PublishContent(c.Request.Context(), &req)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		message, details := explainPublishError("图文笔记发布", err)
 		respondError(c, http.StatusInternalServerError, "PUBLISH_FAILED",
 			message, details)
@@ -147,6 +316,9 @@ func (s *AppServer) publishVideoHandler(c *gin.Context) {
 	// 执行视频发布
 	result, err := s.xiaohongshuService.PublishVideo(c.Request.Context(), &req)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		message, details := explainPublishError("视频笔记发布", err)
 		respondError(c, http.StatusInternalServerError, "PUBLISH_VIDEO_FAILED",
 			message, details)
@@ -158,9 +330,19 @@ func (s *AppServer) publishVideoHandler(c *gin.Context) {
 
 // listFeedsHandler 获取Feeds列表
 func (s *AppServer) listFeedsHandler(c *gin.Context) {
+	var page PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+			"请求参数错误", err.Error())
+		return
+	}
+
 	// 获取 Feeds 列表
-	result, err := s.xiaohongshuService.ListFeeds(c.Request.Context())
+	result, err := s.xiaohongshuService.ListFeeds(c.Request.Context(), page)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "LIST_FEEDS_FAILED",
 			"获取Feeds列表失败", err.Error())
 		return
@@ -174,6 +356,7 @@ func (s *AppServer) listFeedsHandler(c *gin.Context) {
 func (s *AppServer) searchFeedsHandler(c *gin.Context) {
 	var keyword string
 	var filters xiaohongshu.FilterOption
+	var page PageRequest
 
 	switch c.Request.Method {
 	case http.MethodPost:
@@ -186,8 +369,14 @@ func (s *AppServer) searchFeedsHandler(c *gin.Context) {
 		}
 		keyword = searchReq.Keyword
 		filters = searchReq.Filters
+		page = searchReq.PageRequest
 	default:
 		keyword = c.Query("keyword")
+		if err := c.ShouldBindQuery(&page); err != nil {
+			respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+				"请求参数错误", err.Error())
+			return
+		}
 	}
 
 	if keyword == "" {
@@ -197,8 +386,11 @@ func (s *AppServer) searchFeedsHandler(c *gin.Context) {
 	}
 
 	// 搜索 Feeds
-	result, err := s.xiaohongshuService.SearchFeeds(c.Request.Context(), keyword, filters)
+	result, err := s.xiaohongshuService.SearchFeeds(c.Request.Context(), keyword, page, filters)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "SEARCH_FEEDS_FAILED",
 			"搜索Feeds失败", err.Error())
 		return
@@ -235,6 +427,9 @@ func (s *AppServer) getFeedDetailHandler(c *gin.Context) {
 	}
 
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "GET_FEED_DETAIL_FAILED",
 			"获取Feed详情失败", err.Error())
 		return
@@ -254,8 +449,11 @@ func (s *AppServer) userProfileHandler(c *gin.Context) {
 	}
 
 	// 获取用户信息
-	result, err := s.xiaohongshuService.UserProfile(c.Request.Context(), req.UserID, req.XsecToken)
+	result, err := s.xiaohongshuService.UserProfile(c.Request.Context(), req.UserID, req.XsecToken, req.PageRequest)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "GET_USER_PROFILE_FAILED",
 			"获取用户主页失败", err.Error())
 		return
@@ -277,6 +475,9 @@ func (s *AppServer) postCommentHandler(c *gin.Context) {
 	// 发表评论
 	result, err := s.xiaohongshuService.PostCommentToFeed(c.Request.Context(), req.FeedID, req.XsecToken, req.Content)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "POST_COMMENT_FAILED",
 			"发表评论失败", err.Error())
 		return
@@ -297,6 +498,9 @@ func (s *AppServer) replyCommentHandler(c *gin.Context) {
 
 	result, err := s.xiaohongshuService.ReplyCommentToFeed(c.Request.Context(), req.FeedID, req.XsecToken, req.CommentID, req.UserID, req.Content)
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "REPLY_COMMENT_FAILED",
 			"回复评论失败", err.Error())
 		return
@@ -323,6 +527,9 @@ func (s *AppServer) likeFeedHandler(c *gin.Context) {
 		result, err = s.xiaohongshuService.LikeFeed(c.Request.Context(), req.FeedID, req.XsecToken)
 	}
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "LIKE_FEED_FAILED",
 			"点赞操作失败", err.Error())
 		return
@@ -349,6 +556,9 @@ func (s *AppServer) favoriteFeedHandler(c *gin.Context) {
 		result, err = s.xiaohongshuService.FavoriteFeed(c.Request.Context(), req.FeedID, req.XsecToken)
 	}
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "FAVORITE_FEED_FAILED",
 			"收藏操作失败", err.Error())
 		return
@@ -360,19 +570,63 @@ func (s *AppServer) favoriteFeedHandler(c *gin.Context) {
 
 // healthHandler 健康检查
 func healthHandler(c *gin.Context) {
+	info := compat.Current()
 	respondSuccess(c, map[string]any{
-		"status":    "healthy",
-		"service":   "xiaohongshu-mcp",
-		"account":   "ai-report",
-		"timestamp": "now",
+		"status":      "healthy",
+		"service":     "xiaohongshu-mcp",
+		"version":     info.Version,
+		"commit":      info.Commit,
+		"tool_schema": info.ToolSchema,
+		"features":    info.Features,
+		"account":     "ai-report",
+		"timestamp":   "now",
 	}, "服务正常")
 }
 
+// livezHandler 存活检查：进程能响应即返回 200
+func livezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// readyzHandler 就绪检查：cookies 已加载且浏览器可用时返回 200，否则 503
+func (s *AppServer) readyzHandler(c *gin.Context) {
+	checks := map[string]string{}
+	ready := true
+
+	if st, err := os.Stat(cookies.GetCookiesFilePath()); err != nil || st.Size() <= 2 {
+		checks["cookies"] = "missing"
+		ready = false
+	} else {
+		checks["cookies"] = "ok"
+	}
+
+	// 浏览器为懒加载，未启动不算失败；已启动但无法响应说明 Chrome 已退出
+	started, err := s.xiaohongshuService.CheckBrowser(2 * time.Second)
+	switch {
+	case !started:
+		checks["browser"] = "not_started"
+	case err != nil:
+		checks["browser"] = "dead: " + err.Error()
+		ready = false
+	default:
+		checks["browser"] = "ok"
+	}
+
+	if !ready {
+		respondError(c, http.StatusServiceUnavailable, "NOT_READY", "服务未就绪", checks)
+		return
+	}
+	respondSuccess(c, checks, "服务就绪")
+}
+
 // myProfileHandler 我的信息
 func (s *AppServer) myProfileHandler(c *gin.Context) {
 	// 获取当前登录用户信息
 	result, err := s.xiaohongshuService.GetMyProfile(c.Request.Context())
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "GET_MY_PROFILE_FAILED",
 			"获取我的主页失败", err.Error())
 		return
@@ -386,6 +640,9 @@ func (s *AppServer) myProfileHandler(c *gin.Context) {
 func (s *AppServer) notificationMentionsHandler(c *gin.Context) {
 	result, err := s.xiaohongshuService.GetNotificationMentions(c.Request.Context())
 	if err != nil {
+		if respondBlocked(c, err) {
+			return
+		}
 		respondError(c, http.StatusInternalServerError, "GET_NOTIFICATION_MENTIONS_FAILED",
 			"获取评论和@通知失败", err.Error())
 		return
@@ -394,3 +651,133 @@ func (s *AppServer) notificationMentionsHandler(c *gin.Context) {
 	c.Set("account", "ai-report")
 	respondSuccess(c, result, "获取评论和@通知成功")
 }
+
+// selectorsHandler 查看当前生效的页面选择器配置
+func (s *AppServer) selectorsHandler(c *gin.Context) {
+	respondSuccess(c, xiaohongshu.GetSelectorInfo(), "获取选择器配置成功")
+}
+
+// mcpHistoryQuery 调用记录查询参数
+type mcpHistoryQuery struct {
+	Tool   string    `form:"tool"`
+	Status string    `form:"status"` // ok, error, panic
+	Since  time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int       `form:"limit"`
+}
+
+// mcpHistoryHandler 查询 MCP 工具调用记录
+func (s *AppServer) mcpHistoryHandler(c *gin.Context) {
+	var q mcpHistoryQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "参数错误", err.Error())
+		return
+	}
+
+	records := mcpCalls.Query(MCPCallFilter(q))
+	respondSuccess(c, map[string]any{
+		"records": records,
+		"count":   len(records),
+	}, "获取调用记录成功")
+}
+
+type activityQuery struct {
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int       `form:"limit"`
+}
+
+// activityHandler 查询账号动态（写操作结果、登录失效、验证码）
+func (s *AppServer) activityHandler(c *gin.Context) {
+	var q activityQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "参数错误", err.Error())
+		return
+	}
+
+	records := activities.Query(q.Since, q.Limit)
+	respondSuccess(c, map[string]any{
+		"records": records,
+		"count":   len(records),
+	}, "获取账号动态成功")
+}
+
+// reloadSelectorsHandler 重新加载选择器覆盖文件，失败时保持原配置
+func (s *AppServer) reloadSelectorsHandler(c *gin.Context) {
+	info, err := xiaohongshu.ReloadSelectors()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "RELOAD_SELECTORS_FAILED",
+			"重新加载选择器配置失败", err.Error())
+		return
+	}
+	respondSuccess(c, info, "选择器配置已重新加载")
+}
+
+// jobErrorStatus 定时任务错误对应的 HTTP 状态码
+func jobErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, taskqueue.ErrJobNotFound):
+		return http.StatusNotFound, "JOB_NOT_FOUND"
+	case errors.Is(err, errScheduleDisabled):
+		return http.StatusServiceUnavailable, "SCHEDULE_DISABLED"
+	case errors.Is(err, errReadOnly):
+		return http.StatusForbidden, "READ_ONLY"
+	default:
+		return http.StatusBadRequest, "JOB_FAILED"
+	}
+}
+
+// listJobsHandler 列出定时任务
+func (s *AppServer) listJobsHandler(c *gin.Context) {
+	jobs, err := s.xiaohongshuService.ListJobs()
+	if err != nil {
+		status, code := jobErrorStatus(err)
+		respondError(c, status, code, "获取定时任务失败", err.Error())
+		return
+	}
+	respondSuccess(c, map[string]any{"jobs": jobs, "count": len(jobs)}, "获取定时任务成功")
+}
+
+// createJobHandler 创建定时任务
+func (s *AppServer) createJobHandler(c *gin.Context) {
+	var req CreateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "参数错误", err.Error())
+		return
+	}
+	job, err := s.xiaohongshuService.CreateJob(&req)
+	if err != nil {
+		status, code := jobErrorStatus(err)
+		respondError(c, status, code, "创建定时任务失败", err.Error())
+		return
+	}
+	respondSuccess(c, job, "创建定时任务成功")
+}
+
+// pauseJobHandler 暂停定时任务
+func (s *AppServer) pauseJobHandler(c *gin.Context) {
+	s.setJobPaused(c, true)
+}
+
+// resumeJobHandler 恢复定时任务
+func (s *AppServer) resumeJobHandler(c *gin.Context) {
+	s.setJobPaused(c, false)
+}
+
+func (s *AppServer) setJobPaused(c *gin.Context, paused bool) {
+	job, err := s.xiaohongshuService.SetJobPaused(c.Param("jid"), paused)
+	if err != nil {
+		status, code := jobErrorStatus(err)
+		respondError(c, status, code, "更新定时任务失败", err.Error())
+		return
+	}
+	respondSuccess(c, job, "更新定时任务成功")
+}
+
+// deleteJobHandler 删除定时任务
+func (s *AppServer) deleteJobHandler(c *gin.Context) {
+	if err := s.xiaohongshuService.DeleteJob(c.Param("jid")); err != nil {
+		status, code := jobErrorStatus(err)
+		respondError(c, status, code, "删除定时任务失败", err.Error())
+		return
+	}
+	respondSuccess(c, nil, "删除定时任务成功")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// setupInterstitialRecovery 配置导航落到滑块验证/网络异常页后的自动恢复。
+// cooldown 为 0 使用默认值，负数表示不自动恢复；restartBrowser 为 true 时恢复失败后重启浏览器，
+// 配置了代理池时之后的登录/发布会换用新的代理。
+func (s *XiaohongshuService) setupInterstitialRecovery(cooldown time.Duration, restartBrowser bool) {
+	if cooldown == 0 {
+		cooldown = xiaohongshu.DefaultInterstitialCooldown
+	}
+	xiaohongshu.SetInterstitialRecovery(xiaohongshu.InterstitialRecovery{
+		Cooldown:       cooldown,
+		RefreshCookies: refreshPageCookies,
+		OnGiveUp: func(kind, url string) {
+			// 停留在验证码地址的页面关闭时由 noteCaptcha 记录，这里只记录其他拦截页
+			if activities != nil && !isCaptchaURL(url) {
+				activities.Add(activityCaptcha, url, fmt.Errorf("拦截页自动恢复失败: %s", kind))
+			}
+			if restartBrowser {
+				s.markBrowserStale()
+			}
+		},
+	})
+}
+
+// refreshPageCookies 把 cookies 文件中保存的登录态重新注入页面所在的浏览器
+func refreshPageCookies(page *rod.Page) error {
+	data, err := cookies.NewLoadCookie(cookies.GetCookiesFilePath()).LoadCookies()
+	if err != nil {
+		return err
+	}
+	var cks []*proto.NetworkCookie
+	if err := json.Unmarshal(data, &cks); err != nil {
+		return err
+	}
+	return page.Browser().SetCookies(proto.CookiesToParams(cks))
+}
+
+// markBrowserStale 下次获取共享浏览器时重启
+func (s *XiaohongshuService) markBrowserStale() {
+	s.browserMu.Lock()
+	s.browserStale = true
+	s.browserMu.Unlock()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// errTooBusy 并发与排队均已满
+var errTooBusy = errors.New("当前操作过多，请稍后重试")
+
+// opLimiter 限制同时进行的浏览器操作数量，超出部分排队，队列满则直接拒绝
+type opLimiter struct {
+	slots    chan struct{}
+	maxQueue int
+
+	mu      sync.Mutex
+	waiting int
+}
+
+// newOpLimiter maxConcurrent<=0 表示不限制
+func newOpLimiter(maxConcurrent, maxQueue int) *opLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &opLimiter{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: maxQueue,
+	}
+}
+
+// Acquire 获取执行名额，返回的 release 必须调用
+func (l *opLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.waiting >= l.maxQueue {
+		l.mu.Unlock()
+		return nil, errTooBusy
+	}
+	l.waiting++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *opLimiter) release() {
+	<-l.slots
+}
+
+// limitMiddleware HTTP 接口限流，超出排队上限返回 429
+func (s *AppServer) limitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		release, err := s.limiter.Acquire(c.Request.Context())
+		if err != nil {
+			respondError(c, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", errTooBusy.Error(), err.Error())
+			c.Abort()
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+// mcpLimitMiddleware MCP 工具调用限流（仅 tools/call），超出排队上限返回错误结果
+func (s *AppServer) mcpLimitMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/call" {
+			return next(ctx, method, req)
+		}
+
+		release, err := s.limiter.Acquire(ctx)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("工具未执行: %v", err)}},
+				IsError: true,
+			}, nil
+		}
+		defer release()
+
+		return next(ctx, method, req)
+	}
+}
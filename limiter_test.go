@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOpLimiterQueueAndReject(t *testing.T) {
+	l := newOpLimiter(1, 1)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("首个请求应直接获得名额: %v", err)
+	}
+
+	queued := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		queued <- err
+	}()
+
+	// 等待第二个请求进入队列
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		waiting := l.waiting
+		l.mu.Unlock()
+		if waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("第二个请求应进入排队")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := l.Acquire(context.Background()); !errors.Is(err, errTooBusy) {
+		t.Fatalf("队列已满时应拒绝，got=%v", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Fatalf("排队的请求应在名额释放后执行: %v", err)
+	}
+}
+
+func TestOpLimiterDisabled(t *testing.T) {
+	l := newOpLimiter(0, 0)
+	for i := 0; i < 5; i++ {
+		release, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("未限制时不应拒绝: %v", err)
+		}
+		release()
+	}
+}
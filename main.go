@@ -1,11 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/i18n"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
 
 func main() {
@@ -17,16 +24,106 @@ func main() {
 		proxyPool   string // 登录/发布代理池地址
 		userDataDir string // 用户数据目录
 		userAgent   string // 浏览器 User-Agent
+		stateDir    string // 运行状态目录（任务日志、发布历史）
+		recordDir   string // 录制目录
+		replayDir   string // 回放目录
+		timezone    string // 账号所在时区
+
+		drainTimeout time.Duration // 退出时等待进行中请求的时间
+		browserIdle  time.Duration // 浏览器空闲关闭时间
+
+		navigateTimeout time.Duration // 单次页面导航超时
+		elementTimeout  time.Duration // 可选元素探测超时
+		settleFactor    float64       // 动作后固定等待的倍率
+		lookupTimeout   time.Duration // 必需元素单次查找超时
+		retryAttempts   int           // 元素查找/点击重试次数
+		retryBackoff    time.Duration // 重试初始退避
+		toolTimingsRaw  string        // 按工具覆盖的超时配置
+		selectorsFile   string        // 选择器覆盖文件
+		extractMode     string        // 读操作提取方式
+
+		maxConcurrent int // 同时执行的浏览器操作数
+		maxQueue      int // 排队等待上限
+		maxReadTabs   int // 只读操作并行标签页数
+
+		enableDevTools bool // 暴露浏览器远程调试代理
+		readOnly       bool // 只读模式
+
+		watchMentions  time.Duration // 评论和@轮询间隔
+		mentionWebhook string        // 新评论和@推送地址
+		watchFollowers time.Duration // 新增关注轮询间隔
+
+		eventWebhook string // 事件 webhook 地址，逗号分隔
+		eventNotify  string // 事件群机器人地址，逗号分隔
+		eventTypes   string // 推送的事件类型，逗号分隔
+
+		prePublishHook string        // 发布前内容处理地址
+		preCommentHook string        // 评论前内容处理地址
+		hookTimeout    time.Duration // 内容处理超时
+		hookFailOpen   bool          // 内容处理失败时按原内容继续
+
+		moderationPolicy string // 内容审核策略
+		sensitiveWords   string // 敏感词表文件
+		moderationAPI    string // 外部审核接口
+
+		lang string // 默认语言
+
+		captchaCooldown       time.Duration // 拦截页重试前冷却
+		captchaRestartBrowser bool          // 拦截页恢复失败后重启浏览器
+
+		capabilities bool // 输出版本与能力后退出
 	)
 	flag.BoolVar(&headless, "headless", true, "是否无头模式")
 	flag.StringVar(&binPath, "bin", "", "浏览器二进制文件路径")
-	flag.StringVar(&port, "port", ":18060", "端口")
+	flag.StringVar(&port, "port", ":18060", "监听地址，如 :18060；使用 unix:/path/to.sock 监听 Unix socket")
 	flag.StringVar(&proxy, "proxy", "", "登录/发布代理地址，如 http://127.0.0.1:7890")
 	flag.StringVar(&proxyPool, "proxy-pool-url", "", "登录/发布代理池提取地址")
 	flag.StringVar(&userDataDir, "user-data-dir", "", "浏览器用户数据目录（多用户隔离）")
 	flag.StringVar(&userAgent, "user-agent", "", "浏览器 User-Agent（为空使用默认值）")
+	flag.DurationVar(&browserIdle, "browser-idle-timeout", 0, "浏览器空闲多久后自动关闭（下次调用时自动重启），0 表示不关闭")
+	flag.StringVar(&stateDir, "state-dir", "", "运行状态目录，保存任务日志与发布历史（默认 ./state）")
+	flag.StringVar(&recordDir, "record-dir", "", "录制模式：把页面网络响应与 HTML 快照保存到该目录，供回放使用")
+	flag.StringVar(&replayDir, "replay-dir", "", "回放模式：浏览器请求全部由录制目录响应、不访问网络，用于在 CI 中脱离真实账号测试")
+	flag.DurationVar(&drainTimeout, "drain-timeout", 0, "退出时等待进行中请求完成的最长时间（默认 2m）")
+	flag.DurationVar(&navigateTimeout, "navigate-timeout", 0, "单次页面导航超时（默认 60s）")
+	flag.DurationVar(&elementTimeout, "element-timeout", 0, "可选元素探测超时（默认 2s）")
+	flag.Float64Var(&settleFactor, "settle-factor", 0, "动作后固定等待时长的倍率（默认 1，慢代理可调大）")
+	flag.DurationVar(&lookupTimeout, "selector-timeout", 0, "必需元素单次查找超时（默认 10s）")
+	flag.IntVar(&retryAttempts, "selector-attempts", 0, "元素查找/点击失败后的重试次数（默认 3）")
+	flag.DurationVar(&retryBackoff, "selector-backoff", 0, "元素重试的初始退避，之后指数增长（默认 500ms）")
+	flag.StringVar(&toolTimingsRaw, "tool-timings", "", "按 MCP 工具覆盖超时，如 publish_content:navigate=90s,settle=1.5;search_feeds:element=5s,attempts=5")
+	flag.StringVar(&selectorsFile, "selectors-file", "", "页面选择器覆盖文件（JSON），可通过 POST /api/v1/selectors/reload 热加载")
+	flag.StringVar(&extractMode, "extract-mode", "", "读操作数据提取方式：hybrid（优先页面接口响应，失败回退页面状态，默认）或 dom")
+	flag.IntVar(&maxConcurrent, "max-concurrent", -1, "同时执行的浏览器操作数，0 表示不限制（默认 4）")
+	flag.IntVar(&maxQueue, "max-queue", -1, "超出并发后排队等待的操作数上限，超出返回 429（默认 10）")
+	flag.IntVar(&maxReadTabs, "max-read-tabs", 0, "只读操作（搜索、详情等）可并行使用的标签页数（默认 3），写操作始终独占")
+	flag.BoolVar(&enableDevTools, "enable-devtools", false, "开放 /api/v1/debug/devtools 代理浏览器远程调试（DevTools），仅用于排查问题")
+	flag.StringVar(&timezone, "timezone", "", "账号所在时区（IANA 名称，如 Asia/Shanghai、America/New_York），定时发布、周期任务和浏览器页面按该时区的当地时间，默认跟随服务器")
+	flag.BoolVar(&readOnly, "read-only", false, "只读模式：拒绝发布、评论、回复、点赞、收藏等所有写操作，写工具不出现在 MCP 工具列表中，用于只做调研/监控的账号")
+	flag.DurationVar(&watchMentions, "watch-mentions", 0, "轮询评论和@通知的间隔（如 2m），新通知通过 /api/v1/mentions/stream 与 webhook 推送，0 表示不启用")
+	flag.StringVar(&mentionWebhook, "mention-webhook", "", "新评论和@的推送地址（POST JSON）")
+	flag.DurationVar(&watchFollowers, "watch-followers", 0, "轮询新增关注的间隔（如 10m），新粉丝作为 new_follower 事件发布，0 表示不启用")
+	flag.StringVar(&eventWebhook, "event-webhook", "", "事件推送地址（POST JSON），多个用逗号分隔；事件包括 note_published、comment_received、login_expired、captcha、write_failed、job_failed")
+	flag.StringVar(&eventNotify, "event-notify", "", "事件通知群机器人地址（钉钉、飞书、企业微信、Slack），多个用逗号分隔")
+	flag.StringVar(&eventTypes, "event-types", "", "只推送这些事件类型到 webhook 和群机器人，逗号分隔，默认全部")
+	flag.StringVar(&prePublishHook, "pre-publish-hook", "", "发布前把标题、正文、标签 POST 到该地址处理（翻译、润色、合规审核等），使用返回的内容发布")
+	flag.StringVar(&preCommentHook, "pre-comment-hook", "", "发表评论/回复前把内容 POST 到该地址处理，使用返回的内容")
+	flag.DurationVar(&hookTimeout, "hook-timeout", 0, "内容处理钩子单次调用超时（默认 30s）")
+	flag.BoolVar(&hookFailOpen, "hook-fail-open", false, "内容处理钩子不可用时按原内容继续（默认中止发布/评论）")
+	flag.StringVar(&moderationPolicy, "moderation", "", "写操作前内容审核命中后的处理：block（拒绝，配置了审核来源时默认）、warn（放行并告警）、log（仅记录）、off")
+	flag.StringVar(&sensitiveWords, "sensitive-words", "", "本地敏感词表文件，每行一个词，# 开头为注释")
+	flag.StringVar(&moderationAPI, "moderation-api", "", "外部内容审核接口，POST {kind,text}，返回 {flagged,reasons}")
+	flag.StringVar(&lang, "lang", "", "接口提示与 MCP 工具说明的默认语言：zh（默认）或 en，请求可通过 Accept-Language 或 ?lang= 覆盖")
+	flag.DurationVar(&captchaCooldown, "captcha-cooldown", 0, "导航落到滑块验证或网络异常页后，冷却多久再重新注入 cookies 并重试一次（默认 8s），负数表示不自动恢复")
+	flag.BoolVar(&captchaRestartBrowser, "captcha-restart-browser", false, "拦截页自动恢复失败后重启浏览器，配置代理池时之后的登录/发布换用新代理")
+	flag.BoolVar(&capabilities, "capabilities", false, "输出构建版本、工具结构版本和支持的可选功能（JSON）后退出，供 manager 检查兼容性")
 	flag.Parse()
 
+	if capabilities {
+		_ = json.NewEncoder(os.Stdout).Encode(compat.Current())
+		return
+	}
+
 	// 环境变量 fallback
 	if len(binPath) == 0 {
 		binPath = os.Getenv("ROD_BROWSER_BIN")
@@ -43,6 +140,117 @@ func main() {
 	if len(userAgent) == 0 {
 		userAgent = os.Getenv("BROWSER_USER_AGENT")
 	}
+	if len(stateDir) == 0 {
+		stateDir = os.Getenv("XHS_STATE_DIR")
+	}
+	if len(recordDir) == 0 {
+		recordDir = os.Getenv("XHS_RECORD_DIR")
+	}
+	if len(replayDir) == 0 {
+		replayDir = os.Getenv("XHS_REPLAY_DIR")
+	}
+	if recordDir != "" && replayDir != "" {
+		logrus.Fatal("-record-dir 与 -replay-dir 不能同时使用")
+	}
+	if timezone == "" {
+		timezone = os.Getenv("XHS_TIMEZONE")
+	}
+	if drainTimeout == 0 {
+		drainTimeout = durationFromEnv("XHS_DRAIN_TIMEOUT")
+	}
+	if browserIdle == 0 {
+		browserIdle = durationFromEnv("XHS_BROWSER_IDLE_TIMEOUT")
+	}
+	if navigateTimeout == 0 {
+		navigateTimeout = durationFromEnv("XHS_NAVIGATE_TIMEOUT")
+	}
+	if elementTimeout == 0 {
+		elementTimeout = durationFromEnv("XHS_ELEMENT_TIMEOUT")
+	}
+	if settleFactor == 0 {
+		if v := os.Getenv("XHS_SETTLE_FACTOR"); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				logrus.Fatalf("invalid XHS_SETTLE_FACTOR: %v", err)
+			}
+			settleFactor = f
+		}
+	}
+	if lookupTimeout == 0 {
+		lookupTimeout = durationFromEnv("XHS_SELECTOR_TIMEOUT")
+	}
+	if retryAttempts == 0 {
+		retryAttempts = intFromEnv("XHS_SELECTOR_ATTEMPTS", 0)
+	}
+	if retryBackoff == 0 {
+		retryBackoff = durationFromEnv("XHS_SELECTOR_BACKOFF")
+	}
+	if maxConcurrent < 0 {
+		maxConcurrent = intFromEnv("XHS_MAX_CONCURRENT", configs.GetMaxConcurrent())
+	}
+	if maxQueue < 0 {
+		maxQueue = intFromEnv("XHS_MAX_QUEUE", configs.GetMaxQueue())
+	}
+	if maxReadTabs == 0 {
+		maxReadTabs = intFromEnv("XHS_MAX_READ_TABS", configs.GetMaxReadTabs())
+	}
+	if len(toolTimingsRaw) == 0 {
+		toolTimingsRaw = os.Getenv("XHS_TOOL_TIMINGS")
+	}
+	if len(selectorsFile) == 0 {
+		selectorsFile = os.Getenv("XHS_SELECTORS_FILE")
+	}
+	if len(extractMode) == 0 {
+		extractMode = os.Getenv("XHS_EXTRACT_MODE")
+	}
+	if !enableDevTools {
+		enableDevTools = os.Getenv("XHS_ENABLE_DEVTOOLS") == "true"
+	}
+	if !readOnly {
+		readOnly = os.Getenv("XHS_READ_ONLY") == "true"
+	}
+	if watchMentions == 0 {
+		watchMentions = durationFromEnv("XHS_WATCH_MENTIONS")
+	}
+	if mentionWebhook == "" {
+		mentionWebhook = os.Getenv("XHS_MENTION_WEBHOOK")
+	}
+	if watchFollowers == 0 {
+		watchFollowers = durationFromEnv("XHS_WATCH_FOLLOWERS")
+	}
+	if eventWebhook == "" {
+		eventWebhook = os.Getenv("XHS_EVENT_WEBHOOK")
+	}
+	if eventNotify == "" {
+		eventNotify = os.Getenv("XHS_EVENT_NOTIFY")
+	}
+	if eventTypes == "" {
+		eventTypes = os.Getenv("XHS_EVENT_TYPES")
+	}
+	if prePublishHook == "" {
+		prePublishHook = os.Getenv("XHS_PRE_PUBLISH_HOOK")
+	}
+	if preCommentHook == "" {
+		preCommentHook = os.Getenv("XHS_PRE_COMMENT_HOOK")
+	}
+	if hookTimeout == 0 {
+		hookTimeout = durationFromEnv("XHS_HOOK_TIMEOUT")
+	}
+	if !hookFailOpen {
+		hookFailOpen = os.Getenv("XHS_HOOK_FAIL_OPEN") == "true"
+	}
+	if moderationPolicy == "" {
+		moderationPolicy = os.Getenv("XHS_MODERATION")
+	}
+	if sensitiveWords == "" {
+		sensitiveWords = os.Getenv("XHS_SENSITIVE_WORDS")
+	}
+	if moderationAPI == "" {
+		moderationAPI = os.Getenv("XHS_MODERATION_API")
+	}
+	if lang == "" {
+		lang = os.Getenv("XHS_LANG")
+	}
 
 	// 初始化全局配置
 	configs.InitHeadless(headless)
@@ -51,9 +259,85 @@ func main() {
 	configs.SetProxyPool(proxyPool)
 	configs.SetUserDataDir(userDataDir)
 	configs.SetUserAgent(userAgent)
+	configs.SetRecordDir(recordDir)
+	configs.SetReplayDir(replayDir)
+	configs.SetStateDir(stateDir)
+	configs.SetDrainTimeout(drainTimeout)
+	configs.SetBrowserIdleTimeout(browserIdle)
+	configs.SetConcurrency(maxConcurrent, maxQueue)
+	configs.SetMaxReadTabs(maxReadTabs)
+	configs.SetDevToolsEnabled(enableDevTools)
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			logrus.Fatalf("invalid timezone %s: %v", timezone, err)
+		}
+		configs.SetLocation(loc)
+		logrus.Infof("账号时区: %s", loc)
+	}
+	configs.SetReadOnly(readOnly)
+	if readOnly {
+		logrus.Info("只读模式已启用，所有写操作将被拒绝")
+	}
+	if lang != "" {
+		l, ok := i18n.Parse(lang)
+		if !ok {
+			logrus.Fatalf("invalid lang: %s", lang)
+		}
+		configs.SetLang(string(l))
+	}
+	// 签名密钥只通过环境变量传入
+	configs.SetMentionWatch(watchMentions, mentionWebhook, os.Getenv("XHS_MENTION_WEBHOOK_SECRET"))
+	configs.SetFollowerWatchInterval(watchFollowers)
+	configs.SetEventSinks(configs.EventSinks{
+		Webhooks: splitList(eventWebhook),
+		Secret:   os.Getenv("XHS_EVENT_WEBHOOK_SECRET"),
+		Notify:   splitList(eventNotify),
+		Types:    splitList(eventTypes),
+	})
+	configs.SetContentHooks(configs.ContentHooks{
+		PrePublish: prePublishHook,
+		PreComment: preCommentHook,
+		Secret:     os.Getenv("XHS_HOOK_SECRET"),
+		Timeout:    hookTimeout,
+		FailOpen:   hookFailOpen,
+	})
+	if err := initModeration(moderationPolicy, sensitiveWords, moderationAPI); err != nil {
+		logrus.Fatalf("invalid moderation config: %v", err)
+	}
+	xiaohongshu.SetTimings(xiaohongshu.Timings{
+		Navigate: navigateTimeout,
+		Element:  elementTimeout,
+		Settle:   settleFactor,
+		Lookup:   lookupTimeout,
+		Attempts: retryAttempts,
+		Backoff:  retryBackoff,
+	})
+	overrides, err := parseToolTimings(toolTimingsRaw, xiaohongshu.GetTimings())
+	if err != nil {
+		logrus.Fatalf("invalid tool timings: %v", err)
+	}
+	toolTimings = overrides
+	xiaohongshu.SetExtractMode(extractMode)
+	if err := xiaohongshu.SetSelectorsFile(selectorsFile); err != nil {
+		logrus.Fatalf("invalid selectors file: %v", err)
+	}
+
+	// 令牌只通过环境变量传入，避免出现在进程参数列表中
+	configs.SetAPIToken(strings.TrimSpace(os.Getenv("XHS_API_TOKEN")))
 
 	// 初始化服务
 	xiaohongshuService := NewXiaohongshuService()
+	xiaohongshuService.setupInterstitialRecovery(captchaCooldown, captchaRestartBrowser)
+	if err := xiaohongshuService.OpenTaskJournal(configs.GetStateDir()); err != nil {
+		logrus.Fatalf("failed to open task journal: %v", err)
+	}
+	if mcpCalls, err = openMCPCallLog(configs.GetStateDir()); err != nil {
+		logrus.Fatalf("failed to open mcp call log: %v", err)
+	}
+	if activities, err = openActivityLog(configs.GetStateDir()); err != nil {
+		logrus.Fatalf("failed to open activity log: %v", err)
+	}
 
 	// 创建并启动应用服务器
 	appServer := NewAppServer(xiaohongshuService)
@@ -61,3 +345,40 @@ func main() {
 		logrus.Fatalf("failed to run server: %v", err)
 	}
 }
+
+// durationFromEnv 读取时长类型的环境变量，未设置返回 0
+func durationFromEnv(key string) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", key, err)
+	}
+	return d
+}
+
+// intFromEnv 读取整数类型的环境变量，未设置返回默认值
+func intFromEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", key, err)
+	}
+	return n
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -250,10 +250,10 @@ func (s *AppServer) handlePublishVideo(ctx context.Context, args map[string]inte
 }
 
 // handleListFeeds 处理获取Feeds列表
-func (s *AppServer) handleListFeeds(ctx context.Context) *MCPToolResult {
+func (s *AppServer) handleListFeeds(ctx context.Context, page PageRequest) *MCPToolResult {
 	logrus.Info("MCP: 获取Feeds列表")
 
-	result, err := s.xiaohongshuService.ListFeeds(ctx)
+	result, err := s.xiaohongshuService.ListFeeds(ctx, page)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{{
@@ -309,7 +309,8 @@ func (s *AppServer) handleSearchFeeds(ctx context.Context, args SearchFeedsArgs)
 		Location:    args.Filters.Location,
 	}
 
-	result, err := s.xiaohongshuService.SearchFeeds(ctx, args.Keyword, filter)
+	page := PageRequest{Cursor: args.Cursor, Limit: args.Limit}
+	result, err := s.xiaohongshuService.SearchFeeds(ctx, args.Keyword, page, filter)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{{
@@ -487,7 +488,10 @@ func (s *AppServer) handleUserProfile(ctx context.Context, args map[string]any)
 
 	logrus.Infof("MCP: 获取用户主页 - User ID: %s", userID)
 
-	result, err := s.xiaohongshuService.UserProfile(ctx, userID, xsecToken)
+	page := PageRequest{}
+	page.Cursor, _ = args["cursor"].(string)
+	page.Limit, _ = args["limit"].(int)
+	result, err := s.xiaohongshuService.UserProfile(ctx, userID, xsecToken, page)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{{
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// MCP 工具调用记录：保存在内存环形缓冲中，配置了运行状态目录时同时追加到 mcp_calls.jsonl，
+// 实例重启后仍可查询最近的调用
+const (
+	maxMCPCallRecords = 1000
+	maxMCPCallArgs    = 2048 // 参数 JSON 超出时截断
+	maxMCPCallError   = 1024
+)
+
+// 调用结果
+const (
+	mcpCallOK    = "ok"
+	mcpCallError = "error"
+	mcpCallPanic = "panic"
+)
+
+// MCPCallRecord 一次工具调用
+type MCPCallRecord struct {
+	At         time.Time `json:"at"`
+	Tool       string    `json:"tool"`
+	Status     string    `json:"status"` // ok, error, panic
+	DurationMs int64     `json:"duration_ms"`
+	Args       string    `json:"args,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// MCPCallFilter 查询条件，零值表示不过滤
+type MCPCallFilter struct {
+	Tool   string
+	Status string
+	Since  time.Time
+	Limit  int
+}
+
+// mcpCallLog 工具调用记录
+type mcpCallLog struct {
+	mu      sync.Mutex
+	path    string
+	records []MCPCallRecord
+}
+
+// mcpCalls 全局调用记录，未打开持久化文件时仅保存在内存中
+var mcpCalls = &mcpCallLog{}
+
+// openMCPCallLog 加载历史记录；文件中超出上限的旧记录在加载时被压缩掉
+func openMCPCallLog(stateDir string) (*mcpCallLog, error) {
+	l := &mcpCallLog{}
+	if stateDir == "" {
+		return l, nil
+	}
+	l.path = filepath.Join(stateDir, "mcp_calls.jsonl")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建状态目录失败: %w", err)
+	}
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取调用记录失败: %w", err)
+	}
+	total := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var r MCPCallRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || r.Tool == "" {
+			continue
+		}
+		total++
+		l.appendLocked(r)
+	}
+	_ = f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取调用记录失败: %w", err)
+	}
+
+	if total > maxMCPCallRecords {
+		if err := l.rewrite(); err != nil {
+			logrus.Warnf("压缩 MCP 调用记录失败: %v", err)
+		}
+	}
+	return l, nil
+}
+
+func (l *mcpCallLog) appendLocked(r MCPCallRecord) {
+	l.records = append(l.records, r)
+	if len(l.records) > maxMCPCallRecords {
+		l.records = append(l.records[:0:0], l.records[len(l.records)-maxMCPCallRecords:]...)
+	}
+}
+
+// rewrite 用内存中的记录重写文件
+func (l *mcpCallLog) rewrite() error {
+	var b strings.Builder
+	for _, r := range l.records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Add 记录一次调用，落盘失败只记录警告
+func (l *mcpCallLog) Add(r MCPCallRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.appendLocked(r)
+	if l.path == "" {
+		return
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logrus.Warnf("写入 MCP 调用记录失败: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logrus.Warnf("写入 MCP 调用记录失败: %v", err)
+	}
+}
+
+// Query 按条件查询，结果按时间倒序
+func (l *mcpCallLog) Query(f MCPCallFilter) []MCPCallRecord {
+	limit := f.Limit
+	if limit <= 0 || limit > maxMCPCallRecords {
+		limit = 100
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]MCPCallRecord, 0, limit)
+	for i := len(l.records) - 1; i >= 0 && len(out) < limit; i-- {
+		r := l.records[i]
+		if f.Tool != "" && r.Tool != f.Tool {
+			continue
+		}
+		if f.Status != "" && r.Status != f.Status {
+			continue
+		}
+		if !f.Since.IsZero() && r.At.Before(f.Since) {
+			break
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// newMCPCallRecord 根据工具返回值生成调用记录
+func newMCPCallRecord(tool string, start time.Time, args any, result *mcp.CallToolResult, err error, panicked bool) MCPCallRecord {
+	r := MCPCallRecord{
+		At:         start,
+		Tool:       tool,
+		Status:     mcpCallOK,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if data, e := json.Marshal(args); e == nil && string(data) != "null" && string(data) != "{}" {
+		r.Args = truncateString(string(data), maxMCPCallArgs)
+	}
+
+	switch {
+	case panicked:
+		r.Status = mcpCallPanic
+		r.Error = firstText(result)
+	case err != nil:
+		r.Status = mcpCallError
+		r.Error = err.Error()
+	case result != nil && result.IsError:
+		r.Status = mcpCallError
+		r.Error = firstText(result)
+	}
+	r.Error = truncateString(r.Error, maxMCPCallError)
+	return r
+}
+
+func firstText(result *mcp.CallToolResult) string {
+	if result == nil {
+		return ""
+	}
+	for _, c := range result.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			return t.Text
+		}
+	}
+	return ""
+}
+
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	// 按 rune 边界截断，避免产生非法 UTF-8
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPCallLogPersistAndQuery(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openMCPCallLog(dir)
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Second)
+	l.Add(newMCPCallRecord("search_feeds", start, SearchFeedsArgs{Keyword: "咖啡"}, &mcp.CallToolResult{}, nil, false))
+	l.Add(newMCPCallRecord("publish_content", start, nil,
+		&mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "发布失败"}}}, nil, false))
+	l.Add(newMCPCallRecord("search_feeds", start, nil, nil, errors.New("超时"), false))
+
+	reopened, err := openMCPCallLog(dir)
+	require.NoError(t, err)
+
+	all := reopened.Query(MCPCallFilter{})
+	require.Len(t, all, 3)
+	require.Equal(t, "search_feeds", all[0].Tool, "结果按时间倒序")
+	require.Contains(t, all[2].Args, "咖啡")
+
+	failed := reopened.Query(MCPCallFilter{Status: mcpCallError})
+	require.Len(t, failed, 2)
+	require.Equal(t, "超时", failed[0].Error)
+	require.Equal(t, "发布失败", failed[1].Error)
+
+	require.Len(t, reopened.Query(MCPCallFilter{Tool: "search_feeds", Limit: 1}), 1)
+	require.Empty(t, reopened.Query(MCPCallFilter{Since: time.Now().Add(time.Hour)}))
+}
+
+func TestTruncateStringKeepsUTF8(t *testing.T) {
+	s := truncateString(strings.Repeat("小红书", 10), 10)
+	require.Equal(t, "小红书…", s)
+}
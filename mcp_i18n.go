@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/i18n"
+)
+
+// mcpLangMiddleware 按请求头 Accept-Language（默认 -lang 配置）翻译工具列表的说明与工具调用的文本结果
+func mcpLangMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		res, err := next(ctx, method, req)
+		if err != nil || res == nil {
+			return res, err
+		}
+		lang := mcpRequestLang(req)
+		if lang == i18n.ZH {
+			return res, nil
+		}
+		switch r := res.(type) {
+		case *mcp.ListToolsResult:
+			// 工具由 server 持有，翻译副本
+			out := *r
+			out.Tools = make([]*mcp.Tool, 0, len(r.Tools))
+			for _, t := range r.Tools {
+				out.Tools = append(out.Tools, translateTool(lang, t))
+			}
+			return &out, nil
+		case *mcp.CallToolResult:
+			for _, c := range r.Content {
+				if tc, ok := c.(*mcp.TextContent); ok {
+					tc.Text = i18n.T(lang, tc.Text)
+				}
+			}
+		}
+		return res, nil
+	}
+}
+
+func mcpRequestLang(req mcp.Request) i18n.Lang {
+	def := i18n.Lang(configs.GetLang())
+	if extra := req.GetExtra(); extra != nil && extra.Header != nil {
+		return i18n.Negotiate(extra.Header.Get("Accept-Language"), def)
+	}
+	return def
+}
+
+// translateTool 翻译工具及其参数 schema 中的 description、title
+func translateTool(lang i18n.Lang, t *mcp.Tool) *mcp.Tool {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return t
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return t
+	}
+	translateDescriptions(lang, raw)
+	if data, err = json.Marshal(raw); err != nil {
+		return t
+	}
+	var out mcp.Tool
+	if err := json.Unmarshal(data, &out); err != nil {
+		logrus.Warnf("翻译工具 %s 说明失败: %v", t.Name, err)
+		return t
+	}
+	return &out
+}
+
+func translateDescriptions(lang i18n.Lang, v any) {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, val := range x {
+			if s, ok := val.(string); ok && (k == "description" || k == "title") {
+				x[k] = i18n.T(lang, s)
+				continue
+			}
+			translateDescriptions(lang, val)
+		}
+	case []any:
+		for _, val := range x {
+			translateDescriptions(lang, val)
+		}
+	}
+}
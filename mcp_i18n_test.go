@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPLangMiddleware(t *testing.T) {
+	var tool *mcp.Tool
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"name": "check_login_status",
+		"description": "检查小红书登录状态",
+		"inputSchema": {"type": "object", "properties": {"feed_id": {"type": "string", "description": "小红书笔记ID，从Feed列表获取"}}}
+	}`), &tool))
+	handler := mcpLangMiddleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method == "tools/list" {
+			return &mcp.ListToolsResult{Tools: []*mcp.Tool{tool}}, nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "参数错误"}}}, nil
+	})
+
+	req := func(accept string) mcp.Request {
+		h := http.Header{}
+		h.Set("Accept-Language", accept)
+		return &mcp.ServerRequest[*mcp.ListToolsParams]{Extra: &mcp.RequestExtra{Header: h}}
+	}
+
+	res, err := handler(context.Background(), "tools/list", req("en"))
+	require.NoError(t, err)
+	got := res.(*mcp.ListToolsResult).Tools[0]
+	require.Equal(t, "Check Xiaohongshu login status", got.Description)
+	require.NotEqual(t, "小红书笔记ID，从Feed列表获取", got.InputSchema.Properties["feed_id"].Description)
+	require.Equal(t, "检查小红书登录状态", tool.Description, "不修改 server 持有的工具")
+
+	res, err = handler(context.Background(), "tools/list", req("zh-CN"))
+	require.NoError(t, err)
+	require.Equal(t, "检查小红书登录状态", res.(*mcp.ListToolsResult).Tools[0].Description)
+
+	res, err = handler(context.Background(), "tools/call", req("en"))
+	require.NoError(t, err)
+	require.Equal(t, "Invalid parameters", res.(*mcp.CallToolResult).Content[0].(*mcp.TextContent).Text)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"runtime/debug"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sirupsen/logrus"
@@ -19,10 +20,10 @@ func boolPtr(b bool) *bool { return &b }
 type PublishContentArgs struct {
 	Title      string   `json:"title" jsonschema:"内容标题（小红书限制：最多20个中文字或英文单词）"`
 	Content    string   `json:"content" jsonschema:"正文内容，不包含以#开头的标签内容，所有话题标签都用tags参数来生成和提供即可"`
-	Images     []string `json:"images" jsonschema:"图片路径列表（至少需要1张图片）。支持两种方式：1. HTTP/HTTPS图片链接（自动下载）；2. 本地图片绝对路径（推荐，如:/Users/user/image.jpg）"`
+	Images     []string `json:"images" jsonschema:"图片路径列表（至少需要1张图片）。支持三种方式：1. HTTP/HTTPS图片链接（自动下载）；2. 对象存储地址 s3://、oss://、cos://bucket/key（使用实例配置的凭证下载）；3. 本地图片绝对路径（推荐，如:/Users/user/image.jpg）"`
 	Tags       []string `json:"tags,omitempty" jsonschema:"话题标签列表（可选参数），如 [美食, 旅行, 生活]"`
 	Products   []string `json:"products,omitempty" jsonschema:"商品关键词列表（可选），用于绑定带货商品。填写商品名称或商品ID，系统会自动搜索并选择第一个匹配结果。需账号已开通商品功能。示例: [面膜, 防晒霜SPF50]"`
-	ScheduleAt string   `json:"schedule_at,omitempty" jsonschema:"定时发布时间（可选），ISO8601格式如 2024-01-20T10:30:00+08:00，支持1小时至14天内。不填则立即发布"`
+	ScheduleAt string   `json:"schedule_at,omitempty" jsonschema:"定时发布时间（可选），ISO8601格式如 2024-01-20T10:30:00+08:00，不带时区偏移时按账号时区的当地时间，支持1小时至14天内。不填则立即发布"`
 	IsOriginal bool     `json:"is_original,omitempty" jsonschema:"是否声明原创（可选），true为声明原创，false或不填则不声明"`
 	Visibility string   `json:"visibility,omitempty" jsonschema:"可见范围（可选），支持: 公开可见(默认)、仅自己可见、仅互关好友可见。不填则默认公开可见"`
 }
@@ -31,17 +32,25 @@ type PublishContentArgs struct {
 type PublishVideoArgs struct {
 	Title      string   `json:"title" jsonschema:"内容标题（小红书限制：最多20个中文字或英文单词）"`
 	Content    string   `json:"content" jsonschema:"正文内容，不包含以#开头的标签内容，所有话题标签都用tags参数来生成和提供即可"`
-	Video      string   `json:"video" jsonschema:"本地视频绝对路径（仅支持单个视频文件，如:/Users/user/video.mp4）"`
+	Video      string   `json:"video" jsonschema:"本地视频绝对路径（仅支持单个视频文件，如:/Users/user/video.mp4），也支持对象存储地址 s3://、oss://、cos://bucket/key"`
 	Tags       []string `json:"tags,omitempty" jsonschema:"话题标签列表（可选参数），如 [美食, 旅行, 生活]"`
 	Products   []string `json:"products,omitempty" jsonschema:"商品关键词列表（可选），用于绑定带货商品。填写商品名称或商品ID，系统会自动搜索并选择第一个匹配结果。需账号已开通商品功能。示例: [面膜, 防晒霜SPF50]"`
-	ScheduleAt string   `json:"schedule_at,omitempty" jsonschema:"定时发布时间（可选），ISO8601格式如 2024-01-20T10:30:00+08:00，支持1小时至14天内。不填则立即发布"`
+	ScheduleAt string   `json:"schedule_at,omitempty" jsonschema:"定时发布时间（可选），ISO8601格式如 2024-01-20T10:30:00+08:00，不带时区偏移时按账号时区的当地时间，支持1小时至14天内。不填则立即发布"`
 	Visibility string   `json:"visibility,omitempty" jsonschema:"可见范围（可选），支持: 公开可见(默认)、仅自己可见、仅互关好友可见。不填则默认公开可见"`
 }
 
+// ListFeedsArgs 获取首页 Feeds 的参数
+type ListFeedsArgs struct {
+	Cursor string `json:"cursor,omitempty" jsonschema:"翻页游标，取上一次结果的cursor字段；首次查询不填"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"每页条数，默认20，最大50"`
+}
+
 // SearchFeedsArgs 搜索内容的参数
 type SearchFeedsArgs struct {
 	Keyword string       `json:"keyword" jsonschema:"搜索关键词"`
 	Filters FilterOption `json:"filters,omitempty" jsonschema:"筛选选项"`
+	Cursor  string       `json:"cursor,omitempty" jsonschema:"翻页游标，取上一次结果的cursor字段；首次查询不填"`
+	Limit   int          `json:"limit,omitempty" jsonschema:"每页条数，默认20，最大50"`
 }
 
 // FilterOption 筛选选项结构体
@@ -68,6 +77,8 @@ type FeedDetailArgs struct {
 type UserProfileArgs struct {
 	UserID    string `json:"user_id" jsonschema:"小红书用户ID，从Feed列表获取"`
 	XsecToken string `json:"xsec_token" jsonschema:"访问令牌，从Feed列表的xsecToken字段获取"`
+	Cursor    string `json:"cursor,omitempty" jsonschema:"笔记列表翻页游标，取上一次结果的cursor字段；首次查询不填"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"每页笔记条数，默认20，最大50"`
 }
 
 // PostCommentArgs 发表评论的参数
@@ -113,6 +124,8 @@ func InitMCPServer(appServer *AppServer) *mcp.Server {
 
 	// 注册所有工具
 	registerTools(server, appServer)
+	// 语言中间件在最外层，限流等提示也会被翻译
+	server.AddReceivingMiddleware(mcpLangMiddleware, mcpReadOnlyMiddleware, appServer.mcpLimitMiddleware)
 
 	logrus.Info("MCP Server initialized with official SDK")
 
@@ -125,8 +138,14 @@ func withPanicRecovery[T any](
 ) func(context.Context, *mcp.CallToolRequest, T) (*mcp.CallToolResult, any, error) {
 
 	return func(ctx context.Context, req *mcp.CallToolRequest, args T) (result *mcp.CallToolResult, resp any, err error) {
+		start := time.Now()
+		panicked := false
+		defer func() {
+			mcpCalls.Add(newMCPCallRecord(toolName, start, args, result, err, panicked))
+		}()
 		defer func() {
 			if r := recover(); r != nil {
+				panicked = true
 				logrus.WithFields(logrus.Fields{
 					"tool":  toolName,
 					"panic": r,
@@ -147,7 +166,7 @@ func withPanicRecovery[T any](
 			}
 		}()
 
-		return handler(ctx, req, args)
+		return handler(withToolTimings(ctx, toolName), req, args)
 	}
 }
 
@@ -232,14 +251,14 @@ func registerTools(server *mcp.Server, appServer *AppServer) {
 	mcp.AddTool(server,
 		&mcp.Tool{
 			Name:        "list_feeds",
-			Description: "获取首页 Feeds 列表",
+			Description: "获取首页 Feeds 列表，结果分页返回；has_more 为 true 时用 cursor 获取下一页",
 			Annotations: &mcp.ToolAnnotations{
 				Title:        "List Feeds",
 				ReadOnlyHint: true,
 			},
 		},
-		withPanicRecovery("list_feeds", func(ctx context.Context, req *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
-			result := appServer.handleListFeeds(ctx)
+		withPanicRecovery("list_feeds", func(ctx context.Context, req *mcp.CallToolRequest, args ListFeedsArgs) (*mcp.CallToolResult, any, error) {
+			result := appServer.handleListFeeds(ctx, PageRequest{Cursor: args.Cursor, Limit: args.Limit})
 			return convertToMCPResult(result), nil, nil
 		}),
 	)
@@ -248,7 +267,7 @@ func registerTools(server *mcp.Server, appServer *AppServer) {
 	mcp.AddTool(server,
 		&mcp.Tool{
 			Name:        "search_feeds",
-			Description: "搜索小红书内容（需要已登录）",
+			Description: "搜索小红书内容（需要已登录），结果分页返回；has_more 为 true 时用 cursor 获取下一页",
 			Annotations: &mcp.ToolAnnotations{
 				Title:        "Search Feeds",
 				ReadOnlyHint: true,
@@ -319,6 +338,8 @@ func registerTools(server *mcp.Server, appServer *AppServer) {
 			argsMap := map[string]interface{}{
 				"user_id":    args.UserID,
 				"xsec_token": args.XsecToken,
+				"cursor":     args.Cursor,
+				"limit":      args.Limit,
 			}
 			result := appServer.handleUserProfile(ctx, argsMap)
 			return convertToMCPResult(result), nil, nil
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// 评论和@监控：按间隔轮询通知页，新出现的通知作为 comment_received 事件发布。
+// 首次轮询只记录已有通知，不推送，避免启动时把历史通知全部当作新消息。
+const mentionSeenLimit = 2000 // 记住的通知 ID 数
+
+var errMentionWatchDisabled = errors.New("未启用评论和@监控（-watch-mentions）")
+
+// MentionEvent 一条新的评论或@通知
+type MentionEvent struct {
+	ID         string                          `json:"id"`
+	Type       string                          `json:"type"` // 通知类型，如 comment/comment_on_my_note、mention/comment
+	DetectedAt time.Time                       `json:"detected_at"`
+	Mention    xiaohongshu.NotificationMention `json:"mention"`
+}
+
+// mentionWatcher 轮询状态
+type mentionWatcher struct {
+	mu      sync.Mutex
+	seeded  bool
+	seen    map[string]time.Time
+	lastErr string
+	lastAt  time.Time
+}
+
+func newMentionWatcher() *mentionWatcher {
+	return &mentionWatcher{seen: map[string]time.Time{}}
+}
+
+// StartMentionWatcher 启动评论和@轮询，interval<=0 表示不启用
+func (s *AppServer) StartMentionWatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.mentions == nil {
+		return
+	}
+	logrus.Infof("评论和@监控已启用，轮询间隔 %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.pollMentions(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// pollMentions 拉取一次通知并推送新增项；并发已满时跳过本轮
+func (s *AppServer) pollMentions(ctx context.Context) {
+	release, err := s.limiter.Acquire(ctx)
+	if err != nil {
+		logrus.Debugf("跳过本轮评论和@轮询: %v", err)
+		return
+	}
+	defer release()
+
+	pollCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	resp, err := s.xiaohongshuService.GetNotificationMentions(pollCtx)
+	if err != nil {
+		logrus.Warnf("轮询评论和@失败: %v", err)
+		s.mentions.setResult(err)
+		return
+	}
+	s.mentions.setResult(nil)
+
+	for _, ev := range s.mentions.observe(resp.Notifications, time.Now()) {
+		bus.Publish(eventCommentReceived, mentionSummary(ev), ev)
+	}
+}
+
+// mentionSummary 事件描述，如「小王 评论了你的笔记: 好看」
+func mentionSummary(ev MentionEvent) string {
+	m := ev.Mention
+	text := firstNonEmpty(mapString(m.CommentInfo, "content"), m.Title)
+	if nickname := mapString(m.UserInfo, "nickname"); nickname != "" {
+		return nickname + " " + m.Title + ": " + text
+	}
+	return text
+}
+
+func (w *mentionWatcher) setResult(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastAt = time.Now()
+	w.lastErr = ""
+	if err != nil {
+		w.lastErr = err.Error()
+	}
+}
+
+// observe 记录本次拉取的通知，返回新增项（按页面顺序倒序，即从旧到新）
+func (w *mentionWatcher) observe(list []xiaohongshu.NotificationMention, now time.Time) []MentionEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var fresh []MentionEvent
+	for i := len(list) - 1; i >= 0; i-- {
+		m := list[i]
+		if m.ID == "" {
+			continue
+		}
+		if _, ok := w.seen[m.ID]; ok {
+			continue
+		}
+		w.seen[m.ID] = now
+		if w.seeded {
+			fresh = append(fresh, MentionEvent{ID: m.ID, Type: m.Type, DetectedAt: now, Mention: m})
+		}
+	}
+	w.seeded = true
+	w.pruneLocked()
+	return fresh
+}
+
+// pruneLocked 通知 ID 过多时丢弃最早记录的一半
+func (w *mentionWatcher) pruneLocked() {
+	if len(w.seen) <= mentionSeenLimit {
+		return
+	}
+	ids := make([]string, 0, len(w.seen))
+	for id := range w.seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return w.seen[ids[a]].Before(w.seen[ids[b]]) })
+	for _, id := range ids[:len(ids)/2] {
+		delete(w.seen, id)
+	}
+}
+
+// mentionStreamHandler 以 SSE 推送新的评论和@（即 comment_received 事件）；?replay=N 先回放最近 N 条
+// GET /api/v1/mentions/stream
+func (s *AppServer) mentionStreamHandler(c *gin.Context) {
+	if configs.GetMentionWatchInterval() <= 0 {
+		respondError(c, http.StatusConflict, "MENTION_WATCH_DISABLED", errMentionWatchDisabled.Error(), nil)
+		return
+	}
+	streamEvents(c, []string{eventCommentReceived})
+}
+
+// mentionWatchStatusHandler 监控状态
+// GET /api/v1/mentions/watch
+func (s *AppServer) mentionWatchStatusHandler(c *gin.Context) {
+	interval := configs.GetMentionWatchInterval()
+	webhook, _ := configs.GetMentionWebhook()
+	w := s.mentions
+	w.mu.Lock()
+	data := gin.H{
+		"enabled":     interval > 0,
+		"interval":    interval.String(),
+		"webhook":     webhook != "",
+		"subscribers": bus.StreamCount(),
+		"seen":        len(w.seen),
+		"last_poll":   w.lastAt,
+		"last_error":  w.lastErr,
+	}
+	w.mu.Unlock()
+	respondSuccess(c, data, "获取评论和@监控状态成功")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+func TestMentionWatcherObserve(t *testing.T) {
+	w := newMentionWatcher()
+	now := time.Now()
+
+	// 首次轮询只记录，不推送
+	fresh := w.observe([]xiaohongshu.NotificationMention{{ID: "b"}, {ID: "a"}}, now)
+	require.Empty(t, fresh)
+
+	fresh = w.observe([]xiaohongshu.NotificationMention{{ID: "d", Type: "mention/comment"}, {ID: "c"}, {ID: "b"}}, now.Add(time.Minute))
+	require.Len(t, fresh, 2)
+	require.Equal(t, "c", fresh[0].ID)
+	require.Equal(t, "d", fresh[1].ID)
+	require.Equal(t, "mention/comment", fresh[1].Type)
+
+	require.Empty(t, w.observe([]xiaohongshu.NotificationMention{{ID: "d"}, {ID: "c"}}, now.Add(2*time.Minute)))
+}
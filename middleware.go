@@ -1,7 +1,9 @@
 package main
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -12,7 +14,7 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Token")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -23,6 +25,38 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// authMiddleware 校验访问令牌（Authorization: Bearer <token> 或 X-API-Token），
+// 未配置令牌时不校验；健康检查类接口始终放行。
+// 订阅阅读器无法设置请求头，/api/v1/rss/ 下的接口额外接受 ?token= 查询参数。
+func authMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		switch c.Request.URL.Path {
+		case "/health", "/livez", "/readyz":
+			c.Next()
+			return
+		}
+
+		got := c.GetHeader("X-API-Token")
+		if got == "" {
+			got, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if got == "" && strings.HasPrefix(c.Request.URL.Path, "/api/v1/rss/") {
+			got = c.Query("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(token)) != 1 {
+			respondError(c, http.StatusUnauthorized, "UNAUTHORIZED", "访问令牌无效", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // errorHandlingMiddleware 错误处理中间件
 func errorHandlingMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
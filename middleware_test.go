@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(authMiddleware("secret"))
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/user/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/rss/mentions", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	testCases := []struct {
+		name   string
+		path   string
+		header map[string]string
+		want   int
+	}{
+		{name: "健康检查免校验", path: "/health", want: http.StatusOK},
+		{name: "缺少令牌", path: "/api/v1/user/me", want: http.StatusUnauthorized},
+		{name: "令牌错误", path: "/api/v1/user/me", header: map[string]string{"Authorization": "Bearer wrong"}, want: http.StatusUnauthorized},
+		{name: "Bearer 令牌", path: "/api/v1/user/me", header: map[string]string{"Authorization": "Bearer secret"}, want: http.StatusOK},
+		{name: "X-API-Token", path: "/api/v1/user/me", header: map[string]string{"X-API-Token": "secret"}, want: http.StatusOK},
+		{name: "订阅接口查询参数令牌", path: "/api/v1/rss/mentions?token=secret", want: http.StatusOK},
+		{name: "订阅接口查询参数令牌错误", path: "/api/v1/rss/mentions?token=wrong", want: http.StatusUnauthorized},
+		{name: "普通接口不接受查询参数令牌", path: "/api/v1/user/me?token=secret", want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("状态码不符合预期: got=%d want=%d", w.Code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/moderation"
+)
+
+// eventContentFlagged 内容命中审核（block 策略下操作已被拒绝，warn 策略下已放行）
+const eventContentFlagged = "content_flagged"
+
+// moderationGate 写操作前的内容审核，未配置审核来源时不生效
+var moderationGate = &moderation.Gate{Policy: moderation.PolicyOff}
+
+// initModeration 按配置组装审核：敏感词表文件、外部审核接口，策略为空时默认 block
+func initModeration(policy, wordsFile, apiURL string) error {
+	var mods []moderation.Moderator
+	if wordsFile != "" {
+		words, err := moderation.LoadWordList(wordsFile)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("已加载敏感词 %d 个", words.Len())
+		mods = append(mods, words)
+	}
+	if apiURL != "" {
+		mods = append(mods, moderation.NewHTTPModerator(apiURL, 0))
+	}
+	def := moderation.PolicyOff
+	if len(mods) > 0 {
+		def = moderation.PolicyBlock
+	}
+	p, err := moderation.ParsePolicy(policy, def)
+	if err != nil {
+		return err
+	}
+	moderationGate = &moderation.Gate{Policy: p, Moderators: mods}
+	return nil
+}
+
+// moderateContent 审核写操作内容，block 策略命中时返回错误
+func moderateContent(ctx context.Context, kind string, parts ...string) error {
+	res, err := moderationGate.Check(ctx, kind, strings.Join(parts, "\n"))
+	if !res.Flagged && len(res.Errors) == 0 {
+		return nil
+	}
+	summary := fmt.Sprintf("%s 内容审核: %s", kind, strings.Join(slices.Concat(res.Reasons, res.Errors), "；"))
+	switch res.Policy {
+	case moderation.PolicyBlock:
+		logrus.Warnf("%s，已拒绝", summary)
+		bus.Publish(eventContentFlagged, summary+"，已拒绝", res)
+	case moderation.PolicyWarn:
+		logrus.Warnf("%s，按 warn 策略放行", summary)
+		bus.Publish(eventContentFlagged, summary+"，已放行", res)
+	default:
+		logrus.Infof("%s", summary)
+	}
+	return err
+}
+
+// ModerationCheckRequest 审核试运行请求
+type ModerationCheckRequest struct {
+	Kind string `json:"kind"`
+	Text string `json:"text" binding:"required"`
+}
+
+// moderationCheckHandler 试运行审核，不执行写操作、不发布事件
+// POST /api/v1/moderation/check
+func (s *AppServer) moderationCheckHandler(c *gin.Context) {
+	var req ModerationCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数错误", err.Error())
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = taskKindPublish
+	}
+	res, err := moderationGate.Check(c.Request.Context(), req.Kind, req.Text)
+	respondSuccess(c, gin.H{"result": res, "allowed": err == nil}, "审核完成")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// 列表类接口统一使用游标分页：首次请求抓取完整结果并缓存在服务端，
+// 之后凭 cursor 直接从缓存翻页，不再重复打开浏览器。
+const (
+	defaultPageSize = 20
+	maxPageSize     = 50
+	pageCacheTTL    = 10 * time.Minute
+	maxPageEntries  = 64
+)
+
+var errInvalidCursor = errors.New("cursor 无效或已过期，请不带 cursor 重新查询")
+
+// PageRequest 分页参数；Limit 超出范围时自动收敛到 [1, 50]
+type PageRequest struct {
+	Cursor string `json:"cursor,omitempty" form:"cursor"`
+	Limit  int    `json:"limit,omitempty" form:"limit"`
+}
+
+func (r PageRequest) size() int {
+	switch {
+	case r.Limit <= 0:
+		return defaultPageSize
+	case r.Limit > maxPageSize:
+		return maxPageSize
+	default:
+		return r.Limit
+	}
+}
+
+type pageEntry struct {
+	kind    string
+	feeds   []xiaohongshu.Feed
+	extra   any // 与列表一同返回的附加信息（如用户主页基础信息）
+	expires time.Time
+}
+
+// pageCache 缓存待翻页的完整结果
+type pageCache struct {
+	mu      sync.Mutex
+	entries map[string]*pageEntry
+}
+
+func newPageCache() *pageCache {
+	return &pageCache{entries: make(map[string]*pageEntry)}
+}
+
+func (c *pageCache) put(kind string, feeds []xiaohongshu.Feed, extra any) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var oldestID string
+	var oldest time.Time
+	for id, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, id)
+			continue
+		}
+		if oldestID == "" || e.expires.Before(oldest) {
+			oldestID, oldest = id, e.expires
+		}
+	}
+	if len(c.entries) >= maxPageEntries && oldestID != "" {
+		delete(c.entries, oldestID)
+	}
+
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	id := hex.EncodeToString(buf)
+	c.entries[id] = &pageEntry{kind: kind, feeds: feeds, extra: extra, expires: now.Add(pageCacheTTL)}
+	return id
+}
+
+func (c *pageCache) get(kind, id string) (*pageEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok || e.kind != kind || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e, true
+}
+
+// feedPage 一页结果
+type feedPage struct {
+	Feeds   []xiaohongshu.Feed
+	Extra   any
+	Cursor  string
+	HasMore bool
+}
+
+// paginateFeeds 按游标返回一页；没有 cursor 时调用 fetch 抓取完整结果
+func (s *XiaohongshuService) paginateFeeds(kind string, req PageRequest, fetch func() ([]xiaohongshu.Feed, any, error)) (*feedPage, error) {
+	var (
+		id     string
+		offset int
+		entry  *pageEntry
+	)
+
+	if req.Cursor != "" {
+		var err error
+		id, offset, err = parseCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		e, ok := s.pages.get(kind, id)
+		if !ok || offset > len(e.feeds) {
+			return nil, errInvalidCursor
+		}
+		entry = e
+	} else {
+		feeds, extra, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		entry = &pageEntry{kind: kind, feeds: feeds, extra: extra}
+	}
+
+	end := offset + req.size()
+	if end > len(entry.feeds) {
+		end = len(entry.feeds)
+	}
+	page := &feedPage{
+		Feeds:   entry.feeds[offset:end],
+		Extra:   entry.extra,
+		HasMore: end < len(entry.feeds),
+	}
+	if page.HasMore {
+		if id == "" {
+			id = s.pages.put(kind, entry.feeds, entry.extra)
+		}
+		page.Cursor = fmt.Sprintf("%s.%d", id, end)
+	}
+	return page, nil
+}
+
+func parseCursor(cursor string) (string, int, error) {
+	id, off, ok := strings.Cut(cursor, ".")
+	if !ok || id == "" {
+		return "", 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(off)
+	if err != nil || offset < 0 {
+		return "", 0, errInvalidCursor
+	}
+	return id, offset, nil
+}
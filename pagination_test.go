@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+func TestPaginateFeeds(t *testing.T) {
+	s := &XiaohongshuService{pages: newPageCache()}
+	all := make([]xiaohongshu.Feed, 45)
+	for i := range all {
+		all[i].ID = string(rune('a' + i%26))
+	}
+
+	fetches := 0
+	fetch := func() ([]xiaohongshu.Feed, any, error) {
+		fetches++
+		return all, nil, nil
+	}
+
+	first, err := s.paginateFeeds("search_feeds", PageRequest{}, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Feeds) != defaultPageSize || !first.HasMore || first.Cursor == "" {
+		t.Fatalf("第一页不符合预期: len=%d has_more=%v cursor=%q", len(first.Feeds), first.HasMore, first.Cursor)
+	}
+
+	second, err := s.paginateFeeds("search_feeds", PageRequest{Cursor: first.Cursor, Limit: 1000}, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Feeds) != 25 || second.HasMore || second.Cursor != "" {
+		t.Fatalf("第二页不符合预期: len=%d has_more=%v cursor=%q", len(second.Feeds), second.HasMore, second.Cursor)
+	}
+	if fetches != 1 {
+		t.Fatalf("翻页不应重新抓取，fetches=%d", fetches)
+	}
+
+	if _, err := s.paginateFeeds("list_feeds", PageRequest{Cursor: first.Cursor}, fetch); !errors.Is(err, errInvalidCursor) {
+		t.Fatalf("跨工具使用游标应返回 errInvalidCursor，got %v", err)
+	}
+	if _, err := s.paginateFeeds("search_feeds", PageRequest{Cursor: "bogus"}, fetch); !errors.Is(err, errInvalidCursor) {
+		t.Fatalf("非法游标应返回 errInvalidCursor，got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+// Package chromecookies 从本机 Chrome/Edge/Chromium 的用户目录读取指定域名的 cookies。
+//
+// 浏览器把 cookies 加密存放在 SQLite 数据库中，密钥因系统而异：
+// Windows 为 Local State 中经 DPAPI 保护的 AES-GCM 密钥，macOS 为钥匙串中的 Safe Storage 密码，
+// Linux 为固定密码或 libsecret 中的密码。只能在浏览器所在系统、以同一系统用户解密。
+package chromecookies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+// 支持的浏览器
+const (
+	BrowserChrome   = "chrome"
+	BrowserEdge     = "edge"
+	BrowserChromium = "chromium"
+)
+
+// DefaultDomain 默认只读取小红书的 cookies
+const DefaultDomain = "xiaohongshu.com"
+
+// ErrNoCookies 浏览器中没有目标域名的 cookie
+var ErrNoCookies = errors.New("浏览器中没有找到目标域名的 cookie，请先在该浏览器中登录")
+
+// Options 读取参数
+type Options struct {
+	Browser     string // chrome、edge、chromium，默认 chrome
+	UserDataDir string // 浏览器用户数据目录，为空使用系统默认位置
+	Profile     string // 配置文件目录名，默认 Default
+	Domain      string // 读取该域名及其子域名的 cookie，默认 xiaohongshu.com
+}
+
+func (o *Options) normalize() error {
+	o.Browser = strings.ToLower(strings.TrimSpace(o.Browser))
+	if o.Browser == "" {
+		o.Browser = BrowserChrome
+	}
+	switch o.Browser {
+	case BrowserChrome, BrowserEdge, BrowserChromium:
+	default:
+		return fmt.Errorf("不支持的浏览器 %q（可选 chrome、edge、chromium）", o.Browser)
+	}
+	if o.UserDataDir == "" {
+		dir, err := DefaultUserDataDir(o.Browser)
+		if err != nil {
+			return err
+		}
+		o.UserDataDir = dir
+	}
+	if o.Profile == "" {
+		o.Profile = "Default"
+	}
+	o.Domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(o.Domain)), ".")
+	if o.Domain == "" {
+		o.Domain = DefaultDomain
+	}
+	return nil
+}
+
+// DefaultUserDataDir 当前系统下浏览器默认的用户数据目录
+func DefaultUserDataDir(browser string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	var parts map[string][]string
+	switch runtime.GOOS {
+	case "windows":
+		base := os.Getenv("LOCALAPPDATA")
+		if base == "" {
+			base = filepath.Join(home, "AppData", "Local")
+		}
+		parts = map[string][]string{
+			BrowserChrome:   {base, "Google", "Chrome", "User Data"},
+			BrowserEdge:     {base, "Microsoft", "Edge", "User Data"},
+			BrowserChromium: {base, "Chromium", "User Data"},
+		}
+	case "darwin":
+		base := filepath.Join(home, "Library", "Application Support")
+		parts = map[string][]string{
+			BrowserChrome:   {base, "Google", "Chrome"},
+			BrowserEdge:     {base, "Microsoft Edge"},
+			BrowserChromium: {base, "Chromium"},
+		}
+	default:
+		base := os.Getenv("XDG_CONFIG_HOME")
+		if base == "" {
+			base = filepath.Join(home, ".config")
+		}
+		parts = map[string][]string{
+			BrowserChrome:   {base, "google-chrome"},
+			BrowserEdge:     {base, "microsoft-edge"},
+			BrowserChromium: {base, "chromium"},
+		}
+	}
+	p, ok := parts[browser]
+	if !ok {
+		return "", fmt.Errorf("不支持的浏览器 %q", browser)
+	}
+	return filepath.Join(p...), nil
+}
+
+// Read 读取并解密目标域名的 cookies，已过期的 cookie 会被跳过
+func Read(opts Options) ([]cookies.Cookie, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	data, err := readCookieDB(filepath.Join(opts.UserDataDir, opts.Profile))
+	if err != nil {
+		return nil, err
+	}
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+	tbl, err := db.readTable("cookies")
+	if err != nil {
+		return nil, err
+	}
+	// 数据库版本 24 起，解密后的值前带 32 字节的域名哈希
+	hashPrefix := false
+	if meta, err := db.readTable("meta"); err == nil {
+		for _, row := range meta.rows {
+			if meta.value(row, "key") == "version" {
+				v, _ := strconv.Atoi(fmt.Sprint(meta.value(row, "value")))
+				hashPrefix = v >= 24
+			}
+		}
+	}
+
+	var (
+		dec  decryptor
+		out  []cookies.Cookie
+		now  = time.Now()
+		errs []string
+	)
+	for _, row := range tbl.rows {
+		host := asString(tbl.value(row, "host_key"))
+		if !matchDomain(host, opts.Domain) {
+			continue
+		}
+		c := cookies.Cookie{
+			Name:     asString(tbl.value(row, "name")),
+			Value:    asString(tbl.value(row, "value")),
+			Domain:   host,
+			Path:     asString(tbl.value(row, "path")),
+			HTTPOnly: asInt(tbl.value(row, "is_httponly")) == 1,
+			Secure:   asInt(tbl.value(row, "is_secure")) == 1,
+			SameSite: sameSite(asInt(tbl.value(row, "samesite"))),
+		}
+		if exp := chromeTime(asInt(tbl.value(row, "expires_utc"))); exp != nil {
+			if !exp.After(now) {
+				continue
+			}
+			c.Expires = exp
+		}
+		if enc, _ := tbl.value(row, "encrypted_value").([]byte); c.Value == "" && len(enc) > 0 {
+			if dec == nil {
+				if dec, err = newDecryptor(opts.Browser, opts.UserDataDir); err != nil {
+					return nil, err
+				}
+			}
+			plain, err := dec(enc)
+			if err != nil {
+				errs = append(errs, c.Name+": "+err.Error())
+				continue
+			}
+			if hashPrefix && len(plain) >= 32 {
+				plain = plain[32:]
+			}
+			c.Value = string(plain)
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("解密 cookie 失败: %s", strings.Join(errs, "；"))
+		}
+		return nil, ErrNoCookies
+	}
+	return out, nil
+}
+
+// readCookieDB 读取 Cookies 数据库，新版本位于 Network 子目录
+func readCookieDB(profileDir string) ([]byte, error) {
+	var lastErr error
+	for _, p := range []string{filepath.Join(profileDir, "Network", "Cookies"), filepath.Join(profileDir, "Cookies")} {
+		data, err := os.ReadFile(p)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			// Windows 下浏览器运行时会独占数据库文件
+			return nil, fmt.Errorf("读取 %s 失败（浏览器运行中时请先关闭）: %w", p, err)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("未找到 Cookies 数据库，请检查浏览器用户目录与配置文件名: %w", lastErr)
+}
+
+func matchDomain(host, domain string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), ".")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// chromeTime 浏览器时间为自 1601-01-01 起的微秒数，0 表示会话 cookie
+func chromeTime(us int64) *time.Time {
+	if us <= 0 {
+		return nil
+	}
+	const epochDelta = 11644473600 // 1601-01-01 到 1970-01-01 的秒数
+	t := time.Unix(us/1e6-epochDelta, (us%1e6)*1e3)
+	return &t
+}
+
+func sameSite(v int64) string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "Lax"
+	case 2:
+		return "Strict"
+	}
+	return ""
+}
+
+func asString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+func asInt(v any) int64 {
+	i, _ := v.(int64)
+	return i
+}
+
+// decryptor 解密 encrypted_value
+type decryptor func(enc []byte) ([]byte, error)
+
+// trimVersion 拆分 v10/v11/v20 前缀
+func trimVersion(enc []byte) (string, []byte) {
+	if len(enc) > 3 && enc[0] == 'v' && bytes.IndexByte([]byte("0123456789"), enc[1]) >= 0 {
+		return string(enc[:3]), enc[3:]
+	}
+	return "", enc
+}
@@ -0,0 +1,50 @@
+package chromecookies
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadLinuxProfile(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("testdata/Cookies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "Default", "Network"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Default", "Network", "Cookies"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := Read(Options{UserDataDir: dir})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range list {
+		got[c.Name] = c.Value
+	}
+	// 过期的、其他域名的 cookie 不导入；v10 密文解密并去掉域名哈希前缀
+	if len(got) != 3 || got["web_session"] != "sess-123" || got["a1"] != "plain-a1" || got["long"] != strings.Repeat("L", 5000) {
+		t.Fatalf("读取结果不符: %d 条 %v", len(got), got["web_session"])
+	}
+	for _, c := range list {
+		if c.Name == "web_session" && (!c.HTTPOnly || !c.Secure || c.SameSite != "Lax" || c.Expires == nil) {
+			t.Fatalf("属性不符: %+v", c)
+		}
+		if c.Name == "a1" && c.Expires != nil {
+			t.Fatalf("会话 cookie 不应有过期时间: %+v", c)
+		}
+	}
+
+	if _, err := Read(Options{UserDataDir: dir, Domain: "nothing.example"}); err != ErrNoCookies {
+		t.Fatalf("没有匹配的 cookie 时应返回 ErrNoCookies: %v", err)
+	}
+	if _, err := Read(Options{UserDataDir: dir, Browser: "firefox"}); err == nil {
+		t.Fatal("不支持的浏览器应报错")
+	}
+}
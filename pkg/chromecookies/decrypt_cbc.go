@@ -0,0 +1,40 @@
+//go:build darwin || linux
+
+package chromecookies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"errors"
+)
+
+// cbcKey 由 Safe Storage 密码派生 AES-128 密钥
+func cbcKey(password string, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha1.New, password, []byte("saltysalt"), iterations, 16)
+}
+
+// decryptCBC macOS 与 Linux 使用的 AES-128-CBC，IV 为 16 个空格，PKCS#7 填充
+func decryptCBC(key, data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("密文长度不正确")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := []byte("                ")
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(out) {
+		return nil, errors.New("解密失败，密钥可能不正确")
+	}
+	for _, b := range out[len(out)-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("解密失败，密钥可能不正确")
+		}
+	}
+	return out[:len(out)-pad], nil
+}
@@ -0,0 +1,33 @@
+package chromecookies
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var keychainServices = map[string]string{
+	BrowserChrome:   "Chrome Safe Storage",
+	BrowserEdge:     "Microsoft Edge Safe Storage",
+	BrowserChromium: "Chromium Safe Storage",
+}
+
+// macOS：密码保存在钥匙串的「<浏览器> Safe Storage」中，首次读取时系统会弹窗请求授权
+func newDecryptor(browser, _ string) (decryptor, error) {
+	service := keychainServices[browser]
+	out, err := exec.Command("security", "find-generic-password", "-w", "-s", service).Output()
+	if err != nil {
+		return nil, fmt.Errorf("从钥匙串读取「%s」失败: %w", service, err)
+	}
+	key, err := cbcKey(strings.TrimSpace(string(out)), 1003)
+	if err != nil {
+		return nil, err
+	}
+	return func(enc []byte) ([]byte, error) {
+		version, data := trimVersion(enc)
+		if version != "v10" {
+			return nil, fmt.Errorf("不支持的加密版本 %q", version)
+		}
+		return decryptCBC(key, data)
+	}, nil
+}
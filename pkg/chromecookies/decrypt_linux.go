@@ -0,0 +1,44 @@
+package chromecookies
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Linux：v10 使用固定密码 peanuts；v11 使用 libsecret（GNOME 钥匙环等）中保存的密码，通过 secret-tool 读取
+func newDecryptor(browser, _ string) (decryptor, error) {
+	v10, err := cbcKey("peanuts", 1)
+	if err != nil {
+		return nil, err
+	}
+	var v11 []byte
+	return func(enc []byte) ([]byte, error) {
+		version, data := trimVersion(enc)
+		switch version {
+		case "v10":
+			return decryptCBC(v10, data)
+		case "v11":
+			if v11 == nil {
+				password, err := keyringPassword(browser)
+				if err != nil {
+					return nil, err
+				}
+				if v11, err = cbcKey(password, 1); err != nil {
+					return nil, err
+				}
+			}
+			return decryptCBC(v11, data)
+		}
+		return nil, fmt.Errorf("不支持的加密版本 %q", version)
+	}, nil
+}
+
+func keyringPassword(browser string) (string, error) {
+	app := map[string]string{BrowserChrome: "chrome", BrowserChromium: "chromium", BrowserEdge: "microsoft-edge"}[browser]
+	out, err := exec.Command("secret-tool", "lookup", "application", app).Output()
+	if err != nil {
+		return "", fmt.Errorf("从系统钥匙环读取 %s 密码失败（需要 secret-tool）: %w", browser, err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !darwin && !linux
+
+package chromecookies
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newDecryptor(string, string) (decryptor, error) {
+	return nil, fmt.Errorf("不支持在 %s 上解密浏览器 cookies", runtime.GOOS)
+}
@@ -0,0 +1,85 @@
+package chromecookies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows：Local State 中的 os_crypt.encrypted_key 经 DPAPI 保护，解出的 AES-256 密钥用于 v10 的 AES-GCM；
+// 无版本前缀的旧数据直接由 DPAPI 解密。Chrome 127 起的 v20（应用绑定加密）只能由浏览器自身解密。
+func newDecryptor(_ string, userDataDir string) (decryptor, error) {
+	var aead cipher.AEAD
+	if key, err := localStateKey(userDataDir); err == nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return func(enc []byte) ([]byte, error) {
+		version, data := trimVersion(enc)
+		switch version {
+		case "":
+			return dpapiDecrypt(enc)
+		case "v10", "v11":
+			if aead == nil {
+				return nil, errors.New("缺少 Local State 中的密钥")
+			}
+			if len(data) < aead.NonceSize()+aead.Overhead() {
+				return nil, errors.New("密文长度不正确")
+			}
+			nonce, ct := data[:aead.NonceSize()], data[aead.NonceSize():]
+			return aead.Open(nil, nonce, ct, nil)
+		case "v20":
+			return nil, errors.New("该 cookie 使用应用绑定加密（v20），无法在浏览器外解密，请改用扫码登录或手动导入")
+		}
+		return nil, fmt.Errorf("不支持的加密版本 %q", version)
+	}, nil
+}
+
+func localStateKey(userDataDir string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(userDataDir, "Local State"))
+	if err != nil {
+		return nil, err
+	}
+	var state struct {
+		OSCrypt struct {
+			EncryptedKey string `json:"encrypted_key"`
+		} `json:"os_crypt"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析 Local State 失败: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(state.OSCrypt.EncryptedKey)
+	if err != nil || len(raw) < 5 || string(raw[:5]) != "DPAPI" {
+		return nil, errors.New("Local State 中的密钥格式不正确")
+	}
+	return dpapiDecrypt(raw[5:])
+}
+
+func dpapiDecrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("密文为空")
+	}
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(&in, nil, nil, 0, nil, 0, &out); err != nil {
+		return nil, fmt.Errorf("DPAPI 解密失败: %w", err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return append([]byte(nil), unsafe.Slice(out.Data, out.Size)...), nil
+}
@@ -0,0 +1,340 @@
+package chromecookies
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 只读的最小 SQLite 解析，仅支持按表名遍历整张表，足以读取浏览器的 Cookies 数据库，
+// 避免为此引入 cgo 或完整的 SQLite 实现。不读取 -wal/-journal 文件。
+
+const sqliteMagic = "SQLite format 3\x00"
+
+var errCorrupt = errors.New("数据库文件格式不正确")
+
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+// sqliteTable 表的列名与所有行
+type sqliteTable struct {
+	columns []string
+	rows    [][]any // 值类型为 nil、int64、float64、string、[]byte
+}
+
+// value 按列名取值，列不存在或该行缺少此列时返回 nil
+func (t *sqliteTable) value(row []any, column string) any {
+	for i, c := range t.columns {
+		if c == column {
+			if i < len(row) {
+				return row[i]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteMagic {
+		return nil, errors.New("不是 SQLite 数据库文件")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, errCorrupt
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, fmt.Errorf("不支持的文本编码 %d", enc)
+	}
+	return &sqliteDB{data: data, pageSize: pageSize, usable: pageSize - int(data[20])}, nil
+}
+
+func (db *sqliteDB) page(n uint32) ([]byte, error) {
+	start := int64(n-1) * int64(db.pageSize)
+	if n == 0 || start+int64(db.pageSize) > int64(len(db.data)) {
+		return nil, errCorrupt
+	}
+	return db.data[start : start+int64(db.pageSize)], nil
+}
+
+// readTable 读取整张表
+func (db *sqliteDB) readTable(name string) (*sqliteTable, error) {
+	var (
+		root    uint32
+		sql     string
+		pkAlias = -1
+	)
+	err := db.walk(1, 0, func(_ int64, rec []any) error {
+		if len(rec) < 5 || rec[0] != "table" || !strings.EqualFold(fmt.Sprint(rec[1]), name) {
+			return nil
+		}
+		if r, ok := rec[3].(int64); ok {
+			root = uint32(r)
+		}
+		sql, _ = rec[4].(string)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if root == 0 {
+		return nil, fmt.Errorf("表 %s 不存在", name)
+	}
+	t := &sqliteTable{}
+	t.columns, pkAlias = parseColumns(sql)
+	err = db.walk(root, 0, func(rowid int64, rec []any) error {
+		// INTEGER PRIMARY KEY 列在记录中存为 NULL，值即 rowid
+		if pkAlias >= 0 && pkAlias < len(rec) && rec[pkAlias] == nil {
+			rec[pkAlias] = rowid
+		}
+		t.rows = append(t.rows, rec)
+		return nil
+	})
+	return t, err
+}
+
+// walk 遍历表 B 树的所有记录
+func (db *sqliteDB) walk(pageNo uint32, depth int, fn func(rowid int64, rec []any) error) error {
+	if depth > 32 {
+		return errCorrupt
+	}
+	p, err := db.page(pageNo)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if pageNo == 1 {
+		hdr = 100
+	}
+	if len(p) < hdr+12 {
+		return errCorrupt
+	}
+	kind := p[hdr]
+	cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	ptrs := hdr + 8
+	if kind == 0x05 {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(p) {
+		return errCorrupt
+	}
+	for i := 0; i < cells; i++ {
+		off := int(binary.BigEndian.Uint16(p[ptrs+2*i:]))
+		if off >= len(p) {
+			return errCorrupt
+		}
+		switch kind {
+		case 0x05: // 内部节点：左子页号 + rowid
+			if off+4 > len(p) {
+				return errCorrupt
+			}
+			if err := db.walk(binary.BigEndian.Uint32(p[off:]), depth+1, fn); err != nil {
+				return err
+			}
+		case 0x0d: // 叶子节点
+			rowid, rec, err := db.leafCell(p, off)
+			if err != nil {
+				return err
+			}
+			if err := fn(rowid, rec); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: 页 %d 类型 %d", errCorrupt, pageNo, kind)
+		}
+	}
+	if kind == 0x05 {
+		return db.walk(binary.BigEndian.Uint32(p[hdr+8:]), depth+1, fn)
+	}
+	return nil
+}
+
+// leafCell 解析叶子单元格，处理溢出页
+func (db *sqliteDB) leafCell(p []byte, off int) (int64, []any, error) {
+	size, n := readVarint(p[off:])
+	if n == 0 {
+		return 0, nil, errCorrupt
+	}
+	off += n
+	rowid, n := readVarint(p[off:])
+	if n == 0 {
+		return 0, nil, errCorrupt
+	}
+	off += n
+
+	total := int(size)
+	maxLocal := db.usable - 35
+	local := total
+	if total > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(p) {
+		return 0, nil, errCorrupt
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, p[off:off+local]...)
+	if local < total {
+		if off+local+4 > len(p) {
+			return 0, nil, errCorrupt
+		}
+		next := binary.BigEndian.Uint32(p[off+local:])
+		for len(payload) < total {
+			ov, err := db.page(next)
+			if err != nil {
+				return 0, nil, err
+			}
+			chunk := ov[4:db.usable]
+			if rest := total - len(payload); len(chunk) > rest {
+				chunk = chunk[:rest]
+			}
+			payload = append(payload, chunk...)
+			next = binary.BigEndian.Uint32(ov)
+		}
+	}
+	rec, err := parseRecord(payload)
+	return int64(rowid), rec, err
+}
+
+// parseRecord 解析记录格式
+func parseRecord(b []byte) ([]any, error) {
+	hdrSize, n := readVarint(b)
+	if n == 0 || int(hdrSize) > len(b) {
+		return nil, errCorrupt
+	}
+	var types []uint64
+	for pos := n; pos < int(hdrSize); {
+		t, m := readVarint(b[pos:])
+		if m == 0 {
+			return nil, errCorrupt
+		}
+		types = append(types, t)
+		pos += m
+	}
+	out := make([]any, len(types))
+	pos := int(hdrSize)
+	for i, t := range types {
+		var size int
+		switch {
+		case t == 0, t == 8, t == 9:
+		case t >= 1 && t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		default:
+			return nil, errCorrupt
+		}
+		if pos+size > len(b) {
+			return nil, errCorrupt
+		}
+		v := b[pos : pos+size]
+		switch {
+		case t == 0:
+			out[i] = nil
+		case t == 8:
+			out[i] = int64(0)
+		case t == 9:
+			out[i] = int64(1)
+		case t == 7:
+			out[i] = math.Float64frombits(binary.BigEndian.Uint64(v))
+		case t <= 6:
+			out[i] = readInt(v)
+		case t%2 == 0:
+			out[i] = append([]byte(nil), v...)
+		default:
+			out[i] = string(v)
+		}
+		pos += size
+	}
+	return out, nil
+}
+
+// readInt 大端有符号整数
+func readInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// readVarint SQLite 变长整数，返回值与占用字节数，数据不足时字节数为 0
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
+
+// parseColumns 从建表语句中取列名，并返回 INTEGER PRIMARY KEY 列的下标（没有为 -1）
+func parseColumns(sql string) ([]string, int) {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return nil, -1
+	}
+	var (
+		defs  []string
+		depth int
+		last  = start + 1
+	)
+	for i := start + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[last:i])
+				last = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[last:end])
+
+	var cols []string
+	pk := -1
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		if strings.Contains(strings.ToUpper(strings.Join(fields[1:], " ")), "INTEGER PRIMARY KEY") {
+			pk = len(cols)
+		}
+		cols = append(cols, strings.Trim(fields[0], "\"`[]'"))
+	}
+	return cols, pk
+}
@@ -0,0 +1,52 @@
+package chromecookies
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadTable(t *testing.T) {
+	data, err := os.ReadFile("testdata/Cookies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openSQLite(data)
+	if err != nil {
+		t.Fatalf("openSQLite: %v", err)
+	}
+	tbl, err := db.readTable("cookies")
+	if err != nil {
+		t.Fatalf("readTable: %v", err)
+	}
+	// 300 条填充数据跨越多个页，长 cookie 使用溢出页
+	if len(tbl.rows) != 305 {
+		t.Fatalf("行数 = %d, want 305", len(tbl.rows))
+	}
+	if tbl.columns[1] != "host_key" || len(tbl.columns) != 20 {
+		t.Fatalf("列解析不符: %v", tbl.columns)
+	}
+	var long []byte
+	for _, row := range tbl.rows {
+		if tbl.value(row, "name") == "long" {
+			long, _ = tbl.value(row, "encrypted_value").([]byte)
+		}
+	}
+	if len(long) < 5000 {
+		t.Fatalf("溢出页内容读取不完整: %d 字节", len(long))
+	}
+
+	if _, err := db.readTable("missing"); err == nil || !strings.Contains(err.Error(), "不存在") {
+		t.Fatalf("表不存在时应报错: %v", err)
+	}
+	if _, err := openSQLite([]byte("not a database")); err == nil {
+		t.Fatal("非 SQLite 文件应报错")
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, pk := parseColumns(`CREATE TABLE t(id INTEGER PRIMARY KEY, "name" TEXT, v DEFAULT (1, 2), UNIQUE (name, v))`)
+	if strings.Join(cols, ",") != "id,name,v" || pk != 0 {
+		t.Fatalf("cols=%v pk=%d", cols, pk)
+	}
+}
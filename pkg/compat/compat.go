@@ -0,0 +1,120 @@
+// Package compat 实例与 manager 之间的版本兼容信息。
+//
+// 实例通过 -capabilities 参数和 /health 报告构建版本、工具结构版本和支持的可选功能，
+// manager 据此只传入实例支持的启动参数，并标记与自身版本不一致的实例。
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"slices"
+)
+
+// ToolSchemaVersion MCP 工具参数结构的版本，新增/删除工具或参数出现不兼容变更时递增
+const ToolSchemaVersion = 1
+
+// 可选功能，对应 manager 按需传给实例的启动参数
+const (
+	FeatureDevTools   = "devtools"    // -enable-devtools
+	FeatureModeration = "moderation"  // -moderation
+	FeatureUnixSocket = "unix_socket" // -port=unix:/path
+	FeatureLang       = "lang"        // -lang
+	FeatureReadOnly   = "read_only"   // -read-only
+	FeatureTimezone   = "timezone"    // -timezone
+)
+
+// Features 当前构建支持的可选功能
+func Features() []string {
+	return []string{FeatureDevTools, FeatureModeration, FeatureUnixSocket, FeatureLang, FeatureReadOnly, FeatureTimezone}
+}
+
+// Info 一个二进制的版本与能力
+type Info struct {
+	Version    string   `json:"version"`
+	Commit     string   `json:"commit,omitempty"`
+	ToolSchema int      `json:"tool_schema"`
+	Features   []string `json:"features"`
+}
+
+// Current 当前进程的版本与能力
+func Current() Info {
+	version, commit := Build()
+	return Info{
+		Version:    version,
+		Commit:     commit,
+		ToolSchema: ToolSchemaVersion,
+		Features:   Features(),
+	}
+}
+
+// Known 是否报告过能力信息；不支持 -capabilities 的旧版本二进制为 false
+func (i Info) Known() bool {
+	return i.ToolSchema > 0
+}
+
+// Supports 是否支持某个可选功能
+func (i Info) Supports(feature string) bool {
+	return slices.Contains(i.Features, feature)
+}
+
+// Parse 解析 -capabilities 的输出
+func Parse(data []byte) (Info, error) {
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, fmt.Errorf("解析能力信息失败: %w", err)
+	}
+	if !info.Known() {
+		return Info{}, fmt.Errorf("能力信息缺少 tool_schema")
+	}
+	return info, nil
+}
+
+// Build 返回构建版本和完整提交号：发布构建为模块版本，本地构建版本为提交号前 12 位
+func Build() (version, commit string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown", ""
+	}
+	return BuildVersion(info)
+}
+
+// BuildVersion 从构建信息中取版本和提交号，规则同 Build；可用于 debug/buildinfo 读取的其他二进制
+func BuildVersion(info *debug.BuildInfo) (version, commit string) {
+	version = info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if version == "(devel)" && commit != "" {
+		version = commit
+		if len(version) > 12 {
+			version = version[:12]
+		}
+		if modified {
+			version += "-dirty"
+		}
+	}
+	return version, commit
+}
+
+// Skew 描述实例与 manager 的版本差异，一致时返回空
+func Skew(manager, instance Info) string {
+	switch {
+	case !instance.Known():
+		return "实例版本过旧，未报告兼容信息"
+	case instance.ToolSchema != manager.ToolSchema:
+		return fmt.Sprintf("工具结构版本不一致：实例 %d，manager %d", instance.ToolSchema, manager.ToolSchema)
+	case instance.Commit != "" && manager.Commit != "" && instance.Commit != manager.Commit:
+		return fmt.Sprintf("构建版本不一致：实例 %s，manager %s", instance.Version, manager.Version)
+	}
+	return ""
+}
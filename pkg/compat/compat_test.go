@@ -0,0 +1,38 @@
+package compat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAndSkew(t *testing.T) {
+	self := Info{Version: "abc", Commit: "abc", ToolSchema: ToolSchemaVersion}
+
+	info, err := Parse([]byte(`{"version":"v1","commit":"def","tool_schema":1,"features":["devtools"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Supports(FeatureDevTools) || info.Supports(FeatureModeration) {
+		t.Fatalf("features = %v", info.Features)
+	}
+	if _, err := Parse([]byte(`{"version":"v1"}`)); err == nil {
+		t.Fatal("缺少 tool_schema 应报错")
+	}
+
+	cases := []struct {
+		inst Info
+		want string
+	}{
+		{self, ""},
+		{Info{Version: "abc", ToolSchema: ToolSchemaVersion}, ""}, // 无提交号时不比较构建
+		{Info{Version: "old"}, "过旧"},
+		{Info{ToolSchema: ToolSchemaVersion + 1}, "工具结构"},
+		{Info{Version: "def", Commit: "def", ToolSchema: ToolSchemaVersion}, "构建版本"},
+	}
+	for _, c := range cases {
+		got := Skew(self, c.inst)
+		if (c.want == "") != (got == "") || !strings.Contains(got, c.want) {
+			t.Errorf("Skew(%+v) = %q, want %q", c.inst, got, c.want)
+		}
+	}
+}
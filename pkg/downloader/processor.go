@@ -1,14 +1,19 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/h2non/filetype"
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/objstore"
 )
 
 // ImageProcessor 图片处理器
 type ImageProcessor struct {
 	downloader *ImageDownloader
+	objects    *objstore.Downloader
 }
 
 // NewImageProcessor 创建图片处理器
@@ -27,13 +32,15 @@ func NewImageProcessorWithProxy(proxy string) (*ImageProcessor, error) {
 	}
 	return &ImageProcessor{
 		downloader: downloader,
+		objects:    objstore.NewDownloader(configs.GetImagesPath(), 2*time.Minute),
 	}, nil
 }
 
 // ProcessImages 处理图片列表，返回本地文件路径
-// 支持两种输入格式：
+// 支持三种输入格式：
 // 1. URL格式 (http/https开头) - 自动下载到本地
-// 2. 本地文件路径 - 直接使用
+// 2. 对象存储地址 (s3/oss/cos://bucket/key) - 使用实例配置的凭证下载到本地
+// 3. 本地文件路径 - 直接使用
 // 保持原始图片顺序，如果下载失败直接返回错误
 func (p *ImageProcessor) ProcessImages(images []string) ([]string, error) {
 	localPaths := make([]string, 0, len(images))
@@ -47,6 +54,15 @@ func (p *ImageProcessor) ProcessImages(images []string) ([]string, error) {
 				return nil, fmt.Errorf("下载图片失败 %s: %w", image, err)
 			}
 			localPaths = append(localPaths, localPath)
+		} else if objstore.IsObjectURL(image) {
+			localPath, err := p.objects.Download(context.Background(), image)
+			if err != nil {
+				return nil, fmt.Errorf("下载图片失败 %s: %w", image, err)
+			}
+			if kind, err := filetype.MatchFile(localPath); err != nil || kind.MIME.Type != "image" {
+				return nil, fmt.Errorf("下载图片失败 %s: 不是有效的图片", image)
+			}
+			localPaths = append(localPaths, localPath)
 		} else {
 			// 本地路径直接使用
 			localPaths = append(localPaths, image)
@@ -0,0 +1,272 @@
+// Package eventbus 进程内事件总线。
+//
+// 业务代码只负责 Publish，webhook、SSE、日志、群机器人等订阅方各自注册为 Sink，
+// 每个订阅方有独立的缓冲队列和投递协程，处理慢或失败的订阅方不会阻塞发布者和其他订阅方。
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	recentLimit = 200 // 保留的最近事件数，用于 SSE 断线重连回放
+	queueSize   = 64  // 每个订阅方的缓冲队列长度，满了丢弃
+)
+
+// Event 一条事件
+type Event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	At      time.Time `json:"at"`
+	Summary string    `json:"summary,omitempty"` // 面向人的一句话描述，日志和群机器人直接使用
+	Data    any       `json:"data,omitempty"`
+}
+
+// Sink 事件订阅方
+type Sink interface {
+	Deliver(ctx context.Context, ev Event) error
+}
+
+// SinkFunc 函数形式的 Sink
+type SinkFunc func(ctx context.Context, ev Event) error
+
+// Deliver 实现 Sink
+func (f SinkFunc) Deliver(ctx context.Context, ev Event) error { return f(ctx, ev) }
+
+// SinkStats 订阅方投递统计
+type SinkStats struct {
+	Name      string    `json:"name"`
+	Types     []string  `json:"types,omitempty"` // 为空表示全部类型
+	Delivered int       `json:"delivered"`
+	Failed    int       `json:"failed"`
+	Dropped   int       `json:"dropped"` // 队列已满被丢弃的事件数
+	LastError string    `json:"last_error,omitempty"`
+	LastAt    time.Time `json:"last_at,omitempty"`
+}
+
+type subscription struct {
+	types []string
+	ch    chan Event
+	stats SinkStats // 由 Bus.mu 保护
+	sink  Sink      // SSE 等流式订阅为 nil
+}
+
+func (s *subscription) wants(typ string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, typ)
+}
+
+// Bus 事件总线
+type Bus struct {
+	// OnError 投递失败回调，可用于记录日志
+	OnError func(sink string, ev Event, err error)
+
+	mu     sync.Mutex
+	subs   map[*subscription]struct{}
+	recent []Event
+	seq    uint64
+	closed bool
+
+	streamsClosed bool // 流式订阅已关闭，服务退出时先断开 SSE 连接
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建事件总线
+func New() *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus{subs: map[*subscription]struct{}{}, ctx: ctx, cancel: cancel}
+}
+
+// Publish 发布事件，不阻塞
+func (b *Bus) Publish(typ, summary string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	now := time.Now()
+	ev := Event{ID: fmt.Sprintf("%d-%d", now.Unix(), b.seq), Type: typ, At: now, Summary: summary, Data: data}
+	if b.closed {
+		return ev
+	}
+
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > recentLimit {
+		b.recent = append(b.recent[:0:0], b.recent[len(b.recent)-recentLimit:]...)
+	}
+	for s := range b.subs {
+		if !s.wants(typ) {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			s.stats.Dropped++
+		}
+	}
+	return ev
+}
+
+// Subscribe 注册订阅方，types 为空表示接收全部事件；返回的函数用于取消订阅
+func (b *Bus) Subscribe(name string, sink Sink, types ...string) (unsubscribe func()) {
+	s := &subscription{types: types, ch: make(chan Event, queueSize), sink: sink, stats: SinkStats{Name: name, Types: types}}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return func() {}
+	}
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for ev := range s.ch {
+			err := sink.Deliver(b.ctx, ev)
+			b.mu.Lock()
+			s.stats.LastAt = time.Now()
+			if err != nil {
+				s.stats.Failed++
+				s.stats.LastError = err.Error()
+			} else {
+				s.stats.Delivered++
+			}
+			b.mu.Unlock()
+			if err != nil && b.OnError != nil {
+				b.OnError(name, ev, err)
+			}
+		}
+	}()
+	return func() { b.remove(s) }
+}
+
+// Stream 注册流式订阅（如 SSE），返回事件通道与需要先补发的事件。
+// lastID 在最近事件中时补发其后的事件，否则补发最近 replay 条。总线关闭时通道会被关闭，ok 为 false 表示已关闭。
+func (b *Bus) Stream(types []string, lastID string, replay int) (ch <-chan Event, backlog []Event, cancel func(), ok bool) {
+	s := &subscription{types: types, ch: make(chan Event, queueSize), stats: SinkStats{Name: "stream", Types: types}}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.streamsClosed {
+		return nil, nil, func() {}, false
+	}
+	b.subs[s] = struct{}{}
+
+	start := len(b.recent)
+	if lastID != "" {
+		for i, ev := range b.recent {
+			if ev.ID == lastID {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if start == len(b.recent) && replay > 0 {
+		start = max(len(b.recent)-replay, 0)
+	}
+	for _, ev := range b.recent[start:] {
+		if s.wants(ev.Type) {
+			backlog = append(backlog, ev)
+		}
+	}
+	return s.ch, backlog, func() { b.remove(s) }, true
+}
+
+func (b *Bus) remove(s *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Recent 最近的事件，按时间倒序
+func (b *Bus) Recent(types []string, limit int) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []Event{}
+	for i := len(b.recent) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		if len(types) == 0 || slices.Contains(types, b.recent[i].Type) {
+			out = append(out, b.recent[i])
+		}
+	}
+	return out
+}
+
+// Stats 各订阅方的投递统计（不含流式订阅）
+func (b *Bus) Stats() []SinkStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := []SinkStats{}
+	for s := range b.subs {
+		if s.sink != nil {
+			out = append(out, s.stats)
+		}
+	}
+	slices.SortFunc(out, func(x, y SinkStats) int {
+		switch {
+		case x.Name < y.Name:
+			return -1
+		case x.Name > y.Name:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// StreamCount 当前流式订阅数
+func (b *Bus) StreamCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for s := range b.subs {
+		if s.sink == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// CloseStreams 断开所有流式订阅并拒绝新的流式订阅，其他订阅方不受影响
+func (b *Bus) CloseStreams() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.streamsClosed = true
+	for s := range b.subs {
+		if s.sink == nil {
+			delete(b.subs, s)
+			close(s.ch)
+		}
+	}
+}
+
+// Close 停止接收新事件，等待订阅方处理完已排队的事件，最多等待 timeout
+func (b *Bus) Close(timeout time.Duration) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+	b.cancel()
+}
@@ -0,0 +1,109 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBusSubscribeAndStream(t *testing.T) {
+	b := New()
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	b.Subscribe("collect", SinkFunc(func(_ context.Context, ev Event) error {
+		mu.Lock()
+		got = append(got, ev.Type)
+		mu.Unlock()
+		return nil
+	}), "a")
+
+	first := b.Publish("a", "", nil)
+	b.Publish("b", "", nil)
+
+	ch, backlog, cancel, ok := b.Stream(nil, first.ID, 0)
+	if !ok || len(backlog) != 1 || backlog[0].Type != "b" {
+		t.Fatalf("按 Last-Event-ID 补发不符合预期: ok=%v backlog=%v", ok, backlog)
+	}
+	b.Publish("a", "", nil)
+	if ev := <-ch; ev.Type != "a" {
+		t.Fatalf("流式订阅收到 %s", ev.Type)
+	}
+	cancel()
+
+	_, backlog, _, _ = b.Stream([]string{"a"}, "", 10)
+	if len(backlog) != 2 {
+		t.Fatalf("按类型回放应为 2 条，实际 %d", len(backlog))
+	}
+
+	b.CloseStreams()
+	if _, _, _, ok := b.Stream(nil, "", 0); ok {
+		t.Fatalf("CloseStreams 后不应再接受流式订阅")
+	}
+
+	b.Close(time.Second)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("订阅方应收到 2 条 a 事件，实际 %v", got)
+	}
+	if st := b.Stats(); len(st) != 0 {
+		t.Fatalf("关闭后不应再有订阅方: %v", st)
+	}
+}
+
+func TestWebhookSinkSignsAndRetries(t *testing.T) {
+	var (
+		calls int
+		body  []byte
+		sig   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		sig = r.Header.Get("X-XHS-Signature")
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, "secret")
+	sink.backoff = time.Millisecond
+	if err := sink.Deliver(context.Background(), Event{ID: "1", Type: "note_published"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("应重试一次，实际调用 %d 次", calls)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); sig != want {
+		t.Fatalf("签名不符合预期: %s", sig)
+	}
+}
+
+func TestChatPayload(t *testing.T) {
+	cases := map[string]string{
+		"https://oapi.dingtalk.com/robot/send?access_token=x":  `{"msgtype":"text","text":{"content":"hi"}}`,
+		"https://open.feishu.cn/open-apis/bot/v2/hook/x":       `{"content":{"text":"hi"},"msg_type":"text"}`,
+		"https://qyapi.weixin.qq.com/cgi-bin/webhook/send?k=x": `{"msgtype":"text","text":{"content":"hi"}}`,
+		"https://hooks.slack.com/services/x":                   `{"text":"hi"}`,
+	}
+	for url, want := range cases {
+		data, _ := json.Marshal(chatPayload(url, "hi"))
+		if string(data) != want {
+			t.Fatalf("%s: got %s want %s", url, data, want)
+		}
+	}
+}
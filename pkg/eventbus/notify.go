@@ -0,0 +1,131 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// TelegramSink 通过 Telegram 机器人推送文本消息
+type TelegramSink struct {
+	Token  string
+	ChatID string
+
+	apiBase string
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewTelegramSink 创建 Telegram 订阅方
+func NewTelegramSink(token, chatID string) *TelegramSink {
+	return &TelegramSink{Token: token, ChatID: chatID, apiBase: "https://api.telegram.org",
+		client: &http.Client{Timeout: webhookTimeout}, backoff: 2 * time.Second}
+}
+
+// Deliver 实现 Sink
+func (s *TelegramSink) Deliver(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(map[string]any{"chat_id": s.ChatID, "text": eventText(ev), "disable_web_page_preview": true})
+	if err != nil {
+		return err
+	}
+	err = postWithRetry(ctx, s.client, s.apiBase+"/bot"+s.Token+"/sendMessage", body, nil, s.backoff)
+	if err != nil && s.Token != "" {
+		// 请求地址中包含机器人 token，不能出现在投递统计和日志里
+		return errors.New(strings.ReplaceAll(err.Error(), s.Token, "***"))
+	}
+	return err
+}
+
+// EmailSink 通过 SMTP 发送邮件。端口 465 使用隐式 TLS，其他端口在服务器支持时升级 STARTTLS。
+type EmailSink struct {
+	Addr     string // host:port
+	Username string // 为空时不认证
+	Password string
+	From     string
+	To       []string
+}
+
+// Deliver 实现 Sink
+func (s *EmailSink) Deliver(ctx context.Context, ev Event) error {
+	host, port, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*webhookTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if port == "465" {
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("收件人 %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(s.From, s.To, ev)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// emailMessage 组装 UTF-8 纯文本邮件，主题为事件文本，正文附带事件时间与 ID
+func emailMessage(from string, to []string, ev Event) []byte {
+	text := eventText(ev)
+	body := text + "\r\n\r\n时间: " + ev.At.Format("2006-01-02 15:04:05 MST") + "\r\n事件 ID: " + ev.ID + "\r\n"
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", text))
+	fmt.Fprintf(&b, "Date: %s\r\n", ev.At.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	enc := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(enc) > 76 {
+		b.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	b.WriteString(enc + "\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,106 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramSinkHidesToken(t *testing.T) {
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret-token/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	sink := NewTelegramSink("secret-token", "42")
+	sink.apiBase, sink.backoff = srv.URL, time.Millisecond
+	if err := sink.Deliver(context.Background(), Event{Type: "process_crashed", Summary: "用户 a 实例意外退出"}); err != nil {
+		t.Fatal(err)
+	}
+	if payload["chat_id"] != "42" || payload["text"] != "[process_crashed] 用户 a 实例意外退出" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+
+	sink.apiBase = "http://127.0.0.1:1"
+	err := sink.Deliver(context.Background(), Event{Type: "x"})
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Fatalf("错误信息不应包含 token: %v", err)
+	}
+}
+
+func TestDingTalkSign(t *testing.T) {
+	signed := dingTalkSign("https://oapi.dingtalk.com/robot/send?access_token=x", "SEC", time.UnixMilli(1700000000000))
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("access_token") != "x" || q.Get("timestamp") != "1700000000000" || q.Get("sign") == "" {
+		t.Fatalf("unexpected signed url: %s", signed)
+	}
+}
+
+func TestEmailSinkDelivers(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		_ = tp.PrintfLine("220 test")
+		var rcpt []string
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO", "MAIL":
+				_ = tp.PrintfLine("250 ok")
+			case "RCPT":
+				rcpt = append(rcpt, line)
+				_ = tp.PrintfLine("250 ok")
+			case "DATA":
+				_ = tp.PrintfLine("354 go")
+				data, _ := tp.ReadDotBytes()
+				got <- strings.Join(rcpt, "\n") + "\n" + string(data)
+				_ = tp.PrintfLine("250 queued")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				return
+			default:
+				_ = tp.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+
+	sink := &EmailSink{Addr: ln.Addr().String(), From: "mcp@example.com", To: []string{"ops@example.com"}}
+	if err := sink.Deliver(context.Background(), Event{ID: "1-1", Type: "alert_firing", Summary: "告警 down", At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-got
+	for _, want := range []string{"RCPT TO:<ops@example.com>", "From: mcp@example.com", "Subject: =?utf-8?q?", "Content-Transfer-Encoding: base64"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("邮件缺少 %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package eventbus
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	webhookTries   = 3
+	webhookTimeout = 10 * time.Second
+)
+
+// WebhookSink 以 JSON POST 推送事件，失败时退避重试。
+// 配置了 secret 时附带 X-XHS-Signature: sha256=<HMAC-SHA256(body)>。
+type WebhookSink struct {
+	URL    string
+	Secret string
+
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewWebhookSink 创建 webhook 订阅方
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{URL: url, Secret: secret, client: &http.Client{Timeout: webhookTimeout}, backoff: 2 * time.Second}
+}
+
+// Deliver 实现 Sink
+func (w *WebhookSink) Deliver(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"X-XHS-Event": ev.Type, "X-XHS-Event-ID": ev.ID}
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		headers["X-XHS-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return postWithRetry(ctx, w.client, w.URL, body, headers, w.backoff)
+}
+
+// 群机器人类型
+const (
+	ChatDingTalk = "dingtalk"
+	ChatFeishu   = "feishu"
+	ChatWeCom    = "wecom"
+	ChatSlack    = "slack"
+)
+
+// ChatSink 推送文本消息到群机器人，未指定 Kind 时按地址识别钉钉、飞书、企业微信和 Slack，其他地址按 {"text": ...} 发送
+type ChatSink struct {
+	URL    string
+	Kind   string // 群机器人类型，为空时按地址识别
+	Secret string // 钉钉机器人的加签密钥
+
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewChatSink 创建群机器人订阅方
+func NewChatSink(url string) *ChatSink {
+	return &ChatSink{URL: url, client: &http.Client{Timeout: webhookTimeout}, backoff: 2 * time.Second}
+}
+
+// Deliver 实现 Sink
+func (s *ChatSink) Deliver(ctx context.Context, ev Event) error {
+	kind := cmp.Or(s.Kind, chatKind(s.URL))
+	body, err := json.Marshal(chatKindPayload(kind, eventText(ev)))
+	if err != nil {
+		return err
+	}
+	target := s.URL
+	if kind == ChatDingTalk && s.Secret != "" {
+		target = dingTalkSign(target, s.Secret, time.Now())
+	}
+	return postWithRetry(ctx, s.client, target, body, nil, s.backoff)
+}
+
+// eventText 推送给人看的一行文本
+func eventText(ev Event) string {
+	if ev.Summary == "" {
+		return "[" + ev.Type + "] " + ev.At.Format("2006-01-02 15:04:05")
+	}
+	return "[" + ev.Type + "] " + ev.Summary
+}
+
+func chatKind(url string) string {
+	switch {
+	case strings.Contains(url, "oapi.dingtalk.com"):
+		return ChatDingTalk
+	case strings.Contains(url, "qyapi.weixin.qq.com"):
+		return ChatWeCom
+	case strings.Contains(url, "open.feishu.cn"), strings.Contains(url, "open.larksuite.com"):
+		return ChatFeishu
+	default:
+		return ChatSlack
+	}
+}
+
+func chatPayload(url, text string) any {
+	return chatKindPayload(chatKind(url), text)
+}
+
+func chatKindPayload(kind, text string) any {
+	switch kind {
+	case ChatDingTalk, ChatWeCom:
+		return map[string]any{"msgtype": "text", "text": map[string]string{"content": text}}
+	case ChatFeishu:
+		return map[string]any{"msg_type": "text", "content": map[string]string{"text": text}}
+	default: // Slack 及兼容格式
+		return map[string]string{"text": text}
+	}
+}
+
+// dingTalkSign 钉钉加签：timestamp 为毫秒时间戳，sign 为 Base64(HMAC-SHA256(secret, timestamp+"\n"+secret))
+func dingTalkSign(rawURL, secret string, now time.Time) string {
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + "timestamp=" + ts + "&sign=" + url.QueryEscape(sign)
+}
+
+func postWithRetry(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string, backoff time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= webhookTries; attempt++ {
+		if err = post(ctx, client, url, body, headers); err == nil {
+			return nil
+		}
+		if attempt == webhookTries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package fileutil 提供崩溃安全的文件写入工具。
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic 以“临时文件 + fsync + rename”的方式写入文件：
+// 进程在任意时刻崩溃，目标文件要么是旧内容，要么是完整的新内容。
+// 写入期间持有 path+".lock" 上的排他咨询锁，避免多个进程同时写同一文件。
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	unlock, err := Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return ReplaceFile(path, data, perm)
+}
+
+// ReplaceFile 与 WriteFileAtomic 相同但不加锁，供已持有 path+".lock" 的调用方使用
+func ReplaceFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpName := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("同步临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("替换文件失败: %w", err)
+	}
+	committed = true
+
+	// rename 本身也需要落盘，否则掉电后目录项可能仍指向旧文件
+	return syncDir(dir)
+}
@@ -0,0 +1,44 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "cookies.json")
+
+	if err := WriteFileAtomic(path, []byte(`[1]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发写入时文件内容始终是某一次完整写入的结果
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := WriteFileAtomic(path, []byte(`[2,2,2]`), 0600); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[2,2,2]` {
+		t.Fatalf("内容不符合预期: %s", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == ".tmp" {
+			t.Fatalf("残留临时文件: %s", e.Name())
+		}
+	}
+}
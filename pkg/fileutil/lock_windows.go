@@ -0,0 +1,33 @@
+//go:build windows
+
+package fileutil
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Lock 获取 path 上的排他锁（LockFileEx），返回释放函数
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+	h := windows.Handle(f.Fd())
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(h, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("加锁失败: %w", err)
+	}
+	return func() {
+		_ = windows.UnlockFileEx(h, 0, 1, 0, ol)
+		_ = f.Close()
+	}, nil
+}
+
+// Windows 不支持对目录句柄 fsync，rename 由 MoveFileEx 保证原子性
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package i18n
+
+import "regexp"
+
+// catalog 中文原文到英文的对照。键为代码中的中文原文，调用方无需改动；
+// 新增面向用户的提示时在这里补充对应英文，未收录的文本原样输出。
+var catalog = map[string]string{
+	// 通用
+	"请求参数错误":                           "Invalid request parameters",
+	"参数错误":                             "Invalid parameters",
+	"无效 JSON":                          "Invalid JSON",
+	"服务器内部错误":                          "Internal server error",
+	"访问令牌无效":                           "Invalid access token",
+	"无效请求":                             "Invalid request",
+	"未登录或令牌无效":                         "Not logged in or invalid token",
+	"用户名或密码错误":                         "Incorrect username or password",
+	"登录失败次数过多，请稍后再试":                   "Too many failed login attempts, please try again later",
+	"服务正在关闭，暂不接受新请求":                   "Server is shutting down and not accepting new requests",
+	"服务未就绪":                            "Service not ready",
+	"服务就绪":                             "Service ready",
+	"操作成功":                             "Operation succeeded",
+	"操作失败":                             "Operation failed",
+	"执行操作失败":                           "Failed to perform action",
+	"limit 需为正整数":                      "limit must be a positive integer",
+	"replay 需为非负整数":                    "replay must be a non-negative integer",
+	"since 需为 RFC3339 时间":              "since must be an RFC3339 timestamp",
+	"读取请求体失败":                          "Failed to read request body",
+	"JSON 序列化失败":                       "Failed to serialize JSON",
+	"id 不能为空":                          "id is required",
+	"sid 不能为空":                         "sid is required",
+	"jid 不能为空":                         "jid is required",
+	"工具名称不能为空":                         "Tool name is required",
+	"图片数据解码失败":                         "Failed to decode image data",
+	"工具未执行":                            "Tool was not executed",
+	"需要 passphrase":                    "passphrase is required",
+	"文件过大（最大 5MB）":                     "File too large (max 5MB)",
+	"format 取值为 json、csv 或 prometheus": "format must be json, csv or prometheus",
+
+	// 登录与 cookies
+	"页面触发安全验证，需要人工处理":  "Security verification triggered; manual action required",
+	"当前账号为只读模式，已拒绝写操作": "This account is read-only; write operations are rejected",
+	"检查登录状态失败":         "Failed to check login status",
+	"检查登录状态成功":         "Login status checked",
+	"检查登录状态完成":         "Login status check finished",
+	"获取登录二维码失败":        "Failed to get login QR code",
+	"获取登录二维码成功":        "Login QR code retrieved",
+	"获取登录扫码图片失败":       "Failed to get login QR code image",
+	"你当前已处于登录状态":       "You are already logged in",
+	"删除 cookies 失败":    "Failed to delete cookies",
+	"读取 cookies 失败":    "Failed to read cookies",
+	"修改 cookie 失败":     "Failed to update cookie",
+	"修改 cookie 成功":     "Cookie updated",
+	"删除 cookie 失败":     "Failed to delete cookie",
+	"删除 cookie 成功":     "Cookie deleted",
+	"cookie 不存在":       "Cookie not found",
+	"读取浏览器 cookies 失败": "Failed to read browser cookies",
+	"导入 cookies 失败":    "Failed to import cookies",
+	"导入浏览器 cookies 成功": "Browser cookies imported",
+	"Cookies 已成功删除，登录状态已重置。下次操作时需要重新登录。": "Cookies deleted and login state reset. You will need to log in again.",
+	"请求体不能为空（需要 JSON 数组）":                "Request body is required (JSON array)",
+	"无效 JSON：需要 cookies 数组":              "Invalid JSON: a cookies array is required",
+
+	// 浏览器与调试
+	"浏览器未启动，请先执行任意操作或获取登录二维码": "Browser is not running; perform any action or request a login QR code first",
+	"浏览器没有打开的页面":              "Browser has no open page",
+	"调试地址无效":                  "Invalid debugger address",
+	"截图失败":                    "Screenshot failed",
+	"调试会话不存在":                 "Debug session not found",
+	"action 仅支持 pause/resume": "action must be pause or resume",
+	"重新加载选择器配置失败":             "Failed to reload selector config",
+	"获取选择器配置成功":               "Selector config retrieved",
+	"选择器配置已重新加载":              "Selector config reloaded",
+	"未开启 DevTools 代理，请使用 -enable-devtools 启动 manager 并重启该账号": "DevTools proxy is disabled; start the manager with -enable-devtools and restart this account",
+
+	// 内容读取
+	"获取Feeds列表失败":   "Failed to list feeds",
+	"获取Feeds列表成功":   "Feeds listed",
+	"缺少关键词参数":       "Missing keyword parameter",
+	"搜索Feeds失败":     "Failed to search feeds",
+	"搜索Feeds成功":     "Feeds searched",
+	"搜索失败":          "Search failed",
+	"获取Feed详情失败":    "Failed to get feed detail",
+	"获取Feed详情成功":    "Feed detail retrieved",
+	"获取用户主页失败":      "Failed to get user profile",
+	"获取我的主页失败":      "Failed to get my profile",
+	"获取我的主页成功":      "My profile retrieved",
+	"获取评论和@通知失败":    "Failed to get comment and mention notifications",
+	"获取评论和@通知成功":    "Comment and mention notifications retrieved",
+	"获取评论和@监控状态成功":  "Mention watcher status retrieved",
+	"生成订阅失败":        "Failed to build feed",
+	"尚未获取账号资料，请先刷新": "Account profile not fetched yet; refresh it first",
+
+	// 写操作
+	"发布成功":                    "Published",
+	"发布失败":                    "Publish failed",
+	"视频发布成功":                  "Video published",
+	"内容发布成功":                  "Content published",
+	"发表评论失败":                  "Failed to post comment",
+	"回复评论失败":                  "Failed to reply to comment",
+	"评论发表成功":                  "Comment posted",
+	"评论回复成功":                  "Reply posted",
+	"点赞操作失败":                  "Like action failed",
+	"收藏操作失败":                  "Favorite action failed",
+	"缺少 comment_id 或 user_id": "Missing comment_id or user_id",
+	"审核完成":                    "Moderation check finished",
+
+	// 定时任务、自动回复、触发器
+	"获取定时任务成功":                      "Scheduled jobs retrieved",
+	"创建定时任务成功":                      "Scheduled job created",
+	"更新定时任务成功":                      "Scheduled job updated",
+	"删除定时任务成功":                      "Scheduled job deleted",
+	"自动回复规则无效":                      "Invalid auto-reply rules",
+	"获取自动回复配置成功":                    "Auto-reply config retrieved",
+	"自动回复规则已保存":                     "Auto-reply rules saved",
+	"触发器不存在":                        "Trigger not found",
+	"触发器的数据来源未启用":                   "The trigger's data source is not enabled",
+	"target_url 需为 http(s) 地址":      "target_url must be an http(s) URL",
+	"保存订阅失败":                        "Failed to save subscription",
+	"订阅不存在":                         "Subscription not found",
+	"获取触发器成功":                       "Triggers retrieved",
+	"订阅成功":                          "Subscribed",
+	"已取消订阅":                         "Unsubscribed",
+	"收到新的评论或@，需要启用 -watch-mentions": "New comment or mention received; requires -watch-mentions",
+	"新增粉丝，需要启用 -watch-followers":    "New follower; requires -watch-followers",
+	"定时任务执行结束，status 为 ok 或 error":  "Scheduled job finished; status is ok or error",
+
+	// manager
+	"用户不存在":                        "User not found",
+	"用户进程未运行":                      "User process is not running",
+	"用户进程已在运行":                     "User process is already running",
+	"用户实例未运行，无法检查登录状态":             "User instance is not running; cannot check login status",
+	"用户实例健康检查失败，请稍后重试":             "User instance health check failed; try again later",
+	"用户进程运行中，请先停止再修改":              "User process is running; stop it before editing",
+	"用户进程运行中，请先停止再删除":              "User process is running; stop it before deleting",
+	"用户进程运行中，请先停止再重置 UA":           "User process is running; stop it before resetting the User-Agent",
+	"用户进程运行中，请先停止再清理":              "User process is running; stop it before cleaning up",
+	"目标与当前数据目录相同":                  "Target is the same as the current data directory",
+	"data_dir 不能为空":                "data_dir is required",
+	"至少选择一项清理内容（cache、media、logs）": "Select at least one item to clean up (cache, media, logs)",
+	"disk_quota_mb 不能为负数":          "disk_quota_mb must not be negative",
+	"max_age_hours 不能为负数":          "max_age_hours must not be negative",
+	"max_files 取值范围 0-100":         "max_files must be between 0 and 100",
+	"请先停止用户进程后重试":                  "Stop the user process and try again",
+	"User-Agent 已重置":               "User-Agent reset",
+	"日志文件不存在":                      "Log file not found",
+	"日志文件不存在，无需清空":                 "Log file not found; nothing to clear",
+	"日志已清空":                        "Logs cleared",
+	"需要 X-Bundle-Passphrase 请求头":   "X-Bundle-Passphrase header is required",
+	"当前已有账号，确认覆盖请使用 ?replace=true": "Accounts already exist; use ?replace=true to overwrite",
+	"导出包过大":                        "Bundle too large",
+	"未配置导出目标，启动时通过 -analytics-pushgateway、-analytics-influx 或 -analytics-csv-dir 指定": "No export target configured; start with -analytics-pushgateway, -analytics-influx or -analytics-csv-dir",
+
+	// MCP 工具说明
+	"检查小红书登录状态":                                             "Check Xiaohongshu login status",
+	"获取登录二维码（返回 Base64 图片和超时时间）":                            "Get the login QR code (returns a Base64 image and timeout)",
+	"删除 cookies 文件，重置登录状态。删除后需要重新登录。":                       "Delete the cookies file and reset login state. You must log in again afterwards.",
+	"发布小红书图文内容":                                             "Publish an image note to Xiaohongshu",
+	"获取首页 Feeds 列表，结果分页返回；has_more 为 true 时用 cursor 获取下一页":  "List home page feeds, paginated; when has_more is true, pass cursor to get the next page",
+	"搜索小红书内容（需要已登录），结果分页返回；has_more 为 true 时用 cursor 获取下一页": "Search Xiaohongshu notes (login required), paginated; when has_more is true, pass cursor to get the next page",
+	"获取小红书笔记详情，返回笔记内容、图片、作者信息、互动数据（点赞/收藏/分享数）及评论列表。默认返回前10条一级评论，如需更多评论请设置load_all_comments=true": "Get note details: content, images, author, engagement (likes/favorites/shares) and comments. Returns the first 10 top-level comments by default; set load_all_comments=true for more",
+	"获取指定的小红书用户主页，返回用户基本信息，关注、粉丝、获赞量及其笔记内容":                                                      "Get a user's profile: basic info, following/follower/like counts and their notes",
+	"获取当前登录账号在通知页“评论和@”中的最新通知列表":                                                                 "Get the latest \"comments and @mentions\" notifications of the logged-in account",
+	"发表评论到小红书笔记":                          "Post a comment on a note",
+	"回复小红书笔记下的指定评论":                       "Reply to a specific comment on a note",
+	"发布小红书视频内容（仅支持本地单个视频文件）":              "Publish a video note (a single local video file only)",
+	"为指定笔记点赞或取消点赞（如已点赞将跳过点赞，如未点赞将跳过取消点赞）": "Like or unlike a note (skipped if already in the requested state)",
+	"收藏指定笔记或取消收藏（如已收藏将跳过收藏，如未收藏将跳过取消收藏）":  "Favorite or unfavorite a note (skipped if already in the requested state)",
+
+	// MCP 工具参数
+	"内容标题（小红书限制：最多20个中文字或英文单词）":                "Title (Xiaohongshu limit: at most 20 Chinese characters or English words)",
+	"正文内容，不包含以#开头的标签内容，所有话题标签都用tags参数来生成和提供即可": "Body text without #hashtags; provide all topic tags via the tags parameter",
+	"图片路径列表（至少需要1张图片）。支持三种方式：1. HTTP/HTTPS图片链接（自动下载）；2. 对象存储地址 s3://、oss://、cos://bucket/key（使用实例配置的凭证下载）；3. 本地图片绝对路径（推荐，如:/Users/user/image.jpg）": "Image list (at least one). Accepts 1. HTTP/HTTPS URLs (downloaded automatically); 2. object storage URLs s3://, oss://, cos://bucket/key (downloaded with the instance credentials); 3. absolute local paths (recommended, e.g. /Users/user/image.jpg)",
+	"话题标签列表（可选参数），如 [美食, 旅行, 生活]": "Topic tags (optional), e.g. [food, travel, life]",
+	"商品关键词列表（可选），用于绑定带货商品。填写商品名称或商品ID，系统会自动搜索并选择第一个匹配结果。需账号已开通商品功能。示例: [面膜, 防晒霜SPF50]":     "Product keywords (optional) for attaching products. Give product names or IDs; the first search match is selected. Requires the account's shop feature. Example: [face mask, sunscreen SPF50]",
+	"定时发布时间（可选），ISO8601格式如 2024-01-20T10:30:00+08:00，不带时区偏移时按账号时区的当地时间，支持1小时至14天内。不填则立即发布": "Scheduled publish time (optional), ISO8601 such as 2024-01-20T10:30:00+08:00; without an offset it is read as local time in the account's timezone. Between 1 hour and 14 days ahead. Publishes immediately if empty",
+	"是否声明原创（可选），true为声明原创，false或不填则不声明":                                                    "Declare as original (optional); true to declare, false or empty not to",
+	"可见范围（可选），支持: 公开可见(默认)、仅自己可见、仅互关好友可见。不填则默认公开可见":                                        "Visibility (optional): 公开可见 (public, default), 仅自己可见 (only me), 仅互关好友可见 (mutual followers). Defaults to public",
+	"本地视频绝对路径（仅支持单个视频文件，如:/Users/user/video.mp4），也支持对象存储地址 s3://、oss://、cos://bucket/key":  "Absolute local video path (a single file, e.g. /Users/user/video.mp4); object storage URLs s3://, oss://, cos://bucket/key are also accepted",
+	"翻页游标，取上一次结果的cursor字段；首次查询不填":                                                          "Pagination cursor from the previous result's cursor field; omit on the first call",
+	"每页条数，默认20，最大50": "Page size, default 20, max 50",
+	"搜索关键词":          "Search keyword",
+	"筛选选项":           "Filter options",
+	"排序依据: 综合|最新|最多点赞|最多评论|最多收藏,默认为'综合'":                             "Sort by: 综合 (relevance) | 最新 (latest) | 最多点赞 (most liked) | 最多评论 (most commented) | 最多收藏 (most favorited); default 综合",
+	"笔记类型: 不限|视频|图文,默认为'不限'":                                         "Note type: 不限 (any) | 视频 (video) | 图文 (image); default 不限",
+	"发布时间: 不限|一天内|一周内|半年内,默认为'不限'":                                   "Publish time: 不限 (any) | 一天内 (1 day) | 一周内 (1 week) | 半年内 (6 months); default 不限",
+	"搜索范围: 不限|已看过|未看过|已关注,默认为'不限'":                                   "Scope: 不限 (any) | 已看过 (viewed) | 未看过 (not viewed) | 已关注 (following); default 不限",
+	"位置距离: 不限|同城|附近,默认为'不限'":                                         "Location: 不限 (any) | 同城 (same city) | 附近 (nearby); default 不限",
+	"小红书笔记ID，从Feed列表获取":                                              "Note ID, from the feed list",
+	"访问令牌，从Feed列表的xsecToken字段获取":                                     "Access token, from the xsecToken field of the feed list",
+	"是否加载全部评论。false仅返回前10条一级评论（默认），true滚动加载更多评论":                     "Load all comments. false returns only the first 10 top-level comments (default); true scrolls to load more",
+	"【仅当load_all_comments为true时生效】限制加载的一级评论数量。例如20表示最多加载20条，默认20":    "[Only when load_all_comments is true] Max number of top-level comments to load, default 20",
+	"【仅当load_all_comments为true时生效】是否展开二级回复。true展开子评论，false不展开（默认）":   "[Only when load_all_comments is true] Expand nested replies; default false",
+	"【仅当click_more_replies为true时生效】跳过回复数过多的评论。例如10表示跳过超过10条回复的，默认10": "[Only when click_more_replies is true] Skip comments with more replies than this, default 10",
+	"【仅当load_all_comments为true时生效】滚动速度slow慢速、normal正常、fast快速":        "[Only when load_all_comments is true] Scroll speed: slow, normal or fast",
+	"小红书用户ID，从Feed列表获取":                                              "User ID, from the feed list",
+	"笔记列表翻页游标，取上一次结果的cursor字段；首次查询不填":                                "Cursor for the notes list from the previous result; omit on the first call",
+	"每页笔记条数，默认20，最大50":                                               "Notes per page, default 20, max 50",
+	"评论内容":             "Comment text",
+	"目标评论ID，从评论列表获取":   "Target comment ID, from the comment list",
+	"目标评论用户ID，从评论列表获取": "Target comment author's user ID, from the comment list",
+	"回复内容":             "Reply text",
+	"是否取消点赞，true为取消点赞，false或未设置则为点赞": "Unlike instead of like; true to unlike, false or unset to like",
+	"是否取消收藏，true为取消收藏，false或未设置则为收藏": "Unfavorite instead of favorite; true to unfavorite, false or unset to favorite",
+}
+
+// patterns 带参数的常见提示
+var patterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`^缺少\s*(\w+)\s*参数$`), "missing parameter $1"},
+	{regexp.MustCompile(`^工具 (\S+) 执行时发生内部错误: (.*)`), "Internal error while running tool $1: $2"},
+	{regexp.MustCompile(`^(.+?)，但序列化失败: (.*)$`), "$1, but serialization failed: $2"},
+	{regexp.MustCompile(`^(.+?)成功 - Feed ID: (\S+)$`), "$1 succeeded - Feed ID: $2"},
+	{regexp.MustCompile(`^实例版本 (\S+) 不支持内容审核（moderation），请升级实例或清空该配置$`), "Instance version $1 does not support moderation; upgrade the instance or clear the setting"},
+	{regexp.MustCompile(`^实例版本 (\S+) 不支持只读模式，为避免写操作未被拦截，拒绝启动$`), "Instance version $1 does not support read-only mode; refusing to start so writes are not left unblocked"},
+	{regexp.MustCompile(`^实例版本 (\S+) 不支持 Unix socket 监听，请升级实例或关闭 socket$`), "Instance version $1 does not support Unix socket listening; upgrade the instance or disable socket"},
+}
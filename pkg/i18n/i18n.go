@@ -0,0 +1,105 @@
+// Package i18n 接口提示与 MCP 工具说明的中英文切换。
+//
+// 代码中的提示保持中文原文，输出时按语言查对照表翻译：
+// 完整匹配优先；其次匹配带参数的常见格式；再按「前缀: 详情」拆分后分别翻译。未收录的文本原样返回。
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang 输出语言
+type Lang string
+
+const (
+	ZH Lang = "zh"
+	EN Lang = "en"
+)
+
+// Parse 解析语言代码，如 en、en-US、zh-CN
+func Parse(s string) (Lang, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "en" || strings.HasPrefix(s, "en-") || strings.HasPrefix(s, "en_"):
+		return EN, true
+	case s == "zh" || strings.HasPrefix(s, "zh-") || strings.HasPrefix(s, "zh_"):
+		return ZH, true
+	}
+	return "", false
+}
+
+// Negotiate 按 Accept-Language 的权重选择支持的语言，没有匹配时返回 def
+func Negotiate(acceptLanguage string, def Lang) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var cands []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			cands = append(cands, candidate{lang, q})
+		}
+	}
+	if len(cands) == 0 {
+		return def
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].q > cands[j].q })
+	return cands[0].lang
+}
+
+// T 把中文提示翻译为指定语言
+func T(lang Lang, s string) string {
+	if lang != EN || s == "" {
+		return s
+	}
+	return translate(s)
+}
+
+func translate(s string) string {
+	if v, ok := catalog[s]; ok {
+		return v
+	}
+	trimmed := strings.TrimSpace(s)
+	if trimmed != s {
+		if v, ok := catalog[trimmed]; ok {
+			return strings.Replace(s, trimmed, v, 1)
+		}
+	}
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+		// 参数部分可能仍是可翻译的中文
+		out := p.repl
+		for i := len(m) - 1; i >= 1; i-- {
+			out = strings.ReplaceAll(out, "$"+strconv.Itoa(i), translate(m[i]))
+		}
+		return out
+	}
+	for _, sep := range []string{": ", "：", "，"} {
+		if head, tail, ok := strings.Cut(s, sep); ok {
+			if v, found := catalog[strings.TrimSpace(head)]; found {
+				out := sep
+				if sep == "：" || sep == "，" {
+					out = ": "
+				}
+				return v + out + translate(tail)
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		def    Lang
+		want   Lang
+	}{
+		{"", ZH, ZH},
+		{"", EN, EN},
+		{"en-US,en;q=0.9", ZH, EN},
+		{"zh-CN,zh;q=0.9,en;q=0.8", EN, ZH},
+		{"fr-FR, en;q=0.5, zh;q=0.7", EN, ZH},
+		{"fr-FR", EN, EN},
+		{"en;q=0", ZH, ZH},
+	}
+	for _, c := range cases {
+		if got := Negotiate(c.header, c.def); got != c.want {
+			t.Errorf("Negotiate(%q, %s) = %s, want %s", c.header, c.def, got, c.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	cases := map[string]string{
+		"参数错误":                       "Invalid parameters",
+		"缺少 feed_id 参数":              "missing parameter feed_id",
+		"服务器内部错误: boom":              "Internal server error: boom",
+		"读取 cookies 失败：no such file": "Failed to read cookies: no such file",
+		"未收录的提示":                     "未收录的提示",
+	}
+	for in, want := range cases {
+		if got := T(EN, in); got != want {
+			t.Errorf("T(en, %q) = %q, want %q", in, got, want)
+		}
+		if got := T(ZH, in); got != in {
+			t.Errorf("T(zh, %q) = %q, want unchanged", in, got)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(ZH))
+	r.GET("/json", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误", "details": "参数错误"})
+	})
+	r.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, "参数错误")
+	})
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept-Language", accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var body map[string]string
+	w := get("/json", "en-US,en;q=0.9")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "Invalid parameters" || body["details"] != "参数错误" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+
+	if w := get("/json?lang=zh", "en"); !json.Valid(w.Body.Bytes()) || w.Body.String() != `{"details":"参数错误","error":"参数错误"}` {
+		t.Fatalf("?lang=zh should keep Chinese, got %s", w.Body.String())
+	}
+	if w := get("/text", "en"); w.Body.String() != "参数错误" {
+		t.Fatalf("non-JSON response should pass through, got %s", w.Body.String())
+	}
+}
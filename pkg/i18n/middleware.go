@@ -0,0 +1,108 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextKey = "i18n.lang"
+
+// translatedFields JSON 响应中需要翻译的顶层字段
+var translatedFields = []string{"error", "message"}
+
+// FromContext 当前请求的语言，未经过中间件时返回 ZH
+func FromContext(c *gin.Context) Lang {
+	if v, ok := c.Get(contextKey); ok {
+		if l, ok := v.(Lang); ok {
+			return l
+		}
+	}
+	return ZH
+}
+
+// Middleware 按 ?lang=、Accept-Language、默认语言的顺序确定语言，
+// 语言为英文时翻译 JSON 响应顶层的 error、message 字段；SSE、文件下载等非 JSON 响应不受影响。
+func Middleware(def Lang) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang, ok := Parse(c.Query("lang"))
+		if !ok {
+			lang = Negotiate(c.GetHeader("Accept-Language"), def)
+		}
+		c.Set(contextKey, lang)
+		if lang == ZH {
+			c.Next()
+			return
+		}
+
+		w := &translatingWriter{ResponseWriter: c.Writer, lang: lang}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// translatingWriter 缓存 JSON 响应，处理完成后翻译再写出
+type translatingWriter struct {
+	gin.ResponseWriter
+	lang      Lang
+	buf       bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+func (w *translatingWriter) decide() {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+}
+
+func (w *translatingWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *translatingWriter) WriteString(s string) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *translatingWriter) finish() {
+	if !w.buffering {
+		return
+	}
+	out := w.buf.Bytes()
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(out, &obj); err == nil {
+		changed := false
+		for _, key := range translatedFields {
+			var s string
+			if raw, ok := obj[key]; !ok || json.Unmarshal(raw, &s) != nil {
+				continue
+			}
+			if t := T(w.lang, s); t != s {
+				obj[key], _ = json.Marshal(t)
+				changed = true
+			}
+		}
+		if changed {
+			if data, err := json.Marshal(obj); err == nil {
+				out = data
+			}
+		}
+	}
+	if w.Header().Get("Content-Length") != "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	}
+	_, _ = w.ResponseWriter.Write(out)
+}
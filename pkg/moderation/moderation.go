@@ -0,0 +1,214 @@
+// Package moderation 写操作前的内容审核。
+//
+// Gate 依次调用各 Moderator（本地敏感词表、外部 HTTP 审核接口等），
+// 命中后按策略处理：block 拒绝操作，warn 放行但告警，log 只记录，off 不审核。
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Policy 命中后的处理策略
+type Policy string
+
+const (
+	PolicyBlock Policy = "block"
+	PolicyWarn  Policy = "warn"
+	PolicyLog   Policy = "log"
+	PolicyOff   Policy = "off"
+)
+
+// ErrBlocked 内容未通过审核
+var ErrBlocked = errors.New("内容未通过审核")
+
+// ParsePolicy 解析策略，空字符串返回 def
+func ParsePolicy(s string, def Policy) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return def, nil
+	case PolicyBlock, PolicyWarn, PolicyLog, PolicyOff:
+		return p, nil
+	default:
+		return "", fmt.Errorf("审核策略取值非法: %q（可选 block、warn、log、off）", s)
+	}
+}
+
+// Verdict 审核结果
+type Verdict struct {
+	Flagged bool     `json:"flagged"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Moderator 审核实现
+type Moderator interface {
+	Name() string
+	Check(ctx context.Context, kind, text string) (Verdict, error)
+}
+
+// WordList 本地敏感词表，不区分大小写的子串匹配
+type WordList struct {
+	words []string
+}
+
+// NewWordList 创建敏感词表，忽略空词
+func NewWordList(words []string) *WordList {
+	l := &WordList{}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			l.words = append(l.words, w)
+		}
+	}
+	return l
+}
+
+// LoadWordList 从文件加载敏感词表，每行一个词，# 开头为注释
+func LoadWordList(path string) (*WordList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取敏感词表失败: %w", err)
+	}
+	defer f.Close()
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("读取敏感词表失败: %w", err)
+	}
+	return NewWordList(words), nil
+}
+
+// Len 词数
+func (l *WordList) Len() int { return len(l.words) }
+
+// Name 实现 Moderator
+func (l *WordList) Name() string { return "words" }
+
+// Check 实现 Moderator
+func (l *WordList) Check(_ context.Context, _ string, text string) (Verdict, error) {
+	lower := strings.ToLower(text)
+	var v Verdict
+	for _, w := range l.words {
+		if strings.Contains(lower, w) {
+			v.Flagged = true
+			v.Reasons = append(v.Reasons, "包含敏感词「"+w+"」")
+		}
+	}
+	return v, nil
+}
+
+// HTTPModerator 调用外部审核接口。
+// 请求：POST {"kind":"publish","text":"..."}；响应：{"flagged":true,"reasons":["..."]}
+type HTTPModerator struct {
+	URL    string
+	client *http.Client
+}
+
+// NewHTTPModerator 创建 HTTP 审核实现
+func NewHTTPModerator(url string, timeout time.Duration) *HTTPModerator {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &HTTPModerator{URL: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Name 实现 Moderator
+func (h *HTTPModerator) Name() string { return "http" }
+
+// Check 实现 Moderator
+func (h *HTTPModerator) Check(ctx context.Context, kind, text string) (Verdict, error) {
+	body, err := json.Marshal(map[string]string{"kind": kind, "text": text})
+	if err != nil {
+		return Verdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Verdict{}, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return Verdict{}, fmt.Errorf("HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var v Verdict
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Verdict{}, fmt.Errorf("解析审核结果失败: %w", err)
+	}
+	return v, nil
+}
+
+// Result 一次审核的汇总结果
+type Result struct {
+	Policy  Policy   `json:"policy"`
+	Flagged bool     `json:"flagged"`
+	Reasons []string `json:"reasons,omitempty"`
+	Errors  []string `json:"errors,omitempty"` // 审核实现调用失败
+}
+
+// Gate 审核入口
+type Gate struct {
+	Policy     Policy
+	Moderators []Moderator
+}
+
+// Enabled 是否需要审核
+func (g *Gate) Enabled() bool {
+	return g != nil && g.Policy != PolicyOff && g.Policy != "" && len(g.Moderators) > 0
+}
+
+// Check 审核文本。策略为 block 且命中时返回 ErrBlocked；
+// 审核接口调用失败在 block 策略下同样拒绝，避免审核服务故障时内容绕过审核。
+func (g *Gate) Check(ctx context.Context, kind, text string) (Result, error) {
+	if !g.Enabled() {
+		return Result{Policy: PolicyOff}, nil
+	}
+	res := Result{Policy: g.Policy}
+	for _, m := range g.Moderators {
+		v, err := m.Check(ctx, kind, text)
+		if err != nil {
+			res.Errors = append(res.Errors, m.Name()+": "+err.Error())
+			continue
+		}
+		if v.Flagged {
+			res.Flagged = true
+			res.Reasons = append(res.Reasons, v.Reasons...)
+		}
+	}
+	if g.Policy != PolicyBlock {
+		return res, nil
+	}
+	if res.Flagged {
+		reason := strings.Join(res.Reasons, "；")
+		if reason == "" {
+			reason = "命中审核规则"
+		}
+		return res, fmt.Errorf("%w: %s", ErrBlocked, reason)
+	}
+	if len(res.Errors) > 0 {
+		return res, fmt.Errorf("%w: 审核服务不可用（%s）", ErrBlocked, strings.Join(res.Errors, "；"))
+	}
+	return res, nil
+}
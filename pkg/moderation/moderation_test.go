@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGatePolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# 注释\n违禁词\n\nSPAM\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	words, err := LoadWordList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words.Len() != 2 {
+		t.Fatalf("应加载 2 个词，实际 %d", words.Len())
+	}
+
+	ctx := context.Background()
+	block := &Gate{Policy: PolicyBlock, Moderators: []Moderator{words}}
+	if _, err := block.Check(ctx, "publish", "正常内容"); err != nil {
+		t.Fatalf("正常内容不应被拒绝: %v", err)
+	}
+	res, err := block.Check(ctx, "publish", "this is spam")
+	if !errors.Is(err, ErrBlocked) || !res.Flagged {
+		t.Fatalf("block 策略命中时应拒绝: res=%+v err=%v", res, err)
+	}
+
+	warn := &Gate{Policy: PolicyWarn, Moderators: []Moderator{words}}
+	res, err = warn.Check(ctx, "comment", "违禁词")
+	if err != nil || !res.Flagged {
+		t.Fatalf("warn 策略应放行并标记: res=%+v err=%v", res, err)
+	}
+
+	off := &Gate{Policy: PolicyOff, Moderators: []Moderator{words}}
+	if res, _ := off.Check(ctx, "comment", "违禁词"); res.Flagged {
+		t.Fatal("off 策略不应审核")
+	}
+}
+
+func TestHTTPModeratorFailsClosedUnderBlock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"flagged":true,"reasons":["广告"]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	g := &Gate{Policy: PolicyBlock, Moderators: []Moderator{NewHTTPModerator(srv.URL, 0)}}
+	if res, err := g.Check(ctx, "publish", "x"); !errors.Is(err, ErrBlocked) || len(res.Reasons) != 1 {
+		t.Fatalf("接口判定违规时应拒绝: res=%+v err=%v", res, err)
+	}
+	g.Moderators = []Moderator{NewHTTPModerator(srv.URL+"/down", 0)}
+	if _, err := g.Check(ctx, "publish", "x"); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("block 策略下审核服务不可用应拒绝: %v", err)
+	}
+	g.Policy = PolicyLog
+	if res, err := g.Check(ctx, "publish", "x"); err != nil || len(res.Errors) != 1 {
+		t.Fatalf("log 策略下审核服务不可用应放行并记录: res=%+v err=%v", res, err)
+	}
+
+	if _, err := ParsePolicy("strict", PolicyOff); err == nil {
+		t.Fatal("非法策略应报错")
+	}
+}
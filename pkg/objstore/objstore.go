@@ -0,0 +1,280 @@
+// Package objstore 从 S3 兼容的对象存储（AWS S3、阿里云 OSS、腾讯云 COS、MinIO 等）下载对象。
+//
+// 支持 s3://bucket/key、oss://bucket/key、cos://bucket/key 三种地址，请求使用 AWS SigV4 签名。
+// 凭证按实例通过环境变量配置（不走命令行参数，避免出现在进程列表中），前缀为 XHS_<SCHEME>_：
+//
+//	XHS_S3_ACCESS_KEY / XHS_S3_SECRET_KEY / XHS_S3_SESSION_TOKEN
+//	XHS_S3_REGION     区域，如 us-east-1、cn-hangzhou、ap-guangzhou
+//	XHS_S3_ENDPOINT   自定义地址，如 https://minio.local:9000；为空时按 scheme 推导
+//	XHS_S3_PATH_STYLE true 时使用 endpoint/bucket/key 形式（MinIO 等）
+//
+// 未配置 access key 时按公开读对象匿名访问。
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 支持的地址前缀
+var schemes = []string{"s3", "oss", "cos"}
+
+// Config 单个 scheme 的访问配置
+type Config struct {
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	PathStyle    bool
+}
+
+// Object 解析后的对象地址
+type Object struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// IsObjectURL 是否为对象存储地址
+func IsObjectURL(s string) bool {
+	_, err := ParseURL(s)
+	return err == nil
+}
+
+// ParseURL 解析 scheme://bucket/key
+func ParseURL(raw string) (Object, error) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(raw), "://")
+	if !ok {
+		return Object{}, fmt.Errorf("不是对象存储地址: %s", raw)
+	}
+	scheme = strings.ToLower(scheme)
+	supported := false
+	for _, s := range schemes {
+		if s == scheme {
+			supported = true
+		}
+	}
+	if !supported {
+		return Object{}, fmt.Errorf("不支持的对象存储类型: %s", scheme)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return Object{}, fmt.Errorf("对象存储地址需为 %s://bucket/key: %s", scheme, raw)
+	}
+	return Object{Scheme: scheme, Bucket: bucket, Key: key}, nil
+}
+
+// ConfigFromEnv 读取 scheme 对应的环境变量配置
+func ConfigFromEnv(scheme string) Config {
+	prefix := "XHS_" + strings.ToUpper(scheme) + "_"
+	return Config{
+		Endpoint:     strings.TrimRight(os.Getenv(prefix+"ENDPOINT"), "/"),
+		Region:       os.Getenv(prefix + "REGION"),
+		AccessKey:    os.Getenv(prefix + "ACCESS_KEY"),
+		SecretKey:    os.Getenv(prefix + "SECRET_KEY"),
+		SessionToken: os.Getenv(prefix + "SESSION_TOKEN"),
+		PathStyle:    os.Getenv(prefix+"PATH_STYLE") == "true",
+	}
+}
+
+// objectURL 计算对象的 HTTP 地址
+func (c Config) objectURL(o Object) (string, error) {
+	region := c.Region
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		switch o.Scheme {
+		case "s3":
+			if region == "" {
+				region = "us-east-1"
+			}
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		case "oss":
+			if region == "" {
+				return "", fmt.Errorf("oss 需要配置 XHS_OSS_REGION 或 XHS_OSS_ENDPOINT")
+			}
+			endpoint = "https://oss-" + strings.TrimPrefix(region, "oss-") + ".aliyuncs.com"
+		case "cos":
+			if region == "" {
+				return "", fmt.Errorf("cos 需要配置 XHS_COS_REGION 或 XHS_COS_ENDPOINT")
+			}
+			endpoint = "https://cos." + region + ".myqcloud.com"
+		}
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	key := encodePath(o.Key)
+	if c.PathStyle {
+		return endpoint + "/" + o.Bucket + "/" + key, nil
+	}
+	scheme, host, _ := strings.Cut(endpoint, "://")
+	return scheme + "://" + o.Bucket + "." + host + "/" + key, nil
+}
+
+// Downloader 下载对象到本地目录
+type Downloader struct {
+	dir    string
+	client *http.Client
+	env    func(scheme string) Config
+}
+
+// NewDownloader 创建下载器，文件保存在 dir 下
+func NewDownloader(dir string, timeout time.Duration) *Downloader {
+	return &Downloader{dir: dir, client: &http.Client{Timeout: timeout}, env: ConfigFromEnv}
+}
+
+// Download 下载对象，返回本地文件路径。同一对象重复下载时复用已有文件。
+func (d *Downloader) Download(ctx context.Context, raw string) (string, error) {
+	o, err := ParseURL(raw)
+	if err != nil {
+		return "", err
+	}
+	cfg := d.env(o.Scheme)
+	u, err := cfg.objectURL(o)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(raw))
+	name := "obj_" + hex.EncodeToString(sum[:8]) + path.Ext(o.Key)
+	dest := filepath.Join(d.dir, name)
+	if st, err := os.Stat(dest); err == nil && st.Size() > 0 {
+		return dest, nil
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return "", fmt.Errorf("创建下载目录失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if cfg.AccessKey != "" {
+		signV4(req, cfg, time.Now())
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("下载 %s 失败: %w", raw, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("下载 %s 失败: HTTP %d %s", raw, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	tmp, err := os.CreateTemp(d.dir, name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("下载 %s 失败: %w", raw, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// signV4 为 GET 请求添加 AWS SigV4 签名，载荷不参与签名
+func signV4(req *http.Request, cfg Config, now time.Time) {
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	const payload = "UNSIGNED-PAYLOAD"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payload,
+		"x-amz-date":           amzDate,
+	}
+	if cfg.SessionToken != "" {
+		headers["x-amz-security-token"] = cfg.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signed,
+		payload,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKey, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// encodePath 按 S3 规则编码对象 key，保留 /
+func encodePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = uriEncode(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package objstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseURL(t *testing.T) {
+	o, err := ParseURL("oss://bucket/dir/图 1.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Scheme != "oss" || o.Bucket != "bucket" || o.Key != "dir/图 1.jpg" {
+		t.Fatalf("解析结果不符: %+v", o)
+	}
+	for _, bad := range []string{"https://a/b", "s3://bucket", "s3:///key", "/local/path.jpg"} {
+		if IsObjectURL(bad) {
+			t.Fatalf("%q 不应识别为对象存储地址", bad)
+		}
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		obj  Object
+		want string
+	}{
+		{Config{}, Object{"s3", "b", "a b.jpg"}, "https://b.s3.us-east-1.amazonaws.com/a%20b.jpg"},
+		{Config{Region: "cn-hangzhou"}, Object{"oss", "b", "x/y.png"}, "https://b.oss-cn-hangzhou.aliyuncs.com/x/y.png"},
+		{Config{Region: "ap-guangzhou"}, Object{"cos", "b-125", "v.mp4"}, "https://b-125.cos.ap-guangzhou.myqcloud.com/v.mp4"},
+		{Config{Endpoint: "http://minio:9000", PathStyle: true}, Object{"s3", "b", "k"}, "http://minio:9000/b/k"},
+	}
+	for _, c := range cases {
+		got, err := c.cfg.objectURL(c.obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Fatalf("objectURL = %q, want %q", got, c.want)
+		}
+	}
+	if _, err := (Config{}).objectURL(Object{"oss", "b", "k"}); err == nil {
+		t.Fatal("oss 未配置区域时应报错")
+	}
+}
+
+func TestDownloadSigned(t *testing.T) {
+	var auth, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		path = r.URL.EscapedPath()
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer srv.Close()
+
+	d := NewDownloader(t.TempDir(), 5*time.Second)
+	d.env = func(string) Config {
+		return Config{Endpoint: srv.URL, PathStyle: true, Region: "us-east-1", AccessKey: "AK", SecretKey: "SK"}
+	}
+	local, err := d.Download(context.Background(), "s3://bucket/a/b c.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/bucket/a/b%20c.jpg" {
+		t.Fatalf("请求路径 = %q", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AK/") || !strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+		t.Fatalf("签名头不符: %q", auth)
+	}
+	data, err := os.ReadFile(local)
+	if err != nil || string(data) != "data" {
+		t.Fatalf("下载内容不符: %q %v", data, err)
+	}
+}
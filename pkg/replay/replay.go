@@ -0,0 +1,200 @@
+// Package replay 浏览器流程的录制与回放。
+//
+// 录制模式下保存真实运行中页面的网络响应（文档、脚本、样式、XHR/Fetch）和页面加载完成后的 HTML 快照；
+// 回放模式下由这些录制结果响应浏览器请求，选择器逻辑和新工具可以在 CI 中脱离真实账号测试。
+//
+// 目录结构：
+//
+//	<dir>/network/<序号>-<摘要>.json   单个请求的响应
+//	<dir>/snapshots/<序号>-<路径>.html 页面 HTML 快照
+package replay
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture 一次录制的请求与响应
+type Fixture struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	BodyHash   string            `json:"body_hash,omitempty"` // 请求体摘要，区分同一接口的不同参数
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       []byte            `json:"body"`
+	RecordedAt time.Time         `json:"recorded_at"`
+}
+
+// volatileParams 每次请求都会变化的查询参数，匹配时忽略
+var volatileParams = map[string]bool{"t": true, "_": true, "timestamp": true}
+
+// NormalizeURL 去掉片段与易变参数并按参数名排序，作为匹配键
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	q := u.Query()
+	for k := range q {
+		if volatileParams[k] {
+			q.Del(k)
+		}
+	}
+	u.RawQuery = q.Encode() // Encode 按键排序
+	return u.String()
+}
+
+// HashBody 请求体摘要，空请求体返回空
+func HashBody(body string) string {
+	if body == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(body))
+	return hex.EncodeToString(sum[:8])
+}
+
+func urlKey(method, rawURL string) string {
+	return strings.ToUpper(method) + " " + NormalizeURL(rawURL)
+}
+
+// Recorder 把录制结果写入目录，可并发使用
+type Recorder struct {
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder 创建录制目录；目录中已有的录制会被保留，新文件序号接在后面
+func NewRecorder(dir string) (*Recorder, error) {
+	for _, sub := range []string{"network", "snapshots"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("创建录制目录失败: %w", err)
+		}
+	}
+	r := &Recorder{dir: dir}
+	for _, sub := range []string{"network", "snapshots"} {
+		entries, _ := os.ReadDir(filepath.Join(dir, sub))
+		r.seq += len(entries)
+	}
+	return r, nil
+}
+
+// Dir 录制目录
+func (r *Recorder) Dir() string { return r.dir }
+
+func (r *Recorder) next() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	return r.seq
+}
+
+// Add 保存一次响应
+func (r *Recorder) Add(f Fixture) error {
+	if f.RecordedAt.IsZero() {
+		f.RecordedAt = time.Now()
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	sum := sha1.Sum([]byte(urlKey(f.Method, f.URL) + f.BodyHash))
+	name := fmt.Sprintf("%06d-%s.json", r.next(), hex.EncodeToString(sum[:4]))
+	return os.WriteFile(filepath.Join(r.dir, "network", name), data, 0644)
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Snapshot 保存页面 HTML 快照，文件名包含页面路径便于查找
+func (r *Recorder) Snapshot(pageURL, html string) error {
+	slug := "page"
+	if u, err := url.Parse(pageURL); err == nil {
+		if s := strings.Trim(unsafeName.ReplaceAllString(u.Path, "_"), "_"); s != "" {
+			slug = s
+		}
+	}
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	name := fmt.Sprintf("%06d-%s.html", r.next(), slug)
+	return os.WriteFile(filepath.Join(r.dir, "snapshots", name), []byte(html), 0644)
+}
+
+// Player 从录制目录查找响应。同一请求录制了多次时按录制顺序依次返回，用完后重复最后一次
+type Player struct {
+	mu     sync.Mutex
+	byBody map[string][]Fixture // 方法、地址与请求体都匹配
+	byURL  map[string][]Fixture // 请求体不同（如含随机字段）时按方法和地址匹配
+	served map[string]int
+	misses []string
+}
+
+// Load 读取录制目录中的全部响应
+func Load(dir string) (*Player, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "network", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("录制目录 %s 中没有网络响应", dir)
+	}
+	sort.Strings(files)
+	p := &Player{byBody: map[string][]Fixture{}, byURL: map[string][]Fixture{}, served: map[string]int{}}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", filepath.Base(file), err)
+		}
+		k := urlKey(f.Method, f.URL)
+		p.byURL[k] = append(p.byURL[k], f)
+		p.byBody[k+" "+f.BodyHash] = append(p.byBody[k+" "+f.BodyHash], f)
+	}
+	return p, nil
+}
+
+// Lookup 查找请求对应的录制响应，找不到时记录为未命中
+func (p *Player) Lookup(method, rawURL, body string) (Fixture, bool) {
+	k := urlKey(method, rawURL)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range []string{k + " " + HashBody(body), k} {
+		list := p.byBody[key]
+		if key == k {
+			list = p.byURL[key]
+		}
+		if len(list) == 0 {
+			continue
+		}
+		i := p.served[key]
+		if i >= len(list) {
+			i = len(list) - 1
+		}
+		p.served[key] = i + 1
+		return list[i], true
+	}
+	p.misses = append(p.misses, k)
+	return Fixture{}, false
+}
+
+// Misses 回放中未命中的请求，用于发现需要补录的页面或接口
+func (p *Player) Misses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.misses...)
+}
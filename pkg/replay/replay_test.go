@@ -0,0 +1,75 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := "https://edith.xiaohongshu.com/api/sns/web/v1/search/notes"
+	for _, f := range []Fixture{
+		{Method: "GET", URL: "https://www.xiaohongshu.com/explore?t=1&b=2&a=1", Status: 200, Body: []byte("<html>1</html>")},
+		{Method: "GET", URL: "https://www.xiaohongshu.com/explore?a=1&b=2&t=2", Status: 200, Body: []byte("<html>2</html>")},
+		{Method: "POST", URL: search, BodyHash: HashBody(`{"keyword":"猫"}`), Status: 200, Body: []byte("cat")},
+		{Method: "POST", URL: search, BodyHash: HashBody(`{"keyword":"狗"}`), Status: 200, Body: []byte("dog")},
+	} {
+		if err := rec.Add(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Snapshot("https://www.xiaohongshu.com/explore/abc", "<html></html>"); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := filepath.Glob(filepath.Join(dir, "snapshots", "*-explore_abc.html")); len(snaps) != 1 {
+		t.Fatalf("snapshots = %v", snaps)
+	}
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一地址按录制顺序返回，用完后重复最后一次；易变参数与参数顺序不影响匹配
+	for _, want := range []string{"<html>1</html>", "<html>2</html>", "<html>2</html>"} {
+		f, ok := p.Lookup("GET", "https://www.xiaohongshu.com/explore?b=2&a=1&t=99#top", "")
+		if !ok || string(f.Body) != want {
+			t.Fatalf("got %q, want %q", f.Body, want)
+		}
+	}
+	// 请求体匹配优先，不匹配时按地址回退
+	if f, _ := p.Lookup("POST", search, `{"keyword":"狗"}`); string(f.Body) != "dog" {
+		t.Fatalf("body match = %q", f.Body)
+	}
+	if _, ok := p.Lookup("POST", search, `{"keyword":"鱼"}`); !ok {
+		t.Fatal("请求体不同应按地址回退")
+	}
+	if _, ok := p.Lookup("GET", "https://www.xiaohongshu.com/user/profile/1", ""); ok {
+		t.Fatal("未录制的地址不应命中")
+	}
+	if misses := p.Misses(); len(misses) != 1 {
+		t.Fatalf("misses = %v", misses)
+	}
+
+	// 继续录制时序号接在已有文件之后
+	rec2, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec2.Add(Fixture{Method: "GET", URL: "https://a.test/", Status: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "network")); len(entries) != 5 {
+		t.Fatalf("network files = %d", len(entries))
+	}
+}
+
+func TestLoadEmptyDir(t *testing.T) {
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("空目录应报错")
+	}
+}
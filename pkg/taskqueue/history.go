@@ -0,0 +1,89 @@
+package taskqueue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// HistoryRecord 一条已成功执行的写操作
+type HistoryRecord struct {
+	Key    string    `json:"key"`
+	Kind   string    `json:"kind"`
+	TaskID string    `json:"task_id"`
+	Title  string    `json:"title,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// History 发布历史，重放任务时用于判断是否已经执行过
+type History struct {
+	mu   sync.Mutex
+	path string
+	keys map[string]HistoryRecord
+}
+
+// OpenHistory 加载发布历史
+func OpenHistory(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建历史目录失败: %w", err)
+	}
+	h := &History{path: path, keys: map[string]HistoryRecord{}}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取发布历史失败: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r HistoryRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || r.Key == "" {
+			continue
+		}
+		h.keys[r.Key] = r
+	}
+	return h, sc.Err()
+}
+
+// Has 是否已存在相同幂等键的记录
+func (h *History) Has(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.keys[key]
+	return ok
+}
+
+// Add 追加一条记录并落盘
+func (h *History) Add(r HistoryRecord) error {
+	if r.At.IsZero() {
+		r.At = time.Now()
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("写入发布历史失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入发布历史失败: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	h.keys[r.Key] = r
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package taskqueue 持久化写操作任务，进程崩溃后可重放未完成的任务。
+package taskqueue
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 任务状态
+const (
+	StatusPending  = "pending"  // 已接收，尚未完成
+	StatusDone     = "done"     // 执行成功
+	StatusFailed   = "failed"   // 执行失败（不会重放）
+	StatusReplayed = "replayed" // 已被重放任务接管
+	StatusSkipped  = "skipped"  // 重放时发现已执行过，跳过
+)
+
+// Task 一条写操作任务
+type Task struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Key       string          `json:"key"` // 幂等键：kind + payload 的摘要
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    string          `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Journal 基于 JSONL 追加写的任务日志，每次状态变更都会 fsync 落盘
+type Journal struct {
+	mu    sync.Mutex
+	path  string
+	f     *os.File
+	tasks map[string]*Task
+}
+
+// Open 打开任务日志；加载时只保留未完成的任务并压缩文件
+func Open(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建任务目录失败: %w", err)
+	}
+
+	tasks, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	for id, t := range tasks {
+		if t.Status != StatusPending {
+			delete(tasks, id)
+		}
+	}
+
+	j := &Journal{path: path, tasks: tasks}
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func load(path string) (map[string]*Task, error) {
+	tasks := map[string]*Task{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return tasks, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取任务日志失败: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var t Task
+		// 崩溃时最后一行可能写了一半，直接忽略
+		if err := json.Unmarshal(sc.Bytes(), &t); err != nil || t.ID == "" {
+			continue
+		}
+		if prev, ok := tasks[t.ID]; ok && len(t.Payload) == 0 {
+			t.Payload = prev.Payload
+		}
+		tasks[t.ID] = &t
+	}
+	return tasks, sc.Err()
+}
+
+// compact 用当前内存中的任务重写日志文件
+func (j *Journal) compact() error {
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("写入任务日志失败: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, t := range j.sortedLocked() {
+		line, _ := json.Marshal(t)
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("写入任务日志失败: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("写入任务日志失败: %w", err)
+	}
+	f.Close()
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("替换任务日志失败: %w", err)
+	}
+
+	j.f, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开任务日志失败: %w", err)
+	}
+	return nil
+}
+
+// appendLocked 追加一条记录并落盘
+func (j *Journal) appendLocked(t *Task) error {
+	line, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入任务日志失败: %w", err)
+	}
+	return j.f.Sync()
+}
+
+// Begin 在执行前登记任务
+func (j *Journal) Begin(kind string, payload any) (*Task, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+	now := time.Now()
+	t := &Task{
+		ID:        newID(),
+		Kind:      kind,
+		Key:       Key(kind, raw),
+		Payload:   raw,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.appendLocked(t); err != nil {
+		return nil, err
+	}
+	j.tasks[t.ID] = t
+	return t, nil
+}
+
+// Finish 根据执行结果结束任务
+func (j *Journal) Finish(id string, execErr error) error {
+	if execErr != nil {
+		return j.Mark(id, StatusFailed, execErr.Error())
+	}
+	return j.Mark(id, StatusDone, "")
+}
+
+// Mark 更新任务状态；非 pending 状态的任务会从内存中移除
+func (j *Journal) Mark(id, status, errMsg string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	t, ok := j.tasks[id]
+	if !ok {
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+	// 状态记录不重复写入 payload，加载时从首条记录继承
+	rec := *t
+	rec.Payload = nil
+	rec.Status = status
+	rec.Error = errMsg
+	rec.UpdatedAt = time.Now()
+	if err := j.appendLocked(&rec); err != nil {
+		return err
+	}
+
+	if status == StatusPending {
+		t.Status, t.Error, t.UpdatedAt = rec.Status, rec.Error, rec.UpdatedAt
+	} else {
+		delete(j.tasks, id)
+	}
+	return nil
+}
+
+// Pending 返回未完成的任务（按创建时间排序）
+func (j *Journal) Pending() []Task {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := make([]Task, 0, len(j.tasks))
+	for _, t := range j.sortedLocked() {
+		out = append(out, *t)
+	}
+	return out
+}
+
+// Close 关闭日志文件
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+func (j *Journal) sortedLocked() []*Task {
+	out := make([]*Task, 0, len(j.tasks))
+	for _, t := range j.tasks {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	return out
+}
+
+// Key 计算幂等键
+func Key(kind string, payload []byte) string {
+	sum := sha256.Sum256(append([]byte(kind+"\n"), payload...))
+	return hex.EncodeToString(sum[:16])
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return time.Now().Format("20060102150405") + "-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,61 @@
+package taskqueue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalKeepsOnlyPendingAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开任务日志失败: %v", err)
+	}
+	done, _ := j.Begin("publish", map[string]string{"title": "a"})
+	failed, _ := j.Begin("publish", map[string]string{"title": "b"})
+	pending, _ := j.Begin("comment", map[string]string{"content": "c"})
+	if err := j.Finish(done.ID, nil); err != nil {
+		t.Fatalf("结束任务失败: %v", err)
+	}
+	if err := j.Finish(failed.ID, errors.New("boom")); err != nil {
+		t.Fatalf("结束任务失败: %v", err)
+	}
+	_ = j.Close()
+
+	// 模拟崩溃后重启
+	j, err = Open(path)
+	if err != nil {
+		t.Fatalf("重新打开任务日志失败: %v", err)
+	}
+	defer j.Close()
+
+	got := j.Pending()
+	if len(got) != 1 || got[0].ID != pending.ID {
+		t.Fatalf("重启后应只剩未完成任务，got=%+v", got)
+	}
+	if string(got[0].Payload) != `{"content":"c"}` {
+		t.Fatalf("任务参数应完整保留，got=%s", got[0].Payload)
+	}
+}
+
+func TestHistoryHas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("打开发布历史失败: %v", err)
+	}
+	key := Key("publish", []byte(`{"title":"a"}`))
+	if err := h.Add(HistoryRecord{Key: key, Kind: "publish"}); err != nil {
+		t.Fatalf("写入发布历史失败: %v", err)
+	}
+
+	h, err = OpenHistory(path)
+	if err != nil {
+		t.Fatalf("重新打开发布历史失败: %v", err)
+	}
+	if !h.Has(key) {
+		t.Fatalf("重新加载后应能查到已发布记录")
+	}
+}
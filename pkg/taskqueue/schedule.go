@@ -0,0 +1,214 @@
+package taskqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/fileutil"
+)
+
+// ErrJobNotFound 定时任务不存在
+var ErrJobNotFound = errors.New("定时任务不存在")
+
+// 周期任务的最小间隔，避免误配置导致频繁操作账号
+const MinJobInterval = time.Hour
+
+// Job 定时/周期任务：到期后按 Kind 执行一次写操作，Every 非空时按间隔重复
+type Job struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	RunAt     time.Time       `json:"run_at"`          // 下次执行时间
+	Every     string          `json:"every,omitempty"` // 重复间隔（Go duration），为空表示只执行一次
+	Paused    bool            `json:"paused"`
+	Runs      int             `json:"runs"`
+	LastRunAt *time.Time      `json:"last_run_at,omitempty"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Schedule 定时任务表，整体保存为一个 JSON 文件，每次修改原子替换
+type Schedule struct {
+	mu   sync.Mutex
+	path string
+	jobs map[string]*Job
+	loc  *time.Location // 周期任务按该时区的日历日顺延
+}
+
+// OpenSchedule 加载定时任务表
+func OpenSchedule(path string) (*Schedule, error) {
+	s := &Schedule{path: path, jobs: map[string]*Job{}, loc: time.Local}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取定时任务失败: %w", err)
+	}
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("解析定时任务失败: %w", err)
+	}
+	for _, j := range jobs {
+		if j.ID != "" {
+			s.jobs[j.ID] = j
+		}
+	}
+	return s, nil
+}
+
+// SetLocation 设置周期任务所在时区
+func (s *Schedule) SetLocation(loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	s.mu.Lock()
+	s.loc = loc
+	s.mu.Unlock()
+}
+
+// Add 新建任务；every 为 0 表示只执行一次
+func (s *Schedule) Add(kind string, payload json.RawMessage, runAt time.Time, every time.Duration) (Job, error) {
+	if every != 0 && every < MinJobInterval {
+		return Job{}, fmt.Errorf("重复间隔不能小于 %s", MinJobInterval)
+	}
+	j := &Job{
+		ID:        newID(),
+		Kind:      kind,
+		Payload:   payload,
+		RunAt:     runAt,
+		CreatedAt: time.Now(),
+	}
+	if every > 0 {
+		j.Every = every.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[j.ID] = j
+	if err := s.saveLocked(); err != nil {
+		delete(s.jobs, j.ID)
+		return Job{}, err
+	}
+	return *j, nil
+}
+
+// List 按下次执行时间排序返回全部任务
+func (s *Schedule) List() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, *j)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].RunAt.Before(out[b].RunAt) })
+	return out
+}
+
+// SetPaused 暂停/恢复任务
+func (s *Schedule) SetPaused(id string, paused bool) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	prev := j.Paused
+	j.Paused = paused
+	if err := s.saveLocked(); err != nil {
+		j.Paused = prev
+		return Job{}, err
+	}
+	return *j, nil
+}
+
+// Delete 删除任务
+func (s *Schedule) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	if err := s.saveLocked(); err != nil {
+		s.jobs[id] = j
+		return err
+	}
+	return nil
+}
+
+// Due 返回已到期且未暂停的任务
+func (s *Schedule) Due(now time.Time) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Job
+	for _, j := range s.jobs {
+		if !j.Paused && !j.RunAt.After(now) {
+			out = append(out, *j)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].RunAt.Before(out[b].RunAt) })
+	return out
+}
+
+// Complete 记录一次执行结果：
+// 周期任务顺延到下一个未来时间点（错过的不补跑）；一次性任务成功后删除，
+// 失败时保留并暂停，便于排查后恢复重试或删除
+func (s *Schedule) Complete(id string, runErr error, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	j.Runs++
+	j.LastRunAt = &now
+	j.LastError = ""
+	if runErr != nil {
+		j.LastError = runErr.Error()
+	}
+
+	every, _ := time.ParseDuration(j.Every)
+	switch {
+	case every > 0:
+		for !j.RunAt.After(now) {
+			j.RunAt = advance(j.RunAt, every, s.loc)
+		}
+	case runErr == nil:
+		delete(s.jobs, id)
+	default:
+		j.Paused = true
+	}
+	return s.saveLocked()
+}
+
+// advance 顺延一个周期：整天数的间隔按时区的日历日顺延，夏令时切换前后保持同一当地时刻
+func advance(t time.Time, every time.Duration, loc *time.Location) time.Time {
+	const day = 24 * time.Hour
+	if every%day == 0 {
+		return t.In(loc).AddDate(0, 0, int(every/day))
+	}
+	return t.Add(every)
+}
+
+func (s *Schedule) saveLocked() error {
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].CreatedAt.Before(jobs[b].CreatedAt) })
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("保存定时任务失败: %w", err)
+	}
+	return nil
+}
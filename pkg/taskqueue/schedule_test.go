@@ -0,0 +1,95 @@
+package taskqueue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduleLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	s, err := OpenSchedule(path)
+	if err != nil {
+		t.Fatalf("打开定时任务失败: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := s.Add("publish", []byte(`{}`), now, 10*time.Minute); err == nil {
+		t.Fatal("间隔过短应被拒绝")
+	}
+	once, _ := s.Add("comment", []byte(`{"content":"a"}`), now.Add(-time.Minute), 0)
+	daily, _ := s.Add("publish", []byte(`{"title":"b"}`), now.Add(-25*time.Hour), 24*time.Hour)
+	later, _ := s.Add("publish", []byte(`{"title":"c"}`), now.Add(time.Hour), 0)
+
+	if due := s.Due(now); len(due) != 2 || due[0].ID != daily.ID {
+		t.Fatalf("应有两个到期任务且按时间排序，got=%+v", due)
+	}
+
+	// 周期任务顺延到未来，一次性任务失败后暂停保留
+	if err := s.Complete(daily.ID, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Complete(once.ID, errors.New("boom"), now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetPaused(later.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后状态保持
+	s, err = OpenSchedule(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due := s.Due(now.Add(2 * time.Hour)); len(due) != 0 {
+		t.Fatalf("暂停的任务不应到期，got=%+v", due)
+	}
+	jobs := map[string]Job{}
+	for _, j := range s.List() {
+		jobs[j.ID] = j
+	}
+	if j := jobs[daily.ID]; !j.RunAt.After(now) || j.Runs != 1 {
+		t.Fatalf("周期任务应顺延，got=%+v", j)
+	}
+	if j := jobs[once.ID]; !j.Paused || j.LastError != "boom" {
+		t.Fatalf("失败的一次性任务应暂停并记录错误，got=%+v", j)
+	}
+
+	// 恢复后再次执行成功即删除
+	if _, err := s.SetPaused(once.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Complete(once.ID, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(once.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("成功的一次性任务应被删除，got %v", err)
+	}
+}
+
+func TestScheduleDailyKeepsLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	s, err := OpenSchedule(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetLocation(loc)
+
+	// 2026-03-08 纽约进入夏令时，每天 09:00 的任务顺延后仍是当地 09:00
+	runAt := time.Date(2026, 3, 7, 9, 0, 0, 0, loc)
+	job, err := s.Add("publish", []byte(`{}`), runAt, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Complete(job.ID, nil, runAt); err != nil {
+		t.Fatal(err)
+	}
+	next := s.List()[0].RunAt.In(loc)
+	if next.Day() != 8 || next.Hour() != 9 || next.Minute() != 0 {
+		t.Fatalf("应顺延到次日当地 09:00，got=%s", next)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+// 只读模式：用于只做调研/监控的账号，实例层面拒绝所有写操作（发布、评论、回复、点赞、收藏），
+// 不论请求来自 MCP、HTTP、定时任务还是自动回复。写操作统一经过 acquireWrite，在这里拦截。
+
+// errReadOnly 只读模式下拒绝写操作
+var errReadOnly = errors.New("READ_ONLY: 当前账号为只读模式，已拒绝写操作")
+
+// writeTools 会修改账号数据的 MCP 工具，只读模式下不在工具列表中出现
+var writeTools = map[string]bool{
+	"publish_content":       true,
+	"publish_with_video":    true,
+	"post_comment_to_feed":  true,
+	"reply_comment_in_feed": true,
+	"like_feed":             true,
+	"favorite_feed":         true,
+}
+
+// acquireWrite 获取写操作的独占标签页，只读模式下直接拒绝
+func (s *XiaohongshuService) acquireWrite(ctx context.Context) (func(), error) {
+	if configs.IsReadOnly() {
+		return nil, errReadOnly
+	}
+	return s.tabs.acquireWrite(ctx)
+}
+
+// mcpReadOnlyMiddleware 只读模式下从工具列表中隐藏写工具，并拒绝对它们的调用
+func mcpReadOnlyMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if !configs.IsReadOnly() {
+			return next(ctx, method, req)
+		}
+		if method == "tools/call" {
+			if p, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && writeTools[p.Name] {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{&mcp.TextContent{Text: "工具未执行: " + errReadOnly.Error()}},
+					IsError: true,
+				}, nil
+			}
+			return next(ctx, method, req)
+		}
+		res, err := next(ctx, method, req)
+		if r, ok := res.(*mcp.ListToolsResult); ok && err == nil {
+			out := *r
+			out.Tools = make([]*mcp.Tool, 0, len(r.Tools))
+			for _, t := range r.Tools {
+				if !writeTools[t.Name] {
+					out.Tools = append(out.Tools, t)
+				}
+			}
+			return &out, nil
+		}
+		return res, err
+	}
+}
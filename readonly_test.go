@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+func TestReadOnlyBlocksWrites(t *testing.T) {
+	configs.SetReadOnly(true)
+	defer configs.SetReadOnly(false)
+
+	s := &XiaohongshuService{tabs: newTabGate(1)}
+	_, err := s.acquireWrite(context.Background())
+	require.ErrorIs(t, err, errReadOnly)
+	_, err = s.LikeFeed(context.Background(), "feed", "token")
+	require.ErrorIs(t, err, errReadOnly)
+
+	called := false
+	handler := mcpReadOnlyMiddleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		called = true
+		if method == "tools/list" {
+			return &mcp.ListToolsResult{Tools: []*mcp.Tool{{Name: "search_feeds"}, {Name: "publish_content"}}}, nil
+		}
+		return &mcp.CallToolResult{}, nil
+	})
+
+	res, err := handler(context.Background(), "tools/list", &mcp.ServerRequest[*mcp.ListToolsParams]{})
+	require.NoError(t, err)
+	tools := res.(*mcp.ListToolsResult).Tools
+	require.Len(t, tools, 1)
+	require.Equal(t, "search_feeds", tools[0].Name)
+
+	called = false
+	res, err = handler(context.Background(), "tools/call", &mcp.ServerRequest[*mcp.CallToolParamsRaw]{Params: &mcp.CallToolParamsRaw{Name: "like_feed"}})
+	require.NoError(t, err)
+	require.True(t, res.(*mcp.CallToolResult).IsError)
+	require.False(t, called, "写工具不应执行")
+
+	_, err = handler(context.Background(), "tools/call", &mcp.ServerRequest[*mcp.CallToolParamsRaw]{Params: &mcp.CallToolParamsRaw{Name: "search_feeds"}})
+	require.NoError(t, err)
+	require.True(t, called)
+}
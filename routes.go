@@ -5,6 +5,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/i18n"
 )
 
 // setupRoutes 设置路由配置
@@ -17,11 +19,16 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 	router.Use(gin.Recovery())
 
 	// 添加中间件
+	router.Use(i18n.Middleware(i18n.Lang(configs.GetLang())))
 	router.Use(errorHandlingMiddleware())
 	router.Use(corsMiddleware())
+	router.Use(authMiddleware(configs.GetAPIToken()))
+	router.Use(drainMiddleware(appServer.drain))
 
 	// 健康检查
 	router.GET("/health", healthHandler)
+	router.GET("/livez", livezHandler)
+	router.GET("/readyz", appServer.readyzHandler)
 
 	// MCP 端点 - 使用官方 SDK 的 Streamable HTTP Handler
 	mcpHandler := mcp.NewStreamableHTTPHandler(
@@ -36,13 +43,21 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 	router.Any("/mcp/*path", gin.WrapH(mcpHandler))
 
 	// API 路由组
+	// 会打开页面的业务接口需要限流，调试/截图类接口不受限
+	limited := appServer.limitMiddleware()
+
 	api := router.Group("/api/v1")
 	{
-		api.GET("/login/status", appServer.checkLoginStatusHandler)
-		api.GET("/login/qrcode", appServer.getLoginQrcodeHandler)
+		api.GET("/login/status", limited, appServer.checkLoginStatusHandler)
+		api.GET("/login/qrcode", limited, appServer.getLoginQrcodeHandler)
 		api.GET("/login/browser/screenshot", appServer.getLoginBrowserScreenshotHandler)
 		api.POST("/login/browser/action", appServer.postLoginBrowserActionHandler)
 
+		api.GET("/debug/screenshot", appServer.pageScreenshotHandler)
+		if configs.IsDevToolsEnabled() {
+			api.Any("/debug/devtools/*path", appServer.devToolsProxyHandler)
+		}
+
 		// 可视化调试（发布流程为主）：会话/步骤/网络/控制台/暂停/截图
 		api.GET("/debug/sessions", appServer.listFlowDebugSessionsHandler)
 		api.GET("/debug/sessions/:sid/stream", appServer.streamFlowDebugSessionHandler)
@@ -51,19 +66,46 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 		api.POST("/debug/sessions/:sid/browser/action", appServer.postFlowDebugSessionBrowserActionHandler)
 
 		api.DELETE("/login/cookies", appServer.deleteCookiesHandler)
-		api.POST("/publish", appServer.publishHandler)
-		api.POST("/publish_video", appServer.publishVideoHandler)
-		api.GET("/feeds/list", appServer.listFeedsHandler)
-		api.GET("/feeds/search", appServer.searchFeedsHandler)
-		api.POST("/feeds/search", appServer.searchFeedsHandler)
-		api.POST("/feeds/detail", appServer.getFeedDetailHandler)
-		api.POST("/feeds/like", appServer.likeFeedHandler)
-		api.POST("/feeds/favorite", appServer.favoriteFeedHandler)
-		api.POST("/user/profile", appServer.userProfileHandler)
-		api.POST("/feeds/comment", appServer.postCommentHandler)
-		api.POST("/feeds/comment/reply", appServer.replyCommentHandler)
-		api.GET("/user/me", appServer.myProfileHandler)
-		api.GET("/notifications/mentions", appServer.notificationMentionsHandler)
+		api.GET("/login/cookies", appServer.listCookiesHandler)
+		api.POST("/login/cookies", limited, appServer.upsertCookieHandler)
+		api.DELETE("/login/cookies/:name", limited, appServer.removeCookieHandler)
+		api.POST("/login/cookies/validate", limited, appServer.validateCookiesHandler)
+		api.POST("/login/cookies/import-browser", limited, appServer.importBrowserCookiesHandler)
+		api.POST("/publish", limited, appServer.publishHandler)
+		api.POST("/publish_video", limited, appServer.publishVideoHandler)
+		api.GET("/feeds/list", limited, appServer.listFeedsHandler)
+		api.GET("/feeds/search", limited, appServer.searchFeedsHandler)
+		api.POST("/feeds/search", limited, appServer.searchFeedsHandler)
+		api.POST("/feeds/detail", limited, appServer.getFeedDetailHandler)
+		api.POST("/feeds/like", limited, appServer.likeFeedHandler)
+		api.POST("/feeds/favorite", limited, appServer.favoriteFeedHandler)
+		api.POST("/user/profile", limited, appServer.userProfileHandler)
+		api.POST("/feeds/comment", limited, appServer.postCommentHandler)
+		api.POST("/feeds/comment/reply", limited, appServer.replyCommentHandler)
+		api.GET("/user/me", limited, appServer.myProfileHandler)
+		api.GET("/notifications/mentions", limited, appServer.notificationMentionsHandler)
+		api.POST("/moderation/check", appServer.moderationCheckHandler)
+		api.GET("/events", appServer.listEventsHandler)
+		api.GET("/events/stream", appServer.eventStreamHandler)
+		api.GET("/mentions/watch", appServer.mentionWatchStatusHandler)
+		api.GET("/mentions/stream", appServer.mentionStreamHandler)
+		api.GET("/triggers", appServer.listTriggersHandler)
+		api.GET("/triggers/:name", appServer.pollTriggerHandler)
+		api.POST("/triggers/:name/subscribe", appServer.subscribeTriggerHandler)
+		api.DELETE("/triggers/subscriptions/:sid", appServer.unsubscribeTriggerHandler)
+		api.GET("/autoreply", appServer.getAutoReplyHandler)
+		api.PUT("/autoreply", appServer.putAutoReplyHandler)
+		api.GET("/rss/search", limited, appServer.rssSearchHandler)
+		api.GET("/rss/mentions", limited, appServer.rssMentionsHandler)
+		api.GET("/mcp/history", appServer.mcpHistoryHandler)
+		api.GET("/activity", appServer.activityHandler)
+		api.GET("/jobs", appServer.listJobsHandler)
+		api.POST("/jobs", appServer.createJobHandler)
+		api.POST("/jobs/:jid/pause", appServer.pauseJobHandler)
+		api.POST("/jobs/:jid/resume", appServer.resumeJobHandler)
+		api.DELETE("/jobs/:jid", appServer.deleteJobHandler)
+		api.GET("/selectors", appServer.selectorsHandler)
+		api.POST("/selectors/reload", appServer.reloadSelectorsHandler)
 	}
 
 	return router
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// RSS/Atom 订阅：关键词搜索结果与本账号收到的评论/@。
+// 阅读器轮询频繁，结果按订阅缓存 feedCacheTTL，过期后下一次请求才会重新打开浏览器抓取；
+// 搜索结果本身没有时间，条目时间取首次出现在该关键词结果中的时间，新笔记因此排在最前。
+const (
+	feedCacheTTL   = 10 * time.Minute
+	feedMaxEntries = 50
+	feedSeenLimit  = 1000 // 每个关键词记住的笔记数
+)
+
+// feedItem 订阅条目
+type feedItem struct {
+	ID      string
+	Title   string
+	Link    string
+	Author  string
+	Summary string
+	Updated time.Time
+}
+
+type cachedFeed struct {
+	items     []feedItem
+	fetchedAt time.Time
+}
+
+// feedMonitor 订阅结果缓存与首次出现时间
+type feedMonitor struct {
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+	seen  map[string]map[string]time.Time // 关键词 -> 笔记 ID -> 首次出现时间
+}
+
+func newFeedMonitor() *feedMonitor {
+	return &feedMonitor{cache: map[string]cachedFeed{}, seen: map[string]map[string]time.Time{}}
+}
+
+// get 返回未过期的缓存，否则调用 fetch 刷新
+func (m *feedMonitor) get(key string, fetch func() ([]feedItem, error)) ([]feedItem, error) {
+	m.mu.Lock()
+	c, ok := m.cache[key]
+	m.mu.Unlock()
+	if ok && time.Since(c.fetchedAt) < feedCacheTTL {
+		return c.items, nil
+	}
+
+	items, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.cache[key] = cachedFeed{items: items, fetchedAt: time.Now()}
+	m.mu.Unlock()
+	return items, nil
+}
+
+// stamp 为搜索结果补上首次出现时间
+func (m *feedMonitor) stamp(keyword string, items []feedItem, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := m.seen[keyword]
+	if seen == nil {
+		seen = map[string]time.Time{}
+		m.seen[keyword] = seen
+	}
+	for i := range items {
+		t, ok := seen[items[i].ID]
+		if !ok {
+			t = now
+			seen[items[i].ID] = t
+		}
+		items[i].Updated = t
+	}
+	if len(seen) > feedSeenLimit {
+		// 丢弃最早出现的一半，下次再出现会被当作新条目
+		ids := make([]string, 0, len(seen))
+		for id := range seen {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(a, b int) bool { return seen[ids[a]].Before(seen[ids[b]]) })
+		for _, id := range ids[:len(ids)/2] {
+			delete(seen, id)
+		}
+	}
+}
+
+// rssSearchHandler 关键词搜索结果订阅
+// GET /api/v1/rss/search?keyword=xxx&format=atom|rss
+func (s *AppServer) rssSearchHandler(c *gin.Context) {
+	keyword := strings.TrimSpace(c.Query("keyword"))
+	if keyword == "" {
+		respondError(c, http.StatusBadRequest, "MISSING_KEYWORD", "缺少关键词参数", "keyword parameter is required")
+		return
+	}
+
+	items, err := s.feeds.get("search:"+keyword, func() ([]feedItem, error) {
+		feeds, err := s.xiaohongshuService.searchFeeds(c.Request.Context(), keyword)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]feedItem, 0, len(feeds))
+		for _, f := range feeds {
+			if f.ID == "" {
+				continue
+			}
+			items = append(items, searchFeedItem(f))
+		}
+		s.feeds.stamp(keyword, items, time.Now())
+		return items, nil
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "SEARCH_FEEDS_FAILED", "搜索失败", err.Error())
+		return
+	}
+	writeFeed(c, "小红书搜索: "+keyword, items)
+}
+
+// rssMentionsHandler 本账号收到的评论和@订阅
+// GET /api/v1/rss/mentions?format=atom|rss
+func (s *AppServer) rssMentionsHandler(c *gin.Context) {
+	items, err := s.feeds.get("mentions", func() ([]feedItem, error) {
+		resp, err := s.xiaohongshuService.GetNotificationMentions(c.Request.Context())
+		if err != nil {
+			return nil, err
+		}
+		items := make([]feedItem, 0, len(resp.Notifications))
+		for _, n := range resp.Notifications {
+			items = append(items, mentionFeedItem(n))
+		}
+		return items, nil
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "GET_MENTIONS_FAILED", "获取评论和@通知失败", err.Error())
+		return
+	}
+	writeFeed(c, "小红书评论和@", items)
+}
+
+func searchFeedItem(f xiaohongshu.Feed) feedItem {
+	author := f.NoteCard.User.Nickname
+	if author == "" {
+		author = f.NoteCard.User.NickName
+	}
+	title := f.NoteCard.DisplayTitle
+	if title == "" {
+		title = "（无标题）"
+	}
+	return feedItem{
+		ID:      f.ID,
+		Title:   title,
+		Link:    noteLink(f.ID, f.XsecToken),
+		Author:  author,
+		Summary: fmt.Sprintf("点赞 %s · 评论 %s", f.NoteCard.InteractInfo.LikedCount, f.NoteCard.InteractInfo.CommentCount),
+	}
+}
+
+func mentionFeedItem(n xiaohongshu.NotificationMention) feedItem {
+	item := feedItem{
+		ID:      n.ID,
+		Title:   n.Title,
+		Author:  mapString(n.UserInfo, "nickname"),
+		Summary: mapString(n.CommentInfo, "content"),
+		Updated: time.UnixMilli(n.Time),
+	}
+	if n.Time < 1e12 { // 秒级时间戳
+		item.Updated = time.Unix(n.Time, 0)
+	}
+	if id := mapString(n.ItemInfo, "id"); id != "" {
+		item.Link = noteLink(id, mapString(n.ItemInfo, "xsec_token"))
+	}
+	if item.Title == "" {
+		item.Title = item.Summary
+	}
+	return item
+}
+
+func noteLink(id, xsecToken string) string {
+	link := "https://www.xiaohongshu.com/explore/" + id
+	if xsecToken != "" {
+		link += "?xsec_token=" + xsecToken + "&xsec_source=pc_search"
+	}
+	return link
+}
+
+func mapString(m map[string]any, key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+// writeFeed 按 ?format= 输出 Atom（默认）或 RSS 2.0
+func writeFeed(c *gin.Context, title string, items []feedItem) {
+	sorted := append([]feedItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Updated.After(sorted[j].Updated) })
+	if len(sorted) > feedMaxEntries {
+		sorted = sorted[:feedMaxEntries]
+	}
+
+	// 订阅地址去掉 token，避免令牌写进阅读器可见的 feed 内容
+	q := c.Request.URL.Query()
+	q.Del("token")
+	self := "http://" + c.Request.Host + c.Request.URL.Path
+	if len(q) > 0 {
+		self += "?" + q.Encode()
+	}
+	var (
+		doc         any
+		contentType string
+	)
+	if c.Query("format") == "rss" {
+		doc, contentType = buildRSS(title, self, sorted), "application/rss+xml; charset=utf-8"
+	} else {
+		doc, contentType = buildAtom(title, self, sorted), "application/atom+xml; charset=utf-8"
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "FEED_RENDER_FAILED", "生成订阅失败", err.Error())
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+func buildAtom(title, self string, items []feedItem) atomFeed {
+	updated := time.Now()
+	if len(items) > 0 {
+		updated = items[0].Updated
+	}
+	f := atomFeed{
+		Title:   title,
+		ID:      self,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: self, Rel: "self"},
+	}
+	for _, it := range items {
+		e := atomEntry{
+			Title:   it.Title,
+			ID:      "urn:xiaohongshu:" + it.ID,
+			Updated: it.Updated.UTC().Format(time.RFC3339),
+			Summary: it.Summary,
+		}
+		if it.Link != "" {
+			e.Link = &atomLink{Href: it.Link}
+		}
+		if it.Author != "" {
+			e.Author = &atomAuthor{Name: it.Author}
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Author      string  `xml:"author,omitempty"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+func buildRSS(title, self string, items []feedItem) rssDoc {
+	doc := rssDoc{Version: "2.0", Channel: rssChannel{Title: title, Link: self, Description: title}}
+	for _, it := range items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        rssGUID{Value: "urn:xiaohongshu:" + it.ID},
+			PubDate:     it.Updated.Format(time.RFC1123Z),
+			Author:      it.Author,
+			Description: it.Summary,
+		})
+	}
+	return doc
+}
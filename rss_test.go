@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedMonitorStampKeepsFirstSeen(t *testing.T) {
+	m := newFeedMonitor()
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := []feedItem{{ID: "a"}}
+	m.stamp("咖啡", first, t0)
+	second := []feedItem{{ID: "a"}, {ID: "b"}}
+	m.stamp("咖啡", second, t0.Add(time.Hour))
+
+	require.Equal(t, t0, second[0].Updated)
+	require.Equal(t, t0.Add(time.Hour), second[1].Updated)
+}
+
+func TestWriteFeedFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	items := []feedItem{
+		{ID: "old", Title: "旧笔记", Updated: time.Unix(100, 0)},
+		{ID: "new", Title: "新笔记 & <标签>", Link: noteLink("new", "tok"), Updated: time.Unix(200, 0)},
+	}
+	router := gin.New()
+	router.GET("/api/v1/rss/search", func(c *gin.Context) { writeFeed(c, "测试", items) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rss/search?keyword=x&token=secret", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "application/atom+xml")
+	body := w.Body.String()
+	require.Contains(t, body, "新笔记 &amp; &lt;标签&gt;")
+	require.NotContains(t, body, "secret")
+	require.Less(t, strings.Index(body, "urn:xiaohongshu:new"), strings.Index(body, "urn:xiaohongshu:old"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/rss/search?keyword=x&format=rss", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Contains(t, w.Header().Get("Content-Type"), "application/rss+xml")
+	require.Contains(t, w.Body.String(), `<rss version="2.0">`)
+}
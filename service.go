@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -17,7 +18,9 @@ import (
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/downloader"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/flowdebug"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/objstore"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/proxyutil"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/taskqueue"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/xhsutil"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
@@ -39,15 +42,30 @@ type XiaohongshuService struct {
 	browserMu          sync.Mutex
 	sharedBrowser      *browser.Browser
 	sharedBrowserProxy string
+	browserLastUsed    time.Time // 最近一次获取共享浏览器的时间，用于空闲回收
+	browserStale       bool      // 拦截页恢复失败后标记，下次获取时重启浏览器
 
 	// 可视化调试：发布流程会话（步骤/网络/控制台/暂停）
 	flowDebug *FlowDebugCenter
+
+	// 多标签页调度：读操作并行，写操作独占
+	tabs *tabGate
+
+	// 写操作持久化：崩溃后重放未完成任务，发布历史用于去重，定时任务到期后按同样方式执行
+	tasks    *taskqueue.Journal
+	history  *taskqueue.History
+	schedule *taskqueue.Schedule
+
+	// 列表结果游标分页缓存
+	pages *pageCache
 }
 
 // NewXiaohongshuService 创建小红书服务实例
 func NewXiaohongshuService() *XiaohongshuService {
 	return &XiaohongshuService{
 		flowDebug: NewFlowDebugCenter(flowDebugDefaultMaxSessions),
+		tabs:      newTabGate(configs.GetMaxReadTabs()),
+		pages:     newPageCache(),
 	}
 }
 
@@ -57,6 +75,45 @@ func (s *XiaohongshuService) Close() {
 	if b != nil {
 		b.Close()
 	}
+	if s.tasks != nil {
+		_ = s.tasks.Close()
+	}
+}
+
+// PersistCookies 退出前把共享浏览器中的最新 cookies 落盘。
+// 浏览器未启动或 cookies 文件已被删除（已退出登录）时跳过。
+func (s *XiaohongshuService) PersistCookies() error {
+	s.browserMu.Lock()
+	b := s.sharedBrowser
+	s.browserMu.Unlock()
+	if b == nil {
+		return nil
+	}
+	return persistBrowserCookies(b)
+}
+
+// persistBrowserCookies 仅在 cookies 文件存在（未退出登录）时落盘
+func persistBrowserCookies(b *browser.Browser) error {
+	if _, err := os.Stat(cookies.GetCookiesFilePath()); err != nil {
+		return nil
+	}
+
+	cks, err := b.GetCookies()
+	if err != nil {
+		return err
+	}
+	return saveCookieList(cks)
+}
+
+// CheckBrowser 检查共享浏览器是否存活；浏览器尚未启动（懒加载）时 started 为 false
+func (s *XiaohongshuService) CheckBrowser(timeout time.Duration) (started bool, err error) {
+	s.browserMu.Lock()
+	b := s.sharedBrowser
+	s.browserMu.Unlock()
+	if b == nil {
+		return false, nil
+	}
+	return true, b.Ping(timeout)
 }
 
 // ListFlowDebugSessions 获取最近的调试会话列表（最新在前）。
@@ -130,8 +187,10 @@ type PublishVideoResponse struct {
 
 // FeedsListResponse Feeds列表响应
 type FeedsListResponse struct {
-	Feeds []xiaohongshu.Feed `json:"feeds"`
-	Count int                `json:"count"`
+	Feeds   []xiaohongshu.Feed `json:"feeds"`
+	Count   int                `json:"count"`
+	Cursor  string             `json:"cursor,omitempty"` // 下一页游标，为空表示没有更多
+	HasMore bool               `json:"has_more"`
 }
 
 // UserProfileResponse 用户主页响应
@@ -139,6 +198,8 @@ type UserProfileResponse struct {
 	UserBasicInfo xiaohongshu.UserBasicInfo      `json:"userBasicInfo"`
 	Interactions  []xiaohongshu.UserInteractions `json:"interactions"`
 	Feeds         []xiaohongshu.Feed             `json:"feeds"`
+	Cursor        string                         `json:"cursor,omitempty"` // 笔记列表的下一页游标
+	HasMore       bool                           `json:"has_more"`
 }
 
 // NotificationMentionsResponse 评论和@通知响应
@@ -183,8 +244,11 @@ func (s *XiaohongshuService) checkLoginStatusOnce(ctx context.Context, proxy str
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	loginAction := xiaohongshu.NewLogin(page)
 
@@ -192,6 +256,7 @@ func (s *XiaohongshuService) checkLoginStatusOnce(ctx context.Context, proxy str
 	if err != nil {
 		return nil, err
 	}
+	activities.NoteLoginState(isLoggedIn)
 
 	response := &LoginStatusResponse{
 		IsLoggedIn: isLoggedIn,
@@ -211,7 +276,10 @@ func (s *XiaohongshuService) getLoginQrcodeOnce(ctx context.Context, proxy strin
 	if err != nil {
 		return nil, err
 	}
-	page := b.NewPage()
+	page, err := b.NewPage()
+	if err != nil {
+		return nil, err
+	}
 
 	// 注册为活跃页面，供调试交互使用
 	s.loginPageMu.Lock()
@@ -279,6 +347,48 @@ func (s *XiaohongshuService) GetLoginBrowserScreenshot(ctx context.Context) ([]b
 	return page.Screenshot(false, nil)
 }
 
+// errNoOpenPage 浏览器未启动或没有打开的业务页面
+var errNoOpenPage = errors.New("没有打开的页面")
+
+// CapturePageScreenshot 截取当前页面：优先登录页，其次最近打开的业务页面。
+// 浏览器未启动时直接返回 errNoOpenPage，不会为了截图拉起浏览器。
+func (s *XiaohongshuService) CapturePageScreenshot(ctx context.Context) ([]byte, string, error) {
+	s.loginPageMu.RLock()
+	page := s.activeLoginPage
+	s.loginPageMu.RUnlock()
+
+	if page == nil {
+		s.browserMu.Lock()
+		b := s.sharedBrowser
+		s.browserMu.Unlock()
+		if b == nil {
+			return nil, "", errNoOpenPage
+		}
+		pages, err := b.OpenPages()
+		if err != nil {
+			return nil, "", err
+		}
+		if len(pages) == 0 {
+			return nil, "", errNoOpenPage
+		}
+		page = pages[len(pages)-1]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	page = page.Context(ctx)
+
+	var url string
+	if info, err := page.Info(); err == nil {
+		url = info.URL
+	}
+	img, err := page.Screenshot(false, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return img, url, nil
+}
+
 // ProcessLoginBrowserAction 处理浏览器交互动作
 func (s *XiaohongshuService) ProcessLoginBrowserAction(ctx context.Context, actionType string, x, y float64, text string) error {
 	s.loginPageMu.RLock()
@@ -304,6 +414,20 @@ func (s *XiaohongshuService) ProcessLoginBrowserAction(ctx context.Context, acti
 
 // PublishContent 发布内容
 func (s *XiaohongshuService) PublishContent(ctx context.Context, req *PublishRequest) (*PublishResponse, error) {
+	// 外部处理在占用浏览器之前完成，处理后的内容写入任务日志，崩溃重放时不会重复处理
+	if err := applyPublishHook(ctx, taskKindPublish, &req.Title, &req.Content, &req.Tags); err != nil {
+		return nil, err
+	}
+	if err := moderateContent(ctx, taskKindPublish, append([]string{req.Title, req.Content}, req.Tags...)...); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// 创建调试会话（无论是否打开 UI，均记录最近一次流程，便于排查发布失败原因）
 	sess := s.flowDebug.NewSession("publish_image")
 	dbgCtx := flowdebug.WithDebugger(ctx, sess)
@@ -322,6 +446,12 @@ func (s *XiaohongshuService) PublishContent(ctx context.Context, req *PublishReq
 		return nil, endErr
 	}
 
+	task := s.beginTask(taskKindPublish, req)
+	defer func() {
+		s.finishTask(task, req.Title, endErr)
+		activities.Add(taskKindPublish, req.Title, endErr)
+	}()
+
 	// 非浏览器准备阶段：这里保持直连，避免在代理有效期内消耗下载/校验时间
 	prepared, err := s.preparePublishContent(req, sess)
 	if err != nil {
@@ -366,7 +496,10 @@ func (s *XiaohongshuService) publishContent(ctx context.Context, content xiaohon
 		return err
 	}
 
-	page := b.NewPage()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return err
+	}
 	if sess != nil {
 		sess.AttachPage(page)
 	}
@@ -374,7 +507,7 @@ func (s *XiaohongshuService) publishContent(ctx context.Context, content xiaohon
 		if sess != nil {
 			sess.DetachPage()
 		}
-		_ = page.Close()
+		closePage()
 	}()
 
 	if sess != nil {
@@ -439,9 +572,18 @@ func (s *XiaohongshuService) preparePublishContent(req *PublishRequest, sess *Fl
 func (s *XiaohongshuService) preparePublishVideo(req *PublishVideoRequest, sess *FlowDebugSession) (*preparedPublishVideo, error) {
 	sess.Step("校验视频与发布时间", map[string]any{"direct_prepare": true})
 	if req.Video == "" {
-		return nil, fmt.Errorf("必须提供本地视频文件")
+		return nil, fmt.Errorf("必须提供本地视频文件或对象存储地址")
+	}
+	videoPath := req.Video
+	if objstore.IsObjectURL(videoPath) {
+		sess.Step("从对象存储下载视频", map[string]any{"url": videoPath})
+		local, err := objstore.NewDownloader(configs.GetImagesPath(), 10*time.Minute).Download(context.Background(), videoPath)
+		if err != nil {
+			return nil, fmt.Errorf("下载视频失败: %w", err)
+		}
+		videoPath = local
 	}
-	if _, err := os.Stat(req.Video); err != nil {
+	if _, err := os.Stat(videoPath); err != nil {
 		return nil, fmt.Errorf("视频文件不存在或不可访问: %v", err)
 	}
 
@@ -455,7 +597,7 @@ func (s *XiaohongshuService) preparePublishVideo(req *PublishVideoRequest, sess
 		Content:      req.Content,
 		Tags:         req.Tags,
 		Products:     req.Products,
-		VideoPath:    req.Video,
+		VideoPath:    videoPath,
 		ScheduleTime: scheduleTime,
 		Visibility:   req.Visibility,
 	}
@@ -478,7 +620,7 @@ func parseScheduleTime(scheduleAt string) (*time.Time, error) {
 		return nil, nil
 	}
 
-	t, err := time.Parse(time.RFC3339, scheduleAt)
+	t, err := parseLocalTime(scheduleAt, configs.GetLocation())
 	if err != nil {
 		return nil, fmt.Errorf("定时发布时间格式错误，请使用 ISO8601 格式: %v", err)
 	}
@@ -496,10 +638,27 @@ func parseScheduleTime(scheduleAt string) (*time.Time, error) {
 			t.Format("2006-01-02 15:04"), maxTime.Format("2006-01-02 15:04"))
 	}
 
-	logrus.Infof("设置定时发布时间: %s", t.Format("2006-01-02 15:04"))
+	logrus.Infof("设置定时发布时间: %s", t.Format("2006-01-02 15:04 MST"))
 	return &t, nil
 }
 
+// parseLocalTime 解析时间：带时区偏移的按偏移解析，不带的视为账号时区的当地时间；
+// 结果转换到账号时区，发布页按浏览器（同为账号时区）的当地时间填写
+func parseLocalTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), nil
+	}
+	var lastErr error
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
+
 // processImages 处理图片列表，支持URL下载和本地路径。
 // 这里固定使用直连，避免在代理有效期内消耗下载和本地预处理时间。
 func (s *XiaohongshuService) processImages(images []string) ([]string, error) {
@@ -509,6 +668,19 @@ func (s *XiaohongshuService) processImages(images []string) ([]string, error) {
 
 // PublishVideo 发布视频（本地文件）
 func (s *XiaohongshuService) PublishVideo(ctx context.Context, req *PublishVideoRequest) (*PublishVideoResponse, error) {
+	if err := applyPublishHook(ctx, taskKindPublishVideo, &req.Title, &req.Content, &req.Tags); err != nil {
+		return nil, err
+	}
+	if err := moderateContent(ctx, taskKindPublishVideo, append([]string{req.Title, req.Content}, req.Tags...)...); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	sess := s.flowDebug.NewSession("publish_video")
 	dbgCtx := flowdebug.WithDebugger(ctx, sess)
 	sess.Step("收到发布视频请求", map[string]any{
@@ -525,6 +697,12 @@ func (s *XiaohongshuService) PublishVideo(ctx context.Context, req *PublishVideo
 		return nil, endErr
 	}
 
+	task := s.beginTask(taskKindPublishVideo, req)
+	defer func() {
+		s.finishTask(task, req.Title, endErr)
+		activities.Add(taskKindPublishVideo, req.Title, endErr)
+	}()
+
 	prepared, err := s.preparePublishVideo(req, sess)
 	if err != nil {
 		endErr = err
@@ -567,7 +745,10 @@ func (s *XiaohongshuService) publishVideo(ctx context.Context, content xiaohongs
 		return err
 	}
 
-	page := b.NewPage()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return err
+	}
 	if sess != nil {
 		sess.AttachPage(page)
 	}
@@ -575,7 +756,7 @@ func (s *XiaohongshuService) publishVideo(ctx context.Context, content xiaohongs
 		if sess != nil {
 			sess.DetachPage()
 		}
-		_ = page.Close()
+		closePage()
 	}()
 
 	if sess != nil {
@@ -590,14 +771,43 @@ func (s *XiaohongshuService) publishVideo(ctx context.Context, content xiaohongs
 }
 
 // ListFeeds 获取Feeds列表
-func (s *XiaohongshuService) ListFeeds(ctx context.Context) (*FeedsListResponse, error) {
+func (s *XiaohongshuService) ListFeeds(ctx context.Context, req PageRequest) (*FeedsListResponse, error) {
+	page, err := s.paginateFeeds("list_feeds", req, func() ([]xiaohongshu.Feed, any, error) {
+		feeds, err := s.listFeeds(ctx)
+		return feeds, nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newFeedsListResponse(page), nil
+}
+
+func newFeedsListResponse(page *feedPage) *FeedsListResponse {
+	return &FeedsListResponse{
+		Feeds:   page.Feeds,
+		Count:   len(page.Feeds),
+		Cursor:  page.Cursor,
+		HasMore: page.HasMore,
+	}
+}
+
+func (s *XiaohongshuService) listFeeds(ctx context.Context) ([]xiaohongshu.Feed, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	// 创建 Feeds 列表 action
 	action := xiaohongshu.NewFeedsListAction(page)
@@ -609,36 +819,41 @@ func (s *XiaohongshuService) ListFeeds(ctx context.Context) (*FeedsListResponse,
 		return nil, err
 	}
 
-	response := &FeedsListResponse{
-		Feeds: feeds,
-		Count: len(feeds),
-	}
-
-	return response, nil
+	return feeds, nil
 }
 
-func (s *XiaohongshuService) SearchFeeds(ctx context.Context, keyword string, filters ...xiaohongshu.FilterOption) (*FeedsListResponse, error) {
-	b, err := s.getBrowser("")
+func (s *XiaohongshuService) SearchFeeds(ctx context.Context, keyword string, req PageRequest, filters ...xiaohongshu.FilterOption) (*FeedsListResponse, error) {
+	page, err := s.paginateFeeds("search_feeds", req, func() ([]xiaohongshu.Feed, any, error) {
+		feeds, err := s.searchFeeds(ctx, keyword, filters...)
+		return feeds, nil, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return newFeedsListResponse(page), nil
+}
 
-	page := b.NewPage()
-	defer page.Close()
-
-	action := xiaohongshu.NewSearchAction(page)
+func (s *XiaohongshuService) searchFeeds(ctx context.Context, keyword string, filters ...xiaohongshu.FilterOption) ([]xiaohongshu.Feed, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	feeds, err := action.Search(ctx, keyword, filters...)
+	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	response := &FeedsListResponse{
-		Feeds: feeds,
-		Count: len(feeds),
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
 	}
+	defer closePage()
 
-	return response, nil
+	action := xiaohongshu.NewSearchAction(page)
+
+	return action.Search(ctx, keyword, filters...)
 }
 
 // GetFeedDetail 获取Feed详情
@@ -648,13 +863,22 @@ func (s *XiaohongshuService) GetFeedDetail(ctx context.Context, feedID, xsecToke
 
 // GetFeedDetailWithConfig 使用配置获取Feed详情
 func (s *XiaohongshuService) GetFeedDetailWithConfig(ctx context.Context, feedID, xsecToken string, loadAllComments bool, config xiaohongshu.CommentLoadConfig) (*FeedDetailResponse, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	// 创建 Feed 详情 action
 	action := xiaohongshu.NewFeedDetailAction(page)
@@ -674,37 +898,62 @@ func (s *XiaohongshuService) GetFeedDetailWithConfig(ctx context.Context, feedID
 }
 
 // UserProfile 获取用户信息
-func (s *XiaohongshuService) UserProfile(ctx context.Context, userID, xsecToken string) (*UserProfileResponse, error) {
-	b, err := s.getBrowser("")
+func (s *XiaohongshuService) UserProfile(ctx context.Context, userID, xsecToken string, req PageRequest) (*UserProfileResponse, error) {
+	page, err := s.paginateFeeds("user_profile:"+userID, req, func() ([]xiaohongshu.Feed, any, error) {
+		result, err := s.userProfile(ctx, userID, xsecToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Feeds, result, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	result := page.Extra.(*xiaohongshu.UserProfileResponse)
+	return &UserProfileResponse{
+		UserBasicInfo: result.UserBasicInfo,
+		Interactions:  result.Interactions,
+		Feeds:         page.Feeds,
+		Cursor:        page.Cursor,
+		HasMore:       page.HasMore,
+	}, nil
+}
 
-	action := xiaohongshu.NewUserProfileAction(page)
+func (s *XiaohongshuService) userProfile(ctx context.Context, userID, xsecToken string) (*xiaohongshu.UserProfileResponse, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	result, err := action.UserProfile(ctx, userID, xsecToken)
+	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
-	response := &UserProfileResponse{
-		UserBasicInfo: result.UserBasicInfo,
-		Interactions:  result.Interactions,
-		Feeds:         result.Feeds,
+
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
 	}
+	defer closePage()
 
-	return response, nil
+	action := xiaohongshu.NewUserProfileAction(page)
 
+	return action.UserProfile(ctx, userID, xsecToken)
 }
 
 // GetNotificationMentions 获取当前登录账号的“评论和@”通知
 func (s *XiaohongshuService) GetNotificationMentions(ctx context.Context) (*NotificationMentionsResponse, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var result *xiaohongshu.NotificationMentionsData
-	var err error
 
-	err = s.withBrowserPage(func(page *rod.Page) error {
+	err = s.withBrowserPage(ctx, func(page *rod.Page) error {
 		action := xiaohongshu.NewNotificationMentionsAction(page)
 		result, err = action.GetMentions(ctx)
 		return err
@@ -722,15 +971,65 @@ func (s *XiaohongshuService) GetNotificationMentions(ctx context.Context) (*Noti
 	}, nil
 }
 
+// GetNotificationConnections 获取当前登录账号的“新增关注”通知
+func (s *XiaohongshuService) GetNotificationConnections(ctx context.Context) (*NotificationMentionsResponse, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var result *xiaohongshu.NotificationMentionsData
+
+	err = s.withBrowserPage(ctx, func(page *rod.Page) error {
+		action := xiaohongshu.NewNotificationConnectionsAction(page)
+		result, err = action.GetConnections(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationMentionsResponse{
+		Notifications:  result.MessageList,
+		Count:          len(result.MessageList),
+		Cursor:         result.Cursor,
+		HasMore:        result.HasMore,
+		SourceEndpoint: "https://edith.xiaohongshu.com/api/sns/web/v1/you/connections",
+	}, nil
+}
+
 // PostCommentToFeed 发表评论到Feed
-func (s *XiaohongshuService) PostCommentToFeed(ctx context.Context, feedID, xsecToken, content string) (*PostCommentResponse, error) {
+func (s *XiaohongshuService) PostCommentToFeed(ctx context.Context, feedID, xsecToken, content string) (_ *PostCommentResponse, err error) {
+	if content, err = applyCommentHook(ctx, taskKindComment, feedID, content); err != nil {
+		return nil, err
+	}
+	if err = moderateContent(ctx, taskKindComment, content); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	task := s.beginTask(taskKindComment, PostCommentRequest{FeedID: feedID, XsecToken: xsecToken, Content: content})
+	defer func() {
+		s.finishTask(task, "", err)
+		activities.Add(taskKindComment, feedID, err)
+	}()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewCommentFeedAction(page)
 
@@ -743,13 +1042,22 @@ func (s *XiaohongshuService) PostCommentToFeed(ctx context.Context, feedID, xsec
 
 // LikeFeed 点赞笔记
 func (s *XiaohongshuService) LikeFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewLikeAction(page)
 	if err := action.Like(ctx, feedID, xsecToken); err != nil {
@@ -760,13 +1068,22 @@ func (s *XiaohongshuService) LikeFeed(ctx context.Context, feedID, xsecToken str
 
 // UnlikeFeed 取消点赞笔记
 func (s *XiaohongshuService) UnlikeFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewLikeAction(page)
 	if err := action.Unlike(ctx, feedID, xsecToken); err != nil {
@@ -777,13 +1094,22 @@ func (s *XiaohongshuService) UnlikeFeed(ctx context.Context, feedID, xsecToken s
 
 // FavoriteFeed 收藏笔记
 func (s *XiaohongshuService) FavoriteFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewFavoriteAction(page)
 	if err := action.Favorite(ctx, feedID, xsecToken); err != nil {
@@ -794,13 +1120,22 @@ func (s *XiaohongshuService) FavoriteFeed(ctx context.Context, feedID, xsecToken
 
 // UnfavoriteFeed 取消收藏笔记
 func (s *XiaohongshuService) UnfavoriteFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewFavoriteAction(page)
 	if err := action.Unfavorite(ctx, feedID, xsecToken); err != nil {
@@ -810,14 +1145,36 @@ func (s *XiaohongshuService) UnfavoriteFeed(ctx context.Context, feedID, xsecTok
 }
 
 // ReplyCommentToFeed 回复指定评论
-func (s *XiaohongshuService) ReplyCommentToFeed(ctx context.Context, feedID, xsecToken, commentID, userID, content string) (*ReplyCommentResponse, error) {
+func (s *XiaohongshuService) ReplyCommentToFeed(ctx context.Context, feedID, xsecToken, commentID, userID, content string) (_ *ReplyCommentResponse, err error) {
+	if content, err = applyCommentHook(ctx, taskKindReply, feedID, content); err != nil {
+		return nil, err
+	}
+	if err = moderateContent(ctx, taskKindReply, content); err != nil {
+		return nil, err
+	}
+
+	release, err := s.acquireWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	task := s.beginTask(taskKindReply, ReplyCommentRequest{FeedID: feedID, XsecToken: xsecToken, CommentID: commentID, UserID: userID, Content: content})
+	defer func() {
+		s.finishTask(task, "", err)
+		activities.Add(taskKindReply, feedID, err)
+	}()
+
 	b, err := s.getBrowser("")
 	if err != nil {
 		return nil, err
 	}
 
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	defer closePage()
 
 	action := xiaohongshu.NewCommentFeedAction(page)
 
@@ -848,6 +1205,15 @@ func createBrowser(proxy string) (*browser.Browser, error) {
 	if userAgent := configs.GetUserAgent(); userAgent != "" {
 		opts = append(opts, browser.WithUserAgent(userAgent))
 	}
+	if dir := configs.GetRecordDir(); dir != "" {
+		opts = append(opts, browser.WithRecordDir(dir))
+	}
+	if dir := configs.GetReplayDir(); dir != "" {
+		opts = append(opts, browser.WithReplayDir(dir))
+	}
+	if loc := configs.GetLocation(); loc != time.Local {
+		opts = append(opts, browser.WithTimezone(loc.String()))
+	}
 	return browser.NewBrowser(configs.IsHeadless(), opts...)
 }
 
@@ -857,7 +1223,7 @@ func (s *XiaohongshuService) getBrowser(proxy string) (*browser.Browser, error)
 	defer s.browserMu.Unlock()
 
 	proxy = strings.TrimSpace(proxy)
-	if s.sharedBrowser != nil && s.sharedBrowserProxy != proxy {
+	if s.sharedBrowser != nil && (s.sharedBrowserProxy != proxy || s.browserStale) {
 		s.loginPageMu.Lock()
 		s.activeLoginPage = nil
 		s.loginPageMu.Unlock()
@@ -873,7 +1239,9 @@ func (s *XiaohongshuService) getBrowser(proxy string) (*browser.Browser, error)
 		}
 		s.sharedBrowser = b
 		s.sharedBrowserProxy = proxy
+		s.browserStale = false
 	}
+	s.browserLastUsed = time.Now()
 	return s.sharedBrowser, nil
 }
 
@@ -882,7 +1250,10 @@ func saveCookies(page *rod.Page) error {
 	if err != nil {
 		return err
 	}
+	return saveCookieList(cks)
+}
 
+func saveCookieList(cks []*proto.NetworkCookie) error {
 	data, err := json.Marshal(cks)
 	if err != nil {
 		return err
@@ -893,17 +1264,34 @@ func saveCookies(page *rod.Page) error {
 }
 
 // withBrowserPage 执行需要浏览器页面的操作的通用函数
-func (s *XiaohongshuService) withBrowserPage(fn func(*rod.Page) error) error {
+func (s *XiaohongshuService) withBrowserPage(ctx context.Context, fn func(*rod.Page) error) error {
 	b, err := s.getBrowser("")
 	if err != nil {
 		return err
 	}
-	page := b.NewPage()
-	defer page.Close()
+	page, closePage, err := openPage(ctx, b)
+	if err != nil {
+		return err
+	}
+	defer closePage()
 
 	return fn(page)
 }
 
+// openPage 打开新页面并绑定 ctx：客户端断开、超时或任务取消时，
+// 页面上的导航与元素等待会立即中止，而不是一直占着标签页。
+// 关闭时使用未绑定的原始页面，保证 ctx 取消后仍能释放标签页。
+func openPage(ctx context.Context, b *browser.Browser) (*rod.Page, func(), error) {
+	page, err := b.NewPage()
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.Context(ctx), func() {
+		noteCaptcha(page)
+		_ = page.Close()
+	}, nil
+}
+
 func (s *XiaohongshuService) resolveLoginPublishProxy(ctx context.Context) (string, error) {
 	return proxyutil.Resolve(ctx, configs.GetProxy(), configs.GetProxyPool())
 }
@@ -924,10 +1312,15 @@ func (s *XiaohongshuService) dropSharedBrowser() *browser.Browser {
 
 // GetMyProfile 获取当前登录用户的个人信息
 func (s *XiaohongshuService) GetMyProfile(ctx context.Context) (*UserProfileResponse, error) {
+	release, err := s.tabs.acquireRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var result *xiaohongshu.UserProfileResponse
-	var err error
 
-	err = s.withBrowserPage(func(page *rod.Page) error {
+	err = s.withBrowserPage(ctx, func(page *rod.Page) error {
 		action := xiaohongshu.NewUserProfileAction(page)
 		result, err = action.GetMyProfileViaSidebar(ctx)
 		return err
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+// cookieEditMu 串行化 cookies 文件的读改写
+var cookieEditMu sync.Mutex
+
+// ListCookies 返回当前 cookies；浏览器运行中时先把浏览器里的最新 cookies 落盘
+func (s *XiaohongshuService) ListCookies() ([]cookies.Cookie, error) {
+	cookieEditMu.Lock()
+	defer cookieEditMu.Unlock()
+
+	data, err := s.syncCookieFile()
+	if err != nil {
+		return nil, err
+	}
+	return cookies.Parse(data)
+}
+
+// UpsertCookie 新增或修改单条 cookie，同时应用到运行中的浏览器
+func (s *XiaohongshuService) UpsertCookie(c cookies.Cookie) error {
+	return s.editCookies(func(data []byte) ([]byte, error) {
+		return cookies.Upsert(data, c)
+	})
+}
+
+// RemoveCookie 删除匹配的 cookie，返回删除条数
+func (s *XiaohongshuService) RemoveCookie(name, domain, path string) (int, error) {
+	var removed int
+	err := s.editCookies(func(data []byte) ([]byte, error) {
+		out, n, err := cookies.Remove(data, name, domain, path)
+		removed = n
+		return out, err
+	})
+	return removed, err
+}
+
+// ImportCookies 批量导入 cookies，同名（name+domain+path）的替换，其余保留
+func (s *XiaohongshuService) ImportCookies(list []cookies.Cookie) error {
+	return s.editCookies(func(data []byte) ([]byte, error) {
+		var err error
+		for _, c := range list {
+			if data, err = cookies.Upsert(data, c); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	})
+}
+
+func (s *XiaohongshuService) editCookies(edit func([]byte) ([]byte, error)) error {
+	cookieEditMu.Lock()
+	defer cookieEditMu.Unlock()
+
+	data, err := s.syncCookieFile()
+	if err != nil {
+		return err
+	}
+	out, err := edit(data)
+	if err != nil {
+		return err
+	}
+	if err := cookies.NewLoadCookie(cookies.GetCookiesFilePath()).SaveCookies(out); err != nil {
+		return err
+	}
+
+	s.browserMu.Lock()
+	b := s.sharedBrowser
+	s.browserMu.Unlock()
+	if b != nil {
+		if err := b.ReplaceCookies(out); err != nil {
+			// 文件已更新，浏览器下次启动时会加载
+			logrus.Warnf("更新浏览器 cookies 失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// syncCookieFile 读取 cookies 文件，读取前先合并浏览器中的最新状态
+func (s *XiaohongshuService) syncCookieFile() ([]byte, error) {
+	if err := s.PersistCookies(); err != nil {
+		logrus.Warnf("同步浏览器 cookies 失败: %v", err)
+	}
+	data, err := cookies.NewLoadCookie(cookies.GetCookiesFilePath()).LoadCookies()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartIdleBrowserReaper 共享浏览器空闲超过 idle 后自动关闭，下次调用时懒加载重启（cookies 会从文件恢复）。
+// idle<=0 表示不启用。
+func (s *XiaohongshuService) StartIdleBrowserReaper(ctx context.Context, idle time.Duration) {
+	if idle <= 0 {
+		return
+	}
+
+	interval := idle / 4
+	if interval < 10*time.Second {
+		interval = 10 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.closeIdleBrowser(idle)
+			}
+		}
+	}()
+}
+
+// closeIdleBrowser 浏览器空闲且没有打开的业务页面时关闭
+func (s *XiaohongshuService) closeIdleBrowser(idle time.Duration) {
+	s.browserMu.Lock()
+	defer s.browserMu.Unlock()
+
+	b := s.sharedBrowser
+	if b == nil || time.Since(s.browserLastUsed) < idle {
+		return
+	}
+
+	// 仍有页面打开（如等待扫码、调试会话暂停）说明还在使用
+	if n, err := b.OpenPageCount(); err == nil && n > 0 {
+		return
+	}
+
+	if err := persistBrowserCookies(b); err != nil {
+		logrus.Warnf("空闲关闭浏览器前保存 cookies 失败: %v", err)
+	}
+
+	s.loginPageMu.Lock()
+	s.activeLoginPage = nil
+	s.loginPageMu.Unlock()
+
+	b.Close()
+	s.sharedBrowser = nil
+	s.sharedBrowserProxy = ""
+	logrus.Infof("浏览器空闲超过 %s，已自动关闭", idle)
+}
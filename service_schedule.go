@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/taskqueue"
+)
+
+// 定时任务检查间隔
+const scheduleTick = 30 * time.Second
+
+var errScheduleDisabled = errors.New("未配置运行状态目录，定时任务不可用")
+
+// CreateJobRequest 创建定时任务请求
+type CreateJobRequest struct {
+	Kind    string          `json:"kind" binding:"required"` // publish, publish_video, comment, reply
+	Payload json.RawMessage `json:"payload" binding:"required"`
+	RunAt   time.Time       `json:"run_at" binding:"required"`
+	Every   string          `json:"every,omitempty"` // 重复间隔，如 24h；为空只执行一次
+}
+
+// ListJobs 列出定时任务
+func (s *XiaohongshuService) ListJobs() ([]taskqueue.Job, error) {
+	if s.schedule == nil {
+		return nil, errScheduleDisabled
+	}
+	return s.schedule.List(), nil
+}
+
+// CreateJob 创建定时任务，payload 需能解析为对应写操作的请求体
+func (s *XiaohongshuService) CreateJob(req *CreateJobRequest) (taskqueue.Job, error) {
+	if s.schedule == nil {
+		return taskqueue.Job{}, errScheduleDisabled
+	}
+	// 定时任务都是写操作
+	if configs.IsReadOnly() {
+		return taskqueue.Job{}, errReadOnly
+	}
+	if err := validateJobPayload(req.Kind, req.Payload); err != nil {
+		return taskqueue.Job{}, err
+	}
+	var every time.Duration
+	if req.Every != "" {
+		d, err := time.ParseDuration(req.Every)
+		if err != nil || d <= 0 {
+			return taskqueue.Job{}, fmt.Errorf("every 取值非法: %q", req.Every)
+		}
+		every = d
+	}
+	return s.schedule.Add(req.Kind, req.Payload, req.RunAt, every)
+}
+
+// SetJobPaused 暂停/恢复定时任务
+func (s *XiaohongshuService) SetJobPaused(id string, paused bool) (taskqueue.Job, error) {
+	if s.schedule == nil {
+		return taskqueue.Job{}, errScheduleDisabled
+	}
+	return s.schedule.SetPaused(id, paused)
+}
+
+// DeleteJob 删除定时任务
+func (s *XiaohongshuService) DeleteJob(id string) error {
+	if s.schedule == nil {
+		return errScheduleDisabled
+	}
+	return s.schedule.Delete(id)
+}
+
+// validateJobPayload 校验任务类型与参数，避免到期后才发现无法执行
+func validateJobPayload(kind string, payload json.RawMessage) error {
+	var target any
+	switch kind {
+	case taskKindPublish:
+		target = &PublishRequest{}
+	case taskKindPublishVideo:
+		target = &PublishVideoRequest{}
+	case taskKindComment:
+		target = &PostCommentRequest{}
+	case taskKindReply:
+		target = &ReplyCommentRequest{}
+	default:
+		return fmt.Errorf("不支持的任务类型: %s", kind)
+	}
+	if err := json.Unmarshal(payload, target); err != nil {
+		return fmt.Errorf("payload 无法解析为 %s 请求: %w", kind, err)
+	}
+	return nil
+}
+
+// jobFinishedData job_finished 事件数据
+func jobFinishedData(id, kind string, err error) map[string]string {
+	data := map[string]string{"job_id": id, "kind": kind, "status": "ok", "error": ""}
+	if err != nil {
+		data["status"], data["error"] = "error", err.Error()
+	}
+	return data
+}
+
+// StartScheduler 定期执行到期的定时任务，执行复用任务重放的分发逻辑
+func (s *XiaohongshuService) StartScheduler(ctx context.Context) {
+	if s.schedule == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(scheduleTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runDueJobs(ctx)
+			}
+		}
+	}()
+}
+
+func (s *XiaohongshuService) runDueJobs(ctx context.Context) {
+	for _, j := range s.schedule.Due(time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		logrus.Infof("执行定时任务 %s（%s）", j.ID, j.Kind)
+		err := s.replayTask(ctx, taskqueue.Task{ID: j.ID, Kind: j.Kind, Payload: j.Payload})
+		if err != nil {
+			logrus.Errorf("定时任务 %s（%s）执行失败: %v", j.ID, j.Kind, err)
+			bus.Publish(eventJobFailed, fmt.Sprintf("定时任务 %s（%s）执行失败: %v", j.ID, j.Kind, err),
+				map[string]string{"job_id": j.ID, "kind": j.Kind, "error": err.Error()})
+		}
+		bus.Publish(eventJobFinished, fmt.Sprintf("定时任务 %s（%s）执行结束", j.ID, j.Kind), jobFinishedData(j.ID, j.Kind, err))
+		if err := s.schedule.Complete(j.ID, err, time.Now()); err != nil && !errors.Is(err, taskqueue.ErrJobNotFound) {
+			logrus.Warnf("更新定时任务 %s 失败: %v", j.ID, err)
+		}
+	}
+}
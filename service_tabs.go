@@ -0,0 +1,58 @@
+package main
+
+import "context"
+
+// tabGate 控制同一浏览器内的并发：只读操作可在多个标签页并行（有上限），
+// 写操作（发布、评论、点赞等）独占浏览器，避免互相干扰页面状态。
+// 等待期间可随 ctx 取消，避免已断开的请求一直排队占位。
+type tabGate struct {
+	writer chan struct{} // 写操作之间互斥
+	slots  chan struct{} // 只读名额；写操作需占满全部名额
+}
+
+func newTabGate(maxReadTabs int) *tabGate {
+	if maxReadTabs <= 0 {
+		maxReadTabs = 1
+	}
+	return &tabGate{
+		writer: make(chan struct{}, 1),
+		slots:  make(chan struct{}, maxReadTabs),
+	}
+}
+
+// acquireRead 获取只读名额
+func (g *tabGate) acquireRead(ctx context.Context) (func(), error) {
+	select {
+	case g.slots <- struct{}{}:
+		return func() { <-g.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquireWrite 获取独占名额
+func (g *tabGate) acquireWrite(ctx context.Context) (func(), error) {
+	select {
+	case g.writer <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	held := 0
+	release := func() {
+		for ; held > 0; held-- {
+			<-g.slots
+		}
+		<-g.writer
+	}
+	for held < cap(g.slots) {
+		select {
+		case g.slots <- struct{}{}:
+			held++
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
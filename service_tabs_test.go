@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTabGateWriteWaitsForReadsAndHonorsCancel(t *testing.T) {
+	g := newTabGate(2)
+
+	releaseRead, err := g.acquireRead(context.Background())
+	if err != nil {
+		t.Fatalf("acquireRead: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := g.acquireWrite(ctx); err == nil {
+		t.Fatalf("存在读操作时写操作应等待直至取消")
+	}
+
+	// 取消后不应残留占位：释放读名额后写操作可立即获取
+	releaseRead()
+	releaseWrite, err := g.acquireWrite(context.Background())
+	if err != nil {
+		t.Fatalf("acquireWrite: %v", err)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel2()
+	if _, err := g.acquireRead(ctx2); err == nil {
+		t.Fatalf("写操作进行中读操作应等待")
+	}
+	releaseWrite()
+
+	if _, err := g.acquireRead(context.Background()); err != nil {
+		t.Fatalf("写操作释放后应能获取读名额: %v", err)
+	}
+}
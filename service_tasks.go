@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/taskqueue"
+)
+
+// 持久化任务类型
+const (
+	taskKindPublish      = "publish"
+	taskKindPublishVideo = "publish_video"
+	taskKindComment      = "comment"
+	taskKindReply        = "reply"
+)
+
+// OpenTaskJournal 打开任务日志、发布历史与定时任务表，stateDir 为空时不启用持久化
+func (s *XiaohongshuService) OpenTaskJournal(stateDir string) error {
+	if stateDir == "" {
+		return nil
+	}
+	journal, err := taskqueue.Open(filepath.Join(stateDir, "tasks.jsonl"))
+	if err != nil {
+		return err
+	}
+	history, err := taskqueue.OpenHistory(filepath.Join(stateDir, "publish_history.jsonl"))
+	if err != nil {
+		_ = journal.Close()
+		return err
+	}
+	schedule, err := taskqueue.OpenSchedule(filepath.Join(stateDir, "jobs.json"))
+	if err != nil {
+		_ = journal.Close()
+		return err
+	}
+	schedule.SetLocation(configs.GetLocation())
+	s.tasks = journal
+	s.history = history
+	s.schedule = schedule
+	return nil
+}
+
+// beginTask 执行写操作前先落盘；日志不可用时仅记录警告，不阻断操作
+func (s *XiaohongshuService) beginTask(kind string, payload any) *taskqueue.Task {
+	if s.tasks == nil {
+		return nil
+	}
+	t, err := s.tasks.Begin(kind, payload)
+	if err != nil {
+		logrus.Warnf("任务持久化失败（%s）: %v", kind, err)
+		return nil
+	}
+	return t
+}
+
+// finishTask 结束任务，成功时写入发布历史供重放去重
+func (s *XiaohongshuService) finishTask(t *taskqueue.Task, title string, execErr error) {
+	if t == nil {
+		return
+	}
+	if execErr == nil && s.history != nil {
+		if err := s.history.Add(taskqueue.HistoryRecord{Key: t.Key, Kind: t.Kind, TaskID: t.ID, Title: title}); err != nil {
+			logrus.Warnf("写入发布历史失败: %v", err)
+		}
+	}
+	if err := s.tasks.Finish(t.ID, execErr); err != nil {
+		logrus.Warnf("更新任务状态失败: %v", err)
+	}
+}
+
+// ReplayPendingTasks 重放上次进程退出时未完成的任务；已在发布历史中的任务直接跳过
+func (s *XiaohongshuService) ReplayPendingTasks(ctx context.Context) {
+	if s.tasks == nil {
+		return
+	}
+	pending := s.tasks.Pending()
+	if len(pending) == 0 {
+		return
+	}
+	logrus.Infof("发现 %d 个未完成的任务，开始重放", len(pending))
+	ctx = withoutContentHooks(ctx)
+
+	for _, t := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if s.history != nil && s.history.Has(t.Key) {
+			logrus.Infof("任务 %s（%s）已执行过，跳过", t.ID, t.Kind)
+			_ = s.tasks.Mark(t.ID, taskqueue.StatusSkipped, "")
+			continue
+		}
+
+		// 先标记旧任务，重放时会登记新任务，避免再次崩溃后重复重放
+		_ = s.tasks.Mark(t.ID, taskqueue.StatusReplayed, "")
+		if err := s.replayTask(ctx, t); err != nil {
+			logrus.Errorf("重放任务 %s（%s）失败: %v", t.ID, t.Kind, err)
+			continue
+		}
+		logrus.Infof("重放任务 %s（%s）成功", t.ID, t.Kind)
+	}
+}
+
+func (s *XiaohongshuService) replayTask(ctx context.Context, t taskqueue.Task) error {
+	switch t.Kind {
+	case taskKindPublish:
+		var req PublishRequest
+		if err := json.Unmarshal(t.Payload, &req); err != nil {
+			return err
+		}
+		_, err := s.PublishContent(ctx, &req)
+		return err
+	case taskKindPublishVideo:
+		var req PublishVideoRequest
+		if err := json.Unmarshal(t.Payload, &req); err != nil {
+			return err
+		}
+		_, err := s.PublishVideo(ctx, &req)
+		return err
+	case taskKindComment:
+		var req PostCommentRequest
+		if err := json.Unmarshal(t.Payload, &req); err != nil {
+			return err
+		}
+		_, err := s.PostCommentToFeed(ctx, req.FeedID, req.XsecToken, req.Content)
+		return err
+	case taskKindReply:
+		var req ReplyCommentRequest
+		if err := json.Unmarshal(t.Payload, &req); err != nil {
+			return err
+		}
+		_, err := s.ReplyCommentToFeed(ctx, req.FeedID, req.XsecToken, req.CommentID, req.UserID, req.Content)
+		return err
+	default:
+		return fmt.Errorf("未知任务类型: %s", t.Kind)
+	}
+}
@@ -91,3 +91,27 @@ func TestExplainPublishError(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLocalTime(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*3600)
+
+	got, err := parseLocalTime("2026-03-10 09:30", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)) {
+		t.Fatalf("不带偏移的时间应按账号时区解析，got=%s", got)
+	}
+
+	got, err = parseLocalTime("2026-03-10T09:30:00+08:00", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Location() != loc || got.Hour() != 20 || got.Day() != 9 {
+		t.Fatalf("带偏移的时间应转换到账号时区，got=%s", got)
+	}
+
+	if _, err := parseLocalTime("明天上午", loc); err == nil {
+		t.Fatal("无法识别的格式应报错")
+	}
+}
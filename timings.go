@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// toolTimings 按 MCP 工具覆盖的超时配置
+var toolTimings = map[string]xiaohongshu.Timings{}
+
+// parseToolTimings 解析按工具覆盖的超时配置，未覆盖的字段沿用 base。
+// 格式：工具名:key=value,key=value;工具名:...
+// 例如：publish_content:navigate=90s,settle=1.5;search_feeds:element=5s
+func parseToolTimings(raw string, base xiaohongshu.Timings) (map[string]xiaohongshu.Timings, error) {
+	out := map[string]xiaohongshu.Timings{}
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, kvs, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("工具超时配置格式错误: %q", part)
+		}
+
+		t := base
+		for _, kv := range strings.Split(kvs, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				return nil, fmt.Errorf("工具 %s 的配置项格式错误: %q", name, kv)
+			}
+			if err := applyTimingField(&t, strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("工具 %s: %w", name, err)
+			}
+		}
+		out[name] = t
+	}
+	return out, nil
+}
+
+// applyTimingField 设置单个配置项
+func applyTimingField(t *xiaohongshu.Timings, key, value string) error {
+	switch key {
+	case "navigate", "element", "lookup", "backoff":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s 取值非法: %q", key, value)
+		}
+		switch key {
+		case "navigate":
+			t.Navigate = d
+		case "element":
+			t.Element = d
+		case "lookup":
+			t.Lookup = d
+		default:
+			t.Backoff = d
+		}
+	case "settle":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f <= 0 {
+			return fmt.Errorf("settle 取值非法: %q", value)
+		}
+		t.Settle = f
+	case "attempts":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("attempts 取值非法: %q", value)
+		}
+		t.Attempts = n
+	default:
+		return fmt.Errorf("未知配置项: %s", key)
+	}
+	return nil
+}
+
+// withToolTimings 如果该工具配置了覆盖值，则写入 context
+func withToolTimings(ctx context.Context, toolName string) context.Context {
+	if t, ok := toolTimings[toolName]; ok {
+		return xiaohongshu.WithTimings(ctx, t)
+	}
+	return ctx
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+func TestParseToolTimings(t *testing.T) {
+	base := xiaohongshu.DefaultTimings()
+
+	got, err := parseToolTimings("publish_content:navigate=90s,settle=1.5; search_feeds:element=5s,attempts=5", base)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	publish := got["publish_content"]
+	if publish.Navigate != 90*time.Second || publish.Settle != 1.5 || publish.Element != base.Element {
+		t.Fatalf("publish_content 配置不符合预期: %+v", publish)
+	}
+	search := got["search_feeds"]
+	if search.Element != 5*time.Second || search.Attempts != 5 || search.Navigate != base.Navigate {
+		t.Fatalf("search_feeds 配置不符合预期: %+v", search)
+	}
+
+	for _, raw := range []string{"publish_content", "publish_content:timeout=1s", "search_feeds:settle=-1", "search_feeds:attempts=0"} {
+		if _, err := parseToolTimings(raw, base); err == nil {
+			t.Fatalf("非法配置应返回错误: %q", raw)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+// 面向 n8n、Zapier 等低代码平台的触发器。
+//
+// 轮询：GET /api/v1/triggers/:name 直接返回扁平对象数组（新的在前），每项带唯一 id，平台按 id 去重；
+// 推送：POST /api/v1/triggers/:name/subscribe {"target_url":"..."} 订阅（REST Hook），事件发生时 POST 同样的扁平对象，
+// 目标返回 410 时自动取消订阅。订阅在配置了运行状态目录时持久化到 triggers.json。
+const (
+	triggerDefaultLimit = 50
+	triggerPushTimeout  = 10 * time.Second
+)
+
+// triggerDef 触发器定义
+type triggerDef struct {
+	Name        string         `json:"name"`
+	Event       string         `json:"event"`
+	Description string         `json:"description"`
+	Sample      map[string]any `json:"sample"`
+}
+
+var triggerDefs = []triggerDef{
+	{
+		Name: "new_comment", Event: eventCommentReceived,
+		Description: "收到新的评论或@，需要启用 -watch-mentions",
+		Sample: map[string]any{
+			"id": "65f0c1d2000000001203abcd", "trigger": "new_comment", "occurred_at": "2026-01-02T15:04:05+08:00",
+			"summary": "小王 评论了你的笔记: 好看", "type": "comment/comment_on_my_note", "title": "评论了你的笔记",
+			"user_id": "5f1a...", "nickname": "小王", "avatar": "https://sns-avatar.xhscdn.com/...",
+			"content": "好看", "comment_id": "65f0c1d2000000001203ef01", "note_id": "65efab12000000001203aaaa", "xsec_token": "AB...",
+		},
+	},
+	{
+		Name: "new_follower", Event: eventNewFollower,
+		Description: "新增粉丝，需要启用 -watch-followers",
+		Sample: map[string]any{
+			"id": "65f0c1d2000000001203bcde", "trigger": "new_follower", "occurred_at": "2026-01-02T15:04:05+08:00",
+			"summary": "小李 关注了你", "type": "follow", "title": "开始关注你了",
+			"user_id": "5f1b...", "nickname": "小李", "avatar": "https://sns-avatar.xhscdn.com/...",
+		},
+	},
+	{
+		Name: "job_finished", Event: eventJobFinished,
+		Description: "定时任务执行结束，status 为 ok 或 error",
+		Sample: map[string]any{
+			"id": "1767337445-42", "trigger": "job_finished", "occurred_at": "2026-01-02T15:04:05+08:00",
+			"summary": "定时任务 job-1（publish）执行结束", "job_id": "job-1", "kind": "publish", "status": "ok", "error": "",
+		},
+	},
+}
+
+func findTrigger(name string) (triggerDef, bool) {
+	for _, d := range triggerDefs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return triggerDef{}, false
+}
+
+// triggerEnabled 触发器的数据来源是否已启用
+func triggerEnabled(d triggerDef) bool {
+	switch d.Event {
+	case eventCommentReceived:
+		return configs.GetMentionWatchInterval() > 0
+	case eventNewFollower:
+		return configs.GetFollowerWatchInterval() > 0
+	}
+	return true
+}
+
+// triggerPayload 把事件展开为扁平对象，字段与 triggerDefs 中的示例一致
+func triggerPayload(d triggerDef, ev eventbus.Event) map[string]any {
+	p := map[string]any{
+		"id":          ev.ID,
+		"trigger":     d.Name,
+		"occurred_at": ev.At.Format(time.RFC3339),
+		"summary":     ev.Summary,
+	}
+	switch data := ev.Data.(type) {
+	case MentionEvent:
+		m := data.Mention
+		// 通知 ID 重启后不变，平台去重更可靠
+		p["id"] = data.ID
+		p["type"] = m.Type
+		p["title"] = m.Title
+		p["user_id"] = firstNonEmpty(mapString(m.UserInfo, "userid"), mapString(m.UserInfo, "user_id"))
+		p["nickname"] = mapString(m.UserInfo, "nickname")
+		p["avatar"] = mapString(m.UserInfo, "image")
+		if d.Event == eventCommentReceived {
+			p["content"] = mapString(m.CommentInfo, "content")
+			p["comment_id"] = mapString(m.CommentInfo, "id")
+			p["note_id"] = mapString(m.ItemInfo, "id")
+			p["xsec_token"] = mapString(m.ItemInfo, "xsec_token")
+		}
+	case map[string]string:
+		for k, v := range data {
+			p[k] = v
+		}
+	}
+	return p
+}
+
+// TriggerSubscription 推送订阅
+type TriggerSubscription struct {
+	ID        string    `json:"id"`
+	Trigger   string    `json:"trigger"`
+	TargetURL string    `json:"target_url"`
+	CreatedAt time.Time `json:"created_at"`
+
+	unsubscribe func()
+}
+
+// triggerHub 管理推送订阅
+type triggerHub struct {
+	mu      sync.Mutex
+	path    string // 为空时只保存在内存中
+	subs    map[string]*TriggerSubscription
+	started bool
+	client  *http.Client
+}
+
+// openTriggerHub 加载已保存的订阅
+func openTriggerHub(stateDir string) (*triggerHub, error) {
+	h := &triggerHub{subs: map[string]*TriggerSubscription{}}
+	if stateDir == "" {
+		return h, nil
+	}
+	h.path = filepath.Join(stateDir, "triggers.json")
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取触发器订阅失败: %w", err)
+	}
+	var list []*TriggerSubscription
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("解析触发器订阅失败: %w", err)
+	}
+	for _, sub := range list {
+		if _, ok := findTrigger(sub.Trigger); ok {
+			h.subs[sub.ID] = sub
+		}
+	}
+	return h, nil
+}
+
+// start 把已有订阅注册到事件总线，之后新增的订阅立即注册
+func (h *triggerHub) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = true
+	for _, sub := range h.subs {
+		h.attachLocked(sub)
+	}
+}
+
+func (h *triggerHub) attachLocked(sub *TriggerSubscription) {
+	d, _ := findTrigger(sub.Trigger)
+	sub.unsubscribe = bus.Subscribe("trigger-"+sub.ID, eventbus.SinkFunc(func(ctx context.Context, ev eventbus.Event) error {
+		return h.push(ctx, sub, triggerPayload(d, ev))
+	}), d.Event)
+}
+
+// push 推送一条事件，目标返回 410 Gone 时取消订阅
+func (h *triggerHub) push(ctx context.Context, sub *TriggerSubscription, payload map[string]any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, triggerPushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		logrus.Infof("触发器订阅 %s 的目标已失效（410），自动取消订阅", sub.ID)
+		go h.remove(sub.ID)
+		return nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (h *triggerHub) add(trigger, target string) (*TriggerSubscription, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	sub := &TriggerSubscription{ID: hex.EncodeToString(buf), Trigger: trigger, TargetURL: target, CreatedAt: time.Now()}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[sub.ID] = sub
+	if err := h.saveLocked(); err != nil {
+		delete(h.subs, sub.ID)
+		return nil, err
+	}
+	if h.started {
+		h.attachLocked(sub)
+	}
+	return sub, nil
+}
+
+func (h *triggerHub) remove(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub, ok := h.subs[id]
+	if !ok {
+		return false
+	}
+	delete(h.subs, id)
+	if sub.unsubscribe != nil {
+		sub.unsubscribe()
+	}
+	if err := h.saveLocked(); err != nil {
+		logrus.Warnf("保存触发器订阅失败: %v", err)
+	}
+	return true
+}
+
+func (h *triggerHub) list() []*TriggerSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]*TriggerSubscription, 0, len(h.subs))
+	for _, sub := range h.subs {
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (h *triggerHub) saveLocked() error {
+	if h.path == "" {
+		return nil
+	}
+	list := make([]*TriggerSubscription, 0, len(h.subs))
+	for _, sub := range h.subs {
+		list = append(list, sub)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// listTriggersHandler 可用触发器、示例数据与当前推送订阅
+// GET /api/v1/triggers
+func (s *AppServer) listTriggersHandler(c *gin.Context) {
+	type triggerView struct {
+		triggerDef
+		Enabled bool `json:"enabled"`
+	}
+	views := make([]triggerView, 0, len(triggerDefs))
+	for _, d := range triggerDefs {
+		views = append(views, triggerView{triggerDef: d, Enabled: triggerEnabled(d)})
+	}
+	respondSuccess(c, gin.H{"triggers": views, "subscriptions": s.triggers.list()}, "获取触发器成功")
+}
+
+// pollTriggerHandler 轮询触发器，返回扁平对象数组（新的在前），?limit=N
+// GET /api/v1/triggers/:name
+func (s *AppServer) pollTriggerHandler(c *gin.Context) {
+	d, ok := findTrigger(c.Param("name"))
+	if !ok {
+		respondError(c, http.StatusNotFound, "TRIGGER_NOT_FOUND", "触发器不存在", nil)
+		return
+	}
+	if !triggerEnabled(d) {
+		respondError(c, http.StatusConflict, "TRIGGER_DISABLED", "触发器的数据来源未启用: "+d.Description, nil)
+		return
+	}
+	limit := triggerDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(c, http.StatusBadRequest, "INVALID_LIMIT", "limit 需为正整数", nil)
+			return
+		}
+		limit = n
+	}
+	items := []map[string]any{}
+	for _, ev := range bus.Recent([]string{d.Event}, limit) {
+		items = append(items, triggerPayload(d, ev))
+	}
+	// 低代码平台的轮询触发器要求顶层直接是数组，这里不使用统一的响应包装
+	c.JSON(http.StatusOK, items)
+}
+
+// subscribeTriggerHandler 订阅推送
+// POST /api/v1/triggers/:name/subscribe {"target_url":"https://..."}
+func (s *AppServer) subscribeTriggerHandler(c *gin.Context) {
+	d, ok := findTrigger(c.Param("name"))
+	if !ok {
+		respondError(c, http.StatusNotFound, "TRIGGER_NOT_FOUND", "触发器不存在", nil)
+		return
+	}
+	var req struct {
+		TargetURL string `json:"target_url" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST", "请求参数错误", err.Error())
+		return
+	}
+	if u, err := url.Parse(req.TargetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondError(c, http.StatusBadRequest, "INVALID_TARGET_URL", "target_url 需为 http(s) 地址", nil)
+		return
+	}
+	sub, err := s.triggers.add(d.Name, req.TargetURL)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "SUBSCRIBE_FAILED", "保存订阅失败", err.Error())
+		return
+	}
+	respondSuccess(c, sub, "订阅成功")
+}
+
+// unsubscribeTriggerHandler 取消推送订阅
+// DELETE /api/v1/triggers/subscriptions/:sid
+func (s *AppServer) unsubscribeTriggerHandler(c *gin.Context) {
+	if !s.triggers.remove(c.Param("sid")) {
+		respondError(c, http.StatusNotFound, "SUBSCRIPTION_NOT_FOUND", "订阅不存在", nil)
+		return
+	}
+	respondSuccess(c, nil, "已取消订阅")
+}
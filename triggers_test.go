@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/eventbus"
+)
+
+func TestTriggerPayload(t *testing.T) {
+	d, ok := findTrigger("new_comment")
+	require.True(t, ok)
+	p := triggerPayload(d, eventbus.Event{ID: "1-1", At: time.Now(), Summary: "s", Data: commentEvent("m1", "小王", "好看")})
+	require.Equal(t, "m1", p["id"])
+	require.Equal(t, "小王", p["nickname"])
+	require.Equal(t, "好看", p["content"])
+	require.Equal(t, "note1", p["note_id"])
+	// 示例数据与实际字段保持一致
+	for k := range p {
+		require.Contains(t, d.Sample, k)
+	}
+
+	d, _ = findTrigger("job_finished")
+	p = triggerPayload(d, eventbus.Event{ID: "1-2", At: time.Now(), Data: jobFinishedData("j1", "publish", nil)})
+	require.Equal(t, "1-2", p["id"])
+	require.Equal(t, "ok", p["status"])
+	for k := range d.Sample {
+		require.Contains(t, p, k)
+	}
+}
+
+func TestTriggerHubPersistAndGone(t *testing.T) {
+	dir := t.TempDir()
+	h, err := openTriggerHub(dir)
+	require.NoError(t, err)
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	sub, err := h.add("job_finished", srv.URL)
+	require.NoError(t, err)
+
+	reloaded, err := openTriggerHub(dir)
+	require.NoError(t, err)
+	require.Len(t, reloaded.list(), 1)
+	require.Equal(t, srv.URL, reloaded.list()[0].TargetURL)
+
+	// 目标返回 410 时自动取消订阅
+	require.NoError(t, h.push(context.Background(), sub, map[string]any{"id": "x"}))
+	require.Equal(t, "x", got["id"])
+	require.Eventually(t, func() bool { return len(h.list()) == 0 }, time.Second, 10*time.Millisecond)
+
+	reloaded, err = openTriggerHub(dir)
+	require.NoError(t, err)
+	require.Empty(t, reloaded.list())
+}
@@ -57,6 +57,7 @@ type FeedDetailRequest struct {
 type SearchFeedsRequest struct {
 	Keyword string                   `json:"keyword" binding:"required"`
 	Filters xiaohongshu.FilterOption `json:"filters,omitempty"`
+	PageRequest
 }
 
 // FeedDetailResponse Feed详情响应
@@ -115,6 +116,7 @@ type FavoriteFeedRequest struct {
 type UserProfileRequest struct {
 	UserID    string `json:"user_id" binding:"required"`
 	XsecToken string `json:"xsec_token" binding:"required"`
+	PageRequest
 }
 
 // ActionResult 通用动作响应（点赞/收藏等）
@@ -0,0 +1,9 @@
+package main
+
+import "github.com/xpzouying/xiaohongshu-mcp/pkg/compat"
+
+// buildVersion 返回构建版本：发布构建为模块版本，本地构建附带 VCS 修订号
+func buildVersion() string {
+	version, _ := compat.Build()
+	return version
+}
@@ -0,0 +1,331 @@
+package xiaohongshu
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// ExtractMode 读操作的数据提取方式
+type ExtractMode string
+
+const (
+	// ExtractHybrid 优先读取页面自身发起的 Web API 响应（已带签名与登录态），失败回退 DOM/状态对象
+	ExtractHybrid ExtractMode = "hybrid"
+	// ExtractDOM 只从页面状态对象/DOM 提取（历史行为）
+	ExtractDOM ExtractMode = "dom"
+)
+
+var (
+	extractModeMu sync.RWMutex
+	extractMode   = ExtractHybrid
+)
+
+// SetExtractMode 设置读操作提取方式，非法值回退为 hybrid
+func SetExtractMode(mode string) {
+	m := ExtractMode(strings.ToLower(strings.TrimSpace(mode)))
+	if m != ExtractDOM {
+		m = ExtractHybrid
+	}
+	extractModeMu.Lock()
+	extractMode = m
+	extractModeMu.Unlock()
+}
+
+// GetExtractMode 获取读操作提取方式
+func GetExtractMode() ExtractMode {
+	extractModeMu.RLock()
+	defer extractModeMu.RUnlock()
+	return extractMode
+}
+
+// apiCapture 监听页面发起的 XHR/Fetch 请求，按顺序保存匹配接口的响应体。
+// 需在导航前创建，签名由页面脚本自行计算，这里只读取结果。
+type apiCapture struct {
+	path   string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+// 单次操作最多保留的响应数，避免长时间滚动加载占用过多内存
+const maxCapturedBodies = 200
+
+// captureAPI 开始监听路径包含 path 的接口响应；hybrid 模式关闭时返回 nil
+func captureAPI(page *rod.Page, path string) *apiCapture {
+	if GetExtractMode() != ExtractHybrid {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(page.GetContext())
+	c := &apiCapture{
+		path:   path,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	pending := map[proto.NetworkRequestID]bool{}
+	wait := page.Context(ctx).EachEvent(
+		func(e *proto.NetworkResponseReceived) {
+			if e.Type != proto.NetworkResourceTypeXHR && e.Type != proto.NetworkResourceTypeFetch {
+				return
+			}
+			if strings.Contains(e.Response.URL, path) && e.Response.Status == 200 {
+				pending[e.RequestID] = true
+			}
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			if !pending[e.RequestID] {
+				return
+			}
+			delete(pending, e.RequestID)
+
+			body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(page)
+			if err != nil {
+				logrus.Debugf("读取接口响应失败 %s: %v", path, err)
+				return
+			}
+			data := []byte(body.Body)
+			if body.Base64Encoded {
+				if data, err = base64.StdEncoding.DecodeString(body.Body); err != nil {
+					return
+				}
+			}
+			c.mu.Lock()
+			if len(c.bodies) < maxCapturedBodies {
+				c.bodies = append(c.bodies, data)
+			} else {
+				c.bodies[len(c.bodies)-1] = data
+			}
+			c.mu.Unlock()
+		},
+	)
+	go func() {
+		defer close(c.done)
+		wait()
+	}()
+	return c
+}
+
+// Latest 等待至少一次响应（最多 timeout），返回最近一次响应体
+func (c *apiCapture) Latest(timeout time.Duration) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		c.mu.Lock()
+		n := len(c.bodies)
+		var latest []byte
+		if n > 0 {
+			latest = c.bodies[n-1]
+		}
+		c.mu.Unlock()
+		if latest != nil {
+			return latest, true
+		}
+		if time.Now().After(deadline) {
+			return nil, false
+		}
+		select {
+		case <-c.done:
+			return nil, false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Reset 丢弃已捕获的响应，用于筛选等会重新请求接口的操作之前
+func (c *apiCapture) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.bodies = nil
+	c.mu.Unlock()
+}
+
+// All 返回按时间顺序捕获到的全部响应
+func (c *apiCapture) All() [][]byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.bodies...)
+}
+
+// Stop 停止监听
+func (c *apiCapture) Stop() {
+	if c == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// apiEnvelope 小红书 Web API 通用响应结构
+type apiEnvelope struct {
+	Code    int             `json:"code"`
+	Success bool            `json:"success"`
+	Msg     string          `json:"msg"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// decodeAPIData 校验响应状态并返回 data，键名统一转换为与页面状态对象一致的驼峰形式
+func decodeAPIData(body []byte) ([]byte, error) {
+	var env apiEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, err
+	}
+	if !env.Success || env.Code != 0 {
+		return nil, fmt.Errorf("接口返回失败: code=%d msg=%s", env.Code, env.Msg)
+	}
+	return camelizeJSON(env.Data)
+}
+
+// apiFeedsPage 列表类接口（搜索、推荐）的 data 结构
+type apiFeedsPage struct {
+	Items   []Feed `json:"items"`
+	HasMore bool   `json:"hasMore"`
+	Cursor  string `json:"cursorScore"`
+}
+
+// parseAPIFeeds 解析列表类接口，过滤掉非笔记条目（推荐词、热搜等）
+func parseAPIFeeds(body []byte) ([]Feed, error) {
+	data, err := decodeAPIData(body)
+	if err != nil {
+		return nil, err
+	}
+	var page apiFeedsPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, err
+	}
+	feeds := make([]Feed, 0, len(page.Items))
+	for _, item := range page.Items {
+		if item.ModelType != "" && item.ModelType != "note" {
+			continue
+		}
+		if item.ID == "" {
+			continue
+		}
+		feeds = append(feeds, item)
+	}
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("接口响应中没有笔记")
+	}
+	return feeds, nil
+}
+
+// camelizeJSON 递归把 snake_case 键转换为 camelCase
+func camelizeJSON(raw []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(camelizeValue(v))
+}
+
+func camelizeValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			out[snakeToCamel(k)] = camelizeValue(val)
+		}
+		return out
+	case []any:
+		for i := range t {
+			t[i] = camelizeValue(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, p := range parts[1:] {
+		if p == "" {
+			continue
+		}
+		r := []rune(p)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// feedsFromCapture 尝试从捕获的接口响应中解析笔记列表，失败时返回 false 由调用方回退
+func feedsFromCapture(c *apiCapture, wait time.Duration, what string) ([]Feed, bool) {
+	body, ok := c.Latest(wait)
+	if !ok {
+		return nil, false
+	}
+	feeds, err := parseAPIFeeds(body)
+	if err != nil {
+		logrus.Debugf("%s接口响应不可用，回退页面状态提取: %v", what, err)
+		return nil, false
+	}
+	logrus.Debugf("%s使用接口响应，共 %d 条", what, len(feeds))
+	return feeds, true
+}
+
+// apiCommentsPage 评论分页接口的 data 结构
+type apiCommentsPage struct {
+	Comments []Comment `json:"comments"`
+	Cursor   string    `json:"cursor"`
+	HasMore  bool      `json:"hasMore"`
+}
+
+// mergeAPIComments 用滚动加载过程中捕获的评论分页补全状态对象中的评论，
+// 按 ID 去重并保持加载顺序；接口不可用时原样返回
+func mergeAPIComments(list CommentList, bodies [][]byte) CommentList {
+	seen := make(map[string]bool, len(list.List))
+	for _, c := range list.List {
+		seen[c.ID] = true
+	}
+	added := 0
+	for _, body := range bodies {
+		data, err := decodeAPIData(body)
+		if err != nil {
+			logrus.Debugf("评论接口响应不可用: %v", err)
+			continue
+		}
+		var page apiCommentsPage
+		if err := json.Unmarshal(data, &page); err != nil {
+			continue
+		}
+		for _, c := range page.Comments {
+			if c.ID == "" || seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			list.List = append(list.List, c)
+			added++
+		}
+		list.Cursor = page.Cursor
+		list.HasMore = page.HasMore
+	}
+	if added > 0 {
+		logrus.Debugf("从评论接口补充 %d 条评论", added)
+	}
+	return list
+}
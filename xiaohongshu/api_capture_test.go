@@ -0,0 +1,42 @@
+package xiaohongshu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIFeeds(t *testing.T) {
+	body := []byte(`{"code":0,"success":true,"data":{"has_more":true,"items":[
+		{"id":"n1","model_type":"note","xsec_token":"tok1","note_card":{"type":"normal","display_title":"标题",
+			"user":{"user_id":"u1","nick_name":"小红"},"interact_info":{"liked_count":"12"}}},
+		{"id":"q1","model_type":"rec_query"}
+	]}}`)
+
+	feeds, err := parseAPIFeeds(body)
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	require.Equal(t, "tok1", feeds[0].XsecToken)
+	require.Equal(t, "标题", feeds[0].NoteCard.DisplayTitle)
+	require.Equal(t, "u1", feeds[0].NoteCard.User.UserID)
+	require.Equal(t, "小红", feeds[0].NoteCard.User.NickName)
+	require.Equal(t, "12", feeds[0].NoteCard.InteractInfo.LikedCount)
+
+	_, err = parseAPIFeeds([]byte(`{"code":-100,"success":false,"msg":"登录已过期"}`))
+	require.Error(t, err)
+}
+
+func TestMergeAPIComments(t *testing.T) {
+	state := CommentList{List: []Comment{{ID: "c1"}}, HasMore: true}
+	bodies := [][]byte{
+		[]byte(`{"code":0,"success":true,"data":{"cursor":"c2","has_more":true,"comments":[
+			{"id":"c1"},{"id":"c2","content":"第二条","user_info":{"user_id":"u2"},"sub_comment_count":"1"}]}}`),
+		[]byte(`{"code":0,"success":true,"data":{"cursor":"","has_more":false,"comments":[{"id":"c3"}]}}`),
+	}
+
+	merged := mergeAPIComments(state, bodies)
+	require.Len(t, merged.List, 3)
+	require.Equal(t, "u2", merged.List[1].UserInfo.UserID)
+	require.Equal(t, "1", merged.List[1].SubCommentCount)
+	require.False(t, merged.HasMore)
+}
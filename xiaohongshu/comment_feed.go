@@ -35,14 +35,14 @@ func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken,
 	if err := page.WaitDOMStable(time.Second, 0); err != nil {
 		return err
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 检测页面是否可访问
 	if err := checkPageAccessible(page); err != nil {
 		return err
 	}
 
-	elem, err := page.Element("div.input-box div.content-edit span")
+	elem, err := findElement(page, Selectors("comment.input_trigger")...)
 	if err != nil {
 		logrus.Warnf("Failed to find comment input box: %v", err)
 		return fmt.Errorf("未找到评论输入框，该帖子可能不支持评论或网页端不可访问: %w", err)
@@ -53,7 +53,7 @@ func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken,
 		return fmt.Errorf("无法点击评论输入框: %w", err)
 	}
 
-	elem2, err := page.Element("div.input-box div.content-edit p.content-input")
+	elem2, err := findElement(page, Selectors("comment.input_field")...)
 	if err != nil {
 		logrus.Warnf("Failed to find comment input field: %v", err)
 		return fmt.Errorf("未找到评论输入区域: %w", err)
@@ -64,9 +64,9 @@ func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken,
 		return fmt.Errorf("无法输入评论内容: %w", err)
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
-	submitButton, err := page.Element("div.bottom button.submit")
+	submitButton, err := findElement(page, Selectors("comment.submit")...)
 	if err != nil {
 		logrus.Warnf("Failed to find submit button: %v", err)
 		return fmt.Errorf("未找到提交按钮: %w", err)
@@ -77,7 +77,7 @@ func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken,
 		return fmt.Errorf("无法点击提交按钮: %w", err)
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	logrus.Infof("Comment posted successfully to feed: %s", feedID)
 	return nil
@@ -98,7 +98,7 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 	if err := page.WaitDOMStable(time.Second, 0); err != nil {
 		return err
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 检测页面是否可访问
 	if err := checkPageAccessible(page); err != nil {
@@ -106,7 +106,7 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 	}
 
 	// 等待评论容器加载
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 
 	// 使用 Go 实现的查找逻辑
 	commentEl, err := findCommentElement(page, commentID, userID)
@@ -119,12 +119,12 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 	if err := commentEl.ScrollIntoView(); err != nil {
 		return fmt.Errorf("scroll into view failed: %w", err)
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	logrus.Info("准备点击回复按钮")
 
 	// 查找并点击回复按钮
-	replyBtn, err := commentEl.Element(".right .interactions .reply")
+	replyBtn, err := commentEl.Element(sel("comment.reply_button"))
 	if err != nil {
 		return fmt.Errorf("无法找到回复按钮: %w", err)
 	}
@@ -133,10 +133,10 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 		return fmt.Errorf("点击回复按钮失败: %w", err)
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 查找回复输入框
-	inputEl, err := page.Element("div.input-box div.content-edit p.content-input")
+	inputEl, err := findElement(page, Selectors("comment.input_field")...)
 	if err != nil {
 		return fmt.Errorf("无法找到回复输入框: %w", err)
 	}
@@ -146,10 +146,10 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 		return fmt.Errorf("输入回复内容失败: %w", err)
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	// 查找并点击提交按钮
-	submitBtn, err := page.Element("div.bottom button.submit")
+	submitBtn, err := findElement(page, Selectors("comment.submit")...)
 	if err != nil {
 		return fmt.Errorf("无法找到提交按钮: %w", err)
 	}
@@ -158,7 +158,7 @@ func (f *CommentFeedAction) ReplyToComment(ctx context.Context, feedID, xsecToke
 		return fmt.Errorf("点击提交按钮失败: %w", err)
 	}
 
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 	logrus.Infof("回复评论成功")
 	return nil
 }
@@ -172,7 +172,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 
 	// 先滚动到评论区
 	scrollToCommentsArea(page)
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	var lastCommentCount = 0
 	stagnantChecks := 0
@@ -223,7 +223,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 			clicked, skipped := clickShowMoreButtonsSmart(page, 0)
 			if clicked > 0 || skipped > 0 {
 				logrus.Infof("为匹配 commentID 预展开回复：点击 %d 个，跳过 %d 个", clicked, skipped)
-				time.Sleep(500 * time.Millisecond)
+				settle(page, 500*time.Millisecond)
 
 				if el := findCommentElementByID(page, commentID); el != nil {
 					logrus.Infof("✓ 展开子回复后找到评论: %s (尝试 %d 次)", commentID, attempt+1)
@@ -237,7 +237,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 			logrus.Infof("滚动到最后一个评论（共 %d 条）", currentCount)
 
 			// 使用 Go 获取所有评论元素
-			elements, err := page.Timeout(2 * time.Second).Elements(".parent-comment, .comment-item, .comment")
+			elements, err := page.Timeout(elementWait(page)).Elements(sel("comment.item"))
 			if err == nil && len(elements) > 0 {
 				// 滚动到最后一个评论
 				lastComment := elements[len(elements)-1]
@@ -248,7 +248,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 			} else {
 				logrus.Warnf("未找到评论元素: %v", err)
 			}
-			time.Sleep(300 * time.Millisecond)
+			settle(page, 300*time.Millisecond)
 		}
 
 		// === 5. 继续向下滚动 ===
@@ -257,7 +257,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 		if err != nil {
 			logrus.Warnf("滚动失败: %v", err)
 		}
-		time.Sleep(500 * time.Millisecond)
+		settle(page, 500*time.Millisecond)
 
 		// === 6. 滚动后立即查找（边滚动边查找）===
 		// 优先通过 commentID 查找（使用 Timeout 避免长时间等待）
@@ -277,7 +277,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 			logrus.Infof("尝试通过 userID 查找: %s", userID)
 
 			// 使用 Timeout 避免长时间等待
-			elements, err := page.Timeout(2 * time.Second).Elements(".comment-item, .comment, .parent-comment")
+			elements, err := page.Timeout(elementWait(page)).Elements(sel("comment.item"))
 			if err == nil && len(elements) > 0 {
 				logrus.Infof("找到 %d 个评论元素", len(elements))
 				for i, el := range elements {
@@ -305,7 +305,7 @@ func findCommentElement(page *rod.Page, commentID, userID string) (*rod.Element,
 
 func findCommentElementByID(page *rod.Page, commentID string) *rod.Element {
 	selector := fmt.Sprintf("#comment-%s", commentID)
-	el, err := page.Timeout(2 * time.Second).Element(selector)
+	el, err := page.Timeout(elementWait(page)).Element(selector)
 	if err != nil || el == nil {
 		return nil
 	}
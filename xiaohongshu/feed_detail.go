@@ -86,6 +86,10 @@ func (f *FeedDetailAction) GetFeedDetailWithConfig(ctx context.Context, feedID,
 	logrus.Infof("配置: 点击更多=%v, 回复阈值=%d, 最大评论数=%d, 滚动速度=%s",
 		config.ClickMoreReplies, config.MaxRepliesThreshold, config.MaxCommentItems, config.ScrollSpeed)
 
+	// 滚动加载评论时页面会请求评论分页接口，捕获后用于补全状态对象
+	capture := captureAPI(page, "/api/sns/web/v2/comment/page")
+	defer capture.Stop()
+
 	// 使用retry-go处理页面导航和DOM稳定等待
 	err = retry.Do(
 		func() error {
@@ -120,7 +124,14 @@ func (f *FeedDetailAction) GetFeedDetailWithConfig(ctx context.Context, feedID,
 		}
 	}
 
-	return f.extractFeedDetail(page, feedID)
+	resp, err = f.extractFeedDetail(page, feedID)
+	if err != nil {
+		return nil, err
+	}
+	if bodies := capture.All(); len(bodies) > 0 {
+		resp.Comments = mergeAPIComments(resp.Comments, bodies)
+	}
+	return resp, nil
 }
 
 // ========== 评论加载器 ==========
@@ -355,7 +366,7 @@ func getScrollInterval(speed string) time.Duration {
 // ========== 按钮点击 ==========
 
 func clickShowMoreButtonsSmart(page *rod.Page, maxRepliesThreshold int) (clicked, skipped int) {
-	elements, err := page.Elements(".show-more")
+	elements, err := page.Elements(sel("feed.show_more"))
 	if err != nil {
 		return 0, 0
 	}
@@ -424,11 +435,13 @@ func clickElementWithHumanBehavior(page *rod.Page, el *rod.Element, text string)
 	err := retry.Do(
 		func() error {
 			// 滚动到元素
-			el.MustEval(`() => {
+			if _, err := el.Eval(`() => {
 				try {
 					this.scrollIntoView({behavior: 'smooth', block: 'center'});
 				} catch (e) {}
-			}`)
+			}`); err != nil {
+				return err
+			}
 
 			sleepRandom(reactionTimeRange.min, reactionTimeRange.max)
 
@@ -436,7 +449,9 @@ func clickElementWithHumanBehavior(page *rod.Page, el *rod.Element, text string)
 			if box, err := el.Shape(); err == nil && len(box.Quads) > 0 {
 				x := float64(box.Quads[0][0]+box.Quads[0][4]) / 2
 				y := float64(box.Quads[0][1]+box.Quads[0][5]) / 2
-				page.Mouse.MustMoveTo(x, y)
+				if err := page.Mouse.MoveTo(proto.Point{X: x, Y: y}); err != nil {
+					return err
+				}
 				sleepRandom(hoverTimeRange.min, hoverTimeRange.max)
 			}
 
@@ -474,7 +489,12 @@ func clickElementWithHumanBehavior(page *rod.Page, el *rod.Element, text string)
 
 func humanScroll(page *rod.Page, speed string, largeMode bool, pushCount int) (bool, int, int) {
 	beforeTop := getScrollTop(page)
-	viewportHeight := page.MustEval(`() => window.innerHeight`).Int()
+	viewportHeight := 800 // 获取失败时的兜底视口高度
+	if res, err := page.Eval(`() => window.innerHeight`); err == nil {
+		viewportHeight = res.Value.Int()
+	} else {
+		logrus.Debugf("获取视口高度失败: %v", err)
+	}
 
 	baseRatio := getScrollRatio(speed)
 	if largeMode {
@@ -487,7 +507,9 @@ func humanScroll(page *rod.Page, speed string, largeMode bool, pushCount int) (b
 
 	for i := 0; i < max(1, pushCount); i++ {
 		scrollDelta := calculateScrollDelta(viewportHeight, baseRatio)
-		page.MustEval(`(delta) => { window.scrollBy(0, delta); }`, scrollDelta)
+		if _, err := page.Eval(`(delta) => { window.scrollBy(0, delta); }`, scrollDelta); err != nil {
+			logrus.Debugf("滚动失败: %v", err)
+		}
 
 		sleepRandom(scrollWaitRange.min, scrollWaitRange.max)
 
@@ -507,7 +529,9 @@ func humanScroll(page *rod.Page, speed string, largeMode bool, pushCount int) (b
 	}
 
 	if !scrolled && pushCount > 0 {
-		page.MustEval(`() => window.scrollTo(0, document.body.scrollHeight)`)
+		if _, err := page.Eval(`() => window.scrollTo(0, document.body.scrollHeight)`); err != nil {
+			logrus.Debugf("滚动到底部失败: %v", err)
+		}
 		sleepRandom(postScrollRange.min, postScrollRange.max)
 		currentScrollTop = getScrollTop(page)
 		actualDelta = currentScrollTop - beforeTop + actualDelta
@@ -545,11 +569,13 @@ func scrollToCommentsArea(page *rod.Page) {
 	logrus.Info("滚动到评论区...")
 
 	// 先定位到评论区
-	if el, err := page.Timeout(2 * time.Second).Element(".comments-container"); err == nil {
-		el.MustScrollIntoView()
+	if el, err := page.Timeout(elementWait(page)).Element(sel("feed.comments_container")); err == nil {
+		if err := el.ScrollIntoView(); err != nil {
+			logrus.Warnf("滚动到评论区失败: %v", err)
+		}
 	}
 	// 等待滚动完成
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	// 触发一次小滚动，激活懒加载机制
 	smartScroll(page, 100)
@@ -557,11 +583,16 @@ func scrollToCommentsArea(page *rod.Page) {
 
 // smartScroll 智能滚动：触发滚轮事件以正确触发懒加载
 func smartScroll(page *rod.Page, delta float64) {
-	page.MustEval(`(delta) => {
-		// 查找滚动目标元素
-		let targetElement = document.querySelector('.note-scroller') 
-			|| document.querySelector('.interaction-container') 
-			|| document.documentElement;
+	_, err := page.Eval(`(delta, selectors) => {
+		// 查找滚动目标元素，按候选顺序尝试
+		let targetElement = document.documentElement;
+		for (const s of selectors) {
+			const el = document.querySelector(s);
+			if (el) {
+				targetElement = el;
+				break;
+			}
+		}
 		
 		// 触发滚轮事件（关键！这样才能触发懒加载）
 		const wheelEvent = new WheelEvent('wheel', {
@@ -572,18 +603,23 @@ func smartScroll(page *rod.Page, delta float64) {
 			view: window
 		});
 		targetElement.dispatchEvent(wheelEvent);
-	}`, delta)
+	}`, delta, Selectors("feed.scroll_target"))
+	if err != nil {
+		logrus.Debugf("触发滚轮事件失败: %v", err)
+	}
 }
 
 func scrollToLastComment(page *rod.Page) {
 	// 获取所有主评论元素
-	elements, err := page.Timeout(2 * time.Second).Elements(".parent-comment")
+	elements, err := page.Timeout(elementWait(page)).Elements(sel("feed.parent_comment"))
 	if err != nil || len(elements) == 0 {
 		return
 	}
 	// 滚动到最后一个评论
 	lastComment := elements[len(elements)-1]
-	lastComment.MustScrollIntoView()
+	if err := lastComment.ScrollIntoView(); err != nil {
+		logrus.Debugf("滚动到最后一条评论失败: %v", err)
+	}
 }
 
 // ========== DOM 查询 ==========
@@ -594,11 +630,14 @@ func getScrollTop(page *rod.Page) int {
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			evalResult := page.MustEval(`() => {
+			evalResult, err := page.Eval(`() => {
 				return window.pageYOffset || document.documentElement.scrollTop || document.body.scrollTop || 0;
 			}`)
+			if err != nil {
+				return err
+			}
 
-			result = evalResult.Int()
+			result = evalResult.Value.Int()
 			return nil
 		},
 		retry.Attempts(3),
@@ -623,13 +662,16 @@ func getCommentCount(page *rod.Page) int {
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			evalResult := page.MustEval(`() => {
-				const container = document.querySelector('.comments-container');
+			evalResult, err := page.Eval(`(containerSel, commentSel) => {
+				const container = document.querySelector(containerSel);
 				if (!container) return 0;
-				return container.querySelectorAll('.parent-comment').length;
-			}`)
+				return container.querySelectorAll(commentSel).length;
+			}`, sel("feed.comments_container"), sel("feed.parent_comment"))
+			if err != nil {
+				return err
+			}
 
-			result = evalResult.Int()
+			result = evalResult.Value.Int()
 			return nil
 		},
 		retry.Attempts(3),
@@ -654,17 +696,20 @@ func getTotalCommentCount(page *rod.Page) int {
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			evalResult := page.MustEval(`() => {
-				const container = document.querySelector('.comments-container');
+			evalResult, err := page.Eval(`(containerSel, totalSel) => {
+				const container = document.querySelector(containerSel);
 				if (!container) return 0;
-				const totalEl = container.querySelector('.total');
+				const totalEl = container.querySelector(totalSel);
 				if (!totalEl) return 0;
 				const text = (totalEl.textContent || '').replace(/\s+/g, '');
 				const match = text.match(/共(\d+)条评论/);
 				return match ? parseInt(match[1], 10) : 0;
-			}`)
+			}`, sel("feed.comments_container"), sel("feed.comment_total"))
+			if err != nil {
+				return err
+			}
 
-			result = evalResult.Int()
+			result = evalResult.Value.Int()
 			return nil
 		},
 		retry.Attempts(3),
@@ -685,7 +730,7 @@ func getTotalCommentCount(page *rod.Page) int {
 
 func checkNoCommentsArea(page *rod.Page) bool {
 	// 查找无评论区域
-	noCommentsEl, err := page.Timeout(2 * time.Second).Element(".no-comments-text")
+	noCommentsEl, err := page.Timeout(elementWait(page)).Element(sel("feed.no_comments"))
 	if err != nil {
 		// 未找到无评论元素，说明有评论或评论区正常
 		return false
@@ -708,14 +753,17 @@ func checkEndContainer(page *rod.Page) bool {
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			evalResult := page.MustEval(`() => {
-				const endContainer = document.querySelector('.end-container');
+			evalResult, err := page.Eval(`(endSel) => {
+				const endContainer = document.querySelector(endSel);
 				if (!endContainer) return false;
 				const text = (endContainer.textContent || '').trim().toUpperCase();
 				return text.includes('THE END') || text.includes('THEEND');
-			}`)
+			}`, sel("feed.end_container"))
+			if err != nil {
+				return err
+			}
 
-			result = evalResult.Bool()
+			result = evalResult.Value.Bool()
 			return nil
 		},
 		retry.Attempts(3),
@@ -737,13 +785,13 @@ func checkEndContainer(page *rod.Page) bool {
 // ========== 页面检查 ==========
 
 func checkPageAccessible(page *rod.Page) error {
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			result := page.MustEval(`() => {
-				const wrapper = document.querySelector('.access-wrapper, .error-wrapper, .not-found-wrapper, .blocked-wrapper');
+			result, err := page.Eval(`(wrapperSel) => {
+				const wrapper = document.querySelector(wrapperSel);
 				if (!wrapper) return null;
 				
 				const text = wrapper.textContent || wrapper.innerText || '';
@@ -770,9 +818,12 @@ func checkPageAccessible(page *rod.Page) error {
 					return '未知错误: ' + text.trim();
 				}
 				return null;
-			}`)
+			}`, sel("feed.access_error"))
+			if err != nil {
+				return err
+			}
 
-			rawJSON, marshalErr := result.MarshalJSON()
+			rawJSON, marshalErr := result.Value.MarshalJSON()
 			if marshalErr != nil {
 				return fmt.Errorf("无法序列化页面状态检查结果: %w", marshalErr)
 			}
@@ -816,7 +867,7 @@ func (f *FeedDetailAction) extractFeedDetail(page *rod.Page, feedID string) (*Fe
 	// 使用retry-go来处理可能的DOM查询失败
 	err := retry.Do(
 		func() error {
-			evalResult := page.MustEval(`() => {
+			evalResult, err := page.Eval(`() => {
 				if (window.__INITIAL_STATE__ &&
 					window.__INITIAL_STATE__.note &&
 					window.__INITIAL_STATE__.note.noteDetailMap) {
@@ -824,10 +875,13 @@ func (f *FeedDetailAction) extractFeedDetail(page *rod.Page, feedID string) (*Fe
 					return JSON.stringify(noteDetailMap);
 				}
 				return "";
-			}`).String()
+			}`)
+			if err != nil {
+				return err
+			}
 
-			if evalResult != "" {
-				result = evalResult
+			if evalResult.Value.String() != "" {
+				result = evalResult.Value.String()
 				return nil
 			}
 			return fmt.Errorf("无法获取初始状态数据")
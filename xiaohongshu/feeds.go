@@ -26,6 +26,10 @@ func (f *FeedsListAction) GetFeedsList(ctx context.Context) (feeds []Feed, err e
 
 	page := f.page.Context(ctx)
 
+	// 首页首屏通常由服务端渲染，接口仅在部分情况下触发；未捕获时回退状态对象
+	capture := captureAPI(page, "/api/sns/web/v1/homefeed")
+	defer capture.Stop()
+
 	if err = navigateWithRetry(page, "https://www.xiaohongshu.com", 3); err != nil {
 		return nil, err
 	}
@@ -59,7 +63,11 @@ func (f *FeedsListAction) GetFeedsList(ctx context.Context) (feeds []Feed, err e
 		});
 	}`))
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
+
+	if apiFeeds, ok := feedsFromCapture(capture, 0, "首页推荐"); ok {
+		return apiFeeds, nil
+	}
 
 	evalResult, err := page.Eval(`() => {
 		const state = window.__INITIAL_STATE__ || window.__INITIAL_SSR_STATE__ || window.__UNIVERSAL_STATE__;
@@ -18,7 +18,8 @@ func TestGetFeedsList(t *testing.T) {
 	require.NoError(t, err)
 	defer b.Close()
 
-	page := b.NewPage()
+	page, err := b.NewPage()
+	require.NoError(t, err)
 	defer page.Close()
 
 	// NewFeedsListAction 内部已经处理导航
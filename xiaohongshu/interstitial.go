@@ -0,0 +1,140 @@
+package xiaohongshu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+)
+
+// 反爬拦截页：导航后落到滑块验证或“网络异常”提示页时，先自动恢复（冷却、重新注入 cookies、重试一次），
+// 仍然失败才返回 ErrCaptchaRequired，由调用方提示人工处理。
+
+// ErrCaptchaRequired 自动恢复失败，需要人工完成验证
+var ErrCaptchaRequired = errors.New("CAPTCHA_REQUIRED: 页面触发安全验证，自动恢复失败，需要人工处理")
+
+// 拦截页类型
+const (
+	InterstitialCaptcha = "captcha" // 滑块/安全验证
+	InterstitialNetwork = "network" // 网络异常提示
+)
+
+// InterstitialError 拦截页导致的失败
+type InterstitialError struct {
+	Kind string
+	URL  string
+}
+
+func (e *InterstitialError) Error() string {
+	return fmt.Sprintf("%s（%s: %s）", ErrCaptchaRequired.Error(), e.Kind, e.URL)
+}
+
+func (e *InterstitialError) Unwrap() error { return ErrCaptchaRequired }
+
+// InterstitialRecovery 拦截页自动恢复配置
+type InterstitialRecovery struct {
+	Cooldown       time.Duration              // 重试前等待，<= 0 表示不自动恢复
+	RefreshCookies func(page *rod.Page) error // 重试前重新注入已保存的 cookies，可为空
+	OnGiveUp       func(kind, url string)     // 恢复失败后回调（如重启浏览器切换代理），可为空
+}
+
+// DefaultInterstitialCooldown 默认冷却时长
+const DefaultInterstitialCooldown = 8 * time.Second
+
+var (
+	recoveryMu sync.RWMutex
+	recovery   = InterstitialRecovery{Cooldown: DefaultInterstitialCooldown}
+)
+
+// SetInterstitialRecovery 设置拦截页自动恢复配置
+func SetInterstitialRecovery(r InterstitialRecovery) {
+	recoveryMu.Lock()
+	recovery = r
+	recoveryMu.Unlock()
+}
+
+func getInterstitialRecovery() InterstitialRecovery {
+	recoveryMu.RLock()
+	defer recoveryMu.RUnlock()
+	return recovery
+}
+
+// IsCaptchaURL 页面被重定向到验证码/安全验证页
+func IsCaptchaURL(u string) bool {
+	return strings.Contains(u, "/website-login/captcha") || strings.Contains(u, "/web-login/captcha")
+}
+
+// interstitialTextMax 拦截页正文很短，超过该长度的页面不按文本判断，避免正常笔记内容误判
+const interstitialTextMax = 600
+
+// classifyInterstitialText 根据页面正文判断拦截页类型，非拦截页返回空
+func classifyInterstitialText(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > interstitialTextMax {
+		return ""
+	}
+	switch {
+	case strings.Contains(text, "滑块") || strings.Contains(text, "安全验证") || strings.Contains(text, "请完成验证"):
+		return InterstitialCaptcha
+	case strings.Contains(text, "网络异常") || strings.Contains(text, "网络环境异常") || strings.Contains(text, "访问频繁"):
+		return InterstitialNetwork
+	}
+	return ""
+}
+
+// detectInterstitial 检查页面是否停留在拦截页，返回类型与当前地址
+func detectInterstitial(page *rod.Page) (kind, url string) {
+	p := page.Timeout(3 * time.Second)
+	info, err := p.Info()
+	if err != nil {
+		return "", ""
+	}
+	if IsCaptchaURL(info.URL) {
+		return InterstitialCaptcha, info.URL
+	}
+	res, err := p.Eval(`() => (document.body && document.body.innerText) || ""`)
+	if err != nil {
+		return "", info.URL
+	}
+	return classifyInterstitialText(res.Value.Str()), info.URL
+}
+
+// recoverInterstitial 导航落到拦截页后的恢复流程：冷却、重新注入 cookies、重新导航一次
+func recoverInterstitial(page *rod.Page, targetURL, kind, landed string) error {
+	r := getInterstitialRecovery()
+	fail := func(kind, landed string) error {
+		logrus.Warnf("拦截页自动恢复失败（%s）: %s", kind, landed)
+		if r.OnGiveUp != nil {
+			r.OnGiveUp(kind, landed)
+		}
+		return &InterstitialError{Kind: kind, URL: landed}
+	}
+	if r.Cooldown <= 0 {
+		return fail(kind, landed)
+	}
+
+	logrus.Warnf("导航落到拦截页（%s）: %s，%s 后重试", kind, landed, r.Cooldown)
+	select {
+	case <-page.GetContext().Done():
+		return page.GetContext().Err()
+	case <-time.After(r.Cooldown):
+	}
+	if r.RefreshCookies != nil {
+		if err := r.RefreshCookies(page); err != nil {
+			logrus.Warnf("重新注入 cookies 失败: %v", err)
+		}
+	}
+	if err := page.Timeout(pageTimings(page).Navigate).Navigate(targetURL); err != nil {
+		return err
+	}
+	_ = page.Timeout(5 * time.Second).WaitLoad()
+	if kind, landed := detectInterstitial(page); kind != "" {
+		return fail(kind, landed)
+	}
+	logrus.Infof("拦截页自动恢复成功: %s", targetURL)
+	return nil
+}
@@ -0,0 +1,24 @@
+package xiaohongshu
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyInterstitialText(t *testing.T) {
+	require.Equal(t, InterstitialCaptcha, classifyInterstitialText("安全验证\n请拖动下方滑块完成拼图"))
+	require.Equal(t, InterstitialNetwork, classifyInterstitialText("网络异常，请稍后重试"))
+	require.Empty(t, classifyInterstitialText("发现 推荐 穿搭 美食"))
+	// 正文较长的正常页面即使提到“网络异常”也不算拦截页
+	require.Empty(t, classifyInterstitialText(strings.Repeat("笔记正文", 200)+"网络异常"))
+}
+
+func TestInterstitialErrorIsCaptchaRequired(t *testing.T) {
+	var err error = &InterstitialError{Kind: InterstitialNetwork, URL: "https://www.xiaohongshu.com/explore"}
+	require.True(t, errors.Is(err, ErrCaptchaRequired))
+	require.Contains(t, err.Error(), "CAPTCHA_REQUIRED")
+	require.True(t, IsCaptchaURL("https://www.xiaohongshu.com/website-login/captcha?redirectPath=x"))
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	myerrors "github.com/xpzouying/xiaohongshu-mcp/errors"
@@ -20,12 +19,6 @@ type ActionResult struct {
 	Message string `json:"message"`
 }
 
-// 选择器常量
-const (
-	SelectorLikeButton    = ".interact-container .left .like-lottie"
-	SelectorCollectButton = ".interact-container .left .reds-icon.collect-icon"
-)
-
 // interactActionType 交互动作类型
 type interactActionType string
 
@@ -55,17 +48,13 @@ func (a *interactAction) preparePage(ctx context.Context, actionType interactAct
 	if err := page.WaitDOMStable(time.Second, 0); err != nil {
 		return nil, err
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	return page, nil
 }
 
-func (a *interactAction) performClick(page *rod.Page, selector string) error {
-	element, err := page.Element(selector)
-	if err != nil {
-		return err
-	}
-	return element.Click(proto.InputMouseButtonLeft, 1)
+func (a *interactAction) performClick(page *rod.Page, selectorKey string) error {
+	return clickElement(page, Selectors(selectorKey)...)
 }
 
 // LikeAction 负责处理点赞相关交互
@@ -117,10 +106,10 @@ func (a *LikeAction) perform(ctx context.Context, feedID, xsecToken string, targ
 }
 
 func (a *LikeAction) toggleLike(page *rod.Page, feedID string, targetLiked bool, actionType interactActionType) error {
-	if err := a.performClick(page, SelectorLikeButton); err != nil {
+	if err := a.performClick(page, "interact.like_button"); err != nil {
 		return err
 	}
-	time.Sleep(3 * time.Second)
+	settle(page, 3*time.Second)
 
 	liked, _, err := a.getInteractState(page, feedID)
 	if err != nil {
@@ -133,10 +122,10 @@ func (a *LikeAction) toggleLike(page *rod.Page, feedID string, targetLiked bool,
 	}
 
 	logrus.Warnf("feed %s %s可能未成功，状态未变化，尝试再次点击", feedID, actionType)
-	if err := a.performClick(page, SelectorLikeButton); err != nil {
+	if err := a.performClick(page, "interact.like_button"); err != nil {
 		return err
 	}
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 
 	liked, _, err = a.getInteractState(page, feedID)
 	if err != nil {
@@ -200,10 +189,10 @@ func (a *FavoriteAction) perform(ctx context.Context, feedID, xsecToken string,
 }
 
 func (a *FavoriteAction) toggleFavorite(page *rod.Page, feedID string, targetCollected bool, actionType interactActionType) error {
-	if err := a.performClick(page, SelectorCollectButton); err != nil {
+	if err := a.performClick(page, "interact.collect_button"); err != nil {
 		return err
 	}
-	time.Sleep(3 * time.Second)
+	settle(page, 3*time.Second)
 
 	_, collected, err := a.getInteractState(page, feedID)
 	if err != nil {
@@ -216,10 +205,10 @@ func (a *FavoriteAction) toggleFavorite(page *rod.Page, feedID string, targetCol
 	}
 
 	logrus.Warnf("feed %s %s可能未成功，状态未变化，尝试再次点击", feedID, actionType)
-	if err := a.performClick(page, SelectorCollectButton); err != nil {
+	if err := a.performClick(page, "interact.collect_button"); err != nil {
 		return err
 	}
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 
 	_, collected, err = a.getInteractState(page, feedID)
 	if err != nil {
@@ -25,9 +25,9 @@ func (a *LoginAction) CheckLoginStatus(ctx context.Context) (bool, error) {
 		return false, errors.Wrap(err, "wait explore page load failed")
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(pp, 1*time.Second)
 
-	exists, _, err := pp.Has(`.main-container .user .link-wrapper .channel`)
+	exists, _, err := pp.Has(sel("login.user_channel"))
 	if err != nil {
 		return false, errors.Wrap(err, "check login status failed")
 	}
@@ -52,17 +52,17 @@ func (a *LoginAction) Login(ctx context.Context) error {
 	}
 
 	// 等待一小段时间让页面完全加载
-	time.Sleep(2 * time.Second)
+	settle(pp, 2*time.Second)
 
 	// 检查是否已经登录
-	if exists, _, _ := pp.Has(".main-container .user .link-wrapper .channel"); exists {
+	if exists, _, _ := pp.Has(sel("login.user_channel")); exists {
 		// 已经登录，直接返回
 		return nil
 	}
 
 	// 等待扫码成功提示或者登录完成
 	// 这里我们等待登录成功的元素出现，这样更简单可靠
-	if _, err := pp.Element(".main-container .user .link-wrapper .channel"); err != nil {
+	if _, err := pp.Element(sel("login.user_channel")); err != nil {
 		return err
 	}
 
@@ -81,15 +81,15 @@ func (a *LoginAction) FetchQrcodeImage(ctx context.Context) (string, bool, error
 	}
 
 	// 等待一小段时间让页面完全加载
-	time.Sleep(2 * time.Second)
+	settle(pp, 2*time.Second)
 
 	// 检查是否已经登录
-	if exists, _, _ := pp.Has(".main-container .user .link-wrapper .channel"); exists {
+	if exists, _, _ := pp.Has(sel("login.user_channel")); exists {
 		return "", true, nil
 	}
 
 	// 获取二维码图片
-	imgEl, err := pp.Element(".login-container .qrcode-img")
+	imgEl, err := findElement(pp, Selectors("login.qrcode_img")...)
 	if err != nil {
 		return "", false, errors.Wrap(err, "find qrcode img failed")
 	}
@@ -114,7 +114,7 @@ func (a *LoginAction) WaitForLogin(ctx context.Context) bool {
 		case <-ctx.Done():
 			return false
 		case <-ticker.C:
-			el, err := pp.Element(".main-container .user .link-wrapper .channel")
+			el, err := pp.Element(sel("login.user_channel"))
 			if err == nil && el != nil {
 				return true
 			}
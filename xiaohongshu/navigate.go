@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
 )
 
 type NavigateAction struct {
@@ -27,7 +26,7 @@ func (n *NavigateAction) ToExplorePage(ctx context.Context) (err error) {
 	if err = page.WaitLoad(); err != nil {
 		return err
 	}
-	if _, err = page.Element(`div#app`); err != nil {
+	if _, err = findElement(page, Selectors("nav.app")...); err != nil {
 		return err
 	}
 
@@ -49,11 +48,7 @@ func (n *NavigateAction) ToProfilePage(ctx context.Context) (err error) {
 	}
 
 	// Find and click the "我" channel link in sidebar
-	profileLink, err := page.Element(`div.main-container li.user.side-bar-component a.link-wrapper span.channel`)
-	if err != nil {
-		return err
-	}
-	if err = profileLink.Click(proto.InputMouseButtonLeft, 1); err != nil {
+	if err = clickElement(page, Selectors("nav.profile_link")...); err != nil {
 		return err
 	}
 
@@ -78,11 +73,7 @@ func (n *NavigateAction) ToNotificationPage(ctx context.Context) (err error) {
 		return err
 	}
 
-	notificationLink, err := page.Element(`a.link-wrapper[href="/notification"]`)
-	if err != nil {
-		return err
-	}
-	if err = notificationLink.Click(proto.InputMouseButtonLeft, 1); err != nil {
+	if err = clickElement(page, Selectors("nav.notification_link")...); err != nil {
 		return err
 	}
 	if err = page.Wait(rod.Eval(`() => location.pathname === "/notification"`)); err != nil {
@@ -93,6 +84,11 @@ func (n *NavigateAction) ToNotificationPage(ctx context.Context) (err error) {
 }
 
 func (n *NavigateAction) ToNotificationMentionsPage(ctx context.Context) (err error) {
+	return n.ToNotificationTab(ctx, "评论和@")
+}
+
+// ToNotificationTab 打开通知页并切换到指定标签，如「评论和@」「新增关注」
+func (n *NavigateAction) ToNotificationTab(ctx context.Context, tab string) (err error) {
 	defer recoverRodPanicAsError(ctx, &err)
 
 	page := n.page.Context(ctx)
@@ -101,31 +97,31 @@ func (n *NavigateAction) ToNotificationMentionsPage(ctx context.Context) (err er
 		return err
 	}
 
-	if err = page.Wait(rod.Eval(`() => [...document.querySelectorAll('.reds-tab-item')].some(
-		(el) => (el.textContent || '').trim() === '评论和@'
-	)`)); err != nil {
+	tabSel := sel("nav.notification_tab")
+	if err = page.Wait(rod.Eval(`(tab, tabSel) => [...document.querySelectorAll(tabSel)].some(
+		(el) => (el.textContent || '').trim() === tab
+	)`, tab, tabSel)); err != nil {
 		return err
 	}
 
-	if _, err = page.Eval(`() => {
-		const mentionsTab = [...document.querySelectorAll('.reds-tab-item')].find(
-			(el) => (el.textContent || '').trim() === '评论和@'
+	if _, err = page.Eval(`(tab, tabSel) => {
+		const target = [...document.querySelectorAll(tabSel)].find(
+			(el) => (el.textContent || '').trim() === tab
 		);
-		if (!mentionsTab) {
+		if (!target) {
 			return false;
 		}
-		if (!mentionsTab.classList.contains('active')) {
-			mentionsTab.click();
+		if (!target.classList.contains('active')) {
+			target.click();
 		}
 		return true;
-	}`); err != nil {
+	}`, tab, tabSel); err != nil {
 		return err
 	}
 
-	if err = page.Wait(rod.Eval(`() => {
-		const activeTab = document.querySelector('.reds-tab-item.active');
-		return !!activeTab && (activeTab.textContent || '').trim() === '评论和@';
-	}`)); err != nil {
+	if err = page.Wait(rod.Eval(`(tab, tabSel) => [...document.querySelectorAll(tabSel)].some(
+		(el) => el.classList.contains('active') && (el.textContent || '').trim() === tab
+	)`, tab, tabSel)); err != nil {
 		return err
 	}
 
@@ -18,12 +18,14 @@ func navigateWithRetry(page *rod.Page, targetURL string, attempts int) error {
 	}
 	attempts = effectiveNavigateAttempts(attempts)
 
+	navTimeout := pageTimings(page).Navigate
+
 	var lastErr error
 	var tried int
 	for i := 1; i <= attempts; i++ {
 		tried = i
-		if err := page.Navigate(targetURL); err == nil {
-			return nil
+		if err := page.Timeout(navTimeout).Navigate(targetURL); err == nil {
+			return checkInterstitial(page, targetURL)
 		} else {
 			lastErr = err
 			if isRetryableNavigationError(err) && i < attempts {
@@ -43,6 +45,16 @@ func navigateWithRetry(page *rod.Page, targetURL string, attempts int) error {
 	return lastErr
 }
 
+// checkInterstitial 导航成功后检查是否落到反爬拦截页，是则尝试自动恢复
+func checkInterstitial(page *rod.Page, targetURL string) error {
+	_ = page.Timeout(5 * time.Second).WaitLoad()
+	kind, landed := detectInterstitial(page)
+	if kind == "" {
+		return nil
+	}
+	return recoverInterstitial(page, targetURL, kind, landed)
+}
+
 func isRetryableNavigationError(err error) bool {
 	if err == nil {
 		return false
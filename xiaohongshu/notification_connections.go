@@ -0,0 +1,81 @@
+package xiaohongshu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	myerrors "github.com/xpzouying/xiaohongshu-mcp/errors"
+)
+
+// NotificationConnectionsAction 表示通知页“新增关注”抓取动作
+type NotificationConnectionsAction struct {
+	page *rod.Page
+}
+
+// NewNotificationConnectionsAction 创建通知页“新增关注”抓取动作
+func NewNotificationConnectionsAction(page *rod.Page) *NotificationConnectionsAction {
+	return &NotificationConnectionsAction{page: page.Timeout(60 * time.Second)}
+}
+
+// GetConnections 获取当前登录账号通知页“新增关注”列表，消息结构与“评论和@”相同
+func (n *NotificationConnectionsAction) GetConnections(ctx context.Context) (resp *NotificationMentionsData, err error) {
+	defer recoverRodPanicAsError(ctx, &err)
+
+	page := n.page.Context(ctx)
+	if err = NewNavigate(page).ToNotificationTab(ctx, "新增关注"); err != nil {
+		return nil, err
+	}
+	settle(page, 500*time.Millisecond)
+
+	// 切换标签后列表异步加载，等待 notificationMap.connections 出现
+	if err = page.Wait(rod.Eval(`() => {
+		const state = window.__INITIAL_STATE__ || window.__INITIAL_SSR_STATE__ || window.__UNIVERSAL_STATE__;
+		return !!(state && state.notification && state.notification.notificationMap &&
+			state.notification.notificationMap.connections);
+	}`)); err != nil {
+		return nil, err
+	}
+
+	evalResult, err := page.Eval(`() => {
+		const unwrap = (value) => {
+			if (!value || typeof value !== 'object') {
+				return value;
+			}
+			if ('value' in value && value.value !== undefined) {
+				return value.value;
+			}
+			if ('_value' in value && value._value !== undefined) {
+				return value._value;
+			}
+			return value;
+		};
+		const state = window.__INITIAL_STATE__ || window.__INITIAL_SSR_STATE__ || window.__UNIVERSAL_STATE__;
+		const raw = unwrap(unwrap(unwrap(state.notification).notificationMap).connections) || {};
+		const messageList = unwrap(raw.messageList ?? raw.message_list);
+		if (!Array.isArray(messageList)) {
+			return '';
+		}
+		return JSON.stringify({
+			messageList,
+			cursor: String(raw.cursor ?? raw.strCursor ?? raw.nextCursor ?? ''),
+			hasMore: Boolean(raw.hasMore ?? raw.has_more),
+		});
+	}`)
+	if err != nil {
+		return nil, err
+	}
+
+	resultJSON := evalResult.Value.String()
+	if resultJSON == "" {
+		return nil, myerrors.ErrNoNotificationConnections
+	}
+
+	var result NotificationMentionsData
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification connections data: %w", err)
+	}
+	return &result, nil
+}
@@ -34,7 +34,7 @@ func (n *NotificationMentionsAction) GetMentions(ctx context.Context) (resp *Not
 		return nil, err
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	return n.extractMentionsData(page)
 }
@@ -51,20 +51,20 @@ func NewPublishImageAction(page *rod.Page) (action *PublishAction, err error) {
 	if err := pp.WaitLoad(); err != nil {
 		logrus.Warnf("等待页面加载出现问题: %v，继续尝试", err)
 	}
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 
 	// 等待页面稳定
 	if err := pp.WaitDOMStable(time.Second, 0.1); err != nil {
 		logrus.Warnf("等待 DOM 稳定出现问题: %v，继续尝试", err)
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	if err := mustClickPublishTab(pp, "上传图文"); err != nil {
 		logrus.Errorf("点击上传图文 TAB 失败: %v", err)
 		return nil, err
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	return &PublishAction{
 		page: pp,
@@ -130,12 +130,14 @@ func (p *PublishAction) Publish(ctx context.Context, content PublishImageContent
 func removePopCover(page *rod.Page) {
 
 	// 先移除弹窗封面
-	has, elem, err := page.Has("div.d-popover")
+	has, elem, err := page.Has(sel("publish.popover"))
 	if err != nil {
 		return
 	}
 	if has {
-		elem.MustRemove()
+		if err := elem.Remove(); err != nil {
+			logrus.Warnf("移除弹窗封面失败: %v", err)
+		}
 	}
 
 	// 兜底：点击一下空位置吧
@@ -145,36 +147,48 @@ func removePopCover(page *rod.Page) {
 func clickEmptyPosition(page *rod.Page) {
 	x := 380 + rand.Intn(100)
 	y := 20 + rand.Intn(60)
-	page.Mouse.MustMoveTo(float64(x), float64(y)).MustClick(proto.InputMouseButtonLeft)
+	if err := page.Mouse.MoveTo(proto.Point{X: float64(x), Y: float64(y)}); err != nil {
+		logrus.Warnf("移动鼠标失败: %v", err)
+		return
+	}
+	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		logrus.Warnf("点击空白位置失败: %v", err)
+	}
 }
 
 func mustClickPublishTab(page *rod.Page, tabname string) error {
-	page.MustElement(`div.upload-content`).MustWaitVisible()
+	uploadContent, err := page.Element(sel("publish.upload_content"))
+	if err != nil {
+		return errors.Wrap(err, "未找到上传区域")
+	}
+	if err := uploadContent.WaitVisible(); err != nil {
+		return errors.Wrap(err, "等待上传区域可见失败")
+	}
 
 	deadline := time.Now().Add(15 * time.Second)
 	for time.Now().Before(deadline) {
 		tab, blocked, err := getTabElement(page, tabname)
 		if err != nil {
 			logrus.Warnf("获取发布 TAB 元素失败: %v", err)
-			time.Sleep(200 * time.Millisecond)
+			settle(page, 200*time.Millisecond)
 			continue
 		}
 
 		if tab == nil {
-			time.Sleep(200 * time.Millisecond)
+			settle(page, 200*time.Millisecond)
 			continue
 		}
 
 		if blocked {
 			logrus.Info("发布 TAB 被遮挡，尝试移除遮挡")
 			removePopCover(page)
-			time.Sleep(200 * time.Millisecond)
+			settle(page, 200*time.Millisecond)
 			continue
 		}
 
 		if err := tab.Click(proto.InputMouseButtonLeft, 1); err != nil {
 			logrus.Warnf("点击发布 TAB 失败: %v", err)
-			time.Sleep(200 * time.Millisecond)
+			settle(page, 200*time.Millisecond)
 			continue
 		}
 
@@ -185,7 +199,7 @@ func mustClickPublishTab(page *rod.Page, tabname string) error {
 }
 
 func getTabElement(page *rod.Page, tabname string) (*rod.Element, bool, error) {
-	elems, err := page.Elements("div.creator-tab")
+	elems, err := page.Elements(sel("publish.creator_tab"))
 	if err != nil {
 		return nil, false, err
 	}
@@ -262,12 +276,12 @@ func uploadImages(ctx context.Context, page *rod.Page, imagesPaths []string) err
 			_ = dbg.WaitIfPaused(ctx)
 		}
 
-		selector := `input[type="file"]`
+		key := "publish.upload_input"
 		if i == 0 {
-			selector = ".upload-input"
+			key = "publish.first_upload_input"
 		}
 
-		uploadInput, err := page.Element(selector)
+		uploadInput, err := findElement(page, Selectors(key)...)
 		if err != nil {
 			return errors.Wrapf(err, "查找上传输入框失败(第%d张)", i+1)
 		}
@@ -281,7 +295,7 @@ func uploadImages(ctx context.Context, page *rod.Page, imagesPaths []string) err
 		if err := waitForUploadComplete(ctx, page, i+1); err != nil {
 			return errors.Wrapf(err, "第%d张图片上传超时", i+1)
 		}
-		time.Sleep(1 * time.Second)
+		settle(page, 1*time.Second)
 	}
 
 	return nil
@@ -299,7 +313,7 @@ func waitForUploadComplete(ctx context.Context, page *rod.Page, expectedCount in
 		if dbg != nil {
 			_ = dbg.WaitIfPaused(ctx)
 		}
-		uploadedImages, err := page.Elements(".img-preview-area .pr")
+		uploadedImages, err := page.Elements(sel("publish.image_preview"))
 		if err != nil {
 			time.Sleep(checkInterval)
 			continue
@@ -335,7 +349,7 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 		dbg.Step("填写标题", map[string]any{"title_len": len(strings.TrimSpace(title))})
 		_ = dbg.WaitIfPaused(ctx)
 	}
-	titleElem, err := page.Element("div.d-input input")
+	titleElem, err := findElement(page, Selectors("publish.title_input")...)
 	if err != nil {
 		return errors.Wrap(err, "查找标题输入框失败")
 	}
@@ -344,13 +358,13 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 	}
 
 	// 检查标题长度
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 	if err := checkTitleMaxLength(page); err != nil {
 		return err
 	}
 	slog.Info("检查标题长度：通过")
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	if dbg != nil {
 		dbg.Step("填写正文/标签", map[string]any{
@@ -373,7 +387,7 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 		return err
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 检查正文长度
 	if err := checkContentMaxLength(page); err != nil {
@@ -406,7 +420,7 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 		dbg.Step("点击发布按钮", nil)
 		_ = dbg.WaitIfPaused(ctx)
 	}
-	submitButton, err := page.Element(".publish-page-publish-btn button.bg-red")
+	submitButton, err := findElement(page, Selectors("publish.submit_button")...)
 	if err != nil {
 		return errors.Wrap(err, "查找发布按钮失败")
 	}
@@ -416,7 +430,7 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 		}
 		if err := submitButton.Click(proto.InputMouseButtonLeft, 1); err != nil {
 			removePopCover(page)
-			submitButton, err = page.Element(".publish-page-publish-btn button.bg-red")
+			submitButton, err = findElement(page, Selectors("publish.submit_button")...)
 			return err
 		}
 		return nil
@@ -424,7 +438,7 @@ func submitPublish(ctx context.Context, page *rod.Page, title, content string, t
 		return errors.Wrap(err, "点击发布按钮失败")
 	}
 
-	time.Sleep(3 * time.Second)
+	settle(page, 3*time.Second)
 	return nil
 }
 
@@ -449,13 +463,13 @@ func addProducts(ctx context.Context, page *rod.Page, productKeywords []string)
 	if err := addButton.ScrollIntoView(); err != nil {
 		logrus.Debugf("滚动到添加商品按钮失败: %v", err)
 	}
-	time.Sleep(100 * time.Millisecond)
+	settle(page, 100*time.Millisecond)
 
 	if err := addButton.Click(proto.InputMouseButtonLeft, 1); err != nil {
 		return errors.Wrap(err, "点击添加商品按钮失败")
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	modal, err := waitForProductModal(page)
 	if err != nil {
@@ -476,7 +490,7 @@ func addProducts(ctx context.Context, page *rod.Page, productKeywords []string)
 		if err != nil {
 			return errors.Wrap(err, "打开商品选择弹窗失败")
 		}
-		searchInput, err := modal.Timeout(10 * time.Second).Element("input[placeholder='搜索商品ID 或 商品名称']")
+		searchInput, err := modal.Timeout(10 * time.Second).Element(sel("product.search_input"))
 		if err != nil {
 			return errors.Wrap(err, "未找到商品搜索输入框")
 		}
@@ -496,7 +510,7 @@ func addProducts(ctx context.Context, page *rod.Page, productKeywords []string)
 	if err != nil {
 		return errors.Wrap(err, "打开商品选择弹窗失败")
 	}
-	saveButton, err := modal.ElementR("div.d-modal-footer button", "保存")
+	saveButton, err := modal.ElementR(sel("product.save_button"), "保存")
 	if err != nil {
 		return errors.Wrap(err, "未找到商品保存按钮")
 	}
@@ -508,7 +522,7 @@ func addProducts(ctx context.Context, page *rod.Page, productKeywords []string)
 		if clickErr := saveButton.Click(proto.InputMouseButtonLeft, 1); clickErr != nil {
 			modal, err = waitForProductModal(page)
 			if err == nil {
-				saveButton, _ = modal.ElementR("div.d-modal-footer button", "保存")
+				saveButton, _ = modal.ElementR(sel("product.save_button"), "保存")
 			}
 			return clickErr
 		}
@@ -525,19 +539,19 @@ func addProducts(ctx context.Context, page *rod.Page, productKeywords []string)
 }
 
 func findAddProductButton(page *rod.Page) (*rod.Element, error) {
-	selectors := []string{
-		"div.multi-good-select-empty-btn button",
-		"div.multi-good-select-add-btn button",
+	if elem, err := findElement(page, Selectors("product.add_button")...); err == nil {
+		return elem, nil
 	}
 
-	for _, selector := range selectors {
-		elem, err := page.Element(selector)
+	var lastErr error
+	for _, selector := range Selectors("product.add_button_text") {
+		elem, err := page.Timeout(pageTimings(page).Lookup).ElementR(selector, "添加商品")
 		if err == nil {
-			return elem, nil
+			return elem.CancelTimeout(), nil
 		}
+		lastErr = err
 	}
-
-	return page.ElementR("button", "添加商品")
+	return nil, lastErr
 }
 
 func inputProductSearchKeyword(input *rod.Element, keyword string) error {
@@ -559,7 +573,7 @@ func inputProductSearchKeyword(input *rod.Element, keyword string) error {
 	}
 
 	// 等待搜索结果加载和DOM稳定
-	time.Sleep(1 * time.Second)
+	settle(input.Page(), 1*time.Second)
 
 	return nil
 }
@@ -610,26 +624,41 @@ func selectProductByKeyword(modal *rod.Element, keyword string) error {
 	return errors.Errorf("重试%d次后未找到匹配商品: %s", maxRetries, keyword)
 }
 
+// productCardSelectors 商品卡片脚本使用的选择器，传入页面脚本以便随配置重新加载
+func productCardSelectors() map[string]any {
+	return map[string]any{
+		"card":     sel("product.card"),
+		"name":     sel("product.sku_name"),
+		"id":       sel("product.sku_id"),
+		"checkbox": sel("product.checkbox"),
+		"targets":  Selectors("product.checkbox_target"),
+	}
+}
+
 func trySelectProduct(modal *rod.Element, lowerKeyword string) (success bool, alreadyChecked bool, err error) {
-	result, err := modal.Eval(`(keyword) => {
-		const cards = this.querySelectorAll('.good-card-container');
+	result, err := modal.Eval(`(keyword, s) => {
+		const cards = this.querySelectorAll(s.card);
 
 		for (let card of cards) {
-			const nameElem = card.querySelector('.sku-name');
+			const nameElem = card.querySelector(s.name);
 			if (!nameElem) continue;
 
 			const name = (nameElem.textContent || '').toLowerCase();
-			const idText = (card.querySelector('.sku-id')?.textContent || '').toLowerCase();
+			const idText = (card.querySelector(s.id)?.textContent || '').toLowerCase();
 			if (!name.includes(keyword) && !idText.includes(keyword)) continue;
 
 			// 找到匹配的商品，检查是否已选中
-			const checkbox = card.querySelector('input[type="checkbox"]');
+			const checkbox = card.querySelector(s.checkbox);
 			if (checkbox && checkbox.checked) {
 				return { success: true, alreadyChecked: true };
 			}
 
 				// 查找并点击复选框容器（优先点击可交互区域）
-				const checkboxContainer = card.querySelector('.d-checkbox-main') || card.querySelector('.d-checkbox');
+				let checkboxContainer = null;
+				for (const t of s.targets) {
+					checkboxContainer = card.querySelector(t);
+					if (checkboxContainer) break;
+				}
 				if (!checkboxContainer) {
 					return { success: false, error: '未找到复选框容器' };
 				}
@@ -639,7 +668,7 @@ func trySelectProduct(modal *rod.Element, lowerKeyword string) (success bool, al
 		}
 
 		return { success: false, error: '未找到匹配商品' };
-	}`, lowerKeyword)
+	}`, lowerKeyword, productCardSelectors())
 
 	if err != nil {
 		return false, false, errors.Wrap(err, "执行选择脚本失败")
@@ -681,22 +710,22 @@ func waitForProductChecked(modal *rod.Element, lowerKeyword string, timeout time
 }
 
 func getProductCheckedState(modal *rod.Element, lowerKeyword string) (found bool, checked bool, err error) {
-	result, err := modal.Eval(`(keyword) => {
-		const cards = this.querySelectorAll('.good-card-container');
+	result, err := modal.Eval(`(keyword, s) => {
+		const cards = this.querySelectorAll(s.card);
 		for (let card of cards) {
-			const nameElem = card.querySelector('.sku-name');
+			const nameElem = card.querySelector(s.name);
 			if (!nameElem) continue;
 
 			const name = (nameElem.textContent || '').toLowerCase();
-			const idText = (card.querySelector('.sku-id')?.textContent || '').toLowerCase();
+			const idText = (card.querySelector(s.id)?.textContent || '').toLowerCase();
 			if (!name.includes(keyword) && !idText.includes(keyword)) continue;
 
-			const checkbox = card.querySelector('input[type="checkbox"]');
+			const checkbox = card.querySelector(s.checkbox);
 			return { found: true, checked: !!(checkbox && checkbox.checked) };
 		}
 
 		return { found: false, checked: false };
-	}`, lowerKeyword)
+	}`, lowerKeyword, productCardSelectors())
 	if err != nil {
 		return false, false, err
 	}
@@ -707,7 +736,7 @@ func getProductCheckedState(modal *rod.Element, lowerKeyword string) (found bool
 func waitForProductListLoad(modal *rod.Element) error {
 	deadline := time.Now().Add(10 * time.Second)
 	for time.Now().Before(deadline) {
-		cards, err := modal.Elements(".good-card-container")
+		cards, err := modal.Elements(sel("product.card"))
 		if err == nil && len(cards) > 0 {
 			for _, card := range cards {
 				if isElementVisible(card) {
@@ -716,7 +745,7 @@ func waitForProductListLoad(modal *rod.Element) error {
 			}
 		}
 
-		emptyStates, err := modal.Elements(".goods-list-empty, .goods-list-search-empty")
+		emptyStates, err := modal.Elements(sel("product.empty"))
 		if err == nil {
 			for _, empty := range emptyStates {
 				if isElementVisible(empty) {
@@ -734,11 +763,11 @@ func waitForProductListLoad(modal *rod.Element) error {
 func waitForModalClose(page *rod.Page) error {
 	deadline := time.Now().Add(5 * time.Second)
 	for time.Now().Before(deadline) {
-		has, _, err := page.Has("div.multi-goods-selector-modal")
+		has, _, err := page.Has(sel("product.modal"))
 		if err == nil && !has {
 			return nil
 		}
-		time.Sleep(200 * time.Millisecond)
+		settle(page, 200*time.Millisecond)
 	}
 
 	return errors.New("关闭商品选择弹窗超时")
@@ -748,7 +777,7 @@ func waitForProductModal(page *rod.Page) (*rod.Element, error) {
 	var modal *rod.Element
 	var modalErr error
 	if err := retryPublishStep("打开商品选择弹窗", func() error {
-		modal, modalErr = page.Timeout(15 * time.Second).Element("div.multi-goods-selector-modal")
+		modal, modalErr = page.Timeout(15 * time.Second).Element(sel("product.modal"))
 		return modalErr
 	}); err != nil {
 		return nil, err
@@ -759,7 +788,7 @@ func waitForProductModal(page *rod.Page) (*rod.Element, error) {
 // waitAndClickTitleInput 在填写正文后等待 1 秒并回点标题输入框，增强后续交互稳定性
 func waitAndClickTitleInput(page *rod.Page) error {
 	slog.Info("正文填写完成，准备等待后回点标题输入框")
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 	if err := retryPublishStep("回点标题输入框", func() error {
 		titleElem, err := findTitleInput(page)
 		if err != nil {
@@ -782,7 +811,7 @@ func waitAndClickTitleInput(page *rod.Page) error {
 
 // 检查标题是否超过最大长度
 func checkTitleMaxLength(page *rod.Page) error {
-	has, elem, err := page.Has(`div.title-container div.max_suffix`)
+	has, elem, err := page.Has(sel("publish.title_max_suffix"))
 	if err != nil {
 		return errors.Wrap(err, "检查标题长度元素失败")
 	}
@@ -808,7 +837,7 @@ func checkContentMaxLength(page *rod.Page) error {
 		err  error
 	)
 	if retryErr := retryPublishStep("检查正文长度元素", func() error {
-		has, elem, err = page.Has(`div.edit-container div.length-error`)
+		has, elem, err = page.Has(sel("publish.content_length_error"))
 		return err
 	}); retryErr != nil {
 		return errors.Wrap(err, "检查正文长度元素失败")
@@ -842,19 +871,9 @@ func makeMaxLengthError(elemText string) error {
 	return errors.Errorf("当前输入长度为%s，最大长度为%s", currLen, maxLen)
 }
 
-var contentElementSelectors = []string{
-	"div.tiptap.ProseMirror[role='textbox'][contenteditable='true']",
-	"div.ProseMirror[role='textbox'][contenteditable='true']",
-	"div.ProseMirror[contenteditable='true']",
-	"div[role='textbox'][contenteditable='true']",
-	"div.tiptap.ProseMirror",
-	"div.ql-editor",
-	"textarea",
-}
-
 // 查找内容输入框 - 优先匹配真实可编辑容器，再回退到 placeholder 方案
 func getContentElement(page *rod.Page) (*rod.Element, bool) {
-	for _, selector := range contentElementSelectors {
+	for _, selector := range Selectors("publish.content_editor") {
 		elements, err := page.Elements(selector)
 		if err != nil {
 			logrus.Debugf("查找正文输入框失败: selector=%s err=%v", selector, err)
@@ -885,7 +904,7 @@ func inputTags(page *rod.Page, tags []string) error {
 		return nil
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	if err := retryPublishStep("准备标签输入光标", func() error {
 		contentElem, ok := getContentElement(page)
@@ -904,7 +923,7 @@ func inputTags(page *rod.Page, tags []string) error {
 			if err := ka.Type(input.ArrowDown).Do(); err != nil {
 				return errors.Wrap(err, "按下方向键失败")
 			}
-			time.Sleep(10 * time.Millisecond)
+			settle(page, 10*time.Millisecond)
 		}
 
 		ka, err := contentElem.KeyActions()
@@ -919,7 +938,7 @@ func inputTags(page *rod.Page, tags []string) error {
 		return err
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	for _, tag := range tags {
 		tag = strings.TrimLeft(tag, "#")
@@ -934,24 +953,24 @@ func inputTag(page *rod.Page, tag string) error {
 	if err := insertContentTextWithRetry(page, "#"); err != nil {
 		return errors.Wrap(err, "输入#失败")
 	}
-	time.Sleep(200 * time.Millisecond)
+	settle(page, 200*time.Millisecond)
 
 	for _, char := range tag {
 		if err := insertContentTextWithRetry(page, string(char)); err != nil {
 			return errors.Wrapf(err, "输入字符[%c]失败", char)
 		}
-		time.Sleep(50 * time.Millisecond)
+		settle(page, 50*time.Millisecond)
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
-	topicContainer, err := page.Element("#creator-editor-topic-container")
+	topicContainer, err := page.Element(sel("publish.topic_container"))
 	if err != nil || topicContainer == nil {
 		slog.Warn("未找到标签联想下拉框，直接输入空格", "tag", tag)
 		return insertContentTextWithRetry(page, " ")
 	}
 
-	firstItem, err := topicContainer.Element(".item")
+	firstItem, err := topicContainer.Element(sel("publish.topic_item"))
 	if err != nil || firstItem == nil {
 		slog.Warn("未找到标签联想选项，直接输入空格", "tag", tag)
 		return insertContentTextWithRetry(page, " ")
@@ -961,9 +980,9 @@ func inputTag(page *rod.Page, tag string) error {
 		return errors.Wrap(err, "点击标签联想选项失败")
 	}
 	slog.Info("成功点击标签联想选项", "tag", tag)
-	time.Sleep(200 * time.Millisecond)
+	settle(page, 200*time.Millisecond)
 
-	time.Sleep(500 * time.Millisecond) // 等待标签处理完成
+	settle(page, 500*time.Millisecond) // 等待标签处理完成
 	return nil
 }
 
@@ -1016,7 +1035,7 @@ func focusContentElement(contentElem *rod.Element) error {
 }
 
 func findTitleInput(page *rod.Page) (*rod.Element, error) {
-	return page.Element("div.d-input input")
+	return page.Element(sel("publish.title_input"))
 }
 
 func retryPublishStep(action string, fn func() error) error {
@@ -1061,7 +1080,7 @@ func isRetryablePublishError(err error) bool {
 }
 
 func findTextboxByPlaceholder(page *rod.Page) (*rod.Element, error) {
-	elements, err := page.Elements("[data-placeholder]")
+	elements, err := page.Elements(sel("publish.placeholder"))
 	if err != nil {
 		return nil, errors.Wrap(err, "查询 data-placeholder 元素失败")
 	}
@@ -1162,17 +1181,13 @@ func setVisibility(page *rod.Page, visibility string) error {
 	}
 
 	// 点击可见范围下拉框
-	dropdown, err := page.Element("div.permission-card-wrapper div.d-select-content")
-	if err != nil {
-		return errors.Wrap(err, "查找可见范围下拉框失败")
-	}
-	if err := dropdown.Click(proto.InputMouseButtonLeft, 1); err != nil {
+	if err := clickElement(page, Selectors("publish.visibility_dropdown")...); err != nil {
 		return errors.Wrap(err, "点击可见范围下拉框失败")
 	}
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	// 在弹窗中查找并点击目标选项
-	opts, err := page.Elements("div.d-options-wrapper div.d-grid-item div.custom-option")
+	opts, err := page.Elements(sel("publish.visibility_option"))
 	if err != nil {
 		return errors.Wrap(err, "查找可见范围选项失败")
 	}
@@ -1186,7 +1201,7 @@ func setVisibility(page *rod.Page, visibility string) error {
 				return errors.Wrap(err, "选择可见范围失败")
 			}
 			slog.Info("已设置可见范围", "visibility", visibility)
-			time.Sleep(200 * time.Millisecond)
+			settle(page, 200*time.Millisecond)
 			return nil
 		}
 	}
@@ -1199,25 +1214,20 @@ func setSchedulePublish(page *rod.Page, t time.Time) error {
 	if err := clickScheduleSwitch(page); err != nil {
 		return err
 	}
-	time.Sleep(800 * time.Millisecond)
+	settle(page, 800*time.Millisecond)
 
 	// 2. 设置日期时间
 	if err := setDateTime(page, t); err != nil {
 		return err
 	}
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	return nil
 }
 
 // clickScheduleSwitch 点击定时发布开关
 func clickScheduleSwitch(page *rod.Page) error {
-	switchElem, err := page.Element(".post-time-wrapper .d-switch")
-	if err != nil {
-		return errors.Wrap(err, "查找定时发布开关失败")
-	}
-
-	if err := switchElem.Click(proto.InputMouseButtonLeft, 1); err != nil {
+	if err := clickElement(page, Selectors("publish.schedule_switch")...); err != nil {
 		return errors.Wrap(err, "点击定时发布开关失败")
 	}
 	slog.Info("已点击定时发布开关")
@@ -1228,7 +1238,7 @@ func clickScheduleSwitch(page *rod.Page) error {
 func setDateTime(page *rod.Page, t time.Time) error {
 	dateTimeStr := t.Format("2006-01-02 15:04")
 
-	input, err := page.Element(".date-picker-container input")
+	input, err := findElement(page, Selectors("publish.schedule_input")...)
 	if err != nil {
 		return errors.Wrap(err, "查找日期时间输入框失败")
 	}
@@ -1251,7 +1261,7 @@ func setOriginal(page *rod.Page) error {
 	// 开关是 div.d-switch 组件
 
 	// 查找包含"原创声明"文本的 custom-switch-card
-	switchCards, err := page.Elements("div.custom-switch-card")
+	switchCards, err := page.Elements(sel("publish.switch_card"))
 	if err != nil {
 		return errors.Wrap(err, "查找原创声明卡片失败")
 	}
@@ -1268,16 +1278,16 @@ func setOriginal(page *rod.Page) error {
 		}
 
 		// 找到原创声明卡片，查找其中的 d-switch
-		switchElem, err := card.Element("div.d-switch")
+		switchElem, err := card.Element(sel("publish.switch"))
 		if err != nil {
 			continue
 		}
 
 		// 检查开关是否已打开
-		checked, err := switchElem.Eval(`() => {
-			const input = this.querySelector('input[type="checkbox"]');
+		checked, err := switchElem.Eval(`(inputSel) => {
+			const input = this.querySelector(inputSel);
 			return input ? input.checked : false;
-		}`)
+		}`, sel("publish.switch_input"))
 		if err != nil {
 			continue
 		}
@@ -1292,7 +1302,7 @@ func setOriginal(page *rod.Page) error {
 			return errors.Wrap(err, "点击原创声明开关失败")
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		settle(page, 500*time.Millisecond)
 
 		// 处理原创声明确认弹窗
 		if err := confirmOriginalDeclaration(page); err != nil {
@@ -1306,16 +1316,25 @@ func setOriginal(page *rod.Page) error {
 	return errors.New("未找到原创声明选项")
 }
 
+// originalDeclarationSelectors 原创声明弹窗脚本使用的选择器
+func originalDeclarationSelectors() map[string]string {
+	return map[string]string{
+		"footer":   sel("publish.original_footer"),
+		"checkbox": sel("publish.original_checkbox"),
+		"confirm":  sel("publish.original_confirm"),
+	}
+}
+
 // confirmOriginalDeclaration 处理原创声明确认弹窗
 func confirmOriginalDeclaration(page *rod.Page) error {
 	// 等待确认弹窗出现
-	time.Sleep(800 * time.Millisecond)
+	settle(page, 800*time.Millisecond)
 
 	// 使用 JavaScript 直接处理弹窗，更可靠
 	result, err := page.Eval(`
-		() => {
+		(s) => {
 			// 查找包含"原创声明须知"的 footer 区域
-			const footers = document.querySelectorAll('div.footer');
+			const footers = document.querySelectorAll(s.footer);
 			for (const footer of footers) {
 				// 检查是否包含原创声明相关内容
 				if (!footer.textContent.includes('原创声明须知')) {
@@ -1323,7 +1342,7 @@ func confirmOriginalDeclaration(page *rod.Page) error {
 				}
 
 				// 找到 checkbox 并勾选
-				const checkbox = footer.querySelector('div.d-checkbox input[type="checkbox"]');
+				const checkbox = footer.querySelector(s.checkbox);
 				if (checkbox && !checkbox.checked) {
 					checkbox.click();
 					console.log('已勾选原创声明须知 checkbox');
@@ -1334,31 +1353,31 @@ func confirmOriginalDeclaration(page *rod.Page) error {
 			}
 			return 'footer_not_found';
 		}
-	`)
+	`, originalDeclarationSelectors())
 	if err != nil {
 		slog.Warn("执行查找弹窗脚本失败", "error", err)
 	} else if result.Value.String() == "footer_not_found" {
 		slog.Warn("未找到原创声明确认弹窗的 footer")
 	}
 
-	time.Sleep(500 * time.Millisecond)
+	settle(page, 500*time.Millisecond)
 
 	// 再次使用 JavaScript 点击声明原创按钮
 	result2, err := page.Eval(`
-		() => {
-			const footers = document.querySelectorAll('div.footer');
+		(s) => {
+			const footers = document.querySelectorAll(s.footer);
 			for (const footer of footers) {
 				if (!footer.textContent.includes('声明原创')) {
 					continue;
 				}
 
 				// 找到声明原创按钮
-				const btn = footer.querySelector('button.custom-button');
+				const btn = footer.querySelector(s.confirm);
 				if (btn) {
 					// 检查是否禁用
 					if (btn.classList.contains('disabled') || btn.disabled) {
 						// 尝试再次勾选 checkbox
-						const checkbox = footer.querySelector('div.d-checkbox input[type="checkbox"]');
+						const checkbox = footer.querySelector(s.checkbox);
 						if (checkbox && !checkbox.checked) {
 							checkbox.click();
 						}
@@ -1370,7 +1389,7 @@ func confirmOriginalDeclaration(page *rod.Page) error {
 			}
 			return 'button_not_found';
 		}
-	`)
+	`, originalDeclarationSelectors())
 	if err != nil {
 		return errors.Wrap(err, "执行点击按钮脚本失败")
 	}
@@ -1386,7 +1405,7 @@ func confirmOriginalDeclaration(page *rod.Page) error {
 	}
 
 	slog.Info("已成功点击声明原创按钮")
-	time.Sleep(300 * time.Millisecond)
+	settle(page, 300*time.Millisecond)
 
 	return nil
 }
@@ -18,7 +18,8 @@ func TestPublish(t *testing.T) {
 	require.NoError(t, err)
 	defer b.Close()
 
-	page := b.NewPage()
+	page, err := b.NewPage()
+	require.NoError(t, err)
 	defer page.Close()
 
 	action, err := NewPublishImageAction(page)
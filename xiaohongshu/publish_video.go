@@ -39,18 +39,18 @@ func NewPublishVideoAction(page *rod.Page) (action *PublishAction, err error) {
 	if err := pp.WaitLoad(); err != nil {
 		logrus.Warnf("等待页面加载出现问题: %v，继续尝试", err)
 	}
-	time.Sleep(2 * time.Second)
+	settle(page, 2*time.Second)
 
 	if err := pp.WaitDOMStable(time.Second, 0.1); err != nil {
 		logrus.Warnf("等待 DOM 稳定出现问题: %v，继续尝试", err)
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	if err := mustClickPublishTab(pp, "上传视频"); err != nil {
 		return nil, errors.Wrap(err, "切换到上传视频失败")
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	return &PublishAction{page: pp}, nil
 }
@@ -113,17 +113,14 @@ func uploadVideo(ctx context.Context, page *rod.Page, videoPath string) error {
 	}
 
 	// 寻找文件上传输入框（与图文一致的 class，或退回到 input[type=file]）
-	var fileInput *rod.Element
-	var err error
-	fileInput, err = pp.Element(".upload-input")
-	if err != nil || fileInput == nil {
-		fileInput, err = pp.Element("input[type='file']")
-		if err != nil || fileInput == nil {
-			return errors.New("未找到视频上传输入框")
-		}
+	fileInput, err := findElement(pp, Selectors("video.upload_input")...)
+	if err != nil {
+		return errors.Wrap(err, "未找到视频上传输入框")
 	}
 
-	fileInput.MustSetFiles(videoPath)
+	if err := fileInput.SetFiles([]string{videoPath}); err != nil {
+		return errors.Wrap(err, "设置视频文件失败")
+	}
 
 	// 对于视频，等待发布按钮变为可点击即表示处理完成
 	if dbg != nil {
@@ -142,7 +139,7 @@ func waitForPublishButtonClickable(ctx context.Context, page *rod.Page) (*rod.El
 	maxWait := 10 * time.Minute
 	interval := 1 * time.Second
 	start := time.Now()
-	selector := ".publish-page-publish-btn button.bg-red"
+	selector := sel("publish.submit_button")
 	dbg := flowdebug.FromContext(ctx)
 
 	slog.Info("开始等待发布按钮可点击(视频)")
@@ -180,14 +177,14 @@ func submitPublishVideo(ctx context.Context, page *rod.Page, title, content stri
 		dbg.Step("填写标题", map[string]any{"title_len": len(strings.TrimSpace(title))})
 		_ = dbg.WaitIfPaused(ctx)
 	}
-	titleElem, err := page.Element("div.d-input input")
+	titleElem, err := findElement(page, Selectors("publish.title_input")...)
 	if err != nil {
 		return errors.Wrap(err, "查找标题输入框失败")
 	}
 	if err := titleElem.Input(title); err != nil {
 		return errors.Wrap(err, "输入标题失败")
 	}
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 正文 + 标签
 	if dbg != nil {
@@ -211,7 +208,7 @@ func submitPublishVideo(ctx context.Context, page *rod.Page, title, content stri
 		return err
 	}
 
-	time.Sleep(1 * time.Second)
+	settle(page, 1*time.Second)
 
 	// 处理定时发布
 	if scheduleTime != nil {
@@ -241,6 +238,6 @@ func submitPublishVideo(ctx context.Context, page *rod.Page, title, content stri
 		return errors.Wrap(err, "点击发布按钮失败")
 	}
 
-	time.Sleep(3 * time.Second)
+	settle(page, 3*time.Second)
 	return nil
 }
@@ -170,6 +170,9 @@ func (s *SearchAction) Search(ctx context.Context, keyword string, filters ...Fi
 
 	page := s.page.Context(ctx)
 
+	capture := captureAPI(page, "/api/sns/web/v1/search/notes")
+	defer capture.Stop()
+
 	if err = navigateSearchResultWithFallback(page, keyword); err != nil {
 		return nil, err
 	}
@@ -201,21 +204,32 @@ func (s *SearchAction) Search(ctx context.Context, keyword string, filters ...Fi
 			}
 		}
 
+		// 筛选后页面会重新请求搜索接口，丢弃筛选前的响应
+		capture.Reset()
+
 		// 悬停在筛选按钮上
-		filterButton := page.MustElement(`div.filter`)
-		filterButton.MustHover()
+		filterButton, err := findElement(page, Selectors("search.filter_button")...)
+		if err != nil {
+			return nil, fmt.Errorf("未找到筛选按钮: %w", err)
+		}
+		if err = filterButton.Hover(); err != nil {
+			return nil, fmt.Errorf("悬停筛选按钮失败: %w", err)
+		}
 
 		// 等待筛选面板出现
-		if err = page.Timeout(8 * time.Second).Wait(rod.Eval(`() => document.querySelector('div.filter-panel') !== null`)); err != nil {
+		if err = page.Timeout(8 * time.Second).Wait(rod.Eval(`(panelSel) => document.querySelector(panelSel) !== null`, sel("search.filter_panel"))); err != nil {
 			return nil, fmt.Errorf("筛选面板加载超时: %w", err)
 		}
 
 		// 应用所有筛选条件
 		for _, filter := range allInternalFilters {
-			selector := fmt.Sprintf(`div.filter-panel div.filters:nth-child(%d) div.tags:nth-child(%d)`,
-				filter.FiltersIndex, filter.TagsIndex)
-			option := page.MustElement(selector)
-			option.MustClick()
+			var candidates []string
+			for _, format := range Selectors("search.filter_option") {
+				candidates = append(candidates, fmt.Sprintf(format, filter.FiltersIndex, filter.TagsIndex))
+			}
+			if err := clickElement(page, candidates...); err != nil {
+				return nil, fmt.Errorf("点击筛选选项失败: %w", err)
+			}
 		}
 
 		// 等待页面更新
@@ -228,6 +242,10 @@ func (s *SearchAction) Search(ctx context.Context, keyword string, filters ...Fi
 		}`))
 	}
 
+	if apiFeeds, ok := feedsFromCapture(capture, elementWait(page), "搜索"); ok {
+		return apiFeeds, nil
+	}
+
 	evalResult, err := page.Eval(`() => {
 		const state = window.__INITIAL_STATE__ || window.__INITIAL_SSR_STATE__ || window.__UNIVERSAL_STATE__;
 		if (!state) return "";
@@ -17,7 +17,8 @@ func TestSearch(t *testing.T) {
 	require.NoError(t, err)
 	defer b.Close()
 
-	page := b.NewPage()
+	page, err := b.NewPage()
+	require.NoError(t, err)
 	defer func() {
 		_ = page.Close()
 	}()
@@ -44,7 +45,8 @@ func TestSearchWithFilters(t *testing.T) {
 	require.NoError(t, err)
 	defer b.Close()
 
-	page := b.NewPage()
+	page, err := b.NewPage()
+	require.NoError(t, err)
 	defer func() {
 		_ = page.Close()
 	}()
@@ -0,0 +1,74 @@
+package xiaohongshu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// retryOptions 选择器重试参数：次数与指数退避均取自页面上的 Timings，
+// 并随页面 context 取消而提前结束。
+func retryOptions(page *rod.Page, what string) []retry.Option {
+	t := pageTimings(page)
+	return []retry.Option{
+		retry.Context(page.GetContext()),
+		retry.Attempts(uint(t.Attempts)),
+		retry.Delay(t.Backoff),
+		retry.MaxDelay(t.Backoff * 8),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			logrus.Debugf("%s 重试 #%d: %v", what, n+1, err)
+		}),
+	}
+}
+
+// findElement 依次尝试候选选择器（应对 A/B 布局），每个选择器最多等待 Lookup；
+// 全部未命中时按指数退避重试整轮。
+func findElement(page *rod.Page, selectors ...string) (*rod.Element, error) {
+	var found *rod.Element
+	err := retry.Do(func() error {
+		el, err := lookupOnce(page, selectors)
+		if err != nil {
+			return err
+		}
+		found = el
+		return nil
+	}, retryOptions(page, "查找元素 "+strings.Join(selectors, " | "))...)
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// clickElement 查找并点击元素；查找或点击失败都会整体重试
+func clickElement(page *rod.Page, selectors ...string) error {
+	return retry.Do(func() error {
+		el, err := lookupOnce(page, selectors)
+		if err != nil {
+			return err
+		}
+		return el.Click(proto.InputMouseButtonLeft, 1)
+	}, retryOptions(page, "点击元素 "+strings.Join(selectors, " | "))...)
+}
+
+func lookupOnce(page *rod.Page, selectors []string) (*rod.Element, error) {
+	wait := pageTimings(page).Lookup
+	var lastErr error
+	for _, selector := range selectors {
+		el, err := page.Timeout(wait).Element(selector)
+		if err == nil {
+			// 去掉查找时的超时，后续操作沿用页面 context
+			return el.CancelTimeout(), nil
+		}
+		if ctxErr := page.GetContext().Err(); ctxErr != nil {
+			return nil, retry.Unrecoverable(ctxErr)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("未找到元素 %s: %w", strings.Join(selectors, " | "), lastErr)
+}
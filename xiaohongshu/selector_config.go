@@ -0,0 +1,164 @@
+package xiaohongshu
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 页面选择器集中配置：内置一份默认值，可用磁盘文件覆盖并在运行时重新加载，
+// 小红书改版时只需修改配置文件即可，无需重新发布二进制。
+
+//go:embed selectors.json
+var defaultSelectorsJSON []byte
+
+// SelectorFile 选择器配置文件格式；同一 key 可配置多个候选，按顺序尝试
+type SelectorFile struct {
+	Version   string              `json:"version"`
+	Selectors map[string][]string `json:"selectors"`
+}
+
+// SelectorInfo 当前生效的选择器配置
+type SelectorInfo struct {
+	Version   string              `json:"version"`
+	Source    string              `json:"source"` // embedded 或覆盖文件路径
+	LoadedAt  time.Time           `json:"loaded_at"`
+	Overrides []string            `json:"overrides,omitempty"` // 被文件覆盖的 key
+	Selectors map[string][]string `json:"selectors"`
+}
+
+var (
+	selectorMu      sync.RWMutex
+	selectorPath    string
+	currentSelector = mustDefaultSelectors()
+)
+
+func mustDefaultSelectors() SelectorInfo {
+	def, err := parseSelectorFile(defaultSelectorsJSON)
+	if err != nil {
+		panic(fmt.Sprintf("内置选择器配置无效: %v", err))
+	}
+	return SelectorInfo{
+		Version:   def.Version,
+		Source:    "embedded",
+		LoadedAt:  time.Now(),
+		Selectors: def.Selectors,
+	}
+}
+
+func parseSelectorFile(data []byte) (*SelectorFile, error) {
+	var f SelectorFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for key, list := range f.Selectors {
+		cleaned := make([]string, 0, len(list))
+		for _, s := range list {
+			if s = strings.TrimSpace(s); s != "" {
+				cleaned = append(cleaned, s)
+			}
+		}
+		if len(cleaned) == 0 {
+			return nil, fmt.Errorf("选择器 %s 为空", key)
+		}
+		f.Selectors[key] = cleaned
+	}
+	return &f, nil
+}
+
+// loadSelectors 以内置配置为基础合并覆盖文件；path 为空时只使用内置配置
+func loadSelectors(path string) (SelectorInfo, error) {
+	info := mustDefaultSelectors()
+	if path == "" {
+		return info, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	override, err := parseSelectorFile(data)
+	if err != nil {
+		return info, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+
+	for key, list := range override.Selectors {
+		if _, ok := info.Selectors[key]; !ok {
+			logrus.Warnf("选择器配置包含未知 key: %s（已忽略）", key)
+			continue
+		}
+		info.Selectors[key] = list
+		info.Overrides = append(info.Overrides, key)
+	}
+	sort.Strings(info.Overrides)
+	if override.Version != "" {
+		info.Version = override.Version
+	}
+	info.Source = path
+	return info, nil
+}
+
+// SetSelectorsFile 设置覆盖文件并立即加载；加载失败时保持原配置不变
+func SetSelectorsFile(path string) error {
+	info, err := loadSelectors(path)
+	if err != nil {
+		return err
+	}
+	selectorMu.Lock()
+	selectorPath = path
+	currentSelector = info
+	selectorMu.Unlock()
+	return nil
+}
+
+// ReloadSelectors 重新读取覆盖文件；加载失败时保持原配置不变
+func ReloadSelectors() (SelectorInfo, error) {
+	selectorMu.RLock()
+	path := selectorPath
+	selectorMu.RUnlock()
+
+	info, err := loadSelectors(path)
+	if err != nil {
+		return GetSelectorInfo(), err
+	}
+	selectorMu.Lock()
+	currentSelector = info
+	selectorMu.Unlock()
+	logrus.Infof("选择器配置已重新加载: version=%s source=%s overrides=%d", info.Version, info.Source, len(info.Overrides))
+	return info, nil
+}
+
+// GetSelectorInfo 获取当前生效的配置（副本）
+func GetSelectorInfo() SelectorInfo {
+	selectorMu.RLock()
+	defer selectorMu.RUnlock()
+	info := currentSelector
+	info.Selectors = make(map[string][]string, len(currentSelector.Selectors))
+	for k, v := range currentSelector.Selectors {
+		info.Selectors[k] = append([]string(nil), v...)
+	}
+	return info
+}
+
+// Selectors 按 key 获取有序候选选择器；key 不存在属于编码错误，直接 panic
+func Selectors(key string) []string {
+	selectorMu.RLock()
+	list, ok := currentSelector.Selectors[key]
+	selectorMu.RUnlock()
+	if !ok {
+		panic("未定义的选择器: " + key)
+	}
+	return list
+}
+
+// sel 返回逗号连接的 CSS 选择器列表，用于 Has/Elements 等单选择器接口
+func sel(key string) string {
+	return strings.Join(Selectors(key), ", ")
+}
@@ -0,0 +1,69 @@
+package xiaohongshu
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 代码中引用的选择器 key 必须在内置配置中定义，否则运行时会 panic
+func TestSelectorKeysDefined(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	info := GetSelectorInfo()
+	keyRe := regexp.MustCompile(`(?:Selectors|sel|performClick)\((?:page, )?"([a-z_]+\.[a-z_]+)"\)`)
+	found := 0
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		require.NoError(t, err)
+		for _, m := range keyRe.FindAllStringSubmatch(string(data), -1) {
+			found++
+			_, ok := info.Selectors[m[1]]
+			require.Truef(t, ok, "%s 引用了未定义的选择器 %s", f, m[1])
+		}
+	}
+	require.NotZero(t, found)
+}
+
+// 页面脚本中的选择器同样应来自配置（作为参数传入），否则覆盖文件对脚本不生效
+func TestNoHardcodedScriptSelectors(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	literalRe := regexp.MustCompile(`querySelector(?:All)?\(['"]`)
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(f)
+		require.NoError(t, err)
+		require.Falsef(t, literalRe.Match(data), "%s 的页面脚本中写死了选择器", f)
+	}
+}
+
+func TestLoadSelectorsOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"version": "hotfix-1",
+		"selectors": {
+			"publish.title_input": ["input.new-title", "div.d-input input"],
+			"unknown.key": ["div"]
+		}
+	}`), 0o644))
+
+	info, err := loadSelectors(path)
+	require.NoError(t, err)
+	require.Equal(t, "hotfix-1", info.Version)
+	require.Equal(t, []string{"publish.title_input"}, info.Overrides)
+	require.Equal(t, []string{"input.new-title", "div.d-input input"}, info.Selectors["publish.title_input"])
+	require.NotEmpty(t, info.Selectors["login.qrcode_img"], "未覆盖的 key 沿用内置值")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"selectors": {"publish.title_input": [" "]}}`), 0o644))
+	_, err = loadSelectors(path)
+	require.Error(t, err)
+}
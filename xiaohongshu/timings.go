@@ -0,0 +1,114 @@
+package xiaohongshu
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Timings 页面操作的超时与等待配置
+type Timings struct {
+	Navigate time.Duration // 单次导航超时
+	Element  time.Duration // 短等待元素出现的超时（可选元素探测）
+	Settle   float64       // 动作后固定等待时长的倍率，1 为默认值
+	Lookup   time.Duration // 必需元素单次查找的超时（每个候选选择器）
+	Attempts int           // 必需元素查找/点击的重试次数
+	Backoff  time.Duration // 重试的初始退避时长，之后按指数增长
+}
+
+// DefaultTimings 默认配置，与历史硬编码值一致
+func DefaultTimings() Timings {
+	return Timings{
+		Navigate: 60 * time.Second,
+		Element:  2 * time.Second,
+		Settle:   1,
+		Lookup:   10 * time.Second,
+		Attempts: 3,
+		Backoff:  500 * time.Millisecond,
+	}
+}
+
+// normalize 非法值回退为默认值
+func (t Timings) normalize() Timings {
+	def := DefaultTimings()
+	if t.Navigate <= 0 {
+		t.Navigate = def.Navigate
+	}
+	if t.Element <= 0 {
+		t.Element = def.Element
+	}
+	if t.Settle <= 0 {
+		t.Settle = def.Settle
+	}
+	if t.Lookup <= 0 {
+		t.Lookup = def.Lookup
+	}
+	if t.Attempts <= 0 {
+		t.Attempts = def.Attempts
+	}
+	if t.Backoff <= 0 {
+		t.Backoff = def.Backoff
+	}
+	return t
+}
+
+var (
+	timingsMu      sync.RWMutex
+	defaultTimings = DefaultTimings()
+)
+
+// SetTimings 设置全局默认配置
+func SetTimings(t Timings) {
+	timingsMu.Lock()
+	defaultTimings = t.normalize()
+	timingsMu.Unlock()
+}
+
+// GetTimings 获取全局默认配置
+func GetTimings() Timings {
+	timingsMu.RLock()
+	defer timingsMu.RUnlock()
+	return defaultTimings
+}
+
+type timingsKey struct{}
+
+// WithTimings 为单次调用覆盖超时配置（如按 MCP 工具单独设置）
+func WithTimings(ctx context.Context, t Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t.normalize())
+}
+
+// TimingsFrom 从 context 读取配置，没有则使用全局默认值
+func TimingsFrom(ctx context.Context) Timings {
+	if ctx != nil {
+		if t, ok := ctx.Value(timingsKey{}).(Timings); ok {
+			return t
+		}
+	}
+	return GetTimings()
+}
+
+// pageTimings 读取页面 context 上的配置
+func pageTimings(page *rod.Page) Timings {
+	return TimingsFrom(page.GetContext())
+}
+
+// elementWait 可选元素探测的超时
+func elementWait(page *rod.Page) time.Duration {
+	return pageTimings(page).Element
+}
+
+// settle 动作后的固定等待，按配置倍率缩放，context 取消时提前返回
+func settle(page *rod.Page, d time.Duration) {
+	ctx := page.GetContext()
+	d = time.Duration(float64(d) * pageTimings(page).Settle)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}